@@ -29,6 +29,10 @@ func init() {
 
 		Since string `help:"since time"`
 		Until string `hlep:"until time"`
+
+		Actor    []string `help:"normalized actor"`
+		Verb     []string `help:"normalized verb" choices:"create|delete|update|start|stop|restart|attach|detach|read|other"`
+		Resource []string `help:"normalized resource type"`
 	}
 	R(&CloudeventListOptions{}, "cloud-event-list", "List cloud events", func(s *mcclient.ClientSession, opts *CloudeventListOptions) error {
 		params, err := options.ListStructToParams(opts)
@@ -66,4 +70,59 @@ func init() {
 		printObject(resp)
 		return nil
 	})
+
+	type CloudeventRetentionPolicyListOptions struct {
+		options.BaseListOptions
+	}
+	R(&CloudeventRetentionPolicyListOptions{}, "cloud-event-retention-policy-list", "List cloud event retention policies", func(s *mcclient.ClientSession, opts *CloudeventRetentionPolicyListOptions) error {
+		params, err := options.ListStructToParams(opts)
+		if err != nil {
+			return err
+		}
+		result, err := cloudevent.CloudeventRetentionPolicies.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, cloudevent.CloudeventRetentionPolicies.GetColumns(s))
+		return nil
+	})
+
+	type CloudeventRetentionPolicyCreateOptions struct {
+		NAME     string `help:"Name of retention policy"`
+		KEEPDAYS int    `help:"Days to keep cloud events of the domain" json:"keep_days"`
+		Domain   string `help:"Domain of the retention policy" json:"project_domain_id"`
+	}
+	R(&CloudeventRetentionPolicyCreateOptions{}, "cloud-event-retention-policy-create", "Create cloud event retention policy for domain", func(s *mcclient.ClientSession, opts *CloudeventRetentionPolicyCreateOptions) error {
+		resp, err := cloudevent.CloudeventRetentionPolicies.Create(s, jsonutils.Marshal(opts))
+		if err != nil {
+			return err
+		}
+		printObject(resp)
+		return nil
+	})
+
+	type CloudeventRetentionPolicyUpdateOptions struct {
+		ID       string `help:"ID or name of retention policy" json:"-"`
+		KeepDays int    `help:"Days to keep cloud events of the domain" json:"keep_days,omitzero"`
+	}
+	R(&CloudeventRetentionPolicyUpdateOptions{}, "cloud-event-retention-policy-update", "Update cloud event retention policy", func(s *mcclient.ClientSession, opts *CloudeventRetentionPolicyUpdateOptions) error {
+		resp, err := cloudevent.CloudeventRetentionPolicies.Update(s, opts.ID, jsonutils.Marshal(opts))
+		if err != nil {
+			return err
+		}
+		printObject(resp)
+		return nil
+	})
+
+	type CloudeventRetentionPolicyIdOptions struct {
+		ID string `help:"ID or name of retention policy"`
+	}
+	R(&CloudeventRetentionPolicyIdOptions{}, "cloud-event-retention-policy-delete", "Delete cloud event retention policy", func(s *mcclient.ClientSession, opts *CloudeventRetentionPolicyIdOptions) error {
+		resp, err := cloudevent.CloudeventRetentionPolicies.Delete(s, opts.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(resp)
+		return nil
+	})
 }
@@ -29,8 +29,10 @@ func init() {
 	cmd.Create(&cloudid.ClouduserCreateOptions{})
 	cmd.Show(&cloudid.ClouduserIdOption{})
 	cmd.Custom(shell.CustomActionGet, "login-info", &cloudid.ClouduserIdOption{})
+	cmd.Get("login-url", &cloudid.ClouduserIdOption{})
 	cmd.Delete(&cloudid.ClouduserIdOption{})
 	cmd.Perform("sync", &cloudid.ClouduserSyncOptions{})
+	cmd.PerformClass("sync-owner", &cloudid.ClouduserSyncOwnerOptions{})
 	cmd.Perform("syncstatus", &cloudid.ClouduserIdOption{})
 	cmd.Perform("attach-policy", &cloudid.ClouduserPolicyOptions{})
 	cmd.Perform("detach-policy", &cloudid.ClouduserPolicyOptions{})
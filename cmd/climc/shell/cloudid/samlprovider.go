@@ -26,4 +26,6 @@ func init() {
 	cmd.Create(&cloudid.SAMLProviderCreateOptions{})
 	cmd.Show(&cloudid.SAMLProviderIdOptions{})
 	cmd.Delete(&cloudid.SAMLProviderIdOptions{})
+	cmd.PerformClass("setup", &cloudid.SAMLProviderSetupOptions{})
+	cmd.Get("verify", &cloudid.SAMLProviderIdOptions{})
 }
@@ -0,0 +1,44 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type AnsibleInventoryOptions struct {
+		options.BaseListOptions
+
+		Host string `help:"show variables of the host only" json:"inventory_host"`
+		List bool   `help:"list all hosts, compatible with ansible inventory script" json:"-"`
+	}
+	R(&AnsibleInventoryOptions{}, "ansible-inventory", "Show ansible dynamic inventory of servers", func(s *mcclient.ClientSession, args *AnsibleInventoryOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.AnsibleInventory.Get(s, params)
+		if err != nil {
+			return err
+		}
+		fmt.Println(result.PrettyString())
+		return nil
+	})
+}
@@ -223,6 +223,136 @@ func init() {
 		return nil
 	})
 
+	type BucketMultipartUploadObjectOptions struct {
+		ID   string `help:"ID or name of bucket" json:"-"`
+		KEY  string `help:"Key of object to upload"`
+		Path string `help:"Path to file to upload" required:"true"`
+
+		PartSizeMb   int64  `help:"Size of each part in MB" default:"64"`
+		StorageClass string `help:"storage CLass"`
+		Acl          string `help:"object acl." choices:"private|public-read|public-read-write"`
+	}
+	R(&BucketMultipartUploadObjectOptions{}, "bucket-object-multipart-upload", "Upload a large object into a bucket part by part", func(s *mcclient.ClientSession, args *BucketMultipartUploadObjectOptions) error {
+		file, err := os.Open(args.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		fileInfo, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		totalSize := fileInfo.Size()
+		partSize := args.PartSizeMb * 1024 * 1024
+		if partSize <= 0 {
+			return fmt.Errorf("invalid part size %d", args.PartSizeMb)
+		}
+
+		input := api.BucketInitMultipartUploadInput{
+			Key:          args.KEY,
+			Acl:          args.Acl,
+			StorageClass: args.StorageClass,
+		}
+		result, err := modules.Buckets.PerformAction(s, args.ID, "init-multipart-upload", jsonutils.Marshal(input))
+		if err != nil {
+			return err
+		}
+		upload := api.BucketMultipartUploadOutput{}
+		err = result.Unmarshal(&upload)
+		if err != nil {
+			return err
+		}
+
+		abort := func() {
+			input := api.BucketAbortMultipartUploadInput{Key: args.KEY, UploadId: upload.UploadId}
+			modules.Buckets.PerformAction(s, args.ID, "abort-multipart-upload", jsonutils.Marshal(input))
+		}
+		etags := []string{}
+		for offset, index := int64(0), 1; offset < totalSize; offset, index = offset+partSize, index+1 {
+			size := partSize
+			if offset+size > totalSize {
+				size = totalSize - offset
+			}
+			etag, err := modules.Buckets.UploadPart(s, args.ID, args.KEY, upload.UploadId, index, io.NewSectionReader(file, offset, size), size, offset, totalSize)
+			if err != nil {
+				abort()
+				return fmt.Errorf("upload part %d: %v", index, err)
+			}
+			fmt.Printf("part %d uploaded %d/%d\n", index, offset+size, totalSize)
+			etags = append(etags, etag)
+		}
+
+		complete := api.BucketCompleteMultipartUploadInput{
+			Key:       args.KEY,
+			UploadId:  upload.UploadId,
+			PartEtags: etags,
+		}
+		_, err = modules.Buckets.PerformAction(s, args.ID, "complete-multipart-upload", jsonutils.Marshal(complete))
+		if err != nil {
+			abort()
+			return err
+		}
+		return nil
+	})
+
+	type BucketMultipartUploadListOptions struct {
+		ID     string `help:"ID or name of bucket" json:"-"`
+		Prefix string `help:"Prefix of object key"`
+	}
+	R(&BucketMultipartUploadListOptions{}, "bucket-object-multipart-list", "List incomplete multipart uploads in a bucket", func(s *mcclient.ClientSession, args *BucketMultipartUploadListOptions) error {
+		params, err := options.StructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.Buckets.GetSpecific(s, args.ID, "multipart-uploads", params)
+		if err != nil {
+			return err
+		}
+		listResult := printutils.ListResult{}
+		err = result.Unmarshal(&listResult)
+		if err != nil {
+			return err
+		}
+		printList(&listResult, []string{})
+		return nil
+	})
+
+	type BucketMultipartUploadAbortOptions struct {
+		ID       string `help:"ID or name of bucket" json:"-"`
+		KEY      string `help:"Key of object" json:"key"`
+		UPLOADID string `help:"Id of multipart upload" json:"upload_id"`
+	}
+	R(&BucketMultipartUploadAbortOptions{}, "bucket-object-multipart-abort", "Abort an incomplete multipart upload", func(s *mcclient.ClientSession, args *BucketMultipartUploadAbortOptions) error {
+		input := api.BucketAbortMultipartUploadInput{Key: args.KEY, UploadId: args.UPLOADID}
+		_, err := modules.Buckets.PerformAction(s, args.ID, "abort-multipart-upload", jsonutils.Marshal(input))
+		return err
+	})
+
+	type BucketDownloadObjectOptions struct {
+		ID     string `help:"ID or name of bucket" json:"-"`
+		KEY    string `help:"Key of object to download"`
+		Output string `help:"Path of file to save the object, default to stdout" short-token:"o"`
+		Range  string `help:"Byte range to download, e.g. bytes=0-1023"`
+	}
+	R(&BucketDownloadObjectOptions{}, "bucket-object-download", "Download an object from a bucket", func(s *mcclient.ClientSession, args *BucketDownloadObjectOptions) error {
+		body, _, err := modules.Buckets.Download(s, args.ID, args.KEY, args.Range)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		var output io.Writer = os.Stdout
+		if len(args.Output) > 0 {
+			file, err := os.Create(args.Output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			output = file
+		}
+		_, err = io.Copy(output, body)
+		return err
+	})
+
 	type BucketPresignObjectsOptions struct {
 		ID            string `help:"ID or name of bucket" json:"-"`
 		KEY           string `help:"Key of object to upload"`
@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+
+	"yunion.io/x/onecloud/cmd/climc/shell"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+	"yunion.io/x/onecloud/pkg/mcclient/options/compute"
+)
+
+func init() {
+	cmd := shell.NewResourceCmd(&modules.CloudBillItems)
+	cmd.List(&compute.CloudBillItemListOptions{})
+	cmd.Show(&options.BaseIdOptions{})
+	cmd.GetProperty(&compute.CloudBillCostOptions{})
+
+	R(&compute.ChargebackStatementOptions{}, "cloud-bill-item-statement", "Generate monthly chargeback statement of projects", func(s *mcclient.ClientSession, opts *compute.ChargebackStatementOptions) error {
+		params, err := opts.Params()
+		if err != nil {
+			return err
+		}
+		result, err := modules.CloudBillItems.Get(s, "statement", params)
+		if err != nil {
+			return err
+		}
+		content, _ := result.GetString("content")
+		if len(opts.Output) == 0 || len(content) == 0 {
+			printObject(result)
+			return nil
+		}
+		data := []byte(content)
+		if opts.Format == "xlsx" {
+			data, err = base64.StdEncoding.DecodeString(content)
+			if err != nil {
+				return err
+			}
+		}
+		return ioutil.WriteFile(opts.Output, data, 0644)
+	})
+
+	pricingCmd := shell.NewResourceCmd(&modules.ChargebackPricings)
+	pricingCmd.List(&compute.ChargebackPricingListOptions{})
+	pricingCmd.Create(&compute.ChargebackPricingCreateOptions{})
+	pricingCmd.Show(&options.BaseIdOptions{})
+	pricingCmd.Update(&compute.ChargebackPricingUpdateOptions{})
+	pricingCmd.Delete(&options.BaseIdOptions{})
+	pricingCmd.Perform("enable", &options.BaseIdOptions{})
+	pricingCmd.Perform("disable", &options.BaseIdOptions{})
+}
@@ -24,10 +24,12 @@ func init() {
 
 	cmd := shell.NewResourceCmd(&modules.Cloudaccounts).WithKeyword("cloud-account")
 	cmd.List(&options.CloudaccountListOptions{})
+	cmd.GetProperty(&options.CloudaccountHealthSummaryOptions{})
 	cmd.Show(&options.SCloudAccountIdOptions{})
 	cmd.Delete(&options.SCloudAccountIdOptions{})
 	cmd.Update(&options.SCloudAccountUpdateBaseOptions{})
 	cmd.PerformClassWithKeyword("preparenets-vmware", "prepare-nets", &options.SVMwareCloudAccountPrepareNetsOptions{})
+	cmd.PerformClass("migrate-secrets", &options.CloudaccountMigrateSecretsOptions{})
 
 	cmd.CreateWithKeyword("create-vmware", &options.SVMwareCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-aliyun", &options.SAliyunCloudAccountCreateOptions{})
@@ -125,6 +127,7 @@ func init() {
 	cmd.Perform("private", &options.SCloudAccountIdOptions{})
 	cmd.Perform("share-mode", &options.CloudaccountShareModeOptions{})
 	cmd.Perform("sync-skus", &options.CloudaccountSyncSkusOptions{})
+	cmd.Perform("audit-permissions", &options.SCloudAccountIdOptions{})
 	cmd.Perform("change-owner", &options.ClouaccountChangeOwnerOptions{})
 	cmd.Perform("change-project", &options.ClouaccountChangeProjectOptions{})
 	cmd.Perform("create-subscription", &options.SubscriptionCreateOptions{})
@@ -31,13 +31,17 @@ func init() {
 	cmd.List(&compute.CloudproviderListOptions{})
 	cmd.Update(&compute.CloudproviderUpdateOptions{})
 	cmd.Show(&options.BaseIdOptions{})
-	cmd.Delete(&options.BaseIdOptions{})
+	cmd.Delete(&compute.CloudproviderDeleteOptions{})
 	cmd.Perform("change-project", &compute.CloudproviderChangeProjectOptions{})
 	cmd.Perform("enable", &options.BaseIdOptions{})
 	cmd.Perform("disable", &options.BaseIdOptions{})
 	cmd.Perform("sync", &compute.CloudproviderSyncOptions{})
 	cmd.Perform("project-mapping", &compute.ClouproviderProjectMappingOptions{})
 	cmd.Perform("set-syncing", &compute.ClouproviderSetSyncingOptions{})
+	cmd.Perform("issue-credential", &compute.CloudproviderIssueCredentialOptions{})
+	cmd.Perform("import-bills", &compute.CloudproviderImportBillsOptions{})
+	cmd.Perform("purge-preview", &options.BaseIdOptions{})
+	cmd.Perform("restore-archive", &options.BaseIdOptions{})
 
 	cmd.GetWithCustomShow("clirc", func(result jsonutils.JSONObject) {
 		rc := make(map[string]string)
@@ -53,4 +57,5 @@ func init() {
 	cmd.Get("storage-classes", &compute.CloudproviderStorageClassesOptions{})
 	cmd.Get("change-owner-candidate-domains", &options.BaseIdOptions{})
 	cmd.Get("balance", &options.BaseIdOptions{})
+	cmd.Get("api-stats", &options.BaseIdOptions{})
 }
@@ -30,6 +30,8 @@ func init() {
 	cmd := shell.NewResourceCmd(&modules.Cloudregions).WithKeyword("cloud-region")
 	cmd.PerformClass("sync-skus", &options.CloudregionSkuSyncOptions{})
 	cmd.Perform("sync-images", &options.CloudregionIdOptions{})
+	cmd.Perform("sync-region-skus", &options.CloudregionSyncRegionSkusOptions{})
+	cmd.GetProperty(&options.CloudregionSkuFreshnessOptions{})
 
 	R(&options.SkuTaskQueryOptions{}, "cloud-region-sync-task-show", "Show details of skus sync tasks", func(s *mcclient.ClientSession, args *options.SkuTaskQueryOptions) error {
 		params, err := args.Params()
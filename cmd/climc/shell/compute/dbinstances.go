@@ -33,6 +33,8 @@ func init() {
 	cmd.Delete(&compute.DBInstanceDeleteOptions{})
 	cmd.Perform("renew", &compute.DBInstanceRenewOptions{})
 	cmd.Perform("change-config", &compute.DBInstanceChangeConfigOptions{})
+	cmd.Perform("update-parameters", &compute.DBInstanceUpdateParametersOptions{})
+	cmd.Get("parameter-drift", &compute.DBInstanceIdOptions{})
 	cmd.Perform("public-connection", &compute.DBInstancePublicConnectionOptions{})
 	cmd.Perform("recovery", &compute.DBInstanceRecoveryOptions{})
 	cmd.Perform("reboot", &compute.DBInstanceIdOptions{})
@@ -60,6 +60,8 @@ func init() {
 		BillingType string `help:"billing type" choices:"postpaid|prepaid"`
 
 		SnapshotpolicyId string `help:"snapshotpolicy id"`
+
+		ExternalProjectId []string `help:"external project or resource group id"`
 	}
 	R(&DiskListOptions{}, "disk-list", "List virtual disks", func(s *mcclient.ClientSession, opts *DiskListOptions) error {
 		params, err := options.ListStructToParams(opts)
@@ -238,12 +240,20 @@ func init() {
 	})
 
 	type DiskResizeOptions struct {
-		DISK string `help:"ID or name of disk"`
-		SIZE string `help:"Size of disk"`
+		DISK         string `help:"ID or name of disk"`
+		SIZE         string `help:"Size of disk"`
+		GrowFs       bool   `help:"Grow partition and filesystem in guest via qga after live resize"`
+		AllowOffline bool   `help:"Stop server to resize disk if live resize is not supported"`
 	}
 	R(&DiskResizeOptions{}, "disk-resize", "Resize a disk", func(s *mcclient.ClientSession, args *DiskResizeOptions) error {
 		params := jsonutils.NewDict()
 		params.Add(jsonutils.NewString(args.SIZE), "size")
+		if args.GrowFs {
+			params.Add(jsonutils.JSONTrue, "grow_fs")
+		}
+		if args.AllowOffline {
+			params.Add(jsonutils.JSONTrue, "allow_offline")
+		}
 		disk, err := modules.Disks.PerformAction(s, args.DISK, "resize", params)
 		if err != nil {
 			return err
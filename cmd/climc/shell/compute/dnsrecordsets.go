@@ -30,4 +30,5 @@ func init() {
 	cmd.Perform("enable", &options.DnsRecordSetIdOptions{})
 	cmd.Perform("disable", &options.DnsRecordSetIdOptions{})
 	cmd.Perform("set-traffic-policies", &options.DnsRecordSetTrafficPolicyOptions{})
+	cmd.Perform("set-failover", &options.DnsRecordSetFailoverOptions{})
 }
@@ -27,6 +27,10 @@ func init() {
 	cmd.Delete(&opts.BaseIdOptions{})
 	cmd.Show(&options.GlobalVpcIdOption{})
 	cmd.Create(&options.GlobalVpcCreateOptions{})
+	cmd.Update(&options.GlobalVpcUpdateOptions{})
 	cmd.Perform("syncstatus", &options.GlobalVpcIdOption{})
+	cmd.Perform("addvpc", &options.GlobalVpcVpcOptions{})
+	cmd.Perform("removevpc", &options.GlobalVpcVpcOptions{})
+	cmd.Get("address-plan", &options.GlobalVpcAddressPlanOptions{})
 	cmd.Get("change-owner-candidate-domains", &options.GlobalVpcIdOption{})
 }
@@ -39,11 +39,14 @@ func init() {
 	cmd.Show(&options.BaseShowOptions{})
 	cmd.GetMetadata(&options.BaseIdOptions{})
 	cmd.GetProperty(&compute.HostStatusStatisticsOptions{})
+	cmd.GetProperty(&compute.HostEolReportOptions{})
 
 	cmd.Perform("ping", &options.BaseIdOptions{})
 	cmd.Perform("undo-convert", &options.BaseIdOptions{})
 	cmd.Perform("maintenance", &options.BaseIdOptions{})
 	cmd.Perform("unmaintenance", &options.BaseIdOptions{})
+	cmd.Perform("enter-maintenance", &compute.HostEnterMaintenanceOptions{})
+	cmd.Perform("host-exit-maintenance", &options.BaseIdOptions{})
 	cmd.Perform("start", &options.BaseIdOptions{})
 	cmd.Perform("stop", &options.BaseIdOptions{})
 	cmd.Perform("reset", &options.BaseIdOptions{})
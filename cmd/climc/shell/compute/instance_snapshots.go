@@ -15,8 +15,13 @@
 package compute
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"yunion.io/x/jsonutils"
 
+	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
 	"yunion.io/x/onecloud/pkg/mcclient/options"
@@ -70,4 +75,46 @@ func init() {
 		return nil
 	})
 
+	type InstanceSnapshotCreateServerOptions struct {
+		ID               string   `help:"ID or Name of instance snapshot" json:"-"`
+		NAME             string   `help:"Name of new server" json:"name"`
+		PreferManager    string   `help:"Target cloudprovider, restore across provider if differ from source" json:"prefer_manager_id"`
+		PreferRegion     string   `help:"Target region" json:"prefer_region_id"`
+		PreferZone       string   `help:"Target zone, restore across zone if differ from source" json:"prefer_zone_id"`
+		Hypervisor       string   `help:"Target hypervisor" json:"hypervisor"`
+		VcpuCount        int      `help:"Cpu count of new server" json:"vcpu_count"`
+		VmemSize         int      `help:"Memory size(MB) of new server" json:"vmem_size"`
+		InstanceType     string   `help:"Instance type of new server" json:"instance_type"`
+		NicMapping       []string `help:"Nic mapping, format: <index>:<network>[:<address>]" json:"-"`
+		DropUnmappedNics bool     `help:"Drop nics without mapping" json:"drop_unmapped_nics"`
+		AutoStart        bool     `help:"Auto start new server" json:"auto_start"`
+	}
+	R(&InstanceSnapshotCreateServerOptions{}, "instance-snapshot-create-server", "Create server from instance snapshot, optionally in another zone or provider", func(s *mcclient.ClientSession, args *InstanceSnapshotCreateServerOptions) error {
+		input := api.InstanceSnapshotCreateServerInput{}
+		err := jsonutils.Marshal(args).Unmarshal(&input)
+		if err != nil {
+			return err
+		}
+		for _, mapping := range args.NicMapping {
+			parts := strings.Split(mapping, ":")
+			if len(parts) < 2 || len(parts) > 3 {
+				return fmt.Errorf("invalid nic mapping %s", mapping)
+			}
+			index, err := strconv.Atoi(parts[0])
+			if err != nil {
+				return fmt.Errorf("invalid nic index %s", parts[0])
+			}
+			nic := api.InstanceSnapshotNicMapping{Index: index, Network: parts[1]}
+			if len(parts) == 3 {
+				nic.Address = parts[2]
+			}
+			input.NicMappings = append(input.NicMappings, nic)
+		}
+		result, err := modules.InstanceSnapshots.PerformAction(s, args.ID, "create-server", jsonutils.Marshal(input))
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
 }
@@ -29,4 +29,5 @@ func init() {
 	cmd.Perform("syncstatus", &options.InterVpcNetworkIdOPtions{})
 	cmd.Perform("addvpc", &options.InterVpcNetworkAddVpcOPtions{})
 	cmd.Perform("removevpc", &options.InterVpcNetworkRemoveVpcOPtions{})
+	cmd.Get("cidr-conflicts", &options.InterVpcNetworkIdOPtions{})
 }
@@ -67,6 +67,18 @@ func init() {
 		printObject(lbcert)
 		return nil
 	})
+	R(&options.LoadbalancerCertificateRenewOptions{}, "lbcert-renew", "Renew lbcert and replace it on all clouds where it is deployed", func(s *mcclient.ClientSession, opts *options.LoadbalancerCertificateRenewOptions) error {
+		params, err := opts.Params()
+		if err != nil {
+			return err
+		}
+		lbcert, err := modules.LoadbalancerCertificates.PerformAction(s, opts.ID, "renew", params)
+		if err != nil {
+			return err
+		}
+		printObject(lbcert)
+		return nil
+	})
 	R(&options.LoadbalancerCertificateDeleteOptions{}, "lbcert-delete", "Delete lbcert", func(s *mcclient.ClientSession, opts *options.LoadbalancerCertificateDeleteOptions) error {
 		lbcert, err := modules.LoadbalancerCertificates.Delete(s, opts.ID, nil)
 		if err != nil {
@@ -31,4 +31,5 @@ func init() {
 	cmd.Perform("syncstauts", &compute.NatGatewayIdOptions{})
 	cmd.Perform("cancel-expire", &compute.NatGatewayIdOptions{})
 	cmd.Perform("postpaid-expire", &compute.NatPostpaidExpireOptions{})
+	cmd.Perform("apply-rules", &compute.NatGatewayApplyRulesOptions{})
 }
@@ -26,4 +26,9 @@ func init() {
 	cmd.List(&options.NetworkAddressListOptions{})
 	cmd.Show(&options.NetworkAddressIdOptions{})
 	cmd.Delete(&options.NetworkAddressIdOptions{})
+
+	conflictCmd := shell.NewResourceCmd(&modules.NetworkIpConflicts)
+	conflictCmd.List(&options.NetworkIpConflictListOptions{})
+	conflictCmd.Show(&options.BaseIdOptions{})
+	conflictCmd.Delete(&options.BaseIdOptions{})
 }
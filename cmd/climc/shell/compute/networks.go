@@ -31,6 +31,7 @@ func init() {
 
 	cmd := shell.NewResourceCmd(&modules.Networks).WithContextManager(&modules.Wires)
 	cmd.List(&options.NetworkListOptions{})
+	cmd.GetProperty(&options.NetworkIpUtilizationOptions{})
 	cmd.Update(&options.NetworkUpdateOptions{})
 	cmd.Show(&options.NetworkIdOptions{})
 	cmd.Delete(&options.NetworkIdOptions{})
@@ -27,12 +27,16 @@ func init() {
 		NETWORK  string   `help:"IP or name of network"`
 		NOTES    string   `help:"Why reserve this IP"`
 		IPS      []string `help:"IPs to reserve"`
+		IpRange  []string `help:"IP ranges to reserve, in form of start-end or cidr, addresses in use are skipped"`
 		Duration string   `help:"reservation duration, e.g. 1I, 1H, 2M"`
 		Status   string   `help:"ip status"`
 	}
 	R(&NetworkReserveIPOptions{}, "network-reserve-ip", "Reserve an IP address from pool", func(s *mcclient.ClientSession, args *NetworkReserveIPOptions) error {
 		params := jsonutils.NewDict()
 		params.Add(jsonutils.NewStringArray(args.IPS), "ips")
+		if len(args.IpRange) > 0 {
+			params.Add(jsonutils.NewStringArray(args.IpRange), "ip_ranges")
+		}
 		params.Add(jsonutils.NewString(args.NOTES), "notes")
 		if len(args.Duration) > 0 {
 			params.Add(jsonutils.NewString(args.Duration), "duration")
@@ -15,6 +15,10 @@
 package compute
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"yunion.io/x/jsonutils"
 
 	"yunion.io/x/onecloud/pkg/mcclient"
@@ -66,9 +70,21 @@ func init() {
 		MaxInstanceNumber    string
 		DesireInstanceNumber string
 		Loadbalance          string
+		Target               []string `help:"placement target, format: hypervisor:cloudregion:network:guest_template[:weight[:priority]]" json:"-"`
 	}
 	R(&ScalingGroupCreateOptions{}, "scaling-group-create", "Create scaling group", func(s *mcclient.ClientSession, args *ScalingGroupCreateOptions) error {
 		params := jsonutils.Marshal(args).(*jsonutils.JSONDict)
+		if len(args.Target) > 0 {
+			targets := jsonutils.NewArray()
+			for _, t := range args.Target {
+				target, err := parseScalingGroupTarget(t)
+				if err != nil {
+					return err
+				}
+				targets.Add(target)
+			}
+			params.Set("targets", targets)
+		}
 		scalingGroup, err := modules.ScalingGroup.Create(s, params)
 		if err != nil {
 			return err
@@ -76,6 +92,38 @@ func init() {
 		printObject(scalingGroup)
 		return nil
 	})
+	type ScalingGroupAddTargetOptions struct {
+		ID     string `help:"ScalingGroup ID or Name"`
+		TARGET string `help:"placement target, format: hypervisor:cloudregion:network:guest_template[:weight[:priority]]"`
+	}
+	R(&ScalingGroupAddTargetOptions{}, "scaling-group-add-target", "Add placement target to ScalingGroup", func(s *mcclient.ClientSession,
+		args *ScalingGroupAddTargetOptions) error {
+		params, err := parseScalingGroupTarget(args.TARGET)
+		if err != nil {
+			return err
+		}
+		ret, err := modules.ScalingGroup.PerformAction(s, args.ID, "add-target", params)
+		if err != nil {
+			return err
+		}
+		printObject(ret)
+		return nil
+	})
+	type ScalingGroupRemoveTargetOptions struct {
+		ID     string `help:"ScalingGroup ID or Name"`
+		TARGET string `help:"placement target ID"`
+	}
+	R(&ScalingGroupRemoveTargetOptions{}, "scaling-group-remove-target", "Remove placement target from ScalingGroup", func(s *mcclient.ClientSession,
+		args *ScalingGroupRemoveTargetOptions) error {
+		params := jsonutils.NewDict()
+		params.Set("target_id", jsonutils.NewString(args.TARGET))
+		ret, err := modules.ScalingGroup.PerformAction(s, args.ID, "remove-target", params)
+		if err != nil {
+			return err
+		}
+		printObject(ret)
+		return nil
+	})
 	type ScalingGroupDeleteOptions struct {
 		ID string `help:"ScalingGroup ID or Name"`
 	}
@@ -143,3 +191,26 @@ func init() {
 		return nil
 	})
 }
+
+func parseScalingGroupTarget(str string) (*jsonutils.JSONDict, error) {
+	parts := strings.Split(str, ":")
+	if len(parts) < 4 || len(parts) > 6 {
+		return nil, fmt.Errorf("invalid target %q, want hypervisor:cloudregion:network:guest_template[:weight[:priority]]", str)
+	}
+	target := jsonutils.NewDict()
+	target.Set("hypervisor", jsonutils.NewString(parts[0]))
+	target.Set("cloudregion", jsonutils.NewString(parts[1]))
+	target.Set("network", jsonutils.NewString(parts[2]))
+	target.Set("guest_template", jsonutils.NewString(parts[3]))
+	for i, key := range []string{"weight", "priority"} {
+		if len(parts) <= 4+i {
+			break
+		}
+		val, err := strconv.Atoi(parts[4+i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %s %q", key, parts[4+i])
+		}
+		target.Set(key, jsonutils.NewInt(int64(val)))
+	}
+	return target, nil
+}
@@ -26,5 +26,7 @@ func init() {
 	cmd.Show(&compute.SecGroupCacheIdOptions{})
 	cmd.Delete(&compute.SecGroupCacheIdOptions{})
 	cmd.Perform("syncstatus", &compute.SecGroupCacheIdOptions{})
+	cmd.Perform("check-drift", &compute.SecGroupCacheCheckDriftOptions{})
 	cmd.Get("references", &compute.SecGroupCacheIdOptions{})
+	cmd.Get("unsupported-rules", &compute.SecGroupCacheIdOptions{})
 }
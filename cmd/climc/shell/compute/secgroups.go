@@ -36,5 +36,6 @@ func init() {
 	cmd.Perform("purge", &options.SecgroupIdOptions{})
 	cmd.Perform("change-owner", &options.SecgroupChangeOwnerOptions{})
 	cmd.Perform("import-rules", &options.SecgroupImportRulesOptions{})
+	cmd.Perform("set-drift-remediation", &options.SecgroupSetDriftRemediationOptions{})
 	cmd.Get("references", &options.SecgroupIdOptions{})
 }
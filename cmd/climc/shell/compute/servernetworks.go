@@ -150,6 +150,46 @@ func init() {
 		return nil
 	})
 
+	type ServerSetNicQosOptions struct {
+		SERVER  string `help:"ID or Name of server"`
+		MACORIP string `help:"IP, Mac, or Index of NIC"`
+		BW      int    `help:"Bandwidth in Mbps, 0 means no limit"`
+	}
+	R(&ServerSetNicQosOptions{}, "server-set-nic-qos", "Set server nic qos bandwidth in Mbps", func(s *mcclient.ClientSession, args *ServerSetNicQosOptions) error {
+		input := compute.ServerSetNicQosInput{BandwidthMb: args.BW}
+		if regutils.MatchMacAddr(args.MACORIP) {
+			input.Mac = args.MACORIP
+		} else if regutils.MatchIP4Addr(args.MACORIP) {
+			input.IpAddr = args.MACORIP
+		} else if regutils.MatchInteger(args.MACORIP) {
+			index, err := strconv.Atoi(args.MACORIP)
+			if err != nil {
+				return err
+			}
+			input.Index = index
+		} else {
+			return fmt.Errorf("Please specify Ip or Mac")
+		}
+		server, err := modules.Servers.PerformAction(s, args.SERVER, "set-nic-qos", jsonutils.Marshal(input))
+		if err != nil {
+			return err
+		}
+		printObject(server)
+		return nil
+	})
+
+	type ServerNicQosOptions struct {
+		SERVER string `help:"ID or Name of server"`
+	}
+	R(&ServerNicQosOptions{}, "server-nic-qos", "Show server nic qos mode and bandwidth", func(s *mcclient.ClientSession, args *ServerNicQosOptions) error {
+		result, err := modules.Servers.GetSpecific(s, args.SERVER, "nic-qos", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
 	type ServerAttachNetworkOptions struct {
 		SERVER  string   `help:"ID or Name of server"`
 		NETDESC []string `help:"Network description"`
@@ -48,6 +48,8 @@ func init() {
 	cmd.Show(new(options.ServerShowOptions))
 	cmd.BatchDeleteWithParam(new(options.ServerDeleteOptions))
 	cmd.BatchPerform("cancel-delete", new(options.ServerCancelDeleteOptions))
+	cmd.PerformClass("batch-delete", new(options.ServerBatchDeleteOptions))
+	cmd.PerformClass("batch-change-project", new(options.ServerBatchChangeProjectOptions))
 	cmd.BatchPut(new(options.ServerUpdateOptions))
 	cmd.GetMetadata(new(options.ServerIdOptions))
 	cmd.Perform("clone", new(options.ServerCloneOptions))
@@ -115,6 +117,8 @@ func init() {
 	cmd.Perform("qga-command", &options.ServerQgaCommand{})
 	cmd.Perform("set-password", &options.ServerSetPasswordOptions{})
 	cmd.Perform("set-boot-index", &options.ServerSetBootIndexOptions{})
+	cmd.BatchPerform("collect-software-inventory", new(options.ServerIdsOptions))
+	cmd.Perform("run-command", &options.ServerRunCommandOptions{})
 
 	cmd.Get("vnc", new(options.ServerVncOptions))
 	cmd.Get("desc", new(options.ServerIdOptions))
@@ -138,6 +142,7 @@ func init() {
 	cmd.GetProperty(&options.ServerGetPropertyProjectTagValueTreeOptions{})
 	cmd.GetProperty(&options.ServerGetPropertyDomainTagValuePairOptions{})
 	cmd.GetProperty(&options.ServerGetPropertyDomainTagValueTreeOptions{})
+	cmd.GetProperty(&options.ServerSlaReportOptions{})
 
 	type ServerTaskShowOptions struct {
 		ID       string `help:"ID or name of server" json:"-"`
@@ -273,6 +278,14 @@ func init() {
 			printList(modulebase.JSON2ListResult(result), listFields)
 			return nil
 		}
+		if baseoptions.BoolV(opts.ValidateOnly) {
+			result, err := modules.Servers.PerformClassAction(s, "validate-create", params.JSON(params))
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		}
 		taskNotify := baseoptions.BoolV(opts.TaskNotify)
 		if taskNotify {
 			s.PrepareTask()
@@ -375,6 +388,18 @@ func init() {
 		return nil
 	})
 
+	type ServerShowUserDataOptions struct {
+		ID string `help:"ID or name of server"`
+	}
+	R(&ServerShowUserDataOptions{}, "server-user-data", "Show server user_data and rendered user_data", func(s *mcclient.ClientSession, args *ServerShowUserDataOptions) error {
+		result, err := modules.Servers.GetSpecific(s, args.ID, "user-data", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
 	type ServerAddExtraOption struct {
 		ID    string `help:"ID or name of server"`
 		KEY   string `help:"Option key"`
@@ -646,6 +671,9 @@ func init() {
 		Server string `help:"ID or name of VM" json:"-" optional:"false" positional:"true"`
 		Disk   string `help:"ID or name of disk to resize" json:"disk" optional:"false" positional:"true"`
 		Size   string `help:"new size of disk in MB" json:"size" optional:"false" positional:"true"`
+
+		GrowFs       bool `help:"Grow partition and filesystem in guest via qga after live resize" json:"grow_fs"`
+		AllowOffline bool `help:"Stop server to resize disk if live resize is not supported" json:"allow_offline"`
 	}
 	R(&ServerResizeDiskOptions{}, "server-resize-disk", "Resize attached disk of a server", func(s *mcclient.ClientSession, args *ServerResizeDiskOptions) error {
 		params := jsonutils.Marshal(args)
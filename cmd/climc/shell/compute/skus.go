@@ -32,6 +32,7 @@ func init() {
 	cmd.Update(&options.ServerSkusUpdateOptions{})
 	cmd.ClassShow(&options.ServerSkusListOptions{})
 	cmd.PerformClass("sync-skus", &options.SkuSyncOptions{})
+	cmd.GetProperty(&options.ServerSkusEquivalentOptions{})
 
 	R(&options.SkuTaskQueryOptions{}, "server-sku-sync-task-show", "Show details of skus sync tasks", func(s *mcclient.ClientSession, args *options.SkuTaskQueryOptions) error {
 		params, err := args.Params()
@@ -20,11 +20,14 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient"
 	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
 	"yunion.io/x/onecloud/pkg/mcclient/options"
+	"yunion.io/x/onecloud/pkg/mcclient/options/compute"
 )
 
 func init() {
 	type SnapshotPolicyListOptions struct {
 		options.BaseListOptions
+
+		ResourceType []string `help:"filter by resource type" choices:"disk|server"`
 	}
 	R(&SnapshotPolicyListOptions{}, "snapshot-policy-list", "List snapshot policy", func(s *mcclient.ClientSession, args *SnapshotPolicyListOptions) error {
 		params, err := options.ListStructToParams(args)
@@ -57,6 +60,8 @@ func init() {
 		RetentionDays  int   `help:"snapshot retention days"`
 		RepeatWeekdays []int `help:"snapshot create days on week"`
 		TimePoints     []int `help:"snapshot create time points on one day"`
+
+		ResourceType string `help:"create disk snapshots or instance snapshots" choices:"disk|server"`
 	}
 
 	R(&SnapshotPolicyCreateOptions{}, "snapshot-policy-create", "Create snapshot policy", func(s *mcclient.ClientSession, args *SnapshotPolicyCreateOptions) error {
@@ -102,6 +107,48 @@ func init() {
 			return nil
 		})
 
+	R(&compute.SnapshotPolicyUpdateOptions{}, "snapshot-policy-update", "Update snapshot policy",
+		func(s *mcclient.ClientSession, opts *compute.SnapshotPolicyUpdateOptions) error {
+			params, err := opts.Params()
+			if err != nil {
+				return err
+			}
+			sp, err := modules.SnapshotPoliciy.Update(s, opts.ID, params)
+			if err != nil {
+				return err
+			}
+			printObject(sp)
+			return nil
+		})
+
+	R(&compute.SnapshotPolicyBindServersOptions{}, "snapshot-policy-bind-server", "bind server snapshotpolicy to servers",
+		func(s *mcclient.ClientSession, opts *compute.SnapshotPolicyBindServersOptions) error {
+			params, err := opts.Params()
+			if err != nil {
+				return err
+			}
+			sp, err := modules.SnapshotPoliciy.PerformAction(s, opts.ID, "bind-servers", params)
+			if err != nil {
+				return err
+			}
+			printObject(sp)
+			return nil
+		})
+
+	R(&compute.SnapshotPolicyBindServersOptions{}, "snapshot-policy-unbind-server", "unbind server snapshotpolicy from servers",
+		func(s *mcclient.ClientSession, opts *compute.SnapshotPolicyBindServersOptions) error {
+			params, err := opts.Params()
+			if err != nil {
+				return err
+			}
+			sp, err := modules.SnapshotPoliciy.PerformAction(s, opts.ID, "unbind-servers", params)
+			if err != nil {
+				return err
+			}
+			printObject(sp)
+			return nil
+		})
+
 	type SnapshotPolicyCacheOptions struct {
 		ID       string `help:"SnasphotPolicy ID"`
 		REGIONID string `help:"Region ID"`
@@ -121,4 +168,43 @@ func init() {
 			return nil
 		},
 	)
+
+	type SnapshotPolicyComplianceOptions struct {
+		ID               string `help:"ID or name of template snapshot policy"`
+		Project          string `help:"filter disks by project"`
+		ProjectDomain    string `help:"filter disks by domain"`
+		NonCompliantOnly bool   `help:"only show non-compliant disks"`
+	}
+	R(&SnapshotPolicyComplianceOptions{}, "snapshot-policy-compliance", "Report disks which lack a snapshot policy matching the template",
+		func(s *mcclient.ClientSession, opts *SnapshotPolicyComplianceOptions) error {
+			params, err := options.StructToParams(opts)
+			if err != nil {
+				return err
+			}
+			result, err := modules.SnapshotPoliciy.GetSpecific(s, opts.ID, "compliance", params)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		})
+
+	type SnapshotPolicyApplyToNonCompliantOptions struct {
+		ID            string `help:"ID or name of template snapshot policy"`
+		Project       string `help:"filter disks by project"`
+		ProjectDomain string `help:"filter disks by domain"`
+	}
+	R(&SnapshotPolicyApplyToNonCompliantOptions{}, "snapshot-policy-apply-to-non-compliant", "Bind snapshot policy to all non-compliant disks",
+		func(s *mcclient.ClientSession, opts *SnapshotPolicyApplyToNonCompliantOptions) error {
+			params, err := options.StructToParams(opts)
+			if err != nil {
+				return err
+			}
+			result, err := modules.SnapshotPoliciy.PerformAction(s, opts.ID, "apply-to-non-compliant", params)
+			if err != nil {
+				return err
+			}
+			printObject(result)
+			return nil
+		})
 }
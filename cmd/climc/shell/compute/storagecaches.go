@@ -103,4 +103,42 @@ func init() {
 		printObject(storage)
 		return nil
 	})
+
+	type StoragecacheSetGcPolicyOptions struct {
+		ID           string `help:"ID or name of storage cache"`
+		GcEnabled    string `help:"Enable scheduled garbage collection of cached images" choices:"true|false"`
+		GcKeepLastN  *int   `help:"Always keep the N most recently used images"`
+		GcUnusedDays *int   `help:"Collect images unused for more than these days, 0 to disable"`
+		GcMaxSizeMb  *int64 `help:"Max total size of cached images in MB, 0 means unlimited"`
+	}
+	R(&StoragecacheSetGcPolicyOptions{}, "storagecache-set-gc-policy", "Set garbage collection policy of storage cache", func(s *mcclient.ClientSession, args *StoragecacheSetGcPolicyOptions) error {
+		params, err := options.StructToParams(args)
+		if err != nil {
+			return err
+		}
+		params.Remove("id")
+		result, err := modules.Storagecaches.PerformAction(s, args.ID, "set-gc-policy", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type StoragecacheGcOptions struct {
+		ID     string `help:"ID or name of storage cache"`
+		DryRun bool   `help:"Only report images to be collected"`
+	}
+	R(&StoragecacheGcOptions{}, "storagecache-gc", "Garbage collect cached images by gc policy", func(s *mcclient.ClientSession, args *StoragecacheGcOptions) error {
+		params := jsonutils.NewDict()
+		if args.DryRun {
+			params.Add(jsonutils.JSONTrue, "dry_run")
+		}
+		result, err := modules.Storagecaches.PerformAction(s, args.ID, "gc", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
 }
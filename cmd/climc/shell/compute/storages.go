@@ -45,6 +45,8 @@ func init() {
 	cmd.Perform("force-detach-host", &compute.StorageForceDetachHost{})
 	cmd.Perform("public", &options.BasePublicOptions{})
 	cmd.Perform("private", &options.BaseIdOptions{})
+	cmd.Get("capacity-forecast", &compute.StorageCapacityForecastOptions{})
+	cmd.GetProperty(&compute.StorageCapacityForecastListOptions{})
 
 	type StorageCephRunOptions struct {
 		ID     string `help:"ID or name of ceph storage"`
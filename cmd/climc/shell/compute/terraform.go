@@ -0,0 +1,87 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+	"os"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
+)
+
+func init() {
+	type TerraformExportOptions struct {
+		PROJECT   string   `help:"ID or name of project"`
+		Format    string   `help:"export format" choices:"hcl|state" default:"hcl"`
+		Resources []string `help:"resources to export, default all" choices:"vpc|network|server|loadbalancer"`
+		Output    string   `help:"write exported content to file instead of stdout"`
+	}
+	R(&TerraformExportOptions{}, "terraform-export", "Export managed resources of a project as terraform import blocks or state", func(s *mcclient.ClientSession, args *TerraformExportOptions) error {
+		params := jsonutils.NewDict()
+		params.Set("format", jsonutils.NewString(args.Format))
+		if len(args.Resources) > 0 {
+			params.Set("resources", jsonutils.NewStringArray(args.Resources))
+		}
+		result, err := modules.Terraform.Export(s, args.PROJECT, params)
+		if err != nil {
+			return err
+		}
+		content, _ := result.GetString("content")
+		if len(args.Output) > 0 {
+			err = os.WriteFile(args.Output, []byte(content), 0644)
+			if err != nil {
+				return err
+			}
+		} else {
+			fmt.Println(content)
+		}
+		skipped := []string{}
+		result.Unmarshal(&skipped, "skipped")
+		for _, res := range skipped {
+			fmt.Fprintf(os.Stderr, "skipped %s\n", res)
+		}
+		return nil
+	})
+
+	type TerraformImportOptions struct {
+		PROJECT string `help:"ID or name of project"`
+		STATE   string `help:"path of terraform state file"`
+		DryRun  bool   `help:"only show matched resources"`
+	}
+	R(&TerraformImportOptions{}, "terraform-import", "Match resources in terraform state to synced resources of a project", func(s *mcclient.ClientSession, args *TerraformImportOptions) error {
+		content, err := os.ReadFile(args.STATE)
+		if err != nil {
+			return err
+		}
+		state, err := jsonutils.Parse(content)
+		if err != nil {
+			return err
+		}
+		params := jsonutils.NewDict()
+		params.Set("state", state)
+		if args.DryRun {
+			params.Set("dry_run", jsonutils.JSONTrue)
+		}
+		result, err := modules.Terraform.Import(s, args.PROJECT, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
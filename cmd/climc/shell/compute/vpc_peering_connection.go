@@ -28,4 +28,6 @@ func init() {
 	cmd.Delete(&options.VpcPeeringConnectionIdOptions{})
 	cmd.Perform("sync", &options.VpcPeeringConnectionIdOptions{})
 	cmd.Perform("syncstatus", &options.VpcPeeringConnectionIdOptions{})
+	cmd.PerformClass("mesh", &options.VpcPeeringConnectionMeshOptions{})
+	cmd.GetProperty(&options.VpcPeeringConnectionTopologyOptions{})
 }
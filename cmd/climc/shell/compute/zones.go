@@ -166,4 +166,60 @@ func init() {
 		return nil
 	})
 
+	type ZoneHostHaPolicyOptions struct {
+		ID                    string `help:"ID or name of zone"`
+		AutoRestartOnHostDown string `help:"restart guests on healthy hosts when host down" choices:"enable|disable"`
+		HostDownSeconds       int    `help:"seconds a host keeps abnormal on cloudprovider before considered down"`
+	}
+	R(&ZoneHostHaPolicyOptions{}, "zone-set-host-ha-policy", "Set host HA policy of managed hosts in zone", func(s *mcclient.ClientSession, args *ZoneHostHaPolicyOptions) error {
+		params := jsonutils.NewDict()
+		if len(args.AutoRestartOnHostDown) > 0 {
+			params.Set("auto_restart_on_host_down", jsonutils.NewString(args.AutoRestartOnHostDown))
+		}
+		if args.HostDownSeconds > 0 {
+			params.Set("host_down_seconds", jsonutils.NewInt(int64(args.HostDownSeconds)))
+		}
+		result, err := modules.Zones.PerformAction(s, args.ID, "set-host-ha-policy", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ZoneRebalancePlanOptions struct {
+		ID            string  `help:"ID or name of zone" json:"-"`
+		Threshold     float64 `help:"max allowed allocation skew between hosts, default 0.1"`
+		MaxMigrations int     `help:"max number of guest migrations in the plan, default 10"`
+	}
+	R(&ZoneRebalancePlanOptions{}, "zone-rebalance-plan", "Show guest migration plan to rebalance hosts in private cloud zone", func(s *mcclient.ClientSession, args *ZoneRebalancePlanOptions) error {
+		query, err := options.StructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.Zones.GetSpecific(s, args.ID, "rebalance-plan", query)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ZoneRebalanceOptions struct {
+		ZoneRebalancePlanOptions
+		Concurrency int `help:"max number of concurrent guest migrations, default 2"`
+	}
+	R(&ZoneRebalanceOptions{}, "zone-rebalance", "Migrate guests to rebalance hosts in private cloud zone", func(s *mcclient.ClientSession, args *ZoneRebalanceOptions) error {
+		params, err := options.StructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.Zones.PerformAction(s, args.ID, "rebalance", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
 }
@@ -470,4 +470,19 @@ func init() {
 		printObject(img)
 		return nil
 	})
+
+	type ImageConvertOptions struct {
+		ID     string `help:"ID or name of image to convert"`
+		FORMAT string `help:"Target image format" choices:"qcow2|vmdk|vhd|raw"`
+	}
+	R(&ImageConvertOptions{}, "image-convert", "Convert image to specific format", func(s *mcclient.ClientSession, opts *ImageConvertOptions) error {
+		params := jsonutils.NewDict()
+		params.Set("format", jsonutils.NewString(opts.FORMAT))
+		result, err := modules.Images.PerformAction(s, opts.ID, "convert", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
 }
@@ -45,6 +45,16 @@ type CloudeventListInput struct {
 	// 执行状态
 	Success *bool `json:"success"`
 
+	// 归一化操作人
+	Actor []string `json:"actor"`
+	// 归一化操作动作
+	// enum: create, delete, update, start, stop, restart, attach, detach, read, other
+	Verb []string `json:"verb"`
+	// 归一化资源类型
+	Resource []string `json:"resource"`
+	// 关键字搜索, 模糊匹配资源名称, 操作人及原始操作
+	Search string `json:"search"`
+
 	// 操作日志起始时间
 	Since time.Time `json:"since"`
 	// 操作日志截止时间
@@ -56,3 +66,27 @@ type CloudeventDetails struct {
 	apis.DomainizedResourceInfo
 	SCloudevent
 }
+
+type CloudeventRetentionPolicyCreateInput struct {
+	apis.DomainLevelResourceCreateInput
+
+	// 域内云平台操作日志保留天数, 超期日志将被清理
+	// required: true
+	KeepDays int `json:"keep_days"`
+}
+
+type CloudeventRetentionPolicyUpdateInput struct {
+	apis.DomainLevelResourceBaseUpdateInput
+
+	// 域内云平台操作日志保留天数
+	KeepDays *int `json:"keep_days"`
+}
+
+type CloudeventRetentionPolicyListInput struct {
+	apis.DomainLevelResourceListInput
+}
+
+type CloudeventRetentionPolicyDetails struct {
+	apis.DomainLevelResourceDetails
+	SCloudeventRetentionPolicy
+}
@@ -48,4 +48,17 @@ const (
 	CLOUD_EVENT_RESOURCE_TYPE_SNAPSHOTPOLICY           = "snapshotpolicy"
 	CLOUD_EVENT_RESOURCE_TYPE_SNAPSHOT                 = "snapshot"
 	CLOUD_EVENT_RESOURCE_TYPE_VPC                      = "vpc"
+	CLOUD_EVENT_RESOURCE_TYPE_UNKNOWN                  = "unknown"
+
+	// 归一化后的操作动作, 屏蔽各平台事件名称差异
+	CLOUD_EVENT_VERB_CREATE  = "create"
+	CLOUD_EVENT_VERB_DELETE  = "delete"
+	CLOUD_EVENT_VERB_UPDATE  = "update"
+	CLOUD_EVENT_VERB_START   = "start"
+	CLOUD_EVENT_VERB_STOP    = "stop"
+	CLOUD_EVENT_VERB_RESTART = "restart"
+	CLOUD_EVENT_VERB_ATTACH  = "attach"
+	CLOUD_EVENT_VERB_DETACH  = "detach"
+	CLOUD_EVENT_VERB_READ    = "read"
+	CLOUD_EVENT_VERB_OTHER   = "other"
 )
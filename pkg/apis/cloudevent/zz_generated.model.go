@@ -39,6 +39,15 @@ type SCloudevent struct {
 	Manager         string               `json:"manager"`
 	Provider        string               `json:"provider"`
 	Brand           string               `json:"brand"`
+	Actor           string               `json:"actor"`
+	Verb            string               `json:"verb"`
+	Resource        string               `json:"resource"`
+}
+
+// SCloudeventRetentionPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/cloudevent/models.SCloudeventRetentionPolicy.
+type SCloudeventRetentionPolicy struct {
+	apis.SDomainLevelResourceBase
+	KeepDays int `json:"keep_days"`
 }
 
 // SCloudprovider is an autogenerated struct via yunion.io/x/onecloud/pkg/cloudevent/models.SCloudprovider.
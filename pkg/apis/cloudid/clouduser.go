@@ -32,6 +32,9 @@ const (
 	CLOUD_USER_STATUS_SYNC_GROUPS_FAILED    = "sync_groups_failed"    // 同步权限组失败
 	CLOUD_USER_STATUS_RESET_PASSWORD        = "reset_password"        // 重置密码中
 	CLOUD_USER_STATUS_RESET_PASSWORD_FAILED = "reset_password_failed" // 重置密码失败
+
+	CLOUD_USER_SYNC_DIRECTION_PUSH = "push" // 本地权限和权限组同步到云上
+	CLOUD_USER_SYNC_DIRECTION_PULL = "pull" // 云上权限和权限组同步到本地
 )
 
 type ClouduserCreateInput struct {
@@ -243,6 +246,38 @@ type ClouduserSyncstatusInput struct {
 }
 
 type ClouduserSyncInput struct {
+	// 同步方向, 默认为push
+	// enum: push, pull
+	Direction string `json:"direction"`
+}
+
+type ClouduserSyncOwnerInput struct {
+	// 本地用户Id
+	UserId string `json:"user_id"`
+	// 需要加入的权限组, 仅对与权限组平台相同的子账号生效
+	CloudgroupIds []string `json:"cloudgroup_ids"`
+	// 需要绑定的权限, 仅对与权限平台相同的子账号生效
+	CloudpolicyIds []string `json:"cloudpolicy_ids"`
+}
+
+type ClouduserSyncOwnerOutput struct {
+	// 本地用户关联的子账号数量
+	ClouduserCount int `json:"clouduser_count"`
+	// 发生变更并开始同步到云上的子账号数量
+	SyncCount int `json:"sync_count"`
+}
+
+type ClouduserLoginInfo struct {
+	// 云平台
+	Provider string `json:"provider"`
+	// 主账号标识, 部分平台登录时需要填写
+	Account string `json:"account"`
+	// 登录用户名
+	Username string `json:"username"`
+	// 控制台登录地址
+	LoginUrl string `json:"login_url"`
+	// 是否允许控制台登录
+	IsConsoleLogin bool `json:"is_console_login"`
 }
 
 type ClouduserUpdateInput struct {
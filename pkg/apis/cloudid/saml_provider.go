@@ -32,6 +32,8 @@ const (
 	SAML_PROVIDER_STATUS_UPDATE_METADATA        = "update_metadata"
 	SAML_PROVIDER_STATUS_UPDATE_METADATA_FAILED = "update_metadata_failed"
 	SAML_PROVIDER_STATUS_SYNC                   = "sync"
+	SAML_PROVIDER_STATUS_SETUP                  = "setup"
+	SAML_PROVIDER_STATUS_SETUP_FAILED           = "setup_failed"
 )
 
 type SAMLProviderListInput struct {
@@ -57,3 +59,26 @@ type SAMLProviderCreateInput struct {
 	// swagger:ignore
 	MetadataDocument string `json:"metadata_document"`
 }
+
+type SAMLProviderSetupInput struct {
+	// 云账号ID或名称
+	CloudaccountId string `json:"cloudaccount_id"`
+
+	// 需要创建联合登录角色的权限组, 角色会绑定权限组中的权限
+	CloudgroupIds []string `json:"cloudgroup_ids"`
+}
+
+type SAMLProviderVerifyOutput struct {
+	// 云上身份提供商是否存在
+	ProviderExists bool `json:"provider_exists"`
+	// 云上身份提供商元数据是否与本地一致
+	MetadataMatch bool `json:"metadata_match"`
+	// 云账号是否开启SAML认证
+	SAMLAuthEnabled bool `json:"saml_auth_enabled"`
+	// 联合登录角色数量
+	CloudroleCount int `json:"cloudrole_count"`
+	// 云上不存在的角色
+	MissingCloudroles []string `json:"missing_cloudroles"`
+	// 是否可以进行联合登录
+	Ready bool `json:"ready"`
+}
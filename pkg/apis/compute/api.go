@@ -206,6 +206,14 @@ type DiskConfig struct {
 	//default: hybird
 	Medium string `json:"medium"`
 
+	//存储性能分级, 未指定backend时, 会选择满足此分级的存储类型
+	//hdd: 容量型
+	//ssd: 性能型
+	//nvme: 极速型
+	//enum: [hdd, ssd, nvme]
+	//required: false
+	StorageTier string `json:"storage_tier"`
+
 	//swagger:ignore
 	ImageProperties map[string]string `json:"image_properties"`
 
@@ -340,6 +348,16 @@ type ServerConfigs struct {
 	// 主机组列表, 参数可以是主机组名称或ID,建议使用ID
 	InstanceGroupIds []string `json:"groups"`
 
+	// 调度打分插件权重, key 为打分插件名称, value 为权重, 0 表示禁用该插件
+	// 未指定的插件使用调度器默认权重, 软约束插件默认权重为0
+	// 例如: {"guest_prefer_cheapest_region": 5, "guest_spread_providers": 2}
+	// required: false
+	SchedPriorityWeights map[string]int `json:"sched_priority_weights"`
+
+	// 按套餐价格调度, 比较各候选区域/云订阅下同规格套餐的按量付费价格, 优先选择价格最低的
+	// required: false
+	PreferLowestPrice bool `json:"prefer_lowest_price"`
+
 	// DEPRECATE
 	Suggestion bool `json:"suggestion"`
 }
@@ -365,6 +383,7 @@ type ServerCreateInput struct {
 	apis.VirtualResourceCreateInput
 	DeletePreventableCreateInput
 	HostnameInput
+	ExternalProjectResourceInput
 
 	*ServerConfigs
 
@@ -555,6 +574,9 @@ type ServerCreateInput struct {
 
 	// 指定用于新建主机的主机镜像ID
 	GuestImageID string `json:"guest_image_id"`
+
+	// 从镜像目录选择系统盘镜像, 按平台和区域匹配实际镜像
+	ImageCatalogId string `json:"image_catalog_id,omitempty"`
 }
 
 func (input *ServerCreateInput) AfterUnmarshal() {
@@ -611,3 +633,33 @@ type HostLoginInfo struct {
 	Password string `json:"password"`
 	Ip       string `json:"ip"`
 }
+
+const (
+	// 创建参数校验失败
+	SERVER_VALIDATE_CREATE_INVALID_INPUT = "invalid_input"
+	// 可用区内套餐不可用或已售罄
+	SERVER_VALIDATE_CREATE_SKU_UNAVAILABLE = "sku_unavailable"
+	// 镜像未缓存或不可用
+	SERVER_VALIDATE_CREATE_IMAGE_NOT_CACHED = "image_not_cached"
+	// 配额不足
+	SERVER_VALIDATE_CREATE_QUOTA_EXCEEDED = "quota_exceeded"
+	// 网络可用地址不足
+	SERVER_VALIDATE_CREATE_NETWORK_EXHAUSTED = "network_exhausted"
+)
+
+type ServerValidateCreateError struct {
+	// 出错的参数, 为空表示整体校验失败
+	Field string `json:"field"`
+	// 错误类型
+	// enum: ["invalid_input", "sku_unavailable", "image_not_cached", "quota_exceeded", "network_exhausted"]
+	Code string `json:"code"`
+	// 错误详情
+	Message string `json:"message"`
+}
+
+type ServerValidateCreateOutput struct {
+	// 是否全部校验通过
+	Valid bool `json:"valid"`
+	// 校验错误列表
+	Errors []ServerValidateCreateError `json:"errors"`
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+)
+
+const (
+	BUCKET_UPLOAD_PART_UPLOAD_ID_HEADER  = "X-Yunion-Bucket-Upload-Id"
+	BUCKET_UPLOAD_PART_INDEX_HEADER      = "X-Yunion-Bucket-Upload-Part-Index"
+	BUCKET_UPLOAD_PART_OFFSET_HEADER     = "X-Yunion-Bucket-Upload-Part-Offset"
+	BUCKET_UPLOAD_PART_TOTAL_SIZE_HEADER = "X-Yunion-Bucket-Upload-Total-Size"
+
+	// 分片序号从1开始, 最多10000个分片
+	BUCKET_UPLOAD_PART_MAX_INDEX = 10000
+
+	// 临时URL最长有效期7天
+	BUCKET_TEMP_URL_MAX_EXPIRE_SECONDS = 7 * 24 * 3600
+)
+
+var (
+	BUCKET_TEMP_URL_METHODS = []string{"GET", "PUT", "HEAD", "DELETE"}
+)
+
+type BucketInitMultipartUploadInput struct {
+	// 对象KEY
+	// required:true
+	Key string `json:"key"`
+	// 对象ACL
+	Acl string `json:"acl"`
+	// 对象存储类型
+	StorageClass string `json:"storage_class"`
+	// 对象元数据
+	Meta map[string]string `json:"meta"`
+}
+
+type BucketMultipartUploadOutput struct {
+	// 对象KEY
+	Key string `json:"key"`
+	// 分片上传ID
+	UploadId string `json:"upload_id"`
+}
+
+type BucketUploadPartOutput struct {
+	// 分片序号
+	PartIndex int `json:"part_index"`
+	// 分片ETag, 完成上传时按分片序号顺序提交
+	Etag string `json:"etag"`
+}
+
+type BucketCompleteMultipartUploadInput struct {
+	// 对象KEY
+	// required:true
+	Key string `json:"key"`
+	// 分片上传ID
+	// required:true
+	UploadId string `json:"upload_id"`
+	// 按分片序号排列的分片ETag
+	// required:true
+	PartEtags []string `json:"part_etags"`
+}
+
+type BucketAbortMultipartUploadInput struct {
+	// 对象KEY
+	// required:true
+	Key string `json:"key"`
+	// 分片上传ID
+	// required:true
+	UploadId string `json:"upload_id"`
+}
+
+type BucketGetMultipartUploadsInput struct {
+	// 对象KEY前缀
+	Prefix string `json:"prefix"`
+}
+
+type BucketMultipartUpload struct {
+	// 对象KEY
+	Key string `json:"key"`
+	// 分片上传ID
+	UploadId string `json:"upload_id"`
+	// 发起人
+	Initiator string `json:"initiator"`
+	// 发起时间
+	Initiated time.Time `json:"initiated"`
+}
+
+type BucketGetMultipartUploadsOutput struct {
+	Data []BucketMultipartUpload `json:"data"`
+}
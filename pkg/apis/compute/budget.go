@@ -0,0 +1,125 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	BUDGET_SCOPE_PROJECT      = "project"
+	BUDGET_SCOPE_DOMAIN       = "domain"
+	BUDGET_SCOPE_CLOUDACCOUNT = "cloudaccount"
+
+	BUDGET_ACTION_NOTIFY       = "notify"
+	BUDGET_ACTION_BLOCK_CREATE = "block_create"
+	BUDGET_ACTION_STOP_GUESTS  = "stop_guests"
+
+	BUDGET_STATUS_OK       = "ok"
+	BUDGET_STATUS_WARNING  = "warning"
+	BUDGET_STATUS_EXCEEDED = "exceeded"
+)
+
+var (
+	BUDGET_SCOPES = []string{
+		BUDGET_SCOPE_PROJECT,
+		BUDGET_SCOPE_DOMAIN,
+		BUDGET_SCOPE_CLOUDACCOUNT,
+	}
+	BUDGET_ACTIONS = []string{
+		BUDGET_ACTION_NOTIFY,
+		BUDGET_ACTION_BLOCK_CREATE,
+		BUDGET_ACTION_STOP_GUESTS,
+	}
+)
+
+type SBudgetThreshold struct {
+	// 预算使用百分比阈值, 例如80表示当月费用达到预算的80%
+	Percent float64 `json:"percent"`
+	// 达到阈值后执行的动作
+	// enum: ["notify", "block_create", "stop_guests"]
+	Actions []string `json:"actions"`
+}
+
+type SBudgetThresholds []SBudgetThreshold
+
+func (t SBudgetThresholds) String() string {
+	return jsonutils.Marshal(t).String()
+}
+
+func (t SBudgetThresholds) IsZero() bool {
+	return len(t) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SBudgetThresholds{}), func() gotypes.ISerializable {
+		return &SBudgetThresholds{}
+	})
+}
+
+type BudgetCreateInput struct {
+	apis.EnabledStatusDomainLevelResourceCreateInput
+
+	// 预算范围
+	// enum: ["project", "domain", "cloudaccount"]
+	// required: true
+	Scope string `json:"scope"`
+	// 预算范围对应的项目, 域或云账号的ID或名称, 域范围时默认为当前域
+	ScopeId string `json:"scope_id"`
+	// 每月预算金额
+	// required: true
+	Amount float64 `json:"amount"`
+	// 币种, 仅统计该币种的账单
+	Currency string `json:"currency"`
+	// 阈值及对应动作, 默认为 100% 时通知
+	Thresholds SBudgetThresholds `json:"thresholds"`
+	// 超出预算时自动关机的虚拟机需要具备的标签, 格式为 key=value
+	StopGuestTag string `json:"stop_guest_tag"`
+}
+
+type BudgetUpdateInput struct {
+	apis.EnabledStatusDomainLevelResourceBaseUpdateInput
+
+	Amount       *float64          `json:"amount"`
+	Thresholds   SBudgetThresholds `json:"thresholds"`
+	StopGuestTag *string           `json:"stop_guest_tag"`
+}
+
+type BudgetListInput struct {
+	apis.EnabledStatusDomainLevelResourceListInput
+
+	Scope    []string `json:"scope"`
+	ScopeId  []string `json:"scope_id"`
+	Blocking *bool    `json:"blocking"`
+}
+
+type BudgetDetails struct {
+	apis.EnabledStatusDomainLevelResourceDetails
+
+	SBudget
+
+	// 预算范围对应的项目, 域或云账号名称
+	ScopeName string `json:"scope_name"`
+	// 当月费用占预算百分比
+	UsagePercent float64 `json:"usage_percent"`
+}
+
+type BudgetCheckInput struct {
+}
@@ -32,3 +32,17 @@ const (
 	CACHED_IMAGE_REFRESH_SECONDS                  = 1     // 1 second
 	CACHED_IMAGE_REFERENCE_SESSION_EXPIRE_SECONDS = 86400 // 1 day
 )
+
+const (
+	IMAGE_CONVERT_TIMEOUT_SECONDS = 3600 * 2 // 2 hours
+	IMAGE_CONVERT_REFRESH_SECONDS = 5        // 5 seconds
+)
+
+var (
+	// 上传自定义镜像时云平台要求的镜像格式, 第一个为首选转换格式
+	CLOUD_PROVIDER_IMAGE_FORMATS = map[string][]string{
+		CLOUD_PROVIDER_VMWARE:  {"vmdk"},
+		CLOUD_PROVIDER_AZURE:   {"vhd"},
+		CLOUD_PROVIDER_PROXMOX: {"qcow2", "raw"},
+	}
+)
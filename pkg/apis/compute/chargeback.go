@@ -0,0 +1,121 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+const (
+	CHARGEBACK_PRICING_STATUS_READY = "ready"
+
+	CHARGEBACK_STATEMENT_FORMAT_JSON  = "json"
+	CHARGEBACK_STATEMENT_FORMAT_CSV   = "csv"
+	CHARGEBACK_STATEMENT_FORMAT_EXCEL = "xlsx"
+)
+
+var (
+	CHARGEBACK_STATEMENT_FORMATS = []string{
+		CHARGEBACK_STATEMENT_FORMAT_JSON,
+		CHARGEBACK_STATEMENT_FORMAT_CSV,
+		CHARGEBACK_STATEMENT_FORMAT_EXCEL,
+	}
+)
+
+type ChargebackPricingCreateInput struct {
+	apis.EnabledStatusDomainLevelResourceCreateInput
+
+	// 适用平台, 为空表示所有平台
+	Provider string `json:"provider"`
+	// 适用资源类型, 为空表示所有资源类型
+	ResourceType string `json:"resource_type"`
+	// 价格系数, 内部结算费用 = 云上费用 * 系数
+	Multiplier float64 `json:"multiplier"`
+}
+
+type ChargebackPricingUpdateInput struct {
+	apis.EnabledStatusDomainLevelResourceBaseUpdateInput
+
+	// 价格系数
+	Multiplier *float64 `json:"multiplier"`
+}
+
+type ChargebackPricingListInput struct {
+	apis.EnabledStatusDomainLevelResourceListInput
+
+	Provider     []string `json:"provider"`
+	ResourceType []string `json:"resource_type"`
+}
+
+type ChargebackPricingDetails struct {
+	apis.EnabledStatusDomainLevelResourceDetails
+
+	SChargebackPricing
+}
+
+type ChargebackStatementInput struct {
+	CloudBillItemListInput
+
+	// 导出格式
+	// enum: ["json", "csv", "xlsx"]
+	Format string `json:"format"`
+}
+
+type ChargebackStatementResource struct {
+	Provider     string `json:"provider"`
+	Region       string `json:"region"`
+	ResourceType string `json:"resource_type"`
+	ResourceId   string `json:"resource_id"`
+	ResourceName string `json:"resource_name"`
+	Currency     string `json:"currency"`
+	// 云上原始费用
+	OriginalAmount float64 `json:"original_amount"`
+	// 价格系数
+	Multiplier float64 `json:"multiplier"`
+	// 内部结算费用
+	Amount float64 `json:"amount"`
+}
+
+type ChargebackProjectStatement struct {
+	ProjectId string `json:"tenant_id"`
+	Project   string `json:"tenant"`
+	DomainId  string `json:"project_domain_id"`
+	Domain    string `json:"project_domain"`
+
+	// 按币种汇总的云上原始费用
+	OriginalAmount map[string]float64 `json:"original_amount"`
+	// 按币种汇总的内部结算费用
+	Amount map[string]float64 `json:"amount"`
+
+	Resources []ChargebackStatementResource `json:"resources"`
+}
+
+type ChargebackDomainStatement struct {
+	DomainId string `json:"project_domain_id"`
+	Domain   string `json:"project_domain"`
+
+	OriginalAmount map[string]float64 `json:"original_amount"`
+	Amount         map[string]float64 `json:"amount"`
+}
+
+type ChargebackStatementOutput struct {
+	BillingMonth []string `json:"billing_month"`
+
+	Projects []ChargebackProjectStatement `json:"projects"`
+	Domains  []ChargebackDomainStatement  `json:"domains"`
+
+	// 导出格式
+	Format string `json:"format"`
+	// 导出内容, csv为文本, xlsx为base64编码
+	Content string `json:"content"`
+}
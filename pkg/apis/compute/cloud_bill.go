@@ -0,0 +1,123 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	CLOUD_BILL_GROUP_BY_PROJECT       = "project"
+	CLOUD_BILL_GROUP_BY_PROVIDER      = "provider"
+	CLOUD_BILL_GROUP_BY_REGION        = "region"
+	CLOUD_BILL_GROUP_BY_CLOUDACCOUNT  = "cloudaccount"
+	CLOUD_BILL_GROUP_BY_RESOURCE_TYPE = "resource_type"
+	CLOUD_BILL_GROUP_BY_BILLING_MONTH = "billing_month"
+
+	// 账单月份格式
+	CLOUD_BILL_MONTH_FORMAT = "2006-01"
+)
+
+var (
+	CLOUD_BILL_GROUP_BYS = []string{
+		CLOUD_BILL_GROUP_BY_PROJECT,
+		CLOUD_BILL_GROUP_BY_PROVIDER,
+		CLOUD_BILL_GROUP_BY_REGION,
+		CLOUD_BILL_GROUP_BY_CLOUDACCOUNT,
+		CLOUD_BILL_GROUP_BY_RESOURCE_TYPE,
+		CLOUD_BILL_GROUP_BY_BILLING_MONTH,
+	}
+)
+
+type CloudBillItemListInput struct {
+	apis.StandaloneAnonResourceListInput
+	apis.ProjectizedResourceListInput
+	ManagedResourceListInput
+	RegionalFilterListInput
+
+	// 账单月份, 例如: 2023-01
+	BillingMonth []string `json:"billing_month"`
+	// 起始账单月份(包含)
+	StartMonth string `json:"start_month"`
+	// 结束账单月份(包含)
+	EndMonth string `json:"end_month"`
+	// 资源类型
+	ResourceType []string `json:"resource_type"`
+	// 云上资源Id
+	ResourceId []string `json:"resource_id"`
+	// 计费方式
+	ChargeType []string `json:"charge_type"`
+}
+
+type CloudBillItemDetails struct {
+	apis.StandaloneAnonResourceDetails
+	apis.ProjectizedResourceInfo
+	ManagedResourceInfo
+	CloudregionResourceInfo
+
+	SCloudBillItem
+}
+
+type CloudBillCostInput struct {
+	CloudBillItemListInput
+
+	// 聚合维度
+	// enum: ["project", "provider", "region", "cloudaccount", "resource_type", "billing_month"]
+	GroupBy string `json:"group_by"`
+}
+
+type CloudBillCostItem struct {
+	// 聚合维度的值, 例如项目Id
+	Key string `json:"key"`
+	// 聚合维度的名称, 例如项目名称
+	Name string `json:"name"`
+	// 费用
+	Amount float64 `json:"amount"`
+	// 币种
+	Currency string `json:"currency"`
+}
+
+type CloudBillCostOutput struct {
+	GroupBy string              `json:"group_by"`
+	Data    []CloudBillCostItem `json:"data"`
+	// 按币种汇总的总费用
+	Total map[string]float64 `json:"total"`
+}
+
+// 归一化后的云上账单明细, 金额为该明细在账单周期内的应付金额
+type CloudBillLineItem struct {
+	// 明细Id, 同一账单月份内唯一
+	ItemId    string    `json:"item_id"`
+	UsageDate time.Time `json:"usage_date"`
+	// 云上区域Id
+	RegionId          string  `json:"region_id"`
+	ResourceType      string  `json:"resource_type"`
+	ResourceId        string  `json:"resource_id"`
+	ResourceName      string  `json:"resource_name"`
+	ProductCode       string  `json:"product_code"`
+	ChargeType        string  `json:"charge_type"`
+	ExternalProjectId string  `json:"external_project_id"`
+	Amount            float64 `json:"amount"`
+	Currency          string  `json:"currency"`
+}
+
+type CloudproviderImportBillsInput struct {
+	// 账单月份, 例如: 2023-01
+	BillingMonth string `json:"billing_month"`
+	// 账单明细, 替换该订阅对应月份已导入的明细
+	Items []CloudBillLineItem `json:"items"`
+}
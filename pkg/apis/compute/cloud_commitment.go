@@ -0,0 +1,174 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	// 预留实例, 抵扣指定规格按量实例费用
+	CLOUD_COMMITMENT_TYPE_RESERVED_INSTANCE = "reserved_instance"
+	// 节省计划, 按每小时承诺金额抵扣费用
+	CLOUD_COMMITMENT_TYPE_SAVINGS_PLAN = "savings_plan"
+	// 承诺使用折扣(Google)
+	CLOUD_COMMITMENT_TYPE_COMMITTED_USE = "committed_use"
+
+	CLOUD_COMMITMENT_STATUS_ACTIVE  = "active"
+	CLOUD_COMMITMENT_STATUS_PENDING = "pending"
+	CLOUD_COMMITMENT_STATUS_EXPIRED = "expired"
+	CLOUD_COMMITMENT_STATUS_UNKNOWN = "unknown"
+
+	CLOUD_COMMITMENT_RECOMMEND_PURCHASE = "purchase"
+	CLOUD_COMMITMENT_RECOMMEND_REDUCE   = "reduce"
+	CLOUD_COMMITMENT_RECOMMEND_RENEW    = "renew"
+
+	// 利用率低于该值时建议调整预留
+	CLOUD_COMMITMENT_LOW_UTILIZATION_PERCENT = 80
+	// 到期前多少天提示续费
+	CLOUD_COMMITMENT_RENEW_DAYS = 30
+)
+
+var (
+	CLOUD_COMMITMENT_TYPES = []string{
+		CLOUD_COMMITMENT_TYPE_RESERVED_INSTANCE,
+		CLOUD_COMMITMENT_TYPE_SAVINGS_PLAN,
+		CLOUD_COMMITMENT_TYPE_COMMITTED_USE,
+	}
+)
+
+type CloudCommitmentListInput struct {
+	apis.StatusInfrasResourceBaseListInput
+	apis.ExternalizedResourceBaseListInput
+
+	ManagedResourceListInput
+	RegionalFilterListInput
+
+	// 承诺类型
+	CommitmentType []string `json:"commitment_type"`
+	// 实例规格
+	InstanceType []string `json:"instance_type"`
+	// 列出指定天数内到期的承诺
+	ExpireWithinDays int `json:"expire_within_days"`
+}
+
+type CloudCommitmentCreateInput struct {
+	apis.StatusInfrasResourceBaseCreateInput
+	CloudregionResourceInput
+	CloudproviderResourceInput
+
+	// 承诺类型
+	// enum: ["reserved_instance", "savings_plan", "committed_use"]
+	// required: true
+	CommitmentType string `json:"commitment_type"`
+	// 预留实例规格, 预留实例及承诺使用折扣必填
+	InstanceType string `json:"instance_type"`
+	// 预留实例数量
+	InstanceCount int    `json:"instance_count"`
+	OsType        string `json:"os_type"`
+	// 付款方式, 如 All Upfront, No Upfront
+	PaymentOption string `json:"payment_option"`
+	// 节省计划每小时承诺金额, 节省计划必填
+	HourlyCommitment float64 `json:"hourly_commitment"`
+	Currency         string  `json:"currency"`
+	// 生效时间
+	StartAt time.Time `json:"start_at"`
+	// 到期时间
+	EndAt time.Time `json:"end_at"`
+	// 云平台控制台统计的利用率(百分比), 未知时不填
+	Utilization *float64 `json:"utilization"`
+}
+
+type CloudCommitmentUpdateInput struct {
+	apis.StatusInfrasResourceBaseUpdateInput
+
+	InstanceCount    *int       `json:"instance_count"`
+	OsType           *string    `json:"os_type"`
+	PaymentOption    *string    `json:"payment_option"`
+	HourlyCommitment *float64   `json:"hourly_commitment"`
+	Currency         *string    `json:"currency"`
+	StartAt          *time.Time `json:"start_at"`
+	EndAt            *time.Time `json:"end_at"`
+	Utilization      *float64   `json:"utilization"`
+}
+
+type CloudCommitmentDetails struct {
+	apis.StatusInfrasResourceBaseDetails
+	ManagedResourceInfo
+	CloudregionResourceInfo
+
+	SCloudCommitment
+
+	// 剩余天数
+	RemainingDays int `json:"remaining_days"`
+}
+
+type CloudCommitmentCoverageItem struct {
+	ManagerId     string `json:"manager_id"`
+	Manager       string `json:"manager"`
+	CloudregionId string `json:"cloudregion_id"`
+	Cloudregion   string `json:"cloudregion"`
+	InstanceType  string `json:"instance_type"`
+
+	// 运行中的按量付费实例数量
+	RunningCount int `json:"running_count"`
+	// 有效的预留实例数量
+	ReservedCount int `json:"reserved_count"`
+	// 被预留实例覆盖的实例数量
+	CoveredCount int `json:"covered_count"`
+	// 覆盖率, 被覆盖实例数/运行实例数
+	CoveragePercent float64 `json:"coverage_percent"`
+	// 利用率, 被覆盖实例数/预留实例数
+	UtilizationPercent float64 `json:"utilization_percent"`
+}
+
+type CloudCommitmentSavingsPlan struct {
+	Id               string    `json:"id"`
+	Name             string    `json:"name"`
+	ManagerId        string    `json:"manager_id"`
+	HourlyCommitment float64   `json:"hourly_commitment"`
+	Currency         string    `json:"currency"`
+	Utilization      float64   `json:"utilization"`
+	EndAt            time.Time `json:"end_at"`
+}
+
+type CloudCommitmentRecommendation struct {
+	// 建议动作
+	// enum: ["purchase", "reduce", "renew"]
+	Action        string `json:"action"`
+	ManagerId     string `json:"manager_id"`
+	CloudregionId string `json:"cloudregion_id"`
+	InstanceType  string `json:"instance_type"`
+	// 建议购买或减少的实例数量
+	Count int `json:"count"`
+	// 建议续费的承诺ID
+	CommitmentId string `json:"commitment_id"`
+	Reason       string `json:"reason"`
+}
+
+type CloudCommitmentCoverageOutput struct {
+	Items []CloudCommitmentCoverageItem `json:"items"`
+
+	TotalRunning       int     `json:"total_running"`
+	TotalReserved      int     `json:"total_reserved"`
+	TotalCovered       int     `json:"total_covered"`
+	CoveragePercent    float64 `json:"coverage_percent"`
+	UtilizationPercent float64 `json:"utilization_percent"`
+
+	SavingsPlans    []CloudCommitmentSavingsPlan    `json:"savings_plans"`
+	Recommendations []CloudCommitmentRecommendation `json:"recommendations"`
+}
@@ -17,6 +17,7 @@ package compute
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
@@ -532,6 +533,57 @@ type SyncRangeInput struct {
 	Resources []string `json:"resources" choices:"project|compute|network|eip|loadbalancer|objectstore|rds|cache|event|cloudid|dnszone|public_ip|intervpcnetwork|saml_auth|quota|nat|nas|waf|mongodb|es|kafka|app|cdn|container|ipv6_gateway|tablestore|modelarts|vpcpeer|misc"`
 }
 
+type CloudaccountHealthSummary struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	Brand    string `json:"brand"`
+	Enabled  bool   `json:"enabled"`
+	Status   string `json:"status"`
+
+	// 账号健康状态
+	HealthStatus string `json:"health_status"`
+	// 账号同步状态
+	SyncStatus string `json:"sync_status"`
+	// 账号探测异常错误次数
+	ErrorCount int `json:"error_count"`
+
+	// 子订阅数量
+	ProviderCount int `json:"provider_count,allowempty"`
+	// 启用的子订阅数量
+	EnabledProviderCount int `json:"enabled_provider_count,allowempty"`
+	// 已连接的子订阅数量
+	ConnectedProviderCount int `json:"connected_provider_count,allowempty"`
+	// 各健康状态的子订阅数量
+	// example: {"normal": 3, "arrears": 1}
+	ProviderHealth map[string]int `json:"provider_health"`
+	// 非健康状态的子订阅数量
+	UnhealthyProviderCount int `json:"unhealthy_provider_count,allowempty"`
+
+	// 最近一次同步结束时间, 不区分同步成功或失败
+	LastSyncEndAt time.Time `json:"last_sync_end_at"`
+	// 所有子订阅区域中最近一次成功同步结束时间
+	LastSyncSuccessAt time.Time `json:"last_sync_success_at"`
+	// 最近一次成功同步距今时长(秒), -1表示从未同步成功
+	LastSyncAgeSeconds int `json:"last_sync_age_seconds"`
+}
+
+type CloudaccountMigrateSecretsInput struct {
+	// 迁回数据库AES加密存储, 用于停用外部密钥存储前回滚
+	ToLocal bool `json:"to_local"`
+}
+
+type CloudaccountMigrateSecretsOutput struct {
+	// 已迁移的云账号数量
+	Cloudaccounts int `json:"cloudaccounts"`
+	// 已迁移的子订阅数量
+	Cloudproviders int `json:"cloudproviders"`
+	// 无需迁移的数量
+	Skipped int `json:"skipped"`
+	// 迁移失败的资源
+	Failed []string `json:"failed"`
+}
+
 type SAccountPermission struct {
 	Permissions []string
 }
@@ -546,8 +598,47 @@ func (s SAccountPermissions) IsZero() bool {
 	return len(s) == 0
 }
 
+const (
+	CLOUD_ACCOUNT_PERMISSION_AUDIT_OK      = "ok"
+	CLOUD_ACCOUNT_PERMISSION_AUDIT_MISSING = "missing"
+	CLOUD_ACCOUNT_PERMISSION_AUDIT_FAILED  = "failed"
+)
+
+type SCapabilityPermissionAudit struct {
+	// 云账号能力, 例如 compute, network, objectstore
+	Capability string `json:"capability"`
+	// 校验的列表操作
+	Actions []string `json:"actions"`
+	// 缺失权限的操作, 包含云平台返回的具体权限
+	Missing []string `json:"missing"`
+	// 非权限类错误
+	Error string `json:"error"`
+}
+
+type SAccountPermissionAudit struct {
+	// 最近一次审计时间
+	AuditAt time.Time `json:"audit_at"`
+	// ok: 权限完整, missing: 存在缺失权限, failed: 审计失败
+	Status string `json:"status"`
+	// 审计失败原因, 例如凭据失效
+	Reason string `json:"reason"`
+
+	Capabilities []SCapabilityPermissionAudit `json:"capabilities"`
+}
+
+func (s SAccountPermissionAudit) String() string {
+	return jsonutils.Marshal(s).String()
+}
+
+func (s SAccountPermissionAudit) IsZero() bool {
+	return s.AuditAt.IsZero()
+}
+
 func init() {
 	gotypes.RegisterSerializable(reflect.TypeOf(&SAccountPermissions{}), func() gotypes.ISerializable {
 		return &SAccountPermissions{}
 	})
+	gotypes.RegisterSerializable(reflect.TypeOf(&SAccountPermissionAudit{}), func() gotypes.ISerializable {
+		return &SAccountPermissionAudit{}
+	})
 }
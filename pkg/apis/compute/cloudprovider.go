@@ -15,6 +15,8 @@
 package compute
 
 import (
+	"time"
+
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/pkg/utils"
 
@@ -279,6 +281,54 @@ type CloudproviderGetCannedAclOutput struct {
 	ObjectCannedAcls []string `json:"object_canned_acls"`
 }
 
+type CloudproviderPurgePreviewResource struct {
+	// 资源类型
+	ResourceType string `json:"resource_type"`
+	// 将被清理的资源数量
+	Count int `json:"count"`
+	// 将被清理的资源ID
+	Ids []string `json:"ids"`
+}
+
+type CloudproviderPurgePreviewOutput struct {
+	// 将被清理的资源总数
+	Total int `json:"total"`
+	// 按资源类型统计, 包含随宿主机、存储及VPC一并清理的虚拟机、磁盘及网络
+	Resources []CloudproviderPurgePreviewResource `json:"resources"`
+}
+
+type CloudproviderApiStatsOutput struct {
+	// 是否处于熔断状态, 熔断期间跳过后台同步, 用户发起的云上操作不受影响
+	// 统计及熔断状态仅保存在当前region服务进程的内存中
+	Degraded bool `json:"degraded"`
+	// 熔断结束时间
+	DegradedUntil time.Time `json:"degraded_until"`
+	// 连续失败次数
+	ConsecutiveFailures int `json:"consecutive_failures"`
+
+	// 建立客户端及获取区域的调用次数, 不含同步过程中各资源的API调用
+	Calls int64 `json:"calls"`
+	// 重试次数
+	Retries int64 `json:"retries"`
+	// 失败次数
+	Failures int64 `json:"failures"`
+	// 熔断期间被跳过的同步次数
+	Rejected int64 `json:"rejected"`
+}
+
+type CloudproviderDeleteInput struct {
+	// 删除前归档将被清理资源的本地元数据(标签、描述及所属项目), 以便重新添加该账号后恢复
+	// default: false
+	Archive bool `json:"archive"`
+}
+
+type CloudproviderRestoreArchiveOutput struct {
+	// 已恢复的资源数量
+	Restored int `json:"restored"`
+	// 未找到对应资源而跳过的数量
+	Skipped int `json:"skipped"`
+}
+
 type CloudproviderSync struct {
 	// 指定区域启用或禁用同步
 	// default: false
@@ -286,3 +336,27 @@ type CloudproviderSync struct {
 	// 指定区域信息
 	CloudregionIds []string `json:"cloudregion_ids"`
 }
+
+const (
+	CLOUD_PROVIDER_CREDENTIAL_MIN_DURATION     = 900
+	CLOUD_PROVIDER_CREDENTIAL_MAX_DURATION     = 43200
+	CLOUD_PROVIDER_CREDENTIAL_DEFAULT_DURATION = 3600
+)
+
+type CloudproviderIssueCredentialInput struct {
+	// 临时凭据有效期(秒)
+	// default: 3600
+	DurationSeconds int64 `json:"duration_seconds"`
+	// 扮演的角色ARN, 阿里云必须指定, AWS需指定角色或策略
+	RoleArn string `json:"role_arn"`
+	// 收缩临时凭据权限的策略文档
+	Policy string `json:"policy"`
+}
+
+type CloudproviderIssueCredentialOutput struct {
+	Provider        string    `json:"provider"`
+	AccessKeyId     string    `json:"access_key_id"`
+	AccessKeySecret string    `json:"access_key_secret"`
+	SecurityToken   string    `json:"security_token"`
+	Expiration      time.Time `json:"expiration"`
+}
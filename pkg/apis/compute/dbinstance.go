@@ -164,6 +164,10 @@ type DBInstanceCreateInput struct {
 
 	// 多可用区部署
 	MultiAZ bool `json:"multi_az"`
+
+	// 参数模板名称或Id, 实例创建完成后按平台转换并应用模板参数
+	// 仅支持修改实例参数的平台可指定, 其余平台创建时直接拒绝
+	ParameterTemplateId string `json:"parameter_template_id"`
 }
 
 type SDBInstanceChangeConfigInput struct {
@@ -173,6 +177,11 @@ type SDBInstanceChangeConfigInput struct {
 	DiskSizeGB   int
 }
 
+type DBInstanceUpdateParametersInput struct {
+	// 待修改的参数, key 须为已同步的参数名称
+	Parameters map[string]string `json:"parameters"`
+}
+
 type SDBInstanceRecoveryConfigInput struct {
 	apis.Meta
 
@@ -56,6 +56,9 @@ const (
 	DBINSTANCE_UPDATE_TAGS        = "update_tags"
 	DBINSTANCE_UPDATE_TAGS_FAILED = "update_tags_fail"
 
+	DBINSTANCE_UPDATE_PARAMETERS        = "update_parameters"        //修改参数中
+	DBINSTANCE_UPDATE_PARAMETERS_FAILED = "update_parameters_failed" //修改参数失败
+
 	//备份状态
 	DBINSTANCE_BACKUP_READY         = compute.DBINSTANCE_BACKUP_READY         //正常
 	DBINSTANCE_BACKUP_CREATING      = compute.DBINSTANCE_BACKUP_CREATING      //创建中
@@ -0,0 +1,103 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	DBINSTANCE_PARAMETER_TEMPLATE_STATUS_AVAILABLE = "available"
+)
+
+// 与平台无关的数据库参数, key 为引擎原生参数名称
+type DBParameterTemplateParameters map[string]string
+
+func (params DBParameterTemplateParameters) String() string {
+	return jsonutils.Marshal(params).String()
+}
+
+func (params DBParameterTemplateParameters) IsZero() bool {
+	return len(params) == 0
+}
+
+type DBInstanceParameterTemplateCreateInput struct {
+	apis.SharableVirtualResourceCreateInput
+
+	// rds引擎
+	// enum: MySQL, SQLServer, PostgreSQL, MariaDB, Oracle, PPAS
+	// required: true
+	Engine string `json:"engine"`
+
+	// rds引擎版本, 为空时适用于所有版本
+	EngineVersion string `json:"engine_version"`
+
+	// 参数列表
+	// required: true
+	// example: {"max_connections":"2000"}
+	Parameters DBParameterTemplateParameters `json:"parameters"`
+}
+
+type DBInstanceParameterTemplateUpdateInput struct {
+	apis.SharableVirtualResourceBaseUpdateInput
+
+	// 参数列表, 更新后可通过 drift 查看使用此模板的实例与模板的差异
+	Parameters DBParameterTemplateParameters `json:"parameters"`
+}
+
+type DBInstanceParameterTemplateListInput struct {
+	apis.SharableVirtualResourceListInput
+
+	// 引擎
+	Engine []string `json:"engine"`
+}
+
+type DBInstanceParameterTemplateDetails struct {
+	apis.SharableVirtualResourceDetails
+	SDBInstanceParameterTemplate
+
+	// 使用此模板的实例数量
+	DBInstanceCount int `json:"dbinstance_count"`
+}
+
+type DBInstanceParameterDrift struct {
+	// 模板中的参数名称
+	Key string `json:"key"`
+	// 转换后在平台上的参数名称
+	ProviderKey string `json:"provider_key"`
+	// 模板期望值
+	Expected string `json:"expected"`
+	// 实例当前值
+	Actual string `json:"actual"`
+	// 实例上不存在此参数
+	Missing bool `json:"missing"`
+}
+
+type DBInstanceParameterTemplateDrift struct {
+	DBInstanceId string                     `json:"dbinstance_id"`
+	DBInstance   string                     `json:"dbinstance"`
+	Drifts       []DBInstanceParameterDrift `json:"drifts"`
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&DBParameterTemplateParameters{}), func() gotypes.ISerializable {
+		return &DBParameterTemplateParameters{}
+	})
+}
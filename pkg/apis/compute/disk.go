@@ -29,6 +29,7 @@ import (
 type DiskCreateInput struct {
 	apis.VirtualResourceCreateInput
 	apis.EncryptedResourceCreateInput
+	ExternalProjectResourceInput
 
 	*DiskConfig
 
@@ -74,6 +75,7 @@ func (req *DiskCreateInput) ToServerCreateInput() *ServerCreateInput {
 	input.Name = req.Name
 	input.ProjectId = req.ProjectId
 	input.ProjectDomainId = req.ProjectDomainId
+	input.ExternalProjectId = req.ExternalProjectId
 	return &input
 }
 
@@ -89,6 +91,7 @@ func (req *ServerCreateInput) ToDiskCreateInput() *DiskCreateInput {
 	input.Name = req.Name
 	input.ProjectId = req.ProjectId
 	input.ProjectDomainId = req.ProjectDomainId
+	input.ExternalProjectId = req.ExternalProjectId
 	return &input
 }
 
@@ -118,6 +121,7 @@ type DiskListInput struct {
 
 	SnapshotPolicyFilterListInput
 	ServerFilterListInput
+	ExternalProjectFilterListInput
 
 	// filter disk by whether it is being used
 	Unused *bool `json:"unused"`
@@ -273,6 +277,13 @@ type DiskResizeInput struct {
 	// default unit: Mb
 	// example: 1024; 40G; 1024M
 	Size string `json:"size"`
+
+	// 在线扩容后在虚拟机内扩展分区及文件系统, 仅支持qga的KVM虚拟机
+	// 关机扩容时由cloud-init在开机时扩展系统盘
+	GrowFs bool `json:"grow_fs"`
+
+	// 运行中的虚拟机不支持在线扩容时, 是否允许先关机扩容再开机
+	AllowOffline bool `json:"allow_offline"`
 }
 
 func (self DiskResizeInput) SizeMb() (int, error) {
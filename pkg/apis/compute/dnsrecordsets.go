@@ -15,11 +15,16 @@
 package compute
 
 import (
+	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
+	"time"
 
 	"yunion.io/x/cloudmux/pkg/apis/compute"
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
 	"yunion.io/x/pkg/util/regutils"
 
 	"yunion.io/x/onecloud/pkg/apis"
@@ -28,8 +33,132 @@ import (
 
 const (
 	DNS_RECORDSET_STATUS_AVAILABLE = compute.DNS_RECORDSET_STATUS_AVAILABLE
+
+	DNS_FAILOVER_PROBE_TCP   = "tcp"
+	DNS_FAILOVER_PROBE_HTTP  = "http"
+	DNS_FAILOVER_PROBE_HTTPS = "https"
+
+	DNS_FAILOVER_DEFAULT_FAILURE_THRESHOLD = 3
 )
 
+var DNS_FAILOVER_PROBE_PROTOCOLS = []string{
+	DNS_FAILOVER_PROBE_TCP,
+	DNS_FAILOVER_PROBE_HTTP,
+	DNS_FAILOVER_PROBE_HTTPS,
+}
+
+type SDnsFailoverEndpoint struct {
+	// 记录值, A/AAAA记录为IP地址, CNAME记录为域名
+	Value string `json:"value"`
+	// 端点所在平台, 仅用于标识
+	Provider string `json:"provider"`
+	// 优先级, 数值越小越优先
+	Priority int `json:"priority"`
+
+	// 是否健康
+	Healthy bool `json:"healthy"`
+	// 连续探测失败次数
+	FailCount int `json:"fail_count"`
+	// 最近一次探测时间
+	LastProbeAt time.Time `json:"last_probe_at"`
+}
+
+// 故障切换记录配置, 健康探测失败时自动将记录值切换到优先级最高的健康端点
+type SDnsFailover struct {
+	Endpoints []SDnsFailoverEndpoint `json:"endpoints"`
+
+	// 探测协议
+	// enum: tcp, http, https
+	ProbeProtocol string `json:"probe_protocol"`
+	// 探测端口, 默认http/tcp为80, https为443
+	ProbePort int `json:"probe_port"`
+	// http(s)探测路径
+	ProbePath string `json:"probe_path"`
+	// 连续失败多少次后判定端点不健康
+	FailureThreshold int `json:"failure_threshold"`
+}
+
+func (self SDnsFailover) String() string {
+	return jsonutils.Marshal(self).String()
+}
+
+func (self SDnsFailover) IsZero() bool {
+	return len(self.Endpoints) == 0
+}
+
+func (self *SDnsFailover) Validate(dnsType string) error {
+	switch cloudprovider.TDnsType(dnsType) {
+	case cloudprovider.DnsTypeA, cloudprovider.DnsTypeAAAA, cloudprovider.DnsTypeCNAME:
+	default:
+		return httperrors.NewNotSupportedError("failover not supported for %s record", dnsType)
+	}
+	if len(self.Endpoints) < 2 {
+		return httperrors.NewInputParameterError("failover requires at least 2 endpoints")
+	}
+	values := map[string]bool{}
+	for i := range self.Endpoints {
+		ep := &self.Endpoints[i]
+		recordset := SDnsRecordSet{}
+		recordset.DnsType = dnsType
+		recordset.DnsValue = ep.Value
+		err := recordset.ValidateDnsrecordValue()
+		if err != nil {
+			return err
+		}
+		if values[ep.Value] {
+			return httperrors.NewDuplicateResourceError("duplicate failover endpoint %s", ep.Value)
+		}
+		values[ep.Value] = true
+		ep.Healthy = true
+		ep.FailCount = 0
+	}
+	sort.SliceStable(self.Endpoints, func(i, j int) bool {
+		return self.Endpoints[i].Priority < self.Endpoints[j].Priority
+	})
+	switch self.ProbeProtocol {
+	case "":
+		self.ProbeProtocol = DNS_FAILOVER_PROBE_TCP
+	case DNS_FAILOVER_PROBE_TCP, DNS_FAILOVER_PROBE_HTTP, DNS_FAILOVER_PROBE_HTTPS:
+	default:
+		return httperrors.NewInputParameterError("invalid probe_protocol %s", self.ProbeProtocol)
+	}
+	if self.ProbePort == 0 {
+		self.ProbePort = 80
+		if self.ProbeProtocol == DNS_FAILOVER_PROBE_HTTPS {
+			self.ProbePort = 443
+		}
+	}
+	if self.ProbePort < 1 || self.ProbePort > 65535 {
+		return httperrors.NewOutOfRangeError("probe_port range limited to [1,65535]")
+	}
+	if self.ProbeProtocol == DNS_FAILOVER_PROBE_TCP {
+		self.ProbePath = ""
+	} else if len(self.ProbePath) == 0 || self.ProbePath[0] != '/' {
+		self.ProbePath = "/" + self.ProbePath
+	}
+	if self.FailureThreshold <= 0 {
+		self.FailureThreshold = DNS_FAILOVER_DEFAULT_FAILURE_THRESHOLD
+	}
+	return nil
+}
+
+// 返回优先级最高的健康端点, 所有端点都不健康时返回nil
+func (self *SDnsFailover) GetActiveEndpoint() *SDnsFailoverEndpoint {
+	for i := range self.Endpoints {
+		if self.Endpoints[i].Healthy {
+			return &self.Endpoints[i]
+		}
+	}
+	return nil
+}
+
+func (ep SDnsFailoverEndpoint) String() string {
+	if len(ep.Provider) > 0 {
+		return fmt.Sprintf("%s(%s)", ep.Value, ep.Provider)
+	}
+	return ep.Value
+}
+
 type DnsRecordPolicy struct {
 	// 平台
 	Provider      string              `json:"provider"`
@@ -48,6 +177,9 @@ type DnsRecordSetCreateInput struct {
 	MxPriority int64  `json:"mx_priority"`
 
 	TrafficPolicies []DnsRecordPolicy `json:"traffic_policies"`
+
+	// 故障切换配置, 仅支持A, AAAA, CNAME记录
+	Failover *SDnsFailover `json:"failover"`
 }
 
 type DnsRecordSetUpdateInput struct {
@@ -59,6 +191,9 @@ type DnsRecordSetUpdateInput struct {
 	MxPriority *int64 `json:"mx_priority"`
 
 	TrafficPolicies []DnsRecordPolicy
+
+	// 故障切换配置
+	Failover *SDnsFailover `json:"failover"`
 }
 
 type DnsRecordSetDetails struct {
@@ -86,6 +221,19 @@ type DnsRecordSetTrafficPoliciesInput struct {
 	TrafficPolicies []DnsRecordPolicy `json:"traffic_policies"`
 }
 
+type DnsRecordSetFailoverInput struct {
+	SDnsFailover
+
+	// 取消故障切换
+	Clear bool `json:"clear"`
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SDnsFailover{}), func() gotypes.ISerializable {
+		return &SDnsFailover{}
+	})
+}
+
 func (recordset *SDnsRecordSet) ValidateDnsrecordValue() error {
 	domainReg := regexp.MustCompile(`^(([a-zA-Z]{1})|([a-zA-Z]{1}[a-zA-Z]{1})|([a-zA-Z]{1}[0-9]{1})|([0-9]{1}[a-zA-Z]{1})|([a-zA-Z0-9][a-zA-Z0-9-_]{1,61}[a-zA-Z0-9]))\.([a-zA-Z]{2,6}|[a-zA-Z0-9-]{2,30}\.[a-zA-Z]{2,3})$`)
 	switch cloudprovider.TDnsType(recordset.DnsType) {
@@ -35,6 +35,31 @@ type ElasticcacheDetails struct {
 
 	// 备可用区列表
 	SlaveZoneInfos []apis.StandaloneShortDesc `json:"slave_zone_infos"`
+
+	// 所在平台支持的管理操作
+	ManageCapability ElasticcacheManageCapability `json:"manage_capability"`
+}
+
+// 弹性缓存在各平台上支持的管理操作, 不支持的操作仅同步
+type ElasticcacheManageCapability struct {
+	// 创建账号
+	Account bool `json:"account"`
+	// 重置密码
+	ResetPassword bool `json:"reset_password"`
+	// 白名单
+	Acl bool `json:"acl"`
+	// 手动备份
+	Backup bool `json:"backup"`
+	// 修改备份策略
+	BackupPolicy bool `json:"backup_policy"`
+	// 修改参数
+	Parameters bool `json:"parameters"`
+	// 清空数据
+	Flush bool `json:"flush"`
+	// 修改免密访问
+	AuthMode bool `json:"auth_mode"`
+	// 从备份恢复
+	Restore bool `json:"restore"`
 }
 
 func (self ElasticcacheDetails) GetMetricTags() map[string]string {
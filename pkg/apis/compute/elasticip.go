@@ -18,6 +18,7 @@ import "yunion.io/x/onecloud/pkg/apis"
 
 type SElasticipCreateInput struct {
 	apis.VirtualResourceCreateInput
+	ExternalProjectResourceInput
 
 	// 区域名称或Id, 建议使用Id
 	// 在指定区域内创建弹性公网ip
@@ -0,0 +1,64 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+const (
+	ELASTICIP_POOL_STATUS_AVAILABLE = "available"
+)
+
+type ElasticipPoolCreateInput struct {
+	apis.EnabledStatusInfrasResourceBaseCreateInput
+	CloudregionResourceInput
+	CloudproviderResourceInput
+
+	// 池中最多保留的EIP数量, 0表示不限制
+	MaxCount int `json:"max_count"`
+
+	// 每个项目最多保留的EIP数量, 0表示不限制
+	ProjectReserveCount int `json:"project_reserve_count"`
+
+	// 仅保留指定计费类型的EIP, 为空表示不限制
+	// enum: traffic, bandwidth
+	ChargeType string `json:"charge_type"`
+}
+
+type ElasticipPoolUpdateInput struct {
+	apis.EnabledStatusInfrasResourceBaseUpdateInput
+
+	MaxCount            *int `json:"max_count"`
+	ProjectReserveCount *int `json:"project_reserve_count"`
+}
+
+type ElasticipPoolListInput struct {
+	apis.EnabledStatusInfrasResourceBaseListInput
+	ManagedResourceListInput
+	RegionalFilterListInput
+}
+
+type ElasticipPoolDetails struct {
+	apis.EnabledStatusInfrasResourceBaseDetails
+	ManagedResourceInfo
+	CloudregionResourceInfo
+
+	SElasticipPool
+
+	// 池中保留的EIP数量
+	EipCount int `json:"eip_count"`
+}
+
+type ElasticipReleaseToPoolInput struct {
+}
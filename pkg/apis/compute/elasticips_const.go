@@ -69,6 +69,7 @@ type ElasticipListInput struct {
 	ManagedResourceListInput
 	RegionalFilterListInput
 	UsableResourceListInput
+	ExternalProjectFilterListInput
 
 	// filter usable eip for given associate type
 	// enmu: server, natgateway
@@ -103,6 +104,11 @@ type ElasticipListInput struct {
 	// 目前只有华为云此字段是必需填写的
 	BgpType []string `json:"bgp_type"`
 
+	// 所属EIP池
+	PoolId string `json:"pool_id"`
+	// 是否保留在EIP池中
+	InPool *bool `json:"in_pool"`
+
 	// 是否跟随主机删除而自动释放
 	AutoDellocate *bool `json:"auto_dellocate"`
 }
@@ -32,8 +32,26 @@ var (
 	MANGER_EXTERNAL_PROJECT_PROVIDERS = []string{
 		CLOUD_PROVIDER_AZURE,
 	}
+
+	// 创建资源时支持指定云上企业项目/资源组的平台
+	EXTERNAL_PROJECT_SELECTABLE_PROVIDERS = []string{
+		CLOUD_PROVIDER_ALIYUN,
+		CLOUD_PROVIDER_HUAWEI,
+		CLOUD_PROVIDER_AZURE,
+	}
 )
 
+type ExternalProjectResourceInput struct {
+	// 云上企业项目或资源组(ID, 名称或云上ID), 仅阿里云, 华为云及Azure支持
+	// 未指定时使用本地项目映射的云上项目
+	ExternalProjectId string `json:"external_project_id"`
+}
+
+type ExternalProjectFilterListInput struct {
+	// 按云上企业项目或资源组的云上ID过滤
+	ExternalProjectId []string `json:"external_project_id"`
+}
+
 type ExternalProjectDetails struct {
 	apis.VirtualResourceDetails
 	ManagedResourceInfo
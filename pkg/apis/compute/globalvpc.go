@@ -18,11 +18,24 @@ import (
 	"yunion.io/x/onecloud/pkg/apis"
 )
 
+const (
+	GLOBAL_VPC_DEFAULT_PREFIX_LEN = 16
+	GLOBAL_VPC_MAX_PREFIX_LEN     = 29
+)
+
 type GlobalVpcCreateInput struct {
 	apis.EnabledStatusInfrasResourceBaseCreateInput
 
 	// 目前仅支持谷歌云创建
 	CloudproviderResourceInput
+
+	// 地址规划, 成员vpc的网段需在此范围内, 多个网段以逗号分隔
+	// example: 10.0.0.0/8,172.16.0.0/12
+	AddressPlan string `json:"address_plan"`
+
+	// 自动分配成员vpc网段时的默认掩码长度
+	// default: 16
+	AddressPlanPrefixLen int `json:"address_plan_prefix_len"`
 }
 
 type GlobalVpcDetails struct {
@@ -58,6 +71,46 @@ type GlobalVpcResourceListInput struct {
 
 type GlobalvpcUpdateInput struct {
 	apis.EnabledStatusInfrasResourceBaseUpdateInput
+
+	// 地址规划, 需包含所有已有成员vpc的网段
+	AddressPlan *string `json:"address_plan"`
+
+	// 自动分配成员vpc网段时的默认掩码长度
+	AddressPlanPrefixLen *int `json:"address_plan_prefix_len"`
+}
+
+type GlobalVpcAddVpcInput struct {
+	// 加入全局vpc的vpc, 可来自不同云平台
+	// required: true
+	VpcId string `json:"vpc_id"`
+}
+
+type GlobalVpcRemoveVpcInput struct {
+	// required: true
+	VpcId string `json:"vpc_id"`
+}
+
+type GlobalVpcAddressPlanInput struct {
+	// 预分配网段的掩码长度, 默认使用全局vpc的address_plan_prefix_len
+	PrefixLen int `json:"prefix_len"`
+}
+
+type GlobalVpcAddressPlanUsage struct {
+	VpcId     string `json:"vpc_id"`
+	Vpc       string `json:"vpc"`
+	Provider  string `json:"provider"`
+	CidrBlock string `json:"cidr_block"`
+}
+
+type GlobalVpcAddressPlanDetails struct {
+	AddressPlan string `json:"address_plan"`
+	PrefixLen   int    `json:"prefix_len"`
+
+	// 成员vpc已占用的网段
+	Used []GlobalVpcAddressPlanUsage `json:"used"`
+
+	// 下一个可分配的网段, 地址规划耗尽时为空
+	NextCidr string `json:"next_cidr"`
 }
 
 type GlobalVpcListInput struct {
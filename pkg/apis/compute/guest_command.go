@@ -0,0 +1,70 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	GUEST_COMMAND_STATUS_PENDING = "pending"
+	GUEST_COMMAND_STATUS_RUNNING = "running"
+	GUEST_COMMAND_STATUS_SUCCESS = "success"
+	GUEST_COMMAND_STATUS_FAILED  = "failed"
+	GUEST_COMMAND_STATUS_TIMEOUT = "timeout"
+
+	GUEST_COMMAND_TYPE_SHELL      = "shell"
+	GUEST_COMMAND_TYPE_POWERSHELL = "powershell"
+
+	// KVM主机通过qemu-guest-agent执行
+	GUEST_COMMAND_CHANNEL_QGA = "qga"
+)
+
+var GUEST_COMMAND_TYPES = []string{GUEST_COMMAND_TYPE_SHELL, GUEST_COMMAND_TYPE_POWERSHELL}
+
+type ServerRunCommandInput struct {
+	// 命令类型, 默认Windows为powershell, 其他为shell
+	// enum: ["shell", "powershell"]
+	CommandType string `json:"command_type"`
+	// 执行的脚本内容
+	Script string `json:"script"`
+	// 超时时间(秒), 默认60
+	Timeout int `json:"timeout"`
+}
+
+type GuestCommandInvocationListInput struct {
+	apis.StatusStandaloneResourceListInput
+	apis.ProjectizedResourceListInput
+	ServerFilterListInput
+
+	// 执行通道
+	// enum: ["qga"]
+	Channel []string `json:"channel"`
+	// 命令类型
+	CommandType []string `json:"command_type"`
+	// 执行人
+	Operator string `json:"operator"`
+}
+
+type GuestCommandInvocationDetails struct {
+	apis.StatusStandaloneResourceDetails
+	apis.ProjectizedResourceInfo
+	GuestResourceInfo
+
+	SGuestCommandInvocation
+
+	// 执行耗时(秒)
+	Duration int `json:"duration"`
+}
@@ -379,6 +379,9 @@ const (
 	VM_METADATA_OS_VERSION          = "os_version"
 	VM_METADATA_CGROUP_CPUSET       = "cgroup_cpuset"
 	VM_METADATA_ENABLE_MEMCLEAN     = "enable_memclean"
+	VM_METADATA_USER_DATA           = "user_data"
+	// 下发到云上的最终 user data, 用于审计
+	VM_METADATA_RENDERED_USER_DATA = "__rendered_user_data"
 )
 
 func Hypervisors2HostTypes(hypervisors []string) []string {
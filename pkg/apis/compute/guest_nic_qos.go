@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+const (
+	// 不支持网卡限速
+	NIC_QOS_MODE_NONE = "none"
+	// 宿主机上通过tc限速, 如KVM
+	NIC_QOS_MODE_TC = "tc"
+	// 通过调整绑定的弹性公网IP带宽限速, 如阿里云、华为云、腾讯云
+	NIC_QOS_MODE_EIP = "eip"
+)
+
+type ServerSetNicQosInput struct {
+	// 网卡IP地址
+	IpAddr string `json:"ip_addr"`
+	// 网卡MAC地址
+	Mac string `json:"mac"`
+	// 网卡序号, 未指定IP及MAC时生效
+	Index int `json:"index"`
+
+	// 带宽限制, 单位Mbps, 0表示不限速
+	BandwidthMb int `json:"bandwidth_mb"`
+}
+
+type ServerNicQos struct {
+	Index       int    `json:"index"`
+	IpAddr      string `json:"ip_addr"`
+	Mac         string `json:"mac"`
+	BandwidthMb int    `json:"bandwidth_mb"`
+}
+
+type ServerNicQosInput struct {
+}
+
+type ServerNicQosOutput struct {
+	// 限速方式
+	// enum: none, tc, eip
+	Mode      string         `json:"mode"`
+	Supported bool           `json:"supported"`
+	Nics      []ServerNicQos `json:"nics"`
+	// 限速方式为eip时, 绑定的弹性公网IP带宽
+	EipBandwidthMb int `json:"eip_bandwidth_mb"`
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+const (
+	// 可用: 运行中
+	GUEST_AVAILABILITY_UP = "up"
+	// 不可用: 状态未知或启动失败
+	GUEST_AVAILABILITY_DOWN = "down"
+	// 不计入SLA: 用户关机、操作过程中等状态
+	GUEST_AVAILABILITY_EXCLUDED = "excluded"
+
+	GUEST_AVAILABILITY_SOURCE_LOCAL = "local"
+	GUEST_AVAILABILITY_SOURCE_SYNC  = "sync"
+
+	GUEST_SLA_GROUP_BY_GUEST   = "guest"
+	GUEST_SLA_GROUP_BY_PROJECT = "project"
+)
+
+var (
+	GUEST_SLA_DOWN_STATUS = []string{VM_UNKNOWN, VM_START_FAILED}
+)
+
+type GuestSlaReportInput struct {
+	ServerListInput
+
+	// 统计月份, 格式为 2006-01, 默认为当月
+	Month string `json:"month"`
+	// 聚合维度
+	// enum: ["guest", "project"]
+	GroupBy string `json:"group_by"`
+}
+
+type GuestSla struct {
+	// 虚拟机或项目ID
+	Id string `json:"id"`
+	// 虚拟机或项目名称
+	Name string `json:"name"`
+
+	ProjectId string `json:"tenant_id"`
+	Project   string `json:"tenant"`
+
+	// 统计的虚拟机数量
+	GuestCount int `json:"guest_count"`
+	// 可用时长, 单位秒
+	UpSeconds int64 `json:"up_seconds"`
+	// 不可用时长, 单位秒
+	DownSeconds int64 `json:"down_seconds"`
+	// 不计入SLA的时长, 单位秒
+	ExcludedSeconds int64 `json:"excluded_seconds"`
+	// 可用状态变化次数
+	Transitions int `json:"transitions"`
+	// 可用率百分比, 可用时长 / (可用时长 + 不可用时长)
+	UptimePercent float64 `json:"uptime_percent"`
+}
+
+type GuestSlaReportOutput struct {
+	Month   string     `json:"month"`
+	GroupBy string     `json:"group_by"`
+	Data    []GuestSla `json:"data"`
+}
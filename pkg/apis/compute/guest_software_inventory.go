@@ -0,0 +1,122 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	GUEST_SOFTWARE_INVENTORY_STATUS_COLLECTING = "collecting"
+	GUEST_SOFTWARE_INVENTORY_STATUS_READY      = "ready"
+	GUEST_SOFTWARE_INVENTORY_STATUS_FAILED     = "failed"
+
+	// 通过qemu-guest-agent采集
+	GUEST_SOFTWARE_INVENTORY_SOURCE_QGA = "qga"
+	// 仅通过云平台接口获取操作系统信息
+	GUEST_SOFTWARE_INVENTORY_SOURCE_PROVIDER = "provider"
+
+	GUEST_OS_OUTDATED_REASON_EOL        = "os_eol"
+	GUEST_OS_OUTDATED_REASON_PATCH      = "patch_outdated"
+	GUEST_OS_OUTDATED_REASON_NO_UPDATES = "no_update_info"
+)
+
+// 已安装的更新或软件包
+type SGuestInstalledUpdate struct {
+	// 补丁编号或软件包名称
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// 安装时间
+	InstalledAt time.Time `json:"installed_at,omitempty"`
+}
+
+type SGuestInstalledUpdates []SGuestInstalledUpdate
+
+func (updates SGuestInstalledUpdates) String() string {
+	return jsonutils.Marshal(updates).String()
+}
+
+func (updates SGuestInstalledUpdates) IsZero() bool {
+	return len(updates) == 0
+}
+
+type GuestSoftwareInventoryListInput struct {
+	apis.StatusStandaloneResourceListInput
+	apis.ProjectizedResourceListInput
+	ServerFilterListInput
+
+	// 采集来源
+	// enum: ["qga", "provider"]
+	Source []string `json:"source"`
+	// 操作系统发行版
+	OsDistribution []string `json:"os_distribution"`
+	// 操作系统版本
+	OsVersion []string `json:"os_version"`
+	// 内核版本
+	KernelVersion []string `json:"kernel_version"`
+	// 已安装指定的补丁或软件包
+	InstalledUpdate string `json:"installed_update"`
+}
+
+type GuestSoftwareInventoryDetails struct {
+	apis.StatusStandaloneResourceDetails
+	apis.ProjectizedResourceInfo
+	GuestResourceInfo
+
+	SGuestSoftwareInventory
+
+	// 过时原因, 为空表示未过时
+	OutdatedReasons []string `json:"outdated_reasons"`
+}
+
+type ServerCollectSoftwareInventoryInput struct {
+}
+
+type GuestOsOutdatedItem struct {
+	GuestId        string    `json:"guest_id"`
+	Guest          string    `json:"guest"`
+	OsDistribution string    `json:"os_distribution"`
+	OsVersion      string    `json:"os_version"`
+	KernelVersion  string    `json:"kernel_version"`
+	LastUpdateAt   time.Time `json:"last_update_at"`
+	Reasons        []string  `json:"reasons"`
+}
+
+type GuestOsDistributionStat struct {
+	OsDistribution string `json:"os_distribution"`
+	OsVersion      string `json:"os_version"`
+	Count          int    `json:"count"`
+	Outdated       int    `json:"outdated"`
+}
+
+// 操作系统过时报告
+type GuestOsOutdatedReport struct {
+	Total         int                       `json:"total"`
+	Outdated      int                       `json:"outdated"`
+	Distributions []GuestOsDistributionStat `json:"distributions"`
+	Items         []GuestOsOutdatedItem     `json:"items"`
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SGuestInstalledUpdates{}), func() gotypes.ISerializable {
+		return &SGuestInstalledUpdates{}
+	})
+}
@@ -41,6 +41,7 @@ type ServerListInput struct {
 
 	GroupFilterListInput
 	SecgroupFilterListInput
+	ExternalProjectFilterListInput
 	//DiskFilterListInput `yunion-ambiguous-prefix:"storage_"`
 	ScalingGroupFilterListInput
 
@@ -696,6 +697,13 @@ type ServerUserDataInput struct {
 	UserData string `json:"user_data"`
 }
 
+type ServerUserDataOutput struct {
+	// 用户设置的 user data
+	UserData string `json:"user_data"`
+	// 按平台渲染后实际下发的 user data
+	RenderedUserData string `json:"rendered_user_data"`
+}
+
 type ServerAttachDiskInput struct {
 	DiskId string `json:"disk_id"`
 
@@ -971,3 +979,30 @@ type ServerSetLiveMigrateParamsInput struct {
 	MaxBandwidthMB  *int64
 	DowntimeLimitMS *int64
 }
+
+type ServerBatchChangeProjectInput struct {
+	apis.PerformChangeProjectOwnerInput
+
+	// 待更换项目的虚拟机ID或名称
+	// required: true
+	Servers []string `json:"servers"`
+	// 仅检查依赖资源, 不实际更换项目
+	// 实际更换时资源逐个更换项目, 不保证原子性, 中途出错时尽力回滚已更换的资源
+	DryRun bool `json:"dry_run"`
+}
+
+type ServerChangeProjectResource struct {
+	// 资源类型, 例如 server, disk, eip, instance_snapshot, secgroup
+	ResourceType string `json:"resource_type"`
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	// 阻止更换项目的原因
+	Reason string `json:"reason,omitempty"`
+}
+
+type ServerBatchChangeProjectOutput struct {
+	// 随虚拟机一起更换项目的资源, dry_run时为计划更换的资源
+	Moved []ServerChangeProjectResource `json:"moved"`
+	// 阻止更换项目的依赖资源, 不为空时不会开始更换
+	Blockers []ServerChangeProjectResource `json:"blockers"`
+}
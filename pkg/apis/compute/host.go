@@ -226,6 +226,9 @@ type HostDetails struct {
 	SysWarn string `json:"sys_warn"`
 	// host init error info
 	SysError string `json:"sys_error"`
+
+	// 虚拟化平台版本是否已停止支持
+	VersionEol bool `json:"version_eol"`
 }
 
 func (self HostDetails) GetMetricTags() map[string]string {
@@ -524,3 +527,19 @@ type HostAutoMigrateInput struct {
 	AutoMigrateOnHostDown     string `json:"auto_migrate_on_host_down"`
 	AutoMigrateOnHostShutdown string `json:"auto_migrate_on_host_shutdown"`
 }
+
+type HostEnterMaintenanceInput struct {
+	// 迁移目标宿主机, 不指定时自动选择同一平台同一可用区内虚拟机最少的宿主机
+	PreferHost string `json:"prefer_host"`
+}
+
+type HostMaintenanceGuest struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	TargetHostId string `json:"target_host_id"`
+	LiveMigrate  bool   `json:"live_migrate"`
+
+	// pending, migrating, migrated, failed
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
@@ -160,6 +160,28 @@ const (
 const (
 	HOSTMETA_AUTO_MIGRATE_ON_HOST_DOWN     = "__auto_migrate_on_host_down"
 	HOSTMETA_AUTO_MIGRATE_ON_HOST_SHUTDOWN = "__auto_migrate_on_host_shutdown"
+
+	// 纳管宿主机在云平台上首次被检测到异常的时间
+	HOSTMETA_MANAGED_HOST_DOWN_AT = "__managed_host_down_at"
+	// 纳管宿主机宕机后已执行HA处理的时间, 宿主机恢复前不再重复处理
+	HOSTMETA_MANAGED_HOST_HA_AT = "__managed_host_ha_at"
+)
+
+const (
+	HOST_MAINTENANCE_GUEST_PENDING   = "pending"
+	HOST_MAINTENANCE_GUEST_MIGRATING = "migrating"
+	HOST_MAINTENANCE_GUEST_MIGRATED  = "migrated"
+	HOST_MAINTENANCE_GUEST_FAILED    = "failed"
+)
+
+const (
+	// 通过BMC(Redfish)对托管宿主机执行的电源操作
+	HOST_BMC_POWER_ON    = "on"
+	HOST_BMC_POWER_OFF   = "off"
+	HOST_BMC_POWER_RESET = "reset"
+
+	HOST_BMC_POWERING   = "bmc_powering"
+	HOST_BMC_POWER_FAIL = "bmc_power_fail"
 )
 
 const (
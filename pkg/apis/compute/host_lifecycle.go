@@ -0,0 +1,39 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+type HostEolItem struct {
+	HostId    string `json:"host_id"`
+	Host      string `json:"host"`
+	HostType  string `json:"host_type"`
+	ManagerId string `json:"manager_id"`
+	Version   string `json:"version"`
+}
+
+type HostVersionStat struct {
+	HostType string `json:"host_type"`
+	// 归一化后的主版本号, 如 ESXi 6.7, Proxmox VE 7.4
+	Version string `json:"version"`
+	Count   int    `json:"count"`
+	Eol     bool   `json:"eol"`
+}
+
+// 宿主机虚拟化平台版本生命周期报告
+type HostEolReport struct {
+	Total    int               `json:"total"`
+	Eol      int               `json:"eol"`
+	Versions []HostVersionStat `json:"versions"`
+	Items    []HostEolItem     `json:"items"`
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	IDLE_RESOURCE_TYPE_EIP          = "eip"
+	IDLE_RESOURCE_TYPE_DISK         = "disk"
+	IDLE_RESOURCE_TYPE_LOADBALANCER = "loadbalancer"
+	IDLE_RESOURCE_TYPE_SERVER       = "server"
+
+	// 未绑定的EIP
+	IDLE_REASON_UNASSOCIATED = "unassociated"
+	// 未挂载的磁盘
+	IDLE_REASON_UNATTACHED = "unattached"
+	// 没有后端服务器的负载均衡
+	IDLE_REASON_NO_BACKEND = "no_backend"
+	// 长时间关机的虚拟机
+	IDLE_REASON_LONG_STOPPED = "long_stopped"
+
+	// 已发现
+	IDLE_RESOURCE_STATUS_DETECTED = "detected"
+	// 已申请清理, 等待审批
+	IDLE_RESOURCE_STATUS_CLEANUP_PENDING = "cleanup_pending"
+	IDLE_RESOURCE_STATUS_CLEANING        = "cleaning"
+	IDLE_RESOURCE_STATUS_CLEANUP_FAILED  = "cleanup_failed"
+	IDLE_RESOURCE_STATUS_CLEANED         = "cleaned"
+	// 已忽略, 不再提示
+	IDLE_RESOURCE_STATUS_IGNORED = "ignored"
+)
+
+var (
+	IDLE_RESOURCE_TYPES = []string{
+		IDLE_RESOURCE_TYPE_EIP,
+		IDLE_RESOURCE_TYPE_DISK,
+		IDLE_RESOURCE_TYPE_LOADBALANCER,
+		IDLE_RESOURCE_TYPE_SERVER,
+	}
+)
+
+type IdleResourceListInput struct {
+	apis.StatusStandaloneResourceListInput
+	apis.ProjectizedResourceListInput
+	ManagedResourceListInput
+
+	// 资源类型
+	// enum: ["eip", "disk", "loadbalancer", "server"]
+	ResourceType []string `json:"resource_type"`
+	// 资源ID
+	ResourceId []string `json:"resource_id"`
+	// 闲置原因
+	Reason []string `json:"reason"`
+}
+
+type IdleResourceDetails struct {
+	apis.StatusStandaloneResourceDetails
+	apis.ProjectizedResourceInfo
+	ManagedResourceInfo
+
+	SIdleResource
+
+	// 闲置天数
+	IdleDays int `json:"idle_days"`
+}
+
+type IdleResourceRequestCleanupInput struct {
+	// 申请原因
+	Reason string `json:"reason"`
+}
+
+type IdleResourceApproveCleanupInput struct {
+}
+
+type IdleResourceRejectCleanupInput struct {
+	Reason string `json:"reason"`
+}
+
+type IdleResourceIgnoreInput struct {
+}
+
+type IdleResourceBatchRequestCleanupInput struct {
+	// 闲置资源ID列表, 为空时对所有可见的已发现闲置资源申请清理
+	IdleResources []string `json:"idle_resources"`
+
+	// 闲置资源类型
+	ResourceType []string `json:"resource_type"`
+
+	Reason string `json:"reason"`
+}
+
+type IdleResourceWasteItem struct {
+	ResourceType string `json:"resource_type"`
+	Reason       string `json:"reason"`
+	Count        int    `json:"count"`
+	// 按币种汇总的上月费用
+	LastMonthCost map[string]float64 `json:"last_month_cost"`
+}
+
+type IdleResourceWasteProject struct {
+	ProjectId string `json:"tenant_id"`
+	Project   string `json:"tenant"`
+	Count     int    `json:"count"`
+
+	LastMonthCost map[string]float64 `json:"last_month_cost"`
+}
+
+type IdleResourceWasteOutput struct {
+	// 最近一次检测时间
+	DetectedAt time.Time `json:"detected_at"`
+
+	Total    int                        `json:"total"`
+	Items    []IdleResourceWasteItem    `json:"items"`
+	Projects []IdleResourceWasteProject `json:"projects"`
+
+	LastMonthCost map[string]float64 `json:"last_month_cost"`
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	IMAGE_CATALOG_STATUS_PUBLISHED   = "published"
+	IMAGE_CATALOG_STATUS_UNPUBLISHED = "unpublished"
+)
+
+// 镜像目录在各平台的镜像映射
+type SImageCatalogMapping struct {
+	// 平台, 例如 Aliyun, Aws, OneCloud
+	Provider string `json:"provider"`
+	// 区域ID, 为空表示该平台所有区域通用, 创建时可指定名称
+	CloudregionId string `json:"cloudregion_id"`
+	// 镜像ID, 公有云为同步的缓存镜像ID, 创建时可指定名称
+	ImageId string `json:"image_id"`
+}
+
+type SImageCatalogMappings []SImageCatalogMapping
+
+func (mappings SImageCatalogMappings) String() string {
+	return jsonutils.Marshal(mappings).String()
+}
+
+func (mappings SImageCatalogMappings) IsZero() bool {
+	return len(mappings) == 0
+}
+
+type ImageCatalogCreateInput struct {
+	apis.StatusInfrasResourceBaseCreateInput
+
+	// 本地镜像(ID或Name), 用于本地IDC创建主机
+	ImageId string `json:"image_id"`
+
+	OsType         string `json:"os_type"`
+	OsDistribution string `json:"os_distribution"`
+	OsVersion      string `json:"os_version"`
+	OsArch         string `json:"os_arch"`
+
+	// 各平台镜像映射
+	Mappings []SImageCatalogMapping `json:"mappings"`
+}
+
+type ImageCatalogUpdateInput struct {
+	apis.StatusInfrasResourceBaseUpdateInput
+
+	// 本地镜像(ID或Name)
+	ImageId *string `json:"image_id"`
+	// 各平台镜像映射, 整体替换
+	Mappings []SImageCatalogMapping `json:"mappings"`
+}
+
+type ImageCatalogListInput struct {
+	apis.StatusInfrasResourceBaseListInput
+
+	OsType []string `json:"os_type"`
+	// 仅列出在指定平台可用的镜像目录
+	Provider string `json:"provider"`
+}
+
+type ImageCatalogMappingDetails struct {
+	SImageCatalogMapping
+
+	Cloudregion string `json:"cloudregion"`
+	Image       string `json:"image"`
+}
+
+type ImageCatalogDetails struct {
+	apis.StatusInfrasResourceBaseDetails
+
+	SImageCatalog
+
+	// 本地镜像名称
+	Image          string                       `json:"image"`
+	MappingDetails []ImageCatalogMappingDetails `json:"mapping_details"`
+}
+
+type ImageCatalogPublishInput struct {
+	// 发布范围, 为空时保持当前共享设置
+	apis.PerformPublicDomainInput
+}
+
+type ImageCatalogUnpublishInput struct {
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SImageCatalogMappings{}), func() gotypes.ISerializable {
+		return &SImageCatalogMappings{}
+	})
+}
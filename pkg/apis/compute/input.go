@@ -164,6 +164,9 @@ type SnapshotPolicyListInput struct {
 
 	// 是否启用？
 	IsActivated *bool `json:"is_activated"`
+
+	// 快照对象类型
+	ResourceType []string `json:"resource_type"`
 }
 
 type DnsRecordDetails struct {
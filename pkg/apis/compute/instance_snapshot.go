@@ -80,3 +80,46 @@ type InstanceSnapshotListInput struct {
 	// 包含内存快照
 	WithMemory *bool `json:"with_memory"`
 }
+
+type InstanceSnapshotNicMapping struct {
+	// 主机快照中的网卡序号
+	Index int `json:"index"`
+
+	// 目标网络名称或ID
+	Network string `json:"network"`
+
+	// 目标网络中的IPv4地址, 为空时自动分配
+	Address string `json:"address"`
+}
+
+type InstanceSnapshotCreateServerInput struct {
+	// 新主机名称
+	Name         string `json:"name"`
+	GenerateName string `json:"generate_name"`
+
+	// 目标云账号, 与源主机不同时通过镜像跨平台恢复
+	PreferManager string `json:"prefer_manager_id"`
+
+	// 目标区域
+	PreferRegion string `json:"prefer_region_id"`
+
+	// 目标可用区, 与源主机不同时通过镜像跨可用区恢复
+	PreferZone string `json:"prefer_zone_id"`
+
+	// 目标虚拟化平台, 默认与源主机一致
+	Hypervisor string `json:"hypervisor"`
+
+	// 默认与主机快照一致
+	VcpuCount    int    `json:"vcpu_count"`
+	VmemSize     int    `json:"vmem_size"`
+	InstanceType string `json:"instance_type"`
+
+	// 网卡映射, 按网卡序号替换主机快照中记录的网络
+	NicMappings []InstanceSnapshotNicMapping `json:"nic_mappings"`
+
+	// 丢弃未指定映射的网卡, 跨可用区恢复时未映射的网卡必须丢弃
+	DropUnmappedNics bool `json:"drop_unmapped_nics"`
+
+	// 创建后是否自动启动
+	AutoStart bool `json:"auto_start"`
+}
@@ -71,6 +71,14 @@ type InterVpcNetworkRemoveVpcInput struct {
 	VpcId string
 }
 
+// 已加入vpc互联的vpc之间或与路由之间的网段冲突
+type InterVpcNetworkCidrConflict struct {
+	Cidr         string `json:"cidr"`
+	Source       string `json:"source"`
+	ConflictCidr string `json:"conflict_cidr"`
+	ConflictWith string `json:"conflict_with"`
+}
+
 type InterVpcNetworkFilterListBase struct {
 	InterVpcNetworkId string `json:"inter_vpc_network_id"`
 }
@@ -56,6 +56,8 @@ type LoadbalancerListInput struct {
 	ChargeType []string `json:"charge_type"`
 	// 套餐名称
 	LoadbalancerSpec []string `json:"loadbalancer_spec"`
+	// 创建负载均衡的蓝图ID
+	BlueprintId string `json:"blueprint_id"`
 
 	// filter for EIP
 	WithEip                  *bool  `json:"with_eip"`
@@ -92,6 +94,29 @@ type LoadbalancerDetails struct {
 	BackendGroup string `json:"backend_group"`
 }
 
+func (self LoadbalancerDetails) GetMetricTags() map[string]string {
+	ret := map[string]string{
+		"id":             self.Id,
+		"lb_id":          self.Id,
+		"lb_name":        self.Name,
+		"lb_address":     self.Address,
+		"zone":           self.Zone,
+		"zone_id":        self.ZoneId,
+		"zone_ext_id":    self.ZoneExtId,
+		"status":         self.Status,
+		"cloudregion":    self.Cloudregion,
+		"cloudregion_id": self.SLoadbalancer.CloudregionId,
+		"region_ext_id":  self.RegionExtId,
+		"tenant":         self.Project,
+		"tenant_id":      self.ProjectId,
+		"brand":          self.Brand,
+		"domain_id":      self.DomainId,
+		"project_domain": self.ProjectDomain,
+		"external_id":    self.ExternalId,
+	}
+	return ret
+}
+
 type LoadbalancerResourceInfo struct {
 	// 负载均衡名称
 	Loadbalancer string `json:"loadbalancer"`
@@ -0,0 +1,259 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+// 负载均衡蓝图中的监听, 与平台无关
+type SLoadbalancerBlueprintListener struct {
+	// enum: tcp, udp, http, https
+	ListenerType string `json:"listener_type"`
+	ListenerPort int    `json:"listener_port"`
+	// 后端服务器端口, 默认与监听端口相同
+	BackendPort int `json:"backend_port"`
+
+	Scheduler                  string `json:"scheduler"`
+	StickySession              string `json:"sticky_session"`
+	StickySessionType          string `json:"sticky_session_type"`
+	StickySessionCookie        string `json:"sticky_session_cookie"`
+	StickySessionCookieTimeout int    `json:"sticky_session_cookie_timeout"`
+	XForwardedFor              *bool  `json:"x_forwarded_for"`
+	Gzip                       bool   `json:"gzip"`
+
+	// https监听使用的证书ID
+	CertificateId   string `json:"certificate_id"`
+	TLSCipherPolicy string `json:"tls_cipher_policy"`
+	EnableHttp2     *bool  `json:"enable_http2"`
+
+	HealthCheck         string `json:"health_check"`
+	HealthCheckType     string `json:"health_check_type"`
+	HealthCheckDomain   string `json:"health_check_domain"`
+	HealthCheckPath     string `json:"health_check_path"`
+	HealthCheckHttpCode string `json:"health_check_http_code"`
+	HealthCheckRise     int    `json:"health_check_rise"`
+	HealthCheckFail     int    `json:"health_check_fail"`
+	HealthCheckTimeout  int    `json:"health_check_timeout"`
+	HealthCheckInterval int    `json:"health_check_interval"`
+}
+
+func (self SLoadbalancerBlueprintListener) GetKey() string {
+	return fmt.Sprintf("%s:%d", self.ListenerType, self.ListenerPort)
+}
+
+// 转换为监听创建参数, 用于校验及实例化
+func (self SLoadbalancerBlueprintListener) ToCreateInput() LoadbalancerListenerCreateInput {
+	input := LoadbalancerListenerCreateInput{
+		ListenerType:               self.ListenerType,
+		ListenerPort:               self.ListenerPort,
+		Scheduler:                  self.Scheduler,
+		StickySession:              self.StickySession,
+		StickySessionType:          self.StickySessionType,
+		StickySessionCookie:        self.StickySessionCookie,
+		StickySessionCookieTimeout: self.StickySessionCookieTimeout,
+		XForwardedFor:              self.XForwardedFor,
+		Gzip:                       self.Gzip,
+		CertificateId:              self.CertificateId,
+		TLSCipherPolicy:            self.TLSCipherPolicy,
+		EnableHttp2:                self.EnableHttp2,
+		HealthCheck:                self.HealthCheck,
+		HealthCheckType:            self.HealthCheckType,
+		HealthCheckDomain:          self.HealthCheckDomain,
+		HealthCheckPath:            self.HealthCheckPath,
+		HealthCheckHttpCode:        self.HealthCheckHttpCode,
+		HealthCheckRise:            self.HealthCheckRise,
+		HealthCheckFail:            self.HealthCheckFail,
+		HealthCheckTimeout:         self.HealthCheckTimeout,
+		HealthCheckInterval:        self.HealthCheckInterval,
+	}
+	input.Name = fmt.Sprintf("%s-%d", self.ListenerType, self.ListenerPort)
+	return input
+}
+
+// 转换为监听更新参数, 用于将蓝图变更批量应用到已实例化的监听
+func (self SLoadbalancerBlueprintListener) ToUpdateInput() LoadbalancerListenerUpdateInput {
+	input := LoadbalancerListenerUpdateInput{
+		Scheduler:           &self.Scheduler,
+		StickySession:       &self.StickySession,
+		XForwardedFor:       self.XForwardedFor,
+		Gzip:                &self.Gzip,
+		EnableHttp2:         self.EnableHttp2,
+		HealthCheck:         &self.HealthCheck,
+		HealthCheckType:     &self.HealthCheckType,
+		HealthCheckPath:     &self.HealthCheckPath,
+		HealthCheckHttpCode: &self.HealthCheckHttpCode,
+	}
+	if len(self.StickySessionType) > 0 {
+		input.StickySessionType = &self.StickySessionType
+	}
+	if len(self.StickySessionCookie) > 0 {
+		input.StickySessionCookie = &self.StickySessionCookie
+	}
+	if self.StickySessionCookieTimeout > 0 {
+		input.StickySessionCookieTimeout = &self.StickySessionCookieTimeout
+	}
+	if len(self.CertificateId) > 0 {
+		input.CertificateId = &self.CertificateId
+	}
+	if len(self.TLSCipherPolicy) > 0 {
+		input.TLSCipherPolicy = &self.TLSCipherPolicy
+	}
+	if self.HealthCheckRise > 0 {
+		input.HealthCheckRise = &self.HealthCheckRise
+	}
+	if self.HealthCheckFail > 0 {
+		input.HealthCheckFail = &self.HealthCheckFail
+	}
+	if self.HealthCheckTimeout > 0 {
+		input.HealthCheckTimeout = &self.HealthCheckTimeout
+	}
+	if self.HealthCheckInterval > 0 {
+		input.HealthCheckInterval = &self.HealthCheckInterval
+	}
+	return input
+}
+
+// 负载均衡蓝图中的后端服务器
+type SLoadbalancerBlueprintBackend struct {
+	// 虚拟机ID, 实例化时只会添加与负载均衡位于同一区域及云账号的虚拟机
+	ServerId string `json:"server_id"`
+	// default: 100
+	Weight int `json:"weight"`
+}
+
+type SLoadbalancerBlueprintSpec struct {
+	Listeners []SLoadbalancerBlueprintListener `json:"listeners"`
+	Backends  []SLoadbalancerBlueprintBackend  `json:"backends"`
+}
+
+func (self SLoadbalancerBlueprintSpec) String() string {
+	return jsonutils.Marshal(self).String()
+}
+
+func (self SLoadbalancerBlueprintSpec) IsZero() bool {
+	return len(self.Listeners) == 0 && len(self.Backends) == 0
+}
+
+func (self *SLoadbalancerBlueprintSpec) Validate() error {
+	if len(self.Listeners) == 0 {
+		return httperrors.NewMissingParameterError("listeners")
+	}
+	keys := map[string]bool{}
+	for i := range self.Listeners {
+		listener := &self.Listeners[i]
+		if listener.ListenerPort < 1 || listener.ListenerPort > 65535 {
+			return httperrors.NewInputParameterError("listener %d: invalid listener_port %d", i, listener.ListenerPort)
+		}
+		if listener.BackendPort == 0 {
+			listener.BackendPort = listener.ListenerPort
+		}
+		if listener.BackendPort < 1 || listener.BackendPort > 65535 {
+			return httperrors.NewInputParameterError("listener %d: invalid backend_port %d", i, listener.BackendPort)
+		}
+		if len(listener.Scheduler) == 0 {
+			listener.Scheduler = LB_SCHEDULER_RR
+		}
+		input := listener.ToCreateInput()
+		err := input.Validate()
+		if err != nil {
+			return httperrors.NewInputParameterError("listener %d: %v", i, err)
+		}
+		if listener.ListenerType == LB_LISTENER_TYPE_HTTPS && len(listener.CertificateId) == 0 {
+			return httperrors.NewInputParameterError("listener %d: certificate_id is required for https listener", i)
+		}
+		if keys[listener.GetKey()] {
+			return httperrors.NewDuplicateResourceError("listener %s", listener.GetKey())
+		}
+		keys[listener.GetKey()] = true
+	}
+	for i := range self.Backends {
+		if len(self.Backends[i].ServerId) == 0 {
+			return httperrors.NewInputParameterError("backend %d: missing server_id", i)
+		}
+		if self.Backends[i].Weight == 0 {
+			self.Backends[i].Weight = 100
+		}
+		if self.Backends[i].Weight < 0 || self.Backends[i].Weight > 256 {
+			return httperrors.NewInputParameterError("backend %d: invalid weight %d", i, self.Backends[i].Weight)
+		}
+	}
+	return nil
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SLoadbalancerBlueprintSpec{}), func() gotypes.ISerializable {
+		return &SLoadbalancerBlueprintSpec{}
+	})
+}
+
+type LoadbalancerBlueprintCreateInput struct {
+	apis.SharableVirtualResourceCreateInput
+
+	// 蓝图定义
+	// required: true
+	Spec SLoadbalancerBlueprintSpec `json:"spec"`
+}
+
+type LoadbalancerBlueprintUpdateInput struct {
+	apis.SharableVirtualResourceBaseUpdateInput
+
+	// 蓝图定义, 更新后可通过sync操作批量应用到由此蓝图创建的负载均衡
+	Spec *SLoadbalancerBlueprintSpec `json:"spec"`
+}
+
+type LoadbalancerBlueprintListInput struct {
+	apis.SharableVirtualResourceListInput
+}
+
+type LoadbalancerBlueprintDetails struct {
+	apis.SharableVirtualResourceDetails
+	SLoadbalancerBlueprint
+
+	// 由此蓝图创建的负载均衡数量
+	LoadbalancerCount int `json:"loadbalancer_count"`
+}
+
+type LoadbalancerBlueprintInstantiateInput struct {
+	// 负载均衡创建参数, 包括平台, 区域, vpc, 网络及规格等平台相关信息
+	LoadbalancerCreateInput
+}
+
+type LoadbalancerBlueprintSyncInput struct {
+	// 指定负载均衡, 为空时应用到由此蓝图创建的所有负载均衡
+	LoadbalancerIds []string `json:"loadbalancer_ids"`
+}
+
+type LoadbalancerBlueprintApplyResult struct {
+	LoadbalancerId string `json:"loadbalancer_id"`
+	// 新建的监听
+	Created []string `json:"created"`
+	// 更新的监听
+	Updated []string `json:"updated"`
+	// 因不在同一区域或云账号而未添加的后端服务器
+	SkippedBackends []string `json:"skipped_backends"`
+	Error           string   `json:"error"`
+}
+
+type LoadbalancerBlueprintSyncOutput struct {
+	Results []LoadbalancerBlueprintApplyResult `json:"results"`
+}
@@ -211,6 +211,14 @@ var LB_TLS_CERT_PUBKEY_ALGOS = choices.NewChoices(
 	LB_TLS_CERT_PUBKEY_ALGO_ECDSA,
 )
 
+const (
+	LB_CERT_STATUS_RENEWING     = "renewing"
+	LB_CERT_STATUS_RENEW_FAILED = "renew_failed"
+
+	// 通过用户提供的webhook获取新证书
+	LB_CERT_RENEW_HOOK_WEBHOOK = "webhook"
+)
+
 // TODO may want extra for legacy apps
 const (
 	LB_TLS_CIPHER_POLICY_1_0        = "tls_cipher_policy_1_0"
@@ -30,4 +30,7 @@ type CachedLoadbalancerCertificateListInput struct {
 	ManagedResourceListInput
 	RegionalFilterListInput
 	LoadbalancerCertificateFilterListInput
+
+	// 列出指定天数内过期(包含已过期)的证书缓存
+	ExpireWithinDays *int `json:"expire_within_days"`
 }
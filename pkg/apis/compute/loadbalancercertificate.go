@@ -50,8 +50,27 @@ type LoadbalancerCertificateFilterListInput struct {
 	OrderByCertificate string `json:"order_by_certificate"`
 }
 
+type LoadbalancerCertificateRenewHookInput struct {
+	// 证书续期钩子类型, 为空表示不自动续期
+	// enum: webhook
+	RenewHook *string `json:"renew_hook"`
+	// 续期钩子地址, webhook类型时必须指定, 仅系统管理员可设置
+	RenewHookUrl *string `json:"renew_hook_url"`
+	// 证书过期前多少天自动续期, 为0时使用服务默认值
+	RenewBeforeDays *int `json:"renew_before_days"`
+}
+
 type LoadbalancerCertificateUpdateInput struct {
 	apis.SharableVirtualResourceBaseUpdateInput
+
+	LoadbalancerCertificateRenewHookInput
+}
+
+type LoadbalancerCertificateRenewInput struct {
+	// 新证书内容, 为空时通过续期钩子获取
+	Certificate string `json:"certificate"`
+	// 新证书私钥
+	PrivateKey string `json:"private_key"`
 }
 
 type LoadbalancerCertificateListInput struct {
@@ -64,6 +83,9 @@ type LoadbalancerCertificateListInput struct {
 
 	CommonName              []string `json:"common_name"`
 	SubjectAlternativeNames []string `json:"subject_alternative_names"`
+
+	// 列出指定天数内过期(包含已过期)的证书
+	ExpireWithinDays *int `json:"expire_within_days"`
 }
 
 type LoadbalancerCertificateCreateInput struct {
@@ -87,4 +109,6 @@ type LoadbalancerCertificateCreateInput struct {
 	CommonName string `json:"common_name"`
 	// swagger: ignore
 	SubjectAlternativeNames string `json:"subject_alternative_names"`
+
+	LoadbalancerCertificateRenewHookInput
 }
@@ -152,3 +152,52 @@ func (opts *NatgatewayCreateInput) Params() (jsonutils.JSONObject, error) {
 type NatgatewayDeleteInput struct {
 	Force bool `json:"force"`
 }
+
+type NatDRuleInput struct {
+	// 弹性公网IP名称或Id
+	Eip string `json:"eip"`
+	// swagger:ignore
+	ExternalIp   string `json:"external_ip"`
+	ExternalPort int    `json:"external_port"`
+	InternalIp   string `json:"internal_ip"`
+	InternalPort int    `json:"internal_port"`
+	// enum: tcp, udp, any
+	IpProtocol string `json:"ip_protocol"`
+}
+
+type NatSRuleInput struct {
+	// 弹性公网IP名称或Id
+	Eip string `json:"eip"`
+	// swagger:ignore
+	Ip string `json:"ip"`
+	// 源IP子网, 与source_cidr二选一
+	NetworkId string `json:"network_id"`
+	// 源网段, 须在NAT网关所在Vpc内
+	SourceCidr string `json:"source_cidr"`
+}
+
+type NatGatewayApplyRulesInput struct {
+	// 需要调整的规则类型, 未指定的类型保持不变
+	// enum: dnat, snat
+	// required: true
+	RuleTypes []string `json:"rule_types"`
+
+	// 期望的DNAT规则全集, 与现有规则对比后增删
+	DnatRules []NatDRuleInput `json:"dnat_rules"`
+	// 期望的SNAT规则全集, 与现有规则对比后增删
+	SnatRules []NatSRuleInput `json:"snat_rules"`
+
+	// 仅返回差异, 不做修改
+	DryRun bool `json:"dry_run"`
+}
+
+// 规则在云上无法原地修改, 变更的规则体现为先删除再新增
+type NatGatewayApplyRulesOutput struct {
+	DnatAdded     []NatDRuleInput `json:"dnat_added"`
+	DnatRemoved   []NatDRuleInput `json:"dnat_removed"`
+	DnatUnchanged int             `json:"dnat_unchanged"`
+
+	SnatAdded     []NatSRuleInput `json:"snat_added"`
+	SnatRemoved   []NatSRuleInput `json:"snat_removed"`
+	SnatUnchanged int             `json:"snat_unchanged"`
+}
@@ -288,6 +288,10 @@ type NetworkReserveIpInput struct {
 	// example: [10.168.222.131, 10.168.222.134]
 	Ips []string `json:"ips"`
 
+	// description: reserved ip ranges, in form of start-end or cidr, addresses in use are skipped
+	// example: [10.168.222.140-10.168.222.150, 10.168.222.160/28]
+	IpRanges []string `json:"ip_ranges"`
+
 	// description: the comment
 	// example: reserve ip for test
 	Notes  string `json:"notes"`
@@ -0,0 +1,91 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	NETWORK_IP_UTILIZATION_GROUP_BY_NETWORK = "network"
+	NETWORK_IP_UTILIZATION_GROUP_BY_WIRE    = "wire"
+	NETWORK_IP_UTILIZATION_GROUP_BY_ZONE    = "zone"
+
+	// 云上网卡IP已被本地其他资源占用
+	NETWORK_IP_CONFLICT_IP_IN_USE = "ip_in_use"
+	// 云上网卡IP不属于任何本地网络
+	NETWORK_IP_CONFLICT_NO_NETWORK = "no_network"
+
+	// 单次预留IP段的最大地址数量
+	NETWORK_RESERVE_IP_RANGE_MAX = 1024
+)
+
+type NetworkReserveIpOutput struct {
+	// 成功预留的IP
+	Reserved []string `json:"reserved"`
+	// IP段中已被占用而跳过的IP
+	Skipped []string `json:"skipped"`
+}
+
+type NetworkIpUtilizationInput struct {
+	NetworkListInput
+
+	// 统计维度
+	// enum: network,wire,zone
+	// default: network
+	GroupBy string `json:"group_by"`
+}
+
+type NetworkIpUtilization struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+
+	// 网络数量
+	NetworkCount int `json:"network_count"`
+	// 地址总数
+	Total int `json:"total"`
+	// 已使用地址数量, 不包含预留IP
+	Used int `json:"used"`
+	// 预留IP数量
+	Reserved int `json:"reserved"`
+	// 可用地址数量
+	Free int `json:"free"`
+	// 使用率, 包含预留IP, 0~1
+	Utilization float64 `json:"utilization"`
+}
+
+type NetworkIpUtilizationOutput struct {
+	GroupBy string                 `json:"group_by"`
+	Data    []NetworkIpUtilization `json:"data"`
+}
+
+type NetworkIpConflictListInput struct {
+	apis.StandaloneAnonResourceListInput
+	NetworkFilterListInput
+
+	GuestId []string `json:"guest_id"`
+	// 冲突原因
+	Reason []string `json:"reason"`
+}
+
+type NetworkIpConflictDetails struct {
+	apis.StandaloneAnonResourceDetails
+	NetworkResourceInfo
+
+	SNetworkIpConflict
+
+	// 虚拟机名称
+	Guest string `json:"guest"`
+}
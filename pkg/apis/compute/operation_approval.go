@@ -0,0 +1,139 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	// 删除云账号或子订阅及其全部资源
+	OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER = "purge_cloudprovider"
+	// 删除或清除单台虚拟机
+	OPERATION_APPROVAL_ACTION_DELETE_SERVER = "delete_server"
+	// 批量删除虚拟机
+	OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER = "batch_delete_server"
+	// 跨域更换虚拟机项目
+	OPERATION_APPROVAL_ACTION_CHANGE_PROJECT_CROSS_DOMAIN = "change_project_cross_domain"
+
+	OPERATION_APPROVAL_STATUS_PENDING        = "pending_approval"
+	OPERATION_APPROVAL_STATUS_REJECTED       = "rejected"
+	OPERATION_APPROVAL_STATUS_EXPIRED        = "expired"
+	OPERATION_APPROVAL_STATUS_EXECUTING      = "executing"
+	OPERATION_APPROVAL_STATUS_EXECUTED       = "executed"
+	OPERATION_APPROVAL_STATUS_EXECUTE_FAILED = "execute_failed"
+
+	OPERATION_APPROVAL_DEFAULT_EXPIRE_HOURS = 24
+)
+
+var (
+	OPERATION_APPROVAL_ACTIONS = []string{
+		OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER,
+		OPERATION_APPROVAL_ACTION_DELETE_SERVER,
+		OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER,
+		OPERATION_APPROVAL_ACTION_CHANGE_PROJECT_CROSS_DOMAIN,
+	}
+)
+
+type SOperationApprovalActions []string
+
+func (a SOperationApprovalActions) String() string {
+	return jsonutils.Marshal(a).String()
+}
+
+func (a SOperationApprovalActions) IsZero() bool {
+	return len(a) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SOperationApprovalActions{}), func() gotypes.ISerializable {
+		return &SOperationApprovalActions{}
+	})
+}
+
+type OperationApprovalPolicyCreateInput struct {
+	apis.EnabledStatusDomainLevelResourceCreateInput
+
+	// 需要审批的操作
+	// enum: ["purge_cloudprovider", "delete_server", "batch_delete_server", "change_project_cross_domain"]
+	// required: true
+	Actions SOperationApprovalActions `json:"actions"`
+	// 审批请求有效期, 单位小时, 默认24
+	ExpireHours int `json:"expire_hours"`
+}
+
+type OperationApprovalPolicyUpdateInput struct {
+	apis.EnabledStatusDomainLevelResourceBaseUpdateInput
+
+	Actions     SOperationApprovalActions `json:"actions"`
+	ExpireHours *int                      `json:"expire_hours"`
+}
+
+type OperationApprovalPolicyListInput struct {
+	apis.EnabledStatusDomainLevelResourceListInput
+
+	Action []string `json:"action"`
+}
+
+type OperationApprovalPolicyDetails struct {
+	apis.EnabledStatusDomainLevelResourceDetails
+
+	SOperationApprovalPolicy
+}
+
+type OperationApprovalListInput struct {
+	apis.StatusDomainLevelResourceListInput
+
+	Action       []string `json:"action"`
+	ResourceType []string `json:"resource_type"`
+	// 仅列出由当前用户发起的请求
+	Mine *bool `json:"mine"`
+}
+
+type OperationApprovalDetails struct {
+	apis.StatusDomainLevelResourceDetails
+
+	SOperationApproval
+}
+
+type OperationApprovalApproveInput struct {
+	// 审批意见
+	Reason string `json:"reason"`
+}
+
+type OperationApprovalRejectInput struct {
+	// 驳回原因
+	Reason string `json:"reason"`
+}
+
+type ServerBatchDeleteInput struct {
+	ServerDeleteInput
+
+	// 待删除的虚拟机ID或名称
+	// required: true
+	Servers []string `json:"servers"`
+}
+
+type ServerBatchDeleteOutput struct {
+	// 已开始删除的虚拟机
+	Deleted []string `json:"deleted"`
+	// 需要审批时创建的审批请求
+	Approvals []string `json:"approvals"`
+}
@@ -43,6 +43,8 @@ type ProjectMappingRuleInfo struct {
 	Condition string `json:"condition"`
 	// 是否自动根据标签值创建项目, 仅标签列表中有且仅有一个没有value的key时支持
 	AutoCreateProject bool `json:"auto_create_project"`
+	// 自动创建项目时所属的域id, 默认为云账号所属域
+	AutoCreateDomainId string `json:"auto_create_domain_id"`
 	// 符合条件时，资源放置的项目id, 此参数和auto_create_project互斥
 	ProjectId string `json:"project_id"`
 	// 只读信息
@@ -79,6 +81,9 @@ func (rule *ProjectMappingRuleInfo) Validate() error {
 	if emptyValueTags != 1 && rule.AutoCreateProject {
 		return httperrors.NewInputParameterError("not support auto_create_project")
 	}
+	if len(rule.AutoCreateDomainId) > 0 && !rule.AutoCreateProject {
+		return httperrors.NewInputParameterError("auto_create_domain_id requires auto_create_project")
+	}
 	if !rule.AutoCreateProject && len(rule.ProjectId) == 0 {
 		return httperrors.NewInputParameterError("missing project_id")
 	}
@@ -0,0 +1,94 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	PROVIDER_ALERT_RULE_TYPE_DISCONNECTED = "provider_disconnected"
+	PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED  = "sync_failed"
+	PROVIDER_ALERT_RULE_TYPE_ARREARS      = "account_arrears"
+
+	PROVIDER_ALERT_RULE_DEFAULT_SYNC_FAILED_THRESHOLD = 3
+)
+
+var (
+	PROVIDER_ALERT_RULE_TYPES = []string{
+		PROVIDER_ALERT_RULE_TYPE_DISCONNECTED,
+		PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED,
+		PROVIDER_ALERT_RULE_TYPE_ARREARS,
+	}
+)
+
+type SProviderAlertNotification struct {
+	// 接收人ID列表, 为空时发送系统异常通知
+	Receivers []string `json:"receivers"`
+	// 通知渠道, 为空时使用接收人已订阅的渠道
+	// enum: ["email", "mobile", "dingtalk", "feishu", "workwx", "webconsole", "webhook"]
+	Channels []string `json:"channels"`
+}
+
+func (n SProviderAlertNotification) String() string {
+	return jsonutils.Marshal(n).String()
+}
+
+func (n SProviderAlertNotification) IsZero() bool {
+	return len(n.Receivers) == 0 && len(n.Channels) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SProviderAlertNotification{}), func() gotypes.ISerializable {
+		return &SProviderAlertNotification{}
+	})
+}
+
+type ProviderAlertRuleCreateInput struct {
+	apis.EnabledStatusDomainLevelResourceCreateInput
+
+	// 告警规则类型
+	// enum: ["provider_disconnected", "sync_failed", "account_arrears"]
+	// required: true
+	RuleType string `json:"rule_type"`
+	// 连续同步失败次数阈值, 仅 sync_failed 类型有效, 默认为3
+	Threshold int `json:"threshold"`
+	// 通知方式
+	Notification SProviderAlertNotification `json:"notification"`
+}
+
+type ProviderAlertRuleUpdateInput struct {
+	apis.EnabledStatusDomainLevelResourceBaseUpdateInput
+
+	Threshold    *int                        `json:"threshold"`
+	Notification *SProviderAlertNotification `json:"notification"`
+}
+
+type ProviderAlertRuleListInput struct {
+	apis.EnabledStatusDomainLevelResourceListInput
+
+	RuleType []string `json:"rule_type"`
+}
+
+type ProviderAlertRuleDetails struct {
+	apis.EnabledStatusDomainLevelResourceDetails
+
+	SProviderAlertRule
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	// 预留中, 预留额度从可用配额中扣除
+	QUOTA_RESERVATION_STATUS_ACTIVE = "active"
+	// 预留额度已被本项目新建的资源全部消耗
+	QUOTA_RESERVATION_STATUS_EXHAUSTED = "exhausted"
+	QUOTA_RESERVATION_STATUS_EXPIRED   = "expired"
+	QUOTA_RESERVATION_STATUS_RELEASED  = "released"
+
+	QUOTA_RESERVATION_DEFAULT_DURATION = "1d"
+)
+
+type QuotaReservationCreateInput struct {
+	apis.StatusStandaloneResourceCreateInput
+	apis.ProjectizedResourceCreateInput
+
+	// 预留的虚拟机数量
+	Count int `json:"count"`
+	// 预留的CPU核数
+	Cpu int `json:"cpu"`
+	// 预留的内存大小, 单位MB
+	Memory int `json:"memory"`
+	// 预留的存储大小, 单位MB
+	Storage int `json:"storage"`
+
+	// 预留时长, 例如 2h, 1d, 默认为1d
+	Duration string `json:"duration"`
+	// 预留过期时间, 优先于 duration
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type QuotaReservationListInput struct {
+	apis.StatusStandaloneResourceListInput
+	apis.ProjectizedResourceListInput
+}
+
+type QuotaReservationDetails struct {
+	apis.StatusStandaloneResourceDetails
+	apis.ProjectizedResourceInfo
+
+	SQuotaReservation
+
+	// 剩余未消耗的预留额度
+	RemainingCount   int `json:"remaining_count"`
+	RemainingCpu     int `json:"remaining_cpu"`
+	RemainingMemory  int `json:"remaining_memory"`
+	RemainingStorage int `json:"remaining_storage"`
+}
+
+type QuotaReservationReleaseInput struct {
+}
+
+type QuotaForecastInput struct {
+	apis.ProjectizedResourceInput
+
+	// 计划创建的虚拟机数量
+	Count int `json:"count"`
+	// 单台虚拟机CPU核数
+	VcpuCount int `json:"vcpu_count"`
+	// 单台虚拟机内存大小, 单位MB
+	VmemSize int `json:"vmem_size"`
+	// 单台虚拟机存储大小, 单位MB
+	DiskSize int `json:"disk_size"`
+}
+
+type QuotaForecastOutput struct {
+	// 按当前用量, 待创建资源及预留计算, 还可创建的虚拟机数量, -1 表示不受配额限制
+	Allocable int `json:"allocable"`
+	// 计划数量是否可以满足
+	Satisfied bool `json:"satisfied"`
+
+	// 本项目预留中尚未消耗的额度
+	ReservedCount   int `json:"reserved_count"`
+	ReservedCpu     int `json:"reserved_cpu"`
+	ReservedMemory  int `json:"reserved_memory"`
+	ReservedStorage int `json:"reserved_storage"`
+}
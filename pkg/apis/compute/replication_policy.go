@@ -0,0 +1,138 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	REPLICATION_RESOURCE_TYPE_SNAPSHOT = "snapshot"
+	REPLICATION_RESOURCE_TYPE_IMAGE    = "image"
+
+	REPLICATION_POLICY_STATUS_READY = "ready"
+
+	REPLICATION_RECORD_STATUS_PENDING     = "pending"
+	REPLICATION_RECORD_STATUS_REPLICATING = "replicating"
+	REPLICATION_RECORD_STATUS_READY       = "ready"
+	REPLICATION_RECORD_STATUS_FAILED      = "failed"
+)
+
+var (
+	// 复制策略支持的资源类型, 云上快照跨区域复制暂无平台驱动实现
+	REPLICATION_RESOURCE_TYPES = []string{REPLICATION_RESOURCE_TYPE_IMAGE}
+)
+
+// 复制目标
+type SReplicationTarget struct {
+	// 目标区域ID, 创建时可指定名称
+	CloudregionId string `json:"cloudregion_id"`
+	// 目标云订阅ID, 创建时可指定名称
+	// 为空表示本地IDC
+	ManagerId string `json:"manager_id"`
+}
+
+type SReplicationTargets []SReplicationTarget
+
+func (targets SReplicationTargets) String() string {
+	return jsonutils.Marshal(targets).String()
+}
+
+func (targets SReplicationTargets) IsZero() bool {
+	return len(targets) == 0
+}
+
+type ReplicationPolicyCreateInput struct {
+	apis.EnabledStatusInfrasResourceBaseCreateInput
+
+	// 源区域
+	CloudregionResourceInput
+
+	// 复制的资源类型
+	// enum: image
+	ResourceType string `json:"resource_type"`
+
+	// 复制目标列表
+	Targets []SReplicationTarget `json:"targets"`
+}
+
+type ReplicationPolicyUpdateInput struct {
+	apis.EnabledStatusInfrasResourceBaseUpdateInput
+
+	// 复制目标列表, 不影响已创建的复制记录
+	Targets []SReplicationTarget `json:"targets"`
+}
+
+type ReplicationPolicyListInput struct {
+	apis.EnabledStatusInfrasResourceBaseListInput
+
+	RegionalFilterListInput
+
+	ResourceType []string `json:"resource_type"`
+}
+
+type ReplicationTargetDetails struct {
+	SReplicationTarget
+
+	Cloudregion string `json:"cloudregion"`
+	Manager     string `json:"manager"`
+}
+
+type ReplicationPolicyDetails struct {
+	apis.EnabledStatusInfrasResourceBaseDetails
+	CloudregionResourceInfo
+
+	SReplicationPolicy
+
+	TargetDetails []ReplicationTargetDetails `json:"target_details"`
+	// 各状态复制记录数量
+	RecordStatus map[string]int `json:"record_status"`
+}
+
+type ReplicationRecordListInput struct {
+	apis.StatusStandaloneResourceListInput
+	apis.ProjectizedResourceListInput
+
+	// 复制策略(ID或Name)
+	ReplicationPolicyId string   `json:"replication_policy_id"`
+	ResourceType        []string `json:"resource_type"`
+	ResourceId          []string `json:"resource_id"`
+	TargetCloudregionId []string `json:"target_cloudregion_id"`
+}
+
+type ReplicationRecordDetails struct {
+	apis.StatusStandaloneResourceDetails
+	apis.ProjectizedResourceInfo
+
+	SReplicationRecord
+
+	ReplicationPolicy string `json:"replication_policy"`
+	TargetCloudregion string `json:"target_cloudregion"`
+	TargetManager     string `json:"target_manager"`
+}
+
+type ReplicationRecordRetryInput struct {
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SReplicationTargets{}), func() gotypes.ISerializable {
+		return &SReplicationTargets{}
+	})
+}
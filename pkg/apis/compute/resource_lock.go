@@ -0,0 +1,69 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	// 禁止删除
+	RESOURCE_LOCK_TYPE_DELETE = "delete"
+	// 禁止关机, 仅适用于主机
+	RESOURCE_LOCK_TYPE_STOP = "stop"
+
+	RESOURCE_LOCK_RESOURCE_SERVER = "server"
+	RESOURCE_LOCK_RESOURCE_DISK   = "disk"
+	RESOURCE_LOCK_RESOURCE_EIP    = "eip"
+
+	RESOURCE_LOCK_STATUS_LOCKED = "locked"
+)
+
+var (
+	RESOURCE_LOCK_TYPES     = []string{RESOURCE_LOCK_TYPE_DELETE, RESOURCE_LOCK_TYPE_STOP}
+	RESOURCE_LOCK_RESOURCES = []string{RESOURCE_LOCK_RESOURCE_SERVER, RESOURCE_LOCK_RESOURCE_DISK, RESOURCE_LOCK_RESOURCE_EIP}
+)
+
+type ResourceLockCreateInput struct {
+	apis.StatusStandaloneResourceCreateInput
+
+	// 被锁定的资源类型
+	// enum: ["server", "disk", "eip"]
+	ResourceType string `json:"resource_type"`
+	// 被锁定的资源ID或名称
+	ResourceId string `json:"resource_id"`
+	// 锁类型
+	// enum: ["delete", "stop"]
+	LockType string `json:"lock_type"`
+}
+
+type ResourceLockListInput struct {
+	apis.StatusStandaloneResourceListInput
+	apis.ProjectizedResourceListInput
+
+	ResourceType []string `json:"resource_type"`
+	ResourceId   []string `json:"resource_id"`
+	LockType     []string `json:"lock_type"`
+}
+
+type ResourceLockDetails struct {
+	apis.StatusStandaloneResourceDetails
+	apis.ProjectizedResourceInfo
+
+	SResourceLock
+
+	// 被锁定的资源名称
+	Resource string `json:"resource"`
+}
@@ -71,6 +71,9 @@ const (
 	SG_STATUS_CREATE_FAILED      = "create_failed"
 	SG_STATUS_DELETED            = "deleted" // 删除
 
+	SG_TARGET_STATUS_AVAILABLE   = "available"   // 可用
+	SG_TARGET_STATUS_UNAVAILABLE = "unavailable" // 创建失败或实例均不健康, 冷却期内不再扩容
+
 	SP_STATUS_READY         = "ready" // 正常
 	SP_STATUS_CREATING      = "creating"
 	SP_STATUS_CREATE_FAILED = "create_failed" // 创建失败
@@ -14,7 +14,11 @@
 
 package compute
 
-import "yunion.io/x/onecloud/pkg/apis"
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
 
 type ScalingGroupCreateInput struct {
 	apis.VirtualResourceCreateInput
@@ -94,6 +98,65 @@ type ScalingGroupCreateInput struct {
 	// description: 负载均衡后端服务器的weight
 	// example: 10
 	LoadbalancerBackendWeight int `json:"loadbalancer_backend_weight"`
+
+	// description: 跨平台/区域的实例分布目标, 指定后按权重在多个目标间分布实例
+	// required: false
+	Targets []ScalingGroupTargetInput `json:"targets"`
+}
+
+type ScalingGroupTargetInput struct {
+	// description: hypervisor
+	// example: aliyun
+	Hypervisor string `json:"hypervisor"`
+
+	// description: cloud region id or name
+	// example: cr-test-one
+	Cloudregion string `json:"cloudregion"`
+	// swagger: ignore
+	CloudregionId string `json:"cloudregion_id"`
+
+	// description: 网络 id or name
+	// example: n-test-one
+	Network string `json:"network"`
+	// swagger: ignore
+	NetworkId string `json:"network_id"`
+
+	// description: 主机模板 id or name
+	// example: gt-test-one
+	GuestTemplate string `json:"guest_template"`
+	// swagger: ignore
+	GuestTemplateId string `json:"guest_template_id"`
+
+	// description: 权重, 实例按权重比例分布; 为0表示仅在其他目标失败时使用
+	// example: 1
+	Weight *int `json:"weight"`
+
+	// description: 故障转移优先级, 数值越小越优先
+	// example: 0
+	Priority int `json:"priority"`
+}
+
+type ScalingGroupRemoveTargetInput struct {
+	// description: 分布目标ID
+	TargetId string `json:"target_id"`
+}
+
+type ScalingGroupTargetDetails struct {
+	Id              string    `json:"id"`
+	Hypervisor      string    `json:"hypervisor"`
+	CloudregionId   string    `json:"cloudregion_id"`
+	Cloudregion     string    `json:"cloudregion"`
+	NetworkId       string    `json:"network_id"`
+	Network         string    `json:"network"`
+	GuestTemplateId string    `json:"guest_template_id"`
+	GuestTemplate   string    `json:"guest_template"`
+	Weight          int       `json:"weight"`
+	Priority        int       `json:"priority"`
+	Status          string    `json:"status"`
+	UnavailableTill time.Time `json:"unavailable_till"`
+	LastFailure     string    `json:"last_failure"`
+	// 目标内实例数
+	InstanceNumber int `json:"instance_number"`
 }
 
 type ScalingGroupListInput struct {
@@ -136,6 +199,9 @@ type ScalingGroupDetails struct {
 
 	// description: 网络信息
 	Networks []ScalingGroupNetwork `json:"networks"`
+
+	// description: 跨平台/区域的实例分布目标
+	Targets []ScalingGroupTargetDetails `json:"targets"`
 }
 
 type ScalingGroupNetwork struct {
@@ -79,6 +79,7 @@ type SchedtagDetails struct {
 
 	DynamicSchedtagCount int    `json:"dynamic_schedtag_count"`
 	SchedpolicyCount     int    `json:"schedpolicy_count"`
+	SchedtagRuleCount    int    `json:"schedtag_rule_count"`
 	HostCount            int    `json:"host_count"`
 	ServerCount          int    `json:"server_count"`
 	OtherCount           int    `json:"other_count"`
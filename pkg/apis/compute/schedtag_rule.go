@@ -0,0 +1,95 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/util/tagutils"
+)
+
+var (
+	// 支持自动关联调度标签的同步资源类型
+	SCHEDTAG_RULE_RESOURCE_TYPES = []string{"hosts", "storages", "networks"}
+)
+
+// 云上标签匹配条件, 资源须包含所有标签, 标签值为空时匹配任意值
+type SchedtagRuleTags tagutils.TTagSet
+
+func (tags SchedtagRuleTags) String() string {
+	return jsonutils.Marshal(tags).String()
+}
+
+func (tags SchedtagRuleTags) IsZero() bool {
+	return len(tags) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SchedtagRuleTags{}), func() gotypes.ISerializable {
+		return &SchedtagRuleTags{}
+	})
+}
+
+type SchedtagRuleCreateInput struct {
+	apis.EnabledStatusStandaloneResourceCreateInput
+	SchedtagResourceInput
+
+	// 匹配的云平台, 为空时匹配所有平台
+	// example: Aliyun
+	Provider string `json:"provider"`
+	// 匹配的区域ID或名称, 为空时匹配所有区域
+	CloudregionId string `json:"cloudregion_id"`
+	// 匹配的可用区ID或名称, 为空时匹配所有可用区
+	ZoneId string `json:"zone_id"`
+	// 匹配的云上标签
+	Tags SchedtagRuleTags `json:"tags"`
+}
+
+type SchedtagRuleUpdateInput struct {
+	apis.EnabledStatusStandaloneResourceBaseUpdateInput
+
+	Provider      *string          `json:"provider"`
+	CloudregionId *string          `json:"cloudregion_id"`
+	ZoneId        *string          `json:"zone_id"`
+	Tags          SchedtagRuleTags `json:"tags"`
+}
+
+type SchedtagRuleListInput struct {
+	apis.EnabledStatusStandaloneResourceListInput
+	SchedtagFilterListInput
+
+	Provider      []string `json:"provider"`
+	CloudregionId string   `json:"cloudregion_id"`
+	ZoneId        string   `json:"zone_id"`
+}
+
+type SchedtagRuleDetails struct {
+	apis.EnabledStatusStandaloneResourceDetails
+	SchedtagResourceInfo
+
+	SSchedtagRule
+
+	Cloudregion string `json:"cloudregion"`
+	Zone        string `json:"zone"`
+}
+
+type SchedtagRuleApplyOutput struct {
+	// 新关联调度标签的资源数量
+	AttachedCount int `json:"attached_count"`
+}
@@ -158,6 +158,9 @@ type SecgroupListInput struct {
 	// equals
 	Equals string
 
+	// 由指定安全策略模板生成的安全组
+	PolicyTemplateId string `json:"policy_template_id"`
+
 	// 按缓存数量排序
 	// pattern:asc|desc
 	OrderByCacheCnt string `json:"order_by_cache_cnt"`
@@ -194,6 +197,9 @@ type SecurityGroupCacheListInput struct {
 
 	VpcFilterListInput
 	SecgroupFilterListInput
+
+	// 规则漂移状态
+	DriftStatus []string `json:"drift_status"`
 }
 
 type SecurityGroupRuleListInput struct {
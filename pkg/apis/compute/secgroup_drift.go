@@ -0,0 +1,60 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+)
+
+// 安全组缓存与本地期望规则之间的差异
+type SSecgroupRuleDrift struct {
+	// 本地存在但云上缺失的规则
+	Missing []string `json:"missing"`
+	// 云上存在但本地未定义的规则, 通常是直接在云控制台修改所致
+	Extra []string `json:"extra"`
+}
+
+func (self SSecgroupRuleDrift) String() string {
+	return jsonutils.Marshal(self).String()
+}
+
+func (self SSecgroupRuleDrift) IsZero() bool {
+	return len(self.Missing) == 0 && len(self.Extra) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SSecgroupRuleDrift{}), func() gotypes.ISerializable {
+		return &SSecgroupRuleDrift{}
+	})
+}
+
+type SecgroupDriftRemediationInput struct {
+	// 检测到云上规则漂移时是否自动以本地规则覆盖云上规则
+	AutoRemediateDrift bool `json:"auto_remediate_drift"`
+}
+
+type SecgroupCacheCheckDriftInput struct {
+	// 检测到漂移后立即以本地规则覆盖云上规则
+	Remediate bool `json:"remediate"`
+}
+
+type SecgroupCacheDriftOutput struct {
+	DriftStatus string              `json:"drift_status"`
+	Drift       *SSecgroupRuleDrift `json:"drift"`
+	Remediated  bool                `json:"remediated"`
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+// 与平台无关的安全规则列表
+type SecurityPolicyRules []SSecgroupRuleResource
+
+func (rules SecurityPolicyRules) String() string {
+	return jsonutils.Marshal(rules).String()
+}
+
+func (rules SecurityPolicyRules) IsZero() bool {
+	return len(rules) == 0
+}
+
+type SecurityPolicyTemplateCreateInput struct {
+	apis.SharableVirtualResourceCreateInput
+
+	// 规则列表
+	// required: true
+	Rules SecurityPolicyRules `json:"rules"`
+}
+
+type SecurityPolicyTemplateUpdateInput struct {
+	apis.SharableVirtualResourceBaseUpdateInput
+
+	// 规则列表, 更新后会同步到由此模板生成的所有安全组
+	Rules SecurityPolicyRules `json:"rules"`
+}
+
+type SecurityPolicyTemplateListInput struct {
+	apis.SharableVirtualResourceListInput
+}
+
+type SecurityPolicyTemplateDetails struct {
+	apis.SharableVirtualResourceDetails
+	SSecurityPolicyTemplate
+
+	// 由此模板生成的安全组数量
+	SecgroupCount int `json:"secgroup_count"`
+}
+
+type SecurityPolicyTemplateApplyInput struct {
+	// 虚拟机id或名称列表, 可以分布在不同平台
+	// required: true
+	ServerIds []string `json:"server_ids"`
+}
+
+type SecurityPolicyTemplateCompatibilityInput struct {
+	// 平台列表, 为空时检查所有平台
+	Providers []string `json:"providers"`
+}
+
+type SecurityPolicyRuleCompatibility struct {
+	Provider string `json:"provider"`
+	// 规则在模板中的序号, 从0开始
+	Index  int                   `json:"index"`
+	Rule   SSecgroupRuleResource `json:"rule"`
+	Reason string                `json:"reason"`
+}
+
+type SecurityPolicyTemplateApplyResult struct {
+	ServerId   string `json:"server_id"`
+	SecgroupId string `json:"secgroup_id"`
+	Error      string `json:"error"`
+	// 在虚拟机所在平台上无法表达的规则
+	UnsupportedRules []SecurityPolicyRuleCompatibility `json:"unsupported_rules"`
+}
+
+type SecurityPolicyTemplateApplyOutput struct {
+	Results []SecurityPolicyTemplateApplyResult `json:"results"`
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SecurityPolicyRules{}), func() gotypes.ISerializable {
+		return &SecurityPolicyRules{}
+	})
+}
@@ -21,3 +21,8 @@ const (
 	SECGROUP_CACHE_STATUS_CACHING       = "caching"
 	SECGROUP_CACHE_STATUS_DELETE_FAILED = "delete_failed"
 )
+
+const (
+	SECGROUP_CACHE_DRIFT_STATUS_IN_SYNC = "in_sync"
+	SECGROUP_CACHE_DRIFT_STATUS_DRIFTED = "drifted"
+)
@@ -185,4 +185,65 @@ type ServerSkuUpdateInput struct {
 	GpuCount *int `json:"gpu_count"`
 
 	GpuMaxCount *int `json:"gpu_max_count"`
+
+	// 按量付费参考价格(每小时)
+	HourlyPrice *float64 `json:"hourly_price"`
+	// 币种
+	Currency string `json:"currency"`
+}
+
+type ServerSkuEquivalentInput struct {
+	// 源套餐ID, 和cpu_core_count/memory_size_mb二选一
+	SkuId string `json:"sku_id"`
+
+	// 源规格CPU核数
+	CpuCoreCount int `json:"cpu_core_count"`
+	// 源规格内存大小(MB)
+	MemorySizeMB int `json:"memory_size_mb"`
+	// 源规格套餐类型
+	// example: general_purpose
+	InstanceTypeCategory string `json:"instance_type_category"`
+	// 源规格CPU架构
+	// example: x86
+	CpuArch string `json:"cpu_arch"`
+
+	// 目标云平台, 默认为除源套餐平台外的全部平台
+	// example: Aws
+	Provider []string `json:"provider"`
+	// 目标区域ID
+	CloudregionId []string `json:"cloudregion_id"`
+
+	// 返回数量, 默认20
+	Limit int `json:"limit"`
+}
+
+type ServerSkuEquivalent struct {
+	Id                   string `json:"id"`
+	Name                 string `json:"name"`
+	Provider             string `json:"provider"`
+	CloudregionId        string `json:"cloudregion_id"`
+	Cloudregion          string `json:"cloudregion"`
+	InstanceTypeFamily   string `json:"instance_type_family"`
+	InstanceTypeCategory string `json:"instance_type_category"`
+	CpuArch              string `json:"cpu_arch"`
+	CpuCoreCount         int    `json:"cpu_core_count"`
+	MemorySizeMB         int    `json:"memory_size_mb"`
+	GpuCount             int    `json:"gpu_count"`
+
+	// 按量付费参考价格(每小时)
+	HourlyPrice float64 `json:"hourly_price"`
+	// 币种
+	Currency string `json:"currency"`
+	// 与源套餐的价格差, 仅双方均有价格且币种相同时有效
+	PriceDelta *float64 `json:"price_delta,omitempty"`
+
+	// 匹配度, 0-100, 规格和类型完全一致为100
+	Score int `json:"score"`
+}
+
+type ServerSkuEquivalentOutput struct {
+	// 源套餐, 按规格查询时为空
+	Source *ServerSkuEquivalent `json:"source,omitempty"`
+
+	Data []ServerSkuEquivalent `json:"data"`
 }
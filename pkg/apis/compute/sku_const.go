@@ -15,6 +15,8 @@
 package compute
 
 import (
+	"time"
+
 	"yunion.io/x/cloudmux/pkg/apis/compute"
 
 	"yunion.io/x/onecloud/pkg/apis"
@@ -174,5 +176,43 @@ type CloudregionSkuSyncInput struct {
 	Resource string `json:"resource"`
 }
 
+type CloudregionSyncRegionSkusInput struct {
+	// 同步资源类型, 默认同步区域支持的全部类型
+	// choices: serversku|elasticcachesku|dbinstance_sku
+	Resources []string `json:"resources"`
+}
+
+type CloudregionSkuFreshnessInput struct {
+	// 云平台名称
+	// example: Google
+	Provider string `json:"provider"`
+
+	// 区域ID
+	CloudregionIds []string `json:"cloudregion_ids"`
+
+	// 超过该时长(小时)未同步视为过期, 默认为套餐自动同步周期
+	StaleHours int `json:"stale_hours"`
+}
+
+type CloudregionSkuFreshness struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+
+	// 主机套餐最近同步成功时间
+	ServerSkuSyncedAt time.Time `json:"server_sku_synced_at"`
+	// 缓存套餐最近同步成功时间
+	ElasticcacheSkuSyncedAt time.Time `json:"elasticcache_sku_synced_at"`
+	// 数据库套餐最近同步成功时间
+	DBInstanceSkuSyncedAt time.Time `json:"db_instance_sku_synced_at"`
+
+	// 已过期的套餐类型
+	StaleResources []string `json:"stale_resources"`
+}
+
+type CloudregionSkuFreshnessOutput struct {
+	Data []CloudregionSkuFreshness `json:"data"`
+}
+
 type SyncImagesInput struct {
 }
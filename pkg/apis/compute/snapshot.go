@@ -56,6 +56,11 @@ type SSnapshotPolicyCreateInput struct {
 	RetentionDays  int   `json:"retention_days"`
 	RepeatWeekdays []int `json:"repeat_weekdays"`
 	TimePoints     []int `json:"time_points"`
+
+	// 快照对象类型, disk: 磁盘快照, server: 主机快照
+	// enum: disk, server
+	// default: disk
+	ResourceType string `json:"resource_type"`
 }
 
 type SSnapshotPolicyCreateInternalInput struct {
@@ -68,6 +73,8 @@ type SSnapshotPolicyCreateInternalInput struct {
 	RetentionDays  int
 	RepeatWeekdays uint8
 	TimePoints     uint32
+
+	ResourceType string
 }
 
 type SnapshotListInput struct {
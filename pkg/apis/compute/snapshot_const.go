@@ -53,6 +53,7 @@ const (
 	INSTANCE_SNAPSHOT_START_DELETE  = "instance_snapshot_start_delete"
 	INSTANCE_SNAPSHOT_DELETE_FAILED = "instance_snapshot_delete_failed"
 	INSTANCE_SNAPSHOT_RESET         = "instance_snapshot_reset"
+	INSTANCE_SNAPSHOT_CREATE_SERVER = "instance_snapshot_create_server"
 
 	SNAPSHOT_POLICY_CACHE_STATUS_READY         = "ready"
 	SNAPSHOT_POLICY_CACHE_STATUS_DELETING      = "deleting"
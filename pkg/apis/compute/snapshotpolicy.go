@@ -27,9 +27,93 @@ type SnapshotPolicyDetails struct {
 	IsActivated           *bool `json:"is_activated,omitempty"`
 
 	BindingDiskCount int `json:"binding_disk_count"`
+	// 绑定的主机数量
+	BindingServerCount int `json:"binding_server_count"`
+}
+
+const (
+	SNAPSHOT_POLICY_RESOURCE_TYPE_DISK   = "disk"
+	SNAPSHOT_POLICY_RESOURCE_TYPE_SERVER = "server"
+)
+
+var SNAPSHOT_POLICY_RESOURCE_TYPES = []string{SNAPSHOT_POLICY_RESOURCE_TYPE_DISK, SNAPSHOT_POLICY_RESOURCE_TYPE_SERVER}
+
+type SnapshotPolicyBindServersInput struct {
+	// 主机ID或名称列表, 仅适用于主机快照策略
+	Servers []string `json:"servers"`
 }
 
 type SnapshotPolicyResourceInfo struct {
 	// 快照策略名称
 	Snapshotpolicy string `json:"snapshotpolicy"`
 }
+
+const (
+	SNAPSHOT_POLICY_COMPLIANCE_NO_POLICY           = "no_snapshot_policy"
+	SNAPSHOT_POLICY_COMPLIANCE_INACTIVE            = "policy_inactive"
+	SNAPSHOT_POLICY_COMPLIANCE_RETENTION_TOO_SHORT = "retention_too_short"
+	SNAPSHOT_POLICY_COMPLIANCE_WEEKDAYS_UNCOVERED  = "weekdays_not_covered"
+)
+
+// 快照策略合规检查及批量绑定的磁盘范围, 按磁盘列表的同名条件过滤
+type SnapshotPolicyDiskScopeInput struct {
+	// 按项目及域过滤, 例如project_id, project_domain_id, scope
+	apis.ProjectizedResourceListInput
+
+	// 仅检查指定类型的磁盘, 例如sys, data
+	DiskType string `json:"disk_type"`
+	// 仅检查指定存储(ID或Name)上的磁盘
+	StorageId string `json:"storage_id"`
+	// 仅检查挂载到指定虚拟机(ID或Name)的磁盘
+	ServerId string `json:"server_id"`
+}
+
+type SnapshotPolicyComplianceInput struct {
+	// 待检查的磁盘范围
+	SnapshotPolicyDiskScopeInput
+
+	// 仅返回不合规的磁盘
+	NonCompliantOnly bool `json:"non_compliant_only"`
+}
+
+type SnapshotPolicyDiskCompliance struct {
+	DiskId string `json:"disk_id"`
+	Disk   string `json:"disk"`
+
+	GuestId string `json:"guest_id"`
+	Guest   string `json:"guest"`
+
+	TenantId string `json:"tenant_id"`
+	Tenant   string `json:"tenant"`
+	DomainId string `json:"domain_id"`
+
+	// 是否满足模板快照策略的要求
+	Compliant bool `json:"compliant"`
+	// 已绑定的快照策略
+	SnapshotpolicyIds []string `json:"snapshotpolicy_ids"`
+	// 不合规原因
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+type SnapshotPolicyComplianceOutput struct {
+	// 模板快照策略
+	SnapshotpolicyId string `json:"snapshotpolicy_id"`
+
+	Total        int `json:"total"`
+	Compliant    int `json:"compliant"`
+	NonCompliant int `json:"non_compliant"`
+	// 存在不合规磁盘的虚拟机数量
+	NonCompliantGuests int `json:"non_compliant_guests"`
+
+	Data []SnapshotPolicyDiskCompliance `json:"data"`
+}
+
+type SnapshotPolicyApplyToNonCompliantInput struct {
+	// 待处理的磁盘范围
+	SnapshotPolicyDiskScopeInput
+}
+
+type SnapshotPolicyApplyToNonCompliantOutput struct {
+	// 绑定了快照策略的磁盘
+	DiskIds []string `json:"disk_ids"`
+}
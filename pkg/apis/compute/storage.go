@@ -52,6 +52,10 @@ type StorageCreateInput struct {
 	// default: ssd
 	MediumType string `json:"medium_type"`
 
+	// 存储性能分级, 为空时根据存储类型和介质类型自动识别
+	// enum: hdd, ssd, nvme
+	Tier string `json:"tier"`
+
 	ZoneResourceInput
 
 	// ceph认证主机, storage_type为 rbd 时,此参数为必传项
@@ -208,6 +212,10 @@ type StorageUpdateInput struct {
 
 	RbdTimeoutInput
 
+	// 存储性能分级
+	// enum: hdd, ssd, nvme
+	Tier string `json:"tier"`
+
 	// swagger:ignore
 	StorageConf *jsonutils.JSONDict
 
@@ -124,6 +124,10 @@ const (
 	DISK_TYPE_ROTATE = compute.DISK_TYPE_ROTATE
 	DISK_TYPE_SSD    = compute.DISK_TYPE_SSD
 	DISK_TYPE_HYBRID = compute.DISK_TYPE_HYBRID
+
+	STORAGE_TIER_HDD  = "hdd"  // 容量型
+	STORAGE_TIER_SSD  = "ssd"  // 性能型
+	STORAGE_TIER_NVME = "nvme" // 极速型, 通常为NVMe或预配置IOPS存储
 )
 
 const (
@@ -134,6 +138,7 @@ const (
 
 var (
 	DISK_TYPES          = []string{DISK_TYPE_ROTATE, DISK_TYPE_SSD, DISK_TYPE_HYBRID}
+	STORAGE_TIERS       = []string{STORAGE_TIER_HDD, STORAGE_TIER_SSD, STORAGE_TIER_NVME}
 	STORAGE_LOCAL_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_UCLOUD_LOCAL_NORMAL, STORAGE_UCLOUD_LOCAL_SSD, STORAGE_UCLOUD_EXCLUSIVE_LOCAL_DISK,
 		STORAGE_EPHEMERAL_SSD, STORAGE_LOCAL_BASIC, STORAGE_LOCAL_SSD, STORAGE_LOCAL_PRO, STORAGE_OPENSTACK_NOVA,
 		STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_GOOGLE_LOCAL_SSD}
@@ -244,4 +249,8 @@ type StorageListInput struct {
 
 	// filter storages of baremetal host
 	IsBaremetal *bool `json:"is_baremetal"`
+
+	// 按存储性能分级过滤
+	// enum: hdd, ssd, nvme
+	Tier []string `json:"tier"`
 }
@@ -0,0 +1,86 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+)
+
+const (
+	STORAGE_CAPACITY_FORECAST_GROUP_BY_STORAGE = "storage"
+	STORAGE_CAPACITY_FORECAST_GROUP_BY_ZONE    = "zone"
+
+	// 默认使用最近30天的采样数据做预测
+	STORAGE_CAPACITY_FORECAST_DEFAULT_SAMPLE_DAYS = 30
+)
+
+type StorageCapacityForecastInput struct {
+	// 参与预测的历史采样天数, 默认30天
+	SampleDays int `json:"sample_days"`
+}
+
+type StorageCapacityForecastListInput struct {
+	StorageListInput
+	StorageCapacityForecastInput
+
+	// 预测维度
+	// enum: storage, zone
+	GroupBy string `json:"group_by"`
+	// 仅返回预计在该天数内耗尽的结果
+	ExhaustWithinDays int `json:"exhaust_within_days"`
+}
+
+// 单个容量指标的趋势, 容量单位均为MB
+type StorageCapacityTrend struct {
+	// 容量上限
+	Capacity int64 `json:"capacity"`
+	// 当前使用量
+	Used int64 `json:"used"`
+	// 使用率
+	UsedRate float64 `json:"used_rate"`
+	// 每天平均增长量, 负数表示使用量在下降
+	GrowthPerDay float64 `json:"growth_per_day"`
+	// 预计多少天后耗尽, 不增长时为空
+	DaysToExhaust *float64 `json:"days_to_exhaust,omitempty"`
+	// 预计耗尽时间, 不增长时为空
+	ExhaustAt *time.Time `json:"exhaust_at,omitempty"`
+}
+
+type StorageCapacityForecast struct {
+	// 预测维度下的资源ID, 即存储ID或可用区ID
+	Id   string `json:"id"`
+	Name string `json:"name"`
+
+	ZoneId string `json:"zone_id"`
+	Zone   string `json:"zone"`
+
+	// 参与预测的采样点数
+	SampleCount int `json:"sample_count"`
+	// 最早的采样时间
+	SampleSince time.Time `json:"sample_since"`
+
+	// 分配容量(磁盘大小之和)相对于超售后容量的趋势
+	Allocated StorageCapacityTrend `json:"allocated"`
+	// 实际使用容量相对于物理容量的趋势, 仅上报了实际使用量的存储有效
+	Actual *StorageCapacityTrend `json:"actual,omitempty"`
+
+	// 两个指标中最早的耗尽时间
+	ExhaustAt *time.Time `json:"exhaust_at,omitempty"`
+}
+
+type StorageCapacityForecastOutput struct {
+	GroupBy string                    `json:"group_by"`
+	Data    []StorageCapacityForecast `json:"data"`
+}
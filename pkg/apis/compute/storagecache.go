@@ -15,6 +15,8 @@
 package compute
 
 import (
+	"time"
+
 	"yunion.io/x/jsonutils"
 
 	"yunion.io/x/onecloud/pkg/apis"
@@ -113,3 +115,44 @@ type StoragecacheFilterListInput struct {
 
 	ManagedResourceListInput
 }
+
+const (
+	// 最近使用的N个镜像之外, 超过指定天数未使用
+	STORAGECACHE_GC_REASON_UNUSED = "unused"
+	// 缓存总大小超过上限, 按最近最少使用淘汰
+	STORAGECACHE_GC_REASON_EXCEED_SIZE = "exceed_max_size"
+)
+
+type StoragecacheSetGcPolicyInput struct {
+	// 是否启用定时回收
+	GcEnabled *bool `json:"gc_enabled"`
+	// 始终保留最近使用的镜像个数, 0表示不保留
+	GcKeepLastN *int `json:"gc_keep_last_n"`
+	// 超过指定天数未使用的镜像可被回收, 0表示不按时间回收
+	GcUnusedDays *int `json:"gc_unused_days"`
+	// 缓存镜像总大小上限, 单位MB, 0表示不限制
+	GcMaxSizeMb *int64 `json:"gc_max_size_mb"`
+}
+
+type StoragecacheGcInput struct {
+	// 仅返回待回收的镜像, 不实际删除
+	DryRun bool `json:"dry_run"`
+}
+
+type StoragecacheGcCandidate struct {
+	CachedimageId string    `json:"cachedimage_id"`
+	Name          string    `json:"name"`
+	Size          int64     `json:"size"`
+	LastUsedAt    time.Time `json:"last_used_at"`
+	Reason        string    `json:"reason"`
+}
+
+type StoragecacheGcReport struct {
+	StoragecacheId string `json:"storagecache_id"`
+	DryRun         bool   `json:"dry_run"`
+	// 回收前缓存镜像总大小, 单位Byte
+	TotalSize int64 `json:"total_size"`
+	// 可回收大小, 单位Byte
+	ReclaimSize int64                     `json:"reclaim_size"`
+	Candidates  []StoragecacheGcCandidate `json:"candidates"`
+}
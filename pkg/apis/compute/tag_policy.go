@@ -0,0 +1,74 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	// 同步的资源缺失的必需标签, 逗号分隔, 为空表示合规
+	VM_METADATA_TAG_POLICY_MISSING = "__tag_policy_missing"
+)
+
+type STagPolicyKeys []string
+
+func (k STagPolicyKeys) String() string {
+	return jsonutils.Marshal(k).String()
+}
+
+func (k STagPolicyKeys) IsZero() bool {
+	return len(k) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&STagPolicyKeys{}), func() gotypes.ISerializable {
+		return &STagPolicyKeys{}
+	})
+}
+
+type TagPolicyCreateInput struct {
+	apis.EnabledStatusDomainLevelResourceCreateInput
+
+	// 必需的标签键, 例如 owner, cost-center
+	// required: true
+	Keys STagPolicyKeys `json:"keys"`
+	// 缺失标签时是否从所属项目继承标签值并同步到云上资源
+	PushInheritedTags bool `json:"push_inherited_tags"`
+}
+
+type TagPolicyUpdateInput struct {
+	apis.EnabledStatusDomainLevelResourceBaseUpdateInput
+
+	Keys              STagPolicyKeys `json:"keys"`
+	PushInheritedTags *bool          `json:"push_inherited_tags"`
+}
+
+type TagPolicyListInput struct {
+	apis.EnabledStatusDomainLevelResourceListInput
+
+	Key []string `json:"key"`
+}
+
+type TagPolicyDetails struct {
+	apis.EnabledStatusDomainLevelResourceDetails
+
+	STagPolicy
+}
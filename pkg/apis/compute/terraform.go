@@ -0,0 +1,82 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/jsonutils"
+
+const (
+	TERRAFORM_FORMAT_HCL   = "hcl"
+	TERRAFORM_FORMAT_STATE = "state"
+
+	TERRAFORM_RESOURCE_SERVER       = "server"
+	TERRAFORM_RESOURCE_VPC          = "vpc"
+	TERRAFORM_RESOURCE_NETWORK      = "network"
+	TERRAFORM_RESOURCE_LOADBALANCER = "loadbalancer"
+
+	// 导入terraform state后记录资源对应的terraform地址
+	TERRAFORM_ADDRESS_METADATA_KEY = "terraform_address"
+)
+
+var (
+	TERRAFORM_RESOURCES = []string{
+		TERRAFORM_RESOURCE_VPC,
+		TERRAFORM_RESOURCE_NETWORK,
+		TERRAFORM_RESOURCE_SERVER,
+		TERRAFORM_RESOURCE_LOADBALANCER,
+	}
+)
+
+type TerraformExportInput struct {
+	// 导出格式, hcl为terraform import块, state为terraform state v4文件
+	// enum: ["hcl", "state"]
+	// default: hcl
+	Format string `json:"format"`
+	// 导出的资源类型, 默认全部
+	// enum: ["vpc", "network", "server", "loadbalancer"]
+	Resources []string `json:"resources"`
+}
+
+type TerraformExportOutput struct {
+	Format string `json:"format"`
+	// 导出内容
+	Content string `json:"content"`
+	// 导出的资源数量
+	Count int `json:"count"`
+	// 平台不支持terraform导出而跳过的资源
+	Skipped []string `json:"skipped"`
+}
+
+type TerraformImportInput struct {
+	// terraform state文件内容
+	// required: true
+	State jsonutils.JSONObject `json:"state"`
+	// 仅返回匹配结果, 不记录terraform地址
+	DryRun bool `json:"dry_run"`
+}
+
+type TerraformImportedResource struct {
+	// terraform资源地址
+	Address    string `json:"address"`
+	Resource   string `json:"resource"`
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	ExternalId string `json:"external_id"`
+}
+
+type TerraformImportOutput struct {
+	Matched []TerraformImportedResource `json:"matched"`
+	// 未匹配到已同步资源的terraform地址
+	Unmatched []string `json:"unmatched"`
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+const (
+	VMWARE_POOL_MAPPING_TYPE_RESOURCE_POOL = "resource_pool"
+	VMWARE_POOL_MAPPING_TYPE_FOLDER        = "folder"
+)
+
+var VMWARE_POOL_MAPPING_TYPES = []string{
+	VMWARE_POOL_MAPPING_TYPE_RESOURCE_POOL,
+	VMWARE_POOL_MAPPING_TYPE_FOLDER,
+}
+
+type VmwarePoolMappingCreateInput struct {
+	apis.StandaloneResourceCreateInput
+	apis.ProjectizedResourceCreateInput
+
+	// vCenter云订阅Id或名称
+	CloudproviderId string `json:"cloudprovider_id"`
+	// 映射类型
+	// enum: ["resource_pool", "folder"]
+	MappingType string `json:"mapping_type"`
+	// 资源池名称或虚拟机文件夹路径(相对于数据中心的虚拟机根目录, 如 dev/web)
+	Path string `json:"path"`
+
+	// swagger:ignore
+	ManagerId string `json:"manager_id"`
+}
+
+type VmwarePoolMappingListInput struct {
+	apis.StandaloneResourceListInput
+	apis.ProjectizedResourceListInput
+	CloudproviderResourceInput
+
+	// 列出关联指定云账号(ID或Name)的映射
+	CloudaccountId []string `json:"cloudaccount_id"`
+
+	// 以云订阅名称排序
+	// pattern:asc|desc
+	OrderByManager string `json:"order_by_manager"`
+
+	// 映射类型
+	MappingType []string `json:"mapping_type"`
+	// 资源池名称或文件夹路径
+	Path []string `json:"path"`
+}
+
+type VmwarePoolMappingDetails struct {
+	apis.StandaloneResourceDetails
+	apis.ProjectizedResourceInfo
+	ManagedResourceInfo
+
+	SVmwarePoolMapping
+}
@@ -56,3 +56,59 @@ type VpcPeeringConnectionListInput struct {
 type VpcPeeringConnectionUpdateInput struct {
 	apis.EnabledStatusInfrasResourceBaseUpdateInput
 }
+
+const (
+	VPC_TOPOLOGY_LINK_PEERING           = "vpc_peering"
+	VPC_TOPOLOGY_LINK_INTER_VPC_NETWORK = "inter_vpc_network"
+	VPC_TOPOLOGY_LINK_UNREACHABLE       = "unreachable"
+
+	VPC_TOPOLOGY_ACTION_EXISTING = "existing"
+	VPC_TOPOLOGY_ACTION_CREATE   = "create"
+	VPC_TOPOLOGY_ACTION_FAILED   = "failed"
+	VPC_TOPOLOGY_ACTION_SKIP     = "skip"
+)
+
+type VpcPeeringMeshInput struct {
+	// 需要两两互联的vpc id或名称列表, 可以分布在不同平台
+	// required: true
+	VpcIds []string `json:"vpc_ids"`
+	// 跨区域vpc对等连接带宽, 仅对腾讯云有效
+	Bandwidth int `json:"bandwidth"`
+	// 仅计算互联方案, 不实际创建
+	DryRun bool `json:"dry_run"`
+}
+
+type VpcPeeringTopologyInput struct {
+	// vpc id或名称列表
+	// required: true
+	VpcIds []string `json:"vpc_ids"`
+}
+
+type VpcTopologyNode struct {
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	Provider      string `json:"provider"`
+	ManagerId     string `json:"manager_id"`
+	CloudregionId string `json:"cloudregion_id"`
+	Cloudregion   string `json:"cloudregion"`
+	CidrBlock     string `json:"cidr_block"`
+}
+
+type VpcTopologyLink struct {
+	VpcId     string `json:"vpc_id"`
+	PeerVpcId string `json:"peer_vpc_id"`
+	// 互联方式
+	// enum: vpc_peering, inter_vpc_network, unreachable
+	Type string `json:"type"`
+	// vpc对等连接或vpc互联的id
+	ResourceId string `json:"resource_id"`
+	Status     string `json:"status"`
+	// enum: existing, create, failed, skip
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+type VpcTopology struct {
+	Nodes []VpcTopologyNode `json:"nodes"`
+	Links []VpcTopologyLink `json:"links"`
+}
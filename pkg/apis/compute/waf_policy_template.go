@@ -0,0 +1,112 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	WAF_POLICY_TEMPLATE_STATUS_AVAILABLE = "available"
+
+	WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLYING     = "applying"
+	WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLIED      = "applied"
+	WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLY_FAILED = "apply_failed"
+	// 模板规则变更后尚未重新应用
+	WAF_POLICY_TEMPLATE_INSTANCE_STATUS_OUTDATED = "outdated"
+)
+
+// 与平台无关的WAF规则定义
+type WafPolicyTemplateRule struct {
+	// 规则名称, 应用到WAF实例时以模板名称为前缀
+	Name string `json:"name"`
+	// 规则描述
+	Description string `json:"description"`
+	// 优先级, 模板内不可重复
+	Priority int `json:"priority"`
+	// 匹配后默认行为
+	Action *cloudprovider.DefaultAction `json:"action"`
+	// enmu: And, Or, Not
+	StatementCondition cloudprovider.TWafStatementCondition `json:"statement_condition"`
+	// 条件表达式
+	Statements []cloudprovider.SWafStatement `json:"statements"`
+}
+
+type WafPolicyTemplateRules []WafPolicyTemplateRule
+
+func (rules WafPolicyTemplateRules) String() string {
+	return jsonutils.Marshal(rules).String()
+}
+
+func (rules WafPolicyTemplateRules) IsZero() bool {
+	return len(rules) == 0
+}
+
+type WafPolicyTemplateCreateInput struct {
+	apis.SharableVirtualResourceCreateInput
+
+	// 规则列表
+	// required: true
+	Rules WafPolicyTemplateRules `json:"rules"`
+}
+
+type WafPolicyTemplateUpdateInput struct {
+	apis.SharableVirtualResourceBaseUpdateInput
+
+	// 规则列表, 更新后已应用的WAF实例状态变为outdated, 需重新应用
+	Rules WafPolicyTemplateRules `json:"rules"`
+}
+
+type WafPolicyTemplateListInput struct {
+	apis.SharableVirtualResourceListInput
+}
+
+type WafPolicyTemplateDetails struct {
+	apis.SharableVirtualResourceDetails
+
+	SWafPolicyTemplate
+
+	// 已应用此模板的WAF实例数量
+	WafInstanceCount int `json:"waf_instance_count"`
+}
+
+type WafPolicyTemplateApplyInput struct {
+	// WAF实例Id或名称列表
+	// required: true
+	WafInstanceIds []string `json:"waf_instance_ids"`
+}
+
+// 模板在WAF实例上的应用状态
+type WafPolicyTemplateInstanceStatus struct {
+	WafInstanceId string    `json:"waf_instance_id"`
+	WafInstance   string    `json:"waf_instance"`
+	Provider      string    `json:"provider"`
+	Status        string    `json:"status"`
+	Reason        string    `json:"reason"`
+	AppliedAt     time.Time `json:"applied_at"`
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&WafPolicyTemplateRules{}), func() gotypes.ISerializable {
+		return &WafPolicyTemplateRules{}
+	})
+}
@@ -121,3 +121,71 @@ type ZoneResourceInfo struct {
 
 	CloudregionResourceInfo
 }
+
+type ZoneHostHaPolicyInput struct {
+	// 宿主机故障时是否自动将虚拟机重启到同可用区的健康宿主机
+	// enum: enable, disable
+	AutoRestartOnHostDown string `json:"auto_restart_on_host_down"`
+
+	// 宿主机在云平台上持续异常多少秒后判定为故障
+	// default: 180
+	HostDownSeconds int `json:"host_down_seconds"`
+}
+
+type ZoneRebalancePlanInput struct {
+	// 宿主机负载偏差阈值, 负载为内存和CPU分配率中的较大值, 最高与最低负载之差不超过阈值时不再迁移
+	// default: 0.1
+	Threshold float64 `json:"threshold"`
+
+	// 最多迁移的虚拟机数量
+	// default: 10
+	MaxMigrations int `json:"max_migrations"`
+}
+
+type ZoneRebalanceInput struct {
+	ZoneRebalancePlanInput
+
+	// 同时进行的迁移数量
+	// default: 2
+	Concurrency int `json:"concurrency"`
+}
+
+type ZoneRebalanceHost struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+
+	// 当前内存分配率
+	MemPressure float64 `json:"mem_pressure"`
+	// 当前CPU分配率
+	CpuPressure float64 `json:"cpu_pressure"`
+	// 按迁移计划执行后的内存分配率
+	PlannedMemPressure float64 `json:"planned_mem_pressure"`
+	// 按迁移计划执行后的CPU分配率
+	PlannedCpuPressure float64 `json:"planned_cpu_pressure"`
+}
+
+type ZoneRebalanceMigration struct {
+	GuestId      string `json:"guest_id"`
+	Guest        string `json:"guest"`
+	SourceHostId string `json:"source_host_id"`
+	SourceHost   string `json:"source_host"`
+	TargetHostId string `json:"target_host_id"`
+	TargetHost   string `json:"target_host"`
+	VcpuCount    int    `json:"vcpu_count"`
+	VmemSizeMb   int    `json:"vmem_size_mb"`
+	LiveMigrate  bool   `json:"live_migrate"`
+
+	// pending, migrating, migrated, failed
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+type ZoneRebalancePlanOutput struct {
+	Hosts      []ZoneRebalanceHost      `json:"hosts"`
+	Migrations []ZoneRebalanceMigration `json:"migrations"`
+
+	// 迁移前宿主机最高与最低负载之差
+	SkewBefore float64 `json:"skew_before"`
+	// 按迁移计划执行后宿主机最高与最低负载之差
+	SkewAfter float64 `json:"skew_after"`
+}
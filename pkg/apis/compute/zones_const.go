@@ -23,3 +23,28 @@ const (
 	ZONE_SOLDOUT = compute.ZONE_SOLDOUT
 	// ZONE_LACK    = "lack"
 )
+
+const (
+	// 可用区(纳管私有云集群)宿主机故障时是否自动将虚拟机重启到健康宿主机
+	ZONEMETA_HA_RESTART_ON_HOST_DOWN = "__ha_restart_on_host_down"
+	// 宿主机在云平台上持续异常多少秒后判定为故障
+	ZONEMETA_HA_HOST_DOWN_SECONDS = "__ha_host_down_seconds"
+
+	ZONE_HA_DEFAULT_HOST_DOWN_SECONDS = 180
+)
+
+const (
+	// 可用区网络延迟(毫秒), 由运维或探测程序维护, 调度优先选择延迟最低的可用区时使用
+	ZONEMETA_LATENCY_MS = "__latency_ms"
+)
+
+const (
+	ZONE_REBALANCE_DEFAULT_THRESHOLD      = 0.1
+	ZONE_REBALANCE_DEFAULT_MAX_MIGRATIONS = 10
+	ZONE_REBALANCE_DEFAULT_CONCURRENCY    = 2
+
+	ZONE_REBALANCE_MIGRATION_PENDING   = "pending"
+	ZONE_REBALANCE_MIGRATION_MIGRATING = "migrating"
+	ZONE_REBALANCE_MIGRATION_MIGRATED  = "migrated"
+	ZONE_REBALANCE_MIGRATION_FAILED    = "failed"
+)
@@ -184,6 +184,33 @@ type SBucket struct {
 	AccessUrls     jsonutils.JSONObject `json:"access_urls"`
 }
 
+// SBudget is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBudget.
+type SBudget struct {
+	apis.SEnabledStatusDomainLevelResourceBase
+	// 预算范围
+	Scope string `json:"scope"`
+	// 预算范围对应的项目, 域或云账号ID
+	ScopeId string `json:"scope_id"`
+	// 每月预算金额
+	Amount float64 `json:"amount"`
+	// 币种
+	Currency string `json:"currency"`
+	// 阈值及对应动作
+	Thresholds *SBudgetThresholds `json:"thresholds"`
+	// 超出预算时自动关机的虚拟机标签
+	StopGuestTag string `json:"stop_guest_tag"`
+	// 统计月份
+	BudgetMonth string `json:"budget_month"`
+	// 当月已产生费用
+	CurrentSpend float64 `json:"current_spend"`
+	// 当月已触发的最高阈值
+	TriggeredPercent float64 `json:"triggered_percent"`
+	// 是否阻止新建资源
+	Blocking bool `json:"blocking"`
+	// 最近一次检查时间
+	LastCheckedAt time.Time `json:"last_checked_at"`
+}
+
 // SCDNDomain is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCDNDomain.
 type SCDNDomain struct {
 	apis.SEnabledStatusInfrasResourceBase
@@ -217,6 +244,8 @@ type SCachedLoadbalancerCertificate struct {
 	// 云账号ID
 	SCloudregionResourceBase
 	SLoadbalancerCertificateResourceBase
+	// 云上证书过期时间
+	NotAfter time.Time `json:"not_after"`
 }
 
 // SCachedimage is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCachedimage.
@@ -246,6 +275,17 @@ type SCachedimage struct {
 	ImageType string `json:"image_type"`
 }
 
+// SChargebackPricing is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SChargebackPricing.
+type SChargebackPricing struct {
+	apis.SEnabledStatusDomainLevelResourceBase
+	// 适用平台, 为空表示所有平台
+	Provider string `json:"provider"`
+	// 适用资源类型, 为空表示所有资源类型
+	ResourceType string `json:"resource_type"`
+	// 价格系数
+	Multiplier float64 `json:"multiplier"`
+}
+
 // SCloudaccount is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCloudaccount.
 type SCloudaccount struct {
 	apis.SEnabledStatusInfrasResourceBase
@@ -308,6 +348,68 @@ type SCloudaccount struct {
 	LakeOfPermissions *SAccountPermissions `json:"lake_of_permissions"`
 }
 
+// SCloudBillItem is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCloudBillItem.
+type SCloudBillItem struct {
+	apis.SStandaloneAnonResourceBase
+	apis.SProjectizedResourceBase
+	SManagedResourceBase
+	SCloudregionResourceBase
+	// 归属云账号ID
+	CloudaccountId string `json:"cloudaccount_id"`
+	// 平台
+	Provider string `json:"provider"`
+	// 账单月份, 例如: 2023-01
+	BillingMonth string `json:"billing_month"`
+	// 用量日期
+	UsageDate time.Time `json:"usage_date"`
+	// 云上账单明细Id
+	ItemId string `json:"item_id"`
+	// 云上区域Id
+	ExternalRegionId string `json:"external_region_id"`
+	// 资源类型
+	ResourceType string `json:"resource_type"`
+	// 云上资源Id
+	ResourceId string `json:"resource_id"`
+	// 资源名称
+	ResourceName string `json:"resource_name"`
+	// 产品代码
+	ProductCode string `json:"product_code"`
+	// 计费方式
+	ChargeType string `json:"charge_type"`
+	// 费用
+	Amount float64 `json:"amount"`
+	// 币种
+	Currency string `json:"currency"`
+}
+
+// SCloudCommitment is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCloudCommitment.
+type SCloudCommitment struct {
+	apis.SStatusInfrasResourceBase
+	apis.SExternalizedResourceBase
+	SManagedResourceBase
+	SCloudregionResourceBase
+	// 承诺类型
+	CommitmentType string `json:"commitment_type"`
+	// 预留实例规格
+	InstanceType string `json:"instance_type"`
+	// 预留实例数量
+	InstanceCount int `json:"instance_count"`
+	// 操作系统
+	OsType string `json:"os_type"`
+	// 付款方式
+	PaymentOption string `json:"payment_option"`
+	// 节省计划每小时承诺金额
+	HourlyCommitment float64 `json:"hourly_commitment"`
+	// 币种
+	Currency string `json:"currency"`
+	// 生效时间
+	StartAt time.Time `json:"start_at"`
+	// 到期时间
+	EndAt time.Time `json:"end_at"`
+	// 云平台统计的利用率(百分比), -1表示未知
+	Utilization float64 `json:"utilization"`
+}
+
 // SCloudimage is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCloudimage.
 type SCloudimage struct {
 	apis.SStandaloneResourceBase
@@ -386,6 +488,12 @@ type SCloudproviderregion struct {
 	SyncResults    jsonutils.JSONObject `json:"sync_results"`
 	LastDeepSyncAt time.Time            `json:"last_deep_sync_at"`
 	LastAutoSyncAt time.Time            `json:"last_auto_sync_at"`
+	// 连续同步失败次数
+	SyncFailedCount int `json:"sync_failed_count"`
+	// 最近一次成功同步结束时间
+	LastSyncSuccessAt time.Time `json:"last_sync_success_at"`
+	// 熔断期间连续跳过的同步次数
+	SyncSkippedCount int `json:"sync_skipped_count"`
 }
 
 // SCloudproviderschedtag is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCloudproviderschedtag.
@@ -407,6 +515,12 @@ type SCloudregion struct {
 	// 云平台
 	// example: Huawei
 	Provider string `json:"provider"`
+	// 主机套餐最近同步成功时间
+	ServerSkuSyncedAt time.Time `json:"server_sku_synced_at"`
+	// 缓存套餐最近同步成功时间
+	ElasticcacheSkuSyncedAt time.Time `json:"elasticcache_sku_synced_at"`
+	// 数据库套餐最近同步成功时间
+	DBInstanceSkuSyncedAt time.Time `json:"db_instance_sku_synced_at"`
 }
 
 // SCloudregionResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCloudregionResourceBase.
@@ -479,6 +593,8 @@ type SDBInstance struct {
 	Zone3 string `json:"zone3"`
 	// 从备份创建新实例
 	DBInstancebackupId string `json:"dbinstancebackup_id"`
+	// 参数模板Id
+	ParameterTemplateId string `json:"parameter_template_id"`
 }
 
 // SDBInstanceAccount is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDBInstanceAccount.
@@ -552,6 +668,18 @@ type SDBInstanceParameter struct {
 	Value string `json:"value"`
 }
 
+// SDBInstanceParameterTemplate is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDBInstanceParameterTemplate.
+type SDBInstanceParameterTemplate struct {
+	apis.SSharableVirtualResourceBase
+	// 引擎
+	// example: MySQL
+	Engine string `json:"engine"`
+	// 引擎版本, 为空时适用于所有版本
+	EngineVersion string `json:"engine_version"`
+	// 与平台无关的参数列表
+	Parameters *DBParameterTemplateParameters `json:"parameters"`
+}
+
 // SDBInstancePrivilege is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDBInstancePrivilege.
 type SDBInstancePrivilege struct {
 	apis.SResourceBase
@@ -615,6 +743,7 @@ type SDisk struct {
 	SStorageResourceBase
 	apis.SMultiArchResourceBase
 	apis.SAutoDeleteResourceBase
+	SExternalProjectResourceBase
 	apis.SEncryptedResource
 	// 磁盘存储类型
 	// example: qcow2
@@ -687,10 +816,11 @@ type SDnsRecord struct {
 type SDnsRecordSet struct {
 	apis.SEnabledStatusStandaloneResourceBase
 	SDnsZoneResourceBase
-	DnsType    string `json:"dns_type"`
-	DnsValue   string `json:"dns_value"`
-	TTL        int64  `json:"ttl"`
-	MxPriority int64  `json:"mx_priority"`
+	DnsType    string        `json:"dns_type"`
+	DnsValue   string        `json:"dns_value"`
+	TTL        int64         `json:"ttl"`
+	MxPriority int64         `json:"mx_priority"`
+	Failover   *SDnsFailover `json:"failover"`
 }
 
 // SDnsRecordSetTrafficPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDnsRecordSetTrafficPolicy.
@@ -975,6 +1105,7 @@ type SElasticip struct {
 	apis.SExternalizedResourceBase
 	SManagedResourceBase
 	SCloudregionResourceBase
+	SExternalProjectResourceBase
 	SBillingResourceBase
 	// IP子网Id, 仅私有云不为空
 	NetworkId string `json:"network_id"`
@@ -1000,6 +1131,21 @@ type SElasticip struct {
 	BgpType string `json:"bgp_type"`
 	// 是否跟随主机删除而自动释放
 	AutoDellocate *bool `json:"auto_dellocate,omitempty"`
+	// 所属EIP池, 非空表示EIP已释放并保留在池中等待复用
+	PoolId string `json:"pool_id"`
+}
+
+// SElasticipPool is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SElasticipPool.
+type SElasticipPool struct {
+	apis.SEnabledStatusInfrasResourceBase
+	SManagedResourceBase
+	SCloudregionResourceBase
+	// 池中最多保留的EIP数量, 0表示不限制
+	MaxCount int `json:"max_count"`
+	// 每个项目最多保留的EIP数量, 0表示不限制
+	ProjectReserveCount int `json:"project_reserve_count"`
+	// 仅保留指定计费类型的EIP, 为空表示不限制
+	ChargeType string `json:"charge_type"`
 }
 
 // SExternalProject is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SExternalProject.
@@ -1012,6 +1158,12 @@ type SExternalProject struct {
 	CloudaccountId string `json:"cloudaccount_id"`
 }
 
+// SExternalProjectResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SExternalProjectResourceBase.
+type SExternalProjectResourceBase struct {
+	// 云上企业项目或资源组的云上ID
+	ExternalProjectId string `json:"external_project_id"`
+}
+
 // SFileSystem is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SFileSystem.
 type SFileSystem struct {
 	apis.SStatusInfrasResourceBase
@@ -1043,6 +1195,11 @@ type SGlobalVpc struct {
 	apis.SEnabledStatusInfrasResourceBase
 	apis.SExternalizedResourceBase
 	SManagedResourceBase
+	// 地址规划
+	// example: 10.0.0.0/8
+	AddressPlan string `json:"address_plan"`
+	// 自动分配成员vpc网段时的默认掩码长度
+	AddressPlanPrefixLen int `json:"address_plan_prefix_len"`
 }
 
 // SGlobalVpcResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGlobalVpcResourceBase.
@@ -1104,6 +1261,7 @@ type SGuest struct {
 	apis.SRecordChecksumResourceBase
 	SHostnameResourceBase
 	SHostResourceBase
+	SExternalProjectResourceBase
 	apis.SEncryptedResource
 	// CPU大小
 	VcpuCount int `json:"vcpu_count"`
@@ -1150,12 +1308,64 @@ type SGuest struct {
 	PowerStates string `json:"power_states"`
 }
 
+// SGuestCommandInvocation is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGuestCommandInvocation.
+type SGuestCommandInvocation struct {
+	apis.SStatusStandaloneResourceBase
+	apis.SProjectizedResourceBase
+	GuestId string `json:"guest_id"`
+	// 执行通道
+	Channel string `json:"channel"`
+	// 命令类型
+	CommandType string `json:"command_type"`
+	// 脚本内容
+	Script string `json:"script"`
+	// 超时时间(秒)
+	Timeout  int `json:"timeout"`
+	ExitCode int `json:"exit_code"`
+	// 标准输出
+	Output string `json:"output"`
+	// 标准错误输出
+	ErrOutput string `json:"err_output"`
+	// 输出是否被截断
+	OutputTruncated bool `json:"output_truncated"`
+	// 执行人
+	Operator   string    `json:"operator"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
 // SGuestJointsBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGuestJointsBase.
 type SGuestJointsBase struct {
 	apis.SVirtualJointResourceBase
 	GuestId string `json:"guest_id"`
 }
 
+// SGuestSoftwareInventory is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGuestSoftwareInventory.
+type SGuestSoftwareInventory struct {
+	apis.SStatusStandaloneResourceBase
+	apis.SProjectizedResourceBase
+	GuestId string `json:"guest_id"`
+	// 采集来源
+	Source string `json:"source"`
+	// 操作系统完整名称
+	OsName string `json:"os_name"`
+	// 操作系统发行版
+	OsDistribution string `json:"os_distribution"`
+	// 操作系统版本
+	OsVersion string `json:"os_version"`
+	// 内核版本
+	KernelVersion string `json:"kernel_version"`
+	OsArch        string `json:"os_arch"`
+	// 已安装的更新或软件包
+	InstalledUpdates *SGuestInstalledUpdates `json:"installed_updates"`
+	// 已安装的更新数量
+	UpdateCount int `json:"update_count"`
+	// 最近一次安装更新的时间
+	LastUpdateAt time.Time `json:"last_update_at"`
+	// 最近一次采集时间
+	CollectedAt time.Time `json:"collected_at"`
+}
+
 // SGuestTemplate is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGuestTemplate.
 type SGuestTemplate struct {
 	apis.SSharableVirtualResourceBase
@@ -1428,6 +1638,46 @@ type SHuaweiCachedLbbg struct {
 type SI18nResourceBase struct {
 }
 
+// SIdleResource is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SIdleResource.
+type SIdleResource struct {
+	apis.SStatusStandaloneResourceBase
+	apis.SProjectizedResourceBase
+	SManagedResourceBase
+	// 资源类型
+	ResourceType string `json:"resource_type"`
+	// 资源ID
+	ResourceId string `json:"resource_id"`
+	// 云上资源ID
+	ExternalId string `json:"external_id"`
+	// 闲置原因
+	Reason string `json:"reason"`
+	// 开始闲置时间
+	IdleSince time.Time `json:"idle_since"`
+	// 最近一次检测到闲置的时间
+	LastDetectedAt time.Time `json:"last_detected_at"`
+	// 申请清理的用户
+	RequestedBy string `json:"requested_by"`
+	// 申请清理的用户ID
+	RequestedById string `json:"requested_by_id"`
+	// 申请清理时间
+	RequestedAt time.Time `json:"requested_at"`
+	// 审批人
+	ApprovedBy string `json:"approved_by"`
+}
+
+// SImageCatalog is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SImageCatalog.
+type SImageCatalog struct {
+	apis.SStatusInfrasResourceBase
+	// 本地镜像ID
+	ImageId        string `json:"image_id"`
+	OsType         string `json:"os_type"`
+	OsDistribution string `json:"os_distribution"`
+	OsVersion      string `json:"os_version"`
+	OsArch         string `json:"os_arch"`
+	// 各平台镜像映射
+	Mappings *SImageCatalogMappings `json:"mappings"`
+}
+
 // SIPv6Gateway is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SIPv6Gateway.
 type SIPv6Gateway struct {
 	apis.SSharableVirtualResourceBase
@@ -1501,6 +1751,10 @@ type SInstanceSnapshot struct {
 	MemoryFilePath string `json:"memory_file_path"`
 	// 内存文件校验和
 	MemoryFileChecksum string `json:"memory_file_checksum"`
+	// 创建主机快照的快照策略
+	SnapshotpolicyId string `json:"snapshotpolicy_id"`
+	// 过期时间, 由快照策略的保留天数计算
+	ExpiredAt time.Time `json:"expired_at"`
 }
 
 // SInterVpcNetwork is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SInterVpcNetwork.
@@ -1661,6 +1915,15 @@ type SLoadbalancer struct {
 	BackendGroupId string `json:"backend_group_id"`
 	// LB的其他配置信息
 	LBInfo jsonutils.JSONObject `json:"lb_info"`
+	// 创建此负载均衡的蓝图ID
+	BlueprintId string `json:"blueprint_id"`
+}
+
+// SLoadbalancerBlueprint is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SLoadbalancerBlueprint.
+type SLoadbalancerBlueprint struct {
+	apis.SSharableVirtualResourceBase
+	// 蓝图定义
+	Spec *SLoadbalancerBlueprintSpec `json:"spec"`
 }
 
 // SLoadbalancerAcl is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SLoadbalancerAcl.
@@ -1791,6 +2054,12 @@ type SLoadbalancerCertificate struct {
 	apis.SSharableVirtualResourceBase
 	apis.SExternalizedResourceBase
 	apis.SCertificateResourceBase
+	// 证书续期钩子类型
+	RenewHook string `json:"renew_hook"`
+	// 证书续期钩子地址
+	RenewHookUrl string `json:"renew_hook_url"`
+	// 证书过期前多少天自动续期
+	RenewBeforeDays int `json:"renew_before_days"`
 }
 
 // SLoadbalancerCertificateResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SLoadbalancerCertificateResourceBase.
@@ -2230,6 +2499,17 @@ type SNetworkAddress struct {
 	SubCtrVid int    `json:"sub_ctr_vid"`
 }
 
+// SNetworkIpConflict is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SNetworkIpConflict.
+type SNetworkIpConflict struct {
+	apis.SStandaloneAnonResourceBase
+	SNetworkResourceBase
+	GuestId  string `json:"guest_id"`
+	Mac      string `json:"mac"`
+	LocalIp  string `json:"local_ip"`
+	RemoteIp string `json:"remote_ip"`
+	Reason   string `json:"reason"`
+}
+
 // SNetworkInterface is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SNetworkInterface.
 type SNetworkInterface struct {
 	apis.SStatusInfrasResourceBase
@@ -2293,6 +2573,36 @@ type SOpenstackCachedLbbg struct {
 	ProtocolType string `json:"protocol_type"`
 }
 
+// SOperationApproval is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SOperationApproval.
+type SOperationApproval struct {
+	apis.SStatusDomainLevelResourceBase
+	// 待审批的操作
+	Action string `json:"action"`
+	// 操作的资源类型
+	ResourceType string `json:"resource_type"`
+	// 操作的资源ID
+	ResourceIds jsonutils.JSONObject `json:"resource_ids"`
+	// 操作参数
+	Params      jsonutils.JSONObject `json:"params"`
+	RequesterId string               `json:"requester_id"`
+	Requester   string               `json:"requester"`
+	ApproverId  string               `json:"approver_id"`
+	Approver    string               `json:"approver"`
+	// 审批意见或执行失败原因
+	Reason string `json:"reason"`
+	// 过期时间, 过期后不能再审批
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// SOperationApprovalPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SOperationApprovalPolicy.
+type SOperationApprovalPolicy struct {
+	apis.SEnabledStatusDomainLevelResourceBase
+	// 需要审批的操作
+	Actions *SOperationApprovalActions `json:"actions"`
+	// 审批请求有效期, 单位小时
+	ExpireHours int `json:"expire_hours"`
+}
+
 // SPolicyAssignment is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SPolicyAssignment.
 type SPolicyAssignment struct {
 	apis.SDomainLevelResourceBase
@@ -2328,6 +2638,17 @@ type SProjectMappingResourceBase struct {
 	ProjectMappingId string `json:"project_mapping_id"`
 }
 
+// SProviderAlertRule is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SProviderAlertRule.
+type SProviderAlertRule struct {
+	apis.SEnabledStatusDomainLevelResourceBase
+	// 告警规则类型
+	RuleType string `json:"rule_type"`
+	// 连续同步失败次数阈值
+	Threshold int `json:"threshold"`
+	// 通知方式
+	Notification *SProviderAlertNotification `json:"notification"`
+}
+
 // SQcloudCachedLb is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SQcloudCachedLb.
 type SQcloudCachedLb struct {
 	apis.SVirtualResourceBase
@@ -2354,6 +2675,62 @@ type SQcloudCachedLbbg struct {
 	AssociatedType string `json:"associated_type"`
 }
 
+// SQuotaReservation is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SQuotaReservation.
+type SQuotaReservation struct {
+	apis.SStatusStandaloneResourceBase
+	apis.SProjectizedResourceBase
+	// 预留的虚拟机数量
+	Count int `json:"count"`
+	// 预留的CPU核数
+	Cpu int `json:"cpu"`
+	// 预留的内存大小, 单位MB
+	Memory int `json:"memory"`
+	// 预留的存储大小, 单位MB
+	Storage int `json:"storage"`
+	// 已被本项目新建资源消耗的额度
+	UsedCount   int `json:"used_count"`
+	UsedCpu     int `json:"used_cpu"`
+	UsedMemory  int `json:"used_memory"`
+	UsedStorage int `json:"used_storage"`
+	// 已转给本项目创建中资源的额度, 创建成功后计入已消耗, 失败时退回预留
+	HeldCount   int `json:"held_count"`
+	HeldCpu     int `json:"held_cpu"`
+	HeldMemory  int `json:"held_memory"`
+	HeldStorage int `json:"held_storage"`
+	// 过期时间
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// SReplicationPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SReplicationPolicy.
+type SReplicationPolicy struct {
+	apis.SEnabledStatusInfrasResourceBase
+	SCloudregionResourceBase
+	// 复制的资源类型
+	ResourceType string `json:"resource_type"`
+	// 复制目标
+	Targets *SReplicationTargets `json:"targets"`
+}
+
+// SReplicationRecord is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SReplicationRecord.
+type SReplicationRecord struct {
+	apis.SStatusStandaloneResourceBase
+	apis.SProjectizedResourceBase
+	// 复制策略ID
+	ReplicationPolicyId string `json:"replication_policy_id"`
+	// 资源类型
+	ResourceType string `json:"resource_type"`
+	// 源快照或镜像ID
+	ResourceId string `json:"resource_id"`
+	// 目标区域ID
+	TargetCloudregionId string `json:"target_cloudregion_id"`
+	// 目标云订阅ID
+	TargetManagerId string `json:"target_manager_id"`
+	// 目标镜像缓存ID
+	TargetStoragecacheId string `json:"target_storagecache_id"`
+	// 目标资源的云上ID
+	TargetExternalId string `json:"target_external_id"`
+}
+
 // SReservedip is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SReservedip.
 type SReservedip struct {
 	apis.SResourceBase
@@ -2370,6 +2747,18 @@ type SReservedip struct {
 	Status string `json:"status"`
 }
 
+// SResourceLock is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SResourceLock.
+type SResourceLock struct {
+	apis.SStatusStandaloneResourceBase
+	apis.SProjectizedResourceBase
+	// 被锁定的资源类型
+	ResourceType string `json:"resource_type"`
+	// 被锁定的资源ID
+	ResourceId string `json:"resource_id"`
+	// 锁类型
+	LockType string `json:"lock_type"`
+}
+
 // SRouteTable is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SRouteTable.
 type SRouteTable struct {
 	apis.SStatusInfrasResourceBase
@@ -2474,6 +2863,8 @@ type SScalingGroupGuest struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 	GuestStatus    string `json:"guest_status"`
 	Manual         *bool  `json:"manual,omitempty"`
+	// 实例所属的分布目标, 单一配置的伸缩组为空
+	TargetId string `json:"target_id"`
 }
 
 // SScalingGroupNetwork is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingGroupNetwork.
@@ -2489,6 +2880,23 @@ type SScalingGroupResourceBase struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 }
 
+// SScalingGroupTarget is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingGroupTarget.
+type SScalingGroupTarget struct {
+	apis.SStandaloneAnonResourceBase
+	ScalingGroupId  string `json:"scaling_group_id"`
+	Hypervisor      string `json:"hypervisor"`
+	CloudregionId   string `json:"cloudregion_id"`
+	NetworkId       string `json:"network_id"`
+	GuestTemplateId string `json:"guest_template_id"`
+	// 权重, 为0时仅用于故障转移
+	Weight int `json:"weight"`
+	// 故障转移优先级, 数值越小越优先
+	Priority int `json:"priority"`
+	// 冷却截止时间, 在此之前不再向该目标扩容
+	UnavailableTill time.Time `json:"unavailable_till"`
+	LastFailure     string    `json:"last_failure"`
+}
+
 // SScalingPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingPolicy.
 type SScalingPolicy struct {
 	apis.SVirtualResourceBase
@@ -2541,6 +2949,20 @@ type SSchedtagJointsBase struct {
 	SchedtagId string `json:"schedtag_id"`
 }
 
+// SSchedtagRule is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSchedtagRule.
+type SSchedtagRule struct {
+	apis.SEnabledStatusStandaloneResourceBase
+	SSchedtagResourceBase
+	// 匹配的云平台
+	Provider string `json:"provider"`
+	// 匹配的区域
+	CloudregionId string `json:"cloudregion_id"`
+	// 匹配的可用区
+	ZoneId string `json:"zone_id"`
+	// 匹配的云上标签
+	Tags *SchedtagRuleTags `json:"tags"`
+}
+
 // SSchedtagResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSchedtagResourceBase.
 type SSchedtagResourceBase struct {
 	// 归属调度标签ID
@@ -2551,6 +2973,10 @@ type SSchedtagResourceBase struct {
 type SSecurityGroup struct {
 	apis.SSharableVirtualResourceBase
 	IsDirty bool `json:"is_dirty"`
+	// 生成此安全组的安全策略模板ID
+	PolicyTemplateId string `json:"policy_template_id"`
+	// 检测到云上规则漂移时是否自动以本地规则覆盖
+	AutoRemediateDrift bool `json:"auto_remediate_drift"`
 }
 
 // SSecurityGroupCache is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSecurityGroupCache.
@@ -2565,6 +2991,12 @@ type SSecurityGroupCache struct {
 	// 虚拟私有网络外部Id
 	VpcId             string `json:"vpc_id"`
 	ExternalProjectId string `json:"external_project_id"`
+	// 规则漂移状态
+	DriftStatus string `json:"drift_status"`
+	// 规则漂移详情
+	Drift *SSecgroupRuleDrift `json:"drift"`
+	// 最近一次漂移检测时间
+	DriftCheckedAt time.Time `json:"drift_checked_at"`
 }
 
 // SSecurityGroupResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSecurityGroupResourceBase.
@@ -2589,6 +3021,13 @@ type SSecurityGroupRule struct {
 	IsDirty        bool   `json:"is_dirty"`
 }
 
+// SSecurityPolicyTemplate is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSecurityPolicyTemplate.
+type SSecurityPolicyTemplate struct {
+	apis.SSharableVirtualResourceBase
+	// 与平台无关的规则列表
+	Rules *SecurityPolicyRules `json:"rules"`
+}
+
 // SServerSku is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SServerSku.
 type SServerSku struct {
 	apis.SEnabledStatusStandaloneResourceBase
@@ -2626,6 +3065,10 @@ type SServerSku struct {
 	GpuCount           int    `json:"gpu_count"`
 	GpuMaxCount        int    `json:"gpu_max_count"`
 	Provider           string `json:"provider"`
+	// 按量付费参考价格(每小时)
+	HourlyPrice float64 `json:"hourly_price"`
+	// 币种
+	Currency string `json:"currency"`
 }
 
 // SServiceCatalog is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SServiceCatalog.
@@ -2671,6 +3114,8 @@ type SSnapshotPolicy struct {
 	// 0~23
 	TimePoints  uint32 `json:"time_points"`
 	IsActivated *bool  `json:"is_activated,omitempty"`
+	// 快照对象类型, disk或server
+	ResourceType string `json:"resource_type"`
 }
 
 // SSnapshotPolicyCache is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSnapshotPolicyCache.
@@ -2690,6 +3135,12 @@ type SSnapshotPolicyDisk struct {
 	Status string `json:"status"`
 }
 
+// SSnapshotPolicyGuest is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSnapshotPolicyGuest.
+type SSnapshotPolicyGuest struct {
+	SGuestJointsBase
+	SnapshotpolicyId string `json:"snapshotpolicy_id"`
+}
+
 // SSnapshotPolicyResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSnapshotPolicyResourceBase.
 type SSnapshotPolicyResourceBase struct {
 	// 本地快照策略ID
@@ -2715,6 +3166,9 @@ type SStorage struct {
 	// 介质类型
 	// example: ssd
 	MediumType string `json:"medium_type"`
+	// 性能分级
+	// example: ssd
+	Tier string `json:"tier"`
 	// 超售比
 	Cmtbound float32 `json:"cmtbound"`
 	// 存储配置信息
@@ -2739,6 +3193,16 @@ type SStoragecache struct {
 	SManagedResourceBase
 	// 镜像存储地址
 	Path string `json:"path"`
+	// 是否启用缓存镜像定时回收
+	GcEnabled *bool `json:"gc_enabled,omitempty"`
+	// 始终保留最近使用的镜像个数
+	GcKeepLastN int `json:"gc_keep_last_n"`
+	// 超过指定天数未使用的镜像可被回收
+	GcUnusedDays int `json:"gc_unused_days"`
+	// 缓存镜像总大小上限, 单位MB
+	GcMaxSizeMb int64 `json:"gc_max_size_mb"`
+	// 上次回收时间
+	GcLastRunAt time.Time `json:"gc_last_run_at"`
 }
 
 // SStoragecacheResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SStoragecacheResourceBase.
@@ -2785,6 +3249,15 @@ type STablestore struct {
 	SManagedResourceBase
 }
 
+// STagPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.STagPolicy.
+type STagPolicy struct {
+	apis.SEnabledStatusDomainLevelResourceBase
+	// 必需的标签键
+	Keys *STagPolicyKeys `json:"keys"`
+	// 缺失标签时是否从所属项目继承标签值并同步到云上资源
+	PushInheritedTags bool `json:"push_inherited_tags"`
+}
+
 // STimer is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.STimer.
 type STimer struct {
 	// Cycle type
@@ -2800,6 +3273,17 @@ type STimer struct {
 	IsExpired bool   `json:"is_expired"`
 }
 
+// SVmwarePoolMapping is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SVmwarePoolMapping.
+type SVmwarePoolMapping struct {
+	apis.SStandaloneResourceBase
+	apis.SProjectizedResourceBase
+	SManagedResourceBase
+	// 映射类型
+	MappingType string `json:"mapping_type"`
+	// 资源池名称或虚拟机文件夹路径
+	Path string `json:"path"`
+}
+
 // SVpc is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SVpc.
 type SVpc struct {
 	apis.SEnabledStatusInfrasResourceBase
@@ -2862,6 +3346,26 @@ type SWafInstance struct {
 	DefaultAction *cloudprovider.DefaultAction `json:"default_action"`
 }
 
+// SWafPolicyTemplate is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SWafPolicyTemplate.
+type SWafPolicyTemplate struct {
+	apis.SSharableVirtualResourceBase
+	// 与平台无关的规则列表
+	Rules *WafPolicyTemplateRules `json:"rules"`
+}
+
+// SWafPolicyTemplateInstance is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SWafPolicyTemplateInstance.
+type SWafPolicyTemplateInstance struct {
+	apis.SResourceBase
+	Id                  string `json:"id"`
+	WafPolicyTemplateId string `json:"waf_policy_template_id"`
+	WafInstanceId       string `json:"waf_instance_id"`
+	Status              string `json:"status"`
+	// 应用失败原因
+	Reason string `json:"reason"`
+	// 最近一次成功应用的时间
+	AppliedAt time.Time `json:"applied_at"`
+}
+
 // SWafRegexSet is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SWafRegexSet.
 type SWafRegexSet struct {
 	apis.SStatusInfrasResourceBase
@@ -36,8 +36,9 @@ const (
 	SERVICE_TYPE_CLOUDMON          = "cloudmon"
 	SERVICE_TYPE_VPCAGENT          = "vpcagent"
 
-	SERVICE_TYPE_ETCD     = "etcd"
-	SERVICE_TYPE_INFLUXDB = "influxdb"
+	SERVICE_TYPE_ETCD             = "etcd"
+	SERVICE_TYPE_INFLUXDB         = "influxdb"
+	SERVICE_TYPE_VICTORIA_METRICS = "victoria-metrics"
 
 	SERVICE_TYPE_SCHEDULEDTASK = "scheduledtask"
 
@@ -72,6 +73,7 @@ var (
 		SERVICE_TYPE_KEYSTONE,
 		SERVICE_TYPE_ETCD,
 		SERVICE_TYPE_INFLUXDB,
+		SERVICE_TYPE_VICTORIA_METRICS,
 	}
 )
 
@@ -115,3 +115,8 @@ type ImageUpdateStatusInput struct {
 
 type PerformProbeInput struct {
 }
+
+type PerformConvertInput struct {
+	// 目标镜像格式, 例如: qcow2, vmdk, vhd, raw
+	Format string `json:"format"`
+}
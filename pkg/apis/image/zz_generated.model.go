@@ -102,6 +102,10 @@ type SImageSubformat struct {
 	TorrentLocation string `json:"torrent_location"`
 	TorrentChecksum string `json:"torrent_checksum"`
 	TorrentStatus   string `json:"torrent_status"`
+	// 格式转换进度
+	Progress float32 `json:"progress"`
+	// 是否为按需转换的格式, 按需转换的格式不受 target_image_formats 配置清理
+	ConvertOnDemand bool `json:"convert_on_demand"`
 }
 
 // SImageTag is an autogenerated struct via yunion.io/x/onecloud/pkg/image/models.SImageTag.
@@ -54,6 +54,9 @@ var (
 
 	ActionExceedCount SAction = "exceed_count"
 
+	ActionApprove SAction = "approve"
+	ActionReject  SAction = "reject"
+
 	ResultFailed  SResult = "failed"
 	ResultSucceed SResult = "succeed"
 )
@@ -140,6 +140,14 @@ type CandidateResource struct {
 	// used by backup schedule
 	BackupCandidate *CandidateResource `json:"backup_candidate"`
 
+	// 按价格调度(prefer_lowest_price)时选中的套餐
+	InstanceType string `json:"instance_type,omitempty"`
+	// 选中套餐的按量付费价格(每小时)
+	HourlyPrice float64 `json:"hourly_price,omitempty"`
+	Currency    string  `json:"currency,omitempty"`
+	// 与候选者中最高价格的差值, 负数表示节省的费用
+	PriceDelta float64 `json:"price_delta,omitempty"`
+
 	// Error means no candidate found, include reasons
 	Error string `json:"error"`
 }
@@ -20,3 +20,10 @@ const (
 	SERVICE_TYPE    = apis.SERVICE_TYPE_SCHEDULER
 	SERVICE_VERSION = ""
 )
+
+const (
+	// 软约束打分插件, 需在 sched_priority_weights 中指定大于0的权重才生效
+	PRIORITY_PREFER_CHEAPEST_REGION     = "guest_prefer_cheapest_region"
+	PRIORITY_PREFER_LOWEST_LATENCY_ZONE = "guest_prefer_lowest_latency_zone"
+	PRIORITY_SPREAD_PROVIDERS           = "guest_spread_providers"
+)
@@ -0,0 +1,201 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudevents
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/util/stringutils"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+)
+
+const (
+	SPEC_VERSION = "1.0"
+
+	EVENT_TYPE_PREFIX = "io.yunion.cloudpods"
+
+	EVENT_KIND_CREATED = "created"
+	EVENT_KIND_UPDATED = "updated"
+	EVENT_KIND_DELETED = "deleted"
+	EVENT_KIND_SYNCED  = "synced"
+
+	// 订阅者消费过慢时丢弃超出缓冲的事件
+	subscriberBufferSize = 256
+)
+
+// 只推送资源创建、更新、删除及同步相关的操作日志
+var actionKinds = map[string]string{
+	db.ACT_CREATE:         EVENT_KIND_CREATED,
+	db.ACT_ALLOCATE:       EVENT_KIND_CREATED,
+	db.ACT_UPDATE:         EVENT_KIND_UPDATED,
+	db.ACT_UPDATE_STATUS:  EVENT_KIND_UPDATED,
+	db.ACT_DELETE:         EVENT_KIND_DELETED,
+	db.ACT_DELOCATE:       EVENT_KIND_DELETED,
+	db.ACT_PENDING_DELETE: EVENT_KIND_DELETED,
+	db.ACT_SYNC_CREATE:    EVENT_KIND_SYNCED,
+	db.ACT_SYNC_UPDATE:    EVENT_KIND_SYNCED,
+	db.ACT_SYNC_STATUS:    EVENT_KIND_SYNCED,
+}
+
+// CloudEvents 1.0 JSON格式事件, projectid及domainid为扩展属性
+type SCloudEvent struct {
+	SpecVersion     string               `json:"specversion"`
+	Id              string               `json:"id"`
+	Source          string               `json:"source"`
+	Type            string               `json:"type"`
+	Subject         string               `json:"subject"`
+	Time            time.Time            `json:"time"`
+	DataContentType string               `json:"datacontenttype"`
+	Data            jsonutils.JSONObject `json:"data"`
+
+	ProjectId string `json:"projectid"`
+	DomainId  string `json:"domainid"`
+}
+
+func GetEventType(objType, action string) (string, bool) {
+	kind, ok := actionKinds[action]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s.%s.%s", EVENT_TYPE_PREFIX, objType, kind), true
+}
+
+// 资源归属项目优先, 无归属时取操作者项目
+func getOwner(opslog *db.SOpsLog) (string, string) {
+	if len(opslog.OwnerProjectId) > 0 || len(opslog.OwnerDomainId) > 0 {
+		return opslog.OwnerProjectId, opslog.OwnerDomainId
+	}
+	return opslog.ProjectId, opslog.ProjectDomainId
+}
+
+func NewCloudEvent(opslog *db.SOpsLog) (*SCloudEvent, bool) {
+	eventType, ok := GetEventType(opslog.ObjType, opslog.Action)
+	if !ok {
+		return nil, false
+	}
+	projectId, domainId := getOwner(opslog)
+	data := jsonutils.NewDict()
+	data.Set("id", jsonutils.NewString(opslog.ObjId))
+	data.Set("name", jsonutils.NewString(opslog.ObjName))
+	data.Set("resource_type", jsonutils.NewString(opslog.ObjType))
+	data.Set("action", jsonutils.NewString(opslog.Action))
+	data.Set("user_id", jsonutils.NewString(opslog.UserId))
+	data.Set("user", jsonutils.NewString(opslog.User))
+	if len(opslog.Notes) > 0 {
+		notes, err := jsonutils.ParseString(opslog.Notes)
+		if err != nil {
+			notes = jsonutils.NewString(opslog.Notes)
+		}
+		data.Set("notes", notes)
+	}
+	return &SCloudEvent{
+		SpecVersion:     SPEC_VERSION,
+		Id:              stringutils.UUID4(),
+		Source:          fmt.Sprintf("/%s/%s", consts.GetServiceType(), opslog.ObjType),
+		Type:            eventType,
+		Subject:         opslog.ObjId,
+		Time:            opslog.OpsTime,
+		DataContentType: "application/json",
+		Data:            data,
+		ProjectId:       projectId,
+		DomainId:        domainId,
+	}, true
+}
+
+type SFilter struct {
+	// 为空时不按项目过滤
+	ProjectId string
+	// 为空时不按域过滤
+	DomainId string
+	// 为空时推送全部资源类型
+	ResourceTypes []string
+}
+
+func (f SFilter) Match(event *SCloudEvent, objType string) bool {
+	if len(f.ProjectId) > 0 && event.ProjectId != f.ProjectId {
+		return false
+	}
+	if len(f.DomainId) > 0 && event.DomainId != f.DomainId {
+		return false
+	}
+	if len(f.ResourceTypes) > 0 {
+		for _, t := range f.ResourceTypes {
+			if t == objType {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+type sSubscriber struct {
+	filter SFilter
+	events chan *SCloudEvent
+}
+
+type SHub struct {
+	lock        sync.RWMutex
+	subscribers map[*sSubscriber]struct{}
+}
+
+func NewHub() *SHub {
+	return &SHub{subscribers: map[*sSubscriber]struct{}{}}
+}
+
+func (hub *SHub) Subscribe(filter SFilter) (<-chan *SCloudEvent, func()) {
+	sub := &sSubscriber{
+		filter: filter,
+		events: make(chan *SCloudEvent, subscriberBufferSize),
+	}
+	hub.lock.Lock()
+	hub.subscribers[sub] = struct{}{}
+	hub.lock.Unlock()
+	return sub.events, func() {
+		hub.lock.Lock()
+		defer hub.lock.Unlock()
+		if _, ok := hub.subscribers[sub]; ok {
+			delete(hub.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (hub *SHub) Publish(event *SCloudEvent, objType string) {
+	hub.lock.RLock()
+	defer hub.lock.RUnlock()
+	for sub := range hub.subscribers {
+		if !sub.filter.Match(event, objType) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+func (hub *SHub) OnOpsLog(opslog *db.SOpsLog) {
+	event, ok := NewCloudEvent(opslog)
+	if !ok {
+		return
+	}
+	hub.Publish(event, opslog.ObjType)
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cloudevents
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+)
+
+func TestGetEventType(t *testing.T) {
+	cases := []struct {
+		objType string
+		action  string
+		want    string
+		ok      bool
+	}{
+		{"server", db.ACT_CREATE, "io.yunion.cloudpods.server.created", true},
+		{"disk", db.ACT_DELOCATE, "io.yunion.cloudpods.disk.deleted", true},
+		{"vpc", db.ACT_SYNC_UPDATE, "io.yunion.cloudpods.vpc.synced", true},
+		{"server", db.ACT_ATTACH, "", false},
+	}
+	for i, c := range cases {
+		got, ok := GetEventType(c.objType, c.action)
+		if got != c.want || ok != c.ok {
+			t.Errorf("case %d: want %s(%v) got %s(%v)", i, c.want, c.ok, got, ok)
+		}
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+	projectEvents, cancelProject := hub.Subscribe(SFilter{ProjectId: "p1"})
+	defer cancelProject()
+	diskEvents, cancelDisk := hub.Subscribe(SFilter{ResourceTypes: []string{"disk"}})
+	defer cancelDisk()
+
+	hub.OnOpsLog(&db.SOpsLog{
+		OpsTime:        time.Now(),
+		ObjType:        "server",
+		ObjId:          "s1",
+		Action:         db.ACT_UPDATE,
+		Notes:          `{"name":"new"}`,
+		ProjectId:      "p2",
+		OwnerProjectId: "p1",
+		OwnerDomainId:  "d1",
+	})
+	hub.OnOpsLog(&db.SOpsLog{ObjType: "server", ObjId: "s1", Action: db.ACT_ATTACH, OwnerProjectId: "p1"})
+
+	select {
+	case event := <-projectEvents:
+		if event.Subject != "s1" || event.ProjectId != "p1" || event.DomainId != "d1" {
+			t.Errorf("unexpected event %#v", event)
+		}
+		if name, _ := event.Data.GetString("notes", "name"); name != "new" {
+			t.Errorf("want notes name new got %s", name)
+		}
+	default:
+		t.Errorf("project subscriber should receive event")
+	}
+	select {
+	case event := <-projectEvents:
+		t.Errorf("unexpected event %s", event.Type)
+	default:
+	}
+	select {
+	case event := <-diskEvents:
+		t.Errorf("disk subscriber should not receive %s", event.Type)
+	default:
+	}
+}
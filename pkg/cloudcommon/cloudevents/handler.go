@@ -0,0 +1,167 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cloudevents
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/rbacscope"
+
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+)
+
+const (
+	heartbeatInterval = 30 * time.Second
+)
+
+var (
+	defaultHub = NewHub()
+	hookOnce   sync.Once
+
+	// 每个订阅连接长期占用一个worker
+	streamWorkerMan = appsrv.NewWorkerManager("CloudEventsStreamWorkerManager", 256, 16, false)
+)
+
+type sStreamInput struct {
+	// 仅推送指定项目的事件
+	ProjectId string `json:"project_id"`
+	// 仅推送指定资源类型的事件, 例如 server, disk
+	ResourceType []string `json:"resource_type"`
+}
+
+// 以Server-Sent Events方式推送CloudEvents格式的资源变更事件
+func AddCloudEventsHandler(prefix string, app *appsrv.Application) {
+	hookOnce.Do(func() {
+		db.AddOpsLogEventHook(defaultHub.OnOpsLog)
+	})
+	hi := app.AddHandler2("GET", fmt.Sprintf("%s/cloudevents", prefix), auth.Authenticate(streamHandler), nil, "cloudevents_stream", nil)
+	hi.SetProcessNoTimeout().SetWorkerManager(streamWorkerMan)
+}
+
+func isAllow(userCred mcclient.TokenCredential, scope rbacscope.TRbacScope) bool {
+	return userCred.IsAllow(scope, consts.GetServiceType(), db.OpsLog.KeywordPlural(), policy.PolicyActionList).Result.IsAllow()
+}
+
+// 根据用户权限确定可订阅的事件范围
+func getFilter(ctx context.Context, userCred mcclient.TokenCredential, input sStreamInput) (SFilter, error) {
+	filter := SFilter{ResourceTypes: input.ResourceType}
+	if len(input.ProjectId) > 0 {
+		tenant, err := db.TenantCacheManager.FetchTenantByIdOrName(ctx, input.ProjectId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return filter, httperrors.NewResourceNotFoundError2("project", input.ProjectId)
+			}
+			return filter, httperrors.NewGeneralError(err)
+		}
+		scope := rbacscope.ScopeSystem
+		if tenant.Id == userCred.GetProjectId() {
+			scope = rbacscope.ScopeProject
+		} else if tenant.DomainId == userCred.GetProjectDomainId() {
+			scope = rbacscope.ScopeDomain
+		}
+		if !isAllow(userCred, scope) {
+			return filter, httperrors.NewForbiddenError("not allow to subscribe events of project %s", tenant.Name)
+		}
+		filter.ProjectId = tenant.Id
+		return filter, nil
+	}
+	switch {
+	case isAllow(userCred, rbacscope.ScopeSystem):
+	case isAllow(userCred, rbacscope.ScopeDomain):
+		filter.DomainId = userCred.GetProjectDomainId()
+	case isAllow(userCred, rbacscope.ScopeProject):
+		filter.ProjectId = userCred.GetProjectId()
+	default:
+		return filter, httperrors.NewForbiddenError("not allow to subscribe events")
+	}
+	return filter, nil
+}
+
+func writeEvent(w http.ResponseWriter, event *SCloudEvent) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Type, jsonutils.Marshal(event).String())
+	return err
+}
+
+func streamHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	userCred := auth.FetchUserCredential(ctx, policy.FilterPolicyCredential)
+	query, err := jsonutils.ParseQueryString(r.URL.RawQuery)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	input := sStreamInput{}
+	err = query.Unmarshal(&input)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, httperrors.NewInputParameterError("unmarshal input: %v", err))
+		return
+	}
+	filter, err := getFilter(ctx, userCred, input)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httperrors.GeneralServerError(ctx, w, httperrors.NewNotSupportedError("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events, cancel := defaultHub.Subscribe(filter)
+	defer cancel()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, event)
+			if err != nil {
+				log.Debugf("write cloudevent to %s error: %v", userCred.GetUserName(), err)
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
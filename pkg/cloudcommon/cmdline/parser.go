@@ -167,6 +167,11 @@ func ParseDiskConfig(diskStr string, idx int) (*compute.DiskConfig, error) {
 				return nil, errors.Errorf("invalid disk medium type %s, allow choices: %s", str, compute.DISK_TYPES)
 			}
 			diskConfig.Medium = str
+		case "tier", "storage_tier":
+			if !utils.IsInStringArray(str, compute.STORAGE_TIERS) {
+				return nil, errors.Errorf("invalid disk storage tier %s, allow choices: %s", str, compute.STORAGE_TIERS)
+			}
+			diskConfig.StorageTier = str
 		case "type", "disk_type":
 			diskTypes := []string{compute.DISK_TYPE_SYS, compute.DISK_TYPE_DATA}
 			if !utils.IsInStringArray(str, diskTypes) {
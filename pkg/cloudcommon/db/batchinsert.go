@@ -0,0 +1,178 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/informer"
+)
+
+type sBatchInsertGroup struct {
+	sql     string
+	values  []interface{}
+	objs    []IModel
+	primary []interface{}
+}
+
+// 将单行INSERT语句扩展为n行, 仅支持以 VALUES (...) 结尾的语句
+func mergeInsertSql(sql string, n int) (string, bool) {
+	idx := strings.LastIndex(sql, " VALUES (")
+	if idx < 0 || !strings.HasSuffix(sql, ")") {
+		return "", false
+	}
+	row := sql[idx+len(" VALUES "):]
+	return sql + strings.Repeat(", "+row, n-1), true
+}
+
+// 批量插入同一张表的记录, 列相同的记录合并为一条多行INSERT语句, 每条语句最多 batchSize 行
+// 插入后按主键回读以填充数据库生成的默认值, 批量插入失败时逐条重试, 返回值与 objs 一一对应
+// 更新仍逐条通过 Update 进行, 以保留每条记录的变更比对、版本号及操作日志
+func InsertBatch(ctx context.Context, manager IModelManager, objs []IModel, batchSize int) []error {
+	errs := make([]error, len(objs))
+	ts := manager.TableSpec()
+	primaries := ts.PrimaryColumns()
+	if batchSize <= 1 || len(objs) <= 1 || ts.GetSplitTable() != nil || len(primaries) != 1 || primaries[0].IsAutoIncrement() {
+		for i := range objs {
+			errs[i] = ts.Insert(ctx, objs[i])
+		}
+		return errs
+	}
+
+	table := ts.GetTableSpec()
+	pkName := primaries[0].Name()
+	index := make(map[IModel]int, len(objs))
+	groups := make(map[string]*sBatchInsertGroup)
+	sqls := make([]string, 0)
+	for i := range objs {
+		index[objs[i]] = i
+		result, err := table.InsertSqlPrep(objs[i], false)
+		if err != nil {
+			errs[i] = errors.Wrap(err, "InsertSqlPrep")
+			continue
+		}
+		group, ok := groups[result.Sql]
+		if !ok {
+			group = &sBatchInsertGroup{sql: result.Sql}
+			groups[result.Sql] = group
+			sqls = append(sqls, result.Sql)
+		}
+		group.values = append(group.values, result.Values...)
+		group.objs = append(group.objs, objs[i])
+		group.primary = append(group.primary, result.Primaries[pkName])
+	}
+
+	for _, sql := range sqls {
+		group := groups[sql]
+		width := len(group.values) / len(group.objs)
+		for start := 0; start < len(group.objs); start += batchSize {
+			end := start + batchSize
+			if end > len(group.objs) {
+				end = len(group.objs)
+			}
+			batch := group.objs[start:end]
+			err := insertBatch(table, group.sql, group.values[start*width:end*width], len(batch))
+			if err != nil {
+				log.Warningf("batch insert %d %s failed, fallback to insert one by one: %v", len(batch), manager.KeywordPlural(), err)
+				for _, obj := range batch {
+					errs[index[obj]] = ts.Insert(ctx, obj)
+				}
+				continue
+			}
+			err = fetchAfterBatchInsert(table, group.primary[start:end], batch)
+			if err != nil {
+				log.Warningf("fetch %d %s after batch insert failed, fallback to fetch one by one: %v", len(batch), manager.KeywordPlural(), err)
+			}
+			for i, obj := range batch {
+				if err != nil {
+					// 记录已写入, 回读失败时删除该记录再报错, 避免留下未完成初始化的记录
+					errs[index[obj]] = fetchOrDeleteAfterBatchInsert(table, group.primary[start+i], obj)
+					if errs[index[obj]] != nil {
+						continue
+					}
+				}
+				if sts, ok := ts.(*sTableSpec); ok {
+					sts.rejectRecordChecksumAfterInsert(obj)
+					sts.inform(ctx, obj, informer.Create)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func insertBatch(table *sqlchemy.STableSpec, sql string, values []interface{}, count int) error {
+	batchSql, ok := mergeInsertSql(sql, count)
+	if !ok {
+		return errors.Wrapf(errors.ErrNotSupported, "batch insert sql %s", sql)
+	}
+	result, err := table.Database().TxExec(batchSql, values...)
+	if err != nil {
+		return errors.Wrap(err, "TxExec")
+	}
+	if cnt, err := result.RowsAffected(); err == nil && cnt != int64(count) {
+		return errors.Wrapf(sqlchemy.ErrUnexpectRowCount, "batch insert affected %d != %d", cnt, count)
+	}
+	return nil
+}
+
+func fetchOrDeleteAfterBatchInsert(table *sqlchemy.STableSpec, primary interface{}, obj IModel) error {
+	err := table.Fetch(obj)
+	if err == nil {
+		return nil
+	}
+	pkName := table.PrimaryColumns()[0].Name()
+	_, delErr := table.Database().TxExec(fmt.Sprintf("delete from %s where %s = ?", table.Name(), pkName), primary)
+	if delErr != nil {
+		log.Errorf("delete %s %v of %s after fetch failure: %v", pkName, primary, table.Name(), delErr)
+	}
+	return errors.Wrap(err, "fetch after batch insert")
+}
+
+// 回读记录, 使数据库填充的默认值(如created_at)反映到对象中
+func fetchAfterBatchInsert(table *sqlchemy.STableSpec, primary []interface{}, objs []IModel) error {
+	pkName := table.PrimaryColumns()[0].Name()
+	objMap := make(map[string]IModel, len(objs))
+	for i := range objs {
+		objMap[fmt.Sprintf("%v", primary[i])] = objs[i]
+	}
+	q := table.Query().In(pkName, primary)
+	rows, err := q.Rows()
+	if err != nil {
+		return errors.Wrap(err, "query after batch insert")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row, err := q.Row2Map(rows)
+		if err != nil {
+			return errors.Wrap(err, "Row2Map")
+		}
+		obj, ok := objMap[row[pkName]]
+		if !ok {
+			continue
+		}
+		err = q.RowMap2Struct(row, obj)
+		if err != nil {
+			return errors.Wrap(err, "RowMap2Struct")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import "testing"
+
+func TestMergeInsertSql(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		n      int
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "single",
+			sql:    "INSERT INTO `t` (`a`, `b`) VALUES (?, ?)",
+			n:      1,
+			want:   "INSERT INTO `t` (`a`, `b`) VALUES (?, ?)",
+			wantOk: true,
+		},
+		{
+			name:   "multiple",
+			sql:    "INSERT INTO `t` (`a`, `created_at`) VALUES (?, UTC_TIMESTAMP())",
+			n:      3,
+			want:   "INSERT INTO `t` (`a`, `created_at`) VALUES (?, UTC_TIMESTAMP()), (?, UTC_TIMESTAMP()), (?, UTC_TIMESTAMP())",
+			wantOk: true,
+		},
+		{
+			name:   "upsert",
+			sql:    "INSERT INTO `t` (`a`) VALUES (?) ON DUPLICATE KEY UPDATE `a` = ?",
+			n:      2,
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mergeInsertSql(tt.sql, tt.n)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("mergeInsertSql() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
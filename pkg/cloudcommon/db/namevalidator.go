@@ -124,6 +124,20 @@ func GenerateAlterName(model IModel, hint string) (string, error) {
 }
 
 func GenerateName2(ctx context.Context, manager IModelManager, ownerId mcclient.IIdentityProvider, hint string, model IModel, baseIndex int) (string, error) {
+	return generateName(manager, ownerId, hint, model, baseIndex, nil)
+}
+
+// 批量插入前生成名称, reserved 为尚未写入数据库的待插入记录名称, 生成的名称同时加入 reserved
+func GenerateBatchName(ctx context.Context, manager IModelManager, ownerId mcclient.IIdentityProvider, hint string, reserved map[string]bool) (string, error) {
+	name, err := generateName(manager, ownerId, hint, nil, 1, reserved)
+	if err != nil {
+		return "", err
+	}
+	reserved[name] = true
+	return name, nil
+}
+
+func generateName(manager IModelManager, ownerId mcclient.IIdentityProvider, hint string, model IModel, baseIndex int, reserved map[string]bool) (string, error) {
 	_, pattern, patternLen, offset := stringutils2.ParseNamePattern2(hint)
 	var name string
 	if patternLen == 0 {
@@ -136,6 +150,11 @@ func GenerateName2(ctx context.Context, manager IModelManager, ownerId mcclient.
 		baseIndex += 1
 	}
 	for {
+		if reserved[name] {
+			name = fmt.Sprintf(pattern, baseIndex)
+			baseIndex += 1
+			continue
+		}
 		var uniq bool
 		var err error
 		if model == nil {
@@ -118,6 +118,15 @@ func (opslog *SOpsLog) GetModelManager() IModelManager {
 	return OpsLog
 }
 
+type OpsLogEventHook func(opslog *SOpsLog)
+
+var opslogEventHooks []OpsLogEventHook
+
+// 注册操作日志回调, 须在服务启动时注册, 回调中不应阻塞
+func AddOpsLogEventHook(hook OpsLogEventHook) {
+	opslogEventHooks = append(opslogEventHooks, hook)
+}
+
 func (manager *SOpsLogManager) LogEvent(model IModel, action string, notes interface{}, userCred mcclient.TokenCredential) {
 	if !consts.OpsLogEnabled() {
 		return
@@ -195,6 +204,10 @@ func (manager *SOpsLogManager) LogEvent(model IModel, action string, notes inter
 		}
 	}
 
+	for _, hook := range opslogEventHooks {
+		hook(opslog)
+	}
+
 	opslogWriteWorkerMan.Run(opslog, nil, nil)
 }
 
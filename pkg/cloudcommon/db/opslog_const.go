@@ -113,11 +113,15 @@ const (
 	ACT_SNAPSHOT_POLICY_BIND_DISK_FAIL   = "snapshot_policy_bind_disk_fail"
 	ACT_SNAPSHOT_POLICY_UNBIND_DISK      = "snapshot_policy_unbind_disk"
 	ACT_SNAPSHOT_POLICY_UNBIND_DISK_FAIL = "snapshot_policy_unbind_disk_fail"
+	ACT_SNAPSHOT_POLICY_BIND_SERVER      = "snapshot_policy_bind_server"
+	ACT_SNAPSHOT_POLICY_UNBIND_SERVER    = "snapshot_policy_unbind_server"
 
 	ACT_DISK_CLEAN_UP_SNAPSHOTS      = "disk_clean_up_snapshots"
 	ACT_DISK_CLEAN_UP_SNAPSHOTS_FAIL = "disk_clean_up_snapshots_fail"
 	ACT_DISK_AUTO_SNAPSHOT           = "disk_auto_snapshot"
 	ACT_DISK_AUTO_SNAPSHOT_FAIL      = "disk_auto_snapshot_fail"
+	ACT_SERVER_AUTO_SNAPSHOT         = "server_auto_snapshot"
+	ACT_SERVER_AUTO_SNAPSHOT_FAIL    = "server_auto_snapshot_fail"
 
 	ACT_DISK_AUTO_SYNC_SNAPSHOT      = "disk_auto_sync_snapshot"
 	ACT_DISK_AUTO_SYNC_SNAPSHOT_FAIL = "disk_auto_sync_snapshot_fail"
@@ -258,6 +262,7 @@ const (
 	ACT_GUEST_PANICKED                   = "guest_panicked"
 	ACT_HOST_MAINTENANCE                 = "host_maintenance"
 	ACT_HOST_DOWN                        = "host_down"
+	ACT_ZONE_REBALANCE                   = "zone_rebalance"
 
 	ACT_UPLOAD_OBJECT  = "upload_obj"
 	ACT_DELETE_OBJECT  = "delete_obj"
@@ -270,6 +275,9 @@ const (
 	ACT_SET_POLICY     = "set_policy"
 	ACT_DELETE_POLICY  = "delete_policy"
 
+	ACT_REPLICATE      = "replicate"
+	ACT_REPLICATE_FAIL = "replicate_fail"
+
 	ACT_GRANT_PRIVILEGE  = "grant_privilege"
 	ACT_REVOKE_PRIVILEGE = "revoke_privilege"
 	ACT_SET_PRIVILEGES   = "set_privileges"
@@ -72,7 +72,9 @@ type IQuotaManager interface {
 	db.IResourceModelManager
 
 	checkSetPendingQuota(ctx context.Context, userCred mcclient.TokenCredential, quota IQuota) error
+	checkQuota(ctx context.Context, request IQuota) error
 	cancelPendingUsage(ctx context.Context, userCred mcclient.TokenCredential, localUsage IQuota, cancelUsage IQuota, save bool) error
+	addPendingUsage(ctx context.Context, userCred mcclient.TokenCredential, quota IQuota) error
 	cancelUsage(ctx context.Context, userCred mcclient.TokenCredential, usage IQuota) error
 	addUsage(ctx context.Context, userCred mcclient.TokenCredential, usage IQuota) error
 	getQuotaCount(ctx context.Context, request IQuota, pendingKey IQuotaKeys) (int, error)
@@ -102,6 +102,17 @@ func (manager *SQuotaBaseManager) _cancelPendingUsage(ctx context.Context, userC
 	return nil
 }
 
+func (manager *SQuotaBaseManager) addPendingUsage(ctx context.Context, userCred mcclient.TokenCredential, quota IQuota) error {
+	LockQuota(ctx, manager, quota)
+	defer ReleaseQuota(ctx, manager, quota)
+
+	err := manager.pendingStore.AddQuota(ctx, userCred, quota)
+	if err != nil {
+		return errors.Wrap(err, "manager.pendingStore.AddQuota")
+	}
+	return nil
+}
+
 func (manager *SQuotaBaseManager) cancelUsage(ctx context.Context, userCred mcclient.TokenCredential, usage IQuota) error {
 	LockQuota(ctx, manager, usage)
 	defer ReleaseQuota(ctx, manager, usage)
@@ -27,8 +27,16 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient"
 )
 
+type TPendingQuotaCheckHook func(ctx context.Context, userCred mcclient.TokenCredential, quota IQuota) error
+
+type TCancelPendingUsageHook func(ctx context.Context, userCred mcclient.TokenCredential, cancelUsage IQuota, save bool)
+
 var (
 	quotaManagerTable map[reflect.Type]IQuotaManager
+
+	pendingQuotaCheckHooks []TPendingQuotaCheckHook
+
+	cancelPendingUsageHooks []TCancelPendingUsageHook
 )
 
 func init() {
@@ -47,6 +55,17 @@ func Register(manager IQuotaManager) {
 	manager.SetVirtualObject(manager)
 }
 
+// RegisterPendingQuotaCheckHook 注册资源创建前的额外检查, 例如预算超支时阻止新建资源
+// 与配额检查一样, 仅在开启配额检查时执行
+func RegisterPendingQuotaCheckHook(hook TPendingQuotaCheckHook) {
+	pendingQuotaCheckHooks = append(pendingQuotaCheckHooks, hook)
+}
+
+// RegisterCancelPendingUsageHook 注册待创建资源转为已用(save为true)或被取消后的回调
+func RegisterCancelPendingUsageHook(hook TCancelPendingUsageHook) {
+	cancelPendingUsageHooks = append(cancelPendingUsageHooks, hook)
+}
+
 func getQuotaManager(quota IQuota) IQuotaManager {
 	quotaType := reflect.Indirect(reflect.ValueOf(quota)).Type()
 	if m, ok := quotaManagerTable[quotaType]; ok {
@@ -66,7 +85,28 @@ func CancelPendingUsage(ctx context.Context, userCred mcclient.TokenCredential,
 		return nil
 	}
 	manager := getQuotaManager(cancelUsage)
-	return manager.cancelPendingUsage(ctx, userCred, localUsage, cancelUsage, save)
+	err := manager.cancelPendingUsage(ctx, userCred, localUsage, cancelUsage, save)
+	if err != nil {
+		return err
+	}
+	runCancelPendingUsageHooks(ctx, userCred, cancelUsage, save)
+	return nil
+}
+
+func runCancelPendingUsageHooks(ctx context.Context, userCred mcclient.TokenCredential, cancelUsage IQuota, save bool) {
+	for _, hook := range cancelPendingUsageHooks {
+		hook(ctx, userCred, cancelUsage, save)
+	}
+}
+
+// AddPendingUsage 不检查配额直接增加待创建资源, 用于退回之前转出的待创建资源
+func AddPendingUsage(ctx context.Context, userCred mcclient.TokenCredential, quota IQuota) error {
+	if !consts.EnableQuotaCheck() {
+		return nil
+	}
+
+	manager := getQuotaManager(quota)
+	return manager.addPendingUsage(ctx, userCred, quota)
 }
 
 func CheckSetPendingQuota(ctx context.Context, userCred mcclient.TokenCredential, quota IQuota) error {
@@ -74,15 +114,39 @@ func CheckSetPendingQuota(ctx context.Context, userCred mcclient.TokenCredential
 		return nil
 	}
 
+	for _, hook := range pendingQuotaCheckHooks {
+		err := hook(ctx, userCred, quota)
+		if err != nil {
+			runCancelPendingUsageHooks(ctx, userCred, quota, false)
+			return err
+		}
+	}
+
 	manager := getQuotaManager(quota)
 	err := manager.checkSetPendingQuota(ctx, userCred, quota)
 	if err != nil {
+		// 检查失败时撤销钩子已做的处理
+		runCancelPendingUsageHooks(ctx, userCred, quota, false)
 		return errors.Wrap(err, "manager.checkSetPendingQuota")
 	}
 	savePendingUsagesInContext(ctx, quota)
 	return nil
 }
 
+// CheckQuota 仅检查配额是否足够, 不设置pending用量也不执行检查钩子
+func CheckQuota(ctx context.Context, quota IQuota) error {
+	if !consts.EnableQuotaCheck() {
+		return nil
+	}
+
+	manager := getQuotaManager(quota)
+	err := manager.checkQuota(ctx, quota)
+	if err != nil {
+		return errors.Wrap(err, "manager.checkQuota")
+	}
+	return nil
+}
+
 func CancelUsages(ctx context.Context, userCred mcclient.TokenCredential, usages []db.IUsage) {
 	if !consts.EnableQuotaCheck() {
 		return
@@ -0,0 +1,93 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretstore
+
+import (
+	"context"
+	"fmt"
+
+	alisdk "github.com/aliyun/alibaba-cloud-sdk-go/sdk"
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	ALIYUN_KMS_VERSION = "2016-01-20"
+)
+
+// 阿里云KMS存储, 密文由KMS主密钥加密后作为引用保存, KMS本身不保存密钥
+type SAliyunKmsStore struct {
+	regionId string
+	keyId    string
+
+	client *alisdk.Client
+}
+
+func NewAliyunKmsStore(regionId, accessKey, secret, keyId string) (*SAliyunKmsStore, error) {
+	if len(regionId) == 0 || len(keyId) == 0 {
+		return nil, errors.Error("kms_region_id and kms_key_id are required")
+	}
+	client, err := alisdk.NewClientWithAccessKey(regionId, accessKey, secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "NewClientWithAccessKey")
+	}
+	return &SAliyunKmsStore{regionId: regionId, keyId: keyId, client: client}, nil
+}
+
+func (store *SAliyunKmsStore) GetName() string {
+	return SECRET_STORE_ALIYUN_KMS
+}
+
+func (store *SAliyunKmsStore) request(apiName string, params map[string]string) (jsonutils.JSONObject, error) {
+	req := requests.NewCommonRequest()
+	req.Method = "POST"
+	req.Scheme = "https"
+	req.Domain = fmt.Sprintf("kms.%s.aliyuncs.com", store.regionId)
+	req.Version = ALIYUN_KMS_VERSION
+	req.ApiName = apiName
+	for k, v := range params {
+		req.QueryParams[k] = v
+	}
+	resp, err := store.client.ProcessCommonRequest(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, apiName)
+	}
+	return jsonutils.Parse(resp.GetHttpContentBytes())
+}
+
+func (store *SAliyunKmsStore) Get(ctx context.Context, ref string) (string, error) {
+	resp, err := store.request("Decrypt", map[string]string{"CiphertextBlob": ref})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString("Plaintext")
+}
+
+func (store *SAliyunKmsStore) Put(ctx context.Context, key string, value string) (string, error) {
+	resp, err := store.request("Encrypt", map[string]string{
+		"KeyId":     store.keyId,
+		"Plaintext": value,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString("CiphertextBlob")
+}
+
+func (store *SAliyunKmsStore) Delete(ctx context.Context, ref string) error {
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	REF_PREFIX = "secretstore://"
+
+	SECRET_STORE_NONE       = "none"
+	SECRET_STORE_VAULT      = "vault"
+	SECRET_STORE_ALIYUN_KMS = "aliyun-kms"
+
+	ErrStoreNotFound = errors.Error("secret store not found")
+	ErrInvalidRef    = errors.Error("invalid secret reference")
+)
+
+// 外部密钥存储, Put返回的引用由存储自行解释
+type ISecretStore interface {
+	GetName() string
+
+	Get(ctx context.Context, ref string) (string, error)
+	Put(ctx context.Context, key string, value string) (string, error)
+	Delete(ctx context.Context, ref string) error
+}
+
+type SSecretStoreOptions struct {
+	SecretStore string `help:"external store for cloud account secrets, secrets are AES encrypted in database if none" default:"none" choices:"none|vault|aliyun-kms"`
+
+	VaultAddr  string `help:"address of HashiCorp Vault, e.g. https://vault.example.com:8200, keep it configured while any secret still references vault"`
+	VaultToken string `help:"token to access HashiCorp Vault"`
+	VaultMount string `help:"mount path of Vault KV v2 secrets engine" default:"secret"`
+	VaultPath  string `help:"path prefix of secrets in Vault" default:"cloudpods"`
+
+	KmsRegionId     string `help:"region of aliyun KMS"`
+	KmsAccessKey    string `help:"access key of aliyun KMS"`
+	KmsAccessSecret string `help:"access secret of aliyun KMS"`
+	KmsKeyId        string `help:"id or alias of aliyun KMS master key used to encrypt secrets, keep it configured while any secret still references aliyun-kms"`
+}
+
+var (
+	storeLock    sync.RWMutex
+	stores       = map[string]ISecretStore{}
+	defaultStore string
+)
+
+func Register(store ISecretStore) {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	stores[store.GetName()] = store
+}
+
+func GetStore(name string) (ISecretStore, error) {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+	store, ok := stores[name]
+	if !ok {
+		return nil, errors.Wrap(ErrStoreNotFound, name)
+	}
+	return store, nil
+}
+
+// 返回默认存储, 未配置外部存储时返回nil
+func GetDefaultStore() ISecretStore {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+	if len(defaultStore) == 0 {
+		return nil
+	}
+	return stores[defaultStore]
+}
+
+// 注册所有已配置的外部存储, SecretStore仅决定新密钥写入的存储
+// 已保存的引用按其中的存储名称解析, 切换默认存储后旧引用仍可读取并迁移
+func InitSecretStore(opts SSecretStoreOptions) error {
+	switch opts.SecretStore {
+	case "", SECRET_STORE_NONE, SECRET_STORE_VAULT, SECRET_STORE_ALIYUN_KMS:
+	default:
+		return errors.Wrapf(ErrStoreNotFound, opts.SecretStore)
+	}
+	configured := []ISecretStore{}
+	if len(opts.VaultAddr) > 0 || opts.SecretStore == SECRET_STORE_VAULT {
+		store, err := NewVaultStore(opts.VaultAddr, opts.VaultToken, opts.VaultMount, opts.VaultPath)
+		if err != nil {
+			return errors.Wrapf(err, "new %s store", SECRET_STORE_VAULT)
+		}
+		configured = append(configured, store)
+	}
+	if len(opts.KmsKeyId) > 0 || opts.SecretStore == SECRET_STORE_ALIYUN_KMS {
+		store, err := NewAliyunKmsStore(opts.KmsRegionId, opts.KmsAccessKey, opts.KmsAccessSecret, opts.KmsKeyId)
+		if err != nil {
+			return errors.Wrapf(err, "new %s store", SECRET_STORE_ALIYUN_KMS)
+		}
+		configured = append(configured, store)
+	}
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	defaultStore = ""
+	for _, store := range configured {
+		stores[store.GetName()] = store
+		if store.GetName() == opts.SecretStore {
+			defaultStore = store.GetName()
+		}
+	}
+	return nil
+}
+
+func IsRef(secret string) bool {
+	return strings.HasPrefix(secret, REF_PREFIX)
+}
+
+func FormatRef(store, ref string) string {
+	return fmt.Sprintf("%s%s/%s", REF_PREFIX, store, ref)
+}
+
+// secretstore://<store>/<ref>
+func ParseRef(secret string) (string, string, error) {
+	if !IsRef(secret) {
+		return "", "", ErrInvalidRef
+	}
+	parts := strings.SplitN(strings.TrimPrefix(secret, REF_PREFIX), "/", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", errors.Wrap(ErrInvalidRef, secret)
+	}
+	return parts[0], parts[1], nil
+}
+
+// 解析引用并从对应存储读取密钥
+func Resolve(ctx context.Context, secret string) (string, error) {
+	name, ref, err := ParseRef(secret)
+	if err != nil {
+		return "", err
+	}
+	store, err := GetStore(name)
+	if err != nil {
+		return "", err
+	}
+	value, err := store.Get(ctx, ref)
+	if err != nil {
+		return "", errors.Wrapf(err, "get secret from %s", name)
+	}
+	return value, nil
+}
+
+// 将密钥保存到默认存储, 返回可写入数据库的引用
+func Store(ctx context.Context, key, value string) (string, error) {
+	store := GetDefaultStore()
+	if store == nil {
+		return "", ErrStoreNotFound
+	}
+	ref, err := store.Put(ctx, key, value)
+	if err != nil {
+		return "", errors.Wrapf(err, "put secret to %s", store.GetName())
+	}
+	return FormatRef(store.GetName(), ref), nil
+}
+
+func Remove(ctx context.Context, secret string) error {
+	name, ref, err := ParseRef(secret)
+	if err != nil {
+		return err
+	}
+	store, err := GetStore(name)
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, ref)
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretstore
+
+import (
+	"context"
+	"testing"
+)
+
+type sMemStore struct {
+	data map[string]string
+}
+
+func (store *sMemStore) GetName() string {
+	return "mem"
+}
+
+func (store *sMemStore) Get(ctx context.Context, ref string) (string, error) {
+	return store.data[ref], nil
+}
+
+func (store *sMemStore) Put(ctx context.Context, key string, value string) (string, error) {
+	store.data[key] = value
+	return key, nil
+}
+
+func (store *sMemStore) Delete(ctx context.Context, ref string) error {
+	delete(store.data, ref)
+	return nil
+}
+
+func TestParseRef(t *testing.T) {
+	cases := []struct {
+		in    string
+		store string
+		ref   string
+		fail  bool
+	}{
+		{in: "secretstore://vault/cloudpods/cloudaccounts/abc", store: "vault", ref: "cloudpods/cloudaccounts/abc"},
+		{in: "secretstore://aliyun-kms/MDAx+/Zm9v==", store: "aliyun-kms", ref: "MDAx+/Zm9v=="},
+		{in: "secretstore://vault/", fail: true},
+		{in: "secretstore://vault", fail: true},
+		{in: "c2VjcmV0Cg==", fail: true},
+	}
+	for _, c := range cases {
+		store, ref, err := ParseRef(c.in)
+		if c.fail {
+			if err == nil {
+				t.Errorf("%s: expect error", c.in)
+			}
+			continue
+		}
+		if err != nil || store != c.store || ref != c.ref {
+			t.Errorf("%s: got %s %s %v, want %s %s", c.in, store, ref, err, c.store, c.ref)
+		}
+	}
+}
+
+func TestStoreResolve(t *testing.T) {
+	Register(&sMemStore{data: map[string]string{}})
+	storeLock.Lock()
+	defaultStore = "mem"
+	storeLock.Unlock()
+	defer func() {
+		storeLock.Lock()
+		defaultStore = ""
+		storeLock.Unlock()
+	}()
+
+	ctx := context.Background()
+	ref, err := Store(ctx, "cloudaccounts/abc", "secret")
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if ref != "secretstore://mem/cloudaccounts/abc" {
+		t.Errorf("unexpected ref %s", ref)
+	}
+	value, err := Resolve(ctx, ref)
+	if err != nil || value != "secret" {
+		t.Errorf("Resolve: %s %v", value, err)
+	}
+	err = Remove(ctx, ref)
+	if err != nil {
+		t.Errorf("Remove: %v", err)
+	}
+	_, err = Resolve(ctx, "secretstore://unknown/abc")
+	if err == nil {
+		t.Errorf("expect error for unknown store")
+	}
+}
+
+func TestInitSecretStore(t *testing.T) {
+	defer func() {
+		storeLock.Lock()
+		delete(stores, SECRET_STORE_VAULT)
+		defaultStore = ""
+		storeLock.Unlock()
+	}()
+
+	opts := SSecretStoreOptions{
+		SecretStore: SECRET_STORE_VAULT,
+		VaultAddr:   "https://vault.example.com:8200",
+		VaultToken:  "token",
+	}
+	err := InitSecretStore(opts)
+	if err != nil {
+		t.Fatalf("InitSecretStore: %v", err)
+	}
+	if store := GetDefaultStore(); store == nil || store.GetName() != SECRET_STORE_VAULT {
+		t.Errorf("want vault as default store")
+	}
+
+	// 切换回数据库加密后, 仍已配置的vault继续用于解析旧引用
+	opts.SecretStore = SECRET_STORE_NONE
+	err = InitSecretStore(opts)
+	if err != nil {
+		t.Fatalf("InitSecretStore: %v", err)
+	}
+	if GetDefaultStore() != nil {
+		t.Errorf("want no default store")
+	}
+	if _, err := GetStore(SECRET_STORE_VAULT); err != nil {
+		t.Errorf("want vault still registered, got %v", err)
+	}
+
+	opts.SecretStore = SECRET_STORE_ALIYUN_KMS
+	if err := InitSecretStore(opts); err == nil {
+		t.Errorf("want error on unconfigured default store")
+	}
+	opts.SecretStore = "unknown"
+	if err := InitSecretStore(opts); err == nil {
+		t.Errorf("want error on unknown store")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/httputils"
+)
+
+// HashiCorp Vault KV v2 存储, 引用为密钥路径
+type SVaultStore struct {
+	addr  string
+	token string
+	mount string
+	path  string
+
+	client *http.Client
+}
+
+func NewVaultStore(addr, token, mount, path string) (*SVaultStore, error) {
+	if len(addr) == 0 || len(token) == 0 {
+		return nil, errors.Error("vault_addr and vault_token are required")
+	}
+	if len(mount) == 0 {
+		mount = "secret"
+	}
+	return &SVaultStore{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		path:   strings.Trim(path, "/"),
+		client: httputils.GetTimeoutClient(30 * time.Second),
+	}, nil
+}
+
+func (store *SVaultStore) GetName() string {
+	return SECRET_STORE_VAULT
+}
+
+func (store *SVaultStore) url(kind, ref string) string {
+	return fmt.Sprintf("%s/v1/%s/%s/%s", store.addr, store.mount, kind, ref)
+}
+
+func (store *SVaultStore) header() http.Header {
+	header := http.Header{}
+	header.Set("X-Vault-Token", store.token)
+	return header
+}
+
+func (store *SVaultStore) Get(ctx context.Context, ref string) (string, error) {
+	_, resp, err := httputils.JSONRequest(store.client, ctx, httputils.GET, store.url("data", ref), store.header(), nil, false)
+	if err != nil {
+		return "", errors.Wrapf(err, "read %s", ref)
+	}
+	value, err := resp.GetString("data", "data", "value")
+	if err != nil {
+		return "", errors.Wrapf(err, "get value of %s", ref)
+	}
+	return value, nil
+}
+
+func (store *SVaultStore) Put(ctx context.Context, key string, value string) (string, error) {
+	ref := strings.Trim(key, "/")
+	if len(store.path) > 0 {
+		ref = store.path + "/" + ref
+	}
+	body := jsonutils.Marshal(map[string]interface{}{
+		"data": map[string]string{"value": value},
+	})
+	_, _, err := httputils.JSONRequest(store.client, ctx, httputils.POST, store.url("data", ref), store.header(), body, false)
+	if err != nil {
+		return "", errors.Wrapf(err, "write %s", ref)
+	}
+	return ref, nil
+}
+
+// 删除全部版本及元数据
+func (store *SVaultStore) Delete(ctx context.Context, ref string) error {
+	_, _, err := httputils.JSONRequest(store.client, ctx, httputils.DELETE, store.url("metadata", ref), store.header(), nil, false)
+	if err != nil {
+		return errors.Wrapf(err, "delete %s", ref)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudevent"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// 按域设置的云平台操作日志保留策略
+type SCloudeventRetentionPolicyManager struct {
+	db.SDomainLevelResourceBaseManager
+}
+
+var CloudeventRetentionPolicyManager *SCloudeventRetentionPolicyManager
+
+func init() {
+	CloudeventRetentionPolicyManager = &SCloudeventRetentionPolicyManager{
+		SDomainLevelResourceBaseManager: db.NewDomainLevelResourceBaseManager(
+			SCloudeventRetentionPolicy{},
+			"cloudevent_retention_policies_tbl",
+			"cloudevent_retention_policy",
+			"cloudevent_retention_policies",
+		),
+	}
+	CloudeventRetentionPolicyManager.SetVirtualObject(CloudeventRetentionPolicyManager)
+}
+
+type SCloudeventRetentionPolicy struct {
+	db.SDomainLevelResourceBase
+
+	// 保留天数
+	KeepDays int `nullable:"false" list:"domain" create:"domain_required" update:"domain"`
+}
+
+func (manager *SCloudeventRetentionPolicyManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.CloudeventRetentionPolicyCreateInput,
+) (api.CloudeventRetentionPolicyCreateInput, error) {
+	var err error
+	input.DomainLevelResourceCreateInput, err = manager.SDomainLevelResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.DomainLevelResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	if input.KeepDays <= 0 {
+		return input, httperrors.NewInputParameterError("keep_days must be greater than 0")
+	}
+	cnt, err := manager.Query().Equals("domain_id", ownerId.GetProjectDomainId()).CountWithError()
+	if err != nil {
+		return input, errors.Wrap(err, "CountWithError")
+	}
+	if cnt > 0 {
+		return input, httperrors.NewDuplicateResourceError("domain %s already has a retention policy", ownerId.GetProjectDomainId())
+	}
+	return input, nil
+}
+
+func (policy *SCloudeventRetentionPolicy) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.CloudeventRetentionPolicyUpdateInput,
+) (api.CloudeventRetentionPolicyUpdateInput, error) {
+	var err error
+	input.DomainLevelResourceBaseUpdateInput, err = policy.SDomainLevelResourceBase.ValidateUpdateData(ctx, userCred, query, input.DomainLevelResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	if input.KeepDays != nil && *input.KeepDays <= 0 {
+		return input, httperrors.NewInputParameterError("keep_days must be greater than 0")
+	}
+	return input, nil
+}
+
+// 云平台操作日志保留策略列表
+func (manager *SCloudeventRetentionPolicyManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.CloudeventRetentionPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	return manager.SDomainLevelResourceBaseManager.ListItemFilter(ctx, q, userCred, query.DomainLevelResourceListInput)
+}
+
+func (manager *SCloudeventRetentionPolicyManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.CloudeventRetentionPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	return manager.SDomainLevelResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.DomainLevelResourceListInput)
+}
+
+func (manager *SCloudeventRetentionPolicyManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return manager.SDomainLevelResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (manager *SCloudeventRetentionPolicyManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.CloudeventRetentionPolicyDetails {
+	rows := make([]api.CloudeventRetentionPolicyDetails, len(objs))
+	domainRows := manager.SDomainLevelResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i].DomainLevelResourceDetails = domainRows[i]
+	}
+	return rows
+}
+
+// 清理域内超过保留天数的云平台操作日志
+func (manager *SCloudeventManager) purgeDomainEvents(domainId string, before time.Time) error {
+	if consts.OpsLogWithClickhouse {
+		_, err := sqlchemy.GetDBWithName(db.ClickhouseDB).Exec(
+			fmt.Sprintf("ALTER TABLE %s DELETE WHERE domain_id = ? AND created_at < ?", manager.TableSpec().Name()),
+			domainId, before,
+		)
+		return err
+	}
+	split := manager.GetSplitTable()
+	if split == nil {
+		return nil
+	}
+	metas, err := split.GetTableMetas()
+	if err != nil {
+		return errors.Wrap(err, "GetTableMetas")
+	}
+	for i := range metas {
+		if !metas[i].StartDate.IsZero() && !metas[i].StartDate.Before(before) {
+			continue
+		}
+		tblSpec := split.GetTableSpec(metas[i])
+		_, err := tblSpec.Database().Exec(
+			fmt.Sprintf("delete from %s where domain_id = ? and created_at < ?", tblSpec.Name()),
+			domainId, before,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "purge %s", tblSpec.Name())
+		}
+	}
+	return nil
+}
+
+func (manager *SCloudeventRetentionPolicyManager) PurgeExpiredCloudevents(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	policies := []SCloudeventRetentionPolicy{}
+	err := db.FetchModelObjects(manager, manager.Query(), &policies)
+	if err != nil {
+		log.Errorf("fetch cloudevent retention policies error: %v", err)
+		return
+	}
+	for i := range policies {
+		before := time.Now().AddDate(0, 0, -policies[i].KeepDays)
+		err := CloudeventManager.purgeDomainEvents(policies[i].DomainId, before)
+		if err != nil {
+			log.Errorf("purge cloudevents for domain %s before %s error: %v", policies[i].DomainId, before, err)
+		}
+	}
+}
@@ -92,6 +92,13 @@ type SCloudevent struct {
 	Manager         string `width:"128" charset:"utf8" nullable:"false" index:"true" list:"user"`
 	Provider        string `width:"64" charset:"ascii" nullable:"false" list:"user"`
 	Brand           string `width:"64" charset:"ascii" list:"domain"`
+
+	// 归一化操作人
+	Actor string `width:"128" charset:"utf8" nullable:"true" index:"true" list:"user"`
+	// 归一化操作动作
+	Verb string `width:"16" charset:"ascii" nullable:"true" index:"true" list:"user"`
+	// 归一化资源类型
+	Resource string `width:"64" charset:"ascii" nullable:"true" index:"true" list:"user"`
 }
 
 func (event *SCloudevent) BeforeInsert() {
@@ -151,6 +158,26 @@ func (manager *SCloudeventManager) ListItemFilter(
 		q = q.Equals("success", *input.Success)
 	}
 
+	if len(input.Actor) > 0 {
+		q = q.In("actor", input.Actor)
+	}
+
+	if len(input.Verb) > 0 {
+		q = q.In("verb", input.Verb)
+	}
+
+	if len(input.Resource) > 0 {
+		q = q.In("resource", input.Resource)
+	}
+
+	if len(input.Search) > 0 {
+		q = q.Filter(sqlchemy.OR(
+			sqlchemy.Contains(q.Field("name"), input.Search),
+			sqlchemy.Contains(q.Field("actor"), input.Search),
+			sqlchemy.Contains(q.Field("action"), input.Search),
+		))
+	}
+
 	if !input.Since.IsZero() {
 		q = q.GT("created_at", input.Since)
 	}
@@ -242,6 +269,7 @@ func (manager *SCloudeventManager) SyncCloudevent(ctx context.Context, userCred
 		if len(event.Brand) == 0 {
 			event.Brand = event.Provider
 		}
+		event.normalize()
 
 		for k, v := range map[string]string{
 			"service":       event.Service,
@@ -251,6 +279,9 @@ func (manager *SCloudeventManager) SyncCloudevent(ctx context.Context, userCred
 			"manager":       event.Manager,
 			"provider":      event.Provider,
 			"brand":         event.Brand,
+			"actor":         event.Actor,
+			"verb":          event.Verb,
+			"resource":      event.Resource,
 		} {
 			db.DistinctFieldManager.InsertOrUpdate(ctx, manager, k, v)
 		}
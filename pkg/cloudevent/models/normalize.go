@@ -0,0 +1,172 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudevent"
+)
+
+// 各平台操作名称首个单词与归一化动作的对应关系
+var cloudeventVerbs = map[string]string{
+	"create":   api.CLOUD_EVENT_VERB_CREATE,
+	"run":      api.CLOUD_EVENT_VERB_CREATE,
+	"allocate": api.CLOUD_EVENT_VERB_CREATE,
+	"launch":   api.CLOUD_EVENT_VERB_CREATE,
+	"put":      api.CLOUD_EVENT_VERB_CREATE,
+
+	"delete":    api.CLOUD_EVENT_VERB_DELETE,
+	"terminate": api.CLOUD_EVENT_VERB_DELETE,
+	"release":   api.CLOUD_EVENT_VERB_DELETE,
+	"remove":    api.CLOUD_EVENT_VERB_DELETE,
+	"destroy":   api.CLOUD_EVENT_VERB_DELETE,
+
+	"modify": api.CLOUD_EVENT_VERB_UPDATE,
+	"update": api.CLOUD_EVENT_VERB_UPDATE,
+	"set":    api.CLOUD_EVENT_VERB_UPDATE,
+	"write":  api.CLOUD_EVENT_VERB_UPDATE,
+	"change": api.CLOUD_EVENT_VERB_UPDATE,
+	"reset":  api.CLOUD_EVENT_VERB_UPDATE,
+	"resize": api.CLOUD_EVENT_VERB_UPDATE,
+
+	"start": api.CLOUD_EVENT_VERB_START,
+	"stop":  api.CLOUD_EVENT_VERB_STOP,
+
+	"reboot":  api.CLOUD_EVENT_VERB_RESTART,
+	"restart": api.CLOUD_EVENT_VERB_RESTART,
+
+	"attach":    api.CLOUD_EVENT_VERB_ATTACH,
+	"associate": api.CLOUD_EVENT_VERB_ATTACH,
+	"bind":      api.CLOUD_EVENT_VERB_ATTACH,
+	"mount":     api.CLOUD_EVENT_VERB_ATTACH,
+
+	"detach":       api.CLOUD_EVENT_VERB_DETACH,
+	"disassociate": api.CLOUD_EVENT_VERB_DETACH,
+	"unbind":       api.CLOUD_EVENT_VERB_DETACH,
+	"unassign":     api.CLOUD_EVENT_VERB_DETACH,
+	"umount":       api.CLOUD_EVENT_VERB_DETACH,
+
+	"describe": api.CLOUD_EVENT_VERB_READ,
+	"get":      api.CLOUD_EVENT_VERB_READ,
+	"list":     api.CLOUD_EVENT_VERB_READ,
+	"query":    api.CLOUD_EVENT_VERB_READ,
+	"read":     api.CLOUD_EVENT_VERB_READ,
+	"show":     api.CLOUD_EVENT_VERB_READ,
+}
+
+// 资源类型关键字, 按顺序匹配, 更具体的关键字需排在前面
+var cloudeventResourceKeywords = []struct {
+	resource string
+	keywords []string
+}{
+	{api.CLOUD_EVENT_RESOURCE_TYPE_LOADBALANCERLISTENER, []string{"listener"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_LOADBALANCER, []string{"loadbalancer", "load_balancer"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_NATGATEWAY, []string{"natgateway", "nat_gateway"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_SECGROUPCACHE, []string{"securitygroup", "security_group", "secgroup"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_SNAPSHOTPOLICY, []string{"snapshotpolicy", "snapshot_policy"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_SNAPSHOT, []string{"snapshot"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_NETWORKINTERFACE, []string{"networkinterface", "network_interface"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_EIP, []string{"eip", "elasticip", "publicip", "address"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_DBINSTANCE, []string{"dbinstance", "dbcluster"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_DISK, []string{"disk", "volume"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_IMAGE, []string{"image"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_VPC, []string{"vpc", "virtualnetwork"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_NETWORK, []string{"subnet", "vswitch", "network"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_BUCKET, []string{"bucket"}},
+	{api.CLOUD_EVENT_RESOURCE_TYPE_SERVER, []string{"instance", "server", "virtualmachine"}},
+}
+
+// 取操作名称的最后一段, 如azure的 Microsoft.Compute/virtualMachines/start/action
+func lastActionSegment(action string) string {
+	segs := strings.FieldsFunc(action, func(r rune) bool {
+		return r == '/' || r == ':' || r == '.'
+	})
+	for i := len(segs) - 1; i >= 0; i-- {
+		seg := strings.TrimSpace(segs[i])
+		if len(seg) > 0 && !strings.EqualFold(seg, "action") {
+			return seg
+		}
+	}
+	return ""
+}
+
+// 取驼峰或下划线命名的首个单词, 如 RunInstances => run
+func firstActionWord(action string) string {
+	word := []rune{}
+	for i, r := range action {
+		if r == '_' || r == '-' || r == ' ' {
+			if len(word) > 0 {
+				break
+			}
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && len(word) > 0 {
+			break
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	return string(word)
+}
+
+func normalizeCloudeventVerb(actions ...string) string {
+	for _, action := range actions {
+		word := firstActionWord(lastActionSegment(action))
+		if verb, ok := cloudeventVerbs[word]; ok {
+			return verb
+		}
+	}
+	return api.CLOUD_EVENT_VERB_OTHER
+}
+
+func normalizeCloudeventResource(fields ...string) string {
+	keys := []string{}
+	for _, field := range fields {
+		if len(field) > 0 {
+			keys = append(keys, strings.ToLower(field))
+		}
+	}
+	for _, key := range keys {
+		for _, res := range cloudeventResourceKeywords {
+			for _, keyword := range res.keywords {
+				if strings.Contains(key, keyword) {
+					return res.resource
+				}
+			}
+		}
+	}
+	return api.CLOUD_EVENT_RESOURCE_TYPE_UNKNOWN
+}
+
+// 操作账号可能为 AK(用户名) 或 arn:aws:iam::123:user/name 形式, 仅保留用户名
+func normalizeCloudeventActor(account string) string {
+	actor := strings.TrimSpace(account)
+	if start, end := strings.Index(actor, "("), strings.LastIndex(actor, ")"); start >= 0 && end > start+1 {
+		actor = actor[start+1 : end]
+	}
+	if strings.HasPrefix(actor, "arn:") {
+		if idx := strings.LastIndex(actor, "/"); idx >= 0 {
+			actor = actor[idx+1:]
+		}
+	}
+	return actor
+}
+
+func (event *SCloudevent) normalize() {
+	event.Actor = normalizeCloudeventActor(event.Account)
+	event.Verb = normalizeCloudeventVerb(event.Action, event.Name)
+	event.Resource = normalizeCloudeventResource(event.ResourceType, event.Action, event.Name)
+}
@@ -23,6 +23,7 @@ var (
 	cloudeventSystemResources = []string{}
 	cloudeventDomainResources = []string{
 		"cloudevents",
+		"cloudevent_retention_policies",
 	}
 	cloudeventUserResources = []string{}
 )
@@ -62,6 +62,7 @@ func InitHandlers(app *appsrv.Application) {
 
 		proxy.ProxySettingManager,
 		models.CloudeventManager,
+		models.CloudeventRetentionPolicyManager,
 	} {
 		db.RegisterModelManager(manager)
 		handler := db.NewModelHandler(manager)
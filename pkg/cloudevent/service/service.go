@@ -60,6 +60,7 @@ func StartService() {
 		cron.AddJobAtIntervalsWithStartRun("CloudeventSyncTask", time.Duration(opts.CloudeventSyncIntervalHours)*time.Hour, models.CloudproviderManager.SyncCloudeventTask, true)
 
 		cron.AddJobEveryFewHour("AutoPurgeSplitable", 4, 30, 0, db.AutoPurgeSplitable, false)
+		cron.AddJobEveryFewDays("PurgeExpiredCloudevents", 1, 2, 45, 0, models.CloudeventRetentionPolicyManager.PurgeExpiredCloudevents, false)
 
 		cron.Start()
 		defer cron.Stop()
@@ -132,7 +132,7 @@ func (self *SCloudaccount) GetClouduserAccountName(name string) (string, string)
 			account = u.Query().Get("account")
 		}
 	case computeapi.CLOUD_PROVIDER_AWS:
-		account := strings.TrimPrefix(self.IamLoginUrl, "https://")
+		account = strings.TrimPrefix(self.IamLoginUrl, "https://")
 		if info := strings.Split(account, "."); len(info) > 0 {
 			account = info[0]
 		}
@@ -140,6 +140,25 @@ func (self *SCloudaccount) GetClouduserAccountName(name string) (string, string)
 	return account, name
 }
 
+// 未同步到子账号登录地址时使用的各平台默认控制台登录地址
+// 仅包含支持子账号管理(IsSupportCloudIdService)的平台
+var defaultClouduserLoginUrls = map[string]string{
+	computeapi.CLOUD_PROVIDER_ALIYUN: "https://signin.aliyun.com/login.htm",
+	computeapi.CLOUD_PROVIDER_QCLOUD: "https://cloud.tencent.com/login/subAccount",
+	computeapi.CLOUD_PROVIDER_HUAWEI: "https://auth.huaweicloud.com/authui/login.html#/login",
+	computeapi.CLOUD_PROVIDER_AWS:    "https://console.aws.amazon.com/console/home",
+	computeapi.CLOUD_PROVIDER_AZURE:  "https://portal.azure.com",
+	computeapi.CLOUD_PROVIDER_GOOGLE: "https://console.cloud.google.com",
+}
+
+// 子账号控制台登录地址, 优先使用云上同步的地址
+func (self *SCloudaccount) GetClouduserLoginUrl() string {
+	if len(self.IamLoginUrl) > 0 {
+		return self.IamLoginUrl
+	}
+	return defaultClouduserLoginUrls[self.Provider]
+}
+
 func (manager *SCloudaccountManager) GetCloudaccounts() ([]SCloudaccount, error) {
 	accounts := []SCloudaccount{}
 	q := manager.Query()
@@ -1766,6 +1785,52 @@ func (self *SCloudaccount) RegisterCloudroles(userId string, grouped bool, spId
 	return ret, nil
 }
 
+// 获取或注册权限组对应的联合登录角色
+func (self *SCloudaccount) GetOrCreateGroupCloudrole(ctx context.Context, spId, groupId string) (*SCloudrole, error) {
+	roles, err := self.GetLocalCloudroles("", groupId, spId, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetLocalCloudroles")
+	}
+	if len(roles) > 0 {
+		return &roles[0], nil
+	}
+	group, err := CloudgroupManager.FetchById(groupId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "CloudgroupManager.FetchById(%s)", groupId)
+	}
+	role := &SCloudrole{}
+	role.SetModelManager(CloudroleManager, role)
+	role.CloudaccountId = self.Id
+	role.SAMLProviderId = spId
+	role.Name = stringutils2.GenerateRoleName(group.GetName())
+	role.CloudgroupId = group.GetId()
+	role.Status = api.CLOUD_ROLE_STATUS_CREATING
+	role.DomainId = self.DomainId
+	err = CloudroleManager.TableSpec().Insert(ctx, role)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Insert role")
+	}
+	return role, nil
+}
+
+// 开启云账号SAML认证
+func (self *SCloudaccount) EnableSAMLAuth(ctx context.Context) error {
+	if self.SAMLAuth.IsTrue() {
+		return nil
+	}
+	s := auth.GetAdminSession(ctx, options.Options.Region)
+	params := jsonutils.Marshal(map[string]bool{"saml_auth": true})
+	_, err := modules.Cloudaccounts.Update(s, self.Id, params)
+	if err != nil {
+		return errors.Wrap(err, "Cloudaccounts.Update")
+	}
+	_, err = db.Update(self, func() error {
+		self.SAMLAuth = tristate.True
+		return nil
+	})
+	return err
+}
+
 func (self *SCloudaccount) getCloudrolesForSync(userId string, grouped bool) ([]SCloudrole, error) {
 	sp, valid := self.IsSAMLProviderValid()
 	if !valid {
@@ -37,6 +37,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	npk "yunion.io/x/onecloud/pkg/mcclient/modules/notify"
@@ -1320,9 +1321,28 @@ func (self *SClouduser) PerformSync(ctx context.Context, userCred mcclient.Token
 	if self.Status != api.CLOUD_USER_STATUS_AVAILABLE {
 		return nil, httperrors.NewInvalidStatusError("Can not sync in status %s", self.Status)
 	}
+	if len(input.Direction) == 0 {
+		input.Direction = api.CLOUD_USER_SYNC_DIRECTION_PUSH
+	}
+	if !utils.IsInStringArray(input.Direction, []string{api.CLOUD_USER_SYNC_DIRECTION_PUSH, api.CLOUD_USER_SYNC_DIRECTION_PULL}) {
+		return nil, httperrors.NewInputParameterError("invalid direction %s", input.Direction)
+	}
+	if input.Direction == api.CLOUD_USER_SYNC_DIRECTION_PULL {
+		return nil, self.StartClouduserPullTask(ctx, userCred, "")
+	}
 	return nil, self.StartClouduserSyncTask(ctx, userCred, "")
 }
 
+func (self *SClouduser) StartClouduserPullTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "ClouduserPullTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	self.SetStatus(userCred, api.CLOUD_USER_STATUS_SYNC, "")
+	task.ScheduleRun(nil)
+	return nil
+}
+
 func (self *SClouduser) StartClouduserSyncTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
 	task, err := taskman.TaskManager.NewTask(ctx, "ClouduserSyncTask", self, userCred, nil, parentTaskId, "", nil)
 	if err != nil {
@@ -1428,6 +1448,162 @@ func (self *SClouduser) PerformChangeOwner(ctx context.Context, userCred mcclien
 	return nil, self.StartClouduserResetPasswordTask(ctx, userCred, "", "")
 }
 
+// 获取子账号控制台登录信息
+func (self *SClouduser) GetDetailsLoginUrl(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.ClouduserLoginInfo, error) {
+	account, err := self.GetCloudaccount()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "GetCloudaccount"))
+	}
+	ret := &api.ClouduserLoginInfo{
+		Provider:       account.Provider,
+		LoginUrl:       account.GetClouduserLoginUrl(),
+		IsConsoleLogin: self.IsConsoleLogin.Bool(),
+	}
+	ret.Account, ret.Username = account.GetClouduserAccountName(self.Name)
+	if len(ret.LoginUrl) == 0 {
+		return nil, httperrors.NewNotSupportedError("no console login url for provider %s", account.Provider)
+	}
+	return ret, nil
+}
+
+// 将权限组和权限推送到本地用户关联的所有子账号
+func (manager *SClouduserManager) PerformSyncOwner(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ClouduserSyncOwnerInput) (*api.ClouduserSyncOwnerOutput, error) {
+	if len(input.UserId) == 0 {
+		return nil, httperrors.NewMissingParameterError("user_id")
+	}
+	owner, err := db.UserCacheManager.FetchUserById(ctx, input.UserId)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "Not found user %s", input.UserId))
+	}
+	if !userCred.HasSystemAdminPrivilege() && owner.DomainId != userCred.GetProjectDomainId() {
+		return nil, httperrors.NewForbiddenError("user %s not belong to domain %s", owner.Name, userCred.GetProjectDomain())
+	}
+	// 权限组和权限需要在本地用户所在域可用
+	ownerDomain := &db.SOwnerId{DomainId: owner.DomainId}
+	groups := []*SCloudgroup{}
+	for i := range input.CloudgroupIds {
+		_group, err := validators.ValidateModel(userCred, CloudgroupManager, &input.CloudgroupIds[i])
+		if err != nil {
+			return nil, err
+		}
+		group := _group.(*SCloudgroup)
+		if group.DomainId != owner.DomainId && !group.IsSharable(ownerDomain) {
+			return nil, httperrors.NewForbiddenError("cloudgroup %s is not available in domain of user %s", group.Name, owner.Name)
+		}
+		groups = append(groups, group)
+	}
+	policies := []*SCloudpolicy{}
+	for i := range input.CloudpolicyIds {
+		_policy, err := validators.ValidateModel(userCred, CloudpolicyManager, &input.CloudpolicyIds[i])
+		if err != nil {
+			return nil, err
+		}
+		policy := _policy.(*SCloudpolicy)
+		if policy.DomainId != owner.DomainId && !policy.IsSharable(ownerDomain) {
+			return nil, httperrors.NewForbiddenError("cloudpolicy %s is not available in domain of user %s", policy.Name, owner.Name)
+		}
+		err = policy.ValidateUse()
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	q := manager.Query().Equals("owner_id", owner.Id)
+	// 非系统管理员仅能操作本域的子账号
+	if !userCred.HasSystemAdminPrivilege() {
+		q = q.Equals("domain_id", userCred.GetProjectDomainId())
+	}
+	users := []SClouduser{}
+	err = db.FetchModelObjects(manager, q, &users)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "db.FetchModelObjects"))
+	}
+	ret := &api.ClouduserSyncOwnerOutput{ClouduserCount: len(users)}
+	for i := range users {
+		changed, err := users[i].syncOwnerPermissions(groups, policies)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "sync clouduser %s", users[i].Name))
+		}
+		if !changed || users[i].Status != api.CLOUD_USER_STATUS_AVAILABLE {
+			continue
+		}
+		logclient.AddSimpleActionLog(&users[i], logclient.ACT_SYNC_CONF, input, userCred, true)
+		err = users[i].StartClouduserSyncTask(ctx, userCred, "")
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		ret.SyncCount++
+	}
+	return ret, nil
+}
+
+// 将与子账号平台相同的权限组和权限关联到子账号, 返回是否有变更
+func (self *SClouduser) syncOwnerPermissions(groups []*SCloudgroup, policies []*SCloudpolicy) (bool, error) {
+	account, err := self.GetCloudaccount()
+	if err != nil {
+		return false, errors.Wrapf(err, "GetCloudaccount")
+	}
+	factory, err := account.GetProviderFactory()
+	if err != nil {
+		return false, errors.Wrapf(err, "GetProviderFactory")
+	}
+	changed := false
+	joined, err := self.GetCloudgroups()
+	if err != nil {
+		return false, errors.Wrapf(err, "GetCloudgroups")
+	}
+	groupIds := []string{}
+	for i := range joined {
+		groupIds = append(groupIds, joined[i].Id)
+	}
+	for _, group := range groups {
+		if group.Provider != account.Provider || utils.IsInStringArray(group.Id, groupIds) {
+			continue
+		}
+		err = self.joinGroup(group.Id)
+		if err != nil {
+			return false, errors.Wrapf(err, "joinGroup %s", group.Name)
+		}
+		changed = true
+	}
+	if !factory.IsSupportClouduserPolicy() {
+		return changed, nil
+	}
+	providerIds := []string{""}
+	if factory.IsClouduserpolicyWithSubscription() {
+		providers, err := account.GetCloudproviders()
+		if err != nil {
+			return false, errors.Wrapf(err, "GetCloudproviders")
+		}
+		providerIds = []string{}
+		for i := range providers {
+			providerIds = append(providerIds, providers[i].Id)
+		}
+	}
+	for _, providerId := range providerIds {
+		attached, err := self.GetCloudpolicies(providerId)
+		if err != nil {
+			return false, errors.Wrapf(err, "GetCloudpolicies")
+		}
+		policyIds := []string{}
+		for i := range attached {
+			policyIds = append(policyIds, attached[i].Id)
+		}
+		for _, policy := range policies {
+			if policy.Provider != account.Provider || utils.IsInStringArray(policy.Id, policyIds) {
+				continue
+			}
+			err = self.attachPolicy(policy.Id, providerId)
+			if err != nil {
+				return false, errors.Wrapf(err, "attachPolicy %s", policy.Name)
+			}
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
 func (self *SClouduser) GetCloudgroupQuery() *sqlchemy.SQuery {
 	sq := CloudgroupUserManager.Query("cloudgroup_id").Equals("clouduser_id", self.Id)
 	return CloudgroupManager.Query().In("id", sq.SubQuery())
@@ -16,6 +16,7 @@ package models
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -250,3 +251,120 @@ func (manager *SSAMLProviderManager) FetchCustomizeColumns(
 	}
 	return rows
 }
+
+// 一键配置云账号联合登录
+// 开启SAML认证, 创建身份提供商及权限组对应的角色, 并校验配置结果
+func (manager *SSAMLProviderManager) PerformSetup(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SAMLProviderSetupInput) (jsonutils.JSONObject, error) {
+	if len(input.CloudaccountId) == 0 {
+		return nil, httperrors.NewMissingParameterError("cloudaccount_id")
+	}
+	_account, err := validators.ValidateModel(userCred, CloudaccountManager, &input.CloudaccountId)
+	if err != nil {
+		return nil, err
+	}
+	account := _account.(*SCloudaccount)
+	factory, err := account.GetProviderFactory()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "GetProviderFactory"))
+	}
+	if !factory.IsSupportSAMLAuth() {
+		return nil, httperrors.NewNotSupportedError("%s not support saml auth", account.Provider)
+	}
+	groupIds := []string{}
+	for _, groupId := range input.CloudgroupIds {
+		_group, err := CloudgroupManager.FetchById(groupId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2("cloudgroup", groupId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		group := _group.(*SCloudgroup)
+		if group.Provider != account.Provider {
+			return nil, httperrors.NewConflictError("%s group can not apply for %s account", group.Provider, account.Provider)
+		}
+		groupIds = append(groupIds, group.Id)
+	}
+	sp, err := account.RegisterSAMProvider()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "RegisterSAMProvider"))
+	}
+	if taskman.TaskManager.IsInTask(sp) {
+		return nil, httperrors.NewConflictError("saml provider %s is in task", sp.Name)
+	}
+	return nil, sp.StartSAMLProviderSetupTask(ctx, userCred, groupIds, "")
+}
+
+func (self *SSAMLProvider) StartSAMLProviderSetupTask(ctx context.Context, userCred mcclient.TokenCredential, groupIds []string, parentTaskId string) error {
+	params := jsonutils.NewDict()
+	params.Set("cloudgroup_ids", jsonutils.NewStringArray(groupIds))
+	task, err := taskman.TaskManager.NewTask(ctx, "SAMLProviderSetupTask", self, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	self.SetStatus(userCred, api.SAML_PROVIDER_STATUS_SETUP, "")
+	task.ScheduleRun(nil)
+	return nil
+}
+
+// 校验云上身份提供商及联合登录角色
+func (self *SSAMLProvider) Verify() (*api.SAMLProviderVerifyOutput, error) {
+	ret := &api.SAMLProviderVerifyOutput{MissingCloudroles: []string{}}
+	account, err := self.GetCloudaccount()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetCloudaccount")
+	}
+	ret.SAMLAuthEnabled = account.SAMLAuth.IsTrue()
+	provider, err := account.GetProvider()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetProvider")
+	}
+	if len(self.ExternalId) > 0 {
+		iSAMLProviders, err := provider.GetICloudSAMLProviders()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetICloudSAMLProviders")
+		}
+		for i := range iSAMLProviders {
+			if iSAMLProviders[i].GetGlobalId() != self.ExternalId {
+				continue
+			}
+			ret.ProviderExists = true
+			metadata, err := iSAMLProviders[i].GetMetadataDocument()
+			if err == nil && metadata != nil && metadata.EntityId == options.Options.ApiServer {
+				ret.MetadataMatch = true
+			}
+			break
+		}
+	}
+	roles := []SCloudrole{}
+	q := CloudroleManager.Query().Equals("saml_provider_id", self.Id)
+	err = db.FetchModelObjects(CloudroleManager, q, &roles)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.FetchModelObjects")
+	}
+	ret.CloudroleCount = len(roles)
+	for i := range roles {
+		if len(roles[i].ExternalId) == 0 {
+			ret.MissingCloudroles = append(ret.MissingCloudroles, roles[i].Name)
+			continue
+		}
+		_, err := provider.GetICloudroleById(roles[i].ExternalId)
+		if err != nil {
+			if errors.Cause(err) != cloudprovider.ErrNotFound {
+				return nil, errors.Wrapf(err, "GetICloudroleById(%s)", roles[i].ExternalId)
+			}
+			ret.MissingCloudroles = append(ret.MissingCloudroles, roles[i].Name)
+		}
+	}
+	ret.Ready = ret.SAMLAuthEnabled && ret.ProviderExists && ret.MetadataMatch && len(ret.MissingCloudroles) == 0
+	return ret, nil
+}
+
+// 校验联合登录配置
+func (self *SSAMLProvider) GetDetailsVerify(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.SAMLProviderVerifyOutput, error) {
+	ret, err := self.Verify()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return ret, nil
+}
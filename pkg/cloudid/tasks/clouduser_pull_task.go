@@ -0,0 +1,72 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudid"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudid/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// 将云上子账号的权限和权限组同步到本地
+type ClouduserPullTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(ClouduserPullTask{})
+}
+
+func (self *ClouduserPullTask) taskFailed(ctx context.Context, clouduser *models.SClouduser, err error) {
+	clouduser.SetStatus(self.GetUserCred(), api.CLOUD_USER_STATUS_SYNC_FAILED, err.Error())
+	logclient.AddActionLogWithStartable(self, clouduser, logclient.ACT_SYNC_CONF, err, self.UserCred, false)
+	self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+}
+
+func (self *ClouduserPullTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	user := obj.(*models.SClouduser)
+
+	self.SetStage("OnPullComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		iUser, err := user.GetIClouduser()
+		if err != nil {
+			return nil, errors.Wrap(err, "GetIClouduser")
+		}
+		lockman.LockObject(ctx, user)
+		defer lockman.ReleaseObject(ctx, user)
+
+		syncClouduserPolicies(ctx, self.GetUserCred(), user, iUser)
+		syncClouduserGroups(ctx, self.GetUserCred(), user, iUser)
+		return nil, nil
+	})
+}
+
+func (self *ClouduserPullTask) OnPullComplete(ctx context.Context, user *models.SClouduser, data jsonutils.JSONObject) {
+	user.SetStatus(self.GetUserCred(), api.CLOUD_USER_STATUS_AVAILABLE, "")
+	logclient.AddActionLogWithStartable(self, user, logclient.ACT_SYNC_CONF, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *ClouduserPullTask) OnPullCompleteFailed(ctx context.Context, user *models.SClouduser, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, user, errors.Error(data.String()))
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudid"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudid/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// 一键配置联合登录: 开启SAML认证 -> 创建或更新身份提供商 -> 创建角色并绑定权限 -> 校验
+type SAMLProviderSetupTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(SAMLProviderSetupTask{})
+}
+
+func (self *SAMLProviderSetupTask) taskFailed(ctx context.Context, saml *models.SSAMLProvider, err error) {
+	saml.SetStatus(self.GetUserCred(), api.SAML_PROVIDER_STATUS_SETUP_FAILED, err.Error())
+	logclient.AddActionLogWithStartable(self, saml, logclient.ACT_SETUP_SAML, err, self.UserCred, false)
+	self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+}
+
+func (self *SAMLProviderSetupTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	saml := obj.(*models.SSAMLProvider)
+
+	account, err := saml.GetCloudaccount()
+	if err != nil {
+		self.taskFailed(ctx, saml, errors.Wrapf(err, "GetCloudaccount"))
+		return
+	}
+	err = account.EnableSAMLAuth(ctx)
+	if err != nil {
+		self.taskFailed(ctx, saml, errors.Wrapf(err, "EnableSAMLAuth"))
+		return
+	}
+
+	self.SetStage("OnSAMLProviderReady", nil)
+	if len(saml.ExternalId) == 0 {
+		err = saml.StartSAMLProviderCreateTask(ctx, self.GetUserCred(), self.GetTaskId())
+	} else if saml.IsNeedUpldateMetadata() {
+		err = saml.StartSAMLProviderUpdateMetadataTask(ctx, self.GetUserCred(), self.GetTaskId())
+	} else {
+		self.OnSAMLProviderReady(ctx, saml, nil)
+		return
+	}
+	if err != nil {
+		self.taskFailed(ctx, saml, err)
+	}
+}
+
+func (self *SAMLProviderSetupTask) OnSAMLProviderReady(ctx context.Context, saml *models.SSAMLProvider, data jsonutils.JSONObject) {
+	self.SetStage("OnSetupComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		account, err := saml.GetCloudaccount()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetCloudaccount")
+		}
+		groupIds := jsonutils.GetQueryStringArray(self.GetParams(), "cloudgroup_ids")
+		for _, groupId := range groupIds {
+			role, err := account.GetOrCreateGroupCloudrole(ctx, saml.Id, groupId)
+			if err != nil {
+				return nil, errors.Wrapf(err, "GetOrCreateGroupCloudrole(%s)", groupId)
+			}
+			// 创建云上角色并同步权限组中的权限
+			err = role.SyncRoles()
+			if err != nil {
+				return nil, errors.Wrapf(err, "SyncRoles for %s", role.Name)
+			}
+		}
+		ret, err := saml.Verify()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Verify")
+		}
+		if !ret.Ready {
+			return nil, fmt.Errorf("verify failed: %s", jsonutils.Marshal(ret).String())
+		}
+		return jsonutils.Marshal(ret), nil
+	})
+}
+
+func (self *SAMLProviderSetupTask) OnSAMLProviderReadyFailed(ctx context.Context, saml *models.SSAMLProvider, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, saml, errors.Error(data.String()))
+}
+
+func (self *SAMLProviderSetupTask) OnSetupComplete(ctx context.Context, saml *models.SSAMLProvider, data jsonutils.JSONObject) {
+	saml.SetStatus(self.GetUserCred(), api.SAML_PROVIDER_STATUS_AVAILABLE, "")
+	logclient.AddActionLogWithStartable(self, saml, logclient.ACT_SETUP_SAML, data, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *SAMLProviderSetupTask) OnSetupCompleteFailed(ctx context.Context, saml *models.SSAMLProvider, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, saml, errors.Error(data.String()))
+}
@@ -24,8 +24,8 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 
-	"yunion.io/x/onecloud/pkg/apis"
 	"yunion.io/x/onecloud/pkg/cloudmon/options"
+	"yunion.io/x/onecloud/pkg/cloudmon/providerdriver"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/mcclient/modules/compute"
@@ -90,7 +90,7 @@ func UsegReport(ctx context.Context, userCred mcclient.TokenCredential, isStart
 		}
 		dataList = append(dataList, data...)
 		//写入influDb
-		urls, err := s.GetServiceURLs(apis.SERVICE_TYPE_INFLUXDB, options.Options.SessionEndpointType)
+		urls, err := providerdriver.GetMetricStorageURLs(s)
 		if err != nil {
 			return errors.Wrap(err, "GetServiceURLs")
 		}
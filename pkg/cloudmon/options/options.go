@@ -27,6 +27,8 @@ type CloudMonOptions struct {
 	SkipMetricPullProviders string `help:"Skip indicate provider metric pull" default:""`
 
 	InfluxDatabase string `help:"influxdb database name, default telegraf" default:"telegraf"`
+	// victoria-metrics 兼容 influxdb 写入协议
+	MetricStorageServiceType string `help:"service type of time series database metrics are written to" choices:"influxdb|victoria-metrics" default:"influxdb"`
 
 	DisableServiceMetric               bool  `help:"disable service metric collect"`
 	CollectServiceMetricIntervalMinute int64 `help:"Collect Service metirc Interval unit:minute" default:"5"`
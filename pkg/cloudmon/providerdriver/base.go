@@ -24,7 +24,6 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 
-	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudmon/options"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
@@ -90,10 +89,11 @@ func (self *SBaseCollectDriver) CollectStorageMetrics(ctx context.Context, manag
 
 func (self *SBaseCollectDriver) sendMetrics(ctx context.Context, manager api.CloudproviderDetails, resName string, resCnt int, metrics []influxdb.SMetricData) error {
 	s := auth.GetAdminSession(ctx, options.Options.Region)
-	urls, err := s.GetServiceURLs(apis.SERVICE_TYPE_INFLUXDB, options.Options.SessionEndpointType)
+	urls, err := GetMetricStorageURLs(s)
 	if err != nil {
-		return errors.Wrap(err, "GetServiceURLs")
+		return errors.Wrap(err, "GetMetricStorageURLs")
 	}
+	normalizeMetrics(metrics)
 	log.Infof("send %d %s with %d metrics for %s(%s)", resCnt, resName, len(metrics), manager.Name, manager.Id)
 	return influxdb.BatchSendMetrics(urls, options.Options.InfluxDatabase, metrics, false)
 }
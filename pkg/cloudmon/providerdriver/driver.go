@@ -27,6 +27,9 @@ import (
 
 var driverTable map[string]ICollectDriver = map[string]ICollectDriver{}
 
+// 未注册采集驱动的平台使用默认驱动
+var defaultDriver ICollectDriver = &SDefaultCollect{}
+
 type ICollectDriver interface {
 	GetProvider() string
 	GetDelayDuration() time.Duration
@@ -47,7 +50,10 @@ type ICollectDriver interface {
 func GetDriver(name string) (ICollectDriver, error) {
 	driver, ok := driverTable[name]
 	if !ok {
-		return nil, fmt.Errorf("not found %s collect driver", name)
+		if len(name) == 0 {
+			return nil, fmt.Errorf("not found %s collect driver", name)
+		}
+		return defaultDriver, nil
 	}
 	return driver, nil
 }
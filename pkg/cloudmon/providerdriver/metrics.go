@@ -0,0 +1,225 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerdriver
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudmon/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/influxdb"
+)
+
+// 通用的待采集资源, 各类资源统一转换后按资源ID或按指标类型拉取
+type sMetricResource struct {
+	ExternalId  string
+	Name        string
+	RegionExtId string
+	Tags        map[string]string
+}
+
+func loadbalancerMetricResources(res map[string]api.LoadbalancerDetails) map[string]sMetricResource {
+	ret := map[string]sMetricResource{}
+	for k, lb := range res {
+		ret[k] = sMetricResource{
+			ExternalId:  lb.ExternalId,
+			Name:        lb.Name,
+			RegionExtId: lb.RegionExtId,
+			Tags:        lb.GetMetricTags(),
+		}
+	}
+	return ret
+}
+
+// GetMetricStorageURLs 返回监控数据写入的时序数据库地址, victoria-metrics 兼容 influxdb 写入协议
+func GetMetricStorageURLs(s *mcclient.ClientSession) ([]string, error) {
+	serviceType := options.Options.MetricStorageServiceType
+	if len(serviceType) == 0 {
+		serviceType = apis.SERVICE_TYPE_INFLUXDB
+	}
+	return s.GetServiceURLs(serviceType, options.Options.SessionEndpointType)
+}
+
+func isMetricNameChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_'
+}
+
+// 不同平台返回的指标名称可能包含空格, 横线等字符, 统一替换为下划线
+func normalizeMetricName(name string) string {
+	buf := []byte(name)
+	for i := range buf {
+		if !isMetricNameChar(buf[i]) {
+			buf[i] = '_'
+		}
+	}
+	return string(buf)
+}
+
+func normalizeMetrics(metrics []influxdb.SMetricData) {
+	for i := range metrics {
+		metrics[i].Name = normalizeMetricName(metrics[i].Name)
+		for j := range metrics[i].Metrics {
+			metrics[i].Metrics[j].Key = normalizeMetricName(metrics[i].Metrics[j].Key)
+		}
+	}
+}
+
+func newMetricData(values cloudprovider.MetricValues, value cloudprovider.MetricValue, tags map[string]string) influxdb.SMetricData {
+	metric := influxdb.SMetricData{
+		Name:      values.MetricType.Name(),
+		Timestamp: value.Timestamp,
+		Tags:      []influxdb.SKeyValue{},
+		Metrics: []influxdb.SKeyValue{
+			{
+				Key:   values.MetricType.Key(),
+				Value: strconv.FormatFloat(value.Value, 'E', -1, 64),
+			},
+		},
+	}
+	for k, v := range value.Tags {
+		metric.Tags = append(metric.Tags, influxdb.SKeyValue{Key: k, Value: v})
+	}
+	for k, v := range tags {
+		metric.Tags = append(metric.Tags, influxdb.SKeyValue{Key: k, Value: v})
+	}
+	return metric
+}
+
+// 逐个资源调用 GetMetrics, 平台未实现时第一次返回后即停止
+func (self *SBaseCollectDriver) collectByResourceId(ctx context.Context, manager api.CloudproviderDetails, provider cloudprovider.ICloudProvider, resType cloudprovider.TResourceType, res map[string]sMetricResource, start, end time.Time) error {
+	ch := make(chan struct{}, options.Options.CloudResourceCollectMetricsBatchCount)
+	defer close(ch)
+	metrics := []influxdb.SMetricData{}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	notImplemented := false
+	for i := range res {
+		mu.Lock()
+		skip := notImplemented
+		mu.Unlock()
+		if skip {
+			break
+		}
+		ch <- struct{}{}
+		wg.Add(1)
+		go func(r sMetricResource) {
+			defer func() {
+				wg.Done()
+				<-ch
+			}()
+			opts := &cloudprovider.MetricListOptions{
+				ResourceType: resType,
+				ResourceId:   r.ExternalId,
+				RegionExtId:  r.RegionExtId,
+				StartTime:    start,
+				EndTime:      end,
+			}
+			data, err := provider.GetMetrics(opts)
+			if err != nil {
+				if errors.Cause(err) == cloudprovider.ErrNotImplemented || errors.Cause(err) == cloudprovider.ErrNotSupported {
+					mu.Lock()
+					notImplemented = true
+					mu.Unlock()
+					return
+				}
+				log.Errorf("get %s %s(%s) metrics error: %v", resType, r.Name, r.ExternalId, err)
+				return
+			}
+			for _, values := range data {
+				for _, value := range values.Values {
+					metric := newMetricData(values, value, r.Tags)
+					mu.Lock()
+					metrics = append(metrics, metric)
+					mu.Unlock()
+				}
+			}
+		}(res[i])
+	}
+	wg.Wait()
+	if notImplemented && len(metrics) == 0 {
+		return errors.Wrapf(cloudprovider.ErrNotImplemented, "%s metrics", resType)
+	}
+	return self.sendMetrics(ctx, manager, string(resType), len(res), metrics)
+}
+
+// 按指标类型批量拉取, 返回结果按云上资源ID匹配本地资源
+func (self *SBaseCollectDriver) collectByMetricType(ctx context.Context, manager api.CloudproviderDetails, provider cloudprovider.ICloudProvider, resType cloudprovider.TResourceType, metricTypes []cloudprovider.TMetricType, res map[string]sMetricResource, start, end time.Time) error {
+	metrics := []influxdb.SMetricData{}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, _metricType := range metricTypes {
+		wg.Add(1)
+		go func(metricType cloudprovider.TMetricType) {
+			defer wg.Done()
+			opts := &cloudprovider.MetricListOptions{
+				ResourceType: resType,
+				MetricType:   metricType,
+				StartTime:    start,
+				EndTime:      end,
+			}
+			data, err := provider.GetMetrics(opts)
+			if err != nil {
+				if errors.Cause(err) != cloudprovider.ErrNotImplemented && errors.Cause(err) != cloudprovider.ErrNotSupported {
+					log.Errorf("get metric %s for %s(%s) error: %v", metricType, manager.Name, manager.Id, err)
+				}
+				return
+			}
+			for _, values := range data {
+				r, ok := res[values.Id]
+				if !ok {
+					continue
+				}
+				for _, value := range values.Values {
+					metric := newMetricData(values, value, r.Tags)
+					mu.Lock()
+					metrics = append(metrics, metric)
+					mu.Unlock()
+				}
+			}
+		}(_metricType)
+	}
+	wg.Wait()
+	return self.sendMetrics(ctx, manager, string(resType), len(res), metrics)
+}
+
+func (self *SCollectByResourceIdDriver) CollectLoadbalancerMetrics(ctx context.Context, manager api.CloudproviderDetails, provider cloudprovider.ICloudProvider, res map[string]api.LoadbalancerDetails, start, end time.Time) error {
+	return self.collectByResourceId(ctx, manager, provider, cloudprovider.METRIC_RESOURCE_TYPE_LB, loadbalancerMetricResources(res), start, end)
+}
+
+func (self *SCollectByMetricTypeDriver) CollectLoadbalancerMetrics(ctx context.Context, manager api.CloudproviderDetails, provider cloudprovider.ICloudProvider, res map[string]api.LoadbalancerDetails, start, end time.Time) error {
+	return self.collectByMetricType(ctx, manager, provider, cloudprovider.METRIC_RESOURCE_TYPE_LB, cloudprovider.ALL_LB_METRIC_TYPES, loadbalancerMetricResources(res), start, end)
+}
+
+// 未单独注册采集驱动的平台, 只要实现了 GetMetrics 即可按资源ID采集
+type SDefaultCollect struct {
+	SCollectByResourceIdDriver
+}
+
+func (self *SDefaultCollect) GetProvider() string {
+	return ""
+}
+
+func (self *SDefaultCollect) IsSupportMetrics() bool {
+	return true
+}
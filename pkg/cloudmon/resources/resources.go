@@ -26,7 +26,6 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 
-	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudmon/options"
 	"yunion.io/x/onecloud/pkg/cloudmon/providerdriver"
@@ -783,7 +782,7 @@ func (self *SResources) CollectMetrics(ctx context.Context, userCred mcclient.To
 		}
 		metrics = append(metrics, metric)
 	}
-	urls, err := s.GetServiceURLs(apis.SERVICE_TYPE_INFLUXDB, options.Options.SessionEndpointType)
+	urls, err := providerdriver.GetMetricStorageURLs(s)
 	if err != nil {
 		return
 	}
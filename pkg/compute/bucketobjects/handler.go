@@ -0,0 +1,102 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bucketobjects
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/appctx"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+)
+
+// AddBucketObjectHandler 注册对象下载代理, 浏览器无需持有云账号凭证即可下载对象
+// 与上传一致, 大对象下载设置2小时的处理超时
+func AddBucketObjectHandler(prefix string, app *appsrv.Application) {
+	app.AddHandler2("GET", fmt.Sprintf("%s/buckets/<bucket_id>/download", prefix), auth.Authenticate(downloadObjectHandler), nil, "download_bucket_object", nil).SetProcessTimeout(2 * time.Hour)
+}
+
+func downloadObjectHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	userCred := auth.FetchUserCredential(ctx, policy.FilterPolicyCredential)
+	params := appctx.AppContextParams(ctx)
+	bucketId := params["<bucket_id>"]
+	key := r.URL.Query().Get("key")
+	if len(key) == 0 {
+		httperrors.MissingParameterError(ctx, w, "key")
+		return
+	}
+	obj, err := models.BucketManager.FetchByIdOrName(userCred, bucketId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			httperrors.ResourceNotFoundError(ctx, w, "bucket %s not found", bucketId)
+			return
+		}
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	bucket := obj.(*models.SBucket)
+	if !bucket.IsOwner(userCred) {
+		httperrors.ForbiddenError(ctx, w, "not allow to download object of bucket %s", bucket.Name)
+		return
+	}
+
+	iObj, objRange, reader, err := bucket.GetObject(ctx, key, r.Header.Get("Range"))
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	defer reader.Close()
+
+	header := w.Header()
+	contentType := iObj.GetMeta().Get("Content-Type")
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Accept-Ranges", "bytes")
+	if etag := iObj.GetETag(); len(etag) > 0 {
+		header.Set("ETag", etag)
+	}
+	if lastModified := iObj.GetLastModified(); !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(key)))
+	statusCode := http.StatusOK
+	sizeBytes := iObj.GetSizeBytes()
+	if objRange != nil {
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", objRange.Start, objRange.End, sizeBytes))
+		sizeBytes = objRange.SizeBytes()
+		statusCode = http.StatusPartialContent
+	}
+	header.Set("Content-Length", strconv.FormatInt(sizeBytes, 10))
+	w.WriteHeader(statusCode)
+	_, err = io.Copy(w, reader)
+	if err != nil {
+		log.Errorf("download object %s of bucket %s error: %s", key, bucket.Name, err)
+	}
+}
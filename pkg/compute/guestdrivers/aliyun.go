@@ -46,6 +46,10 @@ func (self *SAliyunGuestDriver) GetHypervisor() string {
 	return api.HYPERVISOR_ALIYUN
 }
 
+func (self *SAliyunGuestDriver) IsSupportUpdateUserData() bool {
+	return true
+}
+
 func (self *SAliyunGuestDriver) GetProvider() string {
 	return api.CLOUD_PROVIDER_ALIYUN
 }
@@ -220,6 +224,10 @@ func (self *SAliyunGuestDriver) IsSupportPublicipToEip() bool {
 	return true
 }
 
+func (self *SAliyunGuestDriver) GetNicQosMode() string {
+	return api.NIC_QOS_MODE_EIP
+}
+
 func (self *SAliyunGuestDriver) IsSupportSetAutoRenew() bool {
 	return true
 }
@@ -39,6 +39,10 @@ func (self *SApsaraGuestDriver) GetHypervisor() string {
 	return api.HYPERVISOR_APSARA
 }
 
+func (self *SApsaraGuestDriver) IsSupportUpdateUserData() bool {
+	return true
+}
+
 func (self *SApsaraGuestDriver) GetProvider() string {
 	return api.CLOUD_PROVIDER_APSARA
 }
@@ -69,6 +73,10 @@ func (self *SApsaraGuestDriver) IsSupportedBillingCycle(bc billing.SBillingCycle
 	return false
 }
 
+func (self *SApsaraGuestDriver) GetNicQosMode() string {
+	return api.NIC_QOS_MODE_NONE
+}
+
 func (self *SApsaraGuestDriver) IsSupportPublicipToEip() bool {
 	return false
 }
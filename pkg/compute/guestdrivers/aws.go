@@ -130,6 +130,10 @@ func (self *SAwsGuestDriver) GetHypervisor() string {
 	return api.HYPERVISOR_AWS
 }
 
+func (self *SAwsGuestDriver) IsSupportUpdateUserData() bool {
+	return true
+}
+
 func (self *SAwsGuestDriver) GetProvider() string {
 	return api.CLOUD_PROVIDER_AWS
 }
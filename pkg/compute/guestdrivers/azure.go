@@ -28,11 +28,8 @@ import (
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/compute/models"
-	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
-	"yunion.io/x/onecloud/pkg/mcclient/auth"
-	modules "yunion.io/x/onecloud/pkg/mcclient/modules/image"
 )
 
 type SAzureGuestDriver struct {
@@ -110,6 +107,11 @@ func (self *SAzureGuestDriver) GetDeployStatus() ([]string, error) {
 	return []string{api.VM_RUNNING}, nil
 }
 
+// 通过 VMAccess 扩展重置, 需虚拟机运行且已安装 VM Agent
+func (self *SAzureGuestDriver) GetResetPasswordStatus() ([]string, error) {
+	return []string{api.VM_RUNNING}, nil
+}
+
 func (self *SAzureGuestDriver) IsNeedRestartForResetLoginInfo() bool {
 	return false
 }
@@ -123,28 +125,8 @@ func (self *SAzureGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *mo
 	return nil
 }
 
-func (self *SAzureGuestDriver) ValidateImage(ctx context.Context, image *cloudprovider.SImage) error {
-	if len(image.ExternalId) == 0 {
-		s := auth.GetAdminSession(ctx, options.Options.Region)
-		result, err := modules.Images.GetSpecific(s, image.Id, "subformats", nil)
-		if err != nil {
-			return errors.Wrap(err, "get subformats")
-		}
-		subFormats := []struct {
-			Format string
-		}{}
-		err = result.Unmarshal(&subFormats)
-		if err != nil {
-			return errors.Wrap(err, "Unmarshal subformats")
-		}
-		for i := range subFormats {
-			if subFormats[i].Format == "vhd" {
-				return nil
-			}
-		}
-		return httperrors.NewResourceNotFoundError("failed to find subformat vhd for image %s, please append 'vhd' for glance options(target_image_formats)", image.Name)
-	}
-	return nil
+func (self *SAzureGuestDriver) IsSupportLiveResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) bool {
+	return false
 }
 
 func (self *SAzureGuestDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.ServerCreateInput) (*api.ServerCreateInput, error) {
@@ -167,11 +149,6 @@ func (self *SAzureGuestDriver) ValidateCreateData(ctx context.Context, userCred
 			return nil, errors.Wrap(err, "GetImage")
 		}
 
-		err = self.ValidateImage(ctx, image)
-		if err != nil {
-			return nil, err
-		}
-
 		if len(image.ExternalId) > 0 && len(input.InstanceType) > 0 {
 			if cachedimage.UEFI.IsFalse() {
 				if strings.HasPrefix(input.InstanceType, "Standard_M") && strings.HasSuffix(input.InstanceType, "v2") {
@@ -183,10 +183,22 @@ func (self *SBaseGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *mod
 	return fmt.Errorf("This Guest driver dose not implement ValidateResizeDisk")
 }
 
+func (self *SBaseGuestDriver) IsSupportLiveResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) bool {
+	return true
+}
+
+func (self *SBaseGuestDriver) RequestGuestGrowFs(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest) error {
+	return httperrors.ErrNotImplemented
+}
+
 func (self *SBaseGuestDriver) GetDeployStatus() ([]string, error) {
 	return []string{}, fmt.Errorf("This Guest driver dose not implement GetDeployStatus")
 }
 
+func (self *SBaseGuestDriver) GetResetPasswordStatus() ([]string, error) {
+	return []string{}, errors.Wrapf(errors.ErrNotSupported, "GetResetPasswordStatus")
+}
+
 func (self *SBaseGuestDriver) IsNeedRestartForResetLoginInfo() bool {
 	return true
 }
@@ -357,6 +369,10 @@ func (self *SBaseGuestDriver) IsSupportdDcryptPasswordFromSecretKey() bool {
 	return true
 }
 
+func (self *SBaseGuestDriver) IsSupportUpdateUserData() bool {
+	return false
+}
+
 func (self *SBaseGuestDriver) GetUserDataType() string {
 	return cloudprovider.CLOUD_CONFIG
 }
@@ -393,6 +409,10 @@ func (self *SBaseGuestDriver) IsSupportPublicipToEip() bool {
 	return false
 }
 
+func (self *SBaseGuestDriver) GetNicQosMode() string {
+	return api.NIC_QOS_MODE_NONE
+}
+
 func (self *SBaseGuestDriver) RequestConvertPublicipToEip(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, task taskman.ITask) error {
 	return fmt.Errorf("Not Implement RequestConvertPublicipToEip")
 }
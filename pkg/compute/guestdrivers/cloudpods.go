@@ -48,6 +48,10 @@ func (self *SCloudpodsGuestDriver) GetHypervisor() string {
 	return api.HYPERVISOR_CLOUDPODS
 }
 
+func (self *SCloudpodsGuestDriver) IsSupportUpdateUserData() bool {
+	return true
+}
+
 func (self *SCloudpodsGuestDriver) GetProvider() string {
 	return api.CLOUD_PROVIDER_CLOUDPODS
 }
@@ -102,6 +106,10 @@ func (self *SCloudpodsGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk
 	return nil
 }
 
+func (self *SCloudpodsGuestDriver) IsSupportLiveResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) bool {
+	return guest.GetDiskIndex(disk.Id) > 0
+}
+
 func (self *SCloudpodsGuestDriver) GetComputeQuotaKeys(scope rbacscope.TRbacScope, ownerId mcclient.IIdentityProvider, brand string) models.SComputeResourceKeys {
 	keys := models.SComputeResourceKeys{}
 	keys.SBaseProjectQuotaKeys = quotas.OwnerIdProjectQuotaKeys(scope, ownerId)
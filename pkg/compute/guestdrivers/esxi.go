@@ -212,6 +212,11 @@ func (self *SESXiGuestDriver) GetDeployStatus() ([]string, error) {
 	return []string{api.VM_READY}, nil
 }
 
+// 由 ESXi agent 挂载系统盘离线修改密码, 需虚拟机关机
+func (self *SESXiGuestDriver) GetResetPasswordStatus() ([]string, error) {
+	return []string{api.VM_READY}, nil
+}
+
 func (self *SESXiGuestDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, data *api.ServerCreateInput) (*api.ServerCreateInput, error) {
 	// check disk config
 	if len(data.Disks) == 0 {
@@ -254,7 +259,8 @@ func (self *SESXiGuestDriver) ValidateCreateEip(ctx context.Context, userCred mc
 }
 
 func (self *SESXiGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) error {
-	if !utils.IsInStringArray(guest.Status, []string{api.VM_READY}) {
+	// vSphere支持热扩展虚拟磁盘
+	if !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
 		return fmt.Errorf("Cannot resize disk when guest in status %s", guest.Status)
 	}
 	count, err := guest.GetInstanceSnapshotCount()
@@ -407,7 +413,17 @@ func (self *SESXiGuestDriver) RequestDeployGuestOnHost(ctx context.Context, gues
 	}
 
 	action, _ := config.GetString("action")
+	pool := ""
 	if action == "create" {
+		// 优先使用vCenter上为项目配置的资源池映射
+		pool, err = models.VmwarePoolMappingManager.GetResourcePool(host.ManagerId, guest.ProjectId)
+		if err != nil {
+			return errors.Wrapf(err, "GetResourcePool(%s)", guest.ProjectId)
+		}
+	}
+	if len(pool) > 0 {
+		config.Add(jsonutils.NewString(pool), "desc", "resource_pool")
+	} else if action == "create" {
 		project, err := db.TenantCacheManager.FetchTenantById(ctx, guest.ProjectId)
 		if err != nil {
 			return errors.Wrapf(err, "FetchTenantById(%s)", guest.ProjectId)
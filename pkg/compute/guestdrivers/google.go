@@ -61,6 +61,11 @@ func (self *SGoogleGuestDriver) GetHypervisor() string {
 	return api.HYPERVISOR_GOOGLE
 }
 
+// 更新 startup-script, 下次开机时执行
+func (self *SGoogleGuestDriver) IsSupportUpdateUserData() bool {
+	return true
+}
+
 func (self *SGoogleGuestDriver) GetProvider() string {
 	return api.CLOUD_PROVIDER_GOOGLE
 }
@@ -41,6 +41,10 @@ func (self *SHuaweiGuestDriver) GetHypervisor() string {
 	return api.HYPERVISOR_HUAWEI
 }
 
+func (self *SHuaweiGuestDriver) GetNicQosMode() string {
+	return api.NIC_QOS_MODE_EIP
+}
+
 func (self *SHuaweiGuestDriver) GetProvider() string {
 	return api.CLOUD_PROVIDER_HUAWEI
 }
@@ -97,6 +101,11 @@ func (self *SHuaweiGuestDriver) GetDeployStatus() ([]string, error) {
 	return []string{api.VM_READY, api.VM_RUNNING}, nil
 }
 
+// 通过 os-reset-password 接口重置
+func (self *SHuaweiGuestDriver) GetResetPasswordStatus() ([]string, error) {
+	return []string{api.VM_READY, api.VM_RUNNING}, nil
+}
+
 func (self *SHuaweiGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) error {
 	if !utils.IsInStringArray(guest.Status, []string{api.VM_RUNNING, api.VM_READY}) {
 		return fmt.Errorf("Cannot resize disk when guest in status %s", guest.Status)
@@ -58,6 +58,11 @@ func (self *SKVMGuestDriver) GetHypervisor() string {
 	return api.HYPERVISOR_KVM
 }
 
+// user data 由宿主机 metadata 服务提供, 同步配置后即生效
+func (self *SKVMGuestDriver) IsSupportUpdateUserData() bool {
+	return true
+}
+
 func (self *SKVMGuestDriver) GetProvider() string {
 	return api.CLOUD_PROVIDER_ONECLOUD
 }
@@ -606,6 +611,45 @@ func (self *SKVMGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *mode
 	return nil
 }
 
+func (self *SKVMGuestDriver) IsSupportLiveResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) bool {
+	return guest.GetDiskIndex(disk.Id) > 0
+}
+
+// 扩展所有分区并按文件系统类型扩容已挂载的文件系统
+const guestGrowFsScript = `lsblk -rnpo NAME,PKNAME,TYPE | while read name pkname type; do
+  [ "$type" = "part" ] && growpart "$pkname" "${name##*[!0-9]}"
+done
+findmnt -rno SOURCE,TARGET,FSTYPE | while read source target fstype; do
+  case "$fstype" in
+    ext2|ext3|ext4) resize2fs "$source" ;;
+    xfs) xfs_growfs "$target" ;;
+  esac
+done
+exit 0`
+
+func (self *SKVMGuestDriver) RequestGuestGrowFs(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest) error {
+	if guest.IsWindows() {
+		return errors.Wrapf(httperrors.ErrNotSupported, "grow fs of windows guest")
+	}
+	host, err := guest.GetHost()
+	if err != nil {
+		return errors.Wrapf(err, "GetHost")
+	}
+	cmd := jsonutils.Marshal(map[string]interface{}{
+		"execute": "guest-exec",
+		"arguments": map[string]interface{}{
+			"path": "/bin/sh",
+			"arg":  []string{"-c", guestGrowFsScript},
+		},
+	})
+	input := api.ServerQgaCommandInput{Command: cmd.String()}
+	_, err = self.RequestQgaCommand(ctx, userCred, jsonutils.Marshal(input), host, guest)
+	if err != nil {
+		return errors.Wrapf(err, "RequestQgaCommand")
+	}
+	return nil
+}
+
 func (self *SKVMGuestDriver) RequestSyncConfigOnHost(ctx context.Context, guest *models.SGuest, host *models.SHost, task taskman.ITask) error {
 	desc, err := guest.GetDriver().GetJsonDescAtHost(ctx, task.GetUserCred(), guest, host, nil)
 	if err != nil {
@@ -739,6 +783,10 @@ func (self *SKVMGuestDriver) IsSupportMigrate() bool {
 	return true
 }
 
+func (self *SKVMGuestDriver) GetNicQosMode() string {
+	return api.NIC_QOS_MODE_TC
+}
+
 func (self *SKVMGuestDriver) IsSupportLiveMigrate() bool {
 	return true
 }
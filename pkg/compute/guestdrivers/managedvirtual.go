@@ -94,7 +94,7 @@ func (self *SManagedVirtualizedGuestDriver) GetJsonDescAtHost(ctx context.Contex
 
 	var err error
 	provider := host.GetCloudprovider()
-	config.ProjectId, err = provider.SyncProject(ctx, userCred, guest.ProjectId)
+	config.ProjectId, err = provider.GetCreateProjectId(ctx, userCred, guest.ProjectId, guest.ExternalProjectId)
 	if err != nil {
 		log.Errorf("failed to sync project %s for create %s guest %s error: %v", guest.ProjectId, provider.Provider, guest.Name, err)
 	}
@@ -421,6 +421,19 @@ func (self *SManagedVirtualizedGuestDriver) RequestDeployGuestOnHost(ctx context
 	if err != nil {
 		return errors.Wrapf(err, "GetUserData")
 	}
+	if len(desc.UserData) > 0 {
+		// 下发的user data已注入登录密码, 仅记录用户提供的部分
+		err = func() error {
+			userData, err := renderUserData(ctx, guest, task.GetUserCred())
+			if err != nil {
+				return errors.Wrapf(err, "renderUserData")
+			}
+			return guest.SetRenderedUserData(ctx, task.GetUserCred(), userData)
+		}()
+		if err != nil {
+			log.Errorf("save rendered userdata of %s error: %v", guest.Name, err)
+		}
+	}
 
 	action, err := config.GetString("action")
 	if err != nil {
@@ -1101,7 +1114,7 @@ func (self *SManagedVirtualizedGuestDriver) RequestSyncSecgroupsOnHost(ctx conte
 	remoteProjectId := ""
 	provider := host.GetCloudprovider()
 	if provider != nil {
-		remoteProjectId, err = provider.SyncProject(ctx, task.GetUserCred(), guest.ProjectId)
+		remoteProjectId, err = provider.GetCreateProjectId(ctx, task.GetUserCred(), guest.ProjectId, guest.ExternalProjectId)
 		if err != nil {
 			log.Errorf("failed to sync project %s for guest %s error: %v", guest.ProjectId, guest.Name, err)
 		}
@@ -1132,11 +1145,48 @@ func (self *SManagedVirtualizedGuestDriver) RequestSyncConfigOnHost(ctx context.
 			}
 		}
 
+		if jsonutils.QueryBoolean(task.GetParams(), "user_data", false) {
+			err := self.syncUserData(ctx, guest, task.GetUserCred())
+			if err != nil {
+				return nil, errors.Wrapf(err, "syncUserData")
+			}
+		}
+
 		return nil, nil
 	})
 	return nil
 }
 
+// 按平台格式渲染用户提供的user data, 不包含登录密码
+func renderUserData(ctx context.Context, guest *models.SGuest, userCred mcclient.TokenCredential) (string, error) {
+	drv := guest.GetDriver()
+	desc := cloudprovider.SManagedVMCreateConfig{
+		IsNeedInjectPasswordByCloudInit: drv.IsNeedInjectPasswordByCloudInit(),
+		UserDataType:                    drv.GetUserDataType(),
+		WindowsUserDataType:             drv.GetWindowsUserDataType(),
+		IsWindowsUserDataTypeNeedEncode: drv.IsWindowsUserDataTypeNeedEncode(),
+	}
+	desc.OsType = guest.GetOS()
+	desc.UserData = guest.GetUserData(ctx, userCred)
+	return desc.GetUserData()
+}
+
+func (self *SManagedVirtualizedGuestDriver) syncUserData(ctx context.Context, guest *models.SGuest, userCred mcclient.TokenCredential) error {
+	userData, err := renderUserData(ctx, guest, userCred)
+	if err != nil {
+		return errors.Wrapf(err, "renderUserData")
+	}
+	iVM, err := guest.GetIVM(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "GetIVM")
+	}
+	err = iVM.UpdateUserData(userData)
+	if err != nil {
+		return errors.Wrapf(err, "UpdateUserData")
+	}
+	return guest.SetRenderedUserData(ctx, userCred, userData)
+}
+
 func (self *SManagedVirtualizedGuestDriver) RequestRenewInstance(ctx context.Context, guest *models.SGuest, bc billing.SBillingCycle) (time.Time, error) {
 	iVM, err := guest.GetIVM(ctx)
 	if err != nil {
@@ -157,6 +157,10 @@ func (self *SNutanixGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *
 	return nil
 }
 
+func (self *SNutanixGuestDriver) IsSupportLiveResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) bool {
+	return false
+}
+
 func (self *SNutanixGuestDriver) ValidateCreateEip(ctx context.Context, userCred mcclient.TokenCredential, input api.ServerCreateEipInput) error {
 	return httperrors.NewInputParameterError("%s not support create eip", self.GetHypervisor())
 }
@@ -168,6 +168,10 @@ func (self *SOpenStackGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk
 	return nil
 }
 
+func (self *SOpenStackGuestDriver) IsSupportLiveResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) bool {
+	return false
+}
+
 func (self *SOpenStackGuestDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.ServerCreateInput) (*api.ServerCreateInput, error) {
 	var err error
 	input, err = self.SManagedVirtualizedGuestDriver.ValidateCreateData(ctx, userCred, input)
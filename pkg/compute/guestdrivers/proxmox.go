@@ -16,9 +16,11 @@ package guestdrivers
 
 import (
 	"context"
+	"fmt"
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/pkg/util/rbacscope"
+	"yunion.io/x/pkg/utils"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
@@ -118,10 +120,26 @@ func (self *SProxmoxGuestDriver) GetRebuildRootStatus() ([]string, error) {
 	return []string{}, cloudprovider.ErrNotSupported
 }
 
+func (self *SProxmoxGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) error {
+	if !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
+		return fmt.Errorf("Cannot resize disk when guest in status %s", guest.Status)
+	}
+	return nil
+}
+
 func (self *SProxmoxGuestDriver) GetDeployStatus() ([]string, error) {
 	return []string{api.VM_READY}, nil
 }
 
+// 通过 qemu-guest-agent 在线重置, 需虚拟机运行
+func (self *SProxmoxGuestDriver) GetResetPasswordStatus() ([]string, error) {
+	return []string{api.VM_RUNNING}, nil
+}
+
+func (self *SProxmoxGuestDriver) IsNeedRestartForResetLoginInfo() bool {
+	return false
+}
+
 func (self *SProxmoxGuestDriver) ValidateCreateEip(ctx context.Context, userCred mcclient.TokenCredential, input api.ServerCreateEipInput) error {
 	return httperrors.NewInputParameterError("%s not support create eip", self.GetHypervisor())
 }
@@ -308,6 +308,10 @@ func (self *SQcloudGuestDriver) IsSupportPublicipToEip() bool {
 	return true
 }
 
+func (self *SQcloudGuestDriver) GetNicQosMode() string {
+	return api.NIC_QOS_MODE_EIP
+}
+
 func (self *SQcloudGuestDriver) IsSupportSetAutoRenew() bool {
 	return true
 }
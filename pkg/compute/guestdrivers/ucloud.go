@@ -96,6 +96,10 @@ func (self *SUCloudGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *m
 	return nil
 }
 
+func (self *SUCloudGuestDriver) IsSupportLiveResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) bool {
+	return false
+}
+
 func (self *SUCloudGuestDriver) GetInstanceCapability() cloudprovider.SInstanceCapability {
 	return cloudprovider.SInstanceCapability{
 		Hypervisor: self.GetHypervisor(),
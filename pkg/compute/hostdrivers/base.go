@@ -55,6 +55,10 @@ func (self *SBaseHostDriver) RequestDetachStorage(ctx context.Context, host *mod
 	return httperrors.NewNotImplementedError("Not Implement RequestDetachStorage")
 }
 
+func (self *SBaseHostDriver) IsSupportBmcPower() bool {
+	return false
+}
+
 func (self *SBaseHostDriver) ValidateDiskSize(storage *models.SStorage, sizeGb int) error {
 	return fmt.Errorf("Not Implement ValidateDiskSize")
 }
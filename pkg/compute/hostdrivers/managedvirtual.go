@@ -129,9 +129,24 @@ func (self *SManagedVirtualizationHostDriver) CheckAndSetCacheImage(ctx context.
 				image.MinRamMb = int(minRamMb)
 				image.TmpPath = options.Options.TempPath
 
+				diskFormat, _ := info.GetString("disk_format")
+				checksums := map[string]string{"": image.Checksum, diskFormat: image.Checksum}
+				if format := models.GetRequiredImageFormat(providerName, diskFormat); len(format) > 0 {
+					checksum, err := models.EnsureImageFormat(ctx, s, image.ImageId, format, func(progress float32) {
+						log.Infof("Convert image %s to %s for storagecache %s status: %.2f%%", image.ImageName, format, storageCache.Name, progress)
+					})
+					if err != nil {
+						return "", errors.Wrapf(err, "EnsureImageFormat(%s)", format)
+					}
+					checksums[format] = checksum
+				}
+
 				image.GetReader = func(imageId, format string) (io.Reader, int64, error) {
 					_, reader, sizeByte, err := modules.Images.Download(s, imageId, format, false)
-					return reader, sizeByte, err
+					if err != nil {
+						return nil, 0, err
+					}
+					return models.NewChecksumReader(reader, checksums[format]), sizeByte, nil
 				}
 				log.Debugf("UploadImage: no external ID")
 				return iStorageCache.UploadImage(ctx, image, callback)
@@ -299,7 +314,7 @@ func (self *SManagedVirtualizationHostDriver) RequestAllocateDiskOnStorage(ctx c
 		if _cloudprovider == nil {
 			return nil, fmt.Errorf("invalid cloudprovider for storage %s(%s)", storage.Name, storage.Id)
 		}
-		projectId, err := _cloudprovider.SyncProject(ctx, userCred, disk.ProjectId)
+		projectId, err := _cloudprovider.GetCreateProjectId(ctx, userCred, disk.ProjectId, disk.ExternalProjectId)
 		if err != nil {
 			log.Errorf("failed to sync project for create %s disk %s error: %v", _cloudprovider.Provider, disk.GetName(), err)
 		}
@@ -43,3 +43,7 @@ func (self *SProxmoxHostDriver) ValidateDiskSize(storage *models.SStorage, sizeG
 func (driver *SProxmoxHostDriver) GetStoragecacheQuota(host *models.SHost) int {
 	return 100
 }
+
+func (driver *SProxmoxHostDriver) IsSupportBmcPower() bool {
+	return true
+}
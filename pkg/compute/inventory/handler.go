@@ -0,0 +1,123 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+)
+
+func AddInventoryHandler(prefix string, app *appsrv.Application) {
+	app.AddHandler2("GET", fmt.Sprintf("%s/ansible-inventory", prefix), auth.Authenticate(inventoryHandler), nil, "get_ansible_inventory", nil)
+}
+
+func inventoryHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	userCred := auth.FetchUserCredential(ctx, policy.FilterPolicyCredential)
+	query, err := jsonutils.ParseQueryString(r.URL.RawQuery)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	input := api.ServerAnsibleInventoryInput{}
+	err = query.Unmarshal(&input)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, httperrors.NewInputParameterError("unmarshal input: %v", err))
+		return
+	}
+	query.(*jsonutils.JSONDict).Remove("inventory_host")
+	servers, err := fetchServers(ctx, userCred, query)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	inventory := buildInventory(servers)
+	if len(input.InventoryHost) > 0 {
+		hostvars := inventory["_meta"].(map[string]interface{})["hostvars"].(map[string]interface{})
+		vars, ok := hostvars[input.InventoryHost]
+		if !ok {
+			vars = map[string]interface{}{}
+		}
+		appsrv.SendJSON(w, jsonutils.Marshal(vars))
+		return
+	}
+	appsrv.SendJSON(w, jsonutils.Marshal(inventory))
+}
+
+// 按虚拟机列表的过滤条件及权限范围获取虚拟机
+func fetchServers(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) ([]sInventoryServer, error) {
+	_, _, err, _ := db.FetchCheckQueryOwnerScope(ctx, userCred, query, models.GuestManager, policy.PolicyActionList, true)
+	if err != nil {
+		return nil, httperrors.NewForbiddenError("%v", err)
+	}
+	q := models.GuestManager.Query()
+	q, err = db.ListItemQueryFilters(models.GuestManager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	guests := []models.SGuest{}
+	err = db.FetchModelObjects(models.GuestManager, q, &guests)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "FetchModelObjects"))
+	}
+	if len(guests) == 0 {
+		return []sInventoryServer{}, nil
+	}
+
+	objs := make([]interface{}, len(guests))
+	resIds := make([]string, len(guests))
+	for i := range guests {
+		objs[i] = &guests[i]
+		resIds[i] = db.GetModelIdstr(&guests[i])
+	}
+	details := models.GuestManager.FetchCustomizeColumns(ctx, userCred, query, objs, nil, true)
+
+	// ssh端口为系统元数据, 不在详情中返回
+	ports := []db.SMetadata{}
+	q = db.Metadata.Query().Equals("key", api.SSH_PORT).In("id", resIds)
+	err = db.FetchModelObjects(db.Metadata, q, &ports)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "fetch ssh ports"))
+	}
+	portMap := map[string]int{}
+	for _, port := range ports {
+		portMap[port.Id], _ = strconv.Atoi(port.Value)
+	}
+
+	ret := make([]sInventoryServer, len(guests))
+	for i := range guests {
+		jsonDict := jsonutils.Marshal(details[i]).(*jsonutils.JSONDict)
+		jsonDict.Update(jsonutils.Marshal(&guests[i]).(*jsonutils.JSONDict))
+		err = jsonDict.Unmarshal(&ret[i].ServerDetails)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "unmarshal details of %s", guests[i].Name))
+		}
+		ret[i].SshPort = portMap[resIds[i]]
+	}
+	return ret, nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+type sInventoryServer struct {
+	api.ServerDetails
+
+	// ssh端口
+	SshPort int
+}
+
+var invalidGroupChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// ansible组名只能包含字母数字和下划线
+func groupName(prefix, name string) string {
+	return prefix + "_" + strings.ToLower(invalidGroupChars.ReplaceAllString(name, "_"))
+}
+
+func (server sInventoryServer) getProvider() string {
+	if len(server.Provider) > 0 {
+		return server.Provider
+	}
+	return server.Hypervisor
+}
+
+func (server sInventoryServer) getIps() []string {
+	ips := []string{}
+	for _, ip := range strings.Split(server.IPs, ",") {
+		ip = strings.TrimSpace(ip)
+		if len(ip) > 0 && ip != server.Eip && !utils.IsInStringArray(ip, ips) {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// 优先使用弹性公网IP连接
+func (server sInventoryServer) getAnsibleHost() string {
+	if len(server.Eip) > 0 {
+		return server.Eip
+	}
+	ips := server.getIps()
+	if len(ips) > 0 {
+		return ips[0]
+	}
+	return ""
+}
+
+func (server sInventoryServer) getTags() map[string]string {
+	tags := map[string]string{}
+	for k, v := range server.Metadata {
+		if strings.HasPrefix(k, apis.USER_TAG_PREFIX) {
+			tags[strings.TrimPrefix(k, apis.USER_TAG_PREFIX)] = v
+		}
+	}
+	return tags
+}
+
+func (server sInventoryServer) getHostVars() map[string]interface{} {
+	vars := map[string]interface{}{
+		"cloudpods_id":             server.Id,
+		"cloudpods_name":           server.Name,
+		"cloudpods_status":         server.Status,
+		"cloudpods_provider":       server.getProvider(),
+		"cloudpods_region":         server.Cloudregion,
+		"cloudpods_zone":           server.Zone,
+		"cloudpods_project":        server.Project,
+		"cloudpods_project_domain": server.ProjectDomain,
+		"cloudpods_os_type":        server.OsType,
+		"cloudpods_os_name":        server.OsName,
+		"cloudpods_external_id":    server.ExternalId,
+		"cloudpods_private_ips":    server.getIps(),
+		"cloudpods_eip":            server.Eip,
+		"cloudpods_keypair":        server.Keypair,
+		"cloudpods_tags":           server.getTags(),
+	}
+	if host := server.getAnsibleHost(); len(host) > 0 {
+		vars["ansible_host"] = host
+	}
+	if server.SshPort > 0 {
+		vars["ansible_port"] = server.SshPort
+	}
+	if account := server.Metadata[api.VM_METADATA_LOGIN_ACCOUNT]; len(account) > 0 {
+		vars["ansible_user"] = account
+	}
+	if strings.EqualFold(server.OsType, "windows") {
+		vars["ansible_connection"] = "winrm"
+	}
+	return vars
+}
+
+func (server sInventoryServer) getGroups() []string {
+	groups := []string{}
+	if provider := server.getProvider(); len(provider) > 0 {
+		groups = append(groups, groupName("provider", provider))
+	}
+	if len(server.Cloudregion) > 0 {
+		groups = append(groups, groupName("region", server.Cloudregion))
+	}
+	if len(server.Project) > 0 {
+		groups = append(groups, groupName("project", server.Project))
+	}
+	if len(server.OsType) > 0 {
+		groups = append(groups, groupName("os", server.OsType))
+	}
+	for k, v := range server.getTags() {
+		groups = append(groups, groupName("tag", k+"_"+v))
+	}
+	return groups
+}
+
+// 生成ansible动态清单格式, 主机名重复时使用虚拟机ID
+func buildInventory(servers []sInventoryServer) map[string]interface{} {
+	names := map[string]int{}
+	for i := range servers {
+		names[servers[i].Name]++
+	}
+	hostvars := map[string]interface{}{}
+	groups := map[string][]string{}
+	for i := range servers {
+		name := servers[i].Name
+		if names[name] > 1 {
+			name = servers[i].Id
+		}
+		hostvars[name] = servers[i].getHostVars()
+		for _, group := range servers[i].getGroups() {
+			groups[group] = append(groups[group], name)
+		}
+	}
+	ret := map[string]interface{}{
+		"_meta": map[string]interface{}{
+			"hostvars": hostvars,
+		},
+	}
+	children := []string{}
+	for group, hosts := range groups {
+		sort.Strings(hosts)
+		ret[group] = map[string]interface{}{
+			"hosts": hosts,
+		}
+		children = append(children, group)
+	}
+	sort.Strings(children)
+	hosts := []string{}
+	for name := range hostvars {
+		hosts = append(hosts, name)
+	}
+	sort.Strings(hosts)
+	ret["all"] = map[string]interface{}{
+		"hosts":    hosts,
+		"children": children,
+	}
+	return ret
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"reflect"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func newServer(id, name, provider, region, ips, eip string, metadata map[string]string) sInventoryServer {
+	server := sInventoryServer{}
+	server.Id = id
+	server.Name = name
+	server.Provider = provider
+	server.Cloudregion = region
+	server.IPs = ips
+	server.Eip = eip
+	server.Project = "system"
+	server.Metadata = metadata
+	return server
+}
+
+func TestBuildInventory(t *testing.T) {
+	servers := []sInventoryServer{
+		newServer("id-1", "web", api.CLOUD_PROVIDER_ALIYUN, "Aliyun cn-hangzhou", "10.0.0.2,47.1.1.1", "47.1.1.1", map[string]string{
+			"user:env":                    "prod",
+			api.VM_METADATA_LOGIN_ACCOUNT: "root",
+		}),
+		newServer("id-2", "db", api.CLOUD_PROVIDER_AWS, "AWS us-east-1", "172.16.0.5", "", nil),
+		newServer("id-3", "db", api.CLOUD_PROVIDER_AWS, "AWS us-east-1", "172.16.0.6", "", nil),
+	}
+	servers[0].SshPort = 2222
+	inv := buildInventory(servers)
+
+	hostvars := inv["_meta"].(map[string]interface{})["hostvars"].(map[string]interface{})
+	web := hostvars["web"].(map[string]interface{})
+	if web["ansible_host"] != "47.1.1.1" || web["ansible_port"] != 2222 || web["ansible_user"] != "root" {
+		t.Errorf("unexpected web hostvars: %v", web)
+	}
+	if !reflect.DeepEqual(web["cloudpods_private_ips"], []string{"10.0.0.2"}) {
+		t.Errorf("unexpected private ips: %v", web["cloudpods_private_ips"])
+	}
+	if _, ok := hostvars["db"]; ok {
+		t.Errorf("duplicated host name should be replaced by id")
+	}
+	if hostvars["id-2"].(map[string]interface{})["ansible_host"] != "172.16.0.5" {
+		t.Errorf("unexpected id-2 hostvars: %v", hostvars["id-2"])
+	}
+
+	cases := map[string][]string{
+		"provider_aliyun":           {"web"},
+		"provider_aws":              {"id-2", "id-3"},
+		"region_aws_us_east_1":      {"id-2", "id-3"},
+		"region_aliyun_cn_hangzhou": {"web"},
+		"project_system":            {"id-2", "id-3", "web"},
+		"tag_env_prod":              {"web"},
+	}
+	for group, want := range cases {
+		g, ok := inv[group]
+		if !ok {
+			t.Errorf("group %s not found", group)
+			continue
+		}
+		if got := g.(map[string]interface{})["hosts"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("group %s: want %v got %v", group, want, got)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/minio/minio-go/pkg/s3utils"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+func (bucket *SBucket) validateObjectKey(key string) error {
+	if len(key) == 0 {
+		return httperrors.NewMissingParameterError("key")
+	}
+	if strings.HasSuffix(key, "/") {
+		return httperrors.NewInputParameterError("object key should not ends with /")
+	}
+	err := s3utils.CheckValidObjectName(key)
+	if err != nil {
+		return httperrors.NewInputParameterError("invalid object key: %s", err)
+	}
+	return nil
+}
+
+func (bucket *SBucket) AllowPerformInitMultipartUpload(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.BucketInitMultipartUploadInput,
+) bool {
+	return bucket.IsOwner(userCred)
+}
+
+// 发起分片上传
+//
+// 发起分片上传, 返回的upload_id用于后续上传分片以及完成或取消上传
+func (bucket *SBucket) PerformInitMultipartUpload(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.BucketInitMultipartUploadInput,
+) (api.BucketMultipartUploadOutput, error) {
+	output := api.BucketMultipartUploadOutput{}
+	if len(bucket.ExternalId) == 0 {
+		return output, httperrors.NewInvalidStatusError("no external bucket")
+	}
+	err := bucket.validateObjectKey(input.Key)
+	if err != nil {
+		return output, err
+	}
+	driver, err := bucket.GetDriver(ctx)
+	if err != nil {
+		return output, errors.Wrap(err, "GetDriver")
+	}
+	if len(input.StorageClass) > 0 && !utils.IsInStringArray(input.StorageClass, driver.GetStorageClasses(bucket.CloudregionId)) {
+		return output, errors.Wrapf(httperrors.ErrInputParameter, "invalid storage class %s", input.StorageClass)
+	}
+	if len(input.Acl) > 0 && !utils.IsInStringArray(input.Acl, driver.GetObjectCannedAcls(bucket.CloudregionId)) {
+		return output, errors.Wrapf(httperrors.ErrInputParameter, "invalid acl %s", input.Acl)
+	}
+	if bucket.ObjectCntLimit > 0 && bucket.ObjectCntLimit < bucket.ObjectCnt+1 {
+		return output, httperrors.NewOutOfQuotaError("object count limit exceeds")
+	}
+
+	iBucket, err := bucket.GetIBucket(ctx)
+	if err != nil {
+		return output, errors.Wrap(err, "GetIBucket")
+	}
+	meta := http.Header{}
+	for k, v := range input.Meta {
+		meta.Set(k, v)
+	}
+	uploadId, err := iBucket.NewMultipartUpload(ctx, input.Key, cloudprovider.TBucketACLType(input.Acl), input.StorageClass, meta)
+	if err != nil {
+		return output, httperrors.NewInternalServerError("NewMultipartUpload error %s", err)
+	}
+	output.Key = input.Key
+	output.UploadId = uploadId
+	return output, nil
+}
+
+func (bucket *SBucket) AllowPerformUploadPart(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject,
+) bool {
+	return bucket.IsOwner(userCred)
+}
+
+// 上传分片
+//
+// 上传分片, 对象KEY, 分片上传ID和分片序号通过请求头指定, 请求体为分片内容
+func (bucket *SBucket) PerformUploadPart(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject,
+) (api.BucketUploadPartOutput, error) {
+	output := api.BucketUploadPartOutput{}
+	if len(bucket.ExternalId) == 0 {
+		return output, httperrors.NewInvalidStatusError("no external bucket")
+	}
+
+	appParams := appsrv.AppContextGetParams(ctx)
+	header := appParams.Request.Header
+
+	key := header.Get(api.BUCKET_UPLOAD_OBJECT_KEY_HEADER)
+	err := bucket.validateObjectKey(key)
+	if err != nil {
+		return output, err
+	}
+	uploadId := header.Get(api.BUCKET_UPLOAD_PART_UPLOAD_ID_HEADER)
+	if len(uploadId) == 0 {
+		return output, httperrors.NewMissingParameterError(api.BUCKET_UPLOAD_PART_UPLOAD_ID_HEADER)
+	}
+	partIndex, err := strconv.Atoi(header.Get(api.BUCKET_UPLOAD_PART_INDEX_HEADER))
+	if err != nil || partIndex < 1 || partIndex > api.BUCKET_UPLOAD_PART_MAX_INDEX {
+		return output, httperrors.NewInputParameterError("invalid part index %q, should be in range [1, %d]", header.Get(api.BUCKET_UPLOAD_PART_INDEX_HEADER), api.BUCKET_UPLOAD_PART_MAX_INDEX)
+	}
+	partSize, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || partSize <= 0 {
+		return output, httperrors.NewInputParameterError("Illegal Content-Length %s", header.Get("Content-Length"))
+	}
+	var offset, totalSize int64
+	if offsetStr := header.Get(api.BUCKET_UPLOAD_PART_OFFSET_HEADER); len(offsetStr) > 0 {
+		offset, err = strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || offset < 0 {
+			return output, httperrors.NewInputParameterError("Illegal part offset %s", offsetStr)
+		}
+	}
+	if totalStr := header.Get(api.BUCKET_UPLOAD_PART_TOTAL_SIZE_HEADER); len(totalStr) > 0 {
+		totalSize, err = strconv.ParseInt(totalStr, 10, 64)
+		if err != nil || totalSize < offset+partSize {
+			return output, httperrors.NewInputParameterError("Illegal total size %s", totalStr)
+		}
+	}
+	if bucket.SizeBytesLimit > 0 && bucket.SizeBytesLimit < bucket.SizeBytes+partSize {
+		return output, httperrors.NewOutOfQuotaError("object size limit exceeds")
+	}
+
+	iBucket, err := bucket.GetIBucket(ctx)
+	if err != nil {
+		return output, errors.Wrap(err, "GetIBucket")
+	}
+	etag, err := iBucket.UploadPart(ctx, key, uploadId, partIndex, io.LimitReader(appParams.Request.Body, partSize), partSize, offset, totalSize)
+	if err != nil {
+		return output, httperrors.NewInternalServerError("UploadPart error %s", err)
+	}
+	output.PartIndex = partIndex
+	output.Etag = etag
+	return output, nil
+}
+
+func (bucket *SBucket) AllowPerformCompleteMultipartUpload(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.BucketCompleteMultipartUploadInput,
+) bool {
+	return bucket.IsOwner(userCred)
+}
+
+// 完成分片上传
+//
+// 完成分片上传, 按分片序号顺序提交各分片的ETag
+func (bucket *SBucket) PerformCompleteMultipartUpload(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.BucketCompleteMultipartUploadInput,
+) (jsonutils.JSONObject, error) {
+	if len(bucket.ExternalId) == 0 {
+		return nil, httperrors.NewInvalidStatusError("no external bucket")
+	}
+	err := bucket.validateObjectKey(input.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.UploadId) == 0 {
+		return nil, httperrors.NewMissingParameterError("upload_id")
+	}
+	if len(input.PartEtags) == 0 {
+		return nil, httperrors.NewMissingParameterError("part_etags")
+	}
+	iBucket, err := bucket.GetIBucket(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetIBucket")
+	}
+	err = iBucket.CompleteMultipartUpload(ctx, input.Key, input.UploadId, input.PartEtags)
+	if err != nil {
+		return nil, httperrors.NewInternalServerError("CompleteMultipartUpload error %s", err)
+	}
+
+	db.OpsLog.LogEvent(bucket, db.ACT_UPLOAD_OBJECT, input.Key, userCred)
+	logclient.AddActionLogWithContext(ctx, bucket, logclient.ACT_UPLOAD_OBJECT, input.Key, userCred, true)
+
+	bucket.syncWithCloudBucket(ctx, userCred, iBucket, nil, true)
+
+	return nil, nil
+}
+
+func (bucket *SBucket) AllowPerformAbortMultipartUpload(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.BucketAbortMultipartUploadInput,
+) bool {
+	return bucket.IsOwner(userCred)
+}
+
+// 取消分片上传
+//
+// 取消分片上传, 并清理已上传的分片
+func (bucket *SBucket) PerformAbortMultipartUpload(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.BucketAbortMultipartUploadInput,
+) (jsonutils.JSONObject, error) {
+	if len(bucket.ExternalId) == 0 {
+		return nil, httperrors.NewInvalidStatusError("no external bucket")
+	}
+	if len(input.Key) == 0 {
+		return nil, httperrors.NewMissingParameterError("key")
+	}
+	if len(input.UploadId) == 0 {
+		return nil, httperrors.NewMissingParameterError("upload_id")
+	}
+	iBucket, err := bucket.GetIBucket(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetIBucket")
+	}
+	err = iBucket.AbortMultipartUpload(ctx, input.Key, input.UploadId)
+	if err != nil {
+		return nil, httperrors.NewInternalServerError("AbortMultipartUpload error %s", err)
+	}
+	return nil, nil
+}
+
+func (bucket *SBucket) AllowGetDetailsMultipartUploads(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	input api.BucketGetMultipartUploadsInput,
+) bool {
+	return bucket.IsOwner(userCred)
+}
+
+// 获取未完成的分片上传列表
+//
+// 获取未完成的分片上传列表
+func (bucket *SBucket) GetDetailsMultipartUploads(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	input api.BucketGetMultipartUploadsInput,
+) (api.BucketGetMultipartUploadsOutput, error) {
+	output := api.BucketGetMultipartUploadsOutput{}
+	if len(bucket.ExternalId) == 0 {
+		return output, httperrors.NewInvalidStatusError("no external bucket")
+	}
+	iBucket, err := bucket.GetIBucket(ctx)
+	if err != nil {
+		return output, errors.Wrap(err, "GetIBucket")
+	}
+	uploads, err := iBucket.ListMultipartUploads()
+	if err != nil {
+		return output, httperrors.NewInternalServerError("ListMultipartUploads error %s", err)
+	}
+	for i := range uploads {
+		if len(input.Prefix) > 0 && !strings.HasPrefix(uploads[i].ObjectName, input.Prefix) {
+			continue
+		}
+		output.Data = append(output.Data, api.BucketMultipartUpload{
+			Key:       uploads[i].ObjectName,
+			UploadId:  uploads[i].UploadID,
+			Initiator: uploads[i].Initiator,
+			Initiated: uploads[i].Initiated,
+		})
+	}
+	return output, nil
+}
+
+// GetObject 获取对象内容, rangeStr为HTTP Range请求头, 为空时获取整个对象
+func (bucket *SBucket) GetObject(ctx context.Context, key string, rangeStr string) (cloudprovider.ICloudObject, *cloudprovider.SGetObjectRange, io.ReadCloser, error) {
+	if len(bucket.ExternalId) == 0 {
+		return nil, nil, nil, httperrors.NewInvalidStatusError("no external bucket")
+	}
+	err := bucket.validateObjectKey(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	iBucket, err := bucket.GetIBucket(ctx)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "GetIBucket")
+	}
+	obj, err := cloudprovider.GetIObject(iBucket, key)
+	if err != nil {
+		if errors.Cause(err) == cloudprovider.ErrNotFound {
+			return nil, nil, nil, httperrors.NewResourceNotFoundError2("object", key)
+		}
+		return nil, nil, nil, httperrors.NewInternalServerError("GetIObject error %s", err)
+	}
+	var objRange *cloudprovider.SGetObjectRange
+	if len(rangeStr) > 0 {
+		r, err := parseObjectRange(rangeStr, obj.GetSizeBytes())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		objRange = &r
+	}
+	reader, err := iBucket.GetObject(ctx, key, objRange)
+	if err != nil {
+		return nil, nil, nil, httperrors.NewInternalServerError("GetObject error %s", err)
+	}
+	return obj, objRange, reader, nil
+}
+
+// 解析单个区间的Range请求头, 支持bytes=start-end, bytes=start-, bytes=-suffix
+func parseObjectRange(rangeStr string, size int64) (cloudprovider.SGetObjectRange, error) {
+	r := cloudprovider.SGetObjectRange{}
+	spec := strings.TrimPrefix(strings.TrimSpace(rangeStr), "bytes=")
+	parts := strings.Split(spec, "-")
+	if len(parts) != 2 || (len(parts[0]) == 0 && len(parts[1]) == 0) {
+		return r, httperrors.NewInputParameterError("invalid range %s", rangeStr)
+	}
+	if len(parts[0]) == 0 {
+		suffix, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || suffix <= 0 {
+			return r, httperrors.NewInputParameterError("invalid range %s", rangeStr)
+		}
+		if suffix > size {
+			suffix = size
+		}
+		r.Start, r.End = size-suffix, size-1
+	} else {
+		start, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return r, httperrors.NewInputParameterError("invalid range %s", rangeStr)
+		}
+		r.Start, r.End = start, size-1
+		if len(parts[1]) > 0 {
+			end, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil {
+				return r, httperrors.NewInputParameterError("invalid range %s", rangeStr)
+			}
+			if end < r.End {
+				r.End = end
+			}
+		}
+	}
+	if r.Start < 0 || r.Start > r.End {
+		return r, httperrors.NewInputParameterError("range %s not satisfiable for object of size %d", rangeStr, size)
+	}
+	return r, nil
+}
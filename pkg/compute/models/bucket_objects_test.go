@@ -0,0 +1,58 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+)
+
+func TestParseObjectRange(t *testing.T) {
+	cases := []struct {
+		name  string
+		in    string
+		size  int64
+		out   cloudprovider.SGetObjectRange
+		isErr bool
+	}{
+		{name: "start-end", in: "bytes=0-99", size: 1000, out: cloudprovider.SGetObjectRange{Start: 0, End: 99}},
+		{name: "start only", in: "bytes=100-", size: 1000, out: cloudprovider.SGetObjectRange{Start: 100, End: 999}},
+		{name: "suffix", in: "bytes=-100", size: 1000, out: cloudprovider.SGetObjectRange{Start: 900, End: 999}},
+		{name: "suffix larger than size", in: "bytes=-2000", size: 1000, out: cloudprovider.SGetObjectRange{Start: 0, End: 999}},
+		{name: "end beyond size", in: "bytes=500-5000", size: 1000, out: cloudprovider.SGetObjectRange{Start: 500, End: 999}},
+		{name: "start beyond size", in: "bytes=1000-", size: 1000, isErr: true},
+		{name: "start after end", in: "bytes=10-5", size: 1000, isErr: true},
+		{name: "empty", in: "bytes=-", size: 1000, isErr: true},
+		{name: "multi range", in: "bytes=0-1,5-6", size: 1000, isErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := parseObjectRange(c.in, c.size)
+			if c.isErr {
+				if err == nil {
+					t.Fatalf("expect error, got %#v", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != c.out {
+				t.Fatalf("want %#v, got %#v", c.out, out)
+			}
+		})
+	}
+}
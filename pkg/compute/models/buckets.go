@@ -95,7 +95,7 @@ type SBucket struct {
 }
 
 func (manager *SBucketManager) SetHandlerProcessTimeout(info *appsrv.SHandlerInfo, r *http.Request) time.Duration {
-	if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/upload") && r.Header.Get(api.BUCKET_UPLOAD_OBJECT_KEY_HEADER) != "" {
+	if r.Method == http.MethodPost && (strings.HasSuffix(r.URL.Path, "/upload") || strings.HasSuffix(r.URL.Path, "/upload-part")) && r.Header.Get(api.BUCKET_UPLOAD_OBJECT_KEY_HEADER) != "" {
 		log.Debugf("upload object, set process timeout to 2 hour!!!")
 		return 2 * time.Hour
 	}
@@ -431,6 +431,10 @@ func (manager *SBucketManager) ValidateCreateData(
 	input api.BucketCreateInput,
 ) (api.BucketCreateInput, error) {
 	var err error
+	input.Metadata, err = TagPolicyManager.validateCreateTags(ctx, ownerId, input.Metadata)
+	if err != nil {
+		return input, err
+	}
 	var cloudRegionV *SCloudregion
 	cloudRegionV, input.CloudregionResourceInput, err = ValidateCloudregionResourceInput(userCred, input.CloudregionResourceInput)
 	if err != nil {
@@ -802,12 +806,19 @@ func (bucket *SBucket) PerformTempUrl(
 	if len(method) == 0 {
 		method = "GET"
 	}
+	method = strings.ToUpper(method)
+	if !utils.IsInStringArray(method, api.BUCKET_TEMP_URL_METHODS) {
+		return output, httperrors.NewInputParameterError("invalid method %s, support %s", method, api.BUCKET_TEMP_URL_METHODS)
+	}
 	if len(key) == 0 {
 		return output, httperrors.NewInputParameterError("missing key")
 	}
 	if expire == 0 {
 		expire = 60 // default 60 seconds
 	}
+	if expire < 0 || expire > api.BUCKET_TEMP_URL_MAX_EXPIRE_SECONDS {
+		return output, httperrors.NewInputParameterError("expire_seconds should be in range (0, %d]", api.BUCKET_TEMP_URL_MAX_EXPIRE_SECONDS)
+	}
 
 	iBucket, err := bucket.GetIBucket(ctx)
 	if err != nil {
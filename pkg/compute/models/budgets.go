@@ -0,0 +1,563 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/gotypes"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	napi "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+	"yunion.io/x/onecloud/pkg/util/tagutils"
+)
+
+type SBudgetManager struct {
+	db.SEnabledStatusDomainLevelResourceBaseManager
+}
+
+var BudgetManager *SBudgetManager
+
+func init() {
+	BudgetManager = &SBudgetManager{
+		SEnabledStatusDomainLevelResourceBaseManager: db.NewEnabledStatusDomainLevelResourceBaseManager(
+			SBudget{},
+			"budgets_tbl",
+			"budget",
+			"budgets",
+		),
+	}
+	BudgetManager.SetVirtualObject(BudgetManager)
+	quotas.RegisterPendingQuotaCheckHook(BudgetManager.checkBlockingBudgets)
+}
+
+// 月度费用预算, 费用来源于云账单
+type SBudget struct {
+	db.SEnabledStatusDomainLevelResourceBase
+
+	// 预算范围
+	Scope string `width:"16" charset:"ascii" nullable:"false" list:"domain" create:"domain_required"`
+	// 预算范围对应的项目, 域或云账号ID
+	ScopeId string `width:"64" charset:"ascii" nullable:"false" list:"domain" create:"domain_optional" index:"true"`
+	// 每月预算金额
+	Amount float64 `nullable:"false" list:"domain" create:"domain_required" update:"domain"`
+	// 币种
+	Currency string `width:"5" charset:"ascii" nullable:"true" list:"domain" create:"domain_optional"`
+	// 阈值及对应动作
+	Thresholds *api.SBudgetThresholds `nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+	// 超出预算时自动关机的虚拟机标签
+	StopGuestTag string `width:"256" charset:"utf8" nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+
+	// 统计月份
+	BudgetMonth string `width:"7" charset:"ascii" nullable:"true" list:"domain"`
+	// 当月已产生费用
+	CurrentSpend float64 `nullable:"false" default:"0" list:"domain"`
+	// 当月已触发的最高阈值
+	TriggeredPercent float64 `nullable:"false" default:"0" list:"domain"`
+	// 是否阻止新建资源
+	Blocking bool `nullable:"false" default:"false" list:"domain"`
+	// 最近一次检查时间
+	LastCheckedAt time.Time `nullable:"true" list:"domain"`
+}
+
+func validateBudgetThresholds(thresholds api.SBudgetThresholds) error {
+	for _, threshold := range thresholds {
+		if threshold.Percent <= 0 {
+			return httperrors.NewInputParameterError("threshold percent must be greater than 0")
+		}
+		if len(threshold.Actions) == 0 {
+			return httperrors.NewInputParameterError("missing actions of threshold %v%%", threshold.Percent)
+		}
+		for _, action := range threshold.Actions {
+			if !utils.IsInStringArray(action, api.BUDGET_ACTIONS) {
+				return httperrors.NewInputParameterError("invalid threshold action %s", action)
+			}
+		}
+	}
+	return nil
+}
+
+func parseBudgetStopGuestTag(tag string) (string, string, error) {
+	pos := strings.Index(tag, "=")
+	if pos <= 0 {
+		return "", "", httperrors.NewInputParameterError("invalid stop_guest_tag %q, should be key=value", tag)
+	}
+	return strings.TrimSpace(tag[:pos]), strings.TrimSpace(tag[pos+1:]), nil
+}
+
+func budgetThresholdsHasAction(thresholds api.SBudgetThresholds, action string) bool {
+	for _, threshold := range thresholds {
+		if utils.IsInStringArray(action, threshold.Actions) {
+			return true
+		}
+	}
+	return false
+}
+
+// block_create 通过配额检查阻止新建资源, 未开启配额检查时不生效
+func validateBudgetBlockCreate(thresholds api.SBudgetThresholds) error {
+	if budgetThresholdsHasAction(thresholds, api.BUDGET_ACTION_BLOCK_CREATE) && !consts.EnableQuotaCheck() {
+		return httperrors.NewNotSupportedError("action %s requires enable_quota_check", api.BUDGET_ACTION_BLOCK_CREATE)
+	}
+	return nil
+}
+
+func (manager *SBudgetManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.BudgetCreateInput) (api.BudgetCreateInput, error) {
+	var err error
+	if input.Amount <= 0 {
+		return input, httperrors.NewInputParameterError("amount must be greater than 0")
+	}
+	switch input.Scope {
+	case api.BUDGET_SCOPE_PROJECT:
+		if len(input.ScopeId) == 0 {
+			return input, httperrors.NewMissingParameterError("scope_id")
+		}
+		tenant, err := db.TenantCacheManager.FetchTenantByIdOrName(ctx, input.ScopeId)
+		if err != nil {
+			return input, httperrors.NewResourceNotFoundError2("project", input.ScopeId)
+		}
+		if tenant.DomainId != ownerId.GetProjectDomainId() {
+			return input, httperrors.NewForbiddenError("project %s not belong to domain %s", tenant.Name, ownerId.GetProjectDomain())
+		}
+		input.ScopeId = tenant.Id
+	case api.BUDGET_SCOPE_DOMAIN:
+		if len(input.ScopeId) > 0 {
+			domain, err := db.TenantCacheManager.FetchDomainByIdOrName(ctx, input.ScopeId)
+			if err != nil {
+				return input, httperrors.NewResourceNotFoundError2("domain", input.ScopeId)
+			}
+			if domain.Id != ownerId.GetProjectDomainId() {
+				return input, httperrors.NewForbiddenError("budget of domain %s must be owned by itself", domain.Name)
+			}
+		}
+		input.ScopeId = ownerId.GetProjectDomainId()
+	case api.BUDGET_SCOPE_CLOUDACCOUNT:
+		if len(input.ScopeId) == 0 {
+			return input, httperrors.NewMissingParameterError("scope_id")
+		}
+		account, err := db.FetchByIdOrName(CloudaccountManager, userCred, input.ScopeId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return input, httperrors.NewResourceNotFoundError2(CloudaccountManager.Keyword(), input.ScopeId)
+			}
+			return input, httperrors.NewGeneralError(err)
+		}
+		input.ScopeId = account.GetId()
+	default:
+		return input, httperrors.NewInputParameterError("invalid scope %q, must be one of %s", input.Scope, strings.Join(api.BUDGET_SCOPES, ","))
+	}
+	if len(input.Thresholds) == 0 {
+		input.Thresholds = api.SBudgetThresholds{{Percent: 100, Actions: []string{api.BUDGET_ACTION_NOTIFY}}}
+	}
+	err = validateBudgetThresholds(input.Thresholds)
+	if err != nil {
+		return input, err
+	}
+	if len(input.StopGuestTag) > 0 {
+		_, _, err = parseBudgetStopGuestTag(input.StopGuestTag)
+		if err != nil {
+			return input, err
+		}
+	} else if budgetThresholdsHasAction(input.Thresholds, api.BUDGET_ACTION_STOP_GUESTS) {
+		return input, httperrors.NewMissingParameterError("stop_guest_tag")
+	}
+	err = validateBudgetBlockCreate(input.Thresholds)
+	if err != nil {
+		return input, err
+	}
+	input.Status = api.BUDGET_STATUS_OK
+	input.EnabledStatusDomainLevelResourceCreateInput, err = manager.SEnabledStatusDomainLevelResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusDomainLevelResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (budget *SBudget) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.BudgetUpdateInput) (api.BudgetUpdateInput, error) {
+	var err error
+	if input.Amount != nil && *input.Amount <= 0 {
+		return input, httperrors.NewInputParameterError("amount must be greater than 0")
+	}
+	thresholds := budget.getThresholds()
+	if len(input.Thresholds) > 0 {
+		err = validateBudgetThresholds(input.Thresholds)
+		if err != nil {
+			return input, err
+		}
+		thresholds = input.Thresholds
+	}
+	stopGuestTag := budget.StopGuestTag
+	if input.StopGuestTag != nil {
+		stopGuestTag = *input.StopGuestTag
+		if len(stopGuestTag) > 0 {
+			_, _, err = parseBudgetStopGuestTag(stopGuestTag)
+			if err != nil {
+				return input, err
+			}
+		}
+	}
+	if len(stopGuestTag) == 0 && budgetThresholdsHasAction(thresholds, api.BUDGET_ACTION_STOP_GUESTS) {
+		return input, httperrors.NewMissingParameterError("stop_guest_tag")
+	}
+	if len(input.Thresholds) > 0 {
+		err = validateBudgetBlockCreate(input.Thresholds)
+		if err != nil {
+			return input, err
+		}
+	}
+	input.EnabledStatusDomainLevelResourceBaseUpdateInput, err = budget.SEnabledStatusDomainLevelResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusDomainLevelResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (budget *SBudget) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	budget.SEnabledStatusDomainLevelResourceBase.PostUpdate(ctx, userCred, query, data)
+	if data.Contains("amount") || data.Contains("thresholds") {
+		// 预算或阈值变化后重新评估
+		budget.check(ctx, userCred, time.Now().UTC())
+	}
+}
+
+func (manager *SBudgetManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.BudgetListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Scope) > 0 {
+		q = q.In("scope", query.Scope)
+	}
+	if len(query.ScopeId) > 0 {
+		q = q.In("scope_id", query.ScopeId)
+	}
+	if query.Blocking != nil {
+		if *query.Blocking {
+			q = q.IsTrue("blocking")
+		} else {
+			q = q.IsFalse("blocking")
+		}
+	}
+	return q, nil
+}
+
+func (manager *SBudgetManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.BudgetListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SBudgetManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SBudgetManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.BudgetDetails {
+	rows := make([]api.BudgetDetails, len(objs))
+	domainRows := manager.SEnabledStatusDomainLevelResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	accountIds := []string{}
+	for i := range objs {
+		budget := objs[i].(*SBudget)
+		if budget.Scope == api.BUDGET_SCOPE_CLOUDACCOUNT {
+			accountIds = append(accountIds, budget.ScopeId)
+		}
+	}
+	accounts := map[string]SCloudaccount{}
+	err := db.FetchModelObjectsByIds(CloudaccountManager, "id", accountIds, &accounts)
+	if err != nil {
+		log.Errorf("fetch cloudaccounts error: %v", err)
+	}
+	for i := range rows {
+		budget := objs[i].(*SBudget)
+		rows[i] = api.BudgetDetails{
+			EnabledStatusDomainLevelResourceDetails: domainRows[i],
+			UsagePercent:                            budget.getUsagePercent(),
+		}
+		switch budget.Scope {
+		case api.BUDGET_SCOPE_PROJECT:
+			if tenant, err := db.TenantCacheManager.FetchTenantById(ctx, budget.ScopeId); err == nil {
+				rows[i].ScopeName = tenant.Name
+			}
+		case api.BUDGET_SCOPE_DOMAIN:
+			rows[i].ScopeName = domainRows[i].ProjectDomain
+		case api.BUDGET_SCOPE_CLOUDACCOUNT:
+			rows[i].ScopeName = accounts[budget.ScopeId].Name
+		}
+	}
+	return rows
+}
+
+func (budget *SBudget) getThresholds() api.SBudgetThresholds {
+	if budget.Thresholds == nil {
+		return api.SBudgetThresholds{}
+	}
+	return *budget.Thresholds
+}
+
+func (budget *SBudget) getUsagePercent() float64 {
+	if budget.Amount <= 0 {
+		return 0
+	}
+	return budget.CurrentSpend * 100 / budget.Amount
+}
+
+// 返回当前使用比例已达到的阈值, 按百分比升序排列
+func evaluateBudgetThresholds(thresholds api.SBudgetThresholds, spend, amount float64) api.SBudgetThresholds {
+	ret := api.SBudgetThresholds{}
+	if amount <= 0 {
+		return ret
+	}
+	usage := spend * 100 / amount
+	for _, threshold := range thresholds {
+		if usage >= threshold.Percent {
+			ret = append(ret, threshold)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Percent < ret[j].Percent
+	})
+	return ret
+}
+
+func (budget *SBudget) getScopeField() string {
+	switch budget.Scope {
+	case api.BUDGET_SCOPE_PROJECT:
+		return "tenant_id"
+	case api.BUDGET_SCOPE_DOMAIN:
+		return "domain_id"
+	default:
+		return "cloudaccount_id"
+	}
+}
+
+func (budget *SBudget) getMonthSpend(month string) (float64, error) {
+	q := CloudBillItemManager.Query().Equals("billing_month", month).Equals(budget.getScopeField(), budget.ScopeId)
+	if len(budget.Currency) > 0 {
+		q = q.Equals("currency", budget.Currency)
+	}
+	sq := q.SubQuery()
+	ret := struct {
+		Amount float64
+	}{}
+	err := sq.Query(sqlchemy.SUM("amount", sq.Field("amount"))).First(&ret)
+	if err != nil && errors.Cause(err) != sqlchemy.ErrEmptyQuery {
+		return 0, err
+	}
+	return ret.Amount, nil
+}
+
+func (budget *SBudget) check(ctx context.Context, userCred mcclient.TokenCredential, now time.Time) error {
+	month := now.Format(api.CLOUD_BILL_MONTH_FORMAT)
+	spend, err := budget.getMonthSpend(month)
+	if err != nil {
+		return errors.Wrap(err, "getMonthSpend")
+	}
+	crossed := evaluateBudgetThresholds(budget.getThresholds(), spend, budget.Amount)
+	triggeredPercent := budget.TriggeredPercent
+	if budget.BudgetMonth != month {
+		// 新的月份重新计算
+		triggeredPercent = 0
+	}
+	newCrossed := api.SBudgetThresholds{}
+	blocking := false
+	for _, threshold := range crossed {
+		if threshold.Percent > triggeredPercent {
+			newCrossed = append(newCrossed, threshold)
+		}
+		if utils.IsInStringArray(api.BUDGET_ACTION_BLOCK_CREATE, threshold.Actions) {
+			blocking = true
+		}
+	}
+	status := api.BUDGET_STATUS_OK
+	if len(crossed) > 0 {
+		status = api.BUDGET_STATUS_WARNING
+		triggeredPercent = crossed[len(crossed)-1].Percent
+	} else {
+		triggeredPercent = 0
+	}
+	if spend >= budget.Amount {
+		status = api.BUDGET_STATUS_EXCEEDED
+	}
+	_, err = db.Update(budget, func() error {
+		budget.BudgetMonth = month
+		budget.CurrentSpend = spend
+		budget.TriggeredPercent = triggeredPercent
+		budget.Blocking = blocking
+		budget.Status = status
+		budget.LastCheckedAt = now
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+	for _, threshold := range newCrossed {
+		budget.onThresholdCrossed(ctx, userCred, threshold)
+	}
+	return nil
+}
+
+func (budget *SBudget) onThresholdCrossed(ctx context.Context, userCred mcclient.TokenCredential, threshold api.SBudgetThreshold) {
+	reason := fmt.Sprintf("budget %s spend %.2f %s reaches %v%% of %.2f", budget.Name, budget.CurrentSpend, budget.Currency, threshold.Percent, budget.Amount)
+	db.OpsLog.LogEvent(budget, db.ACT_UPDATE_STATUS, reason, userCred)
+	for _, action := range threshold.Actions {
+		switch action {
+		case api.BUDGET_ACTION_NOTIFY:
+			data := jsonutils.Marshal(budget).(*jsonutils.JSONDict)
+			data.Add(jsonutils.NewString(reason), "reason")
+			notifyclient.SystemExceptionNotify(ctx, napi.ActionSystemException, BudgetManager.Keyword(), data)
+		case api.BUDGET_ACTION_STOP_GUESTS:
+			err := budget.stopGuests(ctx, userCred)
+			if err != nil {
+				logclient.AddSimpleActionLog(budget, logclient.ACT_BUDGET_STOP_GUESTS, err, userCred, false)
+			}
+		}
+	}
+}
+
+func (budget *SBudget) getStopGuests() ([]SGuest, error) {
+	key, value, err := parseBudgetStopGuestTag(budget.StopGuestTag)
+	if err != nil {
+		return nil, err
+	}
+	q := GuestManager.Query().Equals("status", api.VM_RUNNING)
+	switch budget.Scope {
+	case api.BUDGET_SCOPE_PROJECT:
+		q = q.Equals("tenant_id", budget.ScopeId)
+	case api.BUDGET_SCOPE_DOMAIN:
+		q = q.Equals("domain_id", budget.ScopeId)
+	case api.BUDGET_SCOPE_CLOUDACCOUNT:
+		providers := CloudproviderManager.Query("id").Equals("cloudaccount_id", budget.ScopeId).SubQuery()
+		hosts := HostManager.Query("id").In("manager_id", providers).SubQuery()
+		q = q.In("host_id", hosts)
+	}
+	filters := tagutils.STagFilters{
+		Filters: []map[string][]string{{db.USER_TAG_PREFIX + key: []string{value}}},
+	}
+	q = db.ObjectIdQueryWithTagFilters(q, "id", GuestManager.Keyword(), filters)
+	guests := []SGuest{}
+	err = db.FetchModelObjects(GuestManager, q, &guests)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return guests, nil
+}
+
+func (budget *SBudget) stopGuests(ctx context.Context, userCred mcclient.TokenCredential) error {
+	guests, err := budget.getStopGuests()
+	if err != nil {
+		return errors.Wrap(err, "getStopGuests")
+	}
+	errs := []error{}
+	for i := range guests {
+		guest := &guests[i]
+		err := guest.StartGuestStopTask(ctx, userCred, false, false, "")
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "stop guest %s", guest.Name))
+			continue
+		}
+		logclient.AddSimpleActionLog(guest, logclient.ACT_BUDGET_STOP_GUESTS, fmt.Sprintf("stopped by budget %s", budget.Name), userCred, true)
+	}
+	if len(errs) > 0 {
+		return errors.NewAggregate(errs)
+	}
+	logclient.AddSimpleActionLog(budget, logclient.ACT_BUDGET_STOP_GUESTS, fmt.Sprintf("%d guests stopped", len(guests)), userCred, true)
+	return nil
+}
+
+func (budget *SBudget) PerformCheck(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.BudgetCheckInput) (jsonutils.JSONObject, error) {
+	err := budget.check(ctx, userCred, time.Now().UTC())
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+// 定时根据当月账单检查所有启用的预算
+func (manager *SBudgetManager) CheckBudgets(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	budgets := []SBudget{}
+	err := db.FetchModelObjects(manager, manager.Query().IsTrue("enabled"), &budgets)
+	if err != nil {
+		log.Errorf("fetch budgets error: %v", err)
+		return
+	}
+	now := time.Now().UTC()
+	for i := range budgets {
+		err := budgets[i].check(ctx, userCred, now)
+		if err != nil {
+			log.Errorf("check budget %s error: %v", budgets[i].Name, err)
+		}
+	}
+}
+
+// 预算超支且配置了 block_create 动作时, 阻止范围内新建资源
+func (manager *SBudgetManager) checkBlockingBudgets(ctx context.Context, userCred mcclient.TokenCredential, quota quotas.IQuota) error {
+	keys := quota.GetKeys()
+	if gotypes.IsNil(keys) {
+		return nil
+	}
+	ownerId := keys.OwnerId()
+	if ownerId == nil {
+		return nil
+	}
+	conds := []sqlchemy.ICondition{}
+	q := manager.Query().IsTrue("enabled").IsTrue("blocking")
+	if len(ownerId.GetProjectId()) > 0 {
+		conds = append(conds, sqlchemy.AND(sqlchemy.Equals(q.Field("scope"), api.BUDGET_SCOPE_PROJECT), sqlchemy.Equals(q.Field("scope_id"), ownerId.GetProjectId())))
+	}
+	if len(ownerId.GetProjectDomainId()) > 0 {
+		conds = append(conds, sqlchemy.AND(sqlchemy.Equals(q.Field("scope"), api.BUDGET_SCOPE_DOMAIN), sqlchemy.Equals(q.Field("scope_id"), ownerId.GetProjectDomainId())))
+	}
+	fields, values := keys.Fields(), keys.Values()
+	for i := range fields {
+		if fields[i] == "account_id" && i < len(values) && len(values[i]) > 0 {
+			conds = append(conds, sqlchemy.AND(sqlchemy.Equals(q.Field("scope"), api.BUDGET_SCOPE_CLOUDACCOUNT), sqlchemy.Equals(q.Field("scope_id"), values[i])))
+		}
+	}
+	if len(conds) == 0 {
+		return nil
+	}
+	budgets := []SBudget{}
+	err := db.FetchModelObjects(manager, q.Filter(sqlchemy.OR(conds...)), &budgets)
+	if err != nil {
+		return errors.Wrap(err, "fetch blocking budgets")
+	}
+	if len(budgets) > 0 {
+		return httperrors.NewOutOfQuotaError("budget %s exceeded, creating new resources is blocked", budgets[0].Name)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestEvaluateBudgetThresholds(t *testing.T) {
+	thresholds := api.SBudgetThresholds{
+		{Percent: 100, Actions: []string{api.BUDGET_ACTION_BLOCK_CREATE}},
+		{Percent: 80, Actions: []string{api.BUDGET_ACTION_NOTIFY}},
+		{Percent: 120, Actions: []string{api.BUDGET_ACTION_STOP_GUESTS}},
+	}
+	cases := []struct {
+		spend  float64
+		amount float64
+		want   []float64
+	}{
+		{50, 100, []float64{}},
+		{80, 100, []float64{80}},
+		{110, 100, []float64{80, 100}},
+		{130, 100, []float64{80, 100, 120}},
+		{130, 0, []float64{}},
+	}
+	for _, c := range cases {
+		got := evaluateBudgetThresholds(thresholds, c.spend, c.amount)
+		if len(got) != len(c.want) {
+			t.Errorf("spend %v amount %v: want %v, got %v", c.spend, c.amount, c.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i].Percent != c.want[i] {
+				t.Errorf("spend %v amount %v: want %v, got %v", c.spend, c.amount, c.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestParseBudgetStopGuestTag(t *testing.T) {
+	cases := []struct {
+		tag   string
+		key   string
+		value string
+		err   bool
+	}{
+		{"env=dev", "env", "dev", false},
+		{"env = test ", "env", "test", false},
+		{"env=", "env", "", false},
+		{"=dev", "", "", true},
+		{"env", "", "", true},
+	}
+	for _, c := range cases {
+		key, value, err := parseBudgetStopGuestTag(c.tag)
+		if (err != nil) != c.err || key != c.key || value != c.value {
+			t.Errorf("%q: want %q=%q err %v, got %q=%q err %v", c.tag, c.key, c.value, c.err, key, value, err)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/excelutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SChargebackPricingManager struct {
+	db.SEnabledStatusDomainLevelResourceBaseManager
+}
+
+var ChargebackPricingManager *SChargebackPricingManager
+
+func init() {
+	ChargebackPricingManager = &SChargebackPricingManager{
+		SEnabledStatusDomainLevelResourceBaseManager: db.NewEnabledStatusDomainLevelResourceBaseManager(
+			SChargebackPricing{},
+			"chargeback_pricings_tbl",
+			"chargeback_pricing",
+			"chargeback_pricings",
+		),
+	}
+	ChargebackPricingManager.SetVirtualObject(ChargebackPricingManager)
+}
+
+// 域内部结算价格系数
+type SChargebackPricing struct {
+	db.SEnabledStatusDomainLevelResourceBase
+
+	// 适用平台, 为空表示所有平台
+	Provider string `width:"64" charset:"ascii" nullable:"true" list:"domain" create:"domain_optional"`
+	// 适用资源类型, 为空表示所有资源类型
+	ResourceType string `width:"64" charset:"ascii" nullable:"true" list:"domain" create:"domain_optional"`
+	// 价格系数
+	Multiplier float64 `nullable:"false" default:"1" list:"domain" create:"domain_required" update:"domain"`
+}
+
+func (manager *SChargebackPricingManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ChargebackPricingCreateInput) (api.ChargebackPricingCreateInput, error) {
+	var err error
+	if input.Multiplier <= 0 {
+		return input, httperrors.NewInputParameterError("multiplier must be greater than 0")
+	}
+	if len(input.Provider) > 0 && !utils.IsInStringArray(input.Provider, api.CLOUD_PROVIDERS) {
+		return input, httperrors.NewInputParameterError("invalid provider %s", input.Provider)
+	}
+	q := manager.Query().Equals("domain_id", ownerId.GetProjectDomainId()).Equals("provider", input.Provider).Equals("resource_type", input.ResourceType)
+	cnt, err := q.CountWithError()
+	if err != nil {
+		return input, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return input, httperrors.NewDuplicateResourceError("pricing for provider %q resource type %q already exists", input.Provider, input.ResourceType)
+	}
+	input.Status = api.CHARGEBACK_PRICING_STATUS_READY
+	input.EnabledStatusDomainLevelResourceCreateInput, err = manager.SEnabledStatusDomainLevelResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusDomainLevelResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (pricing *SChargebackPricing) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ChargebackPricingUpdateInput) (api.ChargebackPricingUpdateInput, error) {
+	var err error
+	if input.Multiplier != nil && *input.Multiplier <= 0 {
+		return input, httperrors.NewInputParameterError("multiplier must be greater than 0")
+	}
+	input.EnabledStatusDomainLevelResourceBaseUpdateInput, err = pricing.SEnabledStatusDomainLevelResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusDomainLevelResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (manager *SChargebackPricingManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.ChargebackPricingListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Provider) > 0 {
+		q = q.In("provider", query.Provider)
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.In("resource_type", query.ResourceType)
+	}
+	return q, nil
+}
+
+func (manager *SChargebackPricingManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.ChargebackPricingListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SChargebackPricingManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SChargebackPricingManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.ChargebackPricingDetails {
+	rows := make([]api.ChargebackPricingDetails, len(objs))
+	domainRows := manager.SEnabledStatusDomainLevelResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.ChargebackPricingDetails{
+			EnabledStatusDomainLevelResourceDetails: domainRows[i],
+		}
+	}
+	return rows
+}
+
+// 按 平台+资源类型 > 平台 > 资源类型 > 域默认 的优先级查找价格系数, 未配置时为1
+func findChargebackMultiplier(pricings []SChargebackPricing, domainId, provider, resourceType string) float64 {
+	best, bestScore := 1.0, -1
+	for i := range pricings {
+		p := pricings[i]
+		if p.DomainId != domainId {
+			continue
+		}
+		if len(p.Provider) > 0 && p.Provider != provider {
+			continue
+		}
+		if len(p.ResourceType) > 0 && p.ResourceType != resourceType {
+			continue
+		}
+		score := 0
+		if len(p.Provider) > 0 {
+			score += 2
+		}
+		if len(p.ResourceType) > 0 {
+			score += 1
+		}
+		if score > bestScore {
+			best, bestScore = p.Multiplier, score
+		}
+	}
+	return best
+}
+
+type sChargebackRow struct {
+	TenantId      string
+	DomainId      string
+	Provider      string
+	CloudregionId string
+	ResourceType  string
+	ResourceId    string
+	ResourceName  string
+	Currency      string
+	Amount        float64
+}
+
+// 生成项目月度结算单, 包含资源级别明细, 支持导出csv及excel
+func (manager *SCloudBillItemManager) GetPropertyStatement(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.ChargebackStatementOutput, error) {
+	input := api.ChargebackStatementInput{}
+	err := query.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal input: %v", err)
+	}
+	if len(input.Format) == 0 {
+		input.Format = api.CHARGEBACK_STATEMENT_FORMAT_JSON
+	}
+	if !utils.IsInStringArray(input.Format, api.CHARGEBACK_STATEMENT_FORMATS) {
+		return nil, httperrors.NewInputParameterError("invalid format %s", input.Format)
+	}
+	params := query.(*jsonutils.JSONDict).Copy()
+	if len(input.BillingMonth) == 0 && len(input.StartMonth) == 0 && len(input.EndMonth) == 0 {
+		// 默认出上个月的结算单
+		now := time.Now().UTC()
+		input.BillingMonth = []string{now.AddDate(0, 0, -now.Day()).Format(api.CLOUD_BILL_MONTH_FORMAT)}
+		params.Set("billing_month", jsonutils.NewStringArray(input.BillingMonth))
+	}
+
+	q := manager.Query()
+	q, err = db.ListItemQueryFilters(manager, ctx, q, userCred, params, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	sq := q.SubQuery()
+	groupFields := []string{"tenant_id", "domain_id", "provider", "cloudregion_id", "resource_type", "resource_id", "resource_name", "currency"}
+	fields := []sqlchemy.IQueryField{}
+	groupBy := []interface{}{}
+	for _, f := range groupFields {
+		fields = append(fields, sq.Field(f))
+		groupBy = append(groupBy, sq.Field(f))
+	}
+	statQ := sq.Query(append(fields, sqlchemy.SUM("amount", sq.Field("amount")))...)
+	statQ = statQ.GroupBy(groupBy...)
+	rows := []sChargebackRow{}
+	err = statQ.All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "query statement")
+	}
+
+	domainIds, regionIds := []string{}, []string{}
+	for i := range rows {
+		if !utils.IsInStringArray(rows[i].DomainId, domainIds) {
+			domainIds = append(domainIds, rows[i].DomainId)
+		}
+		if !utils.IsInStringArray(rows[i].CloudregionId, regionIds) {
+			regionIds = append(regionIds, rows[i].CloudregionId)
+		}
+	}
+	pricings := []SChargebackPricing{}
+	err = db.FetchModelObjects(ChargebackPricingManager, ChargebackPricingManager.Query().In("domain_id", domainIds).IsTrue("enabled"), &pricings)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch chargeback pricings")
+	}
+	regions := map[string]SCloudregion{}
+	err = db.FetchModelObjectsByIds(CloudregionManager, "id", regionIds, &regions)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch regions")
+	}
+
+	ret := &api.ChargebackStatementOutput{
+		BillingMonth: input.BillingMonth,
+		Projects:     []api.ChargebackProjectStatement{},
+		Domains:      []api.ChargebackDomainStatement{},
+		Format:       input.Format,
+	}
+	projects := map[string]*api.ChargebackProjectStatement{}
+	domains := map[string]*api.ChargebackDomainStatement{}
+	for i := range rows {
+		row := rows[i]
+		multiplier := findChargebackMultiplier(pricings, row.DomainId, row.Provider, row.ResourceType)
+		resource := api.ChargebackStatementResource{
+			Provider:       row.Provider,
+			Region:         regions[row.CloudregionId].Name,
+			ResourceType:   row.ResourceType,
+			ResourceId:     row.ResourceId,
+			ResourceName:   row.ResourceName,
+			Currency:       row.Currency,
+			OriginalAmount: row.Amount,
+			Multiplier:     multiplier,
+			Amount:         row.Amount * multiplier,
+		}
+		project, ok := projects[row.TenantId]
+		if !ok {
+			project = &api.ChargebackProjectStatement{
+				ProjectId:      row.TenantId,
+				DomainId:       row.DomainId,
+				OriginalAmount: map[string]float64{},
+				Amount:         map[string]float64{},
+			}
+			if tenant, err := db.TenantCacheManager.FetchTenantById(ctx, row.TenantId); err == nil {
+				project.Project = tenant.Name
+				project.Domain = tenant.Domain
+			}
+			projects[row.TenantId] = project
+		}
+		project.Resources = append(project.Resources, resource)
+		project.OriginalAmount[row.Currency] += resource.OriginalAmount
+		project.Amount[row.Currency] += resource.Amount
+
+		domain, ok := domains[row.DomainId]
+		if !ok {
+			domain = &api.ChargebackDomainStatement{
+				DomainId:       row.DomainId,
+				Domain:         project.Domain,
+				OriginalAmount: map[string]float64{},
+				Amount:         map[string]float64{},
+			}
+			domains[row.DomainId] = domain
+		}
+		domain.OriginalAmount[row.Currency] += resource.OriginalAmount
+		domain.Amount[row.Currency] += resource.Amount
+	}
+	for _, project := range projects {
+		ret.Projects = append(ret.Projects, *project)
+	}
+	sort.Slice(ret.Projects, func(i, j int) bool {
+		if ret.Projects[i].Domain != ret.Projects[j].Domain {
+			return ret.Projects[i].Domain < ret.Projects[j].Domain
+		}
+		return ret.Projects[i].Project < ret.Projects[j].Project
+	})
+	for _, domain := range domains {
+		ret.Domains = append(ret.Domains, *domain)
+	}
+	sort.Slice(ret.Domains, func(i, j int) bool {
+		return ret.Domains[i].Domain < ret.Domains[j].Domain
+	})
+
+	switch input.Format {
+	case api.CHARGEBACK_STATEMENT_FORMAT_CSV:
+		ret.Content, err = exportChargebackStatementCsv(ret.Projects)
+	case api.CHARGEBACK_STATEMENT_FORMAT_EXCEL:
+		ret.Content, err = exportChargebackStatementExcel(ret.Projects)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "export %s", input.Format)
+	}
+	return ret, nil
+}
+
+var chargebackStatementHeaders = []string{"domain", "project", "provider", "region", "resource_type", "resource_id", "resource_name", "currency", "original_amount", "multiplier", "amount"}
+
+func chargebackStatementRecords(projects []api.ChargebackProjectStatement) [][]string {
+	records := [][]string{}
+	for _, project := range projects {
+		for _, res := range project.Resources {
+			records = append(records, []string{
+				project.Domain, project.Project, res.Provider, res.Region, res.ResourceType, res.ResourceId, res.ResourceName, res.Currency,
+				fmt.Sprintf("%.4f", res.OriginalAmount), fmt.Sprintf("%.4f", res.Multiplier), fmt.Sprintf("%.4f", res.Amount),
+			})
+		}
+	}
+	return records
+}
+
+func exportChargebackStatementCsv(projects []api.ChargebackProjectStatement) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	err := w.Write(chargebackStatementHeaders)
+	if err != nil {
+		return "", err
+	}
+	err = w.WriteAll(chargebackStatementRecords(projects))
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func exportChargebackStatementExcel(projects []api.ChargebackProjectStatement) (string, error) {
+	data := []jsonutils.JSONObject{}
+	for _, record := range chargebackStatementRecords(projects) {
+		row := jsonutils.NewDict()
+		for i, key := range chargebackStatementHeaders {
+			row.Set(key, jsonutils.NewString(record[i]))
+		}
+		data = append(data, row)
+	}
+	var buf bytes.Buffer
+	err := excelutils.Export(data, chargebackStatementHeaders, chargebackStatementHeaders, &buf)
+	if err != nil {
+		log.Errorf("export chargeback statement excel error: %v", err)
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
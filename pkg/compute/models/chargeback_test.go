@@ -0,0 +1,51 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "testing"
+
+func TestFindChargebackMultiplier(t *testing.T) {
+	newPricing := func(domainId, provider, resourceType string, multiplier float64) SChargebackPricing {
+		p := SChargebackPricing{Provider: provider, ResourceType: resourceType, Multiplier: multiplier}
+		p.DomainId = domainId
+		return p
+	}
+	pricings := []SChargebackPricing{
+		newPricing("d1", "", "", 1.1),
+		newPricing("d1", "Aliyun", "", 1.2),
+		newPricing("d1", "", "server", 1.3),
+		newPricing("d1", "Aliyun", "server", 1.4),
+		newPricing("d2", "Aws", "", 0.9),
+	}
+	cases := []struct {
+		domainId     string
+		provider     string
+		resourceType string
+		want         float64
+	}{
+		{"d1", "Aliyun", "server", 1.4},
+		{"d1", "Aliyun", "disk", 1.2},
+		{"d1", "Aws", "server", 1.3},
+		{"d1", "Aws", "disk", 1.1},
+		{"d2", "Aws", "server", 0.9},
+		{"d2", "Aliyun", "server", 1},
+		{"d3", "Aliyun", "server", 1},
+	}
+	for _, c := range cases {
+		if got := findChargebackMultiplier(pricings, c.domainId, c.provider, c.resourceType); got != c.want {
+			t.Errorf("%s/%s/%s: want %v, got %v", c.domainId, c.provider, c.resourceType, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-ignore
+type SCloudBillItemManager struct {
+	db.SStandaloneAnonResourceBaseManager
+	db.SProjectizedResourceBaseManager
+	SManagedResourceBaseManager
+	SCloudregionResourceBaseManager
+}
+
+var CloudBillItemManager *SCloudBillItemManager
+
+func init() {
+	CloudBillItemManager = &SCloudBillItemManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SCloudBillItem{},
+			"cloud_bill_items_tbl",
+			"cloud_bill_item",
+			"cloud_bill_items",
+		),
+	}
+	CloudBillItemManager.SetVirtualObject(CloudBillItemManager)
+}
+
+// 归一化后的云账单明细
+type SCloudBillItem struct {
+	db.SStandaloneAnonResourceBase
+	db.SProjectizedResourceBase
+	SManagedResourceBase
+	SCloudregionResourceBase
+
+	// 归属云账号ID
+	CloudaccountId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	// 平台
+	Provider string `width:"64" charset:"ascii" nullable:"false" list:"user"`
+	// 账单月份, 例如: 2023-01
+	BillingMonth string `width:"7" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	// 用量日期
+	UsageDate time.Time `nullable:"true" list:"user"`
+	// 云上账单明细Id
+	ItemId string `width:"128" charset:"ascii" nullable:"false" list:"user"`
+	// 云上区域Id
+	ExternalRegionId string `width:"64" charset:"ascii" nullable:"true" list:"user"`
+	// 资源类型
+	ResourceType string `width:"64" charset:"ascii" nullable:"true" list:"user" index:"true"`
+	// 云上资源Id
+	ResourceId string `width:"256" charset:"ascii" nullable:"true" list:"user"`
+	// 资源名称
+	ResourceName string `width:"256" charset:"utf8" nullable:"true" list:"user"`
+	// 产品代码
+	ProductCode string `width:"64" charset:"ascii" nullable:"true" list:"user"`
+	// 计费方式
+	ChargeType string `width:"32" charset:"ascii" nullable:"true" list:"user"`
+	// 费用
+	Amount float64 `nullable:"false" default:"0" list:"user"`
+	// 币种
+	Currency string `width:"8" charset:"ascii" nullable:"true" list:"user"`
+}
+
+func (manager *SCloudBillItemManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return nil, httperrors.NewUnsupportOperationError("cloud bill items are imported by cloudprovider import-bills")
+}
+
+func (manager *SCloudBillItemManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.CloudBillItemListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStandaloneAnonResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SManagedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ManagedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SCloudregionResourceBaseManager.ListItemFilter(ctx, q, userCred, query.RegionalFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.ListItemFilter")
+	}
+	if len(query.BillingMonth) > 0 {
+		q = q.In("billing_month", query.BillingMonth)
+	}
+	if len(query.StartMonth) > 0 {
+		q = q.GE("billing_month", query.StartMonth)
+	}
+	if len(query.EndMonth) > 0 {
+		q = q.LE("billing_month", query.EndMonth)
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.In("resource_type", query.ResourceType)
+	}
+	if len(query.ResourceId) > 0 {
+		q = q.In("resource_id", query.ResourceId)
+	}
+	if len(query.ChargeType) > 0 {
+		q = q.In("charge_type", query.ChargeType)
+	}
+	return q, nil
+}
+
+func (manager *SCloudBillItemManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.CloudBillItemListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStandaloneAnonResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SManagedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ManagedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SCloudregionResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.RegionalFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SCloudBillItemManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStandaloneAnonResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SManagedResourceBaseManager,
+		&manager.SCloudregionResourceBaseManager,
+	)
+}
+
+func (manager *SCloudBillItemManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	return db.ApplyListItemExportKeys(ctx, q, userCred, keys,
+		&manager.SStandaloneAnonResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SManagedResourceBaseManager,
+		&manager.SCloudregionResourceBaseManager,
+	)
+}
+
+func (manager *SCloudBillItemManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.CloudBillItemDetails {
+	rows := make([]api.CloudBillItemDetails, len(objs))
+	stdRows := manager.SStandaloneAnonResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	managerRows := manager.SManagedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	regionRows := manager.SCloudregionResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.CloudBillItemDetails{
+			StandaloneAnonResourceDetails: stdRows[i],
+			ProjectizedResourceInfo:       projRows[i],
+			ManagedResourceInfo:           managerRows[i],
+			CloudregionResourceInfo:       regionRows[i],
+		}
+	}
+	return rows
+}
+
+func (manager *SCloudBillItemManager) purgeAll(ctx context.Context, userCred mcclient.TokenCredential, providerId string) error {
+	_, err := sqlchemy.GetDB().Exec(
+		fmt.Sprintf("delete from %s where manager_id = ?", manager.TableSpec().Name()),
+		providerId,
+	)
+	return err
+}
+
+func (manager *SCloudBillItemManager) removeMonthItems(managerId, billingMonth string) error {
+	_, err := sqlchemy.GetDB().Exec(
+		fmt.Sprintf("delete from %s where manager_id = ? and billing_month = ?", manager.TableSpec().Name()),
+		managerId, billingMonth,
+	)
+	return err
+}
+
+// 以导入的账单替换该订阅对应月份的账单明细, 云上账单在出账前会持续调整, 可重复导入
+func (manager *SCloudBillItemManager) syncMonthItems(ctx context.Context, provider *SCloudprovider, billingMonth string, items []api.CloudBillLineItem) error {
+	lockman.LockRawObject(ctx, manager.Keyword(), fmt.Sprintf("%s-%s", provider.Id, billingMonth))
+	defer lockman.ReleaseRawObject(ctx, manager.Keyword(), fmt.Sprintf("%s-%s", provider.Id, billingMonth))
+
+	regionIds := map[string]string{}
+	for _, cpr := range provider.GetCloudproviderRegions() {
+		region, err := cpr.GetRegion()
+		if err != nil {
+			continue
+		}
+		regionIds[region.ExternalId] = region.Id
+		if idx := strings.Index(region.ExternalId, "/"); idx >= 0 {
+			regionIds[region.ExternalId[idx+1:]] = region.Id
+		}
+	}
+
+	projects := []SExternalProject{}
+	err := db.FetchModelObjects(ExternalProjectManager, ExternalProjectManager.Query().Equals("cloudaccount_id", provider.CloudaccountId), &projects)
+	if err != nil {
+		return errors.Wrap(err, "fetch external projects")
+	}
+	projectMap := map[string]*SExternalProject{}
+	for i := range projects {
+		projectMap[projects[i].ExternalId] = &projects[i]
+	}
+
+	err = manager.removeMonthItems(provider.Id, billingMonth)
+	if err != nil {
+		return errors.Wrapf(err, "remove bill items of %s", billingMonth)
+	}
+	for i := range items {
+		item := &SCloudBillItem{
+			CloudaccountId:   provider.CloudaccountId,
+			Provider:         provider.Provider,
+			BillingMonth:     billingMonth,
+			UsageDate:        items[i].UsageDate,
+			ItemId:           items[i].ItemId,
+			ExternalRegionId: items[i].RegionId,
+			ResourceType:     items[i].ResourceType,
+			ResourceId:       items[i].ResourceId,
+			ResourceName:     items[i].ResourceName,
+			ProductCode:      items[i].ProductCode,
+			ChargeType:       items[i].ChargeType,
+			Amount:           items[i].Amount,
+			Currency:         items[i].Currency,
+		}
+		item.ManagerId = provider.Id
+		item.CloudregionId = regionIds[items[i].RegionId]
+		// 优先按云上项目归属, 否则归属到订阅所在项目
+		if project, ok := projectMap[items[i].ExternalProjectId]; ok && len(items[i].ExternalProjectId) > 0 {
+			item.DomainId = project.DomainId
+			item.ProjectId = project.ProjectId
+		} else {
+			item.DomainId = provider.DomainId
+			item.ProjectId = provider.ProjectId
+		}
+		item.SetModelManager(manager, item)
+		err = manager.TableSpec().Insert(ctx, item)
+		if err != nil {
+			return errors.Wrapf(err, "insert bill item %s", items[i].ItemId)
+		}
+	}
+	return nil
+}
+
+// validateCloudBillLineItems 校验导入的账单明细, 用量日期需在账单月份内
+func validateCloudBillLineItems(billingMonth string, items []api.CloudBillLineItem) error {
+	month, err := time.Parse(api.CLOUD_BILL_MONTH_FORMAT, billingMonth)
+	if err != nil {
+		return httperrors.NewInputParameterError("invalid billing_month %s, expect format like 2023-01", billingMonth)
+	}
+	next := month.AddDate(0, 1, 0)
+	itemIds := map[string]bool{}
+	for i := range items {
+		if len(items[i].ItemId) == 0 {
+			return httperrors.NewMissingParameterError(fmt.Sprintf("items.%d.item_id", i))
+		}
+		if itemIds[items[i].ItemId] {
+			return httperrors.NewDuplicateIdError("item_id", items[i].ItemId)
+		}
+		itemIds[items[i].ItemId] = true
+		if !items[i].UsageDate.IsZero() && (items[i].UsageDate.Before(month) || !items[i].UsageDate.Before(next)) {
+			return httperrors.NewInputParameterError("usage_date %s of item %s is out of billing month %s", items[i].UsageDate, items[i].ItemId, billingMonth)
+		}
+	}
+	return nil
+}
+
+// 导入从云平台导出(AWS CUR, 阿里云BSS, 华为云账单, Azure成本管理)并归一化后的账单明细, 替换该订阅对应月份的账单
+func (self *SCloudprovider) PerformImportBills(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.CloudproviderImportBillsInput) (jsonutils.JSONObject, error) {
+	err := validateCloudBillLineItems(input.BillingMonth, input.Items)
+	if err != nil {
+		return nil, err
+	}
+	err = CloudBillItemManager.syncMonthItems(ctx, self, input.BillingMonth, input.Items)
+	if err != nil {
+		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_IMPORT_BILLS, err, userCred, false)
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_IMPORT_BILLS, input.BillingMonth, userCred, true)
+	return nil, nil
+}
+
+func getCloudBillGroupColumn(groupBy string) string {
+	switch groupBy {
+	case api.CLOUD_BILL_GROUP_BY_PROJECT:
+		return "tenant_id"
+	case api.CLOUD_BILL_GROUP_BY_REGION:
+		return "cloudregion_id"
+	case api.CLOUD_BILL_GROUP_BY_CLOUDACCOUNT:
+		return "cloudaccount_id"
+	}
+	return groupBy
+}
+
+// 按项目/平台/区域等维度汇总费用
+func (manager *SCloudBillItemManager) GetPropertyCost(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.CloudBillCostOutput, error) {
+	input := api.CloudBillCostInput{}
+	err := query.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal input: %v", err)
+	}
+	if len(input.GroupBy) == 0 {
+		input.GroupBy = api.CLOUD_BILL_GROUP_BY_PROJECT
+	}
+	if !utils.IsInStringArray(input.GroupBy, api.CLOUD_BILL_GROUP_BYS) {
+		return nil, httperrors.NewInputParameterError("invalid group_by %s", input.GroupBy)
+	}
+
+	q := manager.Query()
+	q, err = db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	sq := q.SubQuery()
+	column := getCloudBillGroupColumn(input.GroupBy)
+	costQ := sq.Query(
+		sq.Field(column, "key"),
+		sq.Field("currency"),
+		sqlchemy.SUM("amount", sq.Field("amount")),
+	).GroupBy(sq.Field(column), sq.Field("currency"))
+
+	ret := &api.CloudBillCostOutput{
+		GroupBy: input.GroupBy,
+		Data:    []api.CloudBillCostItem{},
+		Total:   map[string]float64{},
+	}
+	err = costQ.All(&ret.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "query cost")
+	}
+
+	keys := []string{}
+	for i := range ret.Data {
+		ret.Total[ret.Data[i].Currency] += ret.Data[i].Amount
+		keys = append(keys, ret.Data[i].Key)
+	}
+	names := map[string]string{}
+	switch input.GroupBy {
+	case api.CLOUD_BILL_GROUP_BY_PROJECT:
+		for _, key := range keys {
+			if tenant, err := db.TenantCacheManager.FetchTenantById(ctx, key); err == nil {
+				names[key] = tenant.Name
+			}
+		}
+	case api.CLOUD_BILL_GROUP_BY_REGION:
+		regions := map[string]SCloudregion{}
+		err = db.FetchModelObjectsByIds(CloudregionManager, "id", keys, &regions)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch regions")
+		}
+		for id, region := range regions {
+			names[id] = region.Name
+		}
+	case api.CLOUD_BILL_GROUP_BY_CLOUDACCOUNT:
+		accounts := map[string]SCloudaccount{}
+		err = db.FetchModelObjectsByIds(CloudaccountManager, "id", keys, &accounts)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch cloudaccounts")
+		}
+		for id, account := range accounts {
+			names[id] = account.Name
+		}
+	}
+	for i := range ret.Data {
+		ret.Data[i].Name = ret.Data[i].Key
+		if name, ok := names[ret.Data[i].Key]; ok {
+			ret.Data[i].Name = name
+		}
+	}
+	return ret, nil
+}
+
+// 统计指定账单月份各区域平均每个资源的费用, 用于调度时比较区域价格
+// 不同币种之间未做换算
+func (manager *SCloudBillItemManager) GetRegionAverageCosts(billingMonth string) (map[string]float64, error) {
+	sq := manager.Query().Equals("billing_month", billingMonth).IsNotEmpty("cloudregion_id").IsNotEmpty("resource_id").SubQuery()
+	q := sq.Query(
+		sq.Field("cloudregion_id"),
+		sq.Field("resource_id"),
+		sqlchemy.SUM("amount", sq.Field("amount")),
+	).GroupBy(sq.Field("cloudregion_id"), sq.Field("resource_id"))
+	rows := []struct {
+		CloudregionId string
+		ResourceId    string
+		Amount        float64
+	}{}
+	err := q.All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "query region costs")
+	}
+	amounts, counts := map[string]float64{}, map[string]int{}
+	for _, row := range rows {
+		amounts[row.CloudregionId] += row.Amount
+		counts[row.CloudregionId]++
+	}
+	ret := map[string]float64{}
+	for regionId, amount := range amounts {
+		ret[regionId] = amount / float64(counts[regionId])
+	}
+	return ret, nil
+}
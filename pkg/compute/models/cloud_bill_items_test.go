@@ -0,0 +1,42 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestValidateCloudBillLineItems(t *testing.T) {
+	cases := []struct {
+		month   string
+		items   []api.CloudBillLineItem
+		wantErr bool
+	}{
+		{month: "2023-03", items: []api.CloudBillLineItem{{ItemId: "a", UsageDate: time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)}, {ItemId: "b"}}},
+		{month: "2023-3-1", wantErr: true},
+		{month: "2023-03", items: []api.CloudBillLineItem{{}}, wantErr: true},
+		{month: "2023-03", items: []api.CloudBillLineItem{{ItemId: "a"}, {ItemId: "a"}}, wantErr: true},
+		{month: "2023-03", items: []api.CloudBillLineItem{{ItemId: "a", UsageDate: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)}}, wantErr: true},
+	}
+	for _, c := range cases {
+		err := validateCloudBillLineItems(c.month, c.items)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s %v: want error %v, got %v", c.month, c.items, c.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,565 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	billing_api "yunion.io/x/onecloud/pkg/apis/billing"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SCloudCommitmentManager struct {
+	db.SStatusInfrasResourceBaseManager
+	db.SExternalizedResourceBaseManager
+	SManagedResourceBaseManager
+	SCloudregionResourceBaseManager
+}
+
+var CloudCommitmentManager *SCloudCommitmentManager
+
+func init() {
+	CloudCommitmentManager = &SCloudCommitmentManager{
+		SStatusInfrasResourceBaseManager: db.NewStatusInfrasResourceBaseManager(
+			SCloudCommitment{},
+			"cloud_commitments_tbl",
+			"cloud_commitment",
+			"cloud_commitments",
+		),
+	}
+	CloudCommitmentManager.SetVirtualObject(CloudCommitmentManager)
+}
+
+// 云上容量承诺(预留实例/节省计划), 由管理员登记
+type SCloudCommitment struct {
+	db.SStatusInfrasResourceBase
+	db.SExternalizedResourceBase
+	SManagedResourceBase
+	SCloudregionResourceBase `width:"36" charset:"ascii" nullable:"true" list:"domain"`
+
+	// 承诺类型
+	CommitmentType string `width:"32" charset:"ascii" nullable:"false" list:"domain" index:"true" create:"domain_required"`
+	// 预留实例规格
+	InstanceType string `width:"64" charset:"utf8" nullable:"true" list:"domain" create:"domain_optional"`
+	// 预留实例数量
+	InstanceCount int `nullable:"false" default:"0" list:"domain" create:"domain_optional" update:"domain"`
+	// 操作系统
+	OsType string `width:"32" charset:"ascii" nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+	// 付款方式
+	PaymentOption string `width:"32" charset:"utf8" nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+	// 节省计划每小时承诺金额
+	HourlyCommitment float64 `nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+	// 币种
+	Currency string `width:"5" charset:"ascii" nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+	// 生效时间
+	StartAt time.Time `nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+	// 到期时间
+	EndAt time.Time `nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+	// 云平台统计的利用率(百分比), -1表示未知
+	Utilization float64 `nullable:"false" default:"-1" list:"domain" create:"domain_optional" update:"domain"`
+}
+
+// 按生效及到期时间计算承诺状态
+func getCloudCommitmentStatus(startAt, endAt, now time.Time) string {
+	if !startAt.IsZero() && now.Before(startAt) {
+		return api.CLOUD_COMMITMENT_STATUS_PENDING
+	}
+	if !endAt.IsZero() && !now.Before(endAt) {
+		return api.CLOUD_COMMITMENT_STATUS_EXPIRED
+	}
+	return api.CLOUD_COMMITMENT_STATUS_ACTIVE
+}
+
+func validateCloudCommitment(commitmentType, instanceType string, instanceCount int, hourlyCommitment float64, startAt, endAt time.Time) error {
+	switch commitmentType {
+	case api.CLOUD_COMMITMENT_TYPE_SAVINGS_PLAN:
+		if hourlyCommitment <= 0 {
+			return httperrors.NewInputParameterError("hourly_commitment of savings plan must be greater than 0")
+		}
+	case api.CLOUD_COMMITMENT_TYPE_RESERVED_INSTANCE, api.CLOUD_COMMITMENT_TYPE_COMMITTED_USE:
+		if len(instanceType) == 0 {
+			return httperrors.NewMissingParameterError("instance_type")
+		}
+		if instanceCount <= 0 {
+			return httperrors.NewInputParameterError("instance_count must be greater than 0")
+		}
+	default:
+		return httperrors.NewInputParameterError("invalid commitment_type %q, must be one of %s", commitmentType, strings.Join(api.CLOUD_COMMITMENT_TYPES, ","))
+	}
+	if !startAt.IsZero() && !endAt.IsZero() && !endAt.After(startAt) {
+		return httperrors.NewInputParameterError("end_at must be after start_at")
+	}
+	return nil
+}
+
+// 云平台未提供容量承诺查询接口, 由管理员按订阅和区域登记购买的预留实例/节省计划
+func (manager *SCloudCommitmentManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.CloudCommitmentCreateInput) (api.CloudCommitmentCreateInput, error) {
+	var err error
+	var region *SCloudregion
+	region, input.CloudregionResourceInput, err = ValidateCloudregionResourceInput(userCred, input.CloudregionResourceInput)
+	if err != nil {
+		return input, errors.Wrap(err, "ValidateCloudregionResourceInput")
+	}
+	var provider *SCloudprovider
+	provider, input.CloudproviderResourceInput, err = ValidateCloudproviderResourceInput(userCred, input.CloudproviderResourceInput)
+	if err != nil {
+		return input, errors.Wrap(err, "ValidateCloudproviderResourceInput")
+	}
+	if region.Provider != provider.Provider {
+		return input, httperrors.NewInputParameterError("region %s does not belong to %s", region.Name, provider.Provider)
+	}
+	input.ManagerId = provider.Id
+	err = validateCloudCommitment(input.CommitmentType, input.InstanceType, input.InstanceCount, input.HourlyCommitment, input.StartAt, input.EndAt)
+	if err != nil {
+		return input, err
+	}
+	if input.Utilization == nil {
+		utilization := -1.0
+		input.Utilization = &utilization
+	}
+	input.Status = getCloudCommitmentStatus(input.StartAt, input.EndAt, time.Now())
+	input.StatusInfrasResourceBaseCreateInput, err = manager.SStatusInfrasResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StatusInfrasResourceBaseCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusInfrasResourceBaseManager.ValidateCreateData")
+	}
+	return input, nil
+}
+
+func (self *SCloudCommitment) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.CloudCommitmentUpdateInput) (api.CloudCommitmentUpdateInput, error) {
+	var err error
+	instanceCount, hourlyCommitment, startAt, endAt := self.InstanceCount, self.HourlyCommitment, self.StartAt, self.EndAt
+	if input.InstanceCount != nil {
+		instanceCount = *input.InstanceCount
+	}
+	if input.HourlyCommitment != nil {
+		hourlyCommitment = *input.HourlyCommitment
+	}
+	if input.StartAt != nil {
+		startAt = *input.StartAt
+	}
+	if input.EndAt != nil {
+		endAt = *input.EndAt
+	}
+	err = validateCloudCommitment(self.CommitmentType, self.InstanceType, instanceCount, hourlyCommitment, startAt, endAt)
+	if err != nil {
+		return input, err
+	}
+	input.StatusInfrasResourceBaseUpdateInput, err = self.SStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.StatusInfrasResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusInfrasResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+func (self *SCloudCommitment) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SStatusInfrasResourceBase.PostUpdate(ctx, userCred, query, data)
+	status := getCloudCommitmentStatus(self.StartAt, self.EndAt, time.Now())
+	if status != self.Status {
+		self.SetStatus(userCred, status, "")
+	}
+}
+
+func (manager *SCloudCommitmentManager) purgeAll(ctx context.Context, userCred mcclient.TokenCredential, providerId string) error {
+	_, err := sqlchemy.GetDB().Exec(
+		fmt.Sprintf("delete from %s where manager_id = ?", manager.TableSpec().Name()),
+		providerId,
+	)
+	return err
+}
+
+// 容量承诺列表
+func (manager *SCloudCommitmentManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.CloudCommitmentListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStatusInfrasResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusInfrasResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SExternalizedResourceBaseManager.ListItemFilter(ctx, q, userCred, input.ExternalizedResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SExternalizedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SManagedResourceBaseManager.ListItemFilter(ctx, q, userCred, input.ManagedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SCloudregionResourceBaseManager.ListItemFilter(ctx, q, userCred, input.RegionalFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.ListItemFilter")
+	}
+	if len(input.CommitmentType) > 0 {
+		q = q.In("commitment_type", input.CommitmentType)
+	}
+	if len(input.InstanceType) > 0 {
+		q = q.In("instance_type", input.InstanceType)
+	}
+	if input.ExpireWithinDays > 0 {
+		q = q.LE("end_at", time.Now().AddDate(0, 0, input.ExpireWithinDays))
+	}
+	return q, nil
+}
+
+func (manager *SCloudCommitmentManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.CloudCommitmentListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStatusInfrasResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.StatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusInfrasResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SManagedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.ManagedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SCloudregionResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.RegionalFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SCloudCommitmentManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStatusInfrasResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	q, err = manager.SManagedResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	q, err = manager.SCloudregionResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SCloudCommitmentManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusInfrasResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusInfrasResourceBaseManager.ListItemExportKeys")
+	}
+	if keys.ContainsAny(manager.SManagedResourceBaseManager.GetExportKeys()...) {
+		q, err = manager.SManagedResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+		if err != nil {
+			return nil, errors.Wrap(err, "SManagedResourceBaseManager.ListItemExportKeys")
+		}
+	}
+	if keys.ContainsAny(manager.SCloudregionResourceBaseManager.GetExportKeys()...) {
+		q, err = manager.SCloudregionResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+		if err != nil {
+			return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.ListItemExportKeys")
+		}
+	}
+	return q, nil
+}
+
+func (manager *SCloudCommitmentManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.CloudCommitmentDetails {
+	rows := make([]api.CloudCommitmentDetails, len(objs))
+
+	stdRows := manager.SStatusInfrasResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	managerRows := manager.SManagedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	regionRows := manager.SCloudregionResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	now := time.Now()
+	for i := range rows {
+		rows[i] = api.CloudCommitmentDetails{
+			StatusInfrasResourceBaseDetails: stdRows[i],
+			ManagedResourceInfo:             managerRows[i],
+			CloudregionResourceInfo:         regionRows[i],
+		}
+		commitment := objs[i].(*SCloudCommitment)
+		if !commitment.EndAt.IsZero() && commitment.EndAt.After(now) {
+			rows[i].RemainingDays = int(commitment.EndAt.Sub(now).Hours() / 24)
+		}
+	}
+	return rows
+}
+
+type sCommitmentCoverageKey struct {
+	ManagerId     string
+	CloudregionId string
+	InstanceType  string
+}
+
+type sCommitmentCoverageCount struct {
+	sCommitmentCoverageKey
+	Count int
+}
+
+// 按 订阅+区域+规格 计算预留实例对按量实例的覆盖率及利用率
+func buildCommitmentCoverage(running, reserved []sCommitmentCoverageCount) []api.CloudCommitmentCoverageItem {
+	items := map[sCommitmentCoverageKey]*api.CloudCommitmentCoverageItem{}
+	getItem := func(key sCommitmentCoverageKey) *api.CloudCommitmentCoverageItem {
+		item, ok := items[key]
+		if !ok {
+			item = &api.CloudCommitmentCoverageItem{
+				ManagerId:     key.ManagerId,
+				CloudregionId: key.CloudregionId,
+				InstanceType:  key.InstanceType,
+			}
+			items[key] = item
+		}
+		return item
+	}
+	for _, cnt := range running {
+		getItem(cnt.sCommitmentCoverageKey).RunningCount += cnt.Count
+	}
+	for _, cnt := range reserved {
+		getItem(cnt.sCommitmentCoverageKey).ReservedCount += cnt.Count
+	}
+	ret := []api.CloudCommitmentCoverageItem{}
+	for _, item := range items {
+		item.CoveredCount = item.RunningCount
+		if item.ReservedCount < item.CoveredCount {
+			item.CoveredCount = item.ReservedCount
+		}
+		if item.RunningCount > 0 {
+			item.CoveragePercent = float64(item.CoveredCount) * 100 / float64(item.RunningCount)
+		}
+		if item.ReservedCount > 0 {
+			item.UtilizationPercent = float64(item.CoveredCount) * 100 / float64(item.ReservedCount)
+		}
+		ret = append(ret, *item)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].ManagerId != ret[j].ManagerId {
+			return ret[i].ManagerId < ret[j].ManagerId
+		}
+		if ret[i].CloudregionId != ret[j].CloudregionId {
+			return ret[i].CloudregionId < ret[j].CloudregionId
+		}
+		return ret[i].InstanceType < ret[j].InstanceType
+	})
+	return ret
+}
+
+// 未覆盖实例较多时建议购买, 预留闲置时建议调整
+func buildCommitmentRecommendations(items []api.CloudCommitmentCoverageItem, minUncovered int) []api.CloudCommitmentRecommendation {
+	ret := []api.CloudCommitmentRecommendation{}
+	for _, item := range items {
+		uncovered := item.RunningCount - item.CoveredCount
+		if minUncovered > 0 && uncovered >= minUncovered {
+			ret = append(ret, api.CloudCommitmentRecommendation{
+				Action:        api.CLOUD_COMMITMENT_RECOMMEND_PURCHASE,
+				ManagerId:     item.ManagerId,
+				CloudregionId: item.CloudregionId,
+				InstanceType:  item.InstanceType,
+				Count:         uncovered,
+				Reason:        fmt.Sprintf("%d of %d running %s instances are not covered by reservations", uncovered, item.RunningCount, item.InstanceType),
+			})
+		}
+		idle := item.ReservedCount - item.CoveredCount
+		if idle > 0 && item.UtilizationPercent < api.CLOUD_COMMITMENT_LOW_UTILIZATION_PERCENT {
+			ret = append(ret, api.CloudCommitmentRecommendation{
+				Action:        api.CLOUD_COMMITMENT_RECOMMEND_REDUCE,
+				ManagerId:     item.ManagerId,
+				CloudregionId: item.CloudregionId,
+				InstanceType:  item.InstanceType,
+				Count:         idle,
+				Reason:        fmt.Sprintf("%d of %d reserved %s instances are idle, consider modifying or selling them", idle, item.ReservedCount, item.InstanceType),
+			})
+		}
+	}
+	return ret
+}
+
+// 预留实例/节省计划覆盖率, 利用率及购买建议
+func (manager *SCloudCommitmentManager) GetPropertyCoverage(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.CloudCommitmentCoverageOutput, error) {
+	q := manager.Query()
+	q, err := db.ListItemQueryFilters(manager, ctx, q, userCred, query.(*jsonutils.JSONDict).Copy(), policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	commitments := []SCloudCommitment{}
+	err = db.FetchModelObjects(manager, q, &commitments)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch commitments")
+	}
+	now := time.Now()
+	actives := []SCloudCommitment{}
+	for i := range commitments {
+		// 登记的承诺随时间生效或到期, 统计前刷新状态
+		status := getCloudCommitmentStatus(commitments[i].StartAt, commitments[i].EndAt, now)
+		if status != commitments[i].Status {
+			commitments[i].SetStatus(userCred, status, "")
+		}
+		if status == api.CLOUD_COMMITMENT_STATUS_ACTIVE {
+			actives = append(actives, commitments[i])
+		}
+	}
+	commitments = actives
+
+	ret := &api.CloudCommitmentCoverageOutput{
+		Items:           []api.CloudCommitmentCoverageItem{},
+		SavingsPlans:    []api.CloudCommitmentSavingsPlan{},
+		Recommendations: []api.CloudCommitmentRecommendation{},
+	}
+	reserved := []sCommitmentCoverageCount{}
+	renewBefore := time.Now().AddDate(0, 0, api.CLOUD_COMMITMENT_RENEW_DAYS)
+	renews := []api.CloudCommitmentRecommendation{}
+	for i := range commitments {
+		commitment := commitments[i]
+		if commitment.CommitmentType == api.CLOUD_COMMITMENT_TYPE_SAVINGS_PLAN {
+			ret.SavingsPlans = append(ret.SavingsPlans, api.CloudCommitmentSavingsPlan{
+				Id:               commitment.Id,
+				Name:             commitment.Name,
+				ManagerId:        commitment.ManagerId,
+				HourlyCommitment: commitment.HourlyCommitment,
+				Currency:         commitment.Currency,
+				Utilization:      commitment.Utilization,
+				EndAt:            commitment.EndAt,
+			})
+		} else if len(commitment.InstanceType) > 0 {
+			reserved = append(reserved, sCommitmentCoverageCount{
+				sCommitmentCoverageKey: sCommitmentCoverageKey{
+					ManagerId:     commitment.ManagerId,
+					CloudregionId: commitment.CloudregionId,
+					InstanceType:  commitment.InstanceType,
+				},
+				Count: commitment.InstanceCount,
+			})
+		}
+		if !commitment.EndAt.IsZero() && commitment.EndAt.Before(renewBefore) {
+			renews = append(renews, api.CloudCommitmentRecommendation{
+				Action:        api.CLOUD_COMMITMENT_RECOMMEND_RENEW,
+				ManagerId:     commitment.ManagerId,
+				CloudregionId: commitment.CloudregionId,
+				InstanceType:  commitment.InstanceType,
+				Count:         commitment.InstanceCount,
+				CommitmentId:  commitment.Id,
+				Reason:        fmt.Sprintf("commitment %s expires at %s", commitment.Name, commitment.EndAt.Format(time.RFC3339)),
+			})
+		}
+	}
+
+	running, err := manager.getRunningOnDemandCounts(ctx, userCred, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "getRunningOnDemandCounts")
+	}
+	ret.Items = buildCommitmentCoverage(running, reserved)
+	ret.Recommendations = append(buildCommitmentRecommendations(ret.Items, options.Options.CommitmentRecommendMinUncovered), renews...)
+
+	managerIds, regionIds := []string{}, []string{}
+	for _, item := range ret.Items {
+		managerIds = append(managerIds, item.ManagerId)
+		regionIds = append(regionIds, item.CloudregionId)
+		ret.TotalRunning += item.RunningCount
+		ret.TotalReserved += item.ReservedCount
+		ret.TotalCovered += item.CoveredCount
+	}
+	if ret.TotalRunning > 0 {
+		ret.CoveragePercent = float64(ret.TotalCovered) * 100 / float64(ret.TotalRunning)
+	}
+	if ret.TotalReserved > 0 {
+		ret.UtilizationPercent = float64(ret.TotalCovered) * 100 / float64(ret.TotalReserved)
+	}
+	providers := map[string]SCloudprovider{}
+	err = db.FetchModelObjectsByIds(CloudproviderManager, "id", managerIds, &providers)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch cloudproviders")
+	}
+	regions := map[string]SCloudregion{}
+	err = db.FetchModelObjectsByIds(CloudregionManager, "id", regionIds, &regions)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch regions")
+	}
+	for i := range ret.Items {
+		ret.Items[i].Manager = providers[ret.Items[i].ManagerId].Name
+		ret.Items[i].Cloudregion = regions[ret.Items[i].CloudregionId].Name
+	}
+	return ret, nil
+}
+
+// 运行中的按量付费公有云实例, 按 订阅+区域+规格 汇总
+func (manager *SCloudCommitmentManager) getRunningOnDemandCounts(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) ([]sCommitmentCoverageCount, error) {
+	q := GuestManager.Query()
+	q, err := db.ListItemQueryFilters(GuestManager, ctx, q, userCred, query.(*jsonutils.JSONDict).Copy(), policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	q = q.Equals("status", api.VM_RUNNING).Equals("billing_type", billing_api.BILLING_TYPE_POSTPAID).IsNotEmpty("instance_type")
+	guests := q.SubQuery()
+	hosts := HostManager.Query().IsNotEmpty("manager_id").SubQuery()
+	zones := ZoneManager.Query().SubQuery()
+	countQ := guests.Query(
+		hosts.Field("manager_id"),
+		zones.Field("cloudregion_id"),
+		guests.Field("instance_type"),
+		sqlchemy.COUNT("count"),
+	)
+	countQ = countQ.Join(hosts, sqlchemy.Equals(guests.Field("host_id"), hosts.Field("id")))
+	countQ = countQ.Join(zones, sqlchemy.Equals(hosts.Field("zone_id"), zones.Field("id")))
+	countQ = countQ.GroupBy(hosts.Field("manager_id"), zones.Field("cloudregion_id"), guests.Field("instance_type"))
+	rows := []struct {
+		ManagerId     string
+		CloudregionId string
+		InstanceType  string
+		Count         int
+	}{}
+	err = countQ.All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "query running guests")
+	}
+	ret := make([]sCommitmentCoverageCount, len(rows))
+	for i := range rows {
+		ret[i] = sCommitmentCoverageCount{
+			sCommitmentCoverageKey: sCommitmentCoverageKey{
+				ManagerId:     rows[i].ManagerId,
+				CloudregionId: rows[i].CloudregionId,
+				InstanceType:  rows[i].InstanceType,
+			},
+			Count: rows[i].Count,
+		}
+	}
+	return ret, nil
+}
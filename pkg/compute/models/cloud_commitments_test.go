@@ -0,0 +1,87 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestBuildCommitmentCoverage(t *testing.T) {
+	newCount := func(instanceType string, count int) sCommitmentCoverageCount {
+		return sCommitmentCoverageCount{
+			sCommitmentCoverageKey: sCommitmentCoverageKey{ManagerId: "m1", CloudregionId: "r1", InstanceType: instanceType},
+			Count:                  count,
+		}
+	}
+	running := []sCommitmentCoverageCount{newCount("c5.large", 10), newCount("m5.large", 2)}
+	reserved := []sCommitmentCoverageCount{newCount("c5.large", 4), newCount("c5.large", 2), newCount("m5.large", 5), newCount("r5.large", 1)}
+	items := buildCommitmentCoverage(running, reserved)
+	want := map[string]api.CloudCommitmentCoverageItem{
+		"c5.large": {RunningCount: 10, ReservedCount: 6, CoveredCount: 6, CoveragePercent: 60, UtilizationPercent: 100},
+		"m5.large": {RunningCount: 2, ReservedCount: 5, CoveredCount: 2, CoveragePercent: 100, UtilizationPercent: 40},
+		"r5.large": {RunningCount: 0, ReservedCount: 1, CoveredCount: 0, CoveragePercent: 0, UtilizationPercent: 0},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("want %d items, got %d", len(want), len(items))
+	}
+	for _, item := range items {
+		w := want[item.InstanceType]
+		if item.RunningCount != w.RunningCount || item.ReservedCount != w.ReservedCount || item.CoveredCount != w.CoveredCount ||
+			item.CoveragePercent != w.CoveragePercent || item.UtilizationPercent != w.UtilizationPercent {
+			t.Errorf("%s: want %+v, got %+v", item.InstanceType, w, item)
+		}
+	}
+
+	recommends := buildCommitmentRecommendations(items, 2)
+	actions := map[string]int{}
+	for _, r := range recommends {
+		actions[r.Action+"/"+r.InstanceType] = r.Count
+	}
+	wantActions := map[string]int{
+		api.CLOUD_COMMITMENT_RECOMMEND_PURCHASE + "/c5.large": 4,
+		api.CLOUD_COMMITMENT_RECOMMEND_REDUCE + "/m5.large":   3,
+		api.CLOUD_COMMITMENT_RECOMMEND_REDUCE + "/r5.large":   1,
+	}
+	if len(actions) != len(wantActions) {
+		t.Fatalf("want recommendations %v, got %v", wantActions, actions)
+	}
+	for k, v := range wantActions {
+		if actions[k] != v {
+			t.Errorf("%s: want %d, got %d", k, v, actions[k])
+		}
+	}
+}
+
+func TestGetCloudCommitmentStatus(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		startAt time.Time
+		endAt   time.Time
+		want    string
+	}{
+		{want: api.CLOUD_COMMITMENT_STATUS_ACTIVE},
+		{startAt: now.AddDate(0, 0, 1), endAt: now.AddDate(1, 0, 0), want: api.CLOUD_COMMITMENT_STATUS_PENDING},
+		{startAt: now.AddDate(-1, 0, 0), endAt: now.AddDate(1, 0, 0), want: api.CLOUD_COMMITMENT_STATUS_ACTIVE},
+		{startAt: now.AddDate(-1, 0, 0), endAt: now, want: api.CLOUD_COMMITMENT_STATUS_EXPIRED},
+	}
+	for _, c := range cases {
+		if got := getCloudCommitmentStatus(c.startAt, c.endAt, now); got != c.want {
+			t.Errorf("%s - %s: want %s, got %s", c.startAt, c.endAt, c.want, got)
+		}
+	}
+}
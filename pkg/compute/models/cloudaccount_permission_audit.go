@@ -0,0 +1,269 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type sPermissionProbe struct {
+	action string
+	probe  func(provider cloudprovider.ICloudProvider, region cloudprovider.ICloudRegion) error
+}
+
+func regionProbe(action string, probe func(region cloudprovider.ICloudRegion) error) sPermissionProbe {
+	return sPermissionProbe{
+		action: action,
+		probe: func(provider cloudprovider.ICloudProvider, region cloudprovider.ICloudRegion) error {
+			if region == nil {
+				return errors.Wrap(cloudprovider.ErrNotFound, "no available region")
+			}
+			return probe(region)
+		},
+	}
+}
+
+// 各能力需要的最小只读权限, 通过列表操作校验
+var cloudaccountPermissionProbes = map[string][]sPermissionProbe{
+	cloudprovider.CLOUD_CAPABILITY_COMPUTE: {
+		regionProbe("ListZones", func(region cloudprovider.ICloudRegion) error {
+			_, err := region.GetIZones()
+			return err
+		}),
+	},
+	cloudprovider.CLOUD_CAPABILITY_NETWORK: {
+		regionProbe("ListVpcs", func(region cloudprovider.ICloudRegion) error {
+			_, err := region.GetIVpcs()
+			return err
+		}),
+	},
+	cloudprovider.CLOUD_CAPABILITY_EIP: {
+		regionProbe("ListEips", func(region cloudprovider.ICloudRegion) error {
+			_, err := region.GetIEips()
+			return err
+		}),
+	},
+	cloudprovider.CLOUD_CAPABILITY_LOADBALANCER: {
+		regionProbe("ListLoadbalancers", func(region cloudprovider.ICloudRegion) error {
+			_, err := region.GetILoadBalancers()
+			return err
+		}),
+	},
+	cloudprovider.CLOUD_CAPABILITY_OBJECTSTORE: {
+		regionProbe("ListBuckets", func(region cloudprovider.ICloudRegion) error {
+			_, err := region.GetIBuckets()
+			return err
+		}),
+	},
+	cloudprovider.CLOUD_CAPABILITY_RDS: {
+		regionProbe("ListDBInstances", func(region cloudprovider.ICloudRegion) error {
+			_, err := region.GetIDBInstances()
+			return err
+		}),
+	},
+	cloudprovider.CLOUD_CAPABILITY_CACHE: {
+		regionProbe("ListElasticcaches", func(region cloudprovider.ICloudRegion) error {
+			_, err := region.GetIElasticcaches()
+			return err
+		}),
+	},
+	cloudprovider.CLOUD_CAPABILITY_NAS: {
+		regionProbe("ListFileSystems", func(region cloudprovider.ICloudRegion) error {
+			_, err := region.GetICloudFileSystems()
+			return err
+		}),
+	},
+	cloudprovider.CLOUD_CAPABILITY_PROJECT: {
+		{
+			action: "ListProjects",
+			probe: func(provider cloudprovider.ICloudProvider, region cloudprovider.ICloudRegion) error {
+				_, err := provider.GetIProjects()
+				return err
+			},
+		},
+	},
+	cloudprovider.CLOUD_CAPABILITY_DNSZONE: {
+		{
+			action: "ListDnsZones",
+			probe: func(provider cloudprovider.ICloudProvider, region cloudprovider.ICloudRegion) error {
+				_, err := provider.GetICloudDnsZones()
+				return err
+			},
+		},
+	},
+	cloudprovider.CLOUD_CAPABILITY_CLOUDID: {
+		{
+			action: "ListUsers",
+			probe: func(provider cloudprovider.ICloudProvider, region cloudprovider.ICloudRegion) error {
+				_, err := provider.GetICloudusers()
+				return err
+			},
+		},
+	},
+}
+
+func isPermissionError(err error) bool {
+	switch errors.Cause(err) {
+	case cloudprovider.ErrForbidden, cloudprovider.ErrNoPermission, cloudprovider.ErrUnauthorized:
+		return true
+	}
+	return false
+}
+
+func getPermissionAuditStatus(caps []api.SCapabilityPermissionAudit) string {
+	for _, capa := range caps {
+		if len(capa.Missing) > 0 {
+			return api.CLOUD_ACCOUNT_PERMISSION_AUDIT_MISSING
+		}
+	}
+	return api.CLOUD_ACCOUNT_PERMISSION_AUDIT_OK
+}
+
+func (self *SCloudaccount) getPermissionAuditRegion(provider cloudprovider.ICloudProvider) cloudprovider.ICloudRegion {
+	defaultRegion, _ := jsonutils.Marshal(self.Options).GetString("default_region")
+	if len(defaultRegion) > 0 {
+		region, err := provider.GetIRegionById(defaultRegion)
+		if err == nil {
+			return region
+		}
+	}
+	regions := provider.GetIRegions()
+	if len(regions) > 0 {
+		return regions[0]
+	}
+	return nil
+}
+
+// 逐个能力校验凭据是否仍具备最小只读权限, 结果保存到permission_audit字段
+func (self *SCloudaccount) AuditPermissions(ctx context.Context) (*api.SAccountPermissionAudit, error) {
+	audit := &api.SAccountPermissionAudit{
+		AuditAt:      time.Now().UTC(),
+		Capabilities: []api.SCapabilityPermissionAudit{},
+	}
+
+	lock := sync.Mutex{}
+	lakes := []string{}
+	updatePermission := self.UpdatePermission(ctx)
+	provider, err := self.getProviderWithUpdatePermission(ctx, func(service, permission string) {
+		lock.Lock()
+		lakes = append(lakes, fmt.Sprintf("%s:%s", service, permission))
+		lock.Unlock()
+		updatePermission(service, permission)
+	})
+	if err != nil {
+		audit.Status = api.CLOUD_ACCOUNT_PERMISSION_AUDIT_FAILED
+		audit.Reason = err.Error()
+		return audit, self.savePermissionAudit(audit)
+	}
+
+	region := self.getPermissionAuditRegion(provider)
+	for _, capa := range provider.GetCapabilities() {
+		capa = strings.TrimSuffix(capa, cloudprovider.READ_ONLY_SUFFIX)
+		probes, ok := cloudaccountPermissionProbes[capa]
+		if !ok {
+			continue
+		}
+		result := api.SCapabilityPermissionAudit{
+			Capability: capa,
+			Actions:    []string{},
+			Missing:    []string{},
+		}
+		errs := []error{}
+		for _, probe := range probes {
+			result.Actions = append(result.Actions, probe.action)
+			lock.Lock()
+			lakes = []string{}
+			lock.Unlock()
+			err := probe.probe(provider, region)
+			lock.Lock()
+			missing := lakes
+			lock.Unlock()
+			if err != nil && errors.Cause(err) != cloudprovider.ErrNotImplemented && errors.Cause(err) != cloudprovider.ErrNotSupported {
+				if isPermissionError(err) || len(missing) > 0 {
+					missing = append(missing, probe.action)
+				} else {
+					errs = append(errs, errors.Wrap(err, probe.action))
+				}
+			}
+			for _, perm := range missing {
+				if !utils.IsInStringArray(perm, result.Missing) {
+					result.Missing = append(result.Missing, perm)
+				}
+			}
+		}
+		if len(errs) > 0 {
+			result.Error = errors.NewAggregate(errs).Error()
+		}
+		audit.Capabilities = append(audit.Capabilities, result)
+	}
+	audit.Status = getPermissionAuditStatus(audit.Capabilities)
+	return audit, self.savePermissionAudit(audit)
+}
+
+func (self *SCloudaccount) savePermissionAudit(audit *api.SAccountPermissionAudit) error {
+	_, err := db.Update(self, func() error {
+		self.PermissionAudit = audit
+		return nil
+	})
+	return err
+}
+
+// 定时审计已启用云账号的凭据权限
+func (manager *SCloudaccountManager) AuditCloudaccountPermissions(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	accounts := []SCloudaccount{}
+	q := manager.Query().IsTrue("enabled")
+	err := db.FetchModelObjects(manager, q, &accounts)
+	if err != nil {
+		log.Errorf("fetch cloudaccounts for permission audit error: %v", err)
+		return
+	}
+	for i := range accounts {
+		err = accounts[i].StartPermissionAuditTask(ctx, userCred, "")
+		if err != nil {
+			log.Errorf("start permission audit task for cloudaccount %s error: %v", accounts[i].Name, err)
+		}
+	}
+}
+
+func (self *SCloudaccount) PerformAuditPermissions(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !self.GetEnabled() {
+		return nil, httperrors.NewInvalidStatusError("Account disabled")
+	}
+	return nil, self.StartPermissionAuditTask(ctx, userCred, "")
+}
+
+func (self *SCloudaccount) StartPermissionAuditTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "CloudaccountPermissionAuditTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	return task.ScheduleRun(nil)
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestIsPermissionError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.Wrap(cloudprovider.ErrForbidden, "ListVpcs"), true},
+		{errors.Wrap(cloudprovider.ErrNoPermission, "ListBuckets"), true},
+		{cloudprovider.ErrUnauthorized, true},
+		{errors.Wrap(cloudprovider.ErrNotFound, "region"), false},
+		{errors.Error("timeout"), false},
+	}
+	for _, c := range cases {
+		if got := isPermissionError(c.err); got != c.want {
+			t.Errorf("isPermissionError(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestGetPermissionAuditStatus(t *testing.T) {
+	cases := []struct {
+		caps []api.SCapabilityPermissionAudit
+		want string
+	}{
+		{[]api.SCapabilityPermissionAudit{}, api.CLOUD_ACCOUNT_PERMISSION_AUDIT_OK},
+		{[]api.SCapabilityPermissionAudit{{Capability: "compute", Error: "timeout"}}, api.CLOUD_ACCOUNT_PERMISSION_AUDIT_OK},
+		{[]api.SCapabilityPermissionAudit{{Capability: "compute"}, {Capability: "network", Missing: []string{"vpc:DescribeVpcs"}}}, api.CLOUD_ACCOUNT_PERMISSION_AUDIT_MISSING},
+	}
+	for _, c := range cases {
+		if got := getPermissionAuditStatus(c.caps); got != c.want {
+			t.Errorf("getPermissionAuditStatus(%v) = %s, want %s", c.caps, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/secretstore"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// 配置了外部密钥存储时保存到外部存储并返回引用, 否则以资源Id为密钥AES加密
+func encryptCloudSecret(ctx context.Context, kind, id, secret string) (string, error) {
+	if secretstore.GetDefaultStore() == nil {
+		return utils.EncryptAESBase64(id, secret)
+	}
+	return secretstore.Store(ctx, fmt.Sprintf("%s/%s", kind, id), secret)
+}
+
+func decryptCloudSecret(ctx context.Context, id, secret string) (string, error) {
+	if secretstore.IsRef(secret) {
+		return secretstore.Resolve(ctx, secret)
+	}
+	return utils.DescryptAESBase64(id, secret)
+}
+
+// 对外接口中将外部存储引用替换为以资源Id为密钥AES加密的密钥, 其他服务仍按原格式解密
+func exportCloudSecret(ctx context.Context, id, secret string) string {
+	if !secretstore.IsRef(secret) {
+		return secret
+	}
+	passwd, err := secretstore.Resolve(ctx, secret)
+	if err != nil {
+		log.Errorf("resolve secret %s error: %v", secret, err)
+		return ""
+	}
+	sec, err := utils.EncryptAESBase64(id, passwd)
+	if err != nil {
+		log.Errorf("encrypt secret of %s error: %v", id, err)
+		return ""
+	}
+	return sec
+}
+
+// 子订阅与云账号共用同一引用, 仍被引用时不删除
+func isCloudSecretInUse(secret string) bool {
+	for _, man := range []db.IModelManager{CloudaccountManager, CloudproviderManager} {
+		cnt, err := man.Query().Equals("secret", secret).CountWithError()
+		if err != nil || cnt > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func removeCloudSecret(ctx context.Context, secret string) {
+	if !secretstore.IsRef(secret) || isCloudSecretInUse(secret) {
+		return
+	}
+	err := secretstore.Remove(ctx, secret)
+	if err != nil {
+		log.Errorf("remove secret %s error: %v", secret, err)
+	}
+}
+
+func getCloudSecretStoreNames(man db.IModelManager) ([]string, error) {
+	q := man.Query("secret").Startswith("secret", secretstore.REF_PREFIX).Distinct()
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, errors.Wrapf(err, "q.Rows")
+	}
+	defer rows.Close()
+	names := []string{}
+	for rows.Next() {
+		var secret string
+		err = rows.Scan(&secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rows.Scan")
+		}
+		name, _, err := secretstore.ParseRef(secret)
+		if err != nil {
+			return nil, err
+		}
+		if !utils.IsInStringArray(name, names) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// 仍有密钥引用的外部存储必须保持配置, 否则拒绝启动, 需先迁移密钥再移除旧存储的配置
+func checkCloudSecretStores() error {
+	for _, man := range []db.IModelManager{CloudaccountManager, CloudproviderManager} {
+		names, err := getCloudSecretStoreNames(man)
+		if err != nil {
+			return errors.Wrapf(err, "get %s secret stores", man.Keyword())
+		}
+		for _, name := range names {
+			_, err := secretstore.GetStore(name)
+			if err != nil {
+				return errors.Wrapf(err, "%s secrets still reference store %s, keep it configured and migrate the secrets first", man.Keyword(), name)
+			}
+		}
+	}
+	return nil
+}
+
+// 在数据库AES加密与外部密钥存储之间迁移单个密钥, 返回是否发生迁移
+func migrateCloudSecret(ctx context.Context, model db.IModel, kind, id string, secret *string, toLocal bool) (bool, error) {
+	if len(*secret) == 0 {
+		return false, nil
+	}
+	isRef := secretstore.IsRef(*secret)
+	if toLocal && !isRef {
+		return false, nil
+	}
+	if !toLocal && isRef {
+		name, _, err := secretstore.ParseRef(*secret)
+		if err != nil {
+			return false, err
+		}
+		if name == secretstore.GetDefaultStore().GetName() {
+			return false, nil
+		}
+	}
+	passwd, err := decryptCloudSecret(ctx, id, *secret)
+	if err != nil {
+		return false, errors.Wrapf(err, "decrypt")
+	}
+	var sec string
+	if toLocal {
+		sec, err = utils.EncryptAESBase64(id, passwd)
+	} else {
+		sec, err = secretstore.Store(ctx, fmt.Sprintf("%s/%s", kind, id), passwd)
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "encrypt")
+	}
+	origin := *secret
+	_, err = db.Update(model, func() error {
+		*secret = sec
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "db.Update")
+	}
+	removeCloudSecret(ctx, origin)
+	return true, nil
+}
+
+// 将云账号及子订阅密钥迁移到当前配置的外部密钥存储, 或迁回数据库AES加密存储
+func (manager *SCloudaccountManager) PerformMigrateSecrets(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.CloudaccountMigrateSecretsInput) (*api.CloudaccountMigrateSecretsOutput, error) {
+	if !userCred.HasSystemAdminPrivilege() {
+		return nil, httperrors.NewForbiddenError("not enough privilege")
+	}
+	if !input.ToLocal && secretstore.GetDefaultStore() == nil {
+		return nil, httperrors.NewInputParameterError("no external secret store configured")
+	}
+	ret := &api.CloudaccountMigrateSecretsOutput{Failed: []string{}}
+
+	accounts := []SCloudaccount{}
+	err := db.FetchModelObjects(manager, manager.Query(), &accounts)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "FetchModelObjects"))
+	}
+	for i := range accounts {
+		account := &accounts[i]
+		migrated, err := migrateCloudSecret(ctx, account, manager.KeywordPlural(), account.Id, &account.Secret, input.ToLocal)
+		if err != nil {
+			log.Errorf("migrate secret of cloudaccount %s error: %v", account.Name, err)
+			ret.Failed = append(ret.Failed, fmt.Sprintf("cloudaccount %s: %v", account.Name, err))
+		} else if migrated {
+			ret.Cloudaccounts++
+		} else {
+			ret.Skipped++
+		}
+	}
+
+	providers := []SCloudprovider{}
+	err = db.FetchModelObjects(CloudproviderManager, CloudproviderManager.Query(), &providers)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "FetchModelObjects"))
+	}
+	for i := range providers {
+		provider := &providers[i]
+		migrated, err := migrateCloudSecret(ctx, provider, CloudproviderManager.KeywordPlural(), provider.Id, &provider.Secret, input.ToLocal)
+		if err != nil {
+			log.Errorf("migrate secret of cloudprovider %s error: %v", provider.Name, err)
+			ret.Failed = append(ret.Failed, fmt.Sprintf("cloudprovider %s: %v", provider.Name, err))
+		} else if migrated {
+			ret.Cloudproviders++
+		} else {
+			ret.Skipped++
+		}
+	}
+	return ret, nil
+}
@@ -45,6 +45,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/proxy"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
 	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
@@ -162,6 +163,9 @@ type SCloudaccount struct {
 
 	// 缺失的权限，云账号操作资源时自动更新
 	LakeOfPermissions *api.SAccountPermissions `length:"medium" get:"user" list:"user"`
+
+	// 最近一次权限审计结果
+	PermissionAudit *api.SAccountPermissionAudit `length:"medium" get:"user" list:"user"`
 }
 
 func (self *SCloudaccount) GetCloudproviders() []SCloudprovider {
@@ -594,7 +598,7 @@ func (ca *SCloudaccount) PerformSyncVMwareNetwork(ctx context.Context, userCred
 }
 
 func (self *SCloudaccount) savePassword(secret string) error {
-	sec, err := utils.EncryptAESBase64(self.Id, secret)
+	sec, err := encryptCloudSecret(context.Background(), CloudaccountManager.KeywordPlural(), self.Id, secret)
 	if err != nil {
 		return err
 	}
@@ -607,7 +611,7 @@ func (self *SCloudaccount) savePassword(secret string) error {
 }
 
 func (self *SCloudaccount) getPassword() (string, error) {
-	return utils.DescryptAESBase64(self.Id, self.Secret)
+	return decryptCloudSecret(context.Background(), self.Id, self.Secret)
 }
 
 func (self *SCloudaccount) PerformSync(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SyncRangeInput) (jsonutils.JSONObject, error) {
@@ -936,6 +940,10 @@ func (self *SCloudaccount) UpdatePermission(ctx context.Context) func(string, st
 }
 
 func (self *SCloudaccount) getProviderInternal(ctx context.Context) (cloudprovider.ICloudProvider, error) {
+	return self.getProviderWithUpdatePermission(ctx, self.UpdatePermission(ctx))
+}
+
+func (self *SCloudaccount) getProviderWithUpdatePermission(ctx context.Context, updatePermission func(string, string)) (cloudprovider.ICloudProvider, error) {
 	secret, err := self.getPassword()
 	if err != nil {
 		return nil, fmt.Errorf("Invalid password %s", err)
@@ -957,7 +965,7 @@ func (self *SCloudaccount) getProviderInternal(ctx context.Context) (cloudprovid
 		ReadOnly:               self.ReadOnly,
 		AliyunResourceGroupIds: options.Options.AliyunResourceGroups,
 
-		UpdatePermission: self.UpdatePermission(ctx),
+		UpdatePermission: updatePermission,
 	})
 }
 
@@ -1279,6 +1287,12 @@ func (manager *SCloudaccountManager) FetchCustomizeColumns(
 			return rows
 		}
 	}
+	if len(fields) == 0 || fields.Contains("secret") {
+		for i := range objs {
+			account := objs[i].(*SCloudaccount)
+			account.Secret = exportCloudSecret(ctx, account.Id, account.Secret)
+		}
+	}
 	for i := range rows {
 		account := objs[i].(*SCloudaccount)
 		detail := api.CloudaccountDetail{
@@ -1507,6 +1521,10 @@ func (manager *SCloudaccountManager) InitializeData() error {
 	if err != nil {
 		return errors.Wrap(err, "initializePublicScope")
 	}
+	err = checkCloudSecretStores()
+	if err != nil {
+		return errors.Wrap(err, "checkCloudSecretStores")
+	}
 
 	return nil
 }
@@ -1901,6 +1919,7 @@ func (account *SCloudaccount) probeAccountStatus(ctx context.Context, userCred m
 	}
 	iamLoginUrl := manager.GetIamLoginUrl()
 	factory := manager.GetFactory()
+	oldHealthStatus := account.HealthStatus
 	diff, err := db.Update(account, func() error {
 		isPublic := factory.IsPublicCloud()
 		account.IsPublicCloud = tristate.NewFromBool(isPublic)
@@ -1923,6 +1942,10 @@ func (account *SCloudaccount) probeAccountStatus(ctx context.Context, userCred m
 		log.Errorf("Failed to update db %s", err)
 	} else {
 		db.OpsLog.LogSyncUpdate(account, diff, userCred)
+		if account.HealthStatus == api.CLOUD_PROVIDER_HEALTH_ARREARS && oldHealthStatus != api.CLOUD_PROVIDER_HEALTH_ARREARS {
+			reason := fmt.Sprintf("cloudaccount %s is in arrears, balance %.2f", account.Name, account.Balance)
+			ProviderAlertRuleManager.fire(ctx, userCred, api.PROVIDER_ALERT_RULE_TYPE_ARREARS, account.DomainId, account, reason, 0)
+		}
 	}
 
 	return manager.GetSubAccounts()
@@ -2119,10 +2142,23 @@ func (self *SCloudaccount) RealDelete(ctx context.Context, userCred mcclient.Tok
 			return errors.Wrapf(err, "dns zone cache %s delete", caches[i].Id)
 		}
 	}
-	return self.SEnabledStatusInfrasResourceBase.Delete(ctx, userCred)
+	err = self.SEnabledStatusInfrasResourceBase.Delete(ctx, userCred)
+	if err != nil {
+		return err
+	}
+	removeCloudSecret(ctx, self.Secret)
+	return nil
 }
 
+// 删除云账号会删除其全部子订阅, 与删除子订阅使用同一审批策略
 func (self *SCloudaccount) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	approval, err := OperationApprovalManager.requireApproval(ctx, userCred, self.DomainId, api.OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER, CloudaccountManager.Keyword(), []string{self.Id}, nil)
+	if err != nil {
+		return err
+	}
+	if approval != nil {
+		return httperrors.NewNotAcceptableError("deleting cloudaccount %s requires approval, approval request %s is pending", self.Name, approval.Id)
+	}
 	return self.StartCloudaccountDeleteTask(ctx, userCred, "")
 }
 
@@ -2366,6 +2402,141 @@ func (manager *SCloudaccountManager) FilterByOwner(q *sqlchemy.SQuery, owner mcc
 	return q
 }
 
+type sAccountHealthRow struct {
+	Id            string
+	Name          string
+	Provider      string
+	Brand         string
+	Enabled       bool
+	Status        string
+	HealthStatus  string
+	SyncStatus    string
+	ErrorCount    int
+	LastSyncEndAt time.Time
+}
+
+type sProviderHealthRow struct {
+	CloudaccountId string
+	HealthStatus   string
+	ProviderCount  int
+	EnabledCount   int
+	ConnectedCount int
+	LastSyncEndAt  time.Time
+}
+
+type sRegionSyncSuccessRow struct {
+	CloudaccountId    string
+	LastSyncSuccessAt time.Time
+}
+
+// 所有云账号健康状况汇总, 每个账号一行
+func (manager *SCloudaccountManager) GetPropertyHealthSummary(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	q := manager.Query()
+	q, err := db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	sq := q.SubQuery()
+	accounts := []sAccountHealthRow{}
+	err = sq.Query(
+		sq.Field("id"),
+		sq.Field("name"),
+		sq.Field("provider"),
+		sq.Field("brand"),
+		sq.Field("enabled"),
+		sq.Field("status"),
+		sq.Field("health_status"),
+		sq.Field("sync_status"),
+		sq.Field("error_count"),
+		sq.Field("last_sync_end_at"),
+	).Asc(sq.Field("name")).All(&accounts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "query accounts")
+	}
+
+	providers := CloudproviderManager.Query().SubQuery()
+	connected := sqlchemy.NewCase().
+		When(sqlchemy.Equals(providers.Field("status"), api.CLOUD_PROVIDER_CONNECTED), sqlchemy.NewConstField(1)).
+		Else(sqlchemy.NewConstField(0))
+	pq := providers.Query(
+		providers.Field("cloudaccount_id"),
+		providers.Field("health_status"),
+		sqlchemy.COUNT("provider_count"),
+		sqlchemy.SUM("enabled_count", providers.Field("enabled")),
+		sqlchemy.SUM("connected_count", sqlchemy.NewFunction(connected, "")),
+		sqlchemy.MAX("last_sync_end_at", providers.Field("last_sync_end_at")),
+	).Filter(sqlchemy.In(providers.Field("cloudaccount_id"), sq.Query(sq.Field("id")).SubQuery()))
+	pq = pq.GroupBy(providers.Field("cloudaccount_id"), providers.Field("health_status"))
+	providerRows := []sProviderHealthRow{}
+	err = pq.All(&providerRows)
+	if err != nil {
+		return nil, errors.Wrapf(err, "query providers")
+	}
+
+	// last_sync_end_at 在同步失败时也会更新, 成功同步时间以各区域的同步结果为准
+	cprs := CloudproviderRegionManager.Query().SubQuery()
+	sq2 := providers.Query(providers.Field("id"), providers.Field("cloudaccount_id")).
+		Filter(sqlchemy.In(providers.Field("cloudaccount_id"), sq.Query(sq.Field("id")).SubQuery())).SubQuery()
+	rq := cprs.Query(
+		sq2.Field("cloudaccount_id"),
+		sqlchemy.MAX("last_sync_success_at", cprs.Field("last_sync_success_at")),
+	).Join(sq2, sqlchemy.Equals(cprs.Field("cloudprovider_id"), sq2.Field("id")))
+	rq = rq.GroupBy(sq2.Field("cloudaccount_id"))
+	successRows := []sRegionSyncSuccessRow{}
+	err = rq.All(&successRows)
+	if err != nil {
+		return nil, errors.Wrapf(err, "query cloudproviderregions")
+	}
+
+	now := time.Now().UTC()
+	ret := make([]api.CloudaccountHealthSummary, len(accounts))
+	idx := map[string]int{}
+	for i, account := range accounts {
+		ret[i] = api.CloudaccountHealthSummary{
+			Id:                 account.Id,
+			Name:               account.Name,
+			Provider:           account.Provider,
+			Brand:              account.Brand,
+			Enabled:            account.Enabled,
+			Status:             account.Status,
+			HealthStatus:       account.HealthStatus,
+			SyncStatus:         account.SyncStatus,
+			ErrorCount:         account.ErrorCount,
+			LastSyncEndAt:      account.LastSyncEndAt,
+			ProviderHealth:     map[string]int{},
+			LastSyncAgeSeconds: -1,
+		}
+		idx[account.Id] = i
+	}
+	for _, row := range providerRows {
+		i, ok := idx[row.CloudaccountId]
+		if !ok {
+			continue
+		}
+		ret[i].ProviderCount += row.ProviderCount
+		ret[i].EnabledProviderCount += row.EnabledCount
+		ret[i].ConnectedProviderCount += row.ConnectedCount
+		ret[i].ProviderHealth[row.HealthStatus] += row.ProviderCount
+		if !utils.IsInStringArray(row.HealthStatus, api.CLOUD_PROVIDER_VALID_HEALTH_STATUS) {
+			ret[i].UnhealthyProviderCount += row.ProviderCount
+		}
+		if row.LastSyncEndAt.After(ret[i].LastSyncEndAt) {
+			ret[i].LastSyncEndAt = row.LastSyncEndAt
+		}
+	}
+	for _, row := range successRows {
+		if i, ok := idx[row.CloudaccountId]; ok {
+			ret[i].LastSyncSuccessAt = row.LastSyncSuccessAt
+		}
+	}
+	for i := range ret {
+		if !ret[i].LastSyncSuccessAt.IsZero() {
+			ret[i].LastSyncAgeSeconds = int(now.Sub(ret[i].LastSyncSuccessAt).Seconds())
+		}
+	}
+	return jsonutils.Marshal(ret), nil
+}
+
 func (manager *SCloudaccountManager) getBrandsOfProvider(provider string) ([]string, error) {
 	q := manager.Query().Equals("provider", provider)
 	cloudaccounts := make([]SCloudaccount, 0)
@@ -2851,5 +3022,6 @@ func (self *SCloudaccount) PerformProjectMapping(ctx context.Context, userCred m
 	if err != nil {
 		return nil, err
 	}
-	return nil, refreshPmCaches()
+	pmCaches.invalidate()
+	return nil, nil
 }
@@ -0,0 +1,203 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// 云平台API熔断状态, 按订阅隔离, 仅保存在当前region服务进程的内存中, 重启后清空
+// 重试只包裹建立云平台客户端(GetProvider)及同步前获取区域(GetIRegionById), 同步过程中各资源的API调用不重试
+// 熔断期间仅跳过该订阅的后台同步, 用户发起的云上操作(如删除、关机)不受影响
+type sProviderCircuit struct {
+	consecutiveFailures int
+	openUntil           time.Time
+
+	calls    int64
+	retries  int64
+	failures int64
+	rejected int64
+}
+
+type sProviderCircuits struct {
+	lock     sync.Mutex
+	circuits map[string]*sProviderCircuit
+}
+
+var providerCircuits = &sProviderCircuits{circuits: map[string]*sProviderCircuit{}}
+
+// 熔断期间跳过同步时返回, 单独计数, 不计入同步失败次数
+var errProviderSyncSkipped = errors.Error("cloudprovider api circuit is open")
+
+func (cs *sProviderCircuits) get(providerId string) *sProviderCircuit {
+	c, ok := cs.circuits[providerId]
+	if !ok {
+		c = &sProviderCircuit{}
+		cs.circuits[providerId] = c
+	}
+	return c
+}
+
+func (cs *sProviderCircuits) call(providerId string) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	cs.get(providerId).calls += 1
+}
+
+// 熔断期间返回true并统计被跳过的同步次数
+func (cs *sProviderCircuits) isOpen(providerId string, now time.Time) bool {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	c, ok := cs.circuits[providerId]
+	if !ok || !now.Before(c.openUntil) {
+		return false
+	}
+	c.rejected += 1
+	return true
+}
+
+func (cs *sProviderCircuits) retry(providerId string) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	cs.get(providerId).retries += 1
+}
+
+// 记录调用结果, 连续失败达到阈值后打开熔断, 返回是否刚刚打开
+func (cs *sProviderCircuits) record(providerId string, failed bool, now time.Time, threshold int, cooldown time.Duration) bool {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	c := cs.get(providerId)
+	if !failed {
+		c.consecutiveFailures = 0
+		return false
+	}
+	c.failures += 1
+	c.consecutiveFailures += 1
+	if threshold <= 0 || c.consecutiveFailures < threshold {
+		return false
+	}
+	c.consecutiveFailures = 0
+	c.openUntil = now.Add(cooldown)
+	return true
+}
+
+func (cs *sProviderCircuits) reset(providerId string) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	delete(cs.circuits, providerId)
+}
+
+func (cs *sProviderCircuits) stats(providerId string, now time.Time) api.CloudproviderApiStatsOutput {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	output := api.CloudproviderApiStatsOutput{}
+	c, ok := cs.circuits[providerId]
+	if !ok {
+		return output
+	}
+	output.Degraded = now.Before(c.openUntil)
+	if output.Degraded {
+		output.DegradedUntil = c.openUntil
+	}
+	output.ConsecutiveFailures = c.consecutiveFailures
+	output.Calls = c.calls
+	output.Retries = c.retries
+	output.Failures = c.failures
+	output.Rejected = c.rejected
+	return output
+}
+
+// 超时、网络异常及云平台服务端错误可通过重试恢复, 其余错误(如认证失败)直接返回
+func isTransientProviderError(err error) bool {
+	if err == nil {
+		return false
+	}
+	cause := errors.Cause(err)
+	switch cause {
+	case errors.ErrTimeout, errors.ErrServer, context.DeadlineExceeded:
+		return true
+	}
+	if netErr, ok := cause.(net.Error); ok {
+		if netErr.Timeout() {
+			return true
+		}
+		if _, ok := netErr.(*net.OpError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 第retry次重试前的等待时间, 每次翻倍
+func providerApiBackoff(base time.Duration, retry int) time.Duration {
+	if retry <= 0 {
+		return 0
+	}
+	return base << uint(retry-1)
+}
+
+// 调用云平台API, 瞬时错误按指数退避重试, 连续失败后熔断一段时间
+// 目前仅用于GetProvider及GetIRegionById
+func (provider *SCloudprovider) callProviderApi(ctx context.Context, name string, fn func() error) error {
+	providerCircuits.call(provider.Id)
+	base := time.Duration(options.Options.CloudApiRetryBackoffMilliseconds) * time.Millisecond
+	var err error
+	for i := 0; i <= options.Options.CloudApiRetryCount; i++ {
+		if i > 0 {
+			providerCircuits.retry(provider.Id)
+			select {
+			case <-ctx.Done():
+				return errors.Wrapf(ctx.Err(), "%s", name)
+			case <-time.After(providerApiBackoff(base, i)):
+			}
+			log.Debugf("retry %s for cloudprovider %s (%d)", name, provider.Name, i)
+		}
+		err = fn()
+		if !isTransientProviderError(err) {
+			break
+		}
+	}
+	cooldown := time.Duration(options.Options.CloudApiCircuitCooldownSeconds) * time.Second
+	if providerCircuits.record(provider.Id, isTransientProviderError(err), time.Now(), options.Options.CloudApiCircuitFailureThreshold, cooldown) {
+		log.Warningf("cloudprovider %s(%s) degraded for %s after consecutive failures of %s: %v", provider.Name, provider.Id, cooldown, name, err)
+	}
+	return err
+}
+
+// 获取云平台API调用统计及熔断状态
+func (provider *SCloudprovider) GetDetailsApiStats(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+) (api.CloudproviderApiStatsOutput, error) {
+	return providerCircuits.stats(provider.Id, time.Now()), nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+)
+
+func TestIsTransientProviderError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "timeout", err: errors.Wrap(errors.ErrTimeout, "GetIRegions"), want: true},
+		{name: "server", err: errors.Wrap(errors.ErrServer, "GetIRegions"), want: true},
+		{name: "deadline", err: context.DeadlineExceeded, want: true},
+		{name: "net", err: &net.OpError{Op: "dial", Err: errors.Error("connection refused")}, want: true},
+		{name: "auth", err: errors.Wrap(errors.Error("InvalidAccessKey"), "GetProvider"), want: false},
+	}
+	for _, c := range cases {
+		if got := isTransientProviderError(c.err); got != c.want {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestProviderApiBackoff(t *testing.T) {
+	base := 500 * time.Millisecond
+	for retry, want := range []time.Duration{0, base, 2 * base, 4 * base} {
+		if got := providerApiBackoff(base, retry); got != want {
+			t.Errorf("retry %d: want %s got %s", retry, want, got)
+		}
+	}
+}
+
+func TestProviderCircuits(t *testing.T) {
+	cs := &sProviderCircuits{circuits: map[string]*sProviderCircuit{}}
+	now := time.Now()
+	cooldown := time.Minute
+
+	if cs.record("p1", true, now, 2, cooldown) {
+		t.Fatalf("circuit should not open before threshold")
+	}
+	if cs.record("p1", false, now, 2, cooldown) || cs.record("p1", true, now, 2, cooldown) {
+		t.Fatalf("success should reset consecutive failures")
+	}
+	if !cs.record("p1", true, now, 2, cooldown) {
+		t.Fatalf("circuit should open at threshold")
+	}
+	if !cs.isOpen("p1", now.Add(time.Second)) {
+		t.Errorf("sync should be skipped during cooldown")
+	}
+	if cs.isOpen("p2", now) {
+		t.Errorf("other providers should not be affected")
+	}
+	if cs.isOpen("p1", now.Add(cooldown)) {
+		t.Errorf("sync should be allowed after cooldown")
+	}
+	cs.call("p1")
+
+	stats := cs.stats("p1", now)
+	if !stats.Degraded || stats.Failures != 3 || stats.Rejected != 1 || stats.Calls != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"sync"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// 已认证的云平台客户端缓存, 避免每次 GetProvider 都重新认证及签发token
+type sProviderClient struct {
+	version   string
+	driver    cloudprovider.ICloudProvider
+	expiredAt time.Time
+}
+
+type sProviderClientCache struct {
+	lock    sync.Mutex
+	clients map[string]*sProviderClient
+}
+
+var providerClients = &sProviderClientCache{clients: map[string]*sProviderClient{}}
+
+// 凭据版本不一致或已过期时返回nil, 需重新认证
+func (cache *sProviderClientCache) get(providerId, version string, now time.Time) cloudprovider.ICloudProvider {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	client, ok := cache.clients[providerId]
+	if !ok {
+		return nil
+	}
+	if client.version != version || !now.Before(client.expiredAt) {
+		delete(cache.clients, providerId)
+		return nil
+	}
+	return client.driver
+}
+
+func (cache *sProviderClientCache) set(providerId, version string, driver cloudprovider.ICloudProvider, now time.Time, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.clients[providerId] = &sProviderClient{
+		version:   version,
+		driver:    driver,
+		expiredAt: now.Add(ttl),
+	}
+}
+
+func (cache *sProviderClientCache) invalidate(providerId string) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	delete(cache.clients, providerId)
+}
+
+func getProviderClientCacheTTL() time.Duration {
+	return time.Duration(options.Options.CloudProviderClientCacheSeconds) * time.Second
+}
+
+// 凭据版本, 账号、密钥、访问地址、代理及账号选项任一变化时版本随之变化
+func (self *SCloudprovider) getCredentialVersion(account *SCloudaccount, accessUrl, defaultRegion string) string {
+	desc := jsonutils.NewDict()
+	desc.Add(jsonutils.NewString(self.Account), "account")
+	desc.Add(jsonutils.NewString(self.Secret), "secret")
+	desc.Add(jsonutils.NewString(accessUrl), "access_url")
+	desc.Add(jsonutils.NewString(defaultRegion), "default_region")
+	desc.Add(jsonutils.NewBool(account.ReadOnly), "read_only")
+	desc.Add(jsonutils.NewStringArray(options.Options.AliyunResourceGroups), "aliyun_resource_groups")
+	if account.Options != nil {
+		desc.Add(account.Options, "options")
+	}
+	desc.Add(jsonutils.NewString(account.ProxySettingId), "proxy_setting_id")
+	if ps := account.proxySetting(); ps != nil {
+		desc.Add(jsonutils.NewTimeString(ps.UpdatedAt), "proxy_setting_updated_at")
+	}
+	return stringutils2.GetMD5Hash(desc.String())
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+)
+
+type sTestCloudProvider struct {
+	cloudprovider.ICloudProvider
+}
+
+func TestProviderClientCache(t *testing.T) {
+	cache := &sProviderClientCache{clients: map[string]*sProviderClient{}}
+	now := time.Now()
+	driver := &sTestCloudProvider{}
+	cache.set("p1", "v1", driver, now, time.Minute)
+	cache.set("p2", "v1", driver, now, 0)
+
+	cases := []struct {
+		name    string
+		id      string
+		version string
+		now     time.Time
+		hit     bool
+	}{
+		{name: "hit", id: "p1", version: "v1", now: now.Add(time.Second), hit: true},
+		{name: "no cache", id: "p2", version: "v1", now: now},
+		{name: "expired", id: "p1", version: "v1", now: now.Add(time.Minute)},
+		{name: "removed after expired", id: "p1", version: "v1", now: now},
+	}
+	for _, c := range cases {
+		if got := cache.get(c.id, c.version, c.now); (got != nil) != c.hit {
+			t.Errorf("%s: want hit %v got %v", c.name, c.hit, got)
+		}
+	}
+
+	cache.set("p1", "v1", driver, now, time.Minute)
+	if cache.get("p1", "v2", now) != nil {
+		t.Errorf("credential changed, should not hit")
+	}
+	if cache.get("p1", "v1", now) != nil {
+		t.Errorf("stale client should be evicted after credential changed")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stsutils"
+)
+
+func (self *SCloudprovider) getClientRC() (map[string]string, error) {
+	passwd, err := self.getPassword()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getPassword")
+	}
+	account, err := self.GetCloudaccount()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetCloudaccount")
+	}
+	return cloudprovider.GetClientRC(self.Name, self.getAccessUrl(), self.Account, passwd, self.Provider, account.Options)
+}
+
+// 校验签发参数, 仅支持可按角色/策略收缩权限并指定有效期的平台
+func validateIssueCredentialInput(provider string, input *api.CloudproviderIssueCredentialInput) error {
+	switch provider {
+	case api.CLOUD_PROVIDER_AWS:
+		if len(input.RoleArn) == 0 && len(input.Policy) == 0 {
+			return httperrors.NewMissingParameterError("role_arn or policy")
+		}
+	case api.CLOUD_PROVIDER_ALIYUN:
+		if len(input.RoleArn) == 0 {
+			return httperrors.NewMissingParameterError("role_arn")
+		}
+	default:
+		return httperrors.NewNotSupportedError("issue credential for %s is not supported", provider)
+	}
+	if input.DurationSeconds == 0 {
+		input.DurationSeconds = api.CLOUD_PROVIDER_CREDENTIAL_DEFAULT_DURATION
+	}
+	if input.DurationSeconds < api.CLOUD_PROVIDER_CREDENTIAL_MIN_DURATION || input.DurationSeconds > api.CLOUD_PROVIDER_CREDENTIAL_MAX_DURATION {
+		return httperrors.NewOutOfRangeError("duration_seconds must be in range [%d, %d]", api.CLOUD_PROVIDER_CREDENTIAL_MIN_DURATION, api.CLOUD_PROVIDER_CREDENTIAL_MAX_DURATION)
+	}
+	if len(input.Policy) > 0 {
+		_, err := jsonutils.ParseString(input.Policy)
+		if err != nil {
+			return httperrors.NewInputParameterError("invalid policy document: %v", err)
+		}
+	}
+	return nil
+}
+
+// 签发云平台临时凭据, 调用者需拥有该云订阅的issue-credential操作权限
+// AWS: AssumeRole或按策略GetFederationToken; 阿里云: STS AssumeRole
+func (self *SCloudprovider) PerformIssueCredential(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.CloudproviderIssueCredentialInput) (*api.CloudproviderIssueCredentialOutput, error) {
+	if !self.GetEnabled() {
+		return nil, httperrors.NewInvalidStatusError("cloudprovider %s is disabled", self.Name)
+	}
+	if self.Status != api.CLOUD_PROVIDER_CONNECTED {
+		return nil, httperrors.NewInvalidStatusError("cloudprovider %s is not connected", self.Name)
+	}
+	err := validateIssueCredentialInput(self.Provider, &input)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := self.getClientRC()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "getClientRC"))
+	}
+	opts := stsutils.SCredentialOptions{
+		DurationSeconds: input.DurationSeconds,
+		Policy:          input.Policy,
+		RoleArn:         input.RoleArn,
+		SessionName:     stsutils.NormalizeSessionName(userCred.GetUserName()),
+	}
+	var cred *stsutils.SCredential
+	switch self.Provider {
+	case api.CLOUD_PROVIDER_AWS:
+		cred, err = stsutils.IssueAwsCredential(rc["AWS_ACCESS_KEY"], rc["AWS_SECRET"], rc["AWS_REGION"], opts)
+	case api.CLOUD_PROVIDER_ALIYUN:
+		cred, err = stsutils.IssueAliyunCredential(rc["ALIYUN_ACCESS_KEY"], rc["ALIYUN_SECRET"], rc["ALIYUN_REGION"], opts)
+	default:
+		return nil, httperrors.NewNotSupportedError("issue credential for %s is not supported", self.Provider)
+	}
+	// 审计日志中不记录凭据内容
+	notes := map[string]interface{}{"duration_seconds": input.DurationSeconds, "role_arn": input.RoleArn, "policy": input.Policy}
+	if err != nil {
+		logclient.AddSimpleActionLog(self, logclient.ACT_ISSUE_CREDENTIAL, errors.Wrapf(err, "issue credential"), userCred, false)
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_ISSUE_CREDENTIAL, notes, userCred, true)
+	return &api.CloudproviderIssueCredentialOutput{
+		Provider:        self.Provider,
+		AccessKeyId:     cred.AccessKeyId,
+		AccessKeySecret: cred.AccessKeySecret,
+		SecurityToken:   cred.SecurityToken,
+		Expiration:      cred.Expiration,
+	}, nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestValidateIssueCredentialInput(t *testing.T) {
+	cases := []struct {
+		provider string
+		input    api.CloudproviderIssueCredentialInput
+		wantErr  bool
+	}{
+		{api.CLOUD_PROVIDER_AWS, api.CloudproviderIssueCredentialInput{}, true},
+		{api.CLOUD_PROVIDER_AWS, api.CloudproviderIssueCredentialInput{RoleArn: "arn:aws:iam::123:role/r"}, false},
+		{api.CLOUD_PROVIDER_AWS, api.CloudproviderIssueCredentialInput{Policy: `{"Version":"2012-10-17"}`}, false},
+		{api.CLOUD_PROVIDER_ALIYUN, api.CloudproviderIssueCredentialInput{Policy: `{"Version":"1"}`}, true},
+		{api.CLOUD_PROVIDER_ALIYUN, api.CloudproviderIssueCredentialInput{RoleArn: "acs:ram::123:role/r", DurationSeconds: 60}, true},
+		{api.CLOUD_PROVIDER_GOOGLE, api.CloudproviderIssueCredentialInput{RoleArn: "r"}, true},
+		{api.CLOUD_PROVIDER_AZURE, api.CloudproviderIssueCredentialInput{RoleArn: "r"}, true},
+	}
+	for _, c := range cases {
+		err := validateIssueCredentialInput(c.provider, &c.input)
+		if (err != nil) != c.wantErr {
+			t.Errorf("provider %s input %+v want err %v got %v", c.provider, c.input, c.wantErr, err)
+		}
+	}
+}
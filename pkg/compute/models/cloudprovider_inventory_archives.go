@@ -0,0 +1,259 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// +onecloud:swagger-gen-ignore
+type SCloudproviderInventoryArchiveManager struct {
+	db.SResourceBaseManager
+}
+
+// 删除子订阅时归档的本地资源元数据, 重新添加同一账号后可据此恢复
+type SCloudproviderInventoryArchive struct {
+	db.SResourceBase
+
+	RowId int64 `primary:"true" auto_increment:"true"`
+
+	CloudaccountId  string `width:"36" charset:"ascii" nullable:"false" index:"true"`
+	Account         string `width:"128" charset:"ascii" nullable:"false"`
+	CloudproviderId string `width:"36" charset:"ascii" nullable:"false"`
+
+	ResourceType string `width:"36" charset:"ascii" nullable:"false"`
+	ResourceId   string `width:"36" charset:"ascii" nullable:"false"`
+	ExternalId   string `width:"256" charset:"utf8" nullable:"false"`
+	Name         string `width:"128" charset:"utf8" nullable:"false"`
+	Description  string `width:"256" charset:"utf8" nullable:"true"`
+	ProjectId    string `name:"tenant_id" width:"128" charset:"ascii" nullable:"true"`
+	DomainId     string `width:"128" charset:"ascii" nullable:"true"`
+	// 用户标签
+	Tags jsonutils.JSONObject `nullable:"true"`
+
+	ArchivedAt time.Time `nullable:"false"`
+	RestoredAt time.Time `nullable:"true"`
+}
+
+var CloudproviderInventoryArchiveManager *SCloudproviderInventoryArchiveManager
+
+func init() {
+	CloudproviderInventoryArchiveManager = &SCloudproviderInventoryArchiveManager{
+		SResourceBaseManager: db.NewResourceBaseManager(
+			SCloudproviderInventoryArchive{},
+			"cloudprovider_inventory_archives_tbl",
+			"cloudprovider_inventory_archive",
+			"cloudprovider_inventory_archives",
+		),
+	}
+	CloudproviderInventoryArchiveManager.SetVirtualObject(CloudproviderInventoryArchiveManager)
+}
+
+// 仅归档有外部ID的资源, 恢复时按外部ID匹配重新同步的资源
+func getArchivableModelManager(keyword string) db.IModelManager {
+	manager := db.GetModelManager(keyword)
+	if manager == nil || manager.TableSpec().ColumnSpec("external_id") == nil {
+		return nil
+	}
+	return manager
+}
+
+func (manager *SCloudproviderInventoryArchiveManager) fetchUserTags(keyword string, ids []string) (map[string]map[string]string, error) {
+	q := db.Metadata.Query().Equals("obj_type", keyword).In("obj_id", ids).Startswith("key", db.USER_TAG_PREFIX)
+	metadatas := []db.SMetadata{}
+	err := db.FetchModelObjects(db.Metadata, q, &metadatas)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	ret := map[string]map[string]string{}
+	for _, meta := range metadatas {
+		if _, ok := ret[meta.ObjId]; !ok {
+			ret[meta.ObjId] = map[string]string{}
+		}
+		ret[meta.ObjId][meta.Key] = meta.Value
+	}
+	return ret, nil
+}
+
+// 归档子订阅下将被清理资源的名称、描述、项目及用户标签
+func (manager *SCloudproviderInventoryArchiveManager) ArchiveProvider(ctx context.Context, provider *SCloudprovider) (int, error) {
+	// 清除此前删除失败时遗留的归档, 避免重复
+	olds := []SCloudproviderInventoryArchive{}
+	err := db.FetchModelObjects(manager, manager.Query().Equals("cloudprovider_id", provider.Id), &olds)
+	if err != nil {
+		return 0, errors.Wrap(err, "fetch previous archives")
+	}
+	for i := range olds {
+		err = olds[i].Delete(ctx, nil)
+		if err != nil {
+			return 0, errors.Wrap(err, "delete previous archive")
+		}
+	}
+
+	archived := 0
+	now := time.Now().UTC()
+	for _, pq := range getPurgePreviewQueries(provider.Id) {
+		resManager := getArchivableModelManager(pq.keyword)
+		if resManager == nil {
+			continue
+		}
+		rows, err := resManager.Query().In("id", pq.q.SubQuery()).IsNotEmpty("external_id").AllStringMap()
+		if err != nil {
+			return archived, errors.Wrapf(err, "query %s", pq.keyword)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		ids := []string{}
+		for _, row := range rows {
+			ids = append(ids, row["id"])
+		}
+		tags, err := manager.fetchUserTags(pq.keyword, ids)
+		if err != nil {
+			return archived, errors.Wrapf(err, "fetchUserTags %s", pq.keyword)
+		}
+		for _, row := range rows {
+			archive := &SCloudproviderInventoryArchive{
+				CloudaccountId:  provider.CloudaccountId,
+				Account:         provider.Account,
+				CloudproviderId: provider.Id,
+				ResourceType:    pq.keyword,
+				ResourceId:      row["id"],
+				ExternalId:      row["external_id"],
+				Name:            row["name"],
+				Description:     row["description"],
+				ProjectId:       row["tenant_id"],
+				DomainId:        row["domain_id"],
+				ArchivedAt:      now,
+			}
+			if len(tags[row["id"]]) > 0 {
+				archive.Tags = jsonutils.Marshal(tags[row["id"]])
+			}
+			archive.SetModelManager(manager, archive)
+			err := manager.TableSpec().Insert(ctx, archive)
+			if err != nil {
+				return archived, errors.Wrapf(err, "insert archive for %s %s", pq.keyword, row["id"])
+			}
+			archived++
+		}
+	}
+	return archived, nil
+}
+
+func (manager *SCloudproviderInventoryArchiveManager) fetchPendingArchives(provider *SCloudprovider) ([]SCloudproviderInventoryArchive, error) {
+	q := manager.Query().Equals("cloudaccount_id", provider.CloudaccountId).Equals("account", provider.Account).
+		NotEquals("cloudprovider_id", provider.Id).IsNull("restored_at").Asc("row_id")
+	archives := []SCloudproviderInventoryArchive{}
+	err := db.FetchModelObjects(manager, q, &archives)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return archives, nil
+}
+
+type iProjectInfoSetter interface {
+	SetProjectInfo(ctx context.Context, userCred mcclient.TokenCredential, projectId, domainId string) error
+}
+
+func (archive *SCloudproviderInventoryArchive) restore(ctx context.Context, userCred mcclient.TokenCredential, model db.IModel) error {
+	lockman.LockObject(ctx, model)
+	defer lockman.ReleaseObject(ctx, model)
+
+	if len(archive.Description) > 0 {
+		_, err := db.Update(model, func() error {
+			return jsonutils.Marshal(map[string]string{"description": archive.Description}).Unmarshal(model)
+		})
+		if err != nil {
+			return errors.Wrap(err, "update description")
+		}
+	}
+	if setter, ok := model.(iProjectInfoSetter); ok && len(archive.ProjectId) > 0 {
+		tenant, err := db.TenantCacheManager.FetchTenantById(ctx, archive.ProjectId)
+		if err != nil {
+			log.Warningf("archived project %s of %s %s not found: %v", archive.ProjectId, archive.ResourceType, model.GetId(), err)
+		} else {
+			err = setter.SetProjectInfo(ctx, userCred, tenant.Id, tenant.DomainId)
+			if err != nil {
+				return errors.Wrap(err, "SetProjectInfo")
+			}
+		}
+	}
+	if sm, ok := model.(db.IStandaloneModel); ok && archive.Tags != nil {
+		tags := map[string]interface{}{}
+		err := archive.Tags.Unmarshal(&tags)
+		if err != nil {
+			return errors.Wrap(err, "unmarshal tags")
+		}
+		err = sm.SetUserMetadataValues(ctx, tags, userCred)
+		if err != nil {
+			return errors.Wrap(err, "SetUserMetadataValues")
+		}
+	}
+	_, err := db.Update(archive, func() error {
+		archive.RestoredAt = time.Now().UTC()
+		return nil
+	})
+	return err
+}
+
+// 将同一账号此前删除子订阅时归档的元数据恢复到重新同步的资源上
+func (self *SCloudprovider) PerformRestoreArchive(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input jsonutils.JSONObject) (*api.CloudproviderRestoreArchiveOutput, error) {
+	archives, err := CloudproviderInventoryArchiveManager.fetchPendingArchives(self)
+	if err != nil {
+		return nil, err
+	}
+	queries := map[string]sPurgePreviewQuery{}
+	for _, pq := range getPurgePreviewQueries(self.Id) {
+		queries[pq.keyword] = pq
+	}
+	output := &api.CloudproviderRestoreArchiveOutput{}
+	for i := range archives {
+		archive := &archives[i]
+		pq, ok := queries[archive.ResourceType]
+		resManager := getArchivableModelManager(archive.ResourceType)
+		if !ok || resManager == nil {
+			output.Skipped++
+			continue
+		}
+		ids, err := filterResult(resManager.Query("id").In("id", pq.q.SubQuery()).Equals("external_id", archive.ExternalId))
+		if err != nil {
+			return nil, errors.Wrapf(err, "find %s %s", archive.ResourceType, archive.ExternalId)
+		}
+		if len(ids) != 1 {
+			output.Skipped++
+			continue
+		}
+		model, err := resManager.FetchById(ids[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "FetchById %s", ids[0])
+		}
+		err = archive.restore(ctx, userCred, model)
+		if err != nil {
+			return nil, errors.Wrapf(err, "restore %s %s", archive.ResourceType, ids[0])
+		}
+		output.Restored++
+	}
+	return output, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"sync"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+)
+
+// 同步策略缓存有效期, 过期后整体重新加载, 已删除的子订阅随之清除
+const pmCacheTTL = 10 * time.Minute
+
+type sPmCacheStore struct {
+	lock sync.RWMutex
+
+	// 子订阅ID -> 同步策略
+	providers map[string]*pmCache
+	// 云账号ID -> 同步策略
+	accounts map[string]*pmCache
+
+	expiredAt time.Time
+}
+
+var pmCaches = newPmCacheStore()
+
+func newPmCacheStore() *sPmCacheStore {
+	return &sPmCacheStore{
+		providers: map[string]*pmCache{},
+		accounts:  map[string]*pmCache{},
+	}
+}
+
+func fetchPmCaches() ([]pmCache, error) {
+	q := CloudproviderManager.Query().SubQuery()
+	providers := q.Query(
+		q.Field("cloudaccount_id"),
+		q.Field("id"),
+		q.Field("project_mapping_id").Label("manager_project_mapping_id"),
+		q.Field("enable_project_sync").Label("manager_enable_project_sync"),
+		q.Field("enable_resource_sync").Label("manager_enable_resource_sync"),
+	)
+	sq := CloudaccountManager.Query().SubQuery()
+	mq := providers.LeftJoin(sq, sqlchemy.Equals(q.Field("cloudaccount_id"), sq.Field("id"))).
+		AppendField(sq.Field("project_mapping_id").Label("account_project_mapping_id")).
+		AppendField(sq.Field("enable_project_sync").Label("account_enable_project_sync")).
+		AppendField(sq.Field("enable_resource_sync").Label("account_enable_resource_sync"))
+	caches := []pmCache{}
+	err := mq.All(&caches)
+	if err != nil {
+		return nil, errors.Wrapf(err, "q.All")
+	}
+	return caches, nil
+}
+
+func (store *sPmCacheStore) set(caches []pmCache, now time.Time) {
+	providers := map[string]*pmCache{}
+	accounts := map[string]*pmCache{}
+	for i := range caches {
+		providers[caches[i].Id] = &caches[i]
+		if _, ok := accounts[caches[i].CloudaccountId]; !ok {
+			accounts[caches[i].CloudaccountId] = &caches[i]
+		}
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.providers = providers
+	store.accounts = accounts
+	store.expiredAt = now.Add(pmCacheTTL)
+}
+
+func (store *sPmCacheStore) refresh() error {
+	caches, err := fetchPmCaches()
+	if err != nil {
+		return errors.Wrapf(err, "fetchPmCaches")
+	}
+	store.set(caches, time.Now())
+	return nil
+}
+
+// 同步策略变更后调用, 下次读取时重新加载
+func (store *sPmCacheStore) invalidate() {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.expiredAt = time.Time{}
+}
+
+func (store *sPmCacheStore) lookup(id string, byAccount bool, now time.Time) (*pmCache, bool) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	if now.After(store.expiredAt) {
+		return nil, false
+	}
+	caches := store.providers
+	if byAccount {
+		caches = store.accounts
+	}
+	cache, ok := caches[id]
+	return cache, ok
+}
+
+// 缓存过期或未命中时重新加载, 新建的子订阅无需等待过期
+func (store *sPmCacheStore) get(id string, byAccount bool) (*pmCache, error) {
+	cache, ok := store.lookup(id, byAccount, time.Now())
+	if ok {
+		return cache, nil
+	}
+	err := store.refresh()
+	if err != nil {
+		return nil, errors.Wrapf(err, "refresh")
+	}
+	cache, ok = store.lookup(id, byAccount, time.Now())
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrNotFound, "%s", id)
+	}
+	return cache, nil
+}
+
+func (store *sPmCacheStore) getByProvider(providerId string) (*pmCache, error) {
+	return store.get(providerId, false)
+}
+
+func (store *sPmCacheStore) getByAccount(accountId string) (*pmCache, error) {
+	return store.get(accountId, true)
+}
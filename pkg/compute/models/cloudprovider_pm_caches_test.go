@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPmCacheStoreLookup(t *testing.T) {
+	store := newPmCacheStore()
+	now := time.Now()
+	store.set([]pmCache{
+		{Id: "p1", CloudaccountId: "a1"},
+		{Id: "p2", CloudaccountId: "a1"},
+		{Id: "p3", CloudaccountId: "a2"},
+	}, now)
+
+	cases := []struct {
+		name      string
+		id        string
+		byAccount bool
+		now       time.Time
+		want      string
+	}{
+		{name: "provider", id: "p2", now: now, want: "p2"},
+		{name: "account", id: "a2", byAccount: true, now: now, want: "p3"},
+		{name: "missing provider", id: "p4", now: now},
+		{name: "expired", id: "p1", now: now.Add(pmCacheTTL + time.Second)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cache, ok := store.lookup(c.id, c.byAccount, c.now)
+			if ok != (len(c.want) > 0) {
+				t.Fatalf("want found %v, got %v", len(c.want) > 0, ok)
+			}
+			if ok && cache.Id != c.want {
+				t.Errorf("want %s, got %s", c.want, cache.Id)
+			}
+		})
+	}
+
+	store.invalidate()
+	if _, ok := store.lookup("p1", false, now); ok {
+		t.Errorf("want miss after invalidate")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// 无法直接按manager_id过滤的资源, 需自行给出待清理资源ID的查询
+type IPurgePreviewManager interface {
+	purgePreviewQuery(providerId string) *sqlchemy.SQuery
+}
+
+func getPurgePreviewQuery(manager IPurgeableManager, providerId string) *sqlchemy.SQuery {
+	if m, ok := manager.(IPurgePreviewManager); ok {
+		return m.purgePreviewQuery(providerId)
+	}
+	m, ok := manager.(db.IModelManager)
+	if !ok || m.TableSpec().ColumnSpec("manager_id") == nil {
+		return nil
+	}
+	return m.Query("id").Equals("manager_id", providerId)
+}
+
+func providerVpcIdQuery(providerId string) *sqlchemy.SSubQuery {
+	return VpcManager.Query("id").Equals("manager_id", providerId).SubQuery()
+}
+
+func (manager *SLoadbalancerBackendGroupManager) purgePreviewQuery(providerId string) *sqlchemy.SQuery {
+	lbs := LoadbalancerManager.Query("id").In("vpc_id", providerVpcIdQuery(providerId)).SubQuery()
+	return manager.Query("id").In("loadbalancer_id", lbs)
+}
+
+func (manager *SLoadbalancerCertificateManager) purgePreviewQuery(providerId string) *sqlchemy.SQuery {
+	certs := CachedLoadbalancerCertificateManager.Query("certificate_id").Equals("manager_id", providerId).IsNotEmpty("certificate_id").SubQuery()
+	return manager.Query("id").In("id", certs)
+}
+
+func (manager *SNatGatewayManager) purgePreviewQuery(providerId string) *sqlchemy.SQuery {
+	return manager.Query("id").In("vpc_id", providerVpcIdQuery(providerId))
+}
+
+func (manager *SElasticcacheManager) purgePreviewQuery(providerId string) *sqlchemy.SQuery {
+	return manager.Query("id").In("vpc_id", providerVpcIdQuery(providerId))
+}
+
+func (manager *SSnapshotPolicyManager) purgePreviewQuery(providerId string) *sqlchemy.SQuery {
+	return SnapshotPolicyCacheManager.Query("id").Equals("manager_id", providerId)
+}
+
+func (manager *SCloudproviderregionManager) purgePreviewQuery(providerId string) *sqlchemy.SQuery {
+	return manager.Query("row_id").Equals("cloudprovider_id", providerId)
+}
+
+func (manager *SCloudregionManager) purgePreviewQuery(providerId string) *sqlchemy.SQuery {
+	// 仍被其他provider使用的region不会被清理
+	others := CloudproviderRegionManager.Query("cloudregion_id").NotEquals("cloudprovider_id", providerId).SubQuery()
+	return manager.Query("id").Equals("manager_id", providerId).NotIn("id", others)
+}
+
+type sPurgePreviewQuery struct {
+	keyword string
+	q       *sqlchemy.SQuery
+}
+
+// 随宿主机、存储及VPC一并清理的资源
+func getPurgePreviewCascadeQueries(providerId string) []sPurgePreviewQuery {
+	hosts := HostManager.Query("id").Equals("manager_id", providerId).SubQuery()
+	storages := StorageManager.Query("id").Equals("manager_id", providerId).SubQuery()
+	wires := WireManager.Query("id").In("vpc_id", providerVpcIdQuery(providerId)).SubQuery()
+	return []sPurgePreviewQuery{
+		{GuestManager.Keyword(), GuestManager.Query("id").In("host_id", hosts)},
+		{DiskManager.Keyword(), DiskManager.Query("id").In("storage_id", storages)},
+		{NetworkManager.Keyword(), NetworkManager.Query("id").In("wire_id", wires)},
+	}
+}
+
+func addPurgePreviewResource(output *api.CloudproviderPurgePreviewOutput, keyword string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	output.Resources = append(output.Resources, api.CloudproviderPurgePreviewResource{
+		ResourceType: keyword,
+		Count:        len(ids),
+		Ids:          ids,
+	})
+	output.Total += len(ids)
+}
+
+// 删除子订阅时将被清理的全部本地资源
+func getPurgePreviewQueries(providerId string) []sPurgePreviewQuery {
+	queries := []sPurgePreviewQuery{}
+	for _, manager := range getPurgeableManagers() {
+		q := getPurgePreviewQuery(manager, providerId)
+		if q == nil {
+			continue
+		}
+		queries = append(queries, sPurgePreviewQuery{manager.Keyword(), q})
+	}
+	return append(queries, getPurgePreviewCascadeQueries(providerId)...)
+}
+
+// 预览删除子订阅时将被清理的本地资源
+func (self *SCloudprovider) PerformPurgePreview(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input jsonutils.JSONObject) (*api.CloudproviderPurgePreviewOutput, error) {
+	queries := getPurgePreviewQueries(self.Id)
+	output := &api.CloudproviderPurgePreviewOutput{
+		Resources: []api.CloudproviderPurgePreviewResource{},
+	}
+	for _, pq := range queries {
+		ids, err := filterResult(pq.q.Distinct())
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "preview %s", pq.keyword))
+		}
+		addPurgePreviewResource(output, pq.keyword, ids)
+	}
+	return output, nil
+}
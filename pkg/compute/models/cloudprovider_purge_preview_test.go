@@ -0,0 +1,37 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestAddPurgePreviewResource(t *testing.T) {
+	output := &api.CloudproviderPurgePreviewOutput{}
+	addPurgePreviewResource(output, "hosts", []string{"h1", "h2"})
+	addPurgePreviewResource(output, "vpcs", []string{})
+	addPurgePreviewResource(output, "disks", []string{"d1"})
+	if output.Total != 3 {
+		t.Errorf("want total 3, got %d", output.Total)
+	}
+	if len(output.Resources) != 2 {
+		t.Fatalf("want 2 resources, got %d", len(output.Resources))
+	}
+	if output.Resources[0].ResourceType != "hosts" || output.Resources[0].Count != 2 {
+		t.Errorf("unexpected resource %#v", output.Resources[0])
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+// api.SCloudproviderUsage 的全部字段, 列表指定的 fields 不包含这些字段时跳过统计
+var cloudproviderUsageFields = []string{
+	"guest_count",
+	"host_count",
+	"vpc_count",
+	"storage_count",
+	"storagecache_count",
+	"eip_count",
+	"snapshot_count",
+	"loadbalancer_count",
+	"dbinstance_count",
+	"elasticcache_count",
+	"project_count",
+	"sync_region_count",
+}
+
+// q 需包含 manager_id 字段, usage 为 api.SCloudproviderUsage 对应的json字段
+func providerUsageCountQuery(q *sqlchemy.SQuery, usage string) *sqlchemy.SQuery {
+	sq := q.SubQuery()
+	return sq.Query(
+		sq.Field("manager_id"),
+		sqlchemy.NewStringField(usage).Label("usage"),
+		sqlchemy.COUNT("count"),
+	).GroupBy(sq.Field("manager_id"))
+}
+
+func getProviderUsageQueries(providerIds []string) []sqlchemy.IQuery {
+	hosts := HostManager.Query().SubQuery()
+	guests := GuestManager.Query("id")
+	guests = guests.Join(hosts, sqlchemy.Equals(guests.Field("host_id"), hosts.Field("id"))).
+		Filter(sqlchemy.In(hosts.Field("manager_id"), providerIds)).
+		AppendField(hosts.Field("manager_id"))
+
+	vpcs := VpcManager.Query().SubQuery()
+	caches := ElasticcacheManager.Query("id")
+	caches = caches.Join(vpcs, sqlchemy.Equals(caches.Field("vpc_id"), vpcs.Field("id"))).
+		Filter(sqlchemy.In(vpcs.Field("manager_id"), providerIds)).
+		AppendField(vpcs.Field("manager_id"))
+
+	regions := CloudproviderRegionManager.Query().SubQuery()
+	syncRegions := regions.Query(regions.Field("cloudprovider_id").Label("manager_id")).
+		Filter(sqlchemy.In(regions.Field("cloudprovider_id"), providerIds))
+
+	return []sqlchemy.IQuery{
+		providerUsageCountQuery(guests, "guest_count"),
+		providerUsageCountQuery(HostManager.Query("manager_id").In("manager_id", providerIds).IsFalse("is_emulated"), "host_count"),
+		providerUsageCountQuery(VpcManager.Query("manager_id").In("manager_id", providerIds).IsFalse("is_emulated"), "vpc_count"),
+		providerUsageCountQuery(StorageManager.Query("manager_id").In("manager_id", providerIds).IsFalse("is_emulated"), "storage_count"),
+		providerUsageCountQuery(StoragecacheManager.Query("manager_id").In("manager_id", providerIds), "storagecache_count"),
+		providerUsageCountQuery(ElasticipManager.Query("manager_id").In("manager_id", providerIds), "eip_count"),
+		providerUsageCountQuery(SnapshotManager.Query("manager_id").In("manager_id", providerIds), "snapshot_count"),
+		providerUsageCountQuery(LoadbalancerManager.Query("manager_id").In("manager_id", providerIds), "loadbalancer_count"),
+		providerUsageCountQuery(DBInstanceManager.Query("manager_id").In("manager_id", providerIds), "dbinstance_count"),
+		providerUsageCountQuery(caches, "elasticcache_count"),
+		providerUsageCountQuery(ExternalProjectManager.Query("manager_id").In("manager_id", providerIds), "project_count"),
+		providerUsageCountQuery(syncRegions, "sync_region_count"),
+	}
+}
+
+type sProviderUsageCount struct {
+	ManagerId string
+	Usage     string
+	Count     int
+}
+
+func mergeProviderUsageCounts(counts []sProviderUsageCount) (map[string]api.SCloudproviderUsage, error) {
+	usages := map[string]map[string]int{}
+	for _, c := range counts {
+		if _, ok := usages[c.ManagerId]; !ok {
+			usages[c.ManagerId] = map[string]int{}
+		}
+		usages[c.ManagerId][c.Usage] = c.Count
+	}
+	ret := map[string]api.SCloudproviderUsage{}
+	for id, usage := range usages {
+		u := api.SCloudproviderUsage{}
+		err := jsonutils.Marshal(usage).Unmarshal(&u)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unmarshal usage of %s", id)
+		}
+		ret[id] = u
+	}
+	return ret, nil
+}
+
+// 一次查询统计多个子订阅的资源数量
+func (manager *SCloudproviderManager) fetchUsages(providerIds []string) (map[string]api.SCloudproviderUsage, error) {
+	if len(providerIds) == 0 {
+		return map[string]api.SCloudproviderUsage{}, nil
+	}
+	uq, err := sqlchemy.UnionAllWithError(getProviderUsageQueries(providerIds)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "UnionAllWithError")
+	}
+	counts := []sProviderUsageCount{}
+	err = uq.Query().All(&counts)
+	if err != nil {
+		return nil, errors.Wrap(err, "query usages")
+	}
+	return mergeProviderUsageCounts(counts)
+}
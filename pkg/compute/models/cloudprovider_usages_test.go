@@ -0,0 +1,37 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "testing"
+
+func TestMergeProviderUsageCounts(t *testing.T) {
+	usages, err := mergeProviderUsageCounts([]sProviderUsageCount{
+		{ManagerId: "p1", Usage: "guest_count", Count: 3},
+		{ManagerId: "p1", Usage: "sync_region_count", Count: 2},
+		{ManagerId: "p2", Usage: "storagecache_count", Count: 1},
+	})
+	if err != nil {
+		t.Fatalf("mergeProviderUsageCounts: %v", err)
+	}
+	if usages["p1"].GuestCount != 3 || usages["p1"].SyncRegionCount != 2 || usages["p1"].HostCount != 0 {
+		t.Errorf("unexpected usage of p1: %#v", usages["p1"])
+	}
+	if usages["p2"].StorageCacheCount != 1 {
+		t.Errorf("unexpected usage of p2: %#v", usages["p2"])
+	}
+	if _, ok := usages["p3"]; ok {
+		t.Errorf("unexpected usage of p3")
+	}
+}
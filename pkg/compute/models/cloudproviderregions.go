@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"time"
 
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
@@ -83,6 +84,13 @@ type SCloudproviderregion struct {
 
 	LastDeepSyncAt time.Time `list:"domain"`
 	LastAutoSyncAt time.Time `list:"domain"`
+
+	// 连续同步失败次数
+	SyncFailedCount int `nullable:"false" default:"0" list:"domain"`
+	// 最近一次成功同步结束时间
+	LastSyncSuccessAt time.Time `nullable:"true" list:"domain"`
+	// 熔断期间连续跳过的同步次数
+	SyncSkippedCount int `nullable:"false" default:"0" list:"domain"`
 }
 
 func (manager *SCloudproviderregionManager) GetMasterFieldName() string {
@@ -424,6 +432,10 @@ func (self *SCloudproviderregion) DoSync(ctx context.Context, userCred mcclient.
 		}
 	}()
 
+	if providerCircuits.isOpen(provider.Id, time.Now()) {
+		return errors.Wrapf(errProviderSyncSkipped, "cloudprovider %s is degraded, skip sync", provider.Name)
+	}
+
 	driver, err := provider.GetProvider(ctx)
 	if err != nil {
 		log.Errorf("Failed to get driver, connection problem?")
@@ -439,7 +451,11 @@ func (self *SCloudproviderregion) DoSync(ctx context.Context, userCred mcclient.
 	log.Debugf("need to do deep sync? ... %v", syncRange.DeepSync)
 
 	if localRegion.isManaged() {
-		remoteRegion, err := driver.GetIRegionById(localRegion.ExternalId)
+		var remoteRegion cloudprovider.ICloudRegion
+		err = provider.callProviderApi(ctx, "GetIRegionById", func() error {
+			remoteRegion, err = driver.GetIRegionById(localRegion.ExternalId)
+			return err
+		})
 		if err != nil {
 			return errors.Wrap(err, "GetIRegionById")
 		}
@@ -461,15 +477,59 @@ func (self *SCloudproviderregion) getSyncTaskKey() string {
 	return fmt.Sprintf("%d", self.RowId)
 }
 
-func (self *SCloudproviderregion) submitSyncTask(ctx context.Context, userCred mcclient.TokenCredential, syncRange SSyncRange) {
+func (self *SCloudproviderregion) submitSyncTask(ctx context.Context, userCred mcclient.TokenCredential, provider *SCloudprovider, syncRange SSyncRange) {
 	self.markStartSync(userCred)
-	RunSyncCloudproviderRegionTask(ctx, self.getSyncTaskKey(), func() {
+	ok := RunSyncCloudproviderRegionTask(ctx, provider.Provider, self.getSyncTaskKey(), func() {
 		ctx = context.WithValue(ctx, "provider-region", fmt.Sprintf("%d", self.RowId))
 		err := self.DoSync(ctx, userCred, syncRange)
 		if err != nil {
 			log.Errorf("DoSync faild %v", err)
 		}
+		self.markSyncResult(ctx, userCred, err)
+	})
+	if !ok {
+		// 队列已满, 恢复同步状态以便下次自动同步时重新调度, 不计入同步失败次数
+		log.Warningf("sync queue is full, sync task of %s region %s will be rescheduled", provider.Name, self.getSyncTaskKey())
+		deepSync := false
+		err := self.markEndSync(ctx, userCred, SSyncResultSet{}, &deepSync)
+		if err != nil {
+			log.Errorf("markEndSync for %s error: %v", self.getSyncTaskKey(), err)
+		}
+	}
+}
+
+// 记录连续同步失败次数及最近成功同步时间, 失败次数达到告警规则阈值时发送通知
+// 熔断跳过的同步未实际执行, 单独计数, 不影响失败次数及告警
+func (self *SCloudproviderregion) markSyncResult(ctx context.Context, userCred mcclient.TokenCredential, syncErr error) {
+	skipped := errors.Cause(syncErr) == errProviderSyncSkipped
+	_, err := db.Update(self, func() error {
+		switch {
+		case skipped:
+			self.SyncSkippedCount += 1
+		case syncErr != nil:
+			self.SyncSkippedCount = 0
+			self.SyncFailedCount += 1
+		default:
+			self.SyncSkippedCount = 0
+			self.SyncFailedCount = 0
+			self.LastSyncSuccessAt = timeutils.UtcNow()
+		}
+		return nil
 	})
+	if err != nil {
+		log.Errorf("update sync_failed_count error: %v", err)
+		return
+	}
+	if syncErr == nil || skipped {
+		return
+	}
+	provider, err := self.GetProvider()
+	if err != nil {
+		log.Errorf("GetProvider for %s error: %v", self.CloudproviderId, err)
+		return
+	}
+	reason := fmt.Sprintf("sync region %s failed %d times: %v", self.CloudregionId, self.SyncFailedCount, syncErr)
+	ProviderAlertRuleManager.fire(ctx, userCred, api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED, provider.DomainId, provider, reason, self.SyncFailedCount)
 }
 
 func (cpr *SCloudproviderregion) resetAutoSync() {
@@ -166,51 +166,8 @@ func (self *pmCache) GetProjectMapping() (*sProjectMapping, error) {
 	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "empty project mapping id")
 }
 
-var pmCaches map[string]*pmCache = map[string]*pmCache{}
-
-func refreshPmCaches() error {
-	q := CloudproviderManager.Query().SubQuery()
-	providers := q.Query(
-		q.Field("cloudaccount_id"),
-		q.Field("id"),
-		q.Field("project_mapping_id").Label("manager_project_mapping_id"),
-		q.Field("enable_project_sync").Label("manager_enable_project_sync"),
-		q.Field("enable_resource_sync").Label("manager_enable_resource_sync"),
-	)
-	sq := CloudaccountManager.Query().SubQuery()
-	mq := providers.LeftJoin(sq, sqlchemy.Equals(q.Field("cloudaccount_id"), sq.Field("id"))).
-		AppendField(sq.Field("project_mapping_id").Label("account_project_mapping_id")).
-		AppendField(sq.Field("enable_project_sync").Label("account_enable_project_sync")).
-		AppendField(sq.Field("enable_resource_sync").Label("account_enable_resource_sync"))
-	caches := []pmCache{}
-	err := mq.All(&caches)
-	if err != nil {
-		return errors.Wrapf(err, "q.All")
-	}
-	for i := range caches {
-		pmCaches[caches[i].Id] = &caches[i]
-	}
-	return nil
-}
-
 func (self *SCloudaccount) GetProjectMapping() (*sProjectMapping, error) {
-	cache, err := func() (*pmCache, error) {
-		for id := range pmCaches {
-			if pmCaches[id].CloudaccountId == self.Id {
-				return pmCaches[id], nil
-			}
-		}
-		err := refreshPmCaches()
-		if err != nil {
-			return nil, errors.Wrapf(err, "refreshPmCaches")
-		}
-		for id := range pmCaches {
-			if pmCaches[id].CloudaccountId == self.Id {
-				return pmCaches[id], nil
-			}
-		}
-		return nil, cloudprovider.ErrNotFound
-	}()
+	cache, err := pmCaches.getByAccount(self.Id)
 	if err != nil {
 		return nil, errors.Wrapf(err, "get project mapping cache")
 	}
@@ -218,17 +175,7 @@ func (self *SCloudaccount) GetProjectMapping() (*sProjectMapping, error) {
 }
 
 func (self *SCloudprovider) GetProjectMapping() (*sProjectMapping, error) {
-	cache, err := func() (*pmCache, error) {
-		mp, ok := pmCaches[self.Id]
-		if ok {
-			return mp, nil
-		}
-		err := refreshPmCaches()
-		if err != nil {
-			return nil, errors.Wrapf(err, "refreshPmCaches")
-		}
-		return pmCaches[self.Id], nil
-	}()
+	cache, err := pmCaches.getByProvider(self.Id)
 	if err != nil {
 		return nil, errors.Wrapf(err, "get project mapping cache")
 	}
@@ -442,7 +389,7 @@ func (self *SCloudprovider) getPassword() (string, error) {
 		}
 		return account.getPassword()
 	}
-	return utils.DescryptAESBase64(self.Id, self.Secret)
+	return decryptCloudSecret(context.Background(), self.Id, self.Secret)
 }
 
 func getTenant(ctx context.Context, projectId string, name string) (*db.STenant, error) {
@@ -490,7 +437,8 @@ func (self *SCloudaccount) getOrCreateTenant(ctx context.Context, name, domainId
 		return createTenant(ctx, name, domainId, desc)
 	}
 	share := self.GetSharedInfo()
-	if tenant.DomainId == self.DomainId || (share.PublicScope == rbacscope.ScopeSystem ||
+	// 指定域内已创建的项目直接复用, 避免每次同步重复创建
+	if tenant.DomainId == self.DomainId || tenant.DomainId == domainId || (share.PublicScope == rbacscope.ScopeSystem ||
 		(share.PublicScope == rbacscope.ScopeDomain && utils.IsInStringArray(tenant.DomainId, share.SharedDomains))) {
 		return tenant.DomainId, tenant.Id, nil
 	}
@@ -924,28 +872,47 @@ func (self *SCloudprovider) GetProvider(ctx context.Context) (cloudprovider.IClo
 		return nil, errors.Wrapf(err, "GetCloudaccount")
 	}
 	defaultRegion, _ := jsonutils.Marshal(account.Options).GetString("default_region")
-	return cloudprovider.GetProvider(cloudprovider.ProviderConfig{
-		Id:        self.Id,
-		Name:      self.Name,
-		Vendor:    self.Provider,
-		URL:       accessUrl,
-		Account:   self.Account,
-		Secret:    passwd,
-		ProxyFunc: account.proxyFunc(),
-
-		AliyunResourceGroupIds: options.Options.AliyunResourceGroups,
-
-		ReadOnly: account.ReadOnly,
-
-		DefaultRegion: defaultRegion,
-		Options:       account.Options,
-
-		UpdatePermission: account.UpdatePermission(ctx),
+	version := self.getCredentialVersion(account, accessUrl, defaultRegion)
+	if driver := providerClients.get(self.Id, version, time.Now()); driver != nil {
+		return driver, nil
+	}
+	ttl := getProviderClientCacheTTL()
+	permCtx := ctx
+	if ttl > 0 {
+		// 缓存的客户端会被后续请求复用, 不能绑定当前请求的ctx
+		permCtx = context.Background()
+	}
+	var driver cloudprovider.ICloudProvider
+	err = self.callProviderApi(ctx, "GetProvider", func() error {
+		driver, err = cloudprovider.GetProvider(cloudprovider.ProviderConfig{
+			Id:        self.Id,
+			Name:      self.Name,
+			Vendor:    self.Provider,
+			URL:       accessUrl,
+			Account:   self.Account,
+			Secret:    passwd,
+			ProxyFunc: account.proxyFunc(),
+
+			AliyunResourceGroupIds: options.Options.AliyunResourceGroups,
+
+			ReadOnly: account.ReadOnly,
+
+			DefaultRegion: defaultRegion,
+			Options:       account.Options,
+
+			UpdatePermission: account.UpdatePermission(permCtx),
+		})
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	providerClients.set(self.Id, version, driver, time.Now(), ttl)
+	return driver, nil
 }
 
 func (self *SCloudprovider) savePassword(secret string) error {
-	sec, err := utils.EncryptAESBase64(self.Id, secret)
+	sec, err := encryptCloudSecret(context.Background(), CloudproviderManager.KeywordPlural(), self.Id, secret)
 	if err != nil {
 		return err
 	}
@@ -1006,25 +973,6 @@ func (manager *SCloudproviderManager) FetchCloudproviderByIdOrName(providerId st
 	return providerObj.(*SCloudprovider)
 }
 
-func (self *SCloudprovider) getUsage() api.SCloudproviderUsage {
-	usage := api.SCloudproviderUsage{}
-
-	usage.GuestCount, _ = self.GetGuestCount()
-	usage.HostCount, _ = self.GetHostCount()
-	usage.VpcCount, _ = self.getVpcCount()
-	usage.StorageCount, _ = self.getStorageCount()
-	usage.StorageCacheCount, _ = self.getStoragecacheCount()
-	usage.EipCount, _ = self.getEipCount()
-	usage.SnapshotCount, _ = self.getSnapshotCount()
-	usage.LoadbalancerCount, _ = self.getLoadbalancerCount()
-	usage.DBInstanceCount, _ = self.getDBInstanceCount()
-	usage.ElasticcacheCount, _ = self.getElasticcacheCount()
-	usage.ProjectCount, _ = self.getExternalProjectCount()
-	usage.SyncRegionCount, _ = self.getSyncRegionCount()
-
-	return usage
-}
-
 func (self *SCloudprovider) getProject(ctx context.Context) *db.STenant {
 	proj, _ := db.TenantCacheManager.FetchTenantById(ctx, self.ProjectId)
 	return proj
@@ -1044,22 +992,43 @@ func (manager *SCloudproviderManager) FetchCustomizeColumns(
 	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
 	pmRows := manager.SProjectMappingResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
 	accountIds := make([]string, len(objs))
+	providerIds := make([]string, len(objs))
+	for i := range objs {
+		providerIds[i] = objs[i].(*SCloudprovider).Id
+	}
+	usages := map[string]api.SCloudproviderUsage{}
+	if len(fields) == 0 || fields.ContainsAny(cloudproviderUsageFields...) {
+		var err error
+		usages, err = manager.fetchUsages(providerIds)
+		if err != nil {
+			log.Errorf("fetchUsages fail %s", err)
+		}
+	}
 	for i := range rows {
 		provider := objs[i].(*SCloudprovider)
+		if len(fields) == 0 || fields.Contains("secret") {
+			provider.Secret = exportCloudSecret(ctx, provider.Id, provider.Secret)
+		}
 		accountIds[i] = provider.CloudaccountId
 		rows[i] = api.CloudproviderDetails{
 			EnabledStatusStandaloneResourceDetails: stdRows[i],
 			ProjectizedResourceInfo:                projRows[i],
-			SCloudproviderUsage:                    provider.getUsage(),
+			SCloudproviderUsage:                    usages[provider.Id],
 			SyncStatus2:                            provider.getSyncStatus2(),
 			ProjectMappingResourceInfo:             pmRows[i],
 		}
-		capabilities, _ := CloudproviderCapabilityManager.getCapabilities(provider.Id)
-		if len(capabilities) > 0 {
-			rows[i].Capabilities = capabilities
+		if len(fields) == 0 || fields.Contains("capabilities") {
+			capabilities, _ := CloudproviderCapabilityManager.getCapabilities(provider.Id)
+			if len(capabilities) > 0 {
+				rows[i].Capabilities = capabilities
+			}
 		}
 	}
 
+	if len(fields) > 0 && !fields.ContainsAny("cloudaccount", "read_only", "brand", "proxy_setting") {
+		return rows
+	}
+
 	accounts := make(map[string]SCloudaccount)
 	err := db.FetchStandaloneObjectsByIds(CloudaccountManager, accountIds, &accounts)
 	if err != nil {
@@ -1068,19 +1037,21 @@ func (manager *SCloudproviderManager) FetchCustomizeColumns(
 		return rows
 	}
 
-	proxySettingIds := make([]string, len(accounts))
-	for i := range accounts {
-		proxySettingId := accounts[i].ProxySettingId
-		if !utils.IsInStringArray(proxySettingId, proxySettingIds) {
-			proxySettingIds = append(proxySettingIds, proxySettingId)
-		}
-	}
 	proxySettings := make(map[string]proxy.SProxySetting)
-	err = db.FetchStandaloneObjectsByIds(proxy.ProxySettingManager, proxySettingIds, &proxySettings)
-	if err != nil {
-		log.Errorf("FetchStandaloneObjectsByIds (%s) fail %s",
-			proxy.ProxySettingManager.KeywordPlural(), err)
-		return rows
+	if len(fields) == 0 || fields.Contains("proxy_setting") {
+		proxySettingIds := make([]string, len(accounts))
+		for i := range accounts {
+			proxySettingId := accounts[i].ProxySettingId
+			if !utils.IsInStringArray(proxySettingId, proxySettingIds) {
+				proxySettingIds = append(proxySettingIds, proxySettingId)
+			}
+		}
+		err = db.FetchStandaloneObjectsByIds(proxy.ProxySettingManager, proxySettingIds, &proxySettings)
+		if err != nil {
+			log.Errorf("FetchStandaloneObjectsByIds (%s) fail %s",
+				proxy.ProxySettingManager.KeywordPlural(), err)
+			return rows
+		}
 	}
 
 	for i := range rows {
@@ -1340,6 +1311,9 @@ func (provider *SCloudprovider) markProviderDisconnected(ctx context.Context, us
 	if err != nil {
 		return err
 	}
+	if provider.Status != api.CLOUD_PROVIDER_DISCONNECTED {
+		ProviderAlertRuleManager.fire(ctx, userCred, api.PROVIDER_ALERT_RULE_TYPE_DISCONNECTED, provider.DomainId, provider, reason, 0)
+	}
 	provider.SetStatus(userCred, api.CLOUD_PROVIDER_DISCONNECTED, reason)
 	return provider.ClearSchedDescCache()
 }
@@ -1429,7 +1403,7 @@ func (provider *SCloudprovider) syncCloudproviderRegions(ctx context.Context, us
 			if wg != nil {
 				wg.Add(1)
 			}
-			cprs[i].submitSyncTask(ctx, userCred, syncRange)
+			cprs[i].submitSyncTask(ctx, userCred, provider, syncRange)
 			if wg != nil {
 				wg.Done()
 			}
@@ -1468,10 +1442,9 @@ func (self *SCloudprovider) Delete(ctx context.Context, userCred mcclient.TokenC
 	return nil
 }
 
-func (self *SCloudprovider) RealDelete(ctx context.Context, userCred mcclient.TokenCredential) error {
-	var err error
-
-	for _, manager := range []IPurgeableManager{
+// 删除子订阅时按顺序清理的本地资源
+func getPurgeableManagers() []IPurgeableManager {
+	return []IPurgeableManager{
 		BucketManager,
 		HostManager,
 		SnapshotManager,
@@ -1510,7 +1483,16 @@ func (self *SCloudprovider) RealDelete(ctx context.Context, userCred mcclient.To
 		CloudregionManager,
 		CloudproviderQuotaManager,
 		ModelartsPoolManager,
-	} {
+		CloudBillItemManager,
+		CloudCommitmentManager,
+		VmwarePoolMappingManager,
+	}
+}
+
+func (self *SCloudprovider) RealDelete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	var err error
+
+	for _, manager := range getPurgeableManagers() {
 		err = manager.purgeAll(ctx, userCred, self.Id)
 		if err != nil {
 			return errors.Wrapf(err, "purge %s", manager.Keyword())
@@ -1524,15 +1506,37 @@ func (self *SCloudprovider) RealDelete(ctx context.Context, userCred mcclient.To
 		return errors.Wrapf(err, "remove dns caches")
 	}
 
-	return self.SEnabledStatusStandaloneResourceBase.Delete(ctx, userCred)
+	pmCaches.invalidate()
+	providerCircuits.reset(self.Id)
+	providerClients.invalidate(self.Id)
+	err = self.SEnabledStatusStandaloneResourceBase.Delete(ctx, userCred)
+	if err != nil {
+		return err
+	}
+	removeCloudSecret(ctx, self.Secret)
+	return nil
 }
 
 func (self *SCloudprovider) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
-	return self.StartCloudproviderDeleteTask(ctx, userCred, "")
+	input := api.CloudproviderDeleteInput{}
+	if data != nil {
+		data.Unmarshal(&input)
+	}
+	approval, err := OperationApprovalManager.requireApproval(ctx, userCred, self.DomainId, api.OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER, CloudproviderManager.Keyword(), []string{self.Id}, jsonutils.Marshal(input))
+	if err != nil {
+		return err
+	}
+	if approval != nil {
+		return httperrors.NewNotAcceptableError("deleting cloudprovider %s requires approval, approval request %s is pending", self.Name, approval.Id)
+	}
+	return self.StartCloudproviderDeleteTask(ctx, userCred, input.Archive, "")
 }
 
-func (self *SCloudprovider) StartCloudproviderDeleteTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+func (self *SCloudprovider) StartCloudproviderDeleteTask(ctx context.Context, userCred mcclient.TokenCredential, archive bool, parentTaskId string) error {
 	params := jsonutils.NewDict()
+	if archive {
+		params.Set("archive", jsonutils.JSONTrue)
+	}
 	task, err := taskman.TaskManager.NewTask(ctx, "CloudProviderDeleteTask", self, userCred, params, parentTaskId, "", nil)
 	if err != nil {
 		return errors.Wrapf(err, "NewTask")
@@ -1590,6 +1594,7 @@ func (self *SCloudprovider) PerformDisable(ctx context.Context, userCred mcclien
 	if err != nil {
 		return nil, err
 	}
+	providerClients.invalidate(self.Id)
 	account, err := self.GetCloudaccount()
 	if err != nil {
 		return nil, err
@@ -2034,7 +2039,8 @@ func (self *SCloudprovider) PerformProjectMapping(ctx context.Context, userCred
 	if err != nil {
 		return nil, err
 	}
-	return nil, refreshPmCaches()
+	pmCaches.invalidate()
+	return nil, nil
 }
 
 func (self *SCloudprovider) PerformSetSyncing(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.CloudproviderSync) (jsonutils.JSONObject, error) {
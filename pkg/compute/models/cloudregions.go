@@ -73,6 +73,13 @@ type SCloudregion struct {
 	// 云平台
 	// example: Huawei
 	Provider string `width:"64" charset:"ascii" list:"user" nullable:"false" default:"OneCloud"`
+
+	// 主机套餐最近同步成功时间
+	ServerSkuSyncedAt time.Time `nullable:"true" list:"user"`
+	// 缓存套餐最近同步成功时间
+	ElasticcacheSkuSyncedAt time.Time `nullable:"true" list:"user"`
+	// 数据库套餐最近同步成功时间
+	DBInstanceSkuSyncedAt time.Time `nullable:"true" list:"user"`
 }
 
 func (self *SCloudregion) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
@@ -1112,6 +1119,14 @@ func (manager *SCloudregionManager) PerformSyncSkus(ctx context.Context, userCre
 	return PerformActionSyncSkus(ctx, userCred, input.Resource, input.SkuSyncInput)
 }
 
+func (self *SCloudregion) PerformSyncRegionSkus(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.CloudregionSyncRegionSkusInput) (jsonutils.JSONObject, error) {
+	return self.performSyncSkus(ctx, userCred, input.Resources)
+}
+
+func (manager *SCloudregionManager) GetPropertySkuFreshness(ctx context.Context, userCred mcclient.TokenCredential, input api.CloudregionSkuFreshnessInput) (*api.CloudregionSkuFreshnessOutput, error) {
+	return GetPropertySkuFreshness(ctx, userCred, input)
+}
+
 func (manager *SCloudregionManager) GetPropertySyncTasks(ctx context.Context, userCred mcclient.TokenCredential, query api.SkuTaskQueryInput) (jsonutils.JSONObject, error) {
 	return GetPropertySkusSyncTasks(ctx, userCred, query)
 }
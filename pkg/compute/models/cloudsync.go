@@ -2166,7 +2166,7 @@ func SyncCloudProject(userCred mcclient.TokenCredential, model db.IVirtualModel,
 					domainId, projectId, newProj, isMatch := rule.IsMatchTags(extTags)
 					if isMatch {
 						if len(newProj) > 0 {
-							domainId, projectId, err = account.getOrCreateTenant(context.TODO(), newProj, "", "", "auto create from tag")
+							domainId, projectId, err = account.getOrCreateTenant(context.TODO(), newProj, rule.AutoCreateDomainId, "", "auto create from tag")
 							if err != nil {
 								return nil, errors.Wrapf(err, "getOrCreateTenant(%s)", newProj)
 							}
@@ -2183,6 +2183,14 @@ func SyncCloudProject(userCred mcclient.TokenCredential, model db.IVirtualModel,
 	if err != nil {
 		log.Errorf("try sync project for %s %s by tags error: %v", model.Keyword(), model.GetName(), err)
 	}
+	if newOwnerId == nil {
+		ownerId, err := VmwarePoolMappingManager.fetchOwnerId(managerId, extModel)
+		if err != nil {
+			log.Errorf("try sync project for %s %s by vmware pool mapping error: %v", model.Keyword(), model.GetName(), err)
+		} else if ownerId != nil {
+			newOwnerId = ownerId
+		}
+	}
 	if extProjectId := extModel.GetProjectId(); len(extProjectId) > 0 && newOwnerId == nil {
 		extProject, err := ExternalProjectManager.GetProject(extProjectId, managerId)
 		if err != nil {
@@ -2198,6 +2206,7 @@ func SyncCloudProject(userCred mcclient.TokenCredential, model db.IVirtualModel,
 		newOwnerId = userCred
 	}
 	model.SyncCloudProjectId(userCred, newOwnerId)
+	checkTagPolicyCompliance(context.TODO(), userCred, model)
 }
 
 func SyncCloudDomain(userCred mcclient.TokenCredential, model db.IDomainLevelModel, syncOwnerId mcclient.IIdentityProvider) {
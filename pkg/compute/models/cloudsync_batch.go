@@ -0,0 +1,69 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/compute/options"
+)
+
+func getCloudSyncBatchSize() int {
+	if options.Options.CloudSyncBatchSize > 0 {
+		return options.Options.CloudSyncBatchSize
+	}
+	return 1
+}
+
+// 批量写入同步新建的资源, 每批在名称锁内生成名称并插入, 错误记录在与 objs 一一对应的 errs 中
+// errs[i] 非空的记录将被跳过, setName 为第i条记录设置名称, reserved 为本批次已分配的名称
+func insertSyncedModels(ctx context.Context, manager db.IModelManager, objs []db.IModel, errs []error, setName func(i int, reserved map[string]bool) error) {
+	batchSize := getCloudSyncBatchSize()
+	for start := 0; start < len(objs); start += batchSize {
+		end := start + batchSize
+		if end > len(objs) {
+			end = len(objs)
+		}
+		func() {
+			lockman.LockRawObject(ctx, manager.Keyword(), "name")
+			defer lockman.ReleaseRawObject(ctx, manager.Keyword(), "name")
+
+			reserved := make(map[string]bool)
+			pending := make([]db.IModel, 0, end-start)
+			index := make([]int, 0, end-start)
+			for i := start; i < end; i++ {
+				if errs[i] != nil {
+					continue
+				}
+				err := setName(i, reserved)
+				if err != nil {
+					errs[i] = errors.Wrapf(err, "GenerateName")
+					continue
+				}
+				pending = append(pending, objs[i])
+				index = append(index, i)
+			}
+			for i, err := range db.InsertBatch(ctx, manager, pending, batchSize) {
+				if err != nil {
+					errs[index[i]] = errors.Wrapf(err, "Insert")
+				}
+			}
+		}()
+	}
+}
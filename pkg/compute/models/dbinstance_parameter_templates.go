@@ -0,0 +1,367 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-model-singular=dbinstance_parameter_template
+// +onecloud:swagger-gen-model-plural=dbinstance_parameter_templates
+type SDBInstanceParameterTemplateManager struct {
+	db.SSharableVirtualResourceBaseManager
+}
+
+var DBInstanceParameterTemplateManager *SDBInstanceParameterTemplateManager
+
+func init() {
+	DBInstanceParameterTemplateManager = &SDBInstanceParameterTemplateManager{
+		SSharableVirtualResourceBaseManager: db.NewSharableVirtualResourceBaseManager(
+			SDBInstanceParameterTemplate{},
+			"dbinstance_parameter_templates_tbl",
+			"dbinstance_parameter_template",
+			"dbinstance_parameter_templates",
+		),
+	}
+	DBInstanceParameterTemplateManager.SetVirtualObject(DBInstanceParameterTemplateManager)
+}
+
+// 数据库参数模板, 以引擎原生参数名描述参数, 创建实例时按平台转换后应用
+type SDBInstanceParameterTemplate struct {
+	db.SSharableVirtualResourceBase
+
+	// 引擎
+	// example: MySQL
+	Engine string `width:"16" charset:"ascii" nullable:"false" list:"user" create:"required"`
+	// 引擎版本, 为空时适用于所有版本
+	EngineVersion string `width:"64" charset:"ascii" nullable:"true" list:"user" create:"optional"`
+
+	// 与平台无关的参数列表
+	Parameters *api.DBParameterTemplateParameters `nullable:"true" list:"user" update:"user" create:"required"`
+}
+
+// 各平台参数名称与引擎原生名称不一致的部分, 未列出的参数按原名称下发
+var dbParameterAliases = map[string]map[string]map[string]string{
+	api.CLOUD_PROVIDER_ALIYUN: {
+		api.DBINSTANCE_TYPE_MYSQL: {
+			"max_statement_time":  "loose_max_statement_time",
+			"thread_pool_enabled": "loose_thread_pool_enabled",
+		},
+	},
+}
+
+// 获取模板参数在指定平台上的参数名称
+func getDBParameterProviderKey(provider, engine, key string) string {
+	for e, aliases := range dbParameterAliases[provider] {
+		if strings.EqualFold(e, engine) {
+			if alias, ok := aliases[key]; ok {
+				return alias
+			}
+			break
+		}
+	}
+	return key
+}
+
+// 将模板参数转换为指定平台上的参数
+func translateDBParameters(provider, engine string, params map[string]string) map[string]string {
+	ret := map[string]string{}
+	for k, v := range params {
+		ret[getDBParameterProviderKey(provider, engine, k)] = v
+	}
+	return ret
+}
+
+// 对比模板参数与实例上已同步的参数, 参数值忽略大小写及首尾空白
+func diffDBParameters(provider, engine string, expected map[string]string, actual []SDBInstanceParameter) []api.DBInstanceParameterDrift {
+	current := map[string]string{}
+	for i := range actual {
+		current[actual[i].Key] = actual[i].Value
+	}
+	keys := []string{}
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ret := []api.DBInstanceParameterDrift{}
+	for _, k := range keys {
+		drift := api.DBInstanceParameterDrift{
+			Key:         k,
+			ProviderKey: getDBParameterProviderKey(provider, engine, k),
+			Expected:    expected[k],
+		}
+		value, ok := current[drift.ProviderKey]
+		if !ok {
+			drift.Missing = true
+			ret = append(ret, drift)
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(drift.Expected)) {
+			drift.Actual = value
+			ret = append(ret, drift)
+		}
+	}
+	return ret
+}
+
+func validateDBParameterTemplateParameters(params api.DBParameterTemplateParameters) error {
+	if len(params) == 0 {
+		return httperrors.NewMissingParameterError("parameters")
+	}
+	for k := range params {
+		if len(strings.TrimSpace(k)) == 0 {
+			return httperrors.NewInputParameterError("empty parameter name")
+		}
+	}
+	return nil
+}
+
+func (manager *SDBInstanceParameterTemplateManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.DBInstanceParameterTemplateCreateInput,
+) (api.DBInstanceParameterTemplateCreateInput, error) {
+	if len(input.Engine) == 0 {
+		return input, httperrors.NewMissingParameterError("engine")
+	}
+	err := validateDBParameterTemplateParameters(input.Parameters)
+	if err != nil {
+		return input, err
+	}
+	input.SharableVirtualResourceCreateInput, err = manager.SSharableVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.SharableVirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	input.Status = api.DBINSTANCE_PARAMETER_TEMPLATE_STATUS_AVAILABLE
+	return input, nil
+}
+
+func (self *SDBInstanceParameterTemplate) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.DBInstanceParameterTemplateUpdateInput,
+) (api.DBInstanceParameterTemplateUpdateInput, error) {
+	if input.Parameters != nil {
+		err := validateDBParameterTemplateParameters(input.Parameters)
+		if err != nil {
+			return input, err
+		}
+	}
+	var err error
+	input.SharableVirtualResourceBaseUpdateInput, err = self.SSharableVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.SharableVirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SDBInstanceParameterTemplate) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := self.GetDBInstanceQuery().CountWithError()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("parameter template %s is used by %d dbinstances", self.Name, cnt)
+	}
+	return self.SSharableVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (self *SDBInstanceParameterTemplate) getParameters() map[string]string {
+	if self.Parameters == nil {
+		return map[string]string{}
+	}
+	return *self.Parameters
+}
+
+// 检查模板能否应用到指定引擎的实例
+func (self *SDBInstanceParameterTemplate) isMatchEngine(engine, version string) bool {
+	if !strings.EqualFold(self.Engine, engine) {
+		return false
+	}
+	return len(self.EngineVersion) == 0 || self.EngineVersion == version
+}
+
+func (self *SDBInstanceParameterTemplate) GetDBInstanceQuery() *sqlchemy.SQuery {
+	return DBInstanceManager.Query().Equals("parameter_template_id", self.Id)
+}
+
+func (self *SDBInstanceParameterTemplate) GetDBInstances() ([]SDBInstance, error) {
+	instances := []SDBInstance{}
+	err := db.FetchModelObjects(DBInstanceManager, self.GetDBInstanceQuery(), &instances)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.FetchModelObjects")
+	}
+	return instances, nil
+}
+
+// 列出使用此模板的实例中参数与模板不一致的部分
+func (self *SDBInstanceParameterTemplate) GetDetailsDrift(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) ([]api.DBInstanceParameterTemplateDrift, error) {
+	instances, err := self.GetDBInstances()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	ret := []api.DBInstanceParameterTemplateDrift{}
+	for i := range instances {
+		drifts, err := instances[i].getParameterDrifts(self)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		if len(drifts) > 0 {
+			ret = append(ret, api.DBInstanceParameterTemplateDrift{
+				DBInstanceId: instances[i].Id,
+				DBInstance:   instances[i].Name,
+				Drifts:       drifts,
+			})
+		}
+	}
+	return ret, nil
+}
+
+func (self *SDBInstance) GetParameterTemplate() (*SDBInstanceParameterTemplate, error) {
+	template, err := DBInstanceParameterTemplateManager.FetchById(self.ParameterTemplateId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchById(%s)", self.ParameterTemplateId)
+	}
+	return template.(*SDBInstanceParameterTemplate), nil
+}
+
+// 按实例所在平台转换后的模板参数
+func (self *SDBInstance) GetTemplateParameters(template *SDBInstanceParameterTemplate) (map[string]string, error) {
+	region, err := self.GetRegion()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetRegion")
+	}
+	return translateDBParameters(region.Provider, self.Engine, template.getParameters()), nil
+}
+
+func (self *SDBInstance) getParameterDrifts(template *SDBInstanceParameterTemplate) ([]api.DBInstanceParameterDrift, error) {
+	region, err := self.GetRegion()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetRegion")
+	}
+	params, err := self.GetDBInstanceParameters()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetDBInstanceParameters")
+	}
+	return diffDBParameters(region.Provider, self.Engine, template.getParameters(), params), nil
+}
+
+// 实例参数与参数模板的差异, 实例参数来自最近一次同步
+func (self *SDBInstance) GetDetailsParameterDrift(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) ([]api.DBInstanceParameterDrift, error) {
+	if len(self.ParameterTemplateId) == 0 {
+		return nil, httperrors.NewBadRequestError("dbinstance %s not use any parameter template", self.Name)
+	}
+	template, err := self.GetParameterTemplate()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	drifts, err := self.getParameterDrifts(template)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return drifts, nil
+}
+
+// 数据库参数模板列表
+func (manager *SDBInstanceParameterTemplateManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.DBInstanceParameterTemplateListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, input.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.ListItemFilter")
+	}
+	if len(input.Engine) > 0 {
+		q = q.In("engine", input.Engine)
+	}
+	return q, nil
+}
+
+func (manager *SDBInstanceParameterTemplateManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.DBInstanceParameterTemplateListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SDBInstanceParameterTemplateManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SDBInstanceParameterTemplateManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.DBInstanceParameterTemplateDetails {
+	rows := make([]api.DBInstanceParameterTemplateDetails, len(objs))
+	virtRows := manager.SSharableVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	templateIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.DBInstanceParameterTemplateDetails{
+			SharableVirtualResourceDetails: virtRows[i],
+		}
+		templateIds[i] = objs[i].(*SDBInstanceParameterTemplate).Id
+	}
+
+	q := DBInstanceManager.Query().In("parameter_template_id", templateIds)
+	q = q.AppendField(q.Field("parameter_template_id"), sqlchemy.COUNT("instance_count")).GroupBy(q.Field("parameter_template_id"))
+	counts := []struct {
+		ParameterTemplateId string
+		InstanceCount       int
+	}{}
+	err := q.All(&counts)
+	if err != nil {
+		return rows
+	}
+	countMap := map[string]int{}
+	for _, cnt := range counts {
+		countMap[cnt.ParameterTemplateId] = cnt.InstanceCount
+	}
+	for i := range rows {
+		rows[i].DBInstanceCount = countMap[templateIds[i]]
+	}
+	return rows
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestDiffDBParameters(t *testing.T) {
+	actual := []SDBInstanceParameter{
+		{Key: "max_connections", Value: "2000"},
+		{Key: "character_set_server", Value: "UTF8MB4"},
+		{Key: "loose_max_statement_time", Value: "0"},
+	}
+	cases := []struct {
+		name     string
+		provider string
+		expected map[string]string
+		want     []api.DBInstanceParameterDrift
+	}{
+		{
+			name:     "match ignore case",
+			provider: api.CLOUD_PROVIDER_HUAWEI,
+			expected: map[string]string{"max_connections": "2000", "character_set_server": "utf8mb4"},
+			want:     []api.DBInstanceParameterDrift{},
+		},
+		{
+			name:     "changed and missing",
+			provider: api.CLOUD_PROVIDER_HUAWEI,
+			expected: map[string]string{"max_connections": "1000", "max_statement_time": "10"},
+			want: []api.DBInstanceParameterDrift{
+				{Key: "max_connections", ProviderKey: "max_connections", Expected: "1000", Actual: "2000"},
+				{Key: "max_statement_time", ProviderKey: "max_statement_time", Expected: "10", Missing: true},
+			},
+		},
+		{
+			name:     "translated by provider",
+			provider: api.CLOUD_PROVIDER_ALIYUN,
+			expected: map[string]string{"max_statement_time": "10"},
+			want: []api.DBInstanceParameterDrift{
+				{Key: "max_statement_time", ProviderKey: "loose_max_statement_time", Expected: "10", Actual: "0"},
+			},
+		},
+	}
+	for _, c := range cases {
+		got := diffDBParameters(c.provider, "mysql", c.expected, actual)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+}
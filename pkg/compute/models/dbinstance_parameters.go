@@ -32,6 +32,12 @@ import (
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
 )
 
+// 支持修改参数的云上实例
+// ICloudDBInstance 未定义参数修改接口, 平台实现该接口后即可通过 update-parameters 修改参数
+type ICloudDBInstanceParameterUpdater interface {
+	UpdateParameters(params map[string]string) error
+}
+
 type SDBInstanceParameterManager struct {
 	db.SStandaloneResourceBaseManager
 	db.SExternalizedResourceBaseManager
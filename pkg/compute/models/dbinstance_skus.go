@@ -532,6 +532,9 @@ func (manager *SDBInstanceSkuManager) SyncDBInstanceSkus(ctx context.Context, us
 			syncResult.Add()
 		}
 	}
+	if !syncResult.IsError() {
+		region.markSkuSynced(manager.Keyword())
+	}
 	return syncResult
 }
 
@@ -144,6 +144,9 @@ type SDBInstance struct {
 
 	// 从备份创建新实例
 	DBInstancebackupId string `width:"36" name:"dbinstancebackup_id" charset:"ascii" nullable:"false" create:"optional"`
+
+	// 参数模板Id
+	ParameterTemplateId string `width:"36" charset:"ascii" nullable:"true" index:"true" list:"user" create:"optional"`
 }
 
 func (manager *SDBInstanceManager) GetContextManagers() [][]db.IModelManager {
@@ -297,6 +300,11 @@ func (manager *SDBInstanceManager) BatchCreateValidateCreateData(ctx context.Con
 }
 
 func (man *SDBInstanceManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.DBInstanceCreateInput) (api.DBInstanceCreateInput, error) {
+	var err error
+	input.Metadata, err = TagPolicyManager.validateCreateTags(ctx, ownerId, input.Metadata)
+	if err != nil {
+		return input, err
+	}
 	if len(input.DBInstancebackupId) > 0 {
 		_backup, err := validators.ValidateModel(userCred, DBInstanceBackupManager, &input.DBInstancebackupId)
 		if err != nil {
@@ -468,6 +476,21 @@ func (man *SDBInstanceManager) ValidateCreateData(ctx context.Context, userCred
 		return input, err
 	}
 
+	if len(input.ParameterTemplateId) > 0 {
+		// 模板参数通过修改参数下发, 不支持修改参数的平台无法应用
+		if !driver.IsSupportDBInstanceParameterUpdate() {
+			return input, httperrors.NewNotSupportedError("%s rds not support apply parameter template", driver.GetProvider())
+		}
+		_template, err := validators.ValidateModel(userCred, DBInstanceParameterTemplateManager, &input.ParameterTemplateId)
+		if err != nil {
+			return input, err
+		}
+		template := _template.(*SDBInstanceParameterTemplate)
+		if !template.isMatchEngine(input.Engine, input.EngineVersion) {
+			return input, httperrors.NewInputParameterError("parameter template %s is for %s %s", template.Name, template.Engine, template.EngineVersion)
+		}
+	}
+
 	quotaKeys := fetchRegionalQuotaKeys(rbacscope.ScopeProject, ownerId, region, cloudprovider)
 	pendingUsage := SRegionQuota{Rds: 1}
 	pendingUsage.SetKeys(quotaKeys)
@@ -1087,6 +1110,15 @@ func (self *SDBInstance) PerformChangeConfig(ctx context.Context, userCred mccli
 		return nil, nil
 	}
 
+	region, err := self.GetRegion()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	err = region.GetDriver().ValidateChangeDBInstanceConfigData(ctx, userCred, self, &input)
+	if err != nil {
+		return nil, err
+	}
+
 	return nil, self.StartDBInstanceChangeConfig(ctx, userCred, jsonutils.Marshal(input).(*jsonutils.JSONDict), "")
 }
 
@@ -1202,6 +1234,47 @@ func (self *SDBInstance) RealDelete(ctx context.Context, userCred mcclient.Token
 	return self.SVirtualResourceBase.Delete(ctx, userCred)
 }
 
+// 修改实例参数
+func (self *SDBInstance) PerformUpdateParameters(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DBInstanceUpdateParametersInput) (jsonutils.JSONObject, error) {
+	if self.Status != api.DBINSTANCE_RUNNING {
+		return nil, httperrors.NewInvalidStatusError("Cannot update parameters in status %s", self.Status)
+	}
+	if len(input.Parameters) == 0 {
+		return nil, httperrors.NewMissingParameterError("parameters")
+	}
+	region, err := self.GetRegion()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if !region.GetDriver().IsSupportDBInstanceParameterUpdate() {
+		return nil, httperrors.NewNotSupportedError("%s dbinstance not support update parameters", region.Provider)
+	}
+	params, err := self.GetDBInstanceParameters()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	keys := map[string]bool{}
+	for i := range params {
+		keys[params[i].Key] = true
+	}
+	for k := range input.Parameters {
+		if !keys[k] {
+			return nil, httperrors.NewInputParameterError("unknown parameter %s", k)
+		}
+	}
+	return nil, self.StartUpdateParametersTask(ctx, userCred, input, "")
+}
+
+func (self *SDBInstance) StartUpdateParametersTask(ctx context.Context, userCred mcclient.TokenCredential, input api.DBInstanceUpdateParametersInput, parentTaskId string) error {
+	self.SetStatus(userCred, api.DBINSTANCE_UPDATE_PARAMETERS, "")
+	task, err := taskman.TaskManager.NewTask(ctx, "DBInstanceUpdateParametersTask", self, userCred, jsonutils.Marshal(input).(*jsonutils.JSONDict), parentTaskId, "", nil)
+	if err != nil {
+		return err
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
 func (self *SDBInstance) GetDBInstanceParameters() ([]SDBInstanceParameter, error) {
 	params := []SDBInstanceParameter{}
 	q := DBInstanceParameterManager.Query().Equals("dbinstance_id", self.Id)
@@ -0,0 +1,83 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+// 用户指定的地址由region服务直接访问, 禁止指向本机、链路本地(如云上元数据服务)及内网地址
+func isGuardedDialAddrAllowed(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() || ip.IsPrivate())
+}
+
+var lookupGuardedDialIPs = net.DefaultResolver.LookupIPAddr
+
+// 连接时解析域名并逐个检查解析出的地址, 直接连接检查通过的地址, 避免域名解析到内网地址或解析结果在校验后变化
+// allow 为空时使用 isGuardedDialAddrAllowed
+func guardedDialContext(ctx context.Context, network, addr string, timeout time.Duration, allow func(ip net.IP) bool) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "SplitHostPort %s", addr)
+	}
+	if allow == nil {
+		allow = isGuardedDialAddrAllowed
+	}
+	ips, err := lookupGuardedDialIPs(ctx, host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "lookup %s", host)
+	}
+	if len(ips) == 0 {
+		return nil, errors.Wrapf(errors.ErrNotFound, "lookup %s", host)
+	}
+	for i := range ips {
+		if !allow(ips[i].IP) {
+			return nil, errors.Wrapf(httperrors.ErrForbidden, "address %s of %s is not allowed", ips[i].IP, host)
+		}
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	var dialErr error
+	for i := range ips {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ips[i].IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		dialErr = err
+	}
+	return nil, dialErr
+}
+
+func newGuardedHttpClient(timeout time.Duration, insecure bool, allow func(ip net.IP) bool) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// 不经过代理, 保证连接的地址即为检查过的地址
+			Proxy: nil,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return guardedDialContext(ctx, network, addr, timeout, allow)
+			},
+			TLSHandshakeTimeout: 10 * time.Second,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: insecure},
+		},
+	}
+}
@@ -63,6 +63,7 @@ type SDiskManager struct {
 	db.SMultiArchResourceBaseManager
 	db.SAutoDeleteResourceBaseManager
 	db.SEncryptedResourceManager
+	SExternalProjectResourceBaseManager
 }
 
 var DiskManager *SDiskManager
@@ -87,6 +88,7 @@ type SDisk struct {
 	SStorageResourceBase `width:"128" charset:"ascii" nullable:"true" list:"admin" create:"optional"`
 	db.SMultiArchResourceBase
 	db.SAutoDeleteResourceBase
+	SExternalProjectResourceBase
 
 	db.SEncryptedResource
 
@@ -169,6 +171,11 @@ func (manager *SDiskManager) ListItemFilter(
 		return nil, errors.Wrap(err, "SExternalizedResourceBaseManager.ListItemFilter")
 	}
 
+	q, err = manager.SExternalProjectResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ExternalProjectFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SExternalProjectResourceBaseManager.ListItemFilter")
+	}
+
 	q, err = manager.SBillingResourceBaseManager.ListItemFilter(ctx, q, userCred, query.BillingResourceListInput)
 	if err != nil {
 		return nil, errors.Wrap(err, "SBillingResourceBaseManager.ListItemFilter")
@@ -492,8 +499,13 @@ func diskCreateInput2ComputeQuotaKeys(input api.DiskCreateInput, ownerId mcclien
 }
 
 func (manager *SDiskManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.DiskCreateInput) (api.DiskCreateInput, error) {
+	var err error
+	input.Metadata, err = TagPolicyManager.validateCreateTags(ctx, ownerId, input.Metadata)
+	if err != nil {
+		return input, err
+	}
 	diskConfig := input.DiskConfig
-	diskConfig, err := parseDiskInfo(ctx, userCred, diskConfig)
+	diskConfig, err = parseDiskInfo(ctx, userCred, diskConfig)
 	if err != nil {
 		return input, err
 	}
@@ -534,6 +546,17 @@ func (manager *SDiskManager) ValidateCreateData(ctx context.Context, userCred mc
 		}
 		input.Storage = storage.Id
 
+		if len(input.ExternalProjectId) > 0 {
+			if provider == nil {
+				return input, httperrors.NewInputParameterError("external project is only supported by managed storage")
+			}
+			providerId := provider.Id
+			input.ExternalProjectId, err = ExternalProjectManager.validateCreateExternalProject(userCred, input.ExternalProjectId, &providerId)
+			if err != nil {
+				return input, err
+			}
+		}
+
 		zone, _ := storage.getZone()
 		quotaKey = fetchComputeQuotaKeys(
 			rbacscope.ScopeProject,
@@ -544,6 +567,16 @@ func (manager *SDiskManager) ValidateCreateData(ctx context.Context, userCred mc
 		)
 
 	} else {
+		if len(diskConfig.StorageTier) > 0 {
+			var storageTypes []string
+			if driver, ok := guestDrivers[input.Hypervisor]; ok {
+				storageTypes = driver.GetStorageTypes()
+			}
+			err = fillDiskConfigByStorageTier(diskConfig, storageTypes, input.PreferZone, input.PreferRegion)
+			if err != nil {
+				return input, err
+			}
+		}
 		if len(diskConfig.Backend) == 0 {
 			diskConfig.Backend = api.STORAGE_LOCAL
 		}
@@ -561,6 +594,10 @@ func (manager *SDiskManager) ValidateCreateData(ctx context.Context, userCred mc
 			}
 			input.PreferManager = manager.Id
 		}
+		input.ExternalProjectId, err = ExternalProjectManager.validateCreateExternalProject(userCred, input.ExternalProjectId, &input.PreferManager)
+		if err != nil {
+			return input, err
+		}
 		serverInput, err := ValidateScheduleCreateData(ctx, userCred, input.ToServerCreateInput(), input.Hypervisor)
 		if err != nil {
 			return input, err
@@ -979,7 +1016,7 @@ func (disk *SDisk) PerformResize(ctx context.Context, userCred mcclient.TokenCre
 	if err != nil {
 		return nil, err
 	}
-	err = disk.doResize(ctx, userCred, sizeMb, guest)
+	err = disk.doResize(ctx, userCred, sizeMb, guest, input)
 	if err != nil {
 		return nil, err
 	}
@@ -1020,7 +1057,7 @@ func (disk *SDisk) GetQuotaKeys() (quotas.IQuotaKeys, error) {
 	), nil
 }
 
-func (disk *SDisk) doResize(ctx context.Context, userCred mcclient.TokenCredential, sizeMb int, guest *SGuest) error {
+func (disk *SDisk) doResize(ctx context.Context, userCred mcclient.TokenCredential, sizeMb int, guest *SGuest, input api.DiskResizeInput) error {
 	if disk.Status != api.DISK_READY {
 		return httperrors.NewResourceNotReadyError("Resize disk when disk is READY")
 	}
@@ -1047,8 +1084,15 @@ func (disk *SDisk) doResize(ctx context.Context, userCred mcclient.TokenCredenti
 			return httperrors.NewOutOfResourceError("Not enough free space")
 		}
 	}
+	offline := false
 	if guest != nil {
-		if err := guest.ValidateResizeDisk(disk, storage); err != nil {
+		if guest.Status == api.VM_RUNNING && !guest.GetDriver().IsSupportLiveResizeDisk(guest, disk, storage) {
+			if !input.AllowOffline {
+				return httperrors.NewUnsupportOperationError("%s server not support live resize this disk, set allow_offline to resize after stopping server", guest.Hypervisor)
+			}
+			offline = true
+		}
+		if err := guest.validateResizeDisk(disk, storage, offline); err != nil {
 			return httperrors.NewInputParameterError("%v", err)
 		}
 	}
@@ -1064,7 +1108,7 @@ func (disk *SDisk) doResize(ctx context.Context, userCred mcclient.TokenCredenti
 	}
 
 	if guest != nil {
-		return guest.StartGuestDiskResizeTask(ctx, userCred, disk.Id, int64(sizeMb), "", &pendingUsage)
+		return guest.startGuestDiskResizeTask(ctx, userCred, disk.Id, int64(sizeMb), input.GrowFs, offline, "", &pendingUsage)
 	} else {
 		return disk.StartDiskResizeTask(ctx, userCred, int64(sizeMb), "", &pendingUsage)
 	}
@@ -1262,6 +1306,12 @@ func (self *SDisk) validateDeleteCondition(ctx context.Context, isPurge bool) er
 	if !isPurge && self.IsNotDeletablePrePaid() {
 		return httperrors.NewForbiddenError("not allow to delete prepaid disk in valid status")
 	}
+	if !isPurge {
+		err := self.validateDeleteLock()
+		if err != nil {
+			return err
+		}
+	}
 	return self.SVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
 }
 
@@ -1509,6 +1559,7 @@ func (manager *SDiskManager) SyncDisks(ctx context.Context, userCred mcclient.To
 		syncResult.Update()
 	}
 
+	newDisks := make([]cloudprovider.ICloudDisk, 0)
 	for i := 0; i < len(added); i += 1 {
 		skip, key := IsNeedSkipSync(added[i])
 		if skip {
@@ -1536,12 +1587,16 @@ func (manager *SDiskManager) SyncDisks(ctx context.Context, userCred mcclient.To
 			}
 			continue
 		}
-		new, err := manager.newFromCloudDisk(ctx, userCred, provider, added[i], storage, -1, syncOwnerId)
-		if err != nil {
-			syncResult.AddError(err)
+		newDisks = append(newDisks, added[i])
+	}
+
+	created, errs := manager.newFromCloudDisks(ctx, userCred, provider, newDisks, storage, -1, syncOwnerId)
+	for i := range newDisks {
+		if errs[i] != nil {
+			syncResult.AddError(errs[i])
 		} else {
-			localDisks = append(localDisks, *new)
-			remoteDisks = append(remoteDisks, added[i])
+			localDisks = append(localDisks, *created[i])
+			remoteDisks = append(remoteDisks, newDisks[i])
 			syncResult.Add()
 		}
 	}
@@ -1678,6 +1733,7 @@ func (self *SDisk) syncWithCloudDisk(ctx context.Context, userCred mcclient.Toke
 		self.Nonpersistent = extDisk.GetIsNonPersistent()
 
 		self.IsEmulated = extDisk.IsEmulated()
+		self.ExternalProjectId = extDisk.GetProjectId()
 
 		if provider.GetFactory().IsSupportPrepaidResources() && !recycle {
 			if billintType := extDisk.GetBillingType(); len(billintType) > 0 {
@@ -1729,14 +1785,50 @@ func (self *SDisk) syncWithCloudDisk(ctx context.Context, userCred mcclient.Toke
 		SyncCloudProject(userCred, self, syncOwnerId, extDisk, storage.ManagerId)
 	} else {
 		self.SyncCloudProjectId(userCred, guests[0].GetOwnerId())
+		checkTagPolicyCompliance(ctx, userCred, self)
 	}
 
 	return nil
 }
 
 func (manager *SDiskManager) newFromCloudDisk(ctx context.Context, userCred mcclient.TokenCredential, provider cloudprovider.ICloudProvider, extDisk cloudprovider.ICloudDisk, storage *SStorage, index int, syncOwnerId mcclient.IIdentityProvider) (*SDisk, error) {
-	disk := SDisk{}
-	disk.SetModelManager(manager, &disk)
+	disks, errs := manager.newFromCloudDisks(ctx, userCred, provider, []cloudprovider.ICloudDisk{extDisk}, storage, index, syncOwnerId)
+	if errs[0] != nil {
+		return nil, errors.Wrapf(errs[0], "newFromCloudDisk")
+	}
+	return disks[0], nil
+}
+
+func (manager *SDiskManager) newFromCloudDisks(ctx context.Context, userCred mcclient.TokenCredential, provider cloudprovider.ICloudProvider, extDisks []cloudprovider.ICloudDisk, storage *SStorage, index int, syncOwnerId mcclient.IIdentityProvider) ([]*SDisk, []error) {
+	disks := make([]*SDisk, len(extDisks))
+	objs := make([]db.IModel, len(extDisks))
+	errs := make([]error, len(extDisks))
+	for i := range extDisks {
+		disks[i] = manager.newDiskFromCloud(provider, extDisks[i], storage, index)
+		objs[i] = disks[i]
+	}
+
+	insertSyncedModels(ctx, manager, objs, errs, func(i int, reserved map[string]bool) error {
+		newName, err := db.GenerateBatchName(ctx, manager, syncOwnerId, extDisks[i].GetName(), reserved)
+		if err != nil {
+			return err
+		}
+		disks[i].Name = newName
+		return nil
+	})
+
+	for i := range disks {
+		if errs[i] != nil {
+			continue
+		}
+		disks[i].postCreateFromCloud(ctx, userCred, extDisks[i], storage, syncOwnerId)
+	}
+	return disks, errs
+}
+
+func (manager *SDiskManager) newDiskFromCloud(provider cloudprovider.ICloudProvider, extDisk cloudprovider.ICloudDisk, storage *SStorage, index int) *SDisk {
+	disk := &SDisk{}
+	disk.SetModelManager(manager, disk)
 
 	disk.Status = extDisk.GetStatus()
 	disk.ExternalId = extDisk.GetGlobalId()
@@ -1753,6 +1845,7 @@ func (manager *SDiskManager) newFromCloudDisk(ctx context.Context, userCred mccl
 	disk.Nonpersistent = extDisk.GetIsNonPersistent()
 
 	disk.IsEmulated = extDisk.IsEmulated()
+	disk.ExternalProjectId = extDisk.GetProjectId()
 
 	if templateId := extDisk.GetTemplateId(); len(templateId) > 0 {
 		cachedImage, err := db.FetchByExternalId(CachedimageManager, templateId)
@@ -1773,44 +1866,30 @@ func (manager *SDiskManager) newFromCloudDisk(ctx context.Context, userCred mccl
 		disk.CreatedAt = createAt
 	}
 
-	var err = func() error {
-		lockman.LockRawObject(ctx, manager.Keyword(), "name")
-		defer lockman.ReleaseRawObject(ctx, manager.Keyword(), "name")
-
-		newName, err := db.GenerateName(ctx, manager, syncOwnerId, extDisk.GetName())
-		if err != nil {
-			return err
-		}
-		disk.Name = newName
-
-		return manager.TableSpec().Insert(ctx, &disk)
-	}()
-	if err != nil {
-		return nil, errors.Wrapf(err, "newFromCloudDisk")
-	}
+	return disk
+}
 
+func (disk *SDisk) postCreateFromCloud(ctx context.Context, userCred mcclient.TokenCredential, extDisk cloudprovider.ICloudDisk, storage *SStorage, syncOwnerId mcclient.IIdentityProvider) {
 	// create new joint model aboutsnapshotpolicy and disk
 	snapshotpolicies, err := extDisk.GetExtSnapshotPolicyIds()
 	if err != nil {
 		log.Warningln("GetExtSnapshotPolicyIds:", errors.Wrapf(err, "Get snapshot policies of ICloudDisk %s.", extDisk.GetId()))
 	}
-	err = SnapshotPolicyDiskManager.SyncAttachDiskExt(ctx, userCred, snapshotpolicies, syncOwnerId, &disk, storage)
+	err = SnapshotPolicyDiskManager.SyncAttachDiskExt(ctx, userCred, snapshotpolicies, syncOwnerId, disk, storage)
 	if err != nil {
 		log.Warningln("SyncAttachDiskExt:", err)
 	}
 
-	syncVirtualResourceMetadata(ctx, userCred, &disk, extDisk)
+	syncVirtualResourceMetadata(ctx, userCred, disk, extDisk)
 
-	SyncCloudProject(userCred, &disk, syncOwnerId, extDisk, storage.ManagerId)
+	SyncCloudProject(userCred, disk, syncOwnerId, extDisk, storage.ManagerId)
 
-	db.OpsLog.LogEvent(&disk, db.ACT_CREATE, disk.GetShortDesc(ctx), userCred)
+	db.OpsLog.LogEvent(disk, db.ACT_CREATE, disk.GetShortDesc(ctx), userCred)
 
 	notifyclient.EventNotify(ctx, userCred, notifyclient.SEventNotifyParam{
-		Obj:    &disk,
+		Obj:    disk,
 		Action: notifyclient.ActionSyncCreate,
 	})
-
-	return &disk, nil
 }
 
 func totalDiskSize(
@@ -1888,6 +1967,9 @@ func totalDiskSize(
 }
 
 func parseDiskInfo(ctx context.Context, userCred mcclient.TokenCredential, info *api.DiskConfig) (*api.DiskConfig, error) {
+	if err := validateStorageTier(info.StorageTier); err != nil {
+		return nil, err
+	}
 	if info.Storage != "" {
 		if err := fillDiskConfigByStorage(userCred, info, info.Storage); err != nil {
 			return nil, errors.Wrap(err, "fillDiskConfigByStorage")
@@ -2075,6 +2157,9 @@ func fillDiskConfigByStorage(userCred mcclient.TokenCredential,
 	if storage.Status != api.STORAGE_ONLINE {
 		return errors.Wrap(httperrors.ErrInvalidStatus, "storage not online")
 	}
+	if !isStorageTierSatisfied(diskConfig.StorageTier, storage.Tier) {
+		return httperrors.NewInputParameterError("storage %s tier %s not satisfy %s", storage.Name, storage.Tier, diskConfig.StorageTier)
+	}
 	diskConfig.Storage = storage.Id
 	diskConfig.Backend = storage.StorageType
 	return nil
@@ -2199,6 +2284,10 @@ func (self *SDisk) RealDelete(ctx context.Context, userCred mcclient.TokenCreden
 	if err != nil {
 		log.Errorf("unable to DetachAllSnapshotpolicies: %v", err)
 	}
+	err = ResourceLockManager.deleteByResource(ctx, userCred, self.Id)
+	if err != nil {
+		log.Errorf("unable to clean resource locks: %v", err)
+	}
 	return self.SVirtualResourceBase.Delete(ctx, userCred)
 }
 
@@ -2650,16 +2739,21 @@ func (manager *SDiskManager) CleanPendingDeleteDisks(ctx context.Context, userCr
 	}
 }
 
-func (manager *SDiskManager) getAutoSnapshotDisksId(isExternal bool) ([]SSnapshotPolicyDisk, error) {
+// 按照服务时区计算当前的快照策略周几及整点
+func getSnapshotPolicyTimePoint(now time.Time) (uint32, uint32) {
 	tz, _ := time.LoadLocation(options.Options.TimeZone)
-	t := time.Now().In(tz)
+	t := now.In(tz)
 	week := t.Weekday()
 	if week == 0 { // sunday is zero
 		week += 7
 	}
-	timePoint := t.Hour()
+	return uint32(week), uint32(t.Hour())
+}
+
+func (manager *SDiskManager) getAutoSnapshotDisksId(isExternal bool) ([]SSnapshotPolicyDisk, error) {
+	week, timePoint := getSnapshotPolicyTimePoint(time.Now())
 
-	sps, err := SnapshotPolicyManager.GetSnapshotPoliciesAt(uint32(week), uint32(timePoint))
+	sps, err := SnapshotPolicyManager.GetSnapshotPoliciesAt(api.SNAPSHOT_POLICY_RESOURCE_TYPE_DISK, week, timePoint)
 	if err != nil {
 		return nil, err
 	}
@@ -3148,6 +3242,9 @@ func (disk *SDisk) PerformBindSnapshotpolicy(ctx context.Context, userCred mccli
 		return nil, errors.Wrap(err, "db.FetchByIdOrName")
 	}
 	snapshotpolicy := imodel.(*SSnapshotPolicy)
+	if snapshotpolicy.ResourceType == api.SNAPSHOT_POLICY_RESOURCE_TYPE_SERVER {
+		return nil, httperrors.NewUnsupportOperationError("server snapshot policy %s can not bind disks", snapshotpolicy.Name)
+	}
 
 	// try to bind
 	spd, err := SnapshotPolicyDiskManager.newSnapshotpolicyDisk(ctx, userCred, snapshotpolicy, disk)
@@ -0,0 +1,189 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+const dnsFailoverProbeTimeout = 5 * time.Second
+
+// 探测地址由用户指定, 禁止探测本机及内网地址
+var isDnsFailoverProbeAddrAllowed = isGuardedDialAddrAllowed
+
+// 设置故障切换
+func (self *SDnsRecordSet) PerformSetFailover(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DnsRecordSetFailoverInput) (jsonutils.JSONObject, error) {
+	if input.Clear {
+		_, err := db.Update(self, func() error {
+			self.Failover = nil
+			return nil
+		})
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		logclient.AddSimpleActionLog(self, logclient.ACT_DNS_FAILOVER, "clear", userCred, true)
+		return nil, nil
+	}
+	failover := input.SDnsFailover
+	err := failover.Validate(self.DnsType)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Update(self, func() error {
+		self.Failover = &failover
+		self.DnsValue = failover.Endpoints[0].Value
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_DNS_FAILOVER, input, userCred, true)
+	dnsZone, err := self.GetDnsZone()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "GetDnsZone"))
+	}
+	dnsZone.DoSyncRecords(ctx, userCred)
+	return nil, nil
+}
+
+// 探测故障切换记录的各端点健康状态, 当前端点不健康时切换到优先级最高的健康端点
+func (manager *SDnsRecordSetManager) FailoverProbe(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := manager.Query().IsTrue("enabled").IsNotNull("failover")
+	records := []SDnsRecordSet{}
+	err := db.FetchModelObjects(manager, q, &records)
+	if err != nil {
+		log.Errorf("FailoverProbe fetch dns recordsets error: %v", err)
+		return
+	}
+	for i := range records {
+		if records[i].Failover == nil || records[i].Failover.IsZero() {
+			continue
+		}
+		err = records[i].probeFailover(ctx, userCred)
+		if err != nil {
+			log.Errorf("probe dns recordset %s failover error: %v", records[i].Name, err)
+		}
+	}
+}
+
+func (self *SDnsRecordSet) probeFailover(ctx context.Context, userCred mcclient.TokenCredential) error {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	failover := *self.Failover
+	failover.Endpoints = make([]api.SDnsFailoverEndpoint, len(self.Failover.Endpoints))
+	copy(failover.Endpoints, self.Failover.Endpoints)
+
+	var wg sync.WaitGroup
+	for i := range failover.Endpoints {
+		wg.Add(1)
+		go func(ep *api.SDnsFailoverEndpoint) {
+			defer wg.Done()
+			err := probeDnsFailoverEndpoint(&failover, ep.Value)
+			if err != nil {
+				log.Debugf("dns failover endpoint %s probe failed: %v", ep.Value, err)
+			}
+			updateDnsFailoverEndpoint(ep, failover.FailureThreshold, err)
+		}(&failover.Endpoints[i])
+	}
+	wg.Wait()
+
+	oldValue := self.DnsValue
+	active, newValue := getDnsFailoverValue(&failover, oldValue)
+	_, err := db.Update(self, func() error {
+		self.Failover = &failover
+		self.DnsValue = newValue
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	if newValue == oldValue {
+		return nil
+	}
+
+	notes := fmt.Sprintf("failover %s -> %s", oldValue, active.String())
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, notes, userCred)
+	logclient.AddSimpleActionLog(self, logclient.ACT_DNS_FAILOVER, notes, userCred, true)
+	dnsZone, err := self.GetDnsZone()
+	if err != nil {
+		return errors.Wrapf(err, "GetDnsZone")
+	}
+	return dnsZone.DoSyncRecords(ctx, userCred)
+}
+
+// 连续探测失败达到阈值才标记为不健康, 一次探测成功即恢复
+func updateDnsFailoverEndpoint(ep *api.SDnsFailoverEndpoint, threshold int, probeErr error) {
+	ep.LastProbeAt = time.Now().UTC()
+	if probeErr != nil {
+		ep.FailCount += 1
+		if ep.FailCount >= threshold {
+			ep.Healthy = false
+		}
+		return
+	}
+	ep.FailCount = 0
+	ep.Healthy = true
+}
+
+// 返回优先级最高的健康端点及记录值, 全部端点不健康时保持当前记录值
+func getDnsFailoverValue(failover *api.SDnsFailover, current string) (*api.SDnsFailoverEndpoint, string) {
+	active := failover.GetActiveEndpoint()
+	if active == nil {
+		return nil, current
+	}
+	return active, active.Value
+}
+
+func probeDnsFailoverEndpoint(failover *api.SDnsFailover, value string) error {
+	addr := net.JoinHostPort(value, strconv.Itoa(failover.ProbePort))
+	switch failover.ProbeProtocol {
+	case api.DNS_FAILOVER_PROBE_HTTP, api.DNS_FAILOVER_PROBE_HTTPS:
+		url := fmt.Sprintf("%s://%s%s", failover.ProbeProtocol, addr, failover.ProbePath)
+		resp, err := newGuardedHttpClient(dnsFailoverProbeTimeout, true, isDnsFailoverProbeAddrAllowed).Get(url)
+		if err != nil {
+			return errors.Wrapf(err, "GET %s", url)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return errors.Errorf("GET %s status %d", url, resp.StatusCode)
+		}
+		return nil
+	default:
+		conn, err := guardedDialContext(context.Background(), "tcp", addr, dnsFailoverProbeTimeout, isDnsFailoverProbeAddrAllowed)
+		if err != nil {
+			return errors.Wrapf(err, "dial %s", addr)
+		}
+		conn.Close()
+		return nil
+	}
+}
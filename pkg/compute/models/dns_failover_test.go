@@ -0,0 +1,114 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestUpdateDnsFailoverEndpoint(t *testing.T) {
+	ep := &api.SDnsFailoverEndpoint{Value: "10.0.0.1", Healthy: true}
+	probeErr := fmt.Errorf("connection refused")
+	for i := 1; i <= 3; i++ {
+		updateDnsFailoverEndpoint(ep, 3, probeErr)
+		if ep.FailCount != i || ep.Healthy != (i < 3) {
+			t.Fatalf("after %d failures: fail_count %d healthy %v", i, ep.FailCount, ep.Healthy)
+		}
+	}
+	if ep.LastProbeAt.IsZero() {
+		t.Errorf("last_probe_at not recorded")
+	}
+	updateDnsFailoverEndpoint(ep, 3, nil)
+	if ep.FailCount != 0 || !ep.Healthy {
+		t.Errorf("endpoint not recovered: fail_count %d healthy %v", ep.FailCount, ep.Healthy)
+	}
+}
+
+func TestGetDnsFailoverValue(t *testing.T) {
+	failover := &api.SDnsFailover{
+		Endpoints: []api.SDnsFailoverEndpoint{
+			{Value: "10.0.0.1", Priority: 1, Healthy: true},
+			{Value: "10.0.0.2", Priority: 2, Healthy: true},
+		},
+	}
+	if _, value := getDnsFailoverValue(failover, "10.0.0.1"); value != "10.0.0.1" {
+		t.Errorf("want primary endpoint, got %s", value)
+	}
+	failover.Endpoints[0].Healthy = false
+	if active, value := getDnsFailoverValue(failover, "10.0.0.1"); active == nil || value != "10.0.0.2" {
+		t.Errorf("want failover to secondary endpoint, got %s", value)
+	}
+	failover.Endpoints[1].Healthy = false
+	if active, value := getDnsFailoverValue(failover, "10.0.0.2"); active != nil || value != "10.0.0.2" {
+		t.Errorf("want current value kept when all endpoints unhealthy, got %s", value)
+	}
+	failover.Endpoints[0].Healthy = true
+	if _, value := getDnsFailoverValue(failover, "10.0.0.2"); value != "10.0.0.1" {
+		t.Errorf("want failback to primary endpoint, got %s", value)
+	}
+}
+
+func TestProbeDnsFailoverEndpoint(t *testing.T) {
+	allow := isDnsFailoverProbeAddrAllowed
+	defer func() {
+		isDnsFailoverProbeAddrAllowed = allow
+	}()
+
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.Atoi(portStr)
+
+	for _, protocol := range []string{api.DNS_FAILOVER_PROBE_TCP, api.DNS_FAILOVER_PROBE_HTTP} {
+		failover := &api.SDnsFailover{ProbeProtocol: protocol, ProbePort: port, ProbePath: "/health"}
+		if err := probeDnsFailoverEndpoint(failover, host); err == nil {
+			t.Errorf("%s probe loopback endpoint: want rejected", protocol)
+		}
+	}
+
+	// 仅放行测试服务所在的本机地址
+	isDnsFailoverProbeAddrAllowed = func(ip net.IP) bool {
+		return ip.IsLoopback()
+	}
+	for _, protocol := range []string{api.DNS_FAILOVER_PROBE_TCP, api.DNS_FAILOVER_PROBE_HTTP} {
+		failover := &api.SDnsFailover{ProbeProtocol: protocol, ProbePort: port, ProbePath: "/health"}
+		if err := probeDnsFailoverEndpoint(failover, host); err != nil {
+			t.Errorf("%s probe healthy endpoint: %v", protocol, err)
+		}
+	}
+	status = http.StatusServiceUnavailable
+	failover := &api.SDnsFailover{ProbeProtocol: api.DNS_FAILOVER_PROBE_HTTP, ProbePort: port, ProbePath: "/health"}
+	if err := probeDnsFailoverEndpoint(failover, host); err == nil {
+		t.Errorf("want http probe failure on status %d", status)
+	}
+
+	srv.Close()
+	failover = &api.SDnsFailover{ProbeProtocol: api.DNS_FAILOVER_PROBE_TCP, ProbePort: port}
+	if err := probeDnsFailoverEndpoint(failover, host); err == nil {
+		t.Errorf("want tcp probe failure on closed port")
+	}
+}
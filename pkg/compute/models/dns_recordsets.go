@@ -69,6 +69,9 @@ type SDnsRecordSet struct {
 	DnsValue   string `width:"256" charset:"ascii" nullable:"false" list:"user" update:"domain" create:"domain_required"`
 	TTL        int64  `nullable:"false" list:"user" update:"domain" create:"domain_required" json:"ttl"`
 	MxPriority int64  `nullable:"false" list:"user" update:"domain" create:"domain_optional"`
+
+	// 故障切换配置及端点健康状态
+	Failover *api.SDnsFailover `nullable:"true" list:"user" update:"domain" create:"domain_optional"`
 }
 
 func (manager *SDnsRecordSetManager) EnableGenerateName() bool {
@@ -142,6 +145,15 @@ func (manager *SDnsRecordSetManager) ValidateCreateData(ctx context.Context, use
 
 	validateInfo.TrafficPolicies = input.TrafficPolicies
 
+	if input.Failover != nil {
+		err = input.Failover.Validate(input.DnsType)
+		if err != nil {
+			return input, err
+		}
+		// 记录值始终指向优先级最高的端点
+		input.DnsValue = input.Failover.Endpoints[0].Value
+	}
+
 	recordset := api.SDnsRecordSet{}
 	recordset.DnsZoneId = input.DnsZoneId
 	recordset.DnsType = input.DnsType
@@ -512,6 +524,18 @@ func (self *SDnsRecordSet) ValidateUpdateData(ctx context.Context, userCred mccl
 	if input.MxPriority == nil {
 		input.MxPriority = &self.MxPriority
 	}
+	if input.Failover != nil {
+		err = input.Failover.Validate(input.DnsType)
+		if err != nil {
+			return input, err
+		}
+		input.DnsValue = input.Failover.Endpoints[0].Value
+	} else if self.Failover != nil && input.DnsType != self.DnsType {
+		err = self.Failover.Validate(input.DnsType)
+		if err != nil {
+			return input, err
+		}
+	}
 	recordset := api.SDnsRecordSet{}
 	recordset.DnsType = input.DnsType
 	recordset.DnsValue = input.DnsValue
@@ -224,6 +224,10 @@ func (manager *SElasticcacheAccountManager) ValidateCreateData(ctx context.Conte
 		if err != nil {
 			return nil, fmt.Errorf("getting elastic cache instance failed")
 		}
+		err = ec.(*SElasticcache).ValidateManageCapability("create account", func(c api.ElasticcacheManageCapability) bool { return c.Account })
+		if err != nil {
+			return nil, err
+		}
 		region, _ = ec.(*SElasticcache).GetRegion()
 	} else {
 		return nil, httperrors.NewMissingParameterError("elasticcache_id")
@@ -482,8 +486,16 @@ func (self *SElasticcacheAccount) ValidatorResetPasswordData(ctx context.Context
 }
 
 func (self *SElasticcacheAccount) PerformResetPassword(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	ec, err := db.FetchById(ElasticcacheManager, self.ElasticcacheId)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "FetchById(%s)", self.ElasticcacheId))
+	}
+	err = ec.(*SElasticcache).ValidateManageCapability("reset password", func(c api.ElasticcacheManageCapability) bool { return c.ResetPassword })
+	if err != nil {
+		return nil, err
+	}
 	self.SetStatus(userCred, api.ELASTIC_CACHE_STATUS_CHANGING, "")
-	data, err := self.ValidatorResetPasswordData(ctx, userCred, query, data)
+	data, err = self.ValidatorResetPasswordData(ctx, userCred, query, data)
 	if err != nil {
 		return nil, err
 	}
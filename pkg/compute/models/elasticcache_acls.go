@@ -208,6 +208,10 @@ func (manager *SElasticcacheAclManager) ValidateCreateData(ctx context.Context,
 		if region == nil {
 			return nil, fmt.Errorf("getting elastic cache region failed")
 		}
+		err = ec.(*SElasticcache).ValidateManageCapability("acl", func(c api.ElasticcacheManageCapability) bool { return c.Acl })
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		return nil, httperrors.NewMissingParameterError("elasticcache")
 	}
@@ -259,6 +263,15 @@ func (self *SElasticcacheAcl) GetRegion() *SCloudregion {
 }
 
 func (self *SElasticcacheAcl) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data *jsonutils.JSONDict) (*jsonutils.JSONDict, error) {
+	ec, err := db.FetchById(ElasticcacheManager, self.ElasticcacheId)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	err = ec.(*SElasticcache).ValidateManageCapability("acl", func(c api.ElasticcacheManageCapability) bool { return c.Acl })
+	if err != nil {
+		return nil, err
+	}
+
 	ips, err := data.GetString("ip_list")
 	if err != nil || ips == "" {
 		return nil, httperrors.NewMissingParameterError("ip_list")
@@ -238,6 +238,10 @@ func (manager *SElasticcacheBackupManager) ValidateCreateData(ctx context.Contex
 		return nil, httperrors.NewMissingParameterError("elasticcache")
 	}
 
+	err := ec.ValidateManageCapability("create backup", func(c api.ElasticcacheManageCapability) bool { return c.Backup })
+	if err != nil {
+		return nil, err
+	}
 	region, _ = ec.GetRegion()
 	driver := region.GetDriver()
 	if err := driver.AllowCreateElasticcacheBackup(ctx, userCred, ownerId, ec); err != nil {
@@ -245,7 +249,6 @@ func (manager *SElasticcacheBackupManager) ValidateCreateData(ctx context.Contex
 	}
 
 	input := apis.StandaloneResourceCreateInput{}
-	var err error
 	err = data.Unmarshal(&input)
 	if err != nil {
 		return nil, httperrors.NewInternalServerError("unmarshal StandaloneResourceCreateInput fail %s", err)
@@ -290,6 +293,11 @@ func (self *SElasticcacheBackup) ValidatorRestoreInstanceData(ctx context.Contex
 		return nil, httperrors.NewConflictError("can't restore elastic cache in status %s", ec.(*SElasticcache).Status)
 	}
 
+	err = ec.(*SElasticcache).ValidateManageCapability("restore instance", func(c api.ElasticcacheManageCapability) bool { return c.Restore })
+	if err != nil {
+		return nil, err
+	}
+
 	return data, nil
 }
 
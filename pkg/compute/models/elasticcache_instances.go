@@ -247,6 +247,9 @@ func (manager *SElasticcacheManager) FetchCustomizeColumns(
 		if net, ok := networks[netIds[i]]; ok {
 			rows[i].Network = net.Name
 		}
+		if len(rows[i].Provider) > 0 {
+			rows[i].ManageCapability = GetRegionDriver(rows[i].Provider).GetElasticcacheManageCapability(objs[i].(*SElasticcache).Engine)
+		}
 	}
 
 	if len(fields) == 0 || fields.Contains("secgroups") || fields.Contains("secgroup") {
@@ -793,6 +796,11 @@ func (manager *SElasticcacheManager) ValidateCreateData(ctx context.Context, use
 }
 
 func (manager *SElasticcacheManager) validateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input *api.ElasticcacheCreateInput) (*api.ElasticcacheCreateInput, error) {
+	var err error
+	input.Metadata, err = TagPolicyManager.validateCreateTags(ctx, ownerId, input.Metadata)
+	if err != nil {
+		return nil, err
+	}
 	if len(input.NetworkId) == 0 {
 		return nil, httperrors.NewMissingParameterError("network_id")
 	}
@@ -1082,7 +1090,11 @@ func (self *SElasticcache) ValidatorUpdateAuthModeData(ctx context.Context, user
 }
 
 func (self *SElasticcache) PerformUpdateAuthMode(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
-	data, err := self.ValidatorUpdateAuthModeData(ctx, userCred, query, data)
+	err := self.ValidateManageCapability("update auth mode", func(c api.ElasticcacheManageCapability) bool { return c.AuthMode })
+	if err != nil {
+		return nil, err
+	}
+	data, err = self.ValidatorUpdateAuthModeData(ctx, userCred, query, data)
 	if err != nil {
 		return nil, err
 	}
@@ -1125,7 +1137,11 @@ func (self *SElasticcache) ValidatorResetPasswordData(ctx context.Context, userC
 }
 
 func (self *SElasticcache) PerformResetPassword(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
-	data, err := self.ValidatorResetPasswordData(ctx, userCred, query, data)
+	err := self.ValidateManageCapability("reset password", func(c api.ElasticcacheManageCapability) bool { return c.ResetPassword })
+	if err != nil {
+		return nil, err
+	}
+	data, err = self.ValidatorResetPasswordData(ctx, userCred, query, data)
 	if err != nil {
 		return nil, err
 	}
@@ -1276,7 +1292,23 @@ func (self *SElasticcache) StartReleasePublicConnectionTask(ctx context.Context,
 	return nil
 }
 
+// 检查所在平台是否支持对实例的指定管理操作
+func (self *SElasticcache) ValidateManageCapability(action string, supported func(api.ElasticcacheManageCapability) bool) error {
+	region, err := self.GetRegion()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if !supported(region.GetDriver().GetElasticcacheManageCapability(self.Engine)) {
+		return httperrors.NewNotSupportedError("%s %s elasticcache not support %s", region.Provider, self.Engine, action)
+	}
+	return nil
+}
+
 func (self *SElasticcache) PerformFlushInstance(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	err := self.ValidateManageCapability("flush instance", func(c api.ElasticcacheManageCapability) bool { return c.Flush })
+	if err != nil {
+		return nil, err
+	}
 	self.SetStatus(userCred, api.ELASTIC_CACHE_STATUS_FLUSHING, "")
 	return nil, self.StartFlushInstanceTask(ctx, userCred, data.(*jsonutils.JSONDict), "")
 }
@@ -1306,7 +1338,11 @@ func (self *SElasticcache) ValidatorUpdateInstanceParametersData(ctx context.Con
 }
 
 func (self *SElasticcache) PerformUpdateInstanceParameters(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
-	data, err := self.ValidatorUpdateInstanceParametersData(ctx, userCred, query, data)
+	err := self.ValidateManageCapability("update parameters", func(c api.ElasticcacheManageCapability) bool { return c.Parameters })
+	if err != nil {
+		return nil, err
+	}
+	data, err = self.ValidatorUpdateInstanceParametersData(ctx, userCred, query, data)
 	if err != nil {
 		return nil, err
 	}
@@ -1352,7 +1388,11 @@ func (self *SElasticcache) ValidatorUpdateBackupPolicyData(ctx context.Context,
 }
 
 func (self *SElasticcache) PerformUpdateBackupPolicy(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
-	data, err := self.ValidatorUpdateBackupPolicyData(ctx, userCred, query, data)
+	err := self.ValidateManageCapability("update backup policy", func(c api.ElasticcacheManageCapability) bool { return c.BackupPolicy })
+	if err != nil {
+		return nil, err
+	}
+	data, err = self.ValidatorUpdateBackupPolicyData(ctx, userCred, query, data)
 	if err != nil {
 		return nil, err
 	}
@@ -466,6 +466,9 @@ func (manager *SElasticcacheSkuManager) SyncElasticcacheSkus(ctx context.Context
 			syncResult.Add()
 		}
 	}
+	if !syncResult.IsError() {
+		region.markSkuSynced(manager.Keyword())
+	}
 	return syncResult
 }
 
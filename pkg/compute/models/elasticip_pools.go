@@ -0,0 +1,436 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-model-singular=elasticip_pool
+// +onecloud:swagger-gen-model-plural=elasticip_pools
+type SElasticipPoolManager struct {
+	db.SEnabledStatusInfrasResourceBaseManager
+	SManagedResourceBaseManager
+	SCloudregionResourceBaseManager
+}
+
+var ElasticipPoolManager *SElasticipPoolManager
+
+func init() {
+	ElasticipPoolManager = &SElasticipPoolManager{
+		SEnabledStatusInfrasResourceBaseManager: db.NewEnabledStatusInfrasResourceBaseManager(
+			SElasticipPool{},
+			"elasticip_pools_tbl",
+			"elasticip_pool",
+			"elasticip_pools",
+		),
+	}
+	ElasticipPoolManager.SetVirtualObject(ElasticipPoolManager)
+}
+
+// SElasticipPool EIP池, 公有云EIP释放时保留在池中(云上仍保持分配), 供同一项目新建虚拟机时复用
+type SElasticipPool struct {
+	db.SEnabledStatusInfrasResourceBase
+
+	SManagedResourceBase
+	SCloudregionResourceBase
+
+	// 池中最多保留的EIP数量, 0表示不限制
+	MaxCount int `nullable:"false" default:"0" list:"domain" create:"optional" update:"domain"`
+	// 每个项目最多保留的EIP数量, 0表示不限制
+	ProjectReserveCount int `nullable:"false" default:"0" list:"domain" create:"optional" update:"domain"`
+	// 仅保留指定计费类型的EIP, 为空表示不限制
+	ChargeType string `width:"64" charset:"ascii" nullable:"true" list:"domain" create:"optional"`
+}
+
+func (manager *SElasticipPoolManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ElasticipPoolCreateInput) (api.ElasticipPoolCreateInput, error) {
+	var err error
+	var region *SCloudregion
+	region, input.CloudregionResourceInput, err = ValidateCloudregionResourceInput(userCred, input.CloudregionResourceInput)
+	if err != nil {
+		return input, errors.Wrap(err, "ValidateCloudregionResourceInput")
+	}
+	var provider *SCloudprovider
+	provider, input.CloudproviderResourceInput, err = ValidateCloudproviderResourceInput(userCred, input.CloudproviderResourceInput)
+	if err != nil {
+		return input, errors.Wrap(err, "ValidateCloudproviderResourceInput")
+	}
+	account, err := provider.GetCloudaccount()
+	if err != nil {
+		return input, errors.Wrap(err, "GetCloudaccount")
+	}
+	if !account.IsPublicCloud.IsTrue() {
+		return input, httperrors.NewUnsupportOperationError("eip pool only supports public cloud")
+	}
+	input.ManagerId = provider.Id
+	cnt, err := manager.Query().Equals("manager_id", provider.Id).Equals("cloudregion_id", region.Id).CountWithError()
+	if err != nil {
+		return input, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return input, httperrors.NewDuplicateResourceError("eip pool of %s in %s already exists", provider.Name, region.Name)
+	}
+	if input.MaxCount < 0 || input.ProjectReserveCount < 0 {
+		return input, httperrors.NewInputParameterError("max_count and project_reserve_count must not be negative")
+	}
+	if len(input.ChargeType) > 0 {
+		err = region.GetDriver().ValidateEipChargeType(input.ChargeType)
+		if err != nil {
+			return input, err
+		}
+	}
+	input.Status = api.ELASTICIP_POOL_STATUS_AVAILABLE
+	input.EnabledStatusInfrasResourceBaseCreateInput, err = manager.SEnabledStatusInfrasResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusInfrasResourceBaseCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ValidateCreateData")
+	}
+	return input, nil
+}
+
+func (self *SElasticipPool) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ElasticipPoolUpdateInput) (api.ElasticipPoolUpdateInput, error) {
+	if (input.MaxCount != nil && *input.MaxCount < 0) || (input.ProjectReserveCount != nil && *input.ProjectReserveCount < 0) {
+		return input, httperrors.NewInputParameterError("max_count and project_reserve_count must not be negative")
+	}
+	var err error
+	input.EnabledStatusInfrasResourceBaseUpdateInput, err = self.SEnabledStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusInfrasResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+// EIP池列表
+func (manager *SElasticipPoolManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ElasticipPoolListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SManagedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ManagedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SCloudregionResourceBaseManager.ListItemFilter(ctx, q, userCred, query.RegionalFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.ListItemFilter")
+	}
+	return q, nil
+}
+
+func (manager *SElasticipPoolManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ElasticipPoolListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SManagedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ManagedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SCloudregionResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.RegionalFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SElasticipPoolManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	q, err = manager.SManagedResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	q, err = manager.SCloudregionResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SElasticipPoolManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ListItemExportKeys")
+	}
+
+	if keys.ContainsAny(manager.SManagedResourceBaseManager.GetExportKeys()...) {
+		q, err = manager.SManagedResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+		if err != nil {
+			return nil, errors.Wrap(err, "SManagedResourceBaseManager.ListItemExportKeys")
+		}
+	}
+	if keys.ContainsAny(manager.SCloudregionResourceBaseManager.GetExportKeys()...) {
+		q, err = manager.SCloudregionResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+		if err != nil {
+			return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.ListItemExportKeys")
+		}
+	}
+	return q, nil
+}
+
+func (manager *SElasticipPoolManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ElasticipPoolDetails {
+	rows := make([]api.ElasticipPoolDetails, len(objs))
+	stdRows := manager.SEnabledStatusInfrasResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	managerRows := manager.SManagedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	regionRows := manager.SCloudregionResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	poolIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.ElasticipPoolDetails{
+			EnabledStatusInfrasResourceBaseDetails: stdRows[i],
+			ManagedResourceInfo:                    managerRows[i],
+			CloudregionResourceInfo:                regionRows[i],
+		}
+		poolIds[i] = objs[i].(*SElasticipPool).Id
+	}
+
+	q := ElasticipManager.Query().In("pool_id", poolIds)
+	q = q.AppendField(q.Field("pool_id"), sqlchemy.COUNT("eip_count")).GroupBy(q.Field("pool_id"))
+	counts := []struct {
+		PoolId   string
+		EipCount int
+	}{}
+	err := q.All(&counts)
+	if err != nil {
+		return rows
+	}
+	countMap := map[string]int{}
+	for _, cnt := range counts {
+		countMap[cnt.PoolId] = cnt.EipCount
+	}
+	for i := range rows {
+		rows[i].EipCount = countMap[poolIds[i]]
+	}
+	return rows
+}
+
+func (self *SElasticipPool) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := self.GetEipQuery().CountWithError()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("eip pool has %d retained eips", cnt)
+	}
+	return self.SEnabledStatusInfrasResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (self *SElasticipPool) GetEipQuery() *sqlchemy.SQuery {
+	return ElasticipManager.Query().Equals("pool_id", self.Id)
+}
+
+// 检查池容量及项目保留数量是否允许再保留一个EIP
+func (self *SElasticipPool) checkRetain(eip *SElasticip) error {
+	total, projectCnt := 0, 0
+	var err error
+	if self.MaxCount > 0 {
+		total, err = self.GetEipQuery().CountWithError()
+		if err != nil {
+			return errors.Wrap(err, "CountWithError")
+		}
+	}
+	if self.ProjectReserveCount > 0 {
+		projectCnt, err = self.GetEipQuery().Equals("tenant_id", eip.ProjectId).CountWithError()
+		if err != nil {
+			return errors.Wrap(err, "CountWithError")
+		}
+	}
+	return self.checkRetainCount(eip, total, projectCnt)
+}
+
+// total 为池中已保留的EIP数量, projectCnt 为EIP所属项目在池中已保留的数量
+func (self *SElasticipPool) checkRetainCount(eip *SElasticip, total, projectCnt int) error {
+	if len(self.ChargeType) > 0 && self.ChargeType != eip.ChargeType {
+		return errors.Wrapf(httperrors.ErrInputParameter, "eip pool only retain %s eip", self.ChargeType)
+	}
+	if self.MaxCount > 0 && total >= self.MaxCount {
+		return errors.Wrapf(httperrors.ErrOutOfLimit, "eip pool is full with %d eips", total)
+	}
+	if self.ProjectReserveCount > 0 && projectCnt >= self.ProjectReserveCount {
+		return errors.Wrapf(httperrors.ErrOutOfLimit, "project has reserved %d eips in pool", projectCnt)
+	}
+	return nil
+}
+
+// 仅未入池的纳管独立EIP可以保留在池中
+func (self *SElasticip) isPoolRetainable() bool {
+	return len(self.ManagerId) > 0 && self.Mode == api.EIP_MODE_STANDALONE_EIP && len(self.PoolId) == 0
+}
+
+func (manager *SElasticipPoolManager) getPool(managerId, regionId string) (*SElasticipPool, error) {
+	q := manager.Query().Equals("manager_id", managerId).Equals("cloudregion_id", regionId).IsTrue("enabled")
+	pool := &SElasticipPool{}
+	pool.SetModelManager(manager, pool)
+	err := q.First(pool)
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// 获取可保留此EIP的池, 没有可用的池时返回nil, 池容量在保留时检查
+func (self *SElasticip) GetRetainPool() *SElasticipPool {
+	if !self.isPoolRetainable() {
+		return nil
+	}
+	pool, err := ElasticipPoolManager.getPool(self.ManagerId, self.CloudregionId)
+	if err != nil {
+		if errors.Cause(err) != sql.ErrNoRows {
+			log.Errorf("get eip pool of %s: %v", self.Name, err)
+		}
+		return nil
+	}
+	return pool
+}
+
+// 将EIP保留在池中, 云上不释放
+// 与从池中取出EIP使用同一把锁, 避免并发保留时超出池容量
+func (self *SElasticip) RetainInPool(ctx context.Context, userCred mcclient.TokenCredential, pool *SElasticipPool) error {
+	lockman.LockRawObject(ctx, ElasticipManager.Keyword(), pool.Id)
+	defer lockman.ReleaseRawObject(ctx, ElasticipManager.Keyword(), pool.Id)
+
+	err := pool.checkRetain(self)
+	if err != nil {
+		return errors.Wrapf(err, "checkRetain")
+	}
+	_, err = db.Update(self, func() error {
+		self.PoolId = pool.Id
+		self.AutoDellocate = tristate.False
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, "retain in eip pool "+pool.Name, userCred)
+	logclient.AddSimpleActionLog(self, logclient.ACT_EIP_RELEASE_TO_POOL, pool.GetShortDesc(ctx), userCred, true)
+	return nil
+}
+
+// 手动将未绑定的EIP释放到池中保留
+func (self *SElasticip) PerformReleaseToPool(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ElasticipReleaseToPoolInput) (jsonutils.JSONObject, error) {
+	if self.IsAssociated() {
+		return nil, httperrors.NewInvalidStatusError("eip is associated with resources")
+	}
+	if self.Status != api.EIP_STATUS_READY {
+		return nil, httperrors.NewInvalidStatusError("eip cannot release to pool in status %s", self.Status)
+	}
+	if len(self.PoolId) > 0 {
+		return nil, nil
+	}
+	if len(self.ManagerId) == 0 || self.Mode != api.EIP_MODE_STANDALONE_EIP {
+		return nil, httperrors.NewUnsupportOperationError("only managed elastic eip can be retained in pool")
+	}
+	pool, err := ElasticipPoolManager.getPool(self.ManagerId, self.CloudregionId)
+	if err != nil {
+		return nil, httperrors.NewResourceNotFoundError("no enabled eip pool for this eip")
+	}
+	err = self.RetainInPool(ctx, userCred, pool)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+// 从池中取出同一项目下计费方式及带宽一致的可复用EIP, 没有时返回nil
+func (manager *SElasticipManager) takeFromPool(ctx context.Context, userCred mcclient.TokenCredential, host *SHost, ownerId mcclient.IIdentityProvider, chargeType string, bandwidth int, autoDellocate bool) *SElasticip {
+	if host == nil || len(host.ManagerId) == 0 {
+		return nil
+	}
+	region, err := host.GetRegion()
+	if err != nil {
+		log.Errorf("takeFromPool: get region of host %s: %v", host.Name, err)
+		return nil
+	}
+	pool, err := ElasticipPoolManager.getPool(host.ManagerId, region.Id)
+	if err != nil {
+		if errors.Cause(err) != sql.ErrNoRows {
+			log.Errorf("takeFromPool: get eip pool of host %s: %v", host.Name, err)
+		}
+		return nil
+	}
+
+	lockman.LockRawObject(ctx, manager.Keyword(), pool.Id)
+	defer lockman.ReleaseRawObject(ctx, manager.Keyword(), pool.Id)
+
+	q := pool.GetEipQuery().Equals("tenant_id", ownerId.GetProjectId()).Equals("status", api.EIP_STATUS_READY)
+	q = q.IsNullOrEmpty("associate_id")
+	if len(chargeType) > 0 {
+		q = q.Equals("charge_type", chargeType)
+	}
+	if bandwidth > 0 {
+		q = q.Equals("bandwidth", bandwidth)
+	}
+	eip := &SElasticip{}
+	eip.SetModelManager(manager, eip)
+	err = q.Asc("created_at").First(eip)
+	if err != nil {
+		if errors.Cause(err) != sql.ErrNoRows {
+			log.Errorf("takeFromPool: query eip pool %s: %v", pool.Name, err)
+		}
+		return nil
+	}
+	_, err = db.Update(eip, func() error {
+		eip.PoolId = ""
+		eip.AutoDellocate = tristate.NewFromBool(autoDellocate)
+		return nil
+	})
+	if err != nil {
+		log.Errorf("takeFromPool: update eip %s: %v", eip.Name, err)
+		return nil
+	}
+	db.OpsLog.LogEvent(eip, db.ACT_UPDATE, "take from eip pool "+pool.Name, userCred)
+	return eip
+}
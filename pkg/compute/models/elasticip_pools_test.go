@@ -0,0 +1,72 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+func TestElasticipPoolCheckRetainCount(t *testing.T) {
+	cases := []struct {
+		name       string
+		pool       SElasticipPool
+		chargeType string
+		total      int
+		projectCnt int
+		want       error
+	}{
+		{"unlimited", SElasticipPool{}, api.EIP_CHARGE_TYPE_BY_TRAFFIC, 100, 100, nil},
+		{"charge type mismatch", SElasticipPool{ChargeType: api.EIP_CHARGE_TYPE_BY_BANDWIDTH}, api.EIP_CHARGE_TYPE_BY_TRAFFIC, 0, 0, httperrors.ErrInputParameter},
+		{"charge type match", SElasticipPool{ChargeType: api.EIP_CHARGE_TYPE_BY_TRAFFIC}, api.EIP_CHARGE_TYPE_BY_TRAFFIC, 0, 0, nil},
+		{"pool full", SElasticipPool{MaxCount: 2}, api.EIP_CHARGE_TYPE_BY_TRAFFIC, 2, 0, httperrors.ErrOutOfLimit},
+		{"pool has room", SElasticipPool{MaxCount: 2}, api.EIP_CHARGE_TYPE_BY_TRAFFIC, 1, 1, nil},
+		{"project reserve reached", SElasticipPool{ProjectReserveCount: 1}, api.EIP_CHARGE_TYPE_BY_TRAFFIC, 5, 1, httperrors.ErrOutOfLimit},
+		{"project reserve left", SElasticipPool{MaxCount: 10, ProjectReserveCount: 2}, api.EIP_CHARGE_TYPE_BY_TRAFFIC, 5, 1, nil},
+	}
+	for _, c := range cases {
+		eip := &SElasticip{ChargeType: c.chargeType}
+		err := c.pool.checkRetainCount(eip, c.total, c.projectCnt)
+		if errors.Cause(err) != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, err)
+		}
+	}
+}
+
+func TestElasticipIsPoolRetainable(t *testing.T) {
+	cases := []struct {
+		name      string
+		managerId string
+		mode      string
+		poolId    string
+		want      bool
+	}{
+		{"managed standalone", "provider", api.EIP_MODE_STANDALONE_EIP, "", true},
+		{"already in pool", "provider", api.EIP_MODE_STANDALONE_EIP, "pool", false},
+		{"instance public ip", "provider", api.EIP_MODE_INSTANCE_PUBLICIP, "", false},
+		{"on premise", "", api.EIP_MODE_STANDALONE_EIP, "", false},
+	}
+	for _, c := range cases {
+		eip := &SElasticip{Mode: c.mode, PoolId: c.poolId}
+		eip.ManagerId = c.managerId
+		if got := eip.isPoolRetainable(); got != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
@@ -52,6 +52,7 @@ type SElasticipManager struct {
 	db.SExternalizedResourceBaseManager
 	SManagedResourceBaseManager
 	SCloudregionResourceBaseManager
+	SExternalProjectResourceBaseManager
 }
 
 var ElasticipManager *SElasticipManager
@@ -75,6 +76,7 @@ type SElasticip struct {
 
 	SManagedResourceBase
 	SCloudregionResourceBase `width:"36" charset:"ascii" nullable:"false" list:"user" create:"required"`
+	SExternalProjectResourceBase
 
 	SBillingResourceBase
 
@@ -109,6 +111,9 @@ type SElasticip struct {
 	// 是否跟随主机删除而自动释放
 	AutoDellocate tristate.TriState `default:"false" get:"user" create:"optional" update:"user"`
 
+	// 所属EIP池, 非空表示EIP已释放并保留在池中等待复用
+	PoolId string `width:"36" charset:"ascii" nullable:"true" list:"user" index:"true"`
+
 	// 区域Id
 	// CloudregionId string `width:"36" charset:"ascii" nullable:"false" list:"user" create:"required"`
 }
@@ -142,6 +147,11 @@ func (manager *SElasticipManager) ListItemFilter(
 		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.ListItemFilter")
 	}
 
+	q, err = manager.SExternalProjectResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ExternalProjectFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SExternalProjectResourceBaseManager.ListItemFilter")
+	}
+
 	associateType := query.UsableEipForAssociateType
 	associateId := query.UsableEipForAssociateId
 	if len(associateType) > 0 && len(associateId) > 0 {
@@ -280,6 +290,23 @@ func (manager *SElasticipManager) ListItemFilter(
 			q = q.IsFalse("auto_dellocate")
 		}
 	}
+	if len(query.PoolId) > 0 {
+		pool, err := ElasticipPoolManager.FetchByIdOrName(userCred, query.PoolId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(ElasticipPoolManager.Keyword(), query.PoolId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("pool_id", pool.GetId())
+	}
+	if query.InPool != nil {
+		if *query.InPool {
+			q = q.IsNotEmpty("pool_id")
+		} else {
+			q = q.IsNullOrEmpty("pool_id")
+		}
+	}
 
 	return q, nil
 }
@@ -517,6 +544,7 @@ func (self *SElasticip) SyncWithCloudEip(ctx context.Context, userCred mcclient.
 		self.Status = ext.GetStatus()
 		self.ExternalId = ext.GetGlobalId()
 		self.IsEmulated = ext.IsEmulated()
+		self.ExternalProjectId = ext.GetProjectId()
 		self.AssociateType = ext.GetAssociationType()
 
 		if chargeType := ext.GetInternetChargeType(); len(chargeType) > 0 {
@@ -559,6 +587,7 @@ func (self *SElasticip) SyncWithCloudEip(ctx context.Context, userCred mcclient.
 	// eip有绑定资源，并且绑定资源是项目资源,eip项目信息跟随绑定资源
 	if res := self.GetAssociateResource(); res != nil && len(res.GetOwnerId().GetProjectId()) > 0 {
 		self.SyncCloudProjectId(userCred, res.GetOwnerId())
+		checkTagPolicyCompliance(ctx, userCred, self)
 	} else {
 		SyncCloudProject(userCred, self, syncOwnerId, ext, self.ManagerId)
 	}
@@ -575,6 +604,7 @@ func (manager *SElasticipManager) newFromCloudEip(ctx context.Context, userCred
 	eip.IpAddr = extEip.GetIpAddr()
 	eip.Mode = extEip.GetMode()
 	eip.IsEmulated = extEip.IsEmulated()
+	eip.ExternalProjectId = extEip.GetProjectId()
 	eip.ManagerId = provider.Id
 	eip.CloudregionId = region.Id
 	eip.ChargeType = extEip.GetInternetChargeType()
@@ -624,6 +654,7 @@ func (manager *SElasticipManager) newFromCloudEip(ctx context.Context, userCred
 
 	if res := eip.GetAssociateResource(); res != nil {
 		eip.SyncCloudProjectId(userCred, res.GetOwnerId())
+		checkTagPolicyCompliance(ctx, userCred, &eip)
 	} else {
 		SyncCloudProject(userCred, &eip, syncOwnerId, extEip, eip.ManagerId)
 	}
@@ -829,6 +860,7 @@ func (self *SElasticip) AssociateLoadbalancer(ctx context.Context, userCred mccl
 	_, err := db.Update(self, func() error {
 		self.AssociateType = api.EIP_ASSOCIATE_TYPE_LOADBALANCER
 		self.AssociateId = lb.Id
+		self.PoolId = ""
 		return nil
 	})
 	if err != nil {
@@ -859,6 +891,7 @@ func (self *SElasticip) AssociateInstance(ctx context.Context, userCred mcclient
 	_, err := db.Update(self, func() error {
 		self.AssociateType = insType
 		self.AssociateId = ins.GetId()
+		self.PoolId = ""
 		return nil
 	})
 	if err != nil {
@@ -889,6 +922,7 @@ func (self *SElasticip) AssociateInstanceGroup(ctx context.Context, userCred mcc
 	_, err := db.Update(self, func() error {
 		self.AssociateType = insType
 		self.AssociateId = ins.GetId()
+		self.PoolId = ""
 		return nil
 	})
 	if err != nil {
@@ -916,6 +950,7 @@ func (self *SElasticip) AssociateNatGateway(ctx context.Context, userCred mcclie
 	_, err := db.Update(self, func() error {
 		self.AssociateType = api.EIP_ASSOCIATE_TYPE_NAT_GATEWAY
 		self.AssociateId = nat.Id
+		self.PoolId = ""
 		return nil
 	})
 	if err != nil {
@@ -945,6 +980,11 @@ func (manager *SElasticipManager) getEipByExtEip(ctx context.Context, userCred m
 }
 
 func (manager *SElasticipManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.SElasticipCreateInput) (api.SElasticipCreateInput, error) {
+	var err error
+	input.Metadata, err = TagPolicyManager.validateCreateTags(ctx, ownerId, input.Metadata)
+	if err != nil {
+		return input, err
+	}
 	if input.CloudregionId == "" {
 		input.CloudregionId = api.DEFAULT_REGION_ID
 	}
@@ -977,6 +1017,11 @@ func (manager *SElasticipManager) ValidateCreateData(ctx context.Context, userCr
 		return input, err
 	}
 
+	input.ExternalProjectId, err = ExternalProjectManager.validateCreateExternalProject(userCred, input.ExternalProjectId, &input.ManagerId)
+	if err != nil {
+		return input, err
+	}
+
 	err = regionDriver.ValidateCreateEipData(ctx, userCred, &input)
 	if err != nil {
 		return input, err
@@ -1052,6 +1097,10 @@ func (self *SElasticip) Delete(ctx context.Context, userCred mcclient.TokenCrede
 }
 
 func (self *SElasticip) RealDelete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	err := ResourceLockManager.deleteByResource(ctx, userCred, self.Id)
+	if err != nil {
+		return errors.Wrapf(err, "clean resource locks")
+	}
 	return self.SVirtualResourceBase.Delete(ctx, userCred)
 }
 
@@ -1063,6 +1112,10 @@ func (self *SElasticip) ValidateDeleteCondition(ctx context.Context, info jsonut
 	if self.IsAssociated() {
 		return fmt.Errorf("eip is associated with resources")
 	}
+	err := self.validateDeleteLock()
+	if err != nil {
+		return err
+	}
 	return self.SVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
 }
 
@@ -1519,6 +1572,16 @@ func (manager *SElasticipManager) NewEipForVMOnHost(ctx context.Context, userCre
 		return nil, err
 	}
 
+	// 优先复用EIP池中同项目保留的EIP, 此时EIP已计入配额
+	if vm != nil {
+		if eip := manager.takeFromPool(ctx, userCred, host, vm.GetOwnerId(), chargeType, bw, autoDellocate); eip != nil {
+			eipPendingUsage := &SRegionQuota{Eip: 1}
+			eipPendingUsage.SetKeys(fetchRegionalQuotaKeys(rbacscope.ScopeProject, vm.GetOwnerId(), region, host.GetCloudprovider()))
+			quotas.CancelPendingUsage(ctx, userCred, pendingUsage, eipPendingUsage, false)
+			return eip, nil
+		}
+	}
+
 	eip := &SElasticip{}
 	eip.SetModelManager(manager, eip)
 
@@ -404,18 +404,21 @@ func (manager *SExternalProjectManager) newFromCloudProject(ctx context.Context,
 		if pm.Rules != nil {
 			for _, rule := range *pm.Rules {
 				domainId, projectId, newProj, isMatch := rule.IsMatchTags(extTags)
-				if isMatch && len(newProj) > 0 {
-					domainId, projectId, err = account.getOrCreateTenant(context.TODO(), newProj, "", "", "auto create from tag")
+				if !isMatch {
+					continue
+				}
+				if len(newProj) > 0 {
+					domainId, projectId, err = account.getOrCreateTenant(context.TODO(), newProj, rule.AutoCreateDomainId, "", "auto create from tag")
 					if err != nil {
 						log.Errorf("getOrCreateTenant(%s) error: %v", newProj, err)
 						continue
 					}
-					if len(domainId) > 0 && len(projectId) > 0 {
-						project.DomainId = domainId
-						project.ProjectId = projectId
-						find = true
-						break
-					}
+				}
+				if len(domainId) > 0 && len(projectId) > 0 {
+					project.DomainId = domainId
+					project.ProjectId = projectId
+					find = true
+					break
 				}
 			}
 		}
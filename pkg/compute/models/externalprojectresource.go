@@ -0,0 +1,124 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type SExternalProjectResourceBase struct {
+	// 云上企业项目或资源组的云上ID
+	ExternalProjectId string `width:"256" charset:"ascii" nullable:"true" list:"user" create:"optional"`
+}
+
+type SExternalProjectResourceBaseManager struct{}
+
+func (manager *SExternalProjectResourceBaseManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ExternalProjectFilterListInput,
+) (*sqlchemy.SQuery, error) {
+	if len(query.ExternalProjectId) > 0 {
+		q = q.In("external_project_id", query.ExternalProjectId)
+	}
+	return q, nil
+}
+
+// 校验创建资源时指定的云上企业项目/资源组, 返回其云上ID
+// 未指定云订阅时限定调度到该项目所属的云订阅
+func (manager *SExternalProjectManager) validateCreateExternalProject(userCred mcclient.TokenCredential, externalProjectId string, managerId *string) (string, error) {
+	if len(externalProjectId) == 0 {
+		return "", nil
+	}
+	q := manager.Query()
+	q = q.Filter(sqlchemy.OR(
+		sqlchemy.Equals(q.Field("id"), externalProjectId),
+		sqlchemy.Equals(q.Field("external_id"), externalProjectId),
+		sqlchemy.Equals(q.Field("name"), externalProjectId),
+	))
+	if len(*managerId) > 0 {
+		providerObj, err := CloudproviderManager.FetchByIdOrName(userCred, *managerId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return "", httperrors.NewResourceNotFoundError2("cloudprovider", *managerId)
+			}
+			return "", httperrors.NewGeneralError(err)
+		}
+		provider := providerObj.(*SCloudprovider)
+		q = q.Equals("cloudaccount_id", provider.CloudaccountId)
+		q = q.Filter(sqlchemy.OR(
+			sqlchemy.IsNullOrEmpty(q.Field("manager_id")),
+			sqlchemy.Equals(q.Field("manager_id"), provider.Id),
+		))
+	}
+	projects := []SExternalProject{}
+	err := db.FetchModelObjects(manager, q, &projects)
+	if err != nil {
+		return "", httperrors.NewGeneralError(err)
+	}
+	if len(projects) == 0 {
+		return "", httperrors.NewResourceNotFoundError2("external_project", externalProjectId)
+	}
+	if len(projects) > 1 {
+		return "", httperrors.NewDuplicateResourceError("external project %s is ambiguous, please specify manager or id", externalProjectId)
+	}
+	project := projects[0]
+	if project.Status != api.EXTERNAL_PROJECT_STATUS_AVAILABLE {
+		return "", httperrors.NewInvalidStatusError("external project %s is not available", project.Name)
+	}
+	account, err := project.GetCloudaccount()
+	if err != nil {
+		return "", httperrors.NewGeneralError(err)
+	}
+	if !utils.IsInStringArray(account.Provider, api.EXTERNAL_PROJECT_SELECTABLE_PROVIDERS) {
+		return "", httperrors.NewNotSupportedError("specify external project for %s resource", account.Provider)
+	}
+	if len(*managerId) == 0 {
+		if len(project.ManagerId) > 0 {
+			*managerId = project.ManagerId
+		} else if providers := account.GetCloudproviders(); len(providers) == 1 {
+			*managerId = providers[0].Id
+		} else {
+			return "", httperrors.NewMissingParameterError("prefer_manager_id")
+		}
+	}
+	return project.ExternalId, nil
+}
+
+// 获取创建资源时使用的云上项目ID, 优先使用资源指定的云上项目, 否则按本地项目映射同步
+func (self *SCloudprovider) GetCreateProjectId(ctx context.Context, userCred mcclient.TokenCredential, projectId, externalProjectId string) (string, error) {
+	if len(externalProjectId) == 0 {
+		return self.SyncProject(ctx, userCred, projectId)
+	}
+	// Azure资源组的云上ID包含订阅前缀
+	if self.Provider == api.CLOUD_PROVIDER_AZURE {
+		if idx := strings.Index(externalProjectId, "/"); idx > -1 {
+			return externalProjectId[idx+1:], nil
+		}
+	}
+	return externalProjectId, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestGetCreateProjectId(t *testing.T) {
+	cases := []struct {
+		provider          string
+		externalProjectId string
+		want              string
+	}{
+		{api.CLOUD_PROVIDER_ALIYUN, "rg-aek2kqcs5q5ehsi", "rg-aek2kqcs5q5ehsi"},
+		{api.CLOUD_PROVIDER_HUAWEI, "0", "0"},
+		{api.CLOUD_PROVIDER_AZURE, "d4f0ec08-3e28-4ae5-bdf9-3dc7c5b0eeca/test-rg", "test-rg"},
+		{api.CLOUD_PROVIDER_AZURE, "test-rg", "test-rg"},
+	}
+	for _, c := range cases {
+		provider := &SCloudprovider{Provider: c.provider}
+		got, err := provider.GetCreateProjectId(context.Background(), nil, "", c.externalProjectId)
+		if err != nil {
+			t.Errorf("%s %s: %v", c.provider, c.externalProjectId, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s %s: want %s, got %s", c.provider, c.externalProjectId, c.want, got)
+		}
+	}
+}
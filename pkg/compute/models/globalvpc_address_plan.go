@@ -0,0 +1,324 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+func parseCidrList(cidrs string) ([]netutils.IPV4Prefix, error) {
+	ret := []netutils.IPV4Prefix{}
+	for _, cidr := range strings.Split(cidrs, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if len(cidr) == 0 {
+			continue
+		}
+		prefix, err := netutils.NewIPV4Prefix(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cidr %s", cidr)
+		}
+		ret = append(ret, prefix)
+	}
+	return ret, nil
+}
+
+// 分配掩码需在允许范围内, 且不能小于规划网段的掩码
+func checkGlobalVpcPrefixLen(prefixes []netutils.IPV4Prefix, prefixLen int) error {
+	if prefixLen < 8 || prefixLen > api.GLOBAL_VPC_MAX_PREFIX_LEN {
+		return httperrors.NewInputParameterError("prefix_len should be between 8 and %d", api.GLOBAL_VPC_MAX_PREFIX_LEN)
+	}
+	for i := range prefixes {
+		if int(prefixes[i].MaskLen) > prefixLen {
+			return httperrors.NewInputParameterError("prefix_len %d is shorter than address plan %s", prefixLen, prefixes[i].String())
+		}
+	}
+	return nil
+}
+
+// 校验并规范化地址规划, 规划内网段不能互相重叠, 且分配掩码不能大于规划网段
+func validateGlobalVpcAddressPlan(plan string, prefixLen int) (string, error) {
+	prefixes, err := parseCidrList(plan)
+	if err != nil {
+		return "", httperrors.NewInputParameterError("invalid address_plan %s: %v", plan, err)
+	}
+	err = checkGlobalVpcPrefixLen(prefixes, prefixLen)
+	if err != nil {
+		return "", err
+	}
+	cidrs := []string{}
+	for i := range prefixes {
+		for j := 0; j < i; j++ {
+			if prefixes[i].ToIPRange().IsOverlap(prefixes[j].ToIPRange()) {
+				return "", httperrors.NewInputParameterError("address plan %s overlaps with %s", prefixes[i].String(), prefixes[j].String())
+			}
+		}
+		cidrs = append(cidrs, prefixes[i].String())
+	}
+	return strings.Join(cidrs, ","), nil
+}
+
+// 未设置地址规划时不做限制
+func isCidrBlockInAddressPlan(plan, cidrBlock string) bool {
+	planPrefixes, _ := parseCidrList(plan)
+	if len(planPrefixes) == 0 {
+		return true
+	}
+	prefixes, err := parseCidrList(cidrBlock)
+	if err != nil {
+		return false
+	}
+	for i := range prefixes {
+		in := false
+		for j := range planPrefixes {
+			if planPrefixes[j].ToIPRange().ContainsRange(prefixes[i].ToIPRange()) {
+				in = true
+				break
+			}
+		}
+		if !in {
+			return false
+		}
+	}
+	return true
+}
+
+// 按地址规划顺序分配第一个与已用网段不重叠的网段
+func allocateGlobalVpcCidr(plan string, prefixLen int, used []string) (string, error) {
+	planPrefixes, err := parseCidrList(plan)
+	if err != nil {
+		return "", errors.Wrapf(err, "parseCidrList")
+	}
+	err = checkGlobalVpcPrefixLen(planPrefixes, prefixLen)
+	if err != nil {
+		return "", err
+	}
+	usedRanges := []netutils.IPV4AddrRange{}
+	for i := range used {
+		prefixes, _ := parseCidrList(used[i])
+		for j := range prefixes {
+			usedRanges = append(usedRanges, prefixes[j].ToIPRange())
+		}
+	}
+	blockSize := uint64(1) << uint(32-prefixLen)
+	for i := range planPrefixes {
+		planRange := planPrefixes[i].ToIPRange()
+		start := uint64(planRange.StartIp())
+		for start+blockSize-1 <= uint64(planRange.EndIp()) {
+			block := netutils.NewIPV4AddrRange(netutils.IPV4Addr(start), netutils.IPV4Addr(start+blockSize-1))
+			conflict := false
+			for j := range usedRanges {
+				if block.IsOverlap(usedRanges[j]) {
+					// 跳过已占用网段, 对齐到下一个可用块
+					next := (uint64(usedRanges[j].EndIp())/blockSize + 1) * blockSize
+					if next > start {
+						start = next
+					} else {
+						start += blockSize
+					}
+					conflict = true
+					break
+				}
+			}
+			if !conflict {
+				prefix := netutils.IPV4Prefix{Address: netutils.IPV4Addr(start), MaskLen: int8(prefixLen)}
+				return prefix.String(), nil
+			}
+		}
+	}
+	return "", errors.Wrapf(httperrors.ErrOutOfResource, "no free /%d cidr in address plan %s", prefixLen, plan)
+}
+
+// 返回成员vpc占用的网段, excludeVpcId 对应的vpc除外
+func (self *SGlobalVpc) getAddressPlanUsed(excludeVpcId string) ([]SVpc, error) {
+	vpcs, err := self.GetVpcs()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetVpcs")
+	}
+	ret := []SVpc{}
+	for i := range vpcs {
+		if vpcs[i].Id != excludeVpcId && len(vpcs[i].CidrBlock) > 0 {
+			ret = append(ret, vpcs[i])
+		}
+	}
+	return ret, nil
+}
+
+// 校验成员vpc网段在地址规划内且不与其他成员vpc重叠
+func (self *SGlobalVpc) ValidateMemberCidr(vpcId, cidrBlock string) error {
+	if !isCidrBlockInAddressPlan(self.AddressPlan, cidrBlock) {
+		return httperrors.NewInputParameterError("cidr %s is out of address plan %s of global vpc %s", cidrBlock, self.AddressPlan, self.Name)
+	}
+	vpcs, err := self.getAddressPlanUsed(vpcId)
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	for i := range vpcs {
+		if cidr, conflict, ok := interVpcCidrOverlap(cidrBlock, vpcs[i].CidrBlock); ok {
+			return httperrors.NewConflictError("cidr %s overlaps with vpc %s cidr %s in global vpc %s", cidr, vpcs[i].Name, conflict, self.Name)
+		}
+	}
+	return nil
+}
+
+func (self *SGlobalVpc) AllocateCidr(prefixLen int) (string, error) {
+	if len(self.AddressPlan) == 0 {
+		return "", httperrors.NewInputParameterError("global vpc %s has no address plan", self.Name)
+	}
+	if prefixLen == 0 {
+		prefixLen = self.AddressPlanPrefixLen
+	}
+	vpcs, err := self.getAddressPlanUsed("")
+	if err != nil {
+		return "", httperrors.NewGeneralError(err)
+	}
+	used := make([]string, len(vpcs))
+	for i := range vpcs {
+		used[i] = vpcs[i].CidrBlock
+	}
+	return allocateGlobalVpcCidr(self.AddressPlan, prefixLen, used)
+}
+
+// 创建vpc时指定了全局vpc, 未指定网段则从地址规划中分配, 否则校验网段
+func validateVpcGlobalVpcAddressPlan(userCred mcclient.TokenCredential, input api.VpcCreateInput) (api.VpcCreateInput, error) {
+	gvpcObj, err := validators.ValidateModel(userCred, GlobalVpcManager, &input.GlobalvpcId)
+	if err != nil {
+		return input, err
+	}
+	gvpc := gvpcObj.(*SGlobalVpc)
+	if len(gvpc.AddressPlan) == 0 {
+		return input, nil
+	}
+	if len(input.CidrBlock) == 0 {
+		input.CidrBlock, err = gvpc.AllocateCidr(0)
+		return input, err
+	}
+	return input, gvpc.ValidateMemberCidr("", input.CidrBlock)
+}
+
+func (self *SGlobalVpc) PerformAddvpc(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.GlobalVpcAddVpcInput) (jsonutils.JSONObject, error) {
+	if len(input.VpcId) == 0 {
+		return nil, httperrors.NewMissingParameterError("vpc_id")
+	}
+	vpcObj, err := validators.ValidateModel(userCred, VpcManager, &input.VpcId)
+	if err != nil {
+		return nil, err
+	}
+	vpc := vpcObj.(*SVpc)
+	if vpc.GlobalvpcId == self.Id {
+		return nil, nil
+	}
+	if len(vpc.GlobalvpcId) > 0 {
+		return nil, httperrors.NewInputParameterError("vpc %s already belongs to global vpc %s", vpc.Name, vpc.GlobalvpcId)
+	}
+
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	err = self.ValidateMemberCidr(vpc.Id, vpc.CidrBlock)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Update(vpc, func() error {
+		vpc.GlobalvpcId = self.Id
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_UPDATE, vpc.GetShortDesc(ctx), userCred, true)
+	return nil, nil
+}
+
+func (self *SGlobalVpc) PerformRemovevpc(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.GlobalVpcRemoveVpcInput) (jsonutils.JSONObject, error) {
+	if len(input.VpcId) == 0 {
+		return nil, httperrors.NewMissingParameterError("vpc_id")
+	}
+	vpcObj, err := validators.ValidateModel(userCred, VpcManager, &input.VpcId)
+	if err != nil {
+		return nil, err
+	}
+	vpc := vpcObj.(*SVpc)
+	if vpc.GlobalvpcId != self.Id {
+		return nil, httperrors.NewInputParameterError("vpc %s does not belong to global vpc %s", vpc.Name, self.Name)
+	}
+	// 云上原生的全局vpc成员由同步维护, 不能手动移除
+	if len(self.ManagerId) > 0 && vpc.ManagerId == self.ManagerId {
+		return nil, httperrors.NewUnsupportOperationError("vpc %s is native member of global vpc %s", vpc.Name, self.Name)
+	}
+	_, err = db.Update(vpc, func() error {
+		vpc.GlobalvpcId = ""
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_UPDATE, vpc.GetShortDesc(ctx), userCred, true)
+	return nil, nil
+}
+
+func (self *SGlobalVpc) GetDetailsAddressPlan(ctx context.Context, userCred mcclient.TokenCredential, input api.GlobalVpcAddressPlanInput) (*api.GlobalVpcAddressPlanDetails, error) {
+	prefixLen := input.PrefixLen
+	if prefixLen == 0 {
+		prefixLen = self.AddressPlanPrefixLen
+	}
+	if len(self.AddressPlan) > 0 {
+		prefixes, _ := parseCidrList(self.AddressPlan)
+		err := checkGlobalVpcPrefixLen(prefixes, prefixLen)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ret := &api.GlobalVpcAddressPlanDetails{
+		AddressPlan: self.AddressPlan,
+		PrefixLen:   prefixLen,
+		Used:        []api.GlobalVpcAddressPlanUsage{},
+	}
+	vpcs, err := self.getAddressPlanUsed("")
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	used := make([]string, len(vpcs))
+	for i := range vpcs {
+		used[i] = vpcs[i].CidrBlock
+		usage := api.GlobalVpcAddressPlanUsage{
+			VpcId:     vpcs[i].Id,
+			Vpc:       vpcs[i].Name,
+			CidrBlock: vpcs[i].CidrBlock,
+		}
+		if provider := vpcs[i].GetCloudprovider(); provider != nil {
+			usage.Provider = provider.Provider
+		} else {
+			usage.Provider = api.CLOUD_PROVIDER_ONECLOUD
+		}
+		ret.Used = append(ret.Used, usage)
+	}
+	if len(self.AddressPlan) > 0 {
+		ret.NextCidr, _ = allocateGlobalVpcCidr(self.AddressPlan, prefixLen, used)
+	}
+	return ret, nil
+}
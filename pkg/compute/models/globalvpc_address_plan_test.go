@@ -0,0 +1,97 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestValidateGlobalVpcAddressPlan(t *testing.T) {
+	cases := []struct {
+		plan      string
+		prefixLen int
+		want      string
+		wantErr   bool
+	}{
+		{"", 16, "", false},
+		{"10.0.0.0/8, 172.16.0.0/12", 16, "10.0.0.0/8,172.16.0.0/12", false},
+		{"10.1.2.3/16", 24, "10.1.0.0/16", false},
+		{"10.0.0.0/8,10.1.0.0/16", 24, "", true},
+		{"10.0.0.0/16", 8, "", true},
+		{"10.0.0.0/8", 30, "", true},
+		{"invalid", 16, "", true},
+	}
+	for _, c := range cases {
+		got, err := validateGlobalVpcAddressPlan(c.plan, c.prefixLen)
+		if (err != nil) != c.wantErr {
+			t.Errorf("plan %q: wantErr %v, got %v", c.plan, c.wantErr, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("plan %q: want %q, got %q", c.plan, c.want, got)
+		}
+	}
+}
+
+func TestAllocateGlobalVpcCidr(t *testing.T) {
+	cases := []struct {
+		name      string
+		plan      string
+		prefixLen int
+		used      []string
+		want      string
+		wantErr   bool
+	}{
+		{"empty", "10.0.0.0/8", 16, nil, "10.0.0.0/16", false},
+		{"skip used", "10.0.0.0/8", 16, []string{"10.0.0.0/16", "10.1.0.0/16"}, "10.2.0.0/16", false},
+		{"skip larger used", "10.0.0.0/8", 24, []string{"10.0.0.0/22"}, "10.0.4.0/24", false},
+		{"smaller used", "10.0.0.0/8", 16, []string{"10.0.3.0/24"}, "10.1.0.0/16", false},
+		{"next plan", "10.0.0.0/15,172.16.0.0/12", 16, []string{"10.0.0.0/16,10.1.0.0/16"}, "172.16.0.0/16", false},
+		{"exhausted", "10.0.0.0/16", 16, []string{"10.0.0.0/16"}, "", true},
+		{"prefix too long", "10.0.0.0/8", 33, []string{"10.0.0.0/16"}, "", true},
+		{"prefix too short", "10.0.0.0/8", 4, nil, "", true},
+		{"prefix shorter than plan", "10.0.0.0/16", 12, nil, "", true},
+	}
+	for _, c := range cases {
+		got, err := allocateGlobalVpcCidr(c.plan, c.prefixLen, c.used)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: wantErr %v, got %v", c.name, c.wantErr, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: want %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestIsCidrBlockInAddressPlan(t *testing.T) {
+	cases := []struct {
+		plan      string
+		cidrBlock string
+		want      bool
+	}{
+		{"", "192.168.0.0/16", true},
+		{"10.0.0.0/8", "10.1.0.0/16", true},
+		{"10.0.0.0/8", "10.1.0.0/16,192.168.0.0/24", false},
+		{"10.0.0.0/8,192.168.0.0/16", "10.1.0.0/16,192.168.0.0/24", true},
+		{"10.0.0.0/16", "10.0.0.0/8", false},
+	}
+	for _, c := range cases {
+		got := isCidrBlockInAddressPlan(c.plan, c.cidrBlock)
+		if got != c.want {
+			t.Errorf("plan %s cidr %s: want %v, got %v", c.plan, c.cidrBlock, c.want, got)
+		}
+	}
+}
@@ -64,6 +64,13 @@ type SGlobalVpc struct {
 	db.SExternalizedResourceBase
 
 	SManagedResourceBase
+
+	// 地址规划
+	// example: 10.0.0.0/8
+	AddressPlan string `width:"256" charset:"ascii" nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+
+	// 自动分配成员vpc网段时的默认掩码长度
+	AddressPlanPrefixLen int `nullable:"false" default:"16" list:"domain" create:"domain_optional" update:"domain"`
 }
 
 func (self *SGlobalVpc) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
@@ -138,6 +145,13 @@ func (manager *SGlobalVpcManager) ValidateCreateData(
 		return input, err
 	}
 	input.ManagerId = input.CloudproviderId
+	if input.AddressPlanPrefixLen == 0 {
+		input.AddressPlanPrefixLen = api.GLOBAL_VPC_DEFAULT_PREFIX_LEN
+	}
+	input.AddressPlan, err = validateGlobalVpcAddressPlan(input.AddressPlan, input.AddressPlanPrefixLen)
+	if err != nil {
+		return input, err
+	}
 	quota := &SDomainQuota{
 		SBaseDomainQuotaKeys: quotas.SBaseDomainQuotaKeys{
 			DomainId: ownerId.GetProjectDomainId(),
@@ -186,6 +200,30 @@ func (self *SGlobalVpc) ValidateUpdateData(
 	if err != nil {
 		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBase.ValidateUpdateData")
 	}
+	if input.AddressPlan != nil || input.AddressPlanPrefixLen != nil {
+		plan, prefixLen := self.AddressPlan, self.AddressPlanPrefixLen
+		if input.AddressPlan != nil {
+			plan = *input.AddressPlan
+		}
+		if input.AddressPlanPrefixLen != nil {
+			prefixLen = *input.AddressPlanPrefixLen
+		}
+		plan, err = validateGlobalVpcAddressPlan(plan, prefixLen)
+		if err != nil {
+			return input, err
+		}
+		vpcs, err := self.GetVpcs()
+		if err != nil {
+			return input, httperrors.NewGeneralError(err)
+		}
+		// 新的地址规划需覆盖已有成员vpc的网段
+		for i := range vpcs {
+			if !isCidrBlockInAddressPlan(plan, vpcs[i].CidrBlock) {
+				return input, httperrors.NewConflictError("vpc %s cidr %s is out of address plan %s", vpcs[i].Name, vpcs[i].CidrBlock, plan)
+			}
+		}
+		input.AddressPlan = &plan
+	}
 	return input, nil
 }
 
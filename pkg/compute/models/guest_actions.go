@@ -747,7 +747,14 @@ func (self *SGuest) PerformSetPassword(ctx context.Context, userCred mcclient.To
 			ResetPassword: input.ResetPassword,
 			AutoStart:     input.AutoStart,
 		}
-		return self.PerformDeploy(ctx, userCred, query, inputDeploy)
+		resetStatus, err := self.GetDriver().GetResetPasswordStatus()
+		if err != nil {
+			return self.PerformDeploy(ctx, userCred, query, inputDeploy)
+		}
+		if !utils.IsInStringArray(self.Status, resetStatus) {
+			return nil, httperrors.NewServerStatusError("Cannot reset password of %s server in status %s, expect status %s", self.Hypervisor, self.Status, strings.Join(resetStatus, ","))
+		}
+		return self.startDeploy(ctx, userCred, inputDeploy, resetStatus)
 	}
 }
 
@@ -778,6 +785,14 @@ func (self *SGuest) GetOldPassword(ctx context.Context, userCred mcclient.TokenC
 }
 
 func (self *SGuest) PerformDeploy(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerDeployInput) (jsonutils.JSONObject, error) {
+	deployStatus, err := self.GetDriver().GetDeployStatus()
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("%v", err)
+	}
+	return self.startDeploy(ctx, userCred, input, deployStatus)
+}
+
+func (self *SGuest) startDeploy(ctx context.Context, userCred mcclient.TokenCredential, input api.ServerDeployInput, deployStatus []string) (jsonutils.JSONObject, error) {
 	self.saveOldPassword(ctx, userCred)
 
 	if input.DeleteKeypair || len(input.KeypairId) > 0 {
@@ -821,11 +836,6 @@ func (self *SGuest) PerformDeploy(ctx context.Context, userCred mcclient.TokenCr
 		doRestart = self.GetDriver().IsNeedRestartForResetLoginInfo()
 	}
 
-	deployStatus, err := self.GetDriver().GetDeployStatus()
-	if err != nil {
-		return nil, httperrors.NewInputParameterError("%v", err)
-	}
-
 	if utils.IsInStringArray(self.Status, deployStatus) {
 		if (doRestart && self.Status == api.VM_RUNNING) || (self.Status != api.VM_RUNNING && (input.AutoStart || input.Restart)) {
 			input.Restart = true
@@ -1478,10 +1488,84 @@ func (self *SGuest) PerformPurge(ctx context.Context, userCred mcclient.TokenCre
 	opts := api.ServerDeleteInput{
 		Purge: true,
 	}
+	approval, err := OperationApprovalManager.requireApproval(ctx, userCred, self.DomainId, api.OPERATION_APPROVAL_ACTION_DELETE_SERVER, GuestManager.Keyword(), []string{self.Id}, jsonutils.Marshal(opts))
+	if err != nil {
+		return nil, err
+	}
+	if approval != nil {
+		return jsonutils.Marshal(approval), nil
+	}
 	err = self.StartDeleteGuestTask(ctx, userCred, "", opts)
 	return nil, err
 }
 
+func (self *SGuest) startBatchDelete(ctx context.Context, userCred mcclient.TokenCredential, opts api.ServerDeleteInput) error {
+	var err error
+	if opts.Purge {
+		err = self.ValidatePurgeCondition(ctx)
+	} else {
+		err = self.ValidateDeleteCondition(ctx, nil)
+	}
+	if err != nil {
+		return err
+	}
+	return self.StartDeleteGuestTask(ctx, userCred, "", opts)
+}
+
+// 批量删除虚拟机, 该域审批策略要求审批时为同一域内的虚拟机创建一个审批请求
+func (manager *SGuestManager) PerformBatchDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerBatchDeleteInput) (*api.ServerBatchDeleteOutput, error) {
+	if len(input.Servers) == 0 {
+		return nil, httperrors.NewMissingParameterError("servers")
+	}
+	guests := map[string][]*SGuest{}
+	domainIds := []string{}
+	for _, id := range input.Servers {
+		obj, err := manager.FetchByIdOrName(userCred, id)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(manager.Keyword(), id)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		guest := obj.(*SGuest)
+		if !guest.AllowDeleteItem(ctx, userCred, jsonutils.Marshal(input.ServerDeleteInput), nil) {
+			return nil, httperrors.NewForbiddenError("not allow to delete server %s", guest.Name)
+		}
+		if _, ok := guests[guest.DomainId]; !ok {
+			domainIds = append(domainIds, guest.DomainId)
+		}
+		guests[guest.DomainId] = append(guests[guest.DomainId], guest)
+	}
+	ret := &api.ServerBatchDeleteOutput{Deleted: []string{}, Approvals: []string{}}
+	errs := []error{}
+	for _, domainId := range domainIds {
+		ids := []string{}
+		for _, guest := range guests[domainId] {
+			ids = append(ids, guest.Id)
+		}
+		approval, err := OperationApprovalManager.requireApproval(ctx, userCred, domainId, api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER, manager.Keyword(), ids, jsonutils.Marshal(input.ServerDeleteInput))
+		if err != nil {
+			return nil, err
+		}
+		if approval != nil {
+			ret.Approvals = append(ret.Approvals, approval.Id)
+			continue
+		}
+		for _, guest := range guests[domainId] {
+			err = guest.startBatchDelete(ctx, userCred, input.ServerDeleteInput)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "delete server %s", guest.Name))
+				continue
+			}
+			ret.Deleted = append(ret.Deleted, guest.Id)
+		}
+	}
+	if len(errs) > 0 {
+		return ret, errors.NewAggregate(errs)
+	}
+	return ret, nil
+}
+
 func (self *SGuest) setKeypairId(userCred mcclient.TokenCredential, keypairId string) error {
 	diff, err := db.Update(self, func() error {
 		self.KeypairId = keypairId
@@ -2415,6 +2499,15 @@ func (self *SGuest) PerformChangeBandwidth(ctx context.Context, userCred mcclien
 		return nil, err
 	}
 
+	mode := self.GetDriver().GetNicQosMode()
+	if mode == api.NIC_QOS_MODE_EIP {
+		err = validateNicQosBandwidth(mode, int(bandwidth))
+		if err != nil {
+			return nil, err
+		}
+		return nil, self.setEipQos(ctx, userCred, int(bandwidth))
+	}
+
 	if guestnic.BwLimit != int(bandwidth) {
 		diff, err := db.Update(guestnic, func() error {
 			guestnic.BwLimit = int(bandwidth)
@@ -2821,7 +2914,13 @@ func (self *SGuest) SetStatus(userCred mcclient.TokenCredential, status, reason
 		}
 	}
 
-	return self.SVirtualResourceBase.SetStatus(userCred, status, reason)
+	oldStatus := self.Status
+	err := self.SVirtualResourceBase.SetStatus(userCred, status, reason)
+	if err != nil {
+		return err
+	}
+	GuestAvailabilityRecordManager.recordStatusChange(context.Background(), self, oldStatus, api.GUEST_AVAILABILITY_SOURCE_LOCAL)
+	return nil
 }
 
 func (self *SGuest) SetPowerStates(powerStates string) error {
@@ -2914,6 +3013,10 @@ func (self *SGuest) PerformStatus(ctx context.Context, userCred mcclient.TokenCr
 
 func (self *SGuest) PerformStop(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject,
 	input api.ServerStopInput) (jsonutils.JSONObject, error) {
+	err := self.validateStopLock()
+	if err != nil {
+		return nil, err
+	}
 	// XXX if is force, force stop guest
 	if input.IsForce || utils.IsInStringArray(self.Status, []string{api.VM_RUNNING, api.VM_STOP_FAILED}) {
 		if err := self.ValidateEncryption(ctx, userCred); err != nil {
@@ -3199,7 +3302,12 @@ func (self *SGuest) PerformCreateEip(ctx context.Context, userCred mcclient.Toke
 		InstanceType:       api.EIP_ASSOCIATE_TYPE_SERVER,
 	}
 
-	err = eip.AllocateAndAssociateInstance(ctx, userCred, self, opts, "")
+	if len(eip.ExternalId) > 0 {
+		// reused from eip pool, association only
+		err = eip.StartEipAssociateInstanceTask(ctx, userCred, opts, "")
+	} else {
+		err = eip.AllocateAndAssociateInstance(ctx, userCred, self, opts, "")
+	}
 	if err != nil {
 		return nil, httperrors.NewGeneralError(err)
 	}
@@ -3215,7 +3323,7 @@ func (self *SGuest) setUserData(ctx context.Context, userCred mcclient.TokenCred
 	if err != nil {
 		return errors.Wrap(err, "encode guest userdata")
 	}
-	err = self.SetMetadata(ctx, "user_data", encodeData, userCred)
+	err = self.SetMetadata(ctx, api.VM_METADATA_USER_DATA, encodeData, userCred)
 	if err != nil {
 		return err
 	}
@@ -3223,7 +3331,7 @@ func (self *SGuest) setUserData(ctx context.Context, userCred mcclient.TokenCred
 }
 
 func (self *SGuest) GetUserData(ctx context.Context, userCred mcclient.TokenCredential) string {
-	userData := self.GetMetadata(ctx, "user_data", userCred)
+	userData := self.GetMetadata(ctx, api.VM_METADATA_USER_DATA, userCred)
 	if len(userData) == 0 {
 		return userData
 	}
@@ -3231,20 +3339,65 @@ func (self *SGuest) GetUserData(ctx context.Context, userCred mcclient.TokenCred
 	return decodeData
 }
 
+// 记录实际下发到平台的 user data, 便于审计
+func (self *SGuest) SetRenderedUserData(ctx context.Context, userCred mcclient.TokenCredential, data string) error {
+	encodeData := ""
+	if len(data) > 0 {
+		var err error
+		encodeData, err = userdata.Encode(data)
+		if err != nil {
+			return errors.Wrap(err, "encode rendered userdata")
+		}
+	}
+	return self.SetMetadata(ctx, api.VM_METADATA_RENDERED_USER_DATA, encodeData, userCred)
+}
+
+func (self *SGuest) GetRenderedUserData(ctx context.Context) string {
+	userData := self.GetMetadata(ctx, api.VM_METADATA_RENDERED_USER_DATA, nil)
+	if len(userData) == 0 {
+		return userData
+	}
+	decodeData, _ := userdata.Decode(userData)
+	return decodeData
+}
+
+// user data可能包含敏感信息, 仅虚拟机所属项目成员及管理员可查看
+func (self *SGuest) GetDetailsUserData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.ServerUserDataOutput, error) {
+	if !self.IsOwner(userCred) && !db.IsAdminAllowGetSpec(ctx, userCred, self, "user-data") {
+		return nil, httperrors.NewForbiddenError("not allow to get user data of server %s", self.Name)
+	}
+	return &api.ServerUserDataOutput{
+		UserData:         self.GetUserData(ctx, userCred),
+		RenderedUserData: self.GetRenderedUserData(ctx),
+	}, nil
+}
+
 func (self *SGuest) PerformUserData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerUserDataInput) (jsonutils.JSONObject, error) {
 	if len(input.UserData) == 0 {
 		return nil, httperrors.NewMissingParameterError("user_data")
 	}
+	if len(self.HostId) > 0 && !self.GetDriver().IsSupportUpdateUserData() {
+		return nil, httperrors.NewUnsupportOperationError("%s not support update user data", self.Hypervisor)
+	}
 	err := self.setUserData(ctx, userCred, input.UserData)
 	if err != nil {
 		return nil, httperrors.NewGeneralError(err)
 	}
 	if len(self.HostId) > 0 {
-		return nil, self.StartSyncTask(ctx, userCred, false, "")
+		return nil, self.startSyncUserDataTask(ctx, userCred)
 	}
 	return nil, nil
 }
 
+func (self *SGuest) startSyncUserDataTask(ctx context.Context, userCred mcclient.TokenCredential) error {
+	if err := self.SetStatus(userCred, api.VM_SYNC_CONFIG, "update user data"); err != nil {
+		return err
+	}
+	data := jsonutils.NewDict()
+	data.Set("user_data", jsonutils.JSONTrue)
+	return self.doSyncTask(ctx, data, userCred, "")
+}
+
 func (self *SGuest) PerformSetQemuParams(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
 	isaSerial, err := data.GetString("disable_isa_serial")
 	if err == nil {
@@ -4443,6 +4596,25 @@ func (self *SGuest) PerformSyncFixNics(ctx context.Context,
 }
 
 func (guest *SGuest) PerformChangeOwner(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformChangeProjectOwnerInput) (jsonutils.JSONObject, error) {
+	if len(input.ProjectId) > 0 {
+		tenant, err := db.TenantCacheManager.FetchTenantByIdOrName(ctx, input.ProjectId)
+		if err != nil {
+			return nil, httperrors.NewNotFoundError("project %s not found", input.ProjectId)
+		}
+		if tenant.DomainId != guest.DomainId {
+			approval, err := OperationApprovalManager.requireApproval(ctx, userCred, guest.DomainId, api.OPERATION_APPROVAL_ACTION_CHANGE_PROJECT_CROSS_DOMAIN, GuestManager.Keyword(), []string{guest.Id}, jsonutils.Marshal(input))
+			if err != nil {
+				return nil, err
+			}
+			if approval != nil {
+				return jsonutils.Marshal(approval), nil
+			}
+		}
+	}
+	return guest.changeOwner(ctx, userCred, query, input)
+}
+
+func (guest *SGuest) changeOwner(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformChangeProjectOwnerInput) (jsonutils.JSONObject, error) {
 	disks, err := guest.GetDisks()
 	if err != nil {
 		return nil, errors.Wrapf(err, "GetDisks")
@@ -4509,7 +4681,7 @@ func (guest *SGuest) PerformResizeDisk(ctx context.Context, userCred mcclient.To
 	if err != nil {
 		return nil, err
 	}
-	err = disk.doResize(ctx, userCred, sizeMb, guest)
+	err = disk.doResize(ctx, userCred, sizeMb, guest, input.DiskResizeInput)
 	if err != nil {
 		return nil, err
 	}
@@ -4517,10 +4689,22 @@ func (guest *SGuest) PerformResizeDisk(ctx context.Context, userCred mcclient.To
 }
 
 func (guest *SGuest) StartGuestDiskResizeTask(ctx context.Context, userCred mcclient.TokenCredential, diskId string, sizeMb int64, parentTaskId string, pendingUsage quotas.IQuota) error {
-	guest.SetStatus(userCred, api.VM_START_RESIZE_DISK, "StartGuestDiskResizeTask")
+	return guest.startGuestDiskResizeTask(ctx, userCred, diskId, sizeMb, false, false, parentTaskId, pendingUsage)
+}
+
+// offline: 先关机, 扩容完成后再开机
+func (guest *SGuest) startGuestDiskResizeTask(ctx context.Context, userCred mcclient.TokenCredential, diskId string, sizeMb int64, growFs, offline bool, parentTaskId string, pendingUsage quotas.IQuota) error {
 	params := jsonutils.NewDict()
 	params.Add(jsonutils.NewInt(sizeMb), "size")
 	params.Add(jsonutils.NewString(diskId), "disk_id")
+	// 关机扩容时由cloud-init在开机时扩展文件系统
+	if growFs && !offline && guest.Status == api.VM_RUNNING {
+		params.Add(jsonutils.JSONTrue, "grow_fs")
+	}
+	guest.SetStatus(userCred, api.VM_START_RESIZE_DISK, "StartGuestDiskResizeTask")
+	if offline {
+		params.Add(jsonutils.JSONTrue, "offline")
+	}
 	task, err := taskman.TaskManager.NewTask(ctx, "GuestResizeDiskTask", guest, userCred, params, parentTaskId, "", pendingUsage)
 	if err != nil {
 		return err
@@ -0,0 +1,286 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type sChangeProjectItem struct {
+	resourceType string
+	model        db.IVirtualModel
+	change       func(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformChangeProjectOwnerInput) (jsonutils.JSONObject, error)
+	// 原项目, 用于失败回滚
+	projectId string
+}
+
+func (item *sChangeProjectItem) resource(reason string) api.ServerChangeProjectResource {
+	return api.ServerChangeProjectResource{
+		ResourceType: item.resourceType,
+		Id:           item.model.GetId(),
+		Name:         item.model.GetName(),
+		Reason:       reason,
+	}
+}
+
+type sChangeProjectPlan struct {
+	target *db.STenant
+	items  []*sChangeProjectItem
+	// 虚拟机在依赖资源之后更换项目
+	guests   []*sChangeProjectItem
+	seen     map[string]bool
+	blockers []api.ServerChangeProjectResource
+}
+
+func (plan *sChangeProjectPlan) add(item *sChangeProjectItem) bool {
+	key := item.resourceType + "/" + item.model.GetId()
+	if plan.seen[key] || item.model.GetOwnerId().GetProjectId() == plan.target.Id {
+		return false
+	}
+	plan.seen[key] = true
+	item.projectId = item.model.GetOwnerId().GetProjectId()
+	if plan.isNameConflict(item.model) {
+		plan.block(item, fmt.Sprintf("name %s duplicated in project %s", item.model.GetName(), plan.target.Name))
+	}
+	return true
+}
+
+func (plan *sChangeProjectPlan) block(item *sChangeProjectItem, reason string) {
+	plan.blockers = append(plan.blockers, item.resource(reason))
+}
+
+func (plan *sChangeProjectPlan) isNameConflict(model db.IVirtualModel) bool {
+	manager := model.GetModelManager()
+	q := manager.Query().Equals("name", model.GetName())
+	q = manager.FilterByOwner(q, plan.target, manager.NamespaceScope())
+	q = manager.FilterBySystemAttributes(q, nil, nil, manager.ResourceScope())
+	q = q.NotEquals("id", model.GetId())
+	cnt, err := q.CountWithError()
+	if err != nil {
+		log.Errorf("check name duplication of %s %s error: %v", manager.Keyword(), model.GetName(), err)
+		return false
+	}
+	return cnt > 0
+}
+
+// 判断安全组是否随虚拟机更换项目: 被批次外虚拟机使用或已共享的安全组不能迁移, 此时目标项目需可使用该安全组
+func getSecgroupChangeProjectAction(usedOutside, shared, accessible bool) (bool, string) {
+	if accessible {
+		return false, ""
+	}
+	if shared {
+		return false, "shared secgroup is not accessible from target project"
+	}
+	if usedOutside {
+		return false, "secgroup is used by servers outside the batch"
+	}
+	return true, ""
+}
+
+func (plan *sChangeProjectPlan) addGuest(ctx context.Context, guest *SGuest, guestIds map[string]bool) error {
+	item := &sChangeProjectItem{resourceType: GuestManager.Keyword(), model: guest, change: guest.SVirtualResourceBase.PerformChangeOwner}
+	if plan.add(item) {
+		plan.guests = append(plan.guests, item)
+		if plan.target.DomainId != guest.DomainId {
+			policy, err := OperationApprovalPolicyManager.getPolicy(guest.DomainId, api.OPERATION_APPROVAL_ACTION_CHANGE_PROJECT_CROSS_DOMAIN)
+			if err != nil {
+				return errors.Wrapf(err, "getPolicy")
+			}
+			if policy != nil {
+				plan.block(item, "cross domain change requires approval")
+			}
+		}
+	}
+
+	disks, err := guest.GetDisks()
+	if err != nil {
+		return errors.Wrapf(err, "GetDisks")
+	}
+	for i := range disks {
+		disk := &disks[i]
+		item := &sChangeProjectItem{resourceType: DiskManager.Keyword(), model: disk, change: disk.PerformChangeOwner}
+		if !plan.add(item) {
+			continue
+		}
+		plan.items = append(plan.items, item)
+		for _, g := range disk.GetGuests() {
+			if !guestIds[g.Id] {
+				plan.block(item, fmt.Sprintf("disk is attached to server %s outside the batch", g.Name))
+			}
+		}
+	}
+
+	eip, err := guest.GetEipOrPublicIp()
+	if err != nil && errors.Cause(err) != sql.ErrNoRows {
+		return errors.Wrapf(err, "GetEipOrPublicIp")
+	}
+	if eip != nil {
+		item := &sChangeProjectItem{resourceType: ElasticipManager.Keyword(), model: eip, change: eip.PerformChangeOwner}
+		if plan.add(item) {
+			plan.items = append(plan.items, item)
+		}
+	}
+
+	isps, err := guest.GetInstanceSnapshots()
+	if err != nil {
+		return errors.Wrapf(err, "GetInstanceSnapshots")
+	}
+	for i := range isps {
+		isp := &isps[i]
+		item := &sChangeProjectItem{resourceType: InstanceSnapshotManager.Keyword(), model: isp, change: isp.PerformChangeOwner}
+		if plan.add(item) {
+			plan.items = append(plan.items, item)
+		}
+	}
+
+	secgroups, err := guest.GetSecgroups()
+	if err != nil {
+		return errors.Wrapf(err, "GetSecgroups")
+	}
+	for i := range secgroups {
+		secgroup := &secgroups[i]
+		key := SecurityGroupManager.Keyword() + "/" + secgroup.Id
+		if plan.seen[key] || secgroup.ProjectId == plan.target.Id {
+			continue
+		}
+		usedOutside := false
+		for _, g := range secgroup.GetGuests() {
+			if !guestIds[g.Id] {
+				usedOutside = true
+				break
+			}
+		}
+		shared := db.SharableModelIsShared(secgroup)
+		accessible := (usedOutside || shared) && db.SharableModelIsSharable(secgroup, plan.target)
+		move, reason := getSecgroupChangeProjectAction(usedOutside, shared, accessible)
+		item := &sChangeProjectItem{resourceType: SecurityGroupManager.Keyword(), model: secgroup, change: secgroup.PerformChangeOwner}
+		if move {
+			if plan.add(item) {
+				plan.items = append(plan.items, item)
+			}
+			continue
+		}
+		plan.seen[key] = true
+		if len(reason) > 0 {
+			plan.block(item, reason)
+		}
+	}
+	return nil
+}
+
+// 按逆序将已更换项目的资源恢复到原项目, 返回未能恢复的资源
+func rollbackChangeProject(ctx context.Context, userCred mcclient.TokenCredential, moved []*sChangeProjectItem) []string {
+	failed := []string{}
+	for i := len(moved) - 1; i >= 0; i-- {
+		input := apis.PerformChangeProjectOwnerInput{}
+		input.ProjectId = moved[i].projectId
+		_, err := moved[i].change(ctx, userCred, nil, input)
+		if err != nil {
+			log.Errorf("rollback project of %s %s error: %v", moved[i].resourceType, moved[i].model.GetName(), err)
+			failed = append(failed, moved[i].resourceType+"/"+moved[i].model.GetName())
+		}
+	}
+	return failed
+}
+
+// 批量更换虚拟机项目, 磁盘及其快照、弹性公网IP、主机快照和仅被批次内虚拟机使用的安全组一并更换
+// 更换前检查所有依赖资源, 任一资源阻止更换时不更换任何资源
+// 资源逐个更换项目, 不是事务操作: 中途出错时尽力将已更换的资源恢复到原项目, 恢复失败的资源在错误信息中列出
+func (manager *SGuestManager) PerformBatchChangeProject(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerBatchChangeProjectInput) (*api.ServerBatchChangeProjectOutput, error) {
+	if len(input.Servers) == 0 {
+		return nil, httperrors.NewMissingParameterError("servers")
+	}
+	if len(input.ProjectId) == 0 {
+		return nil, httperrors.NewMissingParameterError("project_id")
+	}
+	tenant, err := db.TenantCacheManager.FetchTenantByIdOrName(ctx, input.ProjectId)
+	if err != nil {
+		return nil, httperrors.NewNotFoundError("project %s not found", input.ProjectId)
+	}
+	input.ProjectId = tenant.Id
+
+	guests := []*SGuest{}
+	guestIds := map[string]bool{}
+	for _, id := range input.Servers {
+		obj, err := manager.FetchByIdOrName(userCred, id)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(manager.Keyword(), id)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		guest := obj.(*SGuest)
+		if !guestIds[guest.Id] {
+			guestIds[guest.Id] = true
+			guests = append(guests, guest)
+		}
+	}
+
+	plan := &sChangeProjectPlan{target: tenant, seen: map[string]bool{}, blockers: []api.ServerChangeProjectResource{}}
+	for _, guest := range guests {
+		err = plan.addGuest(ctx, guest, guestIds)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "plan server %s", guest.Name))
+		}
+	}
+	items := append(plan.items, plan.guests...)
+	ret := &api.ServerBatchChangeProjectOutput{Moved: []api.ServerChangeProjectResource{}, Blockers: plan.blockers}
+	for _, item := range items {
+		ret.Moved = append(ret.Moved, item.resource(""))
+	}
+	if len(ret.Blockers) > 0 || input.DryRun {
+		return ret, nil
+	}
+
+	moved := []*sChangeProjectItem{}
+	for _, item := range items {
+		err = func() error {
+			lockman.LockObject(ctx, item.model)
+			defer lockman.ReleaseObject(ctx, item.model)
+
+			_, err := item.change(ctx, userCred, query, input.PerformChangeProjectOwnerInput)
+			return err
+		}()
+		if err != nil {
+			failed := rollbackChangeProject(ctx, userCred, moved)
+			if len(failed) > 0 {
+				return nil, errors.Wrapf(err, "change project of %s %s, rollback failed for %s", item.resourceType, item.model.GetName(), strings.Join(failed, ","))
+			}
+			return nil, errors.Wrapf(err, "change project of %s %s", item.resourceType, item.model.GetName())
+		}
+		moved = append(moved, item)
+	}
+	for _, guest := range guests {
+		err = guest.StartSyncTask(ctx, userCred, false, "")
+		if err != nil {
+			log.Errorf("start sync task for server %s error: %v", guest.Name, err)
+		}
+	}
+	return ret, nil
+}
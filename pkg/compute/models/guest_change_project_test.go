@@ -0,0 +1,45 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "testing"
+
+func TestGetSecgroupChangeProjectAction(t *testing.T) {
+	cases := []struct {
+		name        string
+		usedOutside bool
+		shared      bool
+		accessible  bool
+		move        bool
+		blocked     bool
+	}{
+		{name: "only used in batch", move: true},
+		{name: "used outside and accessible", usedOutside: true, accessible: true},
+		{name: "used outside", usedOutside: true, blocked: true},
+		{name: "shared and accessible", shared: true, accessible: true},
+		{name: "shared not accessible", shared: true, blocked: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			move, reason := getSecgroupChangeProjectAction(c.usedOutside, c.shared, c.accessible)
+			if move != c.move {
+				t.Errorf("want move %v, got %v", c.move, move)
+			}
+			if (len(reason) > 0) != c.blocked {
+				t.Errorf("want blocked %v, got reason %q", c.blocked, reason)
+			}
+		})
+	}
+}
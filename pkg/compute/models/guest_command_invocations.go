@@ -0,0 +1,342 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// 输出保留的最大字节数
+const guestCommandOutputMaxBytes = 64 * 1024
+
+type SGuestCommandInvocationManager struct {
+	db.SStatusStandaloneResourceBaseManager
+	db.SProjectizedResourceBaseManager
+	SGuestResourceBaseManager
+}
+
+var GuestCommandInvocationManager *SGuestCommandInvocationManager
+
+func init() {
+	GuestCommandInvocationManager = &SGuestCommandInvocationManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SGuestCommandInvocation{},
+			"guest_command_invocations_tbl",
+			"guest_command_invocation",
+			"guest_command_invocations",
+		),
+	}
+	GuestCommandInvocationManager.SetVirtualObject(GuestCommandInvocationManager)
+}
+
+// 主机命令执行记录
+type SGuestCommandInvocation struct {
+	db.SStatusStandaloneResourceBase
+	db.SProjectizedResourceBase
+	SGuestResourceBase
+
+	// 执行通道
+	Channel string `width:"16" charset:"ascii" nullable:"true" list:"user"`
+	// 命令类型
+	CommandType string `width:"16" charset:"ascii" nullable:"false" list:"user"`
+	// 脚本内容
+	Script string `length:"text" nullable:"false" get:"user"`
+	// 超时时间(秒)
+	Timeout  int `nullable:"false" default:"60" list:"user"`
+	ExitCode int `nullable:"true" list:"user"`
+	// 标准输出
+	Output string `length:"medium" nullable:"true" get:"user"`
+	// 标准错误输出
+	ErrOutput string `length:"medium" nullable:"true" get:"user"`
+	// 输出是否被截断
+	OutputTruncated bool `nullable:"false" default:"false" list:"user"`
+	// 执行人
+	Operator   string    `width:"128" charset:"utf8" nullable:"true" list:"user"`
+	StartedAt  time.Time `nullable:"true" list:"user"`
+	FinishedAt time.Time `nullable:"true" list:"user"`
+}
+
+func (manager *SGuestCommandInvocationManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return nil, httperrors.NewUnsupportOperationError("use server run-command instead")
+}
+
+func (manager *SGuestCommandInvocationManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.GuestCommandInvocationListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SGuestResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ServerFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SGuestResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Channel) > 0 {
+		q = q.In("channel", query.Channel)
+	}
+	if len(query.CommandType) > 0 {
+		q = q.In("command_type", query.CommandType)
+	}
+	if len(query.Operator) > 0 {
+		q = q.Equals("operator", query.Operator)
+	}
+	return q, nil
+}
+
+func (manager *SGuestCommandInvocationManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.GuestCommandInvocationListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SGuestResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ServerFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SGuestResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SGuestCommandInvocationManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SGuestResourceBaseManager,
+	)
+}
+
+func (manager *SGuestCommandInvocationManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	return db.ApplyListItemExportKeys(ctx, q, userCred, keys,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SGuestResourceBaseManager,
+	)
+}
+
+func (manager *SGuestCommandInvocationManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.GuestCommandInvocationDetails {
+	rows := make([]api.GuestCommandInvocationDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	guestRows := manager.SGuestResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.GuestCommandInvocationDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+			ProjectizedResourceInfo:         projRows[i],
+			GuestResourceInfo:               guestRows[i],
+		}
+		invocation := objs[i].(*SGuestCommandInvocation)
+		if !invocation.StartedAt.IsZero() && !invocation.FinishedAt.IsZero() {
+			rows[i].Duration = int(invocation.FinishedAt.Sub(invocation.StartedAt).Seconds())
+		}
+	}
+	return rows
+}
+
+// 截断过长的输出, 保留末尾部分
+func truncateGuestCommandOutput(output string, maxBytes int) (string, bool) {
+	if len(output) <= maxBytes {
+		return output, false
+	}
+	return output[len(output)-maxBytes:], true
+}
+
+func (self *SGuest) getCommandChannel() string {
+	if self.GetHypervisor() == api.HYPERVISOR_KVM {
+		return api.GUEST_COMMAND_CHANNEL_QGA
+	}
+	return ""
+}
+
+// 在主机内执行脚本, 目前仅支持KVM通过qemu-guest-agent执行
+func (self *SGuest) PerformRunCommand(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerRunCommandInput) (jsonutils.JSONObject, error) {
+	channel := self.getCommandChannel()
+	if len(channel) == 0 {
+		return nil, httperrors.NewNotSupportedError("run command on %s server", self.GetHypervisor())
+	}
+	if self.Status != api.VM_RUNNING {
+		return nil, httperrors.NewInvalidStatusError("can't run command in vm status: %s", self.Status)
+	}
+	if len(input.Script) == 0 {
+		return nil, httperrors.NewMissingParameterError("script")
+	}
+	if len(input.CommandType) == 0 {
+		input.CommandType = api.GUEST_COMMAND_TYPE_SHELL
+		if self.IsWindows() {
+			input.CommandType = api.GUEST_COMMAND_TYPE_POWERSHELL
+		}
+	}
+	if !utils.IsInStringArray(input.CommandType, api.GUEST_COMMAND_TYPES) {
+		return nil, httperrors.NewInputParameterError("invalid command_type %s", input.CommandType)
+	}
+	if input.Timeout <= 0 {
+		input.Timeout = 60
+	}
+	if input.Timeout > options.Options.GuestCommandMaxTimeoutSeconds {
+		return nil, httperrors.NewOutOfRangeError("timeout should not exceed %d seconds", options.Options.GuestCommandMaxTimeoutSeconds)
+	}
+
+	invocation := &SGuestCommandInvocation{
+		Channel:     channel,
+		CommandType: input.CommandType,
+		Script:      input.Script,
+		Timeout:     input.Timeout,
+		Operator:    userCred.GetUserName(),
+	}
+	invocation.SetModelManager(GuestCommandInvocationManager, invocation)
+	invocation.GuestId = self.Id
+	invocation.Name = self.Name
+	invocation.ProjectId = self.ProjectId
+	invocation.DomainId = self.DomainId
+	invocation.Status = api.GUEST_COMMAND_STATUS_PENDING
+	err := GuestCommandInvocationManager.TableSpec().Insert(ctx, invocation)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	err = invocation.StartRunTask(ctx, userCred, self, "")
+	if err != nil {
+		return nil, err
+	}
+	return jsonutils.Marshal(map[string]string{"invocation_id": invocation.Id}), nil
+}
+
+func (self *SGuestCommandInvocation) StartRunTask(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, parentTaskId string) error {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(self.Id), "invocation_id")
+	task, err := taskman.TaskManager.NewTask(ctx, "GuestRunCommandTask", guest, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask")
+	}
+	return task.ScheduleRun(nil)
+}
+
+func (self *SGuestCommandInvocation) finish(status string, exitCode int, output, errOutput string, truncated bool) error {
+	output, outTruncated := truncateGuestCommandOutput(output, guestCommandOutputMaxBytes)
+	errOutput, errTruncated := truncateGuestCommandOutput(errOutput, guestCommandOutputMaxBytes)
+	_, err := db.Update(self, func() error {
+		self.Status = status
+		self.ExitCode = exitCode
+		self.Output = output
+		self.ErrOutput = errOutput
+		self.OutputTruncated = truncated || outTruncated || errTruncated
+		self.FinishedAt = time.Now().UTC()
+		return nil
+	})
+	return err
+}
+
+func (self *SGuestCommandInvocation) runByQga(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest) error {
+	host, err := guest.GetHost()
+	if err != nil {
+		return errors.Wrapf(err, "GetHost")
+	}
+	path, args := "/bin/sh", []string{"-c", self.Script}
+	if self.CommandType == api.GUEST_COMMAND_TYPE_POWERSHELL {
+		path, args = "powershell.exe", []string{"-NoProfile", "-NonInteractive", "-Command", self.Script}
+	}
+	ret, err := guest.qgaExec(ctx, userCred, host, path, args, time.Duration(self.Timeout)*time.Second)
+	if err != nil {
+		if errors.Cause(err) == httperrors.ErrTimeout {
+			return self.finish(api.GUEST_COMMAND_STATUS_TIMEOUT, -1, "", err.Error(), false)
+		}
+		return err
+	}
+	status := api.GUEST_COMMAND_STATUS_SUCCESS
+	if ret.ExitCode != 0 {
+		status = api.GUEST_COMMAND_STATUS_FAILED
+	}
+	return self.finish(status, ret.ExitCode, ret.Output, ret.ErrOutput, ret.Truncated)
+}
+
+// 执行命令并记录结果, 返回的错误表示命令未能下发或获取结果失败
+func (self *SGuestCommandInvocation) Run(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest) error {
+	_, err := db.Update(self, func() error {
+		self.Status = api.GUEST_COMMAND_STATUS_RUNNING
+		self.StartedAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "update status")
+	}
+	switch self.Channel {
+	case api.GUEST_COMMAND_CHANNEL_QGA:
+		err = self.runByQga(ctx, userCred, guest)
+	default:
+		err = errors.Wrapf(httperrors.ErrNotSupported, "channel %s", self.Channel)
+	}
+	if err != nil {
+		if e := self.finish(api.GUEST_COMMAND_STATUS_FAILED, -1, "", err.Error(), false); e != nil {
+			log.Errorf("update command invocation %s error: %v", self.Id, e)
+		}
+		return err
+	}
+	return nil
+}
+
+func (manager *SGuestCommandInvocationManager) deleteByGuest(ctx context.Context, userCred mcclient.TokenCredential, guestId string) error {
+	invocations := []SGuestCommandInvocation{}
+	err := db.FetchModelObjects(manager, manager.Query().Equals("guest_id", guestId), &invocations)
+	if err != nil {
+		return errors.Wrap(err, "fetch command invocations")
+	}
+	for i := range invocations {
+		err := invocations[i].Delete(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "delete command invocation %s", invocations[i].Id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "testing"
+
+func TestTruncateGuestCommandOutput(t *testing.T) {
+	cases := []struct {
+		output    string
+		maxBytes  int
+		want      string
+		truncated bool
+	}{
+		{"hello", 10, "hello", false},
+		{"hello", 5, "hello", false},
+		{"hello world", 5, "world", true},
+		{"", 5, "", false},
+	}
+	for _, c := range cases {
+		got, truncated := truncateGuestCommandOutput(c.output, c.maxBytes)
+		if got != c.want || truncated != c.truncated {
+			t.Errorf("truncate(%q, %d): want %q %v got %q %v", c.output, c.maxBytes, c.want, c.truncated, got, truncated)
+		}
+	}
+}
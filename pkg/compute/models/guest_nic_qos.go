@@ -0,0 +1,114 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+func validateNicQosBandwidth(mode string, bandwidthMb int) error {
+	switch mode {
+	case api.NIC_QOS_MODE_TC:
+	case api.NIC_QOS_MODE_EIP:
+		// 弹性公网IP带宽不能为0
+		if bandwidthMb == 0 {
+			return httperrors.NewInputParameterError("bandwidth_mb of eip should be greater than 0")
+		}
+	default:
+		return httperrors.NewNotSupportedError("nic qos is not supported")
+	}
+	if bandwidthMb < 0 || bandwidthMb > api.MAX_BANDWIDTH {
+		return httperrors.NewInputParameterError("bandwidth_mb should be between 0 and %d", api.MAX_BANDWIDTH)
+	}
+	return nil
+}
+
+// 获取网卡限速能力及各网卡当前限速
+func (self *SGuest) GetDetailsNicQos(ctx context.Context, userCred mcclient.TokenCredential, input api.ServerNicQosInput) (*api.ServerNicQosOutput, error) {
+	mode := self.GetDriver().GetNicQosMode()
+	ret := &api.ServerNicQosOutput{
+		Mode:      mode,
+		Supported: mode != api.NIC_QOS_MODE_NONE,
+		Nics:      []api.ServerNicQos{},
+	}
+	if mode == api.NIC_QOS_MODE_EIP {
+		eip, err := self.getQosEip()
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		if eip != nil {
+			ret.EipBandwidthMb = eip.Bandwidth
+		}
+	}
+	gns, err := self.GetNetworks("")
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	for i := range gns {
+		ret.Nics = append(ret.Nics, api.ServerNicQos{
+			Index:       i,
+			IpAddr:      gns[i].IpAddr,
+			Mac:         gns[i].MacAddr,
+			BandwidthMb: gns[i].BwLimit,
+		})
+	}
+	return ret, nil
+}
+
+// 设置网卡限速, 根据平台能力通过宿主机tc或弹性公网IP带宽生效
+func (self *SGuest) PerformSetNicQos(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerSetNicQosInput) (jsonutils.JSONObject, error) {
+	err := validateNicQosBandwidth(self.GetDriver().GetNicQosMode(), input.BandwidthMb)
+	if err != nil {
+		return nil, err
+	}
+	params := jsonutils.NewDict()
+	params.Set("bandwidth", jsonutils.NewInt(int64(input.BandwidthMb)))
+	params.Set("ip_addr", jsonutils.NewString(input.IpAddr))
+	params.Set("mac", jsonutils.NewString(input.Mac))
+	params.Set("index", jsonutils.NewInt(int64(input.Index)))
+	return self.PerformChangeBandwidth(ctx, userCred, query, params)
+}
+
+func (self *SGuest) getQosEip() (*SElasticip, error) {
+	eip, err := self.GetPublicIp()
+	if err != nil {
+		return nil, err
+	}
+	if eip != nil {
+		return eip, nil
+	}
+	return self.GetElasticIp()
+}
+
+// 通过调整弹性公网IP带宽限速, 带宽由弹性公网IP变更任务成功后更新
+func (self *SGuest) setEipQos(ctx context.Context, userCred mcclient.TokenCredential, bandwidthMb int) error {
+	eip, err := self.getQosEip()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if eip == nil {
+		return httperrors.NewInvalidStatusError("server %s has no eip to limit bandwidth", self.Name)
+	}
+	if eip.Bandwidth == bandwidthMb {
+		return nil
+	}
+	return eip.StartEipChangeBandwidthTask(ctx, userCred, int64(bandwidthMb))
+}
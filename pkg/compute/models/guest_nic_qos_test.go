@@ -0,0 +1,46 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestValidateNicQosBandwidth(t *testing.T) {
+	cases := []struct {
+		mode      string
+		bandwidth int
+		wantErr   bool
+	}{
+		{api.NIC_QOS_MODE_NONE, 10, true},
+		{"", 10, true},
+		{api.NIC_QOS_MODE_TC, 0, false},
+		{api.NIC_QOS_MODE_TC, 100, false},
+		{api.NIC_QOS_MODE_TC, -1, true},
+		{api.NIC_QOS_MODE_TC, api.MAX_BANDWIDTH, false},
+		{api.NIC_QOS_MODE_TC, api.MAX_BANDWIDTH + 1, true},
+		{"nic", 10, true},
+		{api.NIC_QOS_MODE_EIP, 0, true},
+		{api.NIC_QOS_MODE_EIP, 5, false},
+	}
+	for _, c := range cases {
+		err := validateNicQosBandwidth(c.mode, c.bandwidth)
+		if (err != nil) != c.wantErr {
+			t.Errorf("mode %q bandwidth %d: got err %v, wantErr %v", c.mode, c.bandwidth, err, c.wantErr)
+		}
+	}
+}
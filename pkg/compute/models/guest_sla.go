@@ -0,0 +1,284 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type SGuestAvailabilityRecordManager struct {
+	db.SResourceBaseManager
+}
+
+// 虚拟机可用状态变化记录, 仅在可用状态发生变化时记录
+type SGuestAvailabilityRecord struct {
+	db.SResourceBase
+
+	RowId   int64  `primary:"true" auto_increment:"true"`
+	GuestId string `width:"36" charset:"ascii" nullable:"false" index:"true"`
+	// 变化后的可用状态
+	Availability string `width:"16" charset:"ascii" nullable:"false"`
+	// 变化后的虚拟机状态
+	Status string `width:"36" charset:"ascii" nullable:"false"`
+	// 状态来源, local: 本地操作, sync: 云上同步
+	Source    string    `width:"16" charset:"ascii" nullable:"false"`
+	ChangedAt time.Time `nullable:"false" index:"true"`
+}
+
+var GuestAvailabilityRecordManager *SGuestAvailabilityRecordManager
+
+func init() {
+	GuestAvailabilityRecordManager = &SGuestAvailabilityRecordManager{
+		SResourceBaseManager: db.NewResourceBaseManager(
+			SGuestAvailabilityRecord{},
+			"guest_availability_records_tbl",
+			"guest_availability_record",
+			"guest_availability_records",
+		),
+	}
+	GuestAvailabilityRecordManager.SetVirtualObject(GuestAvailabilityRecordManager)
+}
+
+func getGuestAvailability(status string) string {
+	if status == api.VM_RUNNING {
+		return api.GUEST_AVAILABILITY_UP
+	}
+	if utils.IsInStringArray(status, api.GUEST_SLA_DOWN_STATUS) {
+		return api.GUEST_AVAILABILITY_DOWN
+	}
+	return api.GUEST_AVAILABILITY_EXCLUDED
+}
+
+func (manager *SGuestAvailabilityRecordManager) recordStatusChange(ctx context.Context, guest *SGuest, oldStatus, source string) {
+	availability := getGuestAvailability(guest.Status)
+	if oldStatus == guest.Status || getGuestAvailability(oldStatus) == availability {
+		return
+	}
+	record := &SGuestAvailabilityRecord{
+		GuestId:      guest.Id,
+		Availability: availability,
+		Status:       guest.Status,
+		Source:       source,
+		ChangedAt:    time.Now().UTC(),
+	}
+	record.SetModelManager(manager, record)
+	err := manager.TableSpec().Insert(ctx, record)
+	if err != nil {
+		log.Errorf("insert availability record for guest %s error: %v", guest.Name, err)
+	}
+}
+
+// 返回 since 之前每台虚拟机最后一次可用状态
+func (manager *SGuestAvailabilityRecordManager) fetchLastAvailabilities(guestIds []string, since time.Time) (map[string]string, error) {
+	records := manager.Query().SubQuery()
+	sq := records.Query(
+		sqlchemy.MAX("row_id", records.Field("row_id")),
+	).Filter(sqlchemy.In(records.Field("guest_id"), guestIds)).LT("changed_at", since).GroupBy(records.Field("guest_id")).SubQuery()
+	q := manager.Query().In("row_id", sq)
+	lasts := []SGuestAvailabilityRecord{}
+	err := db.FetchModelObjects(manager, q, &lasts)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	ret := map[string]string{}
+	for _, r := range lasts {
+		ret[r.GuestId] = r.Availability
+	}
+	return ret, nil
+}
+
+func (manager *SGuestAvailabilityRecordManager) fetchRecords(guestIds []string, since, until time.Time) (map[string][]SGuestAvailabilityRecord, error) {
+	q := manager.Query().In("guest_id", guestIds).GE("changed_at", since).LT("changed_at", until).Asc("changed_at")
+	records := []SGuestAvailabilityRecord{}
+	err := db.FetchModelObjects(manager, q, &records)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	ret := map[string][]SGuestAvailabilityRecord{}
+	for _, r := range records {
+		ret[r.GuestId] = append(ret[r.GuestId], r)
+	}
+	return ret, nil
+}
+
+// 计算 [start, end) 区间内各可用状态的时长, initial 为区间开始时的可用状态
+func computeGuestSla(initial string, records []SGuestAvailabilityRecord, start, end time.Time) api.GuestSla {
+	ret := api.GuestSla{}
+	add := func(availability string, d time.Duration) {
+		if d <= 0 {
+			return
+		}
+		switch availability {
+		case api.GUEST_AVAILABILITY_UP:
+			ret.UpSeconds += int64(d.Seconds())
+		case api.GUEST_AVAILABILITY_DOWN:
+			ret.DownSeconds += int64(d.Seconds())
+		default:
+			ret.ExcludedSeconds += int64(d.Seconds())
+		}
+	}
+	current, at := initial, start
+	for _, r := range records {
+		if r.ChangedAt.Before(start) || !r.ChangedAt.Before(end) {
+			continue
+		}
+		add(current, r.ChangedAt.Sub(at))
+		if r.Availability != current {
+			ret.Transitions += 1
+		}
+		current, at = r.Availability, r.ChangedAt
+	}
+	add(current, end.Sub(at))
+	ret.UptimePercent = getUptimePercent(ret.UpSeconds, ret.DownSeconds)
+	return ret
+}
+
+func getUptimePercent(up, down int64) float64 {
+	if up+down == 0 {
+		return 100
+	}
+	return float64(up) * 100 / float64(up+down)
+}
+
+func parseGuestSlaMonth(month string, now time.Time) (time.Time, time.Time, error) {
+	var start time.Time
+	if len(month) == 0 {
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	} else {
+		var err error
+		start, err = time.Parse("2006-01", month)
+		if err != nil {
+			return start, start, httperrors.NewInputParameterError("invalid month %q, should be 2006-01", month)
+		}
+	}
+	end := start.AddDate(0, 1, 0)
+	if end.After(now) {
+		end = now
+	}
+	return start, end, nil
+}
+
+// 虚拟机月度可用率报表
+func (manager *SGuestManager) GetPropertySlaReport(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.GuestSlaReportOutput, error) {
+	input := api.GuestSlaReportInput{}
+	err := query.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal input: %v", err)
+	}
+	if len(input.GroupBy) == 0 {
+		input.GroupBy = api.GUEST_SLA_GROUP_BY_GUEST
+	}
+	switch input.GroupBy {
+	case api.GUEST_SLA_GROUP_BY_GUEST, api.GUEST_SLA_GROUP_BY_PROJECT:
+	default:
+		return nil, httperrors.NewInputParameterError("invalid group_by %s", input.GroupBy)
+	}
+	now := time.Now().UTC()
+	start, end, err := parseGuestSlaMonth(input.Month, now)
+	if err != nil {
+		return nil, err
+	}
+	ret := &api.GuestSlaReportOutput{Month: start.Format("2006-01"), GroupBy: input.GroupBy, Data: []api.GuestSla{}}
+	if !start.Before(end) {
+		return ret, nil
+	}
+
+	q := manager.Query().LT("created_at", end)
+	q, err = db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	guests := []SGuest{}
+	err = db.FetchModelObjects(manager, q, &guests)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "FetchModelObjects"))
+	}
+	if len(guests) == 0 {
+		return ret, nil
+	}
+	guestIds := []string{}
+	for i := range guests {
+		guestIds = append(guestIds, guests[i].Id)
+	}
+	lasts, err := GuestAvailabilityRecordManager.fetchLastAvailabilities(guestIds, start)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	records, err := GuestAvailabilityRecordManager.fetchRecords(guestIds, start, end)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+
+	projects := map[string]*api.GuestSla{}
+	for i := range guests {
+		guest := &guests[i]
+		guestStart := start
+		if guest.CreatedAt.After(guestStart) {
+			guestStart = guest.CreatedAt
+		}
+		initial, ok := lasts[guest.Id]
+		if !ok {
+			if len(records[guest.Id]) == 0 {
+				// 统计期间无状态变化, 以当前状态为准
+				initial = getGuestAvailability(guest.Status)
+			} else {
+				initial = api.GUEST_AVAILABILITY_EXCLUDED
+			}
+		}
+		sla := computeGuestSla(initial, records[guest.Id], guestStart, end)
+		sla.Id, sla.Name, sla.GuestCount = guest.Id, guest.Name, 1
+		sla.ProjectId = guest.ProjectId
+		if tenant, err := db.TenantCacheManager.FetchTenantById(ctx, guest.ProjectId); err == nil {
+			sla.Project = tenant.Name
+		}
+		switch input.GroupBy {
+		case api.GUEST_SLA_GROUP_BY_GUEST:
+			ret.Data = append(ret.Data, sla)
+		case api.GUEST_SLA_GROUP_BY_PROJECT:
+			project, ok := projects[guest.ProjectId]
+			if !ok {
+				project = &api.GuestSla{Id: sla.ProjectId, Name: sla.Project, ProjectId: sla.ProjectId, Project: sla.Project}
+				projects[guest.ProjectId] = project
+			}
+			project.GuestCount += 1
+			project.UpSeconds += sla.UpSeconds
+			project.DownSeconds += sla.DownSeconds
+			project.ExcludedSeconds += sla.ExcludedSeconds
+			project.Transitions += sla.Transitions
+		}
+	}
+	for _, project := range projects {
+		project.UptimePercent = getUptimePercent(project.UpSeconds, project.DownSeconds)
+		ret.Data = append(ret.Data, *project)
+	}
+	sort.SliceStable(ret.Data, func(i, j int) bool {
+		return ret.Data[i].UptimePercent < ret.Data[j].UptimePercent
+	})
+	return ret, nil
+}
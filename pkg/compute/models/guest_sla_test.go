@@ -0,0 +1,90 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestComputeGuestSla(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	at := func(h int) time.Time {
+		return start.Add(time.Duration(h) * time.Hour)
+	}
+	cases := []struct {
+		name        string
+		initial     string
+		records     []SGuestAvailabilityRecord
+		up          int64
+		down        int64
+		excluded    int64
+		transitions int
+		percent     float64
+	}{
+		{
+			name:    "always up",
+			initial: api.GUEST_AVAILABILITY_UP,
+			up:      36000,
+			percent: 100,
+		},
+		{
+			name:    "down for two hours",
+			initial: api.GUEST_AVAILABILITY_UP,
+			records: []SGuestAvailabilityRecord{
+				{Availability: api.GUEST_AVAILABILITY_DOWN, ChangedAt: at(4)},
+				{Availability: api.GUEST_AVAILABILITY_UP, ChangedAt: at(6)},
+			},
+			up:          28800,
+			down:        7200,
+			transitions: 2,
+			percent:     80,
+		},
+		{
+			name:    "stopped by user is excluded",
+			initial: api.GUEST_AVAILABILITY_UP,
+			records: []SGuestAvailabilityRecord{
+				{Availability: api.GUEST_AVAILABILITY_EXCLUDED, ChangedAt: at(5)},
+				{Availability: api.GUEST_AVAILABILITY_DOWN, ChangedAt: at(-1)},
+			},
+			up:          18000,
+			excluded:    18000,
+			transitions: 1,
+			percent:     100,
+		},
+	}
+	for _, c := range cases {
+		got := computeGuestSla(c.initial, c.records, start, end)
+		if got.UpSeconds != c.up || got.DownSeconds != c.down || got.ExcludedSeconds != c.excluded || got.Transitions != c.transitions || got.UptimePercent != c.percent {
+			t.Errorf("%s: got %#v", c.name, got)
+		}
+	}
+}
+
+func TestGetGuestAvailability(t *testing.T) {
+	for status, want := range map[string]string{
+		api.VM_RUNNING:      api.GUEST_AVAILABILITY_UP,
+		api.VM_UNKNOWN:      api.GUEST_AVAILABILITY_DOWN,
+		api.VM_START_FAILED: api.GUEST_AVAILABILITY_DOWN,
+		api.VM_READY:        api.GUEST_AVAILABILITY_EXCLUDED,
+	} {
+		if got := getGuestAvailability(status); got != want {
+			t.Errorf("%s: want %s, got %s", status, want, got)
+		}
+	}
+}
@@ -0,0 +1,567 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SGuestSoftwareInventoryManager struct {
+	db.SStatusStandaloneResourceBaseManager
+	db.SProjectizedResourceBaseManager
+	SGuestResourceBaseManager
+}
+
+var GuestSoftwareInventoryManager *SGuestSoftwareInventoryManager
+
+func init() {
+	GuestSoftwareInventoryManager = &SGuestSoftwareInventoryManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SGuestSoftwareInventory{},
+			"guest_software_inventories_tbl",
+			"guest_software_inventory",
+			"guest_software_inventories",
+		),
+	}
+	GuestSoftwareInventoryManager.SetVirtualObject(GuestSoftwareInventoryManager)
+}
+
+// 主机软件清单, 记录操作系统, 内核及已安装的更新
+type SGuestSoftwareInventory struct {
+	db.SStatusStandaloneResourceBase
+	db.SProjectizedResourceBase
+	SGuestResourceBase
+
+	// 采集来源
+	Source string `width:"16" charset:"ascii" nullable:"true" list:"user"`
+	// 操作系统完整名称
+	OsName string `width:"128" charset:"utf8" nullable:"true" list:"user"`
+	// 操作系统发行版
+	OsDistribution string `width:"64" charset:"utf8" nullable:"true" list:"user"`
+	// 操作系统版本
+	OsVersion string `width:"64" charset:"ascii" nullable:"true" list:"user"`
+	// 内核版本
+	KernelVersion string `width:"128" charset:"ascii" nullable:"true" list:"user"`
+	OsArch        string `width:"16" charset:"ascii" nullable:"true" list:"user"`
+	// 已安装的更新或软件包
+	InstalledUpdates *api.SGuestInstalledUpdates `length:"medium" get:"user"`
+	// 已安装的更新数量
+	UpdateCount int `nullable:"false" default:"0" list:"user"`
+	// 最近一次安装更新的时间
+	LastUpdateAt time.Time `nullable:"true" list:"user"`
+	// 最近一次采集时间
+	CollectedAt time.Time `nullable:"true" list:"user"`
+}
+
+type SCloudVMSoftwareInventory struct {
+	OsName           string
+	OsDistribution   string
+	OsVersion        string
+	KernelVersion    string
+	OsArch           string
+	InstalledUpdates []api.SGuestInstalledUpdate
+	// 无法获取单个更新的安装时间时, 记录最近一次更新时间
+	LastUpdateAt time.Time
+}
+
+func (manager *SGuestSoftwareInventoryManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return nil, httperrors.NewUnsupportOperationError("software inventories are collected from servers")
+}
+
+func (manager *SGuestSoftwareInventoryManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.GuestSoftwareInventoryListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SGuestResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ServerFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SGuestResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Source) > 0 {
+		q = q.In("source", query.Source)
+	}
+	if len(query.OsDistribution) > 0 {
+		q = q.In("os_distribution", query.OsDistribution)
+	}
+	if len(query.OsVersion) > 0 {
+		q = q.In("os_version", query.OsVersion)
+	}
+	if len(query.KernelVersion) > 0 {
+		q = q.In("kernel_version", query.KernelVersion)
+	}
+	if len(query.InstalledUpdate) > 0 {
+		q = q.Filter(sqlchemy.Contains(q.Field("installed_updates"), fmt.Sprintf(`"name":"%s"`, query.InstalledUpdate)))
+	}
+	return q, nil
+}
+
+func (manager *SGuestSoftwareInventoryManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.GuestSoftwareInventoryListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SGuestResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ServerFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SGuestResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SGuestSoftwareInventoryManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SGuestResourceBaseManager,
+	)
+}
+
+func (manager *SGuestSoftwareInventoryManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	return db.ApplyListItemExportKeys(ctx, q, userCred, keys,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SGuestResourceBaseManager,
+	)
+}
+
+func (manager *SGuestSoftwareInventoryManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.GuestSoftwareInventoryDetails {
+	rows := make([]api.GuestSoftwareInventoryDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	guestRows := manager.SGuestResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	now := time.Now().UTC()
+	for i := range rows {
+		rows[i] = api.GuestSoftwareInventoryDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+			ProjectizedResourceInfo:         projRows[i],
+			GuestResourceInfo:               guestRows[i],
+		}
+		rows[i].OutdatedReasons = objs[i].(*SGuestSoftwareInventory).getOutdatedReasons(now)
+	}
+	return rows
+}
+
+// 已停止支持的操作系统版本
+var guestOsEolVersions = map[string][]string{
+	"centos":  {"5", "6", "7", "8"},
+	"rhel":    {"5", "6"},
+	"ubuntu":  {"12.04", "14.04", "16.04", "18.04"},
+	"debian":  {"7", "8", "9", "10"},
+	"windows": {"2003", "2008", "2012", "7", "8"},
+}
+
+func normalizeGuestOsDistribution(dist string) string {
+	dist = strings.ToLower(strings.TrimSpace(dist))
+	switch {
+	case strings.Contains(dist, "windows"):
+		return "windows"
+	case strings.Contains(dist, "centos"):
+		return "centos"
+	case strings.Contains(dist, "red hat"), dist == "redhat":
+		return "rhel"
+	}
+	return dist
+}
+
+func isGuestOsEol(dist, version string) bool {
+	version = strings.TrimSpace(version)
+	for _, eol := range guestOsEolVersions[normalizeGuestOsDistribution(dist)] {
+		if version == eol || strings.HasPrefix(version, eol+".") || strings.HasPrefix(version, eol+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// 判断操作系统是否过时: 版本已停止支持, 或超过patchDays天未安装更新
+func checkGuestOsOutdated(source, dist, version string, lastUpdateAt, now time.Time, patchDays int) []string {
+	reasons := []string{}
+	if isGuestOsEol(dist, version) {
+		reasons = append(reasons, api.GUEST_OS_OUTDATED_REASON_EOL)
+	}
+	// 云平台接口无法获取更新信息
+	if source == api.GUEST_SOFTWARE_INVENTORY_SOURCE_PROVIDER {
+		return reasons
+	}
+	if lastUpdateAt.IsZero() {
+		reasons = append(reasons, api.GUEST_OS_OUTDATED_REASON_NO_UPDATES)
+	} else if patchDays > 0 && now.Sub(lastUpdateAt) > time.Duration(patchDays)*24*time.Hour {
+		reasons = append(reasons, api.GUEST_OS_OUTDATED_REASON_PATCH)
+	}
+	return reasons
+}
+
+func (self *SGuestSoftwareInventory) getOutdatedReasons(now time.Time) []string {
+	if self.Status != api.GUEST_SOFTWARE_INVENTORY_STATUS_READY {
+		return []string{}
+	}
+	return checkGuestOsOutdated(self.Source, self.OsDistribution, self.OsVersion, self.LastUpdateAt, now, options.Options.GuestOsPatchOutdatedDays)
+}
+
+// 解析采集脚本输出, 每行格式为: 名称\t版本\t安装时间(unix时间戳或yyyy-mm-dd), "#last_update\t时间戳"为最近更新时间
+func parseGuestInstalledUpdates(output string) ([]api.SGuestInstalledUpdate, time.Time) {
+	parseTime := func(str string) time.Time {
+		str = strings.TrimSpace(str)
+		if len(str) == 0 {
+			return time.Time{}
+		}
+		if sec, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC()
+		}
+		if tm, err := time.Parse("2006-01-02", str); err == nil {
+			return tm
+		}
+		return time.Time{}
+	}
+	updates := []api.SGuestInstalledUpdate{}
+	lastUpdateAt := time.Time{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		parts := strings.Split(line, "\t")
+		if len(strings.TrimSpace(parts[0])) == 0 {
+			continue
+		}
+		if parts[0] == "#last_update" {
+			if len(parts) > 1 {
+				if tm := parseTime(parts[1]); tm.After(lastUpdateAt) {
+					lastUpdateAt = tm
+				}
+			}
+			continue
+		}
+		update := api.SGuestInstalledUpdate{Name: strings.TrimSpace(parts[0])}
+		if len(parts) > 1 {
+			update.Version = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			update.InstalledAt = parseTime(parts[2])
+			if update.InstalledAt.After(lastUpdateAt) {
+				lastUpdateAt = update.InstalledAt
+			}
+		}
+		updates = append(updates, update)
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Name < updates[j].Name })
+	return updates, lastUpdateAt
+}
+
+const guestLinuxInstalledUpdatesScript = `if command -v rpm >/dev/null 2>&1; then
+  rpm -qa --qf '%{NAME}\t%{VERSION}-%{RELEASE}\t%{INSTALLTIME}\n'
+elif command -v dpkg-query >/dev/null 2>&1; then
+  dpkg-query -W -f='${Package}\t${Version}\t\n'
+  printf '#last_update\t%s\n' "$(stat -c %Y /var/lib/dpkg/status)"
+fi`
+
+const guestWindowsInstalledUpdatesScript = "Get-HotFix | ForEach-Object { \"$($_.HotFixID)`t$($_.Description)`t$(if ($_.InstalledOn) { $_.InstalledOn.ToString('yyyy-MM-dd') })\" }"
+
+func (self *SGuest) collectSoftwareInventoryByQga(ctx context.Context, userCred mcclient.TokenCredential) (*SCloudVMSoftwareInventory, error) {
+	if self.Status != api.VM_RUNNING {
+		return nil, httperrors.NewInvalidStatusError("can't use qga in vm status: %s", self.Status)
+	}
+	host, err := self.GetHost()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetHost")
+	}
+	osInfo, err := self.requestQga(ctx, userCred, host, "guest-get-osinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := &SCloudVMSoftwareInventory{}
+	ret.OsName, _ = osInfo.GetString("pretty-name")
+	ret.OsDistribution, _ = osInfo.GetString("id")
+	ret.OsVersion, _ = osInfo.GetString("version-id")
+	ret.KernelVersion, _ = osInfo.GetString("kernel-release")
+	ret.OsArch, _ = osInfo.GetString("machine")
+	var output string
+	if self.IsWindows() {
+		output, err = self.qgaExecOutput(ctx, userCred, host, "powershell.exe", []string{"-NoProfile", "-Command", guestWindowsInstalledUpdatesScript})
+	} else {
+		output, err = self.qgaExecOutput(ctx, userCred, host, "/bin/sh", []string{"-c", guestLinuxInstalledUpdatesScript})
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "list installed updates")
+	}
+	ret.InstalledUpdates, ret.LastUpdateAt = parseGuestInstalledUpdates(output)
+	return ret, nil
+}
+
+func (self *SGuest) collectSoftwareInventoryByProvider(ctx context.Context) (*SCloudVMSoftwareInventory, string, error) {
+	iVM, err := self.GetIVM(ctx)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "GetIVM")
+	}
+	ret := &SCloudVMSoftwareInventory{
+		OsName:         iVM.GetFullOsName(),
+		OsDistribution: iVM.GetOsDist(),
+		OsVersion:      iVM.GetOsVersion(),
+		OsArch:         iVM.GetOsArch(),
+	}
+	return ret, api.GUEST_SOFTWARE_INVENTORY_SOURCE_PROVIDER, nil
+}
+
+func (self *SGuest) GetSoftwareInventory() (*SGuestSoftwareInventory, error) {
+	inventory := &SGuestSoftwareInventory{}
+	inventory.SetModelManager(GuestSoftwareInventoryManager, inventory)
+	err := GuestSoftwareInventoryManager.Query().Equals("guest_id", self.Id).First(inventory)
+	if err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+func (self *SGuest) saveSoftwareInventory(ctx context.Context, status string, source string, info *SCloudVMSoftwareInventory) error {
+	inventory, err := self.GetSoftwareInventory()
+	if err != nil && errors.Cause(err) != sql.ErrNoRows {
+		return errors.Wrapf(err, "GetSoftwareInventory")
+	}
+	update := func(inventory *SGuestSoftwareInventory) {
+		inventory.Name = self.Name
+		inventory.ProjectId = self.ProjectId
+		inventory.DomainId = self.DomainId
+		inventory.Status = status
+		if info == nil {
+			return
+		}
+		updates := api.SGuestInstalledUpdates(info.InstalledUpdates)
+		inventory.Source = source
+		inventory.OsName = info.OsName
+		inventory.OsDistribution = info.OsDistribution
+		inventory.OsVersion = info.OsVersion
+		inventory.KernelVersion = info.KernelVersion
+		inventory.OsArch = info.OsArch
+		inventory.InstalledUpdates = &updates
+		inventory.UpdateCount = len(updates)
+		inventory.LastUpdateAt = info.LastUpdateAt
+		inventory.CollectedAt = time.Now().UTC()
+	}
+	if inventory == nil {
+		inventory = &SGuestSoftwareInventory{}
+		inventory.SetModelManager(GuestSoftwareInventoryManager, inventory)
+		inventory.GuestId = self.Id
+		update(inventory)
+		return GuestSoftwareInventoryManager.TableSpec().Insert(ctx, inventory)
+	}
+	_, err = db.Update(inventory, func() error {
+		update(inventory)
+		return nil
+	})
+	return err
+}
+
+// 采集主机软件清单, KVM通过qemu-guest-agent, 云上主机仅通过云平台接口获取操作系统信息
+func (self *SGuest) CollectSoftwareInventory(ctx context.Context, userCred mcclient.TokenCredential) error {
+	var (
+		info   *SCloudVMSoftwareInventory
+		source string
+		err    error
+	)
+	switch {
+	case self.GetHypervisor() == api.HYPERVISOR_KVM:
+		source = api.GUEST_SOFTWARE_INVENTORY_SOURCE_QGA
+		info, err = self.collectSoftwareInventoryByQga(ctx, userCred)
+	case len(self.ExternalId) > 0:
+		info, source, err = self.collectSoftwareInventoryByProvider(ctx)
+	default:
+		err = errors.Wrapf(httperrors.ErrNotSupported, "collect software inventory of %s server", self.GetHypervisor())
+	}
+	if err != nil {
+		if e := self.saveSoftwareInventory(ctx, api.GUEST_SOFTWARE_INVENTORY_STATUS_FAILED, "", nil); e != nil {
+			log.Errorf("save software inventory of %s error: %v", self.Name, e)
+		}
+		return err
+	}
+	return self.saveSoftwareInventory(ctx, api.GUEST_SOFTWARE_INVENTORY_STATUS_READY, source, info)
+}
+
+func (self *SGuest) StartCollectSoftwareInventoryTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	err := self.saveSoftwareInventory(ctx, api.GUEST_SOFTWARE_INVENTORY_STATUS_COLLECTING, "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "saveSoftwareInventory")
+	}
+	task, err := taskman.TaskManager.NewTask(ctx, "GuestCollectSoftwareInventoryTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask")
+	}
+	return task.ScheduleRun(nil)
+}
+
+// 采集主机操作系统及已安装的更新
+func (self *SGuest) PerformCollectSoftwareInventory(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerCollectSoftwareInventoryInput) (jsonutils.JSONObject, error) {
+	if self.GetHypervisor() != api.HYPERVISOR_KVM && len(self.ExternalId) == 0 {
+		return nil, httperrors.NewNotSupportedError("collect software inventory of %s server", self.GetHypervisor())
+	}
+	if self.Status != api.VM_RUNNING {
+		return nil, httperrors.NewInvalidStatusError("can't collect software inventory in vm status: %s", self.Status)
+	}
+	return nil, self.StartCollectSoftwareInventoryTask(ctx, userCred, "")
+}
+
+func (manager *SGuestSoftwareInventoryManager) deleteByGuest(ctx context.Context, userCred mcclient.TokenCredential, guestId string) error {
+	inventories := []SGuestSoftwareInventory{}
+	err := db.FetchModelObjects(manager, manager.Query().Equals("guest_id", guestId), &inventories)
+	if err != nil {
+		return errors.Wrap(err, "fetch software inventories")
+	}
+	for i := range inventories {
+		err := inventories[i].Delete(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "delete software inventory %s", inventories[i].Id)
+		}
+	}
+	return nil
+}
+
+// 定时采集运行中主机的软件清单
+func (manager *SGuestSoftwareInventoryManager) AutoCollect(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	interval := time.Duration(options.Options.GuestSoftwareInventoryIntervalHours) * time.Hour
+	recent := manager.Query("guest_id").GT("collected_at", time.Now().UTC().Add(-interval)).SubQuery()
+	q := GuestManager.Query().Equals("status", api.VM_RUNNING).NotIn("id", recent)
+	q = q.Filter(sqlchemy.OR(
+		sqlchemy.Equals(q.Field("hypervisor"), api.HYPERVISOR_KVM),
+		sqlchemy.IsNotEmpty(q.Field("external_id")),
+	))
+	guests := []SGuest{}
+	err := db.FetchModelObjects(GuestManager, q, &guests)
+	if err != nil {
+		log.Errorf("fetch guests for software inventory error: %v", err)
+		return
+	}
+	for i := range guests {
+		err := guests[i].StartCollectSoftwareInventoryTask(ctx, userCred, "")
+		if err != nil {
+			log.Errorf("collect software inventory of %s error: %v", guests[i].Name, err)
+		}
+	}
+}
+
+func buildGuestOsOutdatedReport(inventories []SGuestSoftwareInventory, guestNames map[string]string, now time.Time) *api.GuestOsOutdatedReport {
+	ret := &api.GuestOsOutdatedReport{
+		Distributions: []api.GuestOsDistributionStat{},
+		Items:         []api.GuestOsOutdatedItem{},
+	}
+	stats := map[string]*api.GuestOsDistributionStat{}
+	for i := range inventories {
+		inventory := &inventories[i]
+		if inventory.Status != api.GUEST_SOFTWARE_INVENTORY_STATUS_READY {
+			continue
+		}
+		ret.Total += 1
+		key := inventory.OsDistribution + "/" + inventory.OsVersion
+		stat, ok := stats[key]
+		if !ok {
+			stat = &api.GuestOsDistributionStat{OsDistribution: inventory.OsDistribution, OsVersion: inventory.OsVersion}
+			stats[key] = stat
+		}
+		stat.Count += 1
+		reasons := inventory.getOutdatedReasons(now)
+		if len(reasons) == 0 {
+			continue
+		}
+		ret.Outdated += 1
+		stat.Outdated += 1
+		ret.Items = append(ret.Items, api.GuestOsOutdatedItem{
+			GuestId:        inventory.GuestId,
+			Guest:          guestNames[inventory.GuestId],
+			OsDistribution: inventory.OsDistribution,
+			OsVersion:      inventory.OsVersion,
+			KernelVersion:  inventory.KernelVersion,
+			LastUpdateAt:   inventory.LastUpdateAt,
+			Reasons:        reasons,
+		})
+	}
+	for _, stat := range stats {
+		ret.Distributions = append(ret.Distributions, *stat)
+	}
+	sort.Slice(ret.Distributions, func(i, j int) bool {
+		if ret.Distributions[i].Count != ret.Distributions[j].Count {
+			return ret.Distributions[i].Count > ret.Distributions[j].Count
+		}
+		return ret.Distributions[i].OsDistribution+ret.Distributions[i].OsVersion < ret.Distributions[j].OsDistribution+ret.Distributions[j].OsVersion
+	})
+	return ret
+}
+
+// 操作系统过时报告
+func (manager *SGuestSoftwareInventoryManager) GetPropertyOutdatedReport(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.GuestOsOutdatedReport, error) {
+	q := manager.Query()
+	q, err := db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	inventories := []SGuestSoftwareInventory{}
+	err = db.FetchModelObjects(manager, q, &inventories)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	guestIds := make([]string, len(inventories))
+	for i := range inventories {
+		guestIds[i] = inventories[i].GuestId
+	}
+	guestNames, err := db.FetchIdNameMap2(GuestManager, guestIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchIdNameMap2")
+	}
+	return buildGuestOsOutdatedReport(inventories, guestNames, time.Now().UTC()), nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestParseGuestInstalledUpdates(t *testing.T) {
+	cases := []struct {
+		name    string
+		output  string
+		updates []api.SGuestInstalledUpdate
+		last    time.Time
+	}{
+		{
+			name:   "rpm",
+			output: "openssl\t1.0.2k-25.el7\t1700000000\nbash\t4.2.46-35.el7\t1600000000\n",
+			updates: []api.SGuestInstalledUpdate{
+				{Name: "bash", Version: "4.2.46-35.el7", InstalledAt: time.Unix(1600000000, 0).UTC()},
+				{Name: "openssl", Version: "1.0.2k-25.el7", InstalledAt: time.Unix(1700000000, 0).UTC()},
+			},
+			last: time.Unix(1700000000, 0).UTC(),
+		},
+		{
+			name:   "dpkg",
+			output: "curl\t7.68.0-1ubuntu2\t\n#last_update\t1650000000\n",
+			updates: []api.SGuestInstalledUpdate{
+				{Name: "curl", Version: "7.68.0-1ubuntu2"},
+			},
+			last: time.Unix(1650000000, 0).UTC(),
+		},
+		{
+			name:   "windows hotfix",
+			output: "KB5005112\tSecurity Update\t2021-08-11\r\nKB4589208\tUpdate\t\r\n",
+			updates: []api.SGuestInstalledUpdate{
+				{Name: "KB4589208", Version: "Update"},
+				{Name: "KB5005112", Version: "Security Update", InstalledAt: time.Date(2021, 8, 11, 0, 0, 0, 0, time.UTC)},
+			},
+			last: time.Date(2021, 8, 11, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, c := range cases {
+		updates, last := parseGuestInstalledUpdates(c.output)
+		if !reflect.DeepEqual(updates, c.updates) {
+			t.Errorf("%s: want updates %v got %v", c.name, c.updates, updates)
+		}
+		if !last.Equal(c.last) {
+			t.Errorf("%s: want last update %s got %s", c.name, c.last, last)
+		}
+	}
+}
+
+func TestCheckGuestOsOutdated(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.Add(-24 * time.Hour)
+	old := now.Add(-200 * 24 * time.Hour)
+	cases := []struct {
+		name    string
+		source  string
+		dist    string
+		version string
+		last    time.Time
+		want    []string
+	}{
+		{"eol centos", api.GUEST_SOFTWARE_INVENTORY_SOURCE_QGA, "centos", "7", recent, []string{api.GUEST_OS_OUTDATED_REASON_EOL}},
+		{"supported", api.GUEST_SOFTWARE_INVENTORY_SOURCE_QGA, "ubuntu", "22.04", recent, []string{}},
+		{"patch outdated", api.GUEST_SOFTWARE_INVENTORY_SOURCE_QGA, "debian", "12", old, []string{api.GUEST_OS_OUTDATED_REASON_PATCH}},
+		{"no update info", api.GUEST_SOFTWARE_INVENTORY_SOURCE_QGA, "mswindows", "2019", time.Time{}, []string{api.GUEST_OS_OUTDATED_REASON_NO_UPDATES}},
+		{"windows eol", api.GUEST_SOFTWARE_INVENTORY_SOURCE_PROVIDER, "Windows Server", "2012 R2", time.Time{}, []string{api.GUEST_OS_OUTDATED_REASON_EOL}},
+		{"provider skip patch", api.GUEST_SOFTWARE_INVENTORY_SOURCE_PROVIDER, "ubuntu", "22.04", time.Time{}, []string{}},
+		{"not prefix match", api.GUEST_SOFTWARE_INVENTORY_SOURCE_QGA, "centos", "70", recent, []string{}},
+	}
+	for _, c := range cases {
+		got := checkGuestOsOutdated(c.source, c.dist, c.version, c.last, now, 90)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/httputils"
+
+	billing_api "yunion.io/x/onecloud/pkg/apis/billing"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+func newServerValidateCreateError(field, code string, err error) api.ServerValidateCreateError {
+	msg := err.Error()
+	if je, ok := err.(*httputils.JSONClientError); ok && len(je.Details) > 0 {
+		msg = je.Details
+	}
+	return api.ServerValidateCreateError{
+		Field:   field,
+		Code:    code,
+		Message: msg,
+	}
+}
+
+// 仅校验创建参数, 依次检查套餐、镜像缓存、配额及网络容量, 不占用配额也不启动任务
+// 镜像缓存及格式转换只在创建任务中进行, 校验路径中只读取缓存状态
+func (manager *SGuestManager) PerformValidateCreate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (*api.ServerValidateCreateOutput, error) {
+	dict, ok := data.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, httperrors.NewInputParameterError("invalid create data")
+	}
+	dict = dict.Copy()
+	// 标记为dry_run, 使支持dry_run的钩子跳过副作用
+	dict.Set("dry_run", jsonutils.JSONTrue)
+	ownerId, err := manager.FetchOwnerId(ctx, dict)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if ownerId == nil {
+		ownerId = userCred
+	}
+	count := 1
+	if cnt, _ := dict.Int("count"); cnt > 1 {
+		count = int(cnt)
+	}
+
+	ret := &api.ServerValidateCreateOutput{Errors: []api.ServerValidateCreateError{}}
+	input, err := manager.validateCreateData(ctx, userCred, ownerId, query, dict)
+	if err != nil {
+		// 后续检查依赖规范化后的参数
+		ret.Errors = append(ret.Errors, newServerValidateCreateError("", api.SERVER_VALIDATE_CREATE_INVALID_INPUT, err))
+		return ret, nil
+	}
+	ret.Errors = append(ret.Errors, validateCreateSku(input)...)
+	ret.Errors = append(ret.Errors, validateCreateImage(input)...)
+	if input.IsSystem == nil || !*input.IsSystem {
+		ret.Errors = append(ret.Errors, manager.validateCreateQuota(ctx, userCred, ownerId, *input, count)...)
+	}
+	ret.Errors = append(ret.Errors, validateCreateNetworks(userCred, input, count)...)
+	ret.Valid = len(ret.Errors) == 0
+	return ret, nil
+}
+
+// 公有云套餐需在指定可用区内且对应计费方式未售罄
+func validateCreateSku(input *api.ServerCreateInput) []api.ServerValidateCreateError {
+	if len(input.InstanceType) == 0 || len(input.PreferZone) == 0 {
+		return nil
+	}
+	if GetDriver(input.Hypervisor).GetProvider() == api.CLOUD_PROVIDER_ONECLOUD {
+		return nil
+	}
+	skuObj, err := ServerSkuManager.FetchByZoneId(input.PreferZone, input.InstanceType)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			err = httperrors.NewResourceNotFoundError("sku %s not available in zone %s", input.InstanceType, input.PreferZone)
+		}
+		return []api.ServerValidateCreateError{newServerValidateCreateError("instance_type", api.SERVER_VALIDATE_CREATE_SKU_UNAVAILABLE, err)}
+	}
+	sku := skuObj.(*SServerSku)
+	status := sku.PostpaidStatus
+	if input.BillingType == billing_api.BILLING_TYPE_PREPAID {
+		status = sku.PrepaidStatus
+	}
+	if status != api.SkuStatusAvailable {
+		err = httperrors.NewInvalidStatusError("sku %s is %s in zone %s", sku.Name, status, input.PreferZone)
+		return []api.ServerValidateCreateError{newServerValidateCreateError("instance_type", api.SERVER_VALIDATE_CREATE_SKU_UNAVAILABLE, err)}
+	}
+	return nil
+}
+
+// 系统盘镜像需已缓存且可用, 公有云镜像还需已同步到目标可用区或区域
+func validateCreateImage(input *api.ServerCreateInput) []api.ServerValidateCreateError {
+	if len(input.Disks) == 0 || len(input.Disks[0].ImageId) == 0 {
+		return nil
+	}
+	imageId := input.Disks[0].ImageId
+	imgObj, err := CachedimageManager.FetchById(imageId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			err = httperrors.NewResourceNotFoundError2(CachedimageManager.Keyword(), imageId)
+		}
+		return []api.ServerValidateCreateError{newServerValidateCreateError("disks.0.image_id", api.SERVER_VALIDATE_CREATE_IMAGE_NOT_CACHED, err)}
+	}
+	image := imgObj.(*SCachedimage)
+	if image.GetStatus() != cloudprovider.IMAGE_STATUS_ACTIVE {
+		err = httperrors.NewInvalidStatusError("image %s status %s", image.Name, image.GetStatus())
+		return []api.ServerValidateCreateError{newServerValidateCreateError("disks.0.image_id", api.SERVER_VALIDATE_CREATE_IMAGE_NOT_CACHED, err)}
+	}
+	if image.ImageType != string(cloudprovider.ImageTypeSystem) || (len(input.PreferZone) == 0 && len(input.PreferRegion) == 0) {
+		return nil
+	}
+	storages := StorageManager.Query("storagecache_id")
+	if len(input.PreferZone) > 0 {
+		storages = storages.Equals("zone_id", input.PreferZone)
+	} else {
+		zones := ZoneManager.Query("id").Equals("cloudregion_id", input.PreferRegion).SubQuery()
+		storages = storages.In("zone_id", zones)
+	}
+	q := StoragecachedimageManager.Query().Equals("cachedimage_id", image.Id).Equals("status", api.CACHED_IMAGE_STATUS_ACTIVE)
+	q = q.In("storagecache_id", storages.SubQuery())
+	cnt, err := q.CountWithError()
+	if err != nil {
+		return []api.ServerValidateCreateError{newServerValidateCreateError("disks.0.image_id", api.SERVER_VALIDATE_CREATE_IMAGE_NOT_CACHED, err)}
+	}
+	if cnt == 0 {
+		err = httperrors.NewResourceNotReadyError("image %s not cached in target zone or region", image.Name)
+		return []api.ServerValidateCreateError{newServerValidateCreateError("disks.0.image_id", api.SERVER_VALIDATE_CREATE_IMAGE_NOT_CACHED, err)}
+	}
+	return nil
+}
+
+func (manager *SGuestManager) validateCreateQuota(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, input api.ServerCreateInput, count int) []api.ServerValidateCreateError {
+	ret := []api.ServerValidateCreateError{}
+	req, regionReq := getGuestResourceRequirements(ctx, userCred, input, ownerId, count, input.Backup)
+	for _, quota := range []quotas.IQuota{&req, &regionReq} {
+		err := quotas.CheckQuota(ctx, quota)
+		if err != nil {
+			ret = append(ret, newServerValidateCreateError("", api.SERVER_VALIDATE_CREATE_QUOTA_EXCEEDED, err))
+		}
+	}
+	return ret
+}
+
+// 同一网络被多块网卡使用时合并计算所需地址数
+func validateCreateNetworks(userCred mcclient.TokenCredential, input *api.ServerCreateInput, count int) []api.ServerValidateCreateError {
+	ret := []api.ServerValidateCreateError{}
+	required := map[string]int{}
+	fields := map[string]string{}
+	netIds := []string{}
+	for i, netConfig := range input.Networks {
+		if len(netConfig.Network) == 0 || len(netConfig.Address) > 0 {
+			continue
+		}
+		if _, ok := required[netConfig.Network]; !ok {
+			netIds = append(netIds, netConfig.Network)
+			fields[netConfig.Network] = fmt.Sprintf("networks.%d", i)
+		}
+		required[netConfig.Network] += count
+	}
+	for _, netId := range netIds {
+		netObj, err := NetworkManager.FetchByIdOrName(userCred, netId)
+		if err != nil {
+			ret = append(ret, newServerValidateCreateError(fields[netId], api.SERVER_VALIDATE_CREATE_NETWORK_EXHAUSTED, err))
+			continue
+		}
+		network := netObj.(*SNetwork)
+		free, err := network.GetFreeAddressCount()
+		if err != nil {
+			ret = append(ret, newServerValidateCreateError(fields[netId], api.SERVER_VALIDATE_CREATE_NETWORK_EXHAUSTED, err))
+			continue
+		}
+		if free < required[netId] {
+			err = httperrors.NewOutOfResourceError("network %s has %d free addresses, %d required", network.Name, free, required[netId])
+			ret = append(ret, newServerValidateCreateError(fields[netId], api.SERVER_VALIDATE_CREATE_NETWORK_EXHAUSTED, err))
+		}
+	}
+	return ret
+}
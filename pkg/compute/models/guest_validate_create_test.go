@@ -0,0 +1,54 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+func TestNewServerValidateCreateError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "client error",
+			err:  httperrors.NewInputParameterError("invalid vcpu_count %d", 0),
+			want: "invalid vcpu_count 0",
+		},
+		{
+			name: "plain error",
+			err:  errors.Error("quota exceeded"),
+			want: "quota exceeded",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := newServerValidateCreateError("vcpu_count", api.SERVER_VALIDATE_CREATE_INVALID_INPUT, c.err)
+			if got.Message != c.want {
+				t.Errorf("want message %q, got %q", c.want, got.Message)
+			}
+			if got.Field != "vcpu_count" || got.Code != api.SERVER_VALIDATE_CREATE_INVALID_INPUT {
+				t.Errorf("unexpected field or code: %#v", got)
+			}
+		})
+	}
+}
@@ -139,7 +139,13 @@ type IGuestDriver interface {
 	GetRebuildRootStatus() ([]string, error)
 	GetChangeConfigStatus(guest *SGuest) ([]string, error)
 	GetDeployStatus() ([]string, error)
+	// 通过云平台API或虚拟机代理重置密码时允许的虚拟机状态, 不支持时返回错误并回退到重新部署
+	GetResetPasswordStatus() ([]string, error)
 	ValidateResizeDisk(guest *SGuest, disk *SDisk, storage *SStorage) error
+	// 运行中的虚拟机是否支持在线扩容磁盘, 不支持时可以关机扩容
+	IsSupportLiveResizeDisk(guest *SGuest, disk *SDisk, storage *SStorage) bool
+	// 在虚拟机内扩展分区及文件系统
+	RequestGuestGrowFs(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest) error
 	CanKeepDetachDisk() bool
 	IsNeedRestartForResetLoginInfo() bool
 	IsRebuildRootSupportChangeImage() bool
@@ -194,11 +200,15 @@ type IGuestDriver interface {
 	GetUserDataType() string
 	GetWindowsUserDataType() string
 	IsWindowsUserDataTypeNeedEncode() bool
+	// 是否支持创建后更新 user data
+	IsSupportUpdateUserData() bool
 	CancelExpireTime(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest) error
 
 	IsSupportCdrom(guest *SGuest) (bool, error)
 	IsSupportFloppy(guest *SGuest) (bool, error)
 	IsSupportPublicipToEip() bool
+	// 网卡限速方式, 见 api.NIC_QOS_MODE_*
+	GetNicQosMode() string
 	RequestConvertPublicipToEip(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, task taskman.ITask) error
 
 	IsSupportSetAutoRenew() bool
@@ -83,6 +83,7 @@ type SGuestManager struct {
 	db.SMultiArchResourceBaseManager
 	db.SRecordChecksumResourceBaseManager
 	SHostnameResourceBaseManager
+	SExternalProjectResourceBaseManager
 
 	db.SEncryptedResourceManager
 }
@@ -117,6 +118,7 @@ type SGuest struct {
 
 	SHostnameResourceBase
 	SHostResourceBase `width:"36" charset:"ascii" nullable:"true" list:"user" get:"user" index:"true"`
+	SExternalProjectResourceBase
 
 	db.SEncryptedResource
 
@@ -272,6 +274,11 @@ func (manager *SGuestManager) ListItemFilter(
 		q = q.In("id", scalingGroupQ.SubQuery())
 	}
 
+	q, err = manager.SExternalProjectResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ExternalProjectFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SExternalProjectResourceBaseManager.ListItemFilter")
+	}
+
 	hypervisorList := query.Hypervisor
 	if len(hypervisorList) > 0 {
 		q = q.In("hypervisor", hypervisorList)
@@ -829,6 +836,12 @@ func (guest *SGuest) validateDeleteCondition(ctx context.Context, isPurge bool)
 	if !isPurge && guest.IsNotDeletablePrePaid() {
 		return httperrors.NewForbiddenError("not allow to delete prepaid server in valid status")
 	}
+	if !isPurge {
+		err := guest.validateDeleteLock()
+		if err != nil {
+			return err
+		}
+	}
 	return guest.SVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
 }
 
@@ -1111,6 +1124,16 @@ func (guest *SGuest) ValidateResizeDisk(disk *SDisk, storage *SStorage) error {
 	return guest.GetDriver().ValidateResizeDisk(guest, disk, storage)
 }
 
+// 关机扩容时按照关机后的状态校验
+func (guest *SGuest) validateResizeDisk(disk *SDisk, storage *SStorage, offline bool) error {
+	if !offline {
+		return guest.ValidateResizeDisk(disk, storage)
+	}
+	stopped := *guest
+	stopped.Status = api.VM_READY
+	return guest.GetDriver().ValidateResizeDisk(&stopped, disk, storage)
+}
+
 func ValidateMemData(vmemSize int, driver IGuestDriver) (int, error) {
 	if vmemSize > 0 {
 		maxVmemGb := driver.GetMaxVMemSizeGB()
@@ -1231,7 +1254,7 @@ func parseInstanceSnapshot(input *api.ServerCreateInput) (*api.ServerCreateInput
 		return nil, httperrors.NewInternalServerError("fetch instance snapshot error %s", err)
 	}
 	isp := ispi.(*SInstanceSnapshot)
-	if isp.Status != api.INSTANCE_SNAPSHOT_READY {
+	if isp.Status != api.INSTANCE_SNAPSHOT_READY && isp.Status != api.INSTANCE_SNAPSHOT_CREATE_SERVER {
 		return nil, httperrors.NewBadRequestError("Instance snapshot not ready")
 	}
 	input, err = isp.ToInstanceCreateInput(input)
@@ -1349,6 +1372,11 @@ func (manager *SGuestManager) validateCreateData(
 		input.InstanceGroupIds = newGroupIds
 	}
 
+	err = ImageCatalogManager.applyToServerCreateInput(userCred, input)
+	if err != nil {
+		return nil, err
+	}
+
 	// check that all image of disk is the part of guest imgae, if use guest image to create guest
 	err = manager.checkGuestImage(ctx, input)
 	if err != nil {
@@ -1372,6 +1400,10 @@ func (manager *SGuestManager) validateCreateData(
 			if err != nil {
 				return nil, httperrors.NewInputParameterError("Invalid root image: %s", err)
 			}
+			err = ImageCatalogManager.checkServerImage(ctx, userCred, diskConfig.ImageId)
+			if err != nil {
+				return nil, err
+			}
 			input.Disks[0] = diskConfig
 			imgEncryptKeyId = diskConfig.ImageEncryptKeyId
 			imgProperties = diskConfig.ImageProperties
@@ -1549,7 +1581,12 @@ func (manager *SGuestManager) validateCreateData(
 		if err != nil {
 			return nil, httperrors.NewGeneralError(err) // should no error
 		}
+		storageTypes := GetDriver(hypervisor).GetStorageTypes()
 		if input.ResourceType != api.HostResourceTypePrepaidRecycle {
+			err = fillDiskConfigByStorageTier(rootDiskConfig, storageTypes, input.PreferZone, input.PreferRegion)
+			if err != nil {
+				return nil, err
+			}
 			if len(rootDiskConfig.Backend) == 0 {
 				defaultStorageType, _ := data.GetString("default_storage_type")
 				if len(defaultStorageType) > 0 {
@@ -1586,6 +1623,10 @@ func (manager *SGuestManager) validateCreateData(
 				log.Warningf("Snapshot error: disk index %d > 0 but disk type is %s", i+1, api.DISK_TYPE_SYS)
 				diskConfig.DiskType = api.DISK_TYPE_DATA
 			}
+			err = fillDiskConfigByStorageTier(diskConfig, storageTypes, input.PreferZone, input.PreferRegion)
+			if err != nil {
+				return nil, err
+			}
 			if len(diskConfig.Backend) == 0 {
 				diskConfig.Backend = rootDiskConfig.Backend
 			}
@@ -1726,6 +1767,27 @@ func (manager *SGuestManager) validateCreateData(
 		return nil, httperrors.NewInputParameterError("%s shall bind up to %d security groups", hypervisor, maxSecgrpCount)
 	}
 
+	if len(input.ExternalProjectId) > 0 {
+		preferManagerId := input.PreferManager
+		if len(input.PreferHost) > 0 && len(preferManagerId) == 0 {
+			hostObj, err := HostManager.FetchById(input.PreferHost)
+			if err != nil {
+				return nil, httperrors.NewResourceNotFoundError2("host", input.PreferHost)
+			}
+			preferManagerId = hostObj.(*SHost).ManagerId
+			if len(preferManagerId) == 0 {
+				return nil, httperrors.NewInputParameterError("external project is only supported by managed host")
+			}
+		}
+		input.ExternalProjectId, err = ExternalProjectManager.validateCreateExternalProject(userCred, input.ExternalProjectId, &preferManagerId)
+		if err != nil {
+			return nil, err
+		}
+		if len(input.PreferHost) == 0 {
+			input.PreferManager = preferManagerId
+		}
+	}
+
 	preferRegionId, _ := data.GetString("prefer_region_id")
 	if err := manager.validateEip(userCred, input, preferRegionId, input.PreferManager); err != nil {
 		return nil, err
@@ -1767,6 +1829,11 @@ func (manager *SGuestManager) validateCreateData(
 		return nil, err
 	}
 
+	input.Metadata, err = TagPolicyManager.validateCreateTags(ctx, ownerId, input.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
 	input.ProjectId = ownerId.GetProjectId()
 	input.ProjectDomainId = ownerId.GetProjectDomainId()
 	return input, nil
@@ -2844,6 +2911,7 @@ func (self *SGuest) syncWithCloudVM(ctx context.Context, userCred mcclient.Token
 		recycle = true
 	}
 
+	oldStatus := self.Status
 	diff, err := db.UpdateWithLock(ctx, self, func() error {
 		if options.NameSyncResources.Contains(self.Keyword()) && !recycle {
 			newName, _ := db.GenerateAlterName(self, extVM.GetName())
@@ -2900,6 +2968,7 @@ func (self *SGuest) syncWithCloudVM(ctx context.Context, userCred mcclient.Token
 		self.Hypervisor = extVM.GetHypervisor()
 
 		self.IsEmulated = extVM.IsEmulated()
+		self.ExternalProjectId = extVM.GetProjectId()
 
 		if provider.GetFactory().IsSupportPrepaidResources() && !recycle &&
 			!extVM.GetExpiredAt().IsZero() {
@@ -2926,6 +2995,7 @@ func (self *SGuest) syncWithCloudVM(ctx context.Context, userCred mcclient.Token
 	}
 
 	db.OpsLog.LogSyncUpdate(self, diff, userCred)
+	GuestAvailabilityRecordManager.recordStatusChange(ctx, self, oldStatus, api.GUEST_AVAILABILITY_SOURCE_SYNC)
 
 	if len(diff) > 0 {
 		notifyclient.EventNotify(ctx, userCred, notifyclient.SEventNotifyParam{
@@ -2950,10 +3020,40 @@ func (self *SGuest) syncWithCloudVM(ctx context.Context, userCred mcclient.Token
 	return nil
 }
 
-func (manager *SGuestManager) newCloudVM(ctx context.Context, userCred mcclient.TokenCredential, provider cloudprovider.ICloudProvider, host *SHost, extVM cloudprovider.ICloudVM, syncOwnerId mcclient.IIdentityProvider) (*SGuest, error) {
+func (manager *SGuestManager) newCloudVMs(ctx context.Context, userCred mcclient.TokenCredential, provider cloudprovider.ICloudProvider, host *SHost, extVMs []cloudprovider.ICloudVM, syncOwnerId mcclient.IIdentityProvider) ([]*SGuest, []error) {
+	guests := make([]*SGuest, len(extVMs))
+	objs := make([]db.IModel, len(extVMs))
+	errs := make([]error, len(extVMs))
+	for i := range extVMs {
+		guests[i] = manager.newGuestFromCloud(provider, host, extVMs[i])
+		objs[i] = guests[i]
+	}
 
-	guest := SGuest{}
-	guest.SetModelManager(manager, &guest)
+	insertSyncedModels(ctx, manager, objs, errs, func(i int, reserved map[string]bool) error {
+		if options.NameSyncResources.Contains(manager.Keyword()) {
+			guests[i].Name = extVMs[i].GetName()
+			return nil
+		}
+		newName, err := db.GenerateBatchName(ctx, manager, syncOwnerId, extVMs[i].GetName(), reserved)
+		if err != nil {
+			return errors.Wrapf(err, "db.GenerateName")
+		}
+		guests[i].Name = newName
+		return nil
+	})
+
+	for i := range guests {
+		if errs[i] != nil {
+			continue
+		}
+		guests[i].postCreateFromCloud(ctx, userCred, host, extVMs[i], syncOwnerId)
+	}
+	return guests, errs
+}
+
+func (manager *SGuestManager) newGuestFromCloud(provider cloudprovider.ICloudProvider, host *SHost, extVM cloudprovider.ICloudVM) *SGuest {
+	guest := &SGuest{}
+	guest.SetModelManager(manager, guest)
 
 	guest.Status = extVM.GetStatus()
 	guest.PowerStates = extVM.GetPowerStates()
@@ -2973,6 +3073,7 @@ func (manager *SGuestManager) newCloudVM(ctx context.Context, userCred mcclient.
 	guest.Throughput = extVM.GetThroughput()
 
 	guest.IsEmulated = extVM.IsEmulated()
+	guest.ExternalProjectId = extVM.GetProjectId()
 
 	if provider.GetFactory().IsSupportPrepaidResources() {
 		guest.BillingType = extVM.GetBillingType()
@@ -3019,54 +3120,36 @@ func (manager *SGuestManager) newCloudVM(ctx context.Context, userCred mcclient.
 		guest.VmemSize = extVM.GetVmemSizeMB()
 	}
 
-	var err = func() error {
-		lockman.LockRawObject(ctx, manager.Keyword(), "name")
-		defer lockman.ReleaseRawObject(ctx, manager.Keyword(), "name")
-
-		if options.NameSyncResources.Contains(manager.Keyword()) {
-			guest.Name = extVM.GetName()
-		} else {
-			newName, err := db.GenerateName(ctx, manager, syncOwnerId, extVM.GetName())
-			if err != nil {
-				return errors.Wrapf(err, "db.GenerateName")
-			}
-			guest.Name = newName
-		}
-
-		return manager.TableSpec().Insert(ctx, &guest)
-	}()
-	if err != nil {
-		return nil, errors.Wrapf(err, "Insert")
-	}
+	return guest
+}
 
+func (guest *SGuest) postCreateFromCloud(ctx context.Context, userCred mcclient.TokenCredential, host *SHost, extVM cloudprovider.ICloudVM, syncOwnerId mcclient.IIdentityProvider) {
 	guest.SyncOsInfo(ctx, userCred, extVM)
 
-	syncVirtualResourceMetadata(ctx, userCred, &guest, extVM)
-	SyncCloudProject(userCred, &guest, syncOwnerId, extVM, host.ManagerId)
+	syncVirtualResourceMetadata(ctx, userCred, guest, extVM)
+	SyncCloudProject(userCred, guest, syncOwnerId, extVM, host.ManagerId)
 
-	db.OpsLog.LogEvent(&guest, db.ACT_CREATE, guest.GetShortDesc(ctx), userCred)
+	db.OpsLog.LogEvent(guest, db.ACT_CREATE, guest.GetShortDesc(ctx), userCred)
 
 	if guest.Status == api.VM_RUNNING {
-		db.OpsLog.LogEvent(&guest, db.ACT_START, guest.GetShortDesc(ctx), userCred)
+		db.OpsLog.LogEvent(guest, db.ACT_START, guest.GetShortDesc(ctx), userCred)
 	}
 
 	notifyclient.EventNotify(ctx, userCred, notifyclient.SEventNotifyParam{
-		Obj:    &guest,
+		Obj:    guest,
 		Action: notifyclient.ActionSyncCreate,
 	})
 
 	if guest.GetDriver().GetMaxSecurityGroupCount() == 0 {
-		db.Update(&guest, func() error {
+		db.Update(guest, func() error {
 			guest.SecgrpId = ""
 			return nil
 		})
 	}
 
 	if guest.Status == api.VM_RUNNING {
-		db.OpsLog.LogEvent(&guest, db.ACT_START, guest.GetShortDesc(ctx), userCred)
+		db.OpsLog.LogEvent(guest, db.ACT_START, guest.GetShortDesc(ctx), userCred)
 	}
-
-	return &guest, nil
 }
 
 func (manager *SGuestManager) TotalCount(
@@ -3396,6 +3479,7 @@ func (self *SGuest) SyncVMNics(ctx context.Context, userCred mcclient.TokenCrede
 	commondb := make([]SGuestnetwork, 0)
 	commonext := make([]cloudprovider.ICloudNic, 0)
 	added := make([]cloudprovider.ICloudNic, 0)
+	conflicts := make([]SNetworkIpConflict, 0)
 	set := compare.SCompareSet{
 		DBFunc:  "GetMAC",
 		DBSet:   nics,
@@ -3423,20 +3507,32 @@ func (self *SGuest) SyncVMNics(ctx context.Context, userCred mcclient.TokenCrede
 			result.UpdateError(err)
 			continue
 		}
+		network := commondb[i].GetNetwork()
+		ip := commonext[i].GetIP()
+		if len(ip) > 0 && !network.Contains(ip) {
+			localNet, err := getCloudNicNetwork(ctx, commonext[i], host, ipList, i)
+			if err != nil {
+				conflicts = append(conflicts, newNetworkIpConflict(self.Id, "", commondb[i].MacAddr, commondb[i].IpAddr, ip, api.NETWORK_IP_CONFLICT_NO_NETWORK))
+				result.UpdateError(errors.Wrapf(err, "getCloudNicNetwork"))
+				continue
+			}
+			network = localNet
+		}
+		if len(ip) > 0 && (ip != commondb[i].IpAddr || network.Id != commondb[i].NetworkId) {
+			// 以云上IP为准, 同时被本地其他资源占用时记录冲突
+			used, err := network.isAddressUsedByOthers(ip, self.Id)
+			if err != nil {
+				result.UpdateError(errors.Wrapf(err, "isAddressUsedByOthers"))
+				continue
+			}
+			if used {
+				conflicts = append(conflicts, newNetworkIpConflict(self.Id, network.Id, commondb[i].MacAddr, commondb[i].IpAddr, ip, api.NETWORK_IP_CONFLICT_IP_IN_USE))
+			}
+		}
 		_, err = db.Update(&commondb[i], func() error {
-			network := commondb[i].GetNetwork()
-			ip := commonext[i].GetIP()
 			if len(ip) > 0 {
-				if !network.Contains(ip) {
-					localNet, err := getCloudNicNetwork(ctx, commonext[i], host, ipList, i)
-					if err != nil {
-						return errors.Wrapf(err, "getCloudNicNetwork")
-					}
-					commondb[i].NetworkId = localNet.Id
-					commondb[i].IpAddr = ip
-				} else {
-					commondb[i].IpAddr = ip
-				}
+				commondb[i].NetworkId = network.Id
+				commondb[i].IpAddr = ip
 			}
 			commondb[i].Driver = commonext[i].GetDriver()
 			return nil
@@ -3455,6 +3551,7 @@ func (self *SGuest) SyncVMNics(ctx context.Context, userCred mcclient.TokenCrede
 			log.Errorf("SyncVMNics getCloudNicNetwork add fail: %s", err)
 			if ip := added[i].GetIP(); len(ip) > 0 {
 				syncIps = append(syncIps, ip)
+				conflicts = append(conflicts, newNetworkIpConflict(self.Id, "", added[i].GetMAC(), "", ip, api.NETWORK_IP_CONFLICT_NO_NETWORK))
 			}
 			result.AddError(err)
 			continue
@@ -3484,6 +3581,11 @@ func (self *SGuest) SyncVMNics(ctx context.Context, userCred mcclient.TokenCrede
 			NicConfs:            []SNicConfig{nicConf},
 		})
 		if err != nil {
+			if len(ip) > 0 {
+				if used, _ := localNet.isAddressUsedByOthers(ip, self.Id); used {
+					conflicts = append(conflicts, newNetworkIpConflict(self.Id, localNet.Id, added[i].GetMAC(), "", ip, api.NETWORK_IP_CONFLICT_IP_IN_USE))
+				}
+			}
 			result.AddError(err)
 			continue
 		}
@@ -3497,6 +3599,11 @@ func (self *SGuest) SyncVMNics(ctx context.Context, userCred mcclient.TokenCrede
 		}
 	}
 
+	err = NetworkIpConflictManager.syncGuestConflicts(ctx, self.Id, conflicts)
+	if err != nil {
+		log.Errorf("sync guest %s ip conflicts error: %v", self.Name, err)
+	}
+
 	if len(syncIps) > 0 {
 		self.SetMetadata(ctx, "sync_ips", strings.Join(syncIps, ","), userCred)
 	} else {
@@ -4389,6 +4496,26 @@ func (self *SGuest) RealDelete(ctx context.Context, userCred mcclient.TokenCrede
 			return errors.Wrapf(err, "backend real delete %s", backends[i].Id)
 		}
 	}
+	err = NetworkIpConflictManager.syncGuestConflicts(ctx, self.Id, nil)
+	if err != nil {
+		return errors.Wrapf(err, "clean ip conflicts")
+	}
+	err = GuestSoftwareInventoryManager.deleteByGuest(ctx, userCred, self.Id)
+	if err != nil {
+		return errors.Wrapf(err, "clean software inventory")
+	}
+	err = GuestCommandInvocationManager.deleteByGuest(ctx, userCred, self.Id)
+	if err != nil {
+		return errors.Wrapf(err, "clean command invocations")
+	}
+	err = SnapshotPolicyGuestManager.deleteByGuest(ctx, userCred, self.Id)
+	if err != nil {
+		return errors.Wrapf(err, "detach snapshot policies")
+	}
+	err = ResourceLockManager.deleteByResource(ctx, userCred, self.Id)
+	if err != nil {
+		return errors.Wrapf(err, "clean resource locks")
+	}
 	return self.SVirtualResourceBase.Delete(ctx, userCred)
 }
 
@@ -4407,6 +4534,13 @@ func (self *SGuest) AllowDeleteItem(ctx context.Context, userCred mcclient.Token
 
 // 删除虚拟机
 func (self *SGuest) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query api.ServerDeleteInput, data jsonutils.JSONObject) error {
+	approval, err := OperationApprovalManager.requireApproval(ctx, userCred, self.DomainId, api.OPERATION_APPROVAL_ACTION_DELETE_SERVER, GuestManager.Keyword(), []string{self.Id}, jsonutils.Marshal(query))
+	if err != nil {
+		return err
+	}
+	if approval != nil {
+		return httperrors.NewNotAcceptableError("deleting server %s requires approval, approval request %s is pending", self.Name, approval.Id)
+	}
 	return self.StartDeleteGuestTask(ctx, userCred, "", query)
 }
 
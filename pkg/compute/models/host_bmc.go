@@ -0,0 +1,88 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	_ "yunion.io/x/onecloud/pkg/util/redfish/loader"
+)
+
+// 托管的私有云宿主机(目前仅Proxmox)记录了BMC地址时, 可通过Redfish进行开关机及重置
+func (self *SHost) isBmcPowerManaged() bool {
+	if self.IsBaremetal || len(self.ManagerId) == 0 {
+		return false
+	}
+	if !utils.IsInStringArray(self.HostType, api.HOST_TYPES) {
+		return false
+	}
+	return self.GetHostDriver().IsSupportBmcPower()
+}
+
+func (self *SHost) GetRedfishDriver(ctx context.Context) (redfish.IRedfishDriver, error) {
+	info, err := self.GetIpmiInfo()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetIpmiInfo")
+	}
+	if len(info.IpAddr) == 0 || len(info.Username) == 0 {
+		return nil, errors.Wrapf(httperrors.ErrInvalidStatus, "host %s has no bmc address or username", self.Name)
+	}
+	passwd := info.Password
+	if len(passwd) > 0 {
+		passwd, err = utils.DescryptAESBase64(self.Id, info.Password)
+		if err != nil {
+			return nil, errors.Wrap(err, "DescryptAESBase64")
+		}
+	}
+	drv := redfish.NewRedfishDriver(ctx, "https://"+info.IpAddr, info.Username, passwd, false)
+	if drv == nil {
+		return nil, errors.Wrapf(httperrors.ErrNotSupported, "bmc %s is not redfish compatible", info.IpAddr)
+	}
+	return drv, nil
+}
+
+func (self *SHost) StartBmcPowerTask(ctx context.Context, userCred mcclient.TokenCredential, action string, force bool) error {
+	if !self.isBmcPowerManaged() {
+		return httperrors.NewNotSupportedError("bmc power management is not supported for %s host %s", self.HostType, self.Name)
+	}
+	if !utils.IsInStringArray(action, []string{api.HOST_BMC_POWER_ON, api.HOST_BMC_POWER_OFF, api.HOST_BMC_POWER_RESET}) {
+		return httperrors.NewInputParameterError("invalid bmc power action %s", action)
+	}
+	info, _ := self.GetIpmiInfo()
+	if len(info.IpAddr) == 0 {
+		return httperrors.NewInvalidStatusError("host %s has no bmc address, set ipmi_ip_addr first", self.Name)
+	}
+	if self.Status == api.HOST_BMC_POWERING {
+		return httperrors.NewInvalidStatusError("host %s is in bmc power operation", self.Name)
+	}
+	params := jsonutils.NewDict()
+	params.Set("action", jsonutils.NewString(action))
+	params.Set("force", jsonutils.NewBool(force))
+	task, err := taskman.TaskManager.NewTask(ctx, "HostBmcPowerTask", self, userCred, params, "", "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	self.SetStatus(userCred, api.HOST_BMC_POWERING, action)
+	return task.ScheduleRun(nil)
+}
@@ -0,0 +1,254 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/timeutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// 设置可用区(纳管私有云集群)的宿主机故障HA策略
+func (zone *SZone) PerformSetHostHaPolicy(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ZoneHostHaPolicyInput) (jsonutils.JSONObject, error) {
+	if input.HostDownSeconds < 0 {
+		return nil, httperrors.NewInputParameterError("host_down_seconds must not be negative")
+	}
+	meta := map[string]interface{}{}
+	switch input.AutoRestartOnHostDown {
+	case "enable", "disable":
+		meta[api.ZONEMETA_HA_RESTART_ON_HOST_DOWN] = input.AutoRestartOnHostDown
+	case "":
+	default:
+		return nil, httperrors.NewInputParameterError("invalid auto_restart_on_host_down %s", input.AutoRestartOnHostDown)
+	}
+	if input.HostDownSeconds > 0 {
+		meta[api.ZONEMETA_HA_HOST_DOWN_SECONDS] = strconv.Itoa(input.HostDownSeconds)
+	}
+	if len(meta) == 0 {
+		return nil, nil
+	}
+	return nil, zone.SetAllMetadata(ctx, meta, userCred)
+}
+
+func (zone *SZone) isHaRestartOnHostDown(ctx context.Context) bool {
+	return zone.GetMetadata(ctx, api.ZONEMETA_HA_RESTART_ON_HOST_DOWN, nil) == "enable"
+}
+
+func (zone *SZone) getHaHostDownSeconds(ctx context.Context) int {
+	seconds, _ := strconv.Atoi(zone.GetMetadata(ctx, api.ZONEMETA_HA_HOST_DOWN_SECONDS, nil))
+	if seconds <= 0 {
+		return api.ZONE_HA_DEFAULT_HOST_DOWN_SECONDS
+	}
+	return seconds
+}
+
+// 纳管私有云宿主机心跳: 通过云平台API检测宿主机状态, 独立于全量同步及时更新 host_status,
+// 宕机持续超过可用区阈值后按可用区策略HA重启虚拟机
+func (manager *SHostManager) ManagedHostHealthCheck(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	providers := CloudproviderManager.GetPrivateOrOnPremiseProviderIdsQuery()
+	q := manager.Query().IsTrue("enabled")
+	q = q.In("manager_id", providers)
+	q = q.NotIn("status", []string{api.BAREMETAL_START_MAINTAIN, api.BAREMETAL_MAINTAINING, api.BAREMETAL_MAINTAIN_FAIL})
+
+	hosts := []SHost{}
+	err := db.FetchModelObjects(manager, q, &hosts)
+	if err != nil {
+		log.Errorf("ManagedHostHealthCheck fetch hosts error: %v", err)
+		return
+	}
+	iregions := map[string]cloudprovider.ICloudRegion{}
+	for i := range hosts {
+		status, err := hosts[i].getManagedHostStatus(ctx, iregions)
+		if err != nil {
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				// 云上已不存在的宿主机由全量同步处理, 不视为宕机
+				log.Infof("managed host %s not found on cloudprovider, leave it to sync", hosts[i].Name)
+				continue
+			}
+			// 云平台不可达时无法判断宿主机状态
+			log.Warningf("check managed host %s status error: %v", hosts[i].Name, err)
+			continue
+		}
+		switch status {
+		case api.HOST_ONLINE:
+			hosts[i].onManagedHostUp(ctx, userCred)
+		case api.HOST_OFFLINE:
+			hosts[i].onManagedHostDown(ctx, userCred)
+		}
+	}
+}
+
+// 同一订阅同一可用区的宿主机复用 iregion, 避免每台宿主机重复获取
+func (host *SHost) getManagedHostStatus(ctx context.Context, iregions map[string]cloudprovider.ICloudRegion) (string, error) {
+	key := fmt.Sprintf("%s-%s", host.ManagerId, host.ZoneId)
+	iregion, ok := iregions[key]
+	if !ok {
+		provider, err := host.GetDriver(ctx)
+		if err != nil {
+			return "", errors.Wrapf(err, "GetDriver")
+		}
+		iregion, err = host.getIRegionOfProvider(provider)
+		if err != nil {
+			return "", err
+		}
+		iregions[key] = iregion
+	}
+	iHost, err := iregion.GetIHostById(host.ExternalId)
+	if err != nil {
+		return "", errors.Wrapf(err, "GetIHostById(%s)", host.ExternalId)
+	}
+	return iHost.GetHostStatus(), nil
+}
+
+func (host *SHost) onManagedHostUp(ctx context.Context, userCred mcclient.TokenCredential) {
+	for _, key := range []string{api.HOSTMETA_MANAGED_HOST_DOWN_AT, api.HOSTMETA_MANAGED_HOST_HA_AT} {
+		if len(host.GetMetadata(ctx, key, nil)) > 0 {
+			host.RemoveMetadata(ctx, key, userCred)
+		}
+	}
+	if host.HostStatus == api.HOST_ONLINE {
+		return
+	}
+	_, err := db.Update(host, func() error {
+		host.HostStatus = api.HOST_ONLINE
+		if host.Status == api.BAREMETAL_UNKNOWN {
+			host.Status = api.BAREMETAL_RUNNING
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("update managed host %s online error: %v", host.Name, err)
+		return
+	}
+	db.OpsLog.LogEvent(host, db.ACT_ONLINE, "host recovered on cloudprovider", userCred)
+	host.SyncAttachedStorageStatus()
+}
+
+func (host *SHost) onManagedHostDown(ctx context.Context, userCred mcclient.TokenCredential) {
+	downAt, _ := timeutils.ParseTimeStr(host.GetMetadata(ctx, api.HOSTMETA_MANAGED_HOST_DOWN_AT, nil))
+	if downAt.IsZero() {
+		downAt = time.Now().UTC()
+		host.SetMetadata(ctx, api.HOSTMETA_MANAGED_HOST_DOWN_AT, timeutils.FullIsoTime(downAt), userCred)
+	}
+	if host.HostStatus != api.HOST_OFFLINE {
+		reason := fmt.Sprintf("host down on cloudprovider since %s", timeutils.FullIsoTime(downAt))
+		host.PerformOffline(ctx, userCred, nil, &api.HostOfflineInput{Reason: reason})
+	}
+	if len(host.GetMetadata(ctx, api.HOSTMETA_MANAGED_HOST_HA_AT, nil)) > 0 {
+		return
+	}
+	zone, err := host.GetZone()
+	if err != nil {
+		log.Errorf("host %s GetZone error: %v", host.Name, err)
+		return
+	}
+	if time.Since(downAt) < time.Duration(zone.getHaHostDownSeconds(ctx))*time.Second {
+		return
+	}
+
+	lockman.LockObject(ctx, host)
+	defer lockman.ReleaseObject(ctx, host)
+
+	guests, err := host.GetGuests()
+	if err != nil {
+		log.Errorf("host %s GetGuests error: %v", host.Name, err)
+		return
+	}
+	host.MarkGuestUnknown(userCred)
+	host.SetMetadata(ctx, api.HOSTMETA_MANAGED_HOST_HA_AT, timeutils.FullIsoTime(time.Now().UTC()), userCred)
+
+	if zone.isHaRestartOnHostDown(ctx) {
+		host.haRestartGuests(ctx, userCred, guests)
+	}
+}
+
+// 将故障宿主机上原本运行中的虚拟机冷迁移到同可用区负载最低的宿主机并启动
+func (host *SHost) haRestartGuests(ctx context.Context, userCred mcclient.TokenCredential, guests []SGuest) {
+	candidates, err := host.getMaintenanceCandidateHosts()
+	if err != nil {
+		log.Errorf("host %s getMaintenanceCandidateHosts error: %v", host.Name, err)
+		return
+	}
+	load := map[string]int{}
+	for i := range candidates {
+		load[candidates[i].Id], _ = candidates[i].GetGuestCount()
+	}
+	for i := range guests {
+		guest := &guests[i]
+		if guest.Status != api.VM_RUNNING {
+			continue
+		}
+		devs, _ := guest.GetIsolatedDevices()
+		msg := getHaRestartSkipReason(guest.Hypervisor, guest.GetDriver().IsSupportMigrate(), len(devs))
+		if len(msg) > 0 {
+			// 不支持迁移的虚拟机保持unknown状态, 等待宿主机恢复或人工处理
+			guest.SetStatus(userCred, api.VM_UNKNOWN, fmt.Sprintf("ha restart skipped: %s", msg))
+			db.OpsLog.LogEvent(guest, db.ACT_MIGRATE_FAIL, fmt.Sprintf("ha restart on host %s down skipped: %s", host.Name, msg), userCred)
+			continue
+		}
+		target := pickHaRestartTarget(candidates, load)
+		if target == nil {
+			msg = "no available host"
+		} else {
+			load[target.Id] += 1
+			err = guest.StartMigrateTask(ctx, userCred, true, true, api.VM_UNKNOWN, target.Id, "")
+			if err != nil {
+				msg = err.Error()
+			}
+		}
+		if len(msg) > 0 {
+			db.OpsLog.LogEvent(guest, db.ACT_MIGRATE_FAIL, fmt.Sprintf("ha restart on host %s down: %s", host.Name, msg), userCred)
+			logclient.AddSimpleActionLog(guest, logclient.ACT_MIGRATE, fmt.Sprintf("ha restart on host %s down: %s", host.Name, msg), userCred, false)
+			continue
+		}
+		db.OpsLog.LogEvent(host, db.ACT_HOST_DOWN, fmt.Sprintf("ha restart guest %s on host %s", guest.Name, target.Name), userCred)
+	}
+}
+
+// 冷迁移仅部分平台驱动支持, 不支持的虚拟机不参与HA重启
+func getHaRestartSkipReason(hypervisor string, supportMigrate bool, isolatedDevCount int) string {
+	if !supportMigrate {
+		return fmt.Sprintf("hypervisor %s not support migrate", hypervisor)
+	}
+	if isolatedDevCount > 0 {
+		return "guest has isolated device"
+	}
+	return ""
+}
+
+// 选择已分配虚拟机数最少的宿主机
+func pickHaRestartTarget(candidates []SHost, load map[string]int) *SHost {
+	var target *SHost
+	for i := range candidates {
+		if target == nil || load[candidates[i].Id] < load[target.Id] {
+			target = &candidates[i]
+		}
+	}
+	return target
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestGetHaRestartSkipReason(t *testing.T) {
+	cases := []struct {
+		hypervisor     string
+		supportMigrate bool
+		devs           int
+		skip           bool
+	}{
+		{api.HYPERVISOR_ESXI, true, 0, false},
+		{api.HYPERVISOR_ESXI, true, 1, true},
+		{api.HYPERVISOR_NUTANIX, false, 0, true},
+		{api.HYPERVISOR_PROXMOX, false, 2, true},
+	}
+	for _, c := range cases {
+		if got := getHaRestartSkipReason(c.hypervisor, c.supportMigrate, c.devs); (len(got) > 0) != c.skip {
+			t.Errorf("%s support %v devs %d: want skip %v, got %q", c.hypervisor, c.supportMigrate, c.devs, c.skip, got)
+		}
+	}
+}
+
+func TestPickHaRestartTarget(t *testing.T) {
+	if target := pickHaRestartTarget(nil, map[string]int{}); target != nil {
+		t.Errorf("want no target without candidates, got %s", target.Id)
+	}
+	candidates := []SHost{}
+	for _, id := range []string{"h1", "h2", "h3"} {
+		host := SHost{}
+		host.Id = id
+		candidates = append(candidates, host)
+	}
+	load := map[string]int{"h1": 3, "h2": 1, "h3": 1}
+	picked := []string{}
+	for i := 0; i < 4; i++ {
+		target := pickHaRestartTarget(candidates, load)
+		load[target.Id] += 1
+		picked = append(picked, target.Id)
+	}
+	want := []string{"h2", "h3", "h2", "h3"}
+	for i := range want {
+		if picked[i] != want[i] {
+			t.Errorf("want %v, got %v", want, picked)
+			break
+		}
+	}
+}
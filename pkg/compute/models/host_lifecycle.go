@@ -0,0 +1,133 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// 已停止支持的虚拟化平台版本
+var hostEolVersions = map[string][]string{
+	api.HOST_TYPE_ESXI:    {"5.0", "5.1", "5.5", "6.0", "6.5", "6.7", "7.0"},
+	api.HOST_TYPE_PROXMOX: {"4", "5", "6", "7"},
+}
+
+// 归一化虚拟化平台版本号
+// ESXi版本格式为 6.7.0-17700523, 归一化为 6.7
+// Proxmox VE版本格式为 pve-manager/7.4-3/9002ab8a, 归一化为 7.4
+func normalizeHostVersion(hostType, version string) string {
+	version = strings.TrimSpace(version)
+	switch hostType {
+	case api.HOST_TYPE_ESXI:
+		version = strings.SplitN(version, "-", 2)[0]
+		parts := strings.Split(version, ".")
+		if len(parts) > 2 {
+			version = strings.Join(parts[:2], ".")
+		}
+	case api.HOST_TYPE_PROXMOX:
+		version = strings.TrimPrefix(version, "pve-manager/")
+		version = strings.SplitN(version, "/", 2)[0]
+		version = strings.SplitN(version, "-", 2)[0]
+	}
+	return version
+}
+
+func isHostVersionEol(hostType, version string) bool {
+	version = normalizeHostVersion(hostType, version)
+	if len(version) == 0 {
+		return false
+	}
+	for _, eol := range hostEolVersions[hostType] {
+		if version == eol || strings.HasPrefix(version, eol+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func buildHostEolReport(hosts []SHost) *api.HostEolReport {
+	ret := &api.HostEolReport{
+		Versions: []api.HostVersionStat{},
+		Items:    []api.HostEolItem{},
+	}
+	stats := map[string]*api.HostVersionStat{}
+	for i := range hosts {
+		host := &hosts[i]
+		version := normalizeHostVersion(host.HostType, host.Version)
+		if len(version) == 0 {
+			continue
+		}
+		ret.Total += 1
+		eol := isHostVersionEol(host.HostType, host.Version)
+		key := host.HostType + "/" + version
+		stat, ok := stats[key]
+		if !ok {
+			stat = &api.HostVersionStat{HostType: host.HostType, Version: version, Eol: eol}
+			stats[key] = stat
+		}
+		stat.Count += 1
+		if !eol {
+			continue
+		}
+		ret.Eol += 1
+		ret.Items = append(ret.Items, api.HostEolItem{
+			HostId:    host.Id,
+			Host:      host.Name,
+			HostType:  host.HostType,
+			ManagerId: host.ManagerId,
+			Version:   host.Version,
+		})
+	}
+	for _, stat := range stats {
+		ret.Versions = append(ret.Versions, *stat)
+	}
+	sort.Slice(ret.Versions, func(i, j int) bool {
+		if ret.Versions[i].HostType != ret.Versions[j].HostType {
+			return ret.Versions[i].HostType < ret.Versions[j].HostType
+		}
+		return ret.Versions[i].Version < ret.Versions[j].Version
+	})
+	return ret
+}
+
+// 宿主机虚拟化平台版本生命周期报告
+func (manager *SHostManager) GetPropertyEolReport(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.HostEolReport, error) {
+	q := manager.Query()
+	q, err := db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	hostTypes := []string{}
+	for hostType := range hostEolVersions {
+		hostTypes = append(hostTypes, hostType)
+	}
+	q = q.In("host_type", hostTypes)
+	hosts := []SHost{}
+	err = db.FetchModelObjects(manager, q, &hosts)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return buildHostEolReport(hosts), nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestIsHostVersionEol(t *testing.T) {
+	cases := []struct {
+		hostType string
+		version  string
+		want     string
+		eol      bool
+	}{
+		{api.HOST_TYPE_ESXI, "6.7.0-17700523", "6.7", true},
+		{api.HOST_TYPE_ESXI, "8.0.2-22380479", "8.0", false},
+		{api.HOST_TYPE_PROXMOX, "pve-manager/7.4-3/9002ab8a", "7.4", true},
+		{api.HOST_TYPE_PROXMOX, "pve-manager/8.1.4/ec5affc9e41f1d79", "8.1.4", false},
+		{api.HOST_TYPE_HYPERVISOR, "v3.11.0", "v3.11.0", false},
+		{api.HOST_TYPE_ESXI, "", "", false},
+	}
+	for _, c := range cases {
+		if got := normalizeHostVersion(c.hostType, c.version); got != c.want {
+			t.Errorf("normalize %s %q: want %q, got %q", c.hostType, c.version, c.want, got)
+		}
+		if got := isHostVersionEol(c.hostType, c.version); got != c.eol {
+			t.Errorf("eol %s %q: want %v, got %v", c.hostType, c.version, c.eol, got)
+		}
+	}
+}
+
+func TestBuildHostEolReport(t *testing.T) {
+	hosts := []SHost{
+		{HostType: api.HOST_TYPE_ESXI, Version: "6.7.0-17700523"},
+		{HostType: api.HOST_TYPE_ESXI, Version: "6.7.0-19195723"},
+		{HostType: api.HOST_TYPE_ESXI, Version: "8.0.2-22380479"},
+		{HostType: api.HOST_TYPE_PROXMOX, Version: ""},
+	}
+	report := buildHostEolReport(hosts)
+	if report.Total != 3 || report.Eol != 2 || len(report.Items) != 2 {
+		t.Errorf("unexpected report total %d eol %d items %d", report.Total, report.Eol, len(report.Items))
+	}
+	if len(report.Versions) != 2 || report.Versions[0].Version != "6.7" || report.Versions[0].Count != 2 || !report.Versions[0].Eol {
+		t.Errorf("unexpected version stats %v", report.Versions)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// 同一平台同一可用区内可接收迁移虚拟机的宿主机
+func (host *SHost) getMaintenanceCandidateHosts() ([]SHost, error) {
+	q := HostManager.Query().Equals("manager_id", host.ManagerId).Equals("zone_id", host.ZoneId).
+		NotEquals("id", host.Id).IsTrue("enabled").Equals("host_status", api.HOST_ONLINE).
+		NotIn("status", []string{api.BAREMETAL_START_MAINTAIN, api.BAREMETAL_MAINTAINING, api.BAREMETAL_MAINTAIN_FAIL})
+	hosts := []SHost{}
+	err := db.FetchModelObjects(HostManager, q, &hosts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchModelObjects")
+	}
+	return hosts, nil
+}
+
+// 纳管宿主机进入维护模式, 迁移宿主机上的虚拟机并禁止调度, 仅在本地标记维护状态, 不修改云平台上宿主机的状态
+func (host *SHost) PerformEnterMaintenance(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostEnterMaintenanceInput) (jsonutils.JSONObject, error) {
+	if !host.IsManaged() {
+		return nil, httperrors.NewUnsupportOperationError("host %s is not managed by cloudprovider, use host-maintenance instead", host.Name)
+	}
+	if host.IsMaintaining() {
+		return nil, httperrors.NewInvalidStatusError("host %s is in maintenance status %s", host.Name, host.Status)
+	}
+
+	var preferHost *SHost
+	if len(input.PreferHost) > 0 {
+		obj, err := HostManager.FetchByIdOrName(userCred, input.PreferHost)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(HostManager.Keyword(), input.PreferHost)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		preferHost = obj.(*SHost)
+		if preferHost.Id == host.Id || preferHost.ManagerId != host.ManagerId {
+			return nil, httperrors.NewInputParameterError("prefer_host %s must be another host of the same cloudprovider", preferHost.Name)
+		}
+		if !preferHost.GetEnabled() || preferHost.IsMaintaining() {
+			return nil, httperrors.NewInvalidStatusError("prefer_host %s is not available", preferHost.Name)
+		}
+	}
+
+	guests, err := host.GetGuests()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetGuests")
+	}
+
+	candidates := []SHost{}
+	load := map[string]int{}
+	if preferHost == nil && len(guests) > 0 {
+		candidates, err = host.getMaintenanceCandidateHosts()
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		for i := range candidates {
+			load[candidates[i].Id], _ = candidates[i].GetGuestCount()
+		}
+	}
+
+	items := []api.HostMaintenanceGuest{}
+	for i := range guests {
+		guest := guests[i]
+		if guest.Status != api.VM_RUNNING && guest.Status != api.VM_READY {
+			return nil, httperrors.NewInvalidStatusError("guest %s status %s can't migrate", guest.Name, guest.Status)
+		}
+		driver := guest.GetDriver()
+		live := guest.Status == api.VM_RUNNING
+		if !driver.IsSupportMigrate() || (live && !driver.IsSupportLiveMigrate()) {
+			return nil, httperrors.NewUnsupportOperationError("guest %s hypervisor %s can't migrate in status %s", guest.Name, guest.Hypervisor, guest.Status)
+		}
+		devs, _ := guest.GetIsolatedDevices()
+		if len(devs) > 0 {
+			return nil, httperrors.NewBadRequestError("guest %s has isolated device, can't migrate", guest.Name)
+		}
+		target := preferHost
+		if target == nil {
+			for j := range candidates {
+				if target == nil || load[candidates[j].Id] < load[target.Id] {
+					target = &candidates[j]
+				}
+			}
+		}
+		if target == nil {
+			return nil, httperrors.NewInsufficientResourceError("no available host to migrate guest %s", guest.Name)
+		}
+		load[target.Id] += 1
+		items = append(items, api.HostMaintenanceGuest{
+			Id:           guest.Id,
+			Name:         guest.Name,
+			TargetHostId: target.Id,
+			LiveMigrate:  live,
+			Status:       api.HOST_MAINTENANCE_GUEST_PENDING,
+		})
+	}
+
+	params := jsonutils.NewDict()
+	params.Set("guests", jsonutils.Marshal(items))
+	return nil, host.StartEnterMaintenanceTask(ctx, userCred, params, "")
+}
+
+func (host *SHost) StartEnterMaintenanceTask(ctx context.Context, userCred mcclient.TokenCredential, params *jsonutils.JSONDict, parentTaskId string) error {
+	host.SetStatus(userCred, api.BAREMETAL_START_MAINTAIN, "enter maintenance")
+	task, err := taskman.TaskManager.NewTask(ctx, "HostEnterMaintenanceTask", host, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask")
+	}
+	return task.ScheduleRun(nil)
+}
+
+func (host *SHost) exitManagedMaintenance(ctx context.Context, userCred mcclient.TokenCredential) error {
+	_, err := db.Update(host, func() error {
+		host.IsMaintenance = false
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	_, err = host.PerformEnable(ctx, userCred, nil, apis.PerformEnableInput{})
+	return err
+}
@@ -64,6 +64,9 @@ type IHostDriver interface {
 	RequestDetachStorage(ctx context.Context, host *SHost, storage *SStorage, task taskman.ITask) error
 	RequestSyncOnHost(ctx context.Context, host *SHost, task taskman.ITask) error
 	RequestProbeIsolatedDevices(ctx context.Context, userCred mcclient.TokenCredential, host *SHost, input jsonutils.JSONObject) (*jsonutils.JSONArray, error)
+
+	// 是否支持通过宿主机BMC(Redfish)进行电源管理
+	IsSupportBmcPower() bool
 }
 
 var hostDrivers map[string]IHostDriver
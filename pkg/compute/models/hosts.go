@@ -1830,12 +1830,12 @@ func (manager *SHostManager) SyncHosts(ctx context.Context, userCred mcclient.To
 			syncResult.Update()
 		}
 	}
+	newHosts, errs := manager.newFromCloudHosts(ctx, userCred, added, provider, zone)
 	for i := 0; i < len(added); i += 1 {
-		new, err := manager.NewFromCloudHost(ctx, userCred, added[i], provider, zone)
-		if err != nil {
-			syncResult.AddError(err)
+		if errs[i] != nil {
+			syncResult.AddError(errs[i])
 		} else {
-			localHosts = append(localHosts, *new)
+			localHosts = append(localHosts, *newHosts[i])
 			remoteHosts = append(remoteHosts, added[i])
 			syncResult.Add()
 		}
@@ -1864,7 +1864,11 @@ func (self *SHost) syncWithCloudHost(ctx context.Context, userCred mcclient.Toke
 	diff, err := db.UpdateWithLock(ctx, self, func() error {
 		// self.Name = extHost.GetName()
 
-		self.Status = extHost.GetStatus()
+		// 本地维护流程中保留维护状态, 避免同步后无法退出维护
+		maintaining := self.IsMaintaining()
+		if !maintaining {
+			self.Status = extHost.GetStatus()
+		}
 		self.HostStatus = extHost.GetHostStatus()
 		self.AccessIp = extHost.GetAccessIp()
 		self.AccessMac = extHost.GetAccessMac()
@@ -1901,9 +1905,10 @@ func (self *SHost) syncWithCloudHost(ctx context.Context, userCred mcclient.Toke
 		}
 
 		self.IsEmulated = extHost.IsEmulated()
-		self.SetEnabled(extHost.GetEnabled())
-
-		self.IsMaintenance = extHost.GetIsMaintenance()
+		if !maintaining {
+			self.SetEnabled(extHost.GetEnabled())
+			self.IsMaintenance = extHost.GetIsMaintenance()
+		}
 		self.Version = extHost.GetVersion()
 
 		return nil
@@ -2083,8 +2088,44 @@ func (s *SHost) syncSchedtags(ctx context.Context, userCred mcclient.TokenCreden
 }
 
 func (manager *SHostManager) NewFromCloudHost(ctx context.Context, userCred mcclient.TokenCredential, extHost cloudprovider.ICloudHost, provider *SCloudprovider, izone *SZone) (*SHost, error) {
-	host := SHost{}
-	host.SetModelManager(manager, &host)
+	hosts, errs := manager.newFromCloudHosts(ctx, userCred, []cloudprovider.ICloudHost{extHost}, provider, izone)
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return hosts[0], nil
+}
+
+func (manager *SHostManager) newFromCloudHosts(ctx context.Context, userCred mcclient.TokenCredential, extHosts []cloudprovider.ICloudHost, provider *SCloudprovider, izone *SZone) ([]*SHost, []error) {
+	hosts := make([]*SHost, len(extHosts))
+	zones := make([]*SZone, len(extHosts))
+	objs := make([]db.IModel, len(extHosts))
+	errs := make([]error, len(extHosts))
+	for i := range extHosts {
+		hosts[i], zones[i], errs[i] = manager.newHostFromCloud(extHosts[i], provider, izone)
+		objs[i] = hosts[i]
+	}
+
+	insertSyncedModels(ctx, manager, objs, errs, func(i int, reserved map[string]bool) error {
+		//newName, err := db.GenerateName(ctx, manager, userCred, extHosts[i].GetName())
+		//if err != nil {
+		//	return errors.Wrapf(err, "db.GenerateName")
+		//}
+		hosts[i].Name = extHosts[i].GetName()
+		return nil
+	})
+
+	for i := range hosts {
+		if errs[i] != nil {
+			continue
+		}
+		errs[i] = hosts[i].postCreateFromCloud(ctx, userCred, extHosts[i], provider, zones[i])
+	}
+	return hosts, errs
+}
+
+func (manager *SHostManager) newHostFromCloud(extHost cloudprovider.ICloudHost, provider *SCloudprovider, izone *SZone) (*SHost, *SZone, error) {
+	host := &SHost{}
+	host.SetModelManager(manager, host)
 
 	if izone == nil {
 		// onpremise host
@@ -2092,13 +2133,13 @@ func (manager *SHostManager) NewFromCloudHost(ctx context.Context, userCred mccl
 		if len(accessIp) == 0 {
 			msg := fmt.Sprintf("fail to find wire for host %s: empty host access ip", extHost.GetName())
 			log.Errorf(msg)
-			return nil, fmt.Errorf(msg)
+			return nil, nil, fmt.Errorf(msg)
 		}
 		wire, err := WireManager.GetOnPremiseWireOfIp(accessIp)
 		if err != nil {
 			msg := fmt.Sprintf("fail to find wire for host %s %s: %s", extHost.GetName(), accessIp, err)
 			log.Errorf(msg)
-			return nil, fmt.Errorf(msg)
+			return nil, nil, fmt.Errorf(msg)
 		}
 		izone, _ = wire.GetZone()
 	}
@@ -2153,40 +2194,31 @@ func (manager *SHostManager) NewFromCloudHost(ctx context.Context, userCred mccl
 	host.IsPublic = false
 	host.PublicScope = string(rbacscope.ScopeNone)
 
-	var err = func() error {
-		lockman.LockRawObject(ctx, manager.Keyword(), "name")
-		defer lockman.ReleaseRawObject(ctx, manager.Keyword(), "name")
-
-		//newName, err := db.GenerateName(ctx, manager, userCred, extHost.GetName())
-		//if err != nil {
-		//	return errors.Wrapf(err, "db.GenerateName")
-		//}
-		host.Name = extHost.GetName()
-
-		return manager.TableSpec().Insert(ctx, &host)
-	}()
-	if err != nil {
-		return nil, errors.Wrapf(err, "Insert")
-	}
+	return host, izone, nil
+}
 
-	db.OpsLog.LogEvent(&host, db.ACT_CREATE, host.GetShortDesc(ctx), userCred)
+func (host *SHost) postCreateFromCloud(ctx context.Context, userCred mcclient.TokenCredential, extHost cloudprovider.ICloudHost, provider *SCloudprovider, izone *SZone) error {
+	db.OpsLog.LogEvent(host, db.ACT_CREATE, host.GetShortDesc(ctx), userCred)
 
-	SyncCloudDomain(userCred, &host, provider.GetOwnerId())
+	SyncCloudDomain(userCred, host, provider.GetOwnerId())
 
 	if err := host.syncSchedtags(ctx, userCred, extHost); err != nil {
 		log.Errorf("newFromCloudHost fail in syncSchedtags %v", err)
-		return nil, err
+		return err
 	}
 
+	extTags, _ := extHost.GetTags()
+	SchedtagRuleManager.attachSyncedResource(ctx, userCred, host, provider, izone.CloudregionId, izone.Id, extTags)
+
 	if provider != nil {
 		host.SyncShareState(ctx, userCred, provider.getAccountShareInfo())
 	}
 
-	if err := manager.ClearSchedDescCache(host.Id); err != nil {
+	if err := HostManager.ClearSchedDescCache(host.Id); err != nil {
 		log.Errorf("ClearSchedDescCache for host %s error %v", host.Name, err)
 	}
 
-	return &host, nil
+	return nil
 }
 
 func (self *SHost) SyncHostStorages(ctx context.Context, userCred mcclient.TokenCredential, storages []cloudprovider.ICloudStorage, provider *SCloudprovider) ([]SStorage, []cloudprovider.ICloudStorage, compare.SyncResult) {
@@ -2536,6 +2568,7 @@ func (self *SHost) SyncHostVMs(ctx context.Context, userCred mcclient.TokenCrede
 		syncResult.Update()
 	}
 
+	newVMs := make([]cloudprovider.ICloudVM, 0)
 	for i := 0; i < len(added); i += 1 {
 		skip, key := IsNeedSkipSync(added[i])
 		if skip {
@@ -2584,13 +2617,17 @@ func (self *SHost) SyncHostVMs(ctx context.Context, userCred mcclient.TokenCrede
 				continue
 			}
 		}
-		new, err := GuestManager.newCloudVM(ctx, userCred, iprovider, self, added[i], syncOwnerId)
-		if err != nil {
-			syncResult.AddError(err)
+		newVMs = append(newVMs, added[i])
+	}
+
+	newGuests, errs := GuestManager.newCloudVMs(ctx, userCred, iprovider, self, newVMs, syncOwnerId)
+	for i := range newVMs {
+		if errs[i] != nil {
+			syncResult.AddError(errs[i])
 		} else {
 			syncVMPair := SGuestSyncResult{
-				Local:  new,
-				Remote: added[i],
+				Local:  newGuests[i],
+				Remote: newVMs[i],
 				IsNew:  true,
 			}
 			syncVMPairs = append(syncVMPairs, syncVMPair)
@@ -2944,21 +2981,9 @@ func (self *SHost) GetIHostAndProvider(ctx context.Context) (cloudprovider.IClou
 	if err != nil {
 		return nil, nil, fmt.Errorf("No cloudprovider for host: %s", err)
 	}
-	var iregion cloudprovider.ICloudRegion
-	if provider.GetFactory().IsOnPremise() {
-		iregion, err = provider.GetOnPremiseIRegion()
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "provider.GetOnPremiseIRegio")
-		}
-	} else {
-		region, err := self.GetRegion()
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "GetRegion")
-		}
-		iregion, err = provider.GetIRegionById(region.ExternalId)
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "provider.GetIRegionById(%s)", region.ExternalId)
-		}
+	iregion, err := self.getIRegionOfProvider(provider)
+	if err != nil {
+		return nil, nil, err
 	}
 	ihost, err := iregion.GetIHostById(self.ExternalId)
 	if err != nil {
@@ -2967,6 +2992,25 @@ func (self *SHost) GetIHostAndProvider(ctx context.Context) (cloudprovider.IClou
 	return ihost, provider, nil
 }
 
+func (self *SHost) getIRegionOfProvider(provider cloudprovider.ICloudProvider) (cloudprovider.ICloudRegion, error) {
+	if provider.GetFactory().IsOnPremise() {
+		iregion, err := provider.GetOnPremiseIRegion()
+		if err != nil {
+			return nil, errors.Wrapf(err, "provider.GetOnPremiseIRegio")
+		}
+		return iregion, nil
+	}
+	region, err := self.GetRegion()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetRegion")
+	}
+	iregion, err := provider.GetIRegionById(region.ExternalId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "provider.GetIRegionById(%s)", region.ExternalId)
+	}
+	return iregion, nil
+}
+
 func (self *SHost) GetIRegion(ctx context.Context) (cloudprovider.ICloudRegion, error) {
 	provider, err := self.GetDriver(ctx)
 	if err != nil {
@@ -3227,6 +3271,7 @@ func (manager *SHostManager) FetchCustomizeColumns(
 		host := objs[i].(*SHost)
 		hostIds[i] = host.Id
 		rows[i] = host.getMoreDetails(ctx, rows[i], showReason)
+		rows[i].VersionEol = isHostVersionEol(host.HostType, host.Version)
 	}
 	guestCnts := manager.FetchGuestCnt(hostIds)
 	for i := range rows {
@@ -3705,7 +3750,8 @@ func (self *SHost) ValidateUpdateData(ctx context.Context, userCred mcclient.Tok
 	ipmiInfoJson := jsonutils.Marshal(ipmiInfo).(*jsonutils.JSONDict)
 	if ipmiInfoJson.Length() > 0 {
 		ipmiIpAddr := ipmiInfo.IpAddr
-		if len(ipmiIpAddr) > 0 {
+		// 托管宿主机的BMC网络不由本平台管理, 无需校验所属网络
+		if len(ipmiIpAddr) > 0 && !self.isBmcPowerManaged() {
 			net, _ := NetworkManager.GetOnPremiseNetworkOfIP(ipmiIpAddr, "", tristate.None)
 			if net == nil {
 				return input, httperrors.NewInputParameterError("%s is out of network IP ranges", ipmiIpAddr)
@@ -3855,6 +3901,9 @@ func fetchIpmiInfo(data api.HostIpmiAttributes, hostId string) (types.SIPMIInfo,
 
 func (self *SHost) PerformStart(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject,
 	data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.IsManaged() && !self.IsBaremetal {
+		return nil, self.StartBmcPowerTask(ctx, userCred, api.HOST_BMC_POWER_ON, false)
+	}
 	if !self.IsBaremetal {
 		return nil, httperrors.NewBadRequestError("Cannot start a non-baremetal host")
 	}
@@ -3880,6 +3929,9 @@ func (self *SHost) PerformStart(ctx context.Context, userCred mcclient.TokenCred
 
 func (self *SHost) PerformStop(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject,
 	data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.IsManaged() && !self.IsBaremetal {
+		return nil, self.StartBmcPowerTask(ctx, userCred, api.HOST_BMC_POWER_OFF, jsonutils.QueryBoolean(data, "is_force", false))
+	}
 	if !self.IsBaremetal {
 		return nil, httperrors.NewBadRequestError("Cannot stop a non-baremetal host")
 	}
@@ -4921,6 +4973,9 @@ func (self *SHost) PerformSyncstatus(ctx context.Context, userCred mcclient.Toke
 }
 
 func (self *SHost) PerformReset(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.IsManaged() && !self.IsBaremetal {
+		return nil, self.StartBmcPowerTask(ctx, userCred, api.HOST_BMC_POWER_RESET, true)
+	}
 	if !self.IsBaremetal {
 		return nil, httperrors.NewBadRequestError("Cannot start a non-baremetal host")
 	}
@@ -5580,6 +5635,12 @@ func (host *SHost) PerformHostExitMaintenance(ctx context.Context, userCred mccl
 	if !utils.IsInStringArray(host.Status, []string{api.BAREMETAL_MAINTAIN_FAIL, api.BAREMETAL_MAINTAINING}) {
 		return nil, httperrors.NewInvalidStatusError("host status %s can't exit maintenance", host.Status)
 	}
+	if host.IsManaged() {
+		err := host.exitManagedMaintenance(ctx, userCred)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+	}
 	err := host.SetStatus(userCred, api.HOST_STATUS_RUNNING, "exit maintenance")
 	if err != nil {
 		return nil, err
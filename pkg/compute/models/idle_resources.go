@@ -0,0 +1,723 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SIdleResourceManager struct {
+	db.SStatusStandaloneResourceBaseManager
+	db.SProjectizedResourceBaseManager
+	SManagedResourceBaseManager
+}
+
+var IdleResourceManager *SIdleResourceManager
+
+func init() {
+	IdleResourceManager = &SIdleResourceManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SIdleResource{},
+			"idle_resources_tbl",
+			"idle_resource",
+			"idle_resources",
+		),
+	}
+	IdleResourceManager.SetVirtualObject(IdleResourceManager)
+}
+
+// 闲置资源, 由定时任务检测生成
+type SIdleResource struct {
+	db.SStatusStandaloneResourceBase
+	db.SProjectizedResourceBase
+	SManagedResourceBase
+
+	// 资源类型
+	ResourceType string `width:"32" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	// 资源ID
+	ResourceId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	// 云上资源ID
+	ExternalId string `width:"256" charset:"utf8" nullable:"true" list:"user"`
+	// 闲置原因
+	Reason string `width:"32" charset:"ascii" nullable:"false" list:"user"`
+	// 开始闲置时间
+	IdleSince time.Time `nullable:"true" list:"user"`
+	// 最近一次检测到闲置的时间
+	LastDetectedAt time.Time `nullable:"true" list:"user"`
+	// 申请清理的用户
+	RequestedBy string `width:"128" charset:"utf8" nullable:"true" list:"user"`
+	// 申请清理的用户ID
+	RequestedById string `width:"128" charset:"ascii" nullable:"true" list:"user"`
+	// 申请清理时间
+	RequestedAt time.Time `nullable:"true" list:"user"`
+	// 审批人
+	ApprovedBy string `width:"128" charset:"utf8" nullable:"true" list:"user"`
+}
+
+type sIdleCandidate struct {
+	ResourceType string
+	ResourceId   string
+	Name         string
+	ExternalId   string
+	ManagerId    string
+	ProjectId    string
+	DomainId     string
+	Reason       string
+	IdleSince    time.Time
+}
+
+func (manager *SIdleResourceManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return nil, httperrors.NewUnsupportOperationError("idle resources are detected periodically")
+}
+
+func (manager *SIdleResourceManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.IdleResourceListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SManagedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ManagedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.In("resource_type", query.ResourceType)
+	}
+	if len(query.ResourceId) > 0 {
+		q = q.In("resource_id", query.ResourceId)
+	}
+	if len(query.Reason) > 0 {
+		q = q.In("reason", query.Reason)
+	}
+	return q, nil
+}
+
+func (manager *SIdleResourceManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.IdleResourceListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SManagedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ManagedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SIdleResourceManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SManagedResourceBaseManager,
+	)
+}
+
+func (manager *SIdleResourceManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	return db.ApplyListItemExportKeys(ctx, q, userCred, keys,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SManagedResourceBaseManager,
+	)
+}
+
+func (manager *SIdleResourceManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.IdleResourceDetails {
+	rows := make([]api.IdleResourceDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	managerRows := manager.SManagedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	now := time.Now().UTC()
+	for i := range rows {
+		rows[i] = api.IdleResourceDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+			ProjectizedResourceInfo:         projRows[i],
+			ManagedResourceInfo:             managerRows[i],
+		}
+		res := objs[i].(*SIdleResource)
+		if !res.IdleSince.IsZero() {
+			rows[i].IdleDays = int(now.Sub(res.IdleSince).Hours() / 24)
+		}
+	}
+	return rows
+}
+
+func (manager *SIdleResourceManager) getResourceManager(resType string) db.IModelManager {
+	switch resType {
+	case api.IDLE_RESOURCE_TYPE_EIP:
+		return ElasticipManager
+	case api.IDLE_RESOURCE_TYPE_DISK:
+		return DiskManager
+	case api.IDLE_RESOURCE_TYPE_LOADBALANCER:
+		return LoadbalancerManager
+	case api.IDLE_RESOURCE_TYPE_SERVER:
+		return GuestManager
+	}
+	return nil
+}
+
+// ids 不为空时仅检测指定资源
+func (manager *SIdleResourceManager) detectIdle(resType string, ids []string, now time.Time) ([]sIdleCandidate, error) {
+	switch resType {
+	case api.IDLE_RESOURCE_TYPE_EIP:
+		return manager.detectIdleEips(ids)
+	case api.IDLE_RESOURCE_TYPE_DISK:
+		return manager.detectIdleDisks(ids)
+	case api.IDLE_RESOURCE_TYPE_LOADBALANCER:
+		return manager.detectIdleLoadbalancers(ids)
+	case api.IDLE_RESOURCE_TYPE_SERVER:
+		return manager.detectIdleGuests(ids, now)
+	}
+	return nil, errors.Wrapf(errors.ErrNotSupported, "resource type %s", resType)
+}
+
+func (manager *SIdleResourceManager) detectIdleEips(ids []string) ([]sIdleCandidate, error) {
+	q := ElasticipManager.Query().Equals("mode", api.EIP_MODE_STANDALONE_EIP).Equals("status", api.EIP_STATUS_READY).IsFalse("pending_deleted").IsNullOrEmpty("associate_id")
+	if len(ids) > 0 {
+		q = q.In("id", ids)
+	}
+	eips := []SElasticip{}
+	err := db.FetchModelObjects(ElasticipManager, q, &eips)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch eips")
+	}
+	ret := []sIdleCandidate{}
+	for i := range eips {
+		eip := eips[i]
+		ret = append(ret, sIdleCandidate{
+			ResourceType: api.IDLE_RESOURCE_TYPE_EIP,
+			ResourceId:   eip.Id,
+			Name:         eip.Name,
+			ExternalId:   eip.ExternalId,
+			ManagerId:    eip.ManagerId,
+			ProjectId:    eip.ProjectId,
+			DomainId:     eip.DomainId,
+			Reason:       api.IDLE_REASON_UNASSOCIATED,
+			IdleSince:    eip.UpdatedAt,
+		})
+	}
+	return ret, nil
+}
+
+func (manager *SIdleResourceManager) detectIdleDisks(ids []string) ([]sIdleCandidate, error) {
+	attached := GuestdiskManager.Query("disk_id").SubQuery()
+	q := DiskManager.Query().Equals("status", api.DISK_READY).IsFalse("pending_deleted").NotIn("id", attached)
+	if len(ids) > 0 {
+		q = q.In("id", ids)
+	}
+	disks := []SDisk{}
+	err := db.FetchModelObjects(DiskManager, q, &disks)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch disks")
+	}
+	ret := []sIdleCandidate{}
+	for i := range disks {
+		disk := disks[i]
+		ret = append(ret, sIdleCandidate{
+			ResourceType: api.IDLE_RESOURCE_TYPE_DISK,
+			ResourceId:   disk.Id,
+			Name:         disk.Name,
+			ExternalId:   disk.ExternalId,
+			ManagerId:    disk.GetCloudproviderId(),
+			ProjectId:    disk.ProjectId,
+			DomainId:     disk.DomainId,
+			Reason:       api.IDLE_REASON_UNATTACHED,
+			IdleSince:    disk.UpdatedAt,
+		})
+	}
+	return ret, nil
+}
+
+func (manager *SIdleResourceManager) detectIdleLoadbalancers(ids []string) ([]sIdleCandidate, error) {
+	backendGroups := LoadbalancerBackendManager.Query("backend_group_id").SubQuery()
+	usedLbs := LoadbalancerBackendGroupManager.Query("loadbalancer_id").In("id", backendGroups).SubQuery()
+	q := LoadbalancerManager.Query().IsFalse("pending_deleted").NotIn("id", usedLbs)
+	if len(ids) > 0 {
+		q = q.In("id", ids)
+	}
+	lbs := []SLoadbalancer{}
+	err := db.FetchModelObjects(LoadbalancerManager, q, &lbs)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch loadbalancers")
+	}
+	ret := []sIdleCandidate{}
+	for i := range lbs {
+		lb := lbs[i]
+		ret = append(ret, sIdleCandidate{
+			ResourceType: api.IDLE_RESOURCE_TYPE_LOADBALANCER,
+			ResourceId:   lb.Id,
+			Name:         lb.Name,
+			ExternalId:   lb.ExternalId,
+			ManagerId:    lb.ManagerId,
+			ProjectId:    lb.ProjectId,
+			DomainId:     lb.DomainId,
+			Reason:       api.IDLE_REASON_NO_BACKEND,
+			IdleSince:    lb.UpdatedAt,
+		})
+	}
+	return ret, nil
+}
+
+func (manager *SIdleResourceManager) detectIdleGuests(ids []string, now time.Time) ([]sIdleCandidate, error) {
+	days := options.Options.IdleGuestStoppedDays
+	if days <= 0 {
+		return []sIdleCandidate{}, nil
+	}
+	// 关机后虚拟机不再更新, 以更新时间近似关机时间
+	q := GuestManager.Query().Equals("status", api.VM_READY).IsFalse("pending_deleted").LT("updated_at", now.AddDate(0, 0, -days))
+	if len(ids) > 0 {
+		q = q.In("id", ids)
+	}
+	guests := []SGuest{}
+	err := db.FetchModelObjects(GuestManager, q, &guests)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch guests")
+	}
+	ret := []sIdleCandidate{}
+	for i := range guests {
+		guest := guests[i]
+		ret = append(ret, sIdleCandidate{
+			ResourceType: api.IDLE_RESOURCE_TYPE_SERVER,
+			ResourceId:   guest.Id,
+			Name:         guest.Name,
+			ExternalId:   guest.ExternalId,
+			ManagerId:    guest.GetCloudproviderId(),
+			ProjectId:    guest.ProjectId,
+			DomainId:     guest.DomainId,
+			Reason:       api.IDLE_REASON_LONG_STOPPED,
+			IdleSince:    guest.UpdatedAt,
+		})
+	}
+	return ret, nil
+}
+
+// 定时检测闲置的EIP, 磁盘, 负载均衡及长时间关机的虚拟机
+func (manager *SIdleResourceManager) DetectIdleResources(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	now := time.Now().UTC()
+	candidates := []sIdleCandidate{}
+	for _, resType := range api.IDLE_RESOURCE_TYPES {
+		ret, err := manager.detectIdle(resType, nil, now)
+		if err != nil {
+			log.Errorf("detect idle resources error: %v", err)
+			continue
+		}
+		candidates = append(candidates, ret...)
+	}
+	err := manager.syncIdleResources(ctx, userCred, candidates, now)
+	if err != nil {
+		log.Errorf("sync idle resources error: %v", err)
+	}
+}
+
+func (manager *SIdleResourceManager) syncIdleResources(ctx context.Context, userCred mcclient.TokenCredential, candidates []sIdleCandidate, now time.Time) error {
+	lockman.LockClass(ctx, manager, "")
+	defer lockman.ReleaseClass(ctx, manager, "")
+
+	existing := []SIdleResource{}
+	err := db.FetchModelObjects(manager, manager.Query(), &existing)
+	if err != nil {
+		return errors.Wrap(err, "fetch idle resources")
+	}
+	existMap := map[string]*SIdleResource{}
+	for i := range existing {
+		existMap[existing[i].ResourceType+"/"+existing[i].ResourceId] = &existing[i]
+	}
+	detected := map[string]bool{}
+	for _, c := range candidates {
+		key := c.ResourceType + "/" + c.ResourceId
+		detected[key] = true
+		if res, ok := existMap[key]; ok {
+			_, err := db.Update(res, func() error {
+				res.Name = c.Name
+				res.ManagerId = c.ManagerId
+				res.ProjectId = c.ProjectId
+				res.DomainId = c.DomainId
+				res.LastDetectedAt = now
+				return nil
+			})
+			if err != nil {
+				log.Errorf("update idle resource %s error: %v", key, err)
+			}
+			continue
+		}
+		res := &SIdleResource{
+			ResourceType:   c.ResourceType,
+			ResourceId:     c.ResourceId,
+			ExternalId:     c.ExternalId,
+			Reason:         c.Reason,
+			IdleSince:      c.IdleSince,
+			LastDetectedAt: now,
+		}
+		res.SetModelManager(manager, res)
+		res.Name = c.Name
+		res.ManagerId = c.ManagerId
+		res.ProjectId = c.ProjectId
+		res.DomainId = c.DomainId
+		res.Status = api.IDLE_RESOURCE_STATUS_DETECTED
+		err := manager.TableSpec().Insert(ctx, res)
+		if err != nil {
+			log.Errorf("insert idle resource %s error: %v", key, err)
+		}
+	}
+	// 不再闲置的资源移除记录, 清理中及已清理的保留
+	for key, res := range existMap {
+		if detected[key] || utils.IsInStringArray(res.Status, []string{api.IDLE_RESOURCE_STATUS_CLEANING, api.IDLE_RESOURCE_STATUS_CLEANED}) {
+			continue
+		}
+		err := res.Delete(ctx, userCred)
+		if err != nil {
+			log.Errorf("remove idle resource %s error: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func (res *SIdleResource) requestCleanup(ctx context.Context, userCred mcclient.TokenCredential, reason string) error {
+	if !utils.IsInStringArray(res.Status, []string{api.IDLE_RESOURCE_STATUS_DETECTED, api.IDLE_RESOURCE_STATUS_CLEANUP_FAILED}) {
+		return httperrors.NewInvalidStatusError("can not request cleanup in status %s", res.Status)
+	}
+	_, err := db.Update(res, func() error {
+		res.Status = api.IDLE_RESOURCE_STATUS_CLEANUP_PENDING
+		res.RequestedBy = userCred.GetUserName()
+		res.RequestedById = userCred.GetUserId()
+		res.RequestedAt = time.Now().UTC()
+		res.ApprovedBy = ""
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+	logclient.AddSimpleActionLog(res, logclient.ACT_IDLE_REQUEST_CLEANUP, reason, userCred, true)
+	return nil
+}
+
+// 申请清理闲置资源, 需要其他用户审批后执行
+func (res *SIdleResource) PerformRequestCleanup(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.IdleResourceRequestCleanupInput) (jsonutils.JSONObject, error) {
+	return nil, res.requestCleanup(ctx, userCred, input.Reason)
+}
+
+// 一键申请清理
+func (manager *SIdleResourceManager) PerformBatchRequestCleanup(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.IdleResourceBatchRequestCleanupInput) (jsonutils.JSONObject, error) {
+	q := manager.Query()
+	q, err := db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionPerform)
+	if err != nil {
+		return nil, err
+	}
+	q = q.In("status", []string{api.IDLE_RESOURCE_STATUS_DETECTED, api.IDLE_RESOURCE_STATUS_CLEANUP_FAILED})
+	if len(input.IdleResources) > 0 {
+		q = q.Filter(sqlchemy.OR(sqlchemy.In(q.Field("id"), input.IdleResources), sqlchemy.In(q.Field("name"), input.IdleResources)))
+	}
+	if len(input.ResourceType) > 0 {
+		q = q.In("resource_type", input.ResourceType)
+	}
+	resources := []SIdleResource{}
+	err = db.FetchModelObjects(manager, q, &resources)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	for i := range resources {
+		err := resources[i].requestCleanup(ctx, userCred, input.Reason)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ret := jsonutils.NewDict()
+	ret.Set("count", jsonutils.NewInt(int64(len(resources))))
+	return ret, nil
+}
+
+// 审批清理申请, 审批人不能是申请人, 且需要有删除该资源的权限
+func (res *SIdleResource) PerformApproveCleanup(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.IdleResourceApproveCleanupInput) (jsonutils.JSONObject, error) {
+	if res.Status != api.IDLE_RESOURCE_STATUS_CLEANUP_PENDING {
+		return nil, httperrors.NewInvalidStatusError("idle resource %s is not waiting for approval", res.Name)
+	}
+	if res.RequestedById == userCred.GetUserId() {
+		return nil, httperrors.NewForbiddenError("cleanup request can not be approved by the requester")
+	}
+	obj, err := res.getResource()
+	if err != nil {
+		if errors.Cause(err) != errors.ErrNotFound {
+			return nil, httperrors.NewGeneralError(err)
+		}
+	} else {
+		err = db.IsObjectRbacAllowed(ctx, obj, userCred, policy.PolicyActionDelete)
+		if err != nil {
+			return nil, err
+		}
+		// 申请后资源可能已被重新使用, 与定时检测一致移除记录
+		candidates, err := IdleResourceManager.detectIdle(res.ResourceType, []string{res.ResourceId}, time.Now().UTC())
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		if len(candidates) == 0 {
+			err = res.Delete(ctx, userCred)
+			if err != nil {
+				return nil, httperrors.NewGeneralError(err)
+			}
+			return nil, httperrors.NewConflictError("%s %s is no longer idle", res.ResourceType, res.Name)
+		}
+		err = db.ValidateDeleteCondition(obj, ctx, jsonutils.NewDict())
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = db.Update(res, func() error {
+		res.ApprovedBy = userCred.GetUserName()
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, res.StartCleanupTask(ctx, userCred, "")
+}
+
+func (res *SIdleResource) PerformRejectCleanup(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.IdleResourceRejectCleanupInput) (jsonutils.JSONObject, error) {
+	if res.Status != api.IDLE_RESOURCE_STATUS_CLEANUP_PENDING {
+		return nil, httperrors.NewInvalidStatusError("idle resource %s is not waiting for approval", res.Name)
+	}
+	_, err := db.Update(res, func() error {
+		res.Status = api.IDLE_RESOURCE_STATUS_DETECTED
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddSimpleActionLog(res, logclient.ACT_IDLE_REJECT_CLEANUP, input.Reason, userCred, true)
+	return nil, nil
+}
+
+// 忽略闲置资源, 资源持续闲置期间不再提示
+func (res *SIdleResource) PerformIgnore(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.IdleResourceIgnoreInput) (jsonutils.JSONObject, error) {
+	if utils.IsInStringArray(res.Status, []string{api.IDLE_RESOURCE_STATUS_CLEANING, api.IDLE_RESOURCE_STATUS_CLEANED}) {
+		return nil, httperrors.NewInvalidStatusError("can not ignore idle resource in status %s", res.Status)
+	}
+	return nil, res.SetStatus(userCred, api.IDLE_RESOURCE_STATUS_IGNORED, "ignore")
+}
+
+func (res *SIdleResource) getResource() (db.IModel, error) {
+	man := IdleResourceManager.getResourceManager(res.ResourceType)
+	if man == nil {
+		return nil, errors.Wrapf(errors.ErrNotSupported, "resource type %s", res.ResourceType)
+	}
+	obj, err := man.FetchById(res.ResourceId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, errors.Wrapf(errors.ErrNotFound, "%s %s", res.ResourceType, res.ResourceId)
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// 按资源类型调用对应的删除任务, 删除完成后回调 parentTaskId
+func (res *SIdleResource) StartResourceDeleteTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	obj, err := res.getResource()
+	if err != nil {
+		return err
+	}
+	switch r := obj.(type) {
+	case *SElasticip:
+		return r.StartEipDeallocateTask(ctx, userCred, parentTaskId)
+	case *SDisk:
+		return r.StartDiskDeleteTask(ctx, userCred, parentTaskId, false, false, false)
+	case *SLoadbalancer:
+		return r.StartLoadBalancerDeleteTask(ctx, userCred, jsonutils.NewDict(), parentTaskId)
+	case *SGuest:
+		return r.StartDeleteGuestTask(ctx, userCred, parentTaskId, api.ServerDeleteInput{})
+	}
+	return errors.Wrapf(errors.ErrNotSupported, "resource type %s", res.ResourceType)
+}
+
+func (res *SIdleResource) StartCleanupTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "IdleResourceCleanupTask", res, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask")
+	}
+	res.SetStatus(userCred, api.IDLE_RESOURCE_STATUS_CLEANING, "")
+	return task.ScheduleRun(nil)
+}
+
+// 闲置资源浪费报告
+func (manager *SIdleResourceManager) GetPropertyWaste(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.IdleResourceWasteOutput, error) {
+	q := manager.Query()
+	q, err := db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	q = q.In("status", []string{api.IDLE_RESOURCE_STATUS_DETECTED, api.IDLE_RESOURCE_STATUS_CLEANUP_PENDING, api.IDLE_RESOURCE_STATUS_CLEANUP_FAILED})
+	resources := []SIdleResource{}
+	err = db.FetchModelObjects(manager, q, &resources)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	costs, err := manager.getLastMonthCosts(resources)
+	if err != nil {
+		return nil, errors.Wrap(err, "getLastMonthCosts")
+	}
+	ret := buildIdleResourceWaste(resources, costs)
+	for i := range ret.Projects {
+		if tenant, err := db.TenantCacheManager.FetchTenantById(ctx, ret.Projects[i].ProjectId); err == nil {
+			ret.Projects[i].Project = tenant.Name
+		}
+	}
+	return ret, nil
+}
+
+func addIdleCost(dst map[string]float64, src map[string]float64) {
+	for currency, amount := range src {
+		dst[currency] += amount
+	}
+}
+
+func buildIdleResourceWaste(resources []SIdleResource, costs map[string]map[string]float64) *api.IdleResourceWasteOutput {
+	ret := &api.IdleResourceWasteOutput{
+		Items:         []api.IdleResourceWasteItem{},
+		Projects:      []api.IdleResourceWasteProject{},
+		LastMonthCost: map[string]float64{},
+	}
+	items := map[string]*api.IdleResourceWasteItem{}
+	projects := map[string]*api.IdleResourceWasteProject{}
+	for i := range resources {
+		res := resources[i]
+		ret.Total += 1
+		if res.LastDetectedAt.After(ret.DetectedAt) {
+			ret.DetectedAt = res.LastDetectedAt
+		}
+		key := res.ResourceType + "/" + res.Reason
+		item, ok := items[key]
+		if !ok {
+			item = &api.IdleResourceWasteItem{ResourceType: res.ResourceType, Reason: res.Reason, LastMonthCost: map[string]float64{}}
+			items[key] = item
+		}
+		item.Count += 1
+		project, ok := projects[res.ProjectId]
+		if !ok {
+			project = &api.IdleResourceWasteProject{ProjectId: res.ProjectId, LastMonthCost: map[string]float64{}}
+			projects[res.ProjectId] = project
+		}
+		project.Count += 1
+		if cost, ok := costs[res.ExternalId]; ok && len(res.ExternalId) > 0 {
+			addIdleCost(item.LastMonthCost, cost)
+			addIdleCost(project.LastMonthCost, cost)
+			addIdleCost(ret.LastMonthCost, cost)
+		}
+	}
+	for _, item := range items {
+		ret.Items = append(ret.Items, *item)
+	}
+	sort.Slice(ret.Items, func(i, j int) bool {
+		if ret.Items[i].ResourceType != ret.Items[j].ResourceType {
+			return ret.Items[i].ResourceType < ret.Items[j].ResourceType
+		}
+		return ret.Items[i].Reason < ret.Items[j].Reason
+	})
+	for _, project := range projects {
+		ret.Projects = append(ret.Projects, *project)
+	}
+	sort.Slice(ret.Projects, func(i, j int) bool {
+		return ret.Projects[i].Count > ret.Projects[j].Count
+	})
+	return ret
+}
+
+// 按云上资源ID汇总上月账单费用
+func (manager *SIdleResourceManager) getLastMonthCosts(resources []SIdleResource) (map[string]map[string]float64, error) {
+	externalIds := []string{}
+	for i := range resources {
+		if len(resources[i].ExternalId) > 0 {
+			externalIds = append(externalIds, resources[i].ExternalId)
+		}
+	}
+	ret := map[string]map[string]float64{}
+	if len(externalIds) == 0 {
+		return ret, nil
+	}
+	now := time.Now().UTC()
+	lastMonth := now.AddDate(0, 0, -now.Day()).Format(api.CLOUD_BILL_MONTH_FORMAT)
+	sq := CloudBillItemManager.Query().Equals("billing_month", lastMonth).In("resource_id", externalIds).SubQuery()
+	q := sq.Query(sq.Field("resource_id"), sq.Field("currency"), sqlchemy.SUM("amount", sq.Field("amount"))).GroupBy(sq.Field("resource_id"), sq.Field("currency"))
+	rows := []struct {
+		ResourceId string
+		Currency   string
+		Amount     float64
+	}{}
+	err := q.All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "query bill items")
+	}
+	for _, row := range rows {
+		if _, ok := ret[row.ResourceId]; !ok {
+			ret[row.ResourceId] = map[string]float64{}
+		}
+		ret[row.ResourceId][row.Currency] += row.Amount
+	}
+	return ret, nil
+}
+
+func (res *SIdleResource) GetShortDesc(ctx context.Context) *jsonutils.JSONDict {
+	desc := res.SStatusStandaloneResourceBase.GetShortDesc(ctx)
+	desc.Set("resource_type", jsonutils.NewString(res.ResourceType))
+	desc.Set("resource_id", jsonutils.NewString(res.ResourceId))
+	desc.Set("reason", jsonutils.NewString(res.Reason))
+	return desc
+}
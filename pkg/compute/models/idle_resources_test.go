@@ -0,0 +1,51 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestBuildIdleResourceWaste(t *testing.T) {
+	newRes := func(resType, reason, projectId, externalId string) SIdleResource {
+		res := SIdleResource{ResourceType: resType, Reason: reason, ExternalId: externalId}
+		res.ProjectId = projectId
+		return res
+	}
+	resources := []SIdleResource{
+		newRes(api.IDLE_RESOURCE_TYPE_EIP, api.IDLE_REASON_UNASSOCIATED, "p1", "eip-1"),
+		newRes(api.IDLE_RESOURCE_TYPE_EIP, api.IDLE_REASON_UNASSOCIATED, "p2", "eip-2"),
+		newRes(api.IDLE_RESOURCE_TYPE_DISK, api.IDLE_REASON_UNATTACHED, "p1", ""),
+	}
+	costs := map[string]map[string]float64{
+		"eip-1": {"CNY": 10},
+		"eip-2": {"CNY": 5, "USD": 1},
+	}
+	ret := buildIdleResourceWaste(resources, costs)
+	if ret.Total != 3 {
+		t.Errorf("want total 3, got %d", ret.Total)
+	}
+	if len(ret.Items) != 2 || ret.Items[0].ResourceType != api.IDLE_RESOURCE_TYPE_DISK || ret.Items[1].Count != 2 {
+		t.Errorf("unexpected items %+v", ret.Items)
+	}
+	if ret.LastMonthCost["CNY"] != 15 || ret.LastMonthCost["USD"] != 1 {
+		t.Errorf("unexpected cost %v", ret.LastMonthCost)
+	}
+	if len(ret.Projects) != 2 || ret.Projects[0].ProjectId != "p1" || ret.Projects[0].LastMonthCost["CNY"] != 10 {
+		t.Errorf("unexpected projects %+v", ret.Projects)
+	}
+}
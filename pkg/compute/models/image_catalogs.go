@@ -0,0 +1,422 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-model-singular=image_catalog
+// +onecloud:swagger-gen-model-plural=image_catalogs
+type SImageCatalogManager struct {
+	db.SStatusInfrasResourceBaseManager
+}
+
+var ImageCatalogManager *SImageCatalogManager
+
+func init() {
+	ImageCatalogManager = &SImageCatalogManager{
+		SStatusInfrasResourceBaseManager: db.NewStatusInfrasResourceBaseManager(
+			SImageCatalog{},
+			"image_catalogs_tbl",
+			"image_catalog",
+			"image_catalogs",
+		),
+	}
+	ImageCatalogManager.SetVirtualObject(ImageCatalogManager)
+}
+
+// 镜像目录, 管理员将审核过的本地镜像及各平台镜像发布到指定域, 租户创建主机时从目录中选择
+type SImageCatalog struct {
+	db.SStatusInfrasResourceBase
+
+	// 本地镜像ID
+	ImageId string `width:"128" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"domain"`
+
+	OsType         string `width:"32" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"domain"`
+	OsDistribution string `width:"64" charset:"utf8" nullable:"true" list:"user" create:"optional" update:"domain"`
+	OsVersion      string `width:"64" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"domain"`
+	OsArch         string `width:"16" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"domain"`
+
+	// 各平台镜像映射
+	Mappings *api.SImageCatalogMappings `length:"text" list:"user" create:"optional" update:"domain"`
+}
+
+func (manager *SImageCatalogManager) validateMappings(userCred mcclient.TokenCredential, mappings []api.SImageCatalogMapping) ([]api.SImageCatalogMapping, error) {
+	ret := []api.SImageCatalogMapping{}
+	keys := map[string]bool{}
+	for i := range mappings {
+		mapping := mappings[i]
+		if !utils.IsInStringArray(mapping.Provider, api.CLOUD_PROVIDERS) {
+			return nil, httperrors.NewInputParameterError("mapping %d: invalid provider %s", i, mapping.Provider)
+		}
+		if len(mapping.CloudregionId) > 0 {
+			regionObj, err := validators.ValidateModel(userCred, CloudregionManager, &mapping.CloudregionId)
+			if err != nil {
+				return nil, err
+			}
+			if region := regionObj.(*SCloudregion); region.Provider != mapping.Provider {
+				return nil, httperrors.NewInputParameterError("mapping %d: region %s does not belong to %s", i, region.Name, mapping.Provider)
+			}
+		}
+		if len(mapping.ImageId) == 0 {
+			return nil, httperrors.NewInputParameterError("mapping %d: missing image_id", i)
+		}
+		_, err := validators.ValidateModel(userCred, CachedimageManager, &mapping.ImageId)
+		if err != nil {
+			return nil, err
+		}
+		key := mapping.Provider + "/" + mapping.CloudregionId
+		if keys[key] {
+			return nil, httperrors.NewDuplicateResourceError("duplicate mapping %s", key)
+		}
+		keys[key] = true
+		ret = append(ret, mapping)
+	}
+	return ret, nil
+}
+
+func (manager *SImageCatalogManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ImageCatalogCreateInput) (api.ImageCatalogCreateInput, error) {
+	var err error
+	if len(input.ImageId) == 0 && len(input.Mappings) == 0 {
+		return input, httperrors.NewMissingParameterError("image_id or mappings")
+	}
+	if len(input.ImageId) > 0 {
+		image, err := CachedimageManager.getImageInfo(ctx, userCred, input.ImageId, false)
+		if err != nil {
+			return input, httperrors.NewImageNotFoundError(input.ImageId)
+		}
+		input.ImageId = image.Id
+		if len(input.OsType) == 0 {
+			input.OsType = image.Properties["os_type"]
+		}
+		if len(input.OsDistribution) == 0 {
+			input.OsDistribution = image.Properties["os_distribution"]
+		}
+		if len(input.OsVersion) == 0 {
+			input.OsVersion = image.Properties["os_version"]
+		}
+		if len(input.OsArch) == 0 {
+			input.OsArch = image.Properties["os_arch"]
+		}
+	}
+	input.Mappings, err = manager.validateMappings(userCred, input.Mappings)
+	if err != nil {
+		return input, err
+	}
+	input.StatusInfrasResourceBaseCreateInput, err = manager.SStatusInfrasResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StatusInfrasResourceBaseCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusInfrasResourceBaseManager.ValidateCreateData")
+	}
+	// 新建的镜像目录需发布后租户才可见
+	input.Status = api.IMAGE_CATALOG_STATUS_UNPUBLISHED
+	return input, nil
+}
+
+func (self *SImageCatalog) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ImageCatalogUpdateInput) (api.ImageCatalogUpdateInput, error) {
+	var err error
+	if input.ImageId != nil && len(*input.ImageId) > 0 {
+		image, err := CachedimageManager.getImageInfo(ctx, userCred, *input.ImageId, false)
+		if err != nil {
+			return input, httperrors.NewImageNotFoundError(*input.ImageId)
+		}
+		input.ImageId = &image.Id
+	}
+	if input.Mappings != nil {
+		input.Mappings, err = ImageCatalogManager.validateMappings(userCred, input.Mappings)
+		if err != nil {
+			return input, err
+		}
+	}
+	input.StatusInfrasResourceBaseUpdateInput, err = self.SStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.StatusInfrasResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusInfrasResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+func (self *SImageCatalog) getMappings() api.SImageCatalogMappings {
+	if self.Mappings == nil {
+		return api.SImageCatalogMappings{}
+	}
+	return *self.Mappings
+}
+
+// 按平台和区域选择镜像: 优先匹配区域, 其次平台通用映射, 本地IDC使用本地镜像
+func resolveImageCatalogImage(localImageId string, mappings api.SImageCatalogMappings, provider, regionId string) string {
+	general := ""
+	for _, mapping := range mappings {
+		if mapping.Provider != provider {
+			continue
+		}
+		if len(regionId) > 0 && mapping.CloudregionId == regionId {
+			return mapping.ImageId
+		}
+		if len(mapping.CloudregionId) == 0 {
+			general = mapping.ImageId
+		}
+	}
+	if len(general) > 0 {
+		return general
+	}
+	if provider == api.CLOUD_PROVIDER_ONECLOUD || len(provider) == 0 {
+		return localImageId
+	}
+	return ""
+}
+
+func (self *SImageCatalog) ResolveImageId(provider, regionId string) string {
+	return resolveImageCatalogImage(self.ImageId, self.getMappings(), provider, regionId)
+}
+
+func (self *SImageCatalog) containsImage(imageId string) bool {
+	if self.ImageId == imageId {
+		return true
+	}
+	for _, mapping := range self.getMappings() {
+		if mapping.ImageId == imageId {
+			return true
+		}
+	}
+	return false
+}
+
+// 发布镜像目录, 可同时指定共享的域
+func (self *SImageCatalog) PerformPublish(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ImageCatalogPublishInput) (jsonutils.JSONObject, error) {
+	if len(input.Scope) > 0 || len(input.SharedDomainIds) > 0 {
+		_, err := self.SStatusInfrasResourceBase.PerformPublic(ctx, userCred, query, input.PerformPublicDomainInput)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := self.SetStatus(userCred, api.IMAGE_CATALOG_STATUS_PUBLISHED, "")
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_PUBLIC, input, userCred, true)
+	return nil, nil
+}
+
+// 下架镜像目录, 已创建的主机不受影响
+func (self *SImageCatalog) PerformUnpublish(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ImageCatalogUnpublishInput) (jsonutils.JSONObject, error) {
+	err := self.SetStatus(userCred, api.IMAGE_CATALOG_STATUS_UNPUBLISHED, "")
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_PRIVATE, input, userCred, true)
+	return nil, nil
+}
+
+// 能管理镜像目录的用户不受目录限制
+func (manager *SImageCatalogManager) isCatalogAdmin(userCred mcclient.TokenCredential) bool {
+	return db.IsDomainAllowCreate(userCred, manager).Result.IsAllow()
+}
+
+// 镜像目录列表
+func (manager *SImageCatalogManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.ImageCatalogListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusInfrasResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusInfrasResourceBaseManager.ListItemFilter")
+	}
+	if !manager.isCatalogAdmin(userCred) {
+		q = q.Equals("status", api.IMAGE_CATALOG_STATUS_PUBLISHED)
+	}
+	if len(input.OsType) > 0 {
+		q = q.In("os_type", input.OsType)
+	}
+	if len(input.Provider) > 0 {
+		conds := []sqlchemy.ICondition{
+			sqlchemy.Contains(q.Field("mappings"), `"provider":"`+input.Provider+`"`),
+		}
+		if input.Provider == api.CLOUD_PROVIDER_ONECLOUD {
+			conds = append(conds, sqlchemy.IsNotEmpty(q.Field("image_id")))
+		}
+		q = q.Filter(sqlchemy.OR(conds...))
+	}
+	return q, nil
+}
+
+func (manager *SImageCatalogManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.ImageCatalogListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusInfrasResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.StatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusInfrasResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SImageCatalogManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusInfrasResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SImageCatalogManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ImageCatalogDetails {
+	rows := make([]api.ImageCatalogDetails, len(objs))
+	stdRows := manager.SStatusInfrasResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	imageIds, regionIds := []string{}, []string{}
+	for i := range rows {
+		rows[i] = api.ImageCatalogDetails{
+			StatusInfrasResourceBaseDetails: stdRows[i],
+		}
+		catalog := objs[i].(*SImageCatalog)
+		imageIds = append(imageIds, catalog.ImageId)
+		for _, mapping := range catalog.getMappings() {
+			imageIds = append(imageIds, mapping.ImageId)
+			regionIds = append(regionIds, mapping.CloudregionId)
+		}
+	}
+	imageNames, err := db.FetchIdNameMap2(CachedimageManager, imageIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 cachedimages error: %v", err)
+		return rows
+	}
+	regionNames, err := db.FetchIdNameMap2(CloudregionManager, regionIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 cloudregions error: %v", err)
+		return rows
+	}
+	for i := range rows {
+		catalog := objs[i].(*SImageCatalog)
+		rows[i].Image = imageNames[catalog.ImageId]
+		for _, mapping := range catalog.getMappings() {
+			rows[i].MappingDetails = append(rows[i].MappingDetails, api.ImageCatalogMappingDetails{
+				SImageCatalogMapping: mapping,
+				Cloudregion:          regionNames[mapping.CloudregionId],
+				Image:                imageNames[mapping.ImageId],
+			})
+		}
+	}
+	return rows
+}
+
+// 此时创建参数中的区域及可用区尚未解析, 按名称或ID查找目标区域
+func (manager *SImageCatalogManager) getServerCreateRegion(userCred mcclient.TokenCredential, input *api.ServerCreateInput) (*SCloudregion, error) {
+	if len(input.PreferRegion) > 0 {
+		regionObj, err := validators.ValidateModel(userCred, CloudregionManager, &input.PreferRegion)
+		if err != nil {
+			return nil, err
+		}
+		return regionObj.(*SCloudregion), nil
+	}
+	if len(input.PreferZone) > 0 {
+		zoneObj, err := validators.ValidateModel(userCred, ZoneManager, &input.PreferZone)
+		if err != nil {
+			return nil, err
+		}
+		region, err := zoneObj.(*SZone).GetRegion()
+		if err != nil {
+			return nil, errors.Wrap(err, "GetRegion")
+		}
+		return region, nil
+	}
+	return nil, nil
+}
+
+// 使用镜像目录时, 按目标平台和区域替换系统盘镜像
+func (manager *SImageCatalogManager) applyToServerCreateInput(userCred mcclient.TokenCredential, input *api.ServerCreateInput) error {
+	if len(input.ImageCatalogId) == 0 {
+		return nil
+	}
+	catalogObj, err := validators.ValidateModel(userCred, manager, &input.ImageCatalogId)
+	if err != nil {
+		return err
+	}
+	catalog := catalogObj.(*SImageCatalog)
+	if catalog.Status != api.IMAGE_CATALOG_STATUS_PUBLISHED {
+		return httperrors.NewInvalidStatusError("image catalog %s is not published", catalog.Name)
+	}
+	region, err := manager.getServerCreateRegion(userCred, input)
+	if err != nil {
+		return err
+	}
+	provider, regionId := "", ""
+	if region != nil {
+		provider, regionId = region.Provider, region.Id
+	}
+	if len(input.Hypervisor) > 0 {
+		provider = GetDriver(input.Hypervisor).GetProvider()
+	}
+	imageId := catalog.ResolveImageId(provider, regionId)
+	if len(imageId) == 0 {
+		return httperrors.NewNotSupportedError("image catalog %s has no image for %s", catalog.Name, provider)
+	}
+	if len(input.Disks) == 0 {
+		input.Disks = append(input.Disks, &api.DiskConfig{})
+	}
+	input.Disks[0].ImageId = imageId
+	return nil
+}
+
+// 启用镜像目录后, 普通用户只能使用已发布且对其可见的镜像目录中的镜像
+func (manager *SImageCatalogManager) checkServerImage(ctx context.Context, userCred mcclient.TokenCredential, imageId string) error {
+	if !options.Options.EnableImageCatalog || len(imageId) == 0 || manager.isCatalogAdmin(userCred) {
+		return nil
+	}
+	q := manager.Query()
+	q, err := db.ListItemQueryFilters(manager, ctx, q, userCred, jsonutils.NewDict(), policy.PolicyActionList)
+	if err != nil {
+		return errors.Wrap(err, "ListItemQueryFilters")
+	}
+	catalogs := []SImageCatalog{}
+	err = db.FetchModelObjects(manager, q.Equals("status", api.IMAGE_CATALOG_STATUS_PUBLISHED), &catalogs)
+	if err != nil {
+		return errors.Wrap(err, "fetch image catalogs")
+	}
+	for i := range catalogs {
+		if catalogs[i].containsImage(imageId) {
+			return nil
+		}
+	}
+	return httperrors.NewForbiddenError("image %s is not published in image catalog", imageId)
+}
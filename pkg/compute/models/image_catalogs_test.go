@@ -0,0 +1,47 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestResolveImageCatalogImage(t *testing.T) {
+	mappings := api.SImageCatalogMappings{
+		{Provider: api.CLOUD_PROVIDER_ALIYUN, ImageId: "ali-general"},
+		{Provider: api.CLOUD_PROVIDER_ALIYUN, CloudregionId: "region-bj", ImageId: "ali-bj"},
+		{Provider: api.CLOUD_PROVIDER_AWS, CloudregionId: "region-us", ImageId: "aws-us"},
+	}
+	cases := []struct {
+		name     string
+		provider string
+		regionId string
+		want     string
+	}{
+		{"region match", api.CLOUD_PROVIDER_ALIYUN, "region-bj", "ali-bj"},
+		{"provider general", api.CLOUD_PROVIDER_ALIYUN, "region-sh", "ali-general"},
+		{"no region general", api.CLOUD_PROVIDER_AWS, "region-eu", ""},
+		{"local image", api.CLOUD_PROVIDER_ONECLOUD, "", "local"},
+		{"empty provider", "", "", "local"},
+		{"no mapping", api.CLOUD_PROVIDER_HUAWEI, "", ""},
+	}
+	for _, c := range cases {
+		if got := resolveImageCatalogImage("local", mappings, c.provider, c.regionId); got != c.want {
+			t.Errorf("%s: want %q got %q", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	imageapi "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/image"
+)
+
+type sImageSubformat struct {
+	Format   string
+	Status   string
+	Checksum string
+	Progress float32
+}
+
+// GetRequiredImageFormat 返回上传到指定云平台前需要转换的镜像格式, 为空表示无需转换
+func GetRequiredImageFormat(provider string, format string) string {
+	formats, ok := api.CLOUD_PROVIDER_IMAGE_FORMATS[provider]
+	if !ok || len(formats) == 0 || utils.IsInStringArray(format, formats) {
+		return ""
+	}
+	return formats[0]
+}
+
+// GetCacheImageConvertFormat 返回缓存镜像到宿主机前需要转换的格式, 为空表示无需转换
+// ESXi宿主机从镜像服务下载自定义镜像时需要vmdk格式, 托管宿主机在上传镜像时自行转换
+func (host *SHost) GetCacheImageConvertFormat(imageId string) (string, error) {
+	if host.HostType != api.HOST_TYPE_ESXI {
+		return "", nil
+	}
+	obj, err := CachedimageManager.FetchById(imageId)
+	if err != nil {
+		return "", errors.Wrapf(err, "CachedimageManager.FetchById(%s)", imageId)
+	}
+	cacheImage := obj.(*SCachedimage)
+	if cloudprovider.TImageType(cacheImage.ImageType) != cloudprovider.ImageTypeCustomized {
+		return "", nil
+	}
+	return GetRequiredImageFormat(api.CLOUD_PROVIDER_VMWARE, cacheImage.GetFormat()), nil
+}
+
+func fetchImageSubformat(s *mcclient.ClientSession, imageId string, format string) (*sImageSubformat, error) {
+	result, err := image.Images.GetSpecific(s, imageId, "subformats", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "get subformats")
+	}
+	subformats := []sImageSubformat{}
+	err = result.Unmarshal(&subformats)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unmarshal subformats")
+	}
+	for i := range subformats {
+		if subformats[i].Format == format {
+			return &subformats[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// startImageConvert 请求镜像服务转换镜像格式, 不等待转换完成
+func startImageConvert(s *mcclient.ClientSession, imageId string, format string) error {
+	sub, err := fetchImageSubformat(s, imageId, format)
+	if err != nil {
+		return err
+	}
+	if sub != nil && utils.IsInStringArray(sub.Status, []string{imageapi.IMAGE_STATUS_ACTIVE, imageapi.IMAGE_STATUS_SAVING}) {
+		return nil
+	}
+	params := jsonutils.NewDict()
+	params.Set("format", jsonutils.NewString(format))
+	_, err = image.Images.PerformAction(s, imageId, "convert", params)
+	if err != nil {
+		return errors.Wrapf(err, "convert image %s to %s", imageId, format)
+	}
+	return nil
+}
+
+// EnsureImageFormat 确保镜像存在指定格式, 必要时触发转换并等待完成, 返回转换后镜像的md5
+func EnsureImageFormat(ctx context.Context, s *mcclient.ClientSession, imageId string, format string, callback func(progress float32)) (string, error) {
+	converting := false
+	timeout := time.Now().Add(time.Duration(api.IMAGE_CONVERT_TIMEOUT_SECONDS) * time.Second)
+	for time.Now().Before(timeout) {
+		sub, err := fetchImageSubformat(s, imageId, format)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case sub != nil && sub.Status == imageapi.IMAGE_STATUS_ACTIVE:
+			if callback != nil {
+				callback(100)
+			}
+			return sub.Checksum, nil
+		case sub != nil && sub.Status == imageapi.IMAGE_STATUS_SAVE_FAIL && converting:
+			return "", errors.Errorf("convert image %s to %s failed", imageId, format)
+		case sub != nil && sub.Status == imageapi.IMAGE_STATUS_SAVING:
+			converting = true
+			if callback != nil {
+				callback(sub.Progress)
+			}
+		case !converting:
+			err = startImageConvert(s, imageId, format)
+			if err != nil {
+				return "", err
+			}
+			converting = true
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Duration(api.IMAGE_CONVERT_REFRESH_SECONDS) * time.Second):
+		}
+	}
+	return "", errors.Wrapf(errors.ErrTimeout, "wait image %s convert to %s", imageId, format)
+}
+
+type sChecksumReader struct {
+	reader   io.Reader
+	checksum string
+	hash     hash.Hash
+}
+
+// NewChecksumReader 读取时计算md5, 读取结束时与期望值不一致则返回错误
+func NewChecksumReader(reader io.Reader, checksum string) io.Reader {
+	if len(checksum) == 0 {
+		return reader
+	}
+	return &sChecksumReader{
+		reader:   reader,
+		checksum: checksum,
+		hash:     md5.New(),
+	}
+}
+
+func (r *sChecksumReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		sum := hex.EncodeToString(r.hash.Sum(nil))
+		if sum != r.checksum {
+			log.Errorf("image checksum mismatch, expect %s got %s", r.checksum, sum)
+			return n, fmt.Errorf("image checksum mismatch, expect %s got %s", r.checksum, sum)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestGetRequiredImageFormat(t *testing.T) {
+	cases := []struct {
+		provider string
+		format   string
+		want     string
+	}{
+		{provider: api.CLOUD_PROVIDER_VMWARE, format: "qcow2", want: "vmdk"},
+		{provider: api.CLOUD_PROVIDER_VMWARE, format: "vmdk", want: ""},
+		{provider: api.CLOUD_PROVIDER_AZURE, format: "raw", want: "vhd"},
+		{provider: api.CLOUD_PROVIDER_PROXMOX, format: "raw", want: ""},
+		{provider: api.CLOUD_PROVIDER_PROXMOX, format: "vmdk", want: "qcow2"},
+		{provider: api.CLOUD_PROVIDER_ALIYUN, format: "qcow2", want: ""},
+	}
+	for _, c := range cases {
+		if got := GetRequiredImageFormat(c.provider, c.format); got != c.want {
+			t.Errorf("%s %s: want %q, got %q", c.provider, c.format, c.want, got)
+		}
+	}
+}
+
+func TestChecksumReader(t *testing.T) {
+	// md5("hello")
+	_, err := ioutil.ReadAll(NewChecksumReader(strings.NewReader("hello"), "5d41402abc4b2a76b9719d911017c592"))
+	if err != nil {
+		t.Fatalf("expect checksum match, got %v", err)
+	}
+	_, err = ioutil.ReadAll(NewChecksumReader(strings.NewReader("hello!"), "5d41402abc4b2a76b9719d911017c592"))
+	if err == nil {
+		t.Fatalf("expect checksum mismatch")
+	}
+}
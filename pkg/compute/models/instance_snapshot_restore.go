@@ -0,0 +1,321 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	schedapi "yunion.io/x/onecloud/pkg/apis/scheduler"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+func (self *SInstanceSnapshot) GetServerConfig() (*schedapi.ServerConfig, error) {
+	serverConfig := new(schedapi.ServerConfig)
+	if err := self.ServerConfig.Unmarshal(serverConfig); err != nil {
+		return nil, errors.Wrap(err, "unmarshal sched input")
+	}
+	return serverConfig, nil
+}
+
+// getSourceZoneId 主机快照所在可用区, 优先取磁盘快照所在存储的可用区
+func (self *SInstanceSnapshot) getSourceZoneId() string {
+	snapshots, _ := self.GetSnapshots()
+	for i := range snapshots {
+		if storage := snapshots[i].GetStorage(); storage != nil {
+			return storage.ZoneId
+		}
+	}
+	if guest, _ := self.GetGuest(); guest != nil {
+		if host, _ := guest.GetHost(); host != nil {
+			return host.ZoneId
+		}
+	}
+	return ""
+}
+
+// validateCreateServerTarget 校验恢复目标, 返回是否需要跨可用区/平台恢复以及目标平台
+func (self *SInstanceSnapshot) validateCreateServerTarget(
+	userCred mcclient.TokenCredential, input *api.InstanceSnapshotCreateServerInput, hypervisor string,
+) (bool, string, error) {
+	cross := false
+	provider := self.GetProviderName()
+	if len(input.PreferRegion) > 0 {
+		regionObj, err := CloudregionManager.FetchByIdOrName(userCred, input.PreferRegion)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return false, "", httperrors.NewResourceNotFoundError2(CloudregionManager.Keyword(), input.PreferRegion)
+			}
+			return false, "", httperrors.NewGeneralError(err)
+		}
+		region := regionObj.(*SCloudregion)
+		input.PreferRegion = region.Id
+		provider = region.Provider
+		cross = cross || region.Id != self.CloudregionId
+	}
+	if len(input.PreferZone) > 0 {
+		zoneObj, err := ZoneManager.FetchByIdOrName(userCred, input.PreferZone)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return false, "", httperrors.NewResourceNotFoundError2(ZoneManager.Keyword(), input.PreferZone)
+			}
+			return false, "", httperrors.NewGeneralError(err)
+		}
+		zone := zoneObj.(*SZone)
+		if len(input.PreferRegion) > 0 && zone.CloudregionId != input.PreferRegion {
+			return false, "", httperrors.NewInputParameterError("zone %s not in region %s", zone.Name, input.PreferRegion)
+		}
+		region, err := zone.GetRegion()
+		if err != nil {
+			return false, "", httperrors.NewGeneralError(err)
+		}
+		input.PreferZone = zone.Id
+		provider = region.Provider
+		cross = cross || zone.Id != self.getSourceZoneId()
+	}
+	if len(input.PreferManager) > 0 {
+		managerObj, err := CloudproviderManager.FetchByIdOrName(userCred, input.PreferManager)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return false, "", httperrors.NewResourceNotFoundError2(CloudproviderManager.Keyword(), input.PreferManager)
+			}
+			return false, "", httperrors.NewGeneralError(err)
+		}
+		manager := managerObj.(*SCloudprovider)
+		input.PreferManager = manager.Id
+		provider = manager.Provider
+		cross = cross || manager.Id != self.ManagerId
+	}
+	if len(input.Hypervisor) > 0 {
+		if !utils.IsInStringArray(input.Hypervisor, api.HYPERVISORS) {
+			return false, "", httperrors.NewInputParameterError("invalid hypervisor %s", input.Hypervisor)
+		}
+		provider = GetDriver(input.Hypervisor).GetProvider()
+		cross = cross || input.Hypervisor != hypervisor
+	}
+	return cross, provider, nil
+}
+
+// remapInstanceSnapshotNetworks 按网卡序号将主机快照中的网络替换为目标网络,
+// 跨可用区恢复时源网络不可用, 未映射的网卡需显式丢弃
+func remapInstanceSnapshotNetworks(
+	nets []*api.NetworkConfig, mappings []api.InstanceSnapshotNicMapping, cross bool, dropUnmapped bool,
+) ([]*api.NetworkConfig, error) {
+	mappingByIndex := map[int]api.InstanceSnapshotNicMapping{}
+	for _, mapping := range mappings {
+		if _, ok := mappingByIndex[mapping.Index]; ok {
+			return nil, httperrors.NewDuplicateIdError("nic_mappings", fmt.Sprintf("%d", mapping.Index))
+		}
+		if len(mapping.Network) == 0 {
+			return nil, httperrors.NewMissingParameterError(fmt.Sprintf("nic_mappings.%d.network", mapping.Index))
+		}
+		mappingByIndex[mapping.Index] = mapping
+	}
+	ret := make([]*api.NetworkConfig, 0, len(nets))
+	for i := range nets {
+		net := *nets[i]
+		if mapping, ok := mappingByIndex[net.Index]; ok {
+			delete(mappingByIndex, net.Index)
+			net.Network = mapping.Network
+			net.Address = mapping.Address
+			net.Address6 = ""
+			net.Wire = ""
+		} else if dropUnmapped {
+			continue
+		} else if cross {
+			return nil, httperrors.NewInputParameterError("nic %d must be remapped to a network of the target zone or dropped", net.Index)
+		}
+		net.Index = len(ret)
+		ret = append(ret, &net)
+	}
+	for index := range mappingByIndex {
+		return nil, httperrors.NewInputParameterError("nic %d not found in instance snapshot", index)
+	}
+	if len(ret) == 0 && len(nets) > 0 {
+		return nil, httperrors.NewInputParameterError("at least one nic should be kept")
+	}
+	return ret, nil
+}
+
+// PerformCreateServer 从主机快照创建新主机, 目标可用区或平台与源主机不同时先将磁盘快照导出为镜像
+func (self *SInstanceSnapshot) PerformCreateServer(
+	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.InstanceSnapshotCreateServerInput,
+) (jsonutils.JSONObject, error) {
+	if self.Status != api.INSTANCE_SNAPSHOT_READY {
+		return nil, httperrors.NewInvalidStatusError("can't create server from instance snapshot in status %s", self.Status)
+	}
+	if len(input.Name) == 0 && len(input.GenerateName) == 0 {
+		return nil, httperrors.NewMissingParameterError("name")
+	}
+	serverConfig, err := self.GetServerConfig()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	cross, provider, err := self.validateCreateServerTarget(userCred, &input, serverConfig.Hypervisor)
+	if err != nil {
+		return nil, err
+	}
+	for i := range input.NicMappings {
+		netObj, err := NetworkManager.FetchByIdOrName(userCred, input.NicMappings[i].Network)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(NetworkManager.Keyword(), input.NicMappings[i].Network)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		input.NicMappings[i].Network = netObj.GetId()
+	}
+	nets, err := remapInstanceSnapshotNetworks(serverConfig.Networks, input.NicMappings, cross, input.DropUnmappedNics)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cross {
+		createInput, err := self.GetCreateServerInput(input, nets, nil)
+		if err != nil {
+			return nil, err
+		}
+		_, err = GuestManager.CreateGuestFromInput(ctx, userCred, self.GetOwnerId(), createInput)
+		if err != nil {
+			return nil, err
+		}
+		self.AddRefCount(ctx)
+		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_RESTORE, input, userCred, true)
+		return nil, nil
+	}
+
+	if !utils.IsInStringArray(self.GetProviderName(), ProviderHasSubSnapshot) {
+		return nil, httperrors.NewUnsupportOperationError("restore instance snapshot of %s to another zone or provider", self.GetProviderName())
+	}
+	if self.IsEncrypted() {
+		return nil, httperrors.NewUnsupportOperationError("restore encrypted instance snapshot to another zone or provider")
+	}
+	return nil, self.StartCreateServerTask(ctx, userCred, input, nets, provider, "")
+}
+
+func (self *SInstanceSnapshot) StartCreateServerTask(
+	ctx context.Context, userCred mcclient.TokenCredential, input api.InstanceSnapshotCreateServerInput,
+	nets []*api.NetworkConfig, provider string, parentTaskId string,
+) error {
+	params := jsonutils.NewDict()
+	params.Set("input", jsonutils.Marshal(input))
+	params.Set("nets", jsonutils.Marshal(nets))
+	params.Set("target_provider", jsonutils.NewString(provider))
+	task, err := taskman.TaskManager.NewTask(ctx, "InstanceSnapshotCreateServerTask", self, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	self.SetStatus(userCred, api.INSTANCE_SNAPSHOT_CREATE_SERVER, "")
+	task.ScheduleRun(nil)
+	return nil
+}
+
+// GetCreateServerInput 生成新主机的创建参数, imageIds为空时直接使用主机快照创建磁盘, 否则按磁盘顺序使用导出的镜像
+func (self *SInstanceSnapshot) GetCreateServerInput(
+	input api.InstanceSnapshotCreateServerInput, nets []*api.NetworkConfig, imageIds []string,
+) (*api.ServerCreateInput, error) {
+	ret := &api.ServerCreateInput{}
+	ret.Name = input.Name
+	ret.GenerateName = input.GenerateName
+	ret.PreferManager = input.PreferManager
+	ret.PreferRegion = input.PreferRegion
+	ret.PreferZone = input.PreferZone
+	ret.Hypervisor = input.Hypervisor
+	ret.VcpuCount = input.VcpuCount
+	ret.VmemSize = input.VmemSize
+	ret.InstanceType = input.InstanceType
+	ret.AutoStart = input.AutoStart
+	ret.Networks = nets
+	if imageIds == nil {
+		ret.InstanceSnapshotId = self.Id
+		return ret, nil
+	}
+
+	serverConfig, err := self.GetServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(imageIds) != len(serverConfig.Disks) {
+		return nil, errors.Errorf("expect %d images, got %d", len(serverConfig.Disks), len(imageIds))
+	}
+	for i, disk := range serverConfig.Disks {
+		ret.Disks = append(ret.Disks, &api.DiskConfig{
+			Index:      disk.Index,
+			ImageId:    imageIds[i],
+			SizeMb:     disk.SizeMb,
+			Fs:         disk.Fs,
+			Mountpoint: disk.Mountpoint,
+		})
+	}
+	if ret.VcpuCount == 0 {
+		ret.VcpuCount = serverConfig.Ncpu
+	}
+	if ret.VmemSize == 0 {
+		ret.VmemSize = serverConfig.Memory
+	}
+	ret.KeypairId = self.KeypairId
+	ret.OsType = self.OsType
+	ret.OsArch = self.OsArch
+	return ret, nil
+}
+
+// GetStagingServerInput 跨可用区恢复时在源可用区创建的中转主机, 用于将磁盘快照导出为镜像
+func (self *SInstanceSnapshot) GetStagingServerInput(name string) *api.ServerCreateInput {
+	isSystem := true
+	ret := &api.ServerCreateInput{}
+	ret.GenerateName = fmt.Sprintf("%s-staging", name)
+	ret.IsSystem = &isSystem
+	ret.InstanceSnapshotId = self.Id
+	return ret
+}
+
+// CreateGuestFromInput 以主机快照所有者身份创建主机, 校验及配额检查由 db.DoCreate 完成
+func (manager *SGuestManager) CreateGuestFromInput(
+	ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, input *api.ServerCreateInput,
+) (*SGuest, error) {
+	if db.InitPendingUsagesInContext != nil {
+		ctx = db.InitPendingUsagesInContext(ctx)
+	}
+	params := jsonutils.Marshal(input).(*jsonutils.JSONDict)
+	guestObj, err := db.DoCreate(manager, ctx, userCred, nil, params, ownerId)
+	if err != nil {
+		if db.CancelPendingUsagesInContext != nil {
+			if e := db.CancelPendingUsagesInContext(ctx, userCred); e != nil {
+				err = errors.Wrapf(err, e.Error())
+			}
+		}
+		return nil, errors.Wrap(err, "db.DoCreate")
+	}
+	guest := guestObj.(*SGuest)
+	func() {
+		lockman.LockObject(ctx, guest)
+		defer lockman.ReleaseObject(ctx, guest)
+
+		guest.PostCreate(ctx, userCred, ownerId, nil, params)
+	}()
+	manager.OnCreateComplete(ctx, []db.IModel{guest}, userCred, ownerId, nil, params)
+	return guest, nil
+}
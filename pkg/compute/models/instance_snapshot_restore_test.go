@@ -0,0 +1,101 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"strings"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestRemapInstanceSnapshotNetworks(t *testing.T) {
+	nets := []*api.NetworkConfig{
+		{Index: 0, Network: "src-net0", Driver: "virtio"},
+		{Index: 1, Network: "src-net1"},
+	}
+	cases := []struct {
+		name         string
+		mappings     []api.InstanceSnapshotNicMapping
+		cross        bool
+		dropUnmapped bool
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name: "same zone keep unmapped",
+			want: []string{"src-net0", "src-net1"},
+		},
+		{
+			name:     "same zone remap one",
+			mappings: []api.InstanceSnapshotNicMapping{{Index: 1, Network: "dst-net"}},
+			want:     []string{"src-net0", "dst-net"},
+		},
+		{
+			name:     "cross zone unmapped",
+			mappings: []api.InstanceSnapshotNicMapping{{Index: 0, Network: "dst-net"}},
+			cross:    true,
+			wantErr:  true,
+		},
+		{
+			name:         "cross zone drop unmapped",
+			mappings:     []api.InstanceSnapshotNicMapping{{Index: 1, Network: "dst-net"}},
+			cross:        true,
+			dropUnmapped: true,
+			want:         []string{"dst-net"},
+		},
+		{
+			name:     "unknown nic",
+			mappings: []api.InstanceSnapshotNicMapping{{Index: 2, Network: "dst-net"}},
+			wantErr:  true,
+		},
+		{
+			name:     "duplicate nic",
+			mappings: []api.InstanceSnapshotNicMapping{{Index: 0, Network: "a"}, {Index: 0, Network: "b"}},
+			wantErr:  true,
+		},
+		{
+			name:         "drop all",
+			dropUnmapped: true,
+			wantErr:      true,
+		},
+	}
+	for _, c := range cases {
+		got, err := remapInstanceSnapshotNetworks(nets, c.mappings, c.cross, c.dropUnmapped)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expect error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		names := []string{}
+		for i, net := range got {
+			if net.Index != i {
+				t.Errorf("%s: nic %d got index %d", c.name, i, net.Index)
+			}
+			names = append(names, net.Network)
+		}
+		if strings.Join(names, ",") != strings.Join(c.want, ",") {
+			t.Errorf("%s: got %v, want %v", c.name, names, c.want)
+		}
+	}
+	if nets[1].Network != "src-net1" {
+		t.Errorf("source networks should not be modified")
+	}
+}
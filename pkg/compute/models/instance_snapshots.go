@@ -17,6 +17,7 @@ package models
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
@@ -92,6 +93,10 @@ type SInstanceSnapshot struct {
 	MemoryFilePath string `width:"512" charset:"utf8" nullable:"true" get:"user" list:"user"`
 	// 内存文件校验和
 	MemoryFileChecksum string `width:"32" charset:"ascii" nullable:"true" get:"user" list:"user"`
+	// 创建主机快照的快照策略
+	SnapshotpolicyId string `width:"36" charset:"ascii" nullable:"true" list:"user" index:"true"`
+	// 过期时间, 由快照策略的保留天数计算
+	ExpiredAt time.Time `nullable:"true" list:"user"`
 }
 
 type SInstanceSnapshotManager struct {
@@ -554,7 +559,7 @@ func (self *SInstanceSnapshot) GetInstanceSnapshotJointAt(diskIndex int) (*SInst
 }
 
 func (self *SInstanceSnapshot) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
-	if self.Status == api.INSTANCE_SNAPSHOT_START_DELETE || self.Status == api.INSTANCE_SNAPSHOT_RESET {
+	if utils.IsInStringArray(self.Status, []string{api.INSTANCE_SNAPSHOT_START_DELETE, api.INSTANCE_SNAPSHOT_RESET, api.INSTANCE_SNAPSHOT_CREATE_SERVER}) {
 		return httperrors.NewForbiddenError("can't delete instance snapshot with wrong status")
 	}
 	return nil
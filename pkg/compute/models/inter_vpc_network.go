@@ -18,6 +18,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"gopkg.in/fatih/set.v0"
 
@@ -26,6 +27,7 @@ import (
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/tristate"
 	"yunion.io/x/pkg/util/compare"
+	"yunion.io/x/pkg/util/netutils"
 	"yunion.io/x/sqlchemy"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
@@ -306,6 +308,16 @@ func (self *SInterVpcNetwork) PerformAddvpc(ctx context.Context, userCred mcclie
 		return nil, httperrors.NewInputParameterError("vpc %s already connected to a interVpcNetwork", vpc.Id)
 	}
 
+	vpcs, err := self.GetVpcs()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	for i := range vpcs {
+		if cidr, conflict, ok := interVpcCidrOverlap(vpc.CidrBlock, vpcs[i].CidrBlock); ok {
+			return nil, httperrors.NewConflictError("vpc %s cidr %s overlaps with vpc %s cidr %s", vpc.Name, cidr, vpcs[i].Name, conflict)
+		}
+	}
+
 	err = self.StartInterVpcNetworkAddVpcTask(ctx, userCred, vpc)
 	if err != nil {
 		return nil, err
@@ -565,3 +577,68 @@ func (self *SInterVpcNetwork) GetICloudInterVpcNetwork(ctx context.Context) (clo
 	}
 	return iVpcNetwork, nil
 }
+
+func (self *SInterVpcNetwork) GetDetailsCidrConflicts(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) ([]api.InterVpcNetworkCidrConflict, error) {
+	vpcs, err := self.GetVpcs()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	routes, err := self.GetInterVpcNetworkRouteSets()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return findInterVpcNetworkCidrConflicts(vpcs, routes), nil
+}
+
+// 两组以逗号分隔的网段中任意一对重叠时返回该对网段
+func interVpcCidrOverlap(cidrs1, cidrs2 string) (string, string, bool) {
+	for _, cidr1 := range strings.Split(cidrs1, ",") {
+		prefix1, err := netutils.NewIPV4Prefix(strings.TrimSpace(cidr1))
+		if err != nil {
+			continue
+		}
+		for _, cidr2 := range strings.Split(cidrs2, ",") {
+			prefix2, err := netutils.NewIPV4Prefix(strings.TrimSpace(cidr2))
+			if err != nil {
+				continue
+			}
+			if prefix1.ToIPRange().IsOverlap(prefix2.ToIPRange()) {
+				return prefix1.String(), prefix2.String(), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// 检查已加入vpc之间, 以及路由与非下一跳vpc之间的网段冲突
+func findInterVpcNetworkCidrConflicts(vpcs []SVpc, routes []SInterVpcNetworkRouteSet) []api.InterVpcNetworkCidrConflict {
+	ret := []api.InterVpcNetworkCidrConflict{}
+	for i := range vpcs {
+		for j := i + 1; j < len(vpcs); j++ {
+			if cidr, conflict, ok := interVpcCidrOverlap(vpcs[i].CidrBlock, vpcs[j].CidrBlock); ok {
+				ret = append(ret, api.InterVpcNetworkCidrConflict{
+					Cidr:         cidr,
+					Source:       vpcs[i].Name,
+					ConflictCidr: conflict,
+					ConflictWith: vpcs[j].Name,
+				})
+			}
+		}
+	}
+	for i := range routes {
+		for j := range vpcs {
+			if routes[i].VpcId == vpcs[j].Id {
+				continue
+			}
+			if cidr, conflict, ok := interVpcCidrOverlap(routes[i].Cidr, vpcs[j].CidrBlock); ok {
+				ret = append(ret, api.InterVpcNetworkCidrConflict{
+					Cidr:         cidr,
+					Source:       routes[i].Name,
+					ConflictCidr: conflict,
+					ConflictWith: vpcs[j].Name,
+				})
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestFindInterVpcNetworkCidrConflicts(t *testing.T) {
+	newVpc := func(id, cidr string) SVpc {
+		vpc := SVpc{CidrBlock: cidr}
+		vpc.Id = id
+		vpc.Name = id
+		return vpc
+	}
+	newRoute := func(name, cidr, vpcId string) SInterVpcNetworkRouteSet {
+		route := SInterVpcNetworkRouteSet{Cidr: cidr}
+		route.Name = name
+		route.VpcId = vpcId
+		return route
+	}
+	cases := []struct {
+		name   string
+		vpcs   []SVpc
+		routes []SInterVpcNetworkRouteSet
+		want   int
+	}{
+		{
+			name: "no conflict",
+			vpcs: []SVpc{newVpc("vpc1", "10.0.0.0/16"), newVpc("vpc2", "10.1.0.0/16")},
+			want: 0,
+		},
+		{
+			name: "vpc overlap",
+			vpcs: []SVpc{newVpc("vpc1", "10.0.0.0/16"), newVpc("vpc2", "172.16.0.0/12,10.0.1.0/24")},
+			want: 1,
+		},
+		{
+			name:   "route overlap with next hop is allowed",
+			vpcs:   []SVpc{newVpc("vpc1", "10.0.0.0/16"), newVpc("vpc2", "10.1.0.0/16")},
+			routes: []SInterVpcNetworkRouteSet{newRoute("route1", "10.0.2.0/24", "vpc1")},
+			want:   0,
+		},
+		{
+			name:   "route overlap with other vpc",
+			vpcs:   []SVpc{newVpc("vpc1", "10.0.0.0/16"), newVpc("vpc2", "10.1.0.0/16")},
+			routes: []SInterVpcNetworkRouteSet{newRoute("route1", "10.1.2.0/24", "vpc1")},
+			want:   1,
+		},
+	}
+	for _, c := range cases {
+		got := findInterVpcNetworkCidrConflicts(c.vpcs, c.routes)
+		if len(got) != c.want {
+			t.Errorf("%s: want %d conflicts, got %v", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,529 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/appctx"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-model-singular=loadbalancer_blueprint
+// +onecloud:swagger-gen-model-plural=loadbalancer_blueprints
+type SLoadbalancerBlueprintManager struct {
+	db.SSharableVirtualResourceBaseManager
+}
+
+var LoadbalancerBlueprintManager *SLoadbalancerBlueprintManager
+
+func init() {
+	LoadbalancerBlueprintManager = &SLoadbalancerBlueprintManager{
+		SSharableVirtualResourceBaseManager: db.NewSharableVirtualResourceBaseManager(
+			SLoadbalancerBlueprint{},
+			"loadbalancer_blueprints_tbl",
+			"loadbalancer_blueprint",
+			"loadbalancer_blueprints",
+		),
+	}
+	LoadbalancerBlueprintManager.SetVirtualObject(LoadbalancerBlueprintManager)
+}
+
+// 负载均衡蓝图, 以与平台无关的形式声明监听, 后端服务器及健康检查, 可在任意平台上实例化为负载均衡并保持关联
+type SLoadbalancerBlueprint struct {
+	db.SSharableVirtualResourceBase
+
+	// 蓝图定义
+	Spec *api.SLoadbalancerBlueprintSpec `length:"medium" nullable:"true" list:"user" update:"user" create:"required"`
+}
+
+func validateLoadbalancerBlueprintSpec(userCred mcclient.TokenCredential, spec *api.SLoadbalancerBlueprintSpec) error {
+	err := spec.Validate()
+	if err != nil {
+		return err
+	}
+	for i := range spec.Listeners {
+		if len(spec.Listeners[i].CertificateId) == 0 {
+			continue
+		}
+		_, err = validators.ValidateModel(userCred, LoadbalancerCertificateManager, &spec.Listeners[i].CertificateId)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range spec.Backends {
+		_, err = validators.ValidateModel(userCred, GuestManager, &spec.Backends[i].ServerId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (manager *SLoadbalancerBlueprintManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.LoadbalancerBlueprintCreateInput,
+) (api.LoadbalancerBlueprintCreateInput, error) {
+	err := validateLoadbalancerBlueprintSpec(userCred, &input.Spec)
+	if err != nil {
+		return input, err
+	}
+	input.SharableVirtualResourceCreateInput, err = manager.SSharableVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.SharableVirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	input.Status = api.LB_STATUS_ENABLED
+	return input, nil
+}
+
+func (self *SLoadbalancerBlueprint) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.LoadbalancerBlueprintUpdateInput,
+) (api.LoadbalancerBlueprintUpdateInput, error) {
+	if input.Spec != nil {
+		err := validateLoadbalancerBlueprintSpec(userCred, input.Spec)
+		if err != nil {
+			return input, err
+		}
+	}
+	var err error
+	input.SharableVirtualResourceBaseUpdateInput, err = self.SSharableVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.SharableVirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SLoadbalancerBlueprint) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := self.GetLoadbalancerQuery().CountWithError()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("blueprint %s is used by %d loadbalancers", self.Name, cnt)
+	}
+	return self.SSharableVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (self *SLoadbalancerBlueprint) getSpec() api.SLoadbalancerBlueprintSpec {
+	if self.Spec == nil {
+		return api.SLoadbalancerBlueprintSpec{}
+	}
+	return *self.Spec
+}
+
+func (self *SLoadbalancerBlueprint) GetLoadbalancerQuery() *sqlchemy.SQuery {
+	return LoadbalancerManager.Query().Equals("blueprint_id", self.Id)
+}
+
+func (self *SLoadbalancerBlueprint) GetLoadbalancers() ([]SLoadbalancer, error) {
+	lbs := []SLoadbalancer{}
+	err := db.FetchModelObjects(LoadbalancerManager, self.GetLoadbalancerQuery(), &lbs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.FetchModelObjects")
+	}
+	return lbs, nil
+}
+
+// 在指定平台上按蓝图创建负载均衡, 负载均衡创建完成后自动创建蓝图中的监听及后端服务器组
+func (self *SLoadbalancerBlueprint) PerformInstantiate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.LoadbalancerBlueprintInstantiateInput) (jsonutils.JSONObject, error) {
+	if len(input.Name) == 0 && len(input.GenerateName) == 0 {
+		input.GenerateName = self.Name
+	}
+	lbId, err := doCreateFromBlueprint(ctx, userCred, LoadbalancerManager, jsonutils.Marshal(input.LoadbalancerCreateInput))
+	if err != nil {
+		return nil, err
+	}
+	lbObj, err := LoadbalancerManager.FetchById(lbId)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "FetchById %s", lbId))
+	}
+	lb := lbObj.(*SLoadbalancer)
+	_, err = db.Update(lb, func() error {
+		lb.BlueprintId = self.Id
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "db.Update"))
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_CREATE, fmt.Sprintf("instantiate loadbalancer %s", lb.Name), userCred, true)
+	return jsonutils.Marshal(map[string]string{"loadbalancer_id": lb.Id}), nil
+}
+
+// 将蓝图批量应用到由其创建的负载均衡, 创建缺失的监听, 并更新已有监听的参数及其后端服务器组
+func (self *SLoadbalancerBlueprint) PerformSync(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.LoadbalancerBlueprintSyncInput) (*api.LoadbalancerBlueprintSyncOutput, error) {
+	lbs, err := self.GetLoadbalancers()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	ret := &api.LoadbalancerBlueprintSyncOutput{}
+	for i := range lbs {
+		if len(input.LoadbalancerIds) > 0 && !utils.IsInStringArray(lbs[i].Id, input.LoadbalancerIds) && !utils.IsInStringArray(lbs[i].Name, input.LoadbalancerIds) {
+			continue
+		}
+		result := self.applyToLoadbalancer(ctx, userCred, &lbs[i])
+		ret.Results = append(ret.Results, result)
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_SYNC_CONF, ret, userCred, true)
+	return ret, nil
+}
+
+// 负载均衡创建完成后应用其关联的蓝图
+func (lb *SLoadbalancer) ApplyBlueprint(ctx context.Context, userCred mcclient.TokenCredential) {
+	if len(lb.BlueprintId) == 0 {
+		return
+	}
+	blueprint, err := LoadbalancerBlueprintManager.FetchById(lb.BlueprintId)
+	if err != nil {
+		log.Errorf("fetch blueprint %s of loadbalancer %s error: %v", lb.BlueprintId, lb.Name, err)
+		return
+	}
+	result := blueprint.(*SLoadbalancerBlueprint).applyToLoadbalancer(ctx, userCred, lb)
+	logclient.AddSimpleActionLog(lb, logclient.ACT_SYNC_CONF, result, userCred, len(result.Error) == 0)
+}
+
+func (self *SLoadbalancerBlueprint) applyToLoadbalancer(ctx context.Context, userCred mcclient.TokenCredential, lb *SLoadbalancer) api.LoadbalancerBlueprintApplyResult {
+	result := api.LoadbalancerBlueprintApplyResult{LoadbalancerId: lb.Id}
+	if lb.Status != api.LB_STATUS_ENABLED {
+		result.Error = fmt.Sprintf("loadbalancer status %s is not %s", lb.Status, api.LB_STATUS_ENABLED)
+		return result
+	}
+
+	lockman.LockObject(ctx, lb)
+	defer lockman.ReleaseObject(ctx, lb)
+
+	listeners, err := lb.GetLoadbalancerListeners()
+	if err != nil {
+		result.Error = errors.Wrapf(err, "GetLoadbalancerListeners").Error()
+		return result
+	}
+	backends, skipped := self.getLoadbalancerBackends(lb)
+	result.SkippedBackends = skipped
+
+	errs := []error{}
+	creates, updates := getBlueprintListenerChanges(self.getSpec(), listeners)
+	for _, update := range updates {
+		key := update.spec.GetKey()
+		err = update.listener.updateFromBlueprint(ctx, userCred, update.spec)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "update listener %s", key))
+			continue
+		}
+		err = update.listener.syncBackendsFromBlueprint(ctx, userCred, update.spec, backends)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "sync backends of listener %s", key))
+			continue
+		}
+		result.Updated = append(result.Updated, key)
+	}
+	for _, listenerSpec := range creates {
+		key := listenerSpec.GetKey()
+		err = self.createListener(ctx, userCred, lb, listenerSpec, backends)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "create listener %s", key))
+			continue
+		}
+		result.Created = append(result.Created, key)
+	}
+	if err := errors.NewAggregate(errs); err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+type sBlueprintListenerUpdate struct {
+	listener *SLoadbalancerListener
+	spec     api.SLoadbalancerBlueprintListener
+}
+
+// 按监听类型及端口匹配蓝图与已有监听, 返回需新建的监听及需更新的已有监听
+func getBlueprintListenerChanges(spec api.SLoadbalancerBlueprintSpec, listeners []SLoadbalancerListener) ([]api.SLoadbalancerBlueprintListener, []sBlueprintListenerUpdate) {
+	listenerMap := map[string]*SLoadbalancerListener{}
+	for i := range listeners {
+		listenerMap[fmt.Sprintf("%s:%d", listeners[i].ListenerType, listeners[i].ListenerPort)] = &listeners[i]
+	}
+	creates, updates := []api.SLoadbalancerBlueprintListener{}, []sBlueprintListenerUpdate{}
+	for _, listenerSpec := range spec.Listeners {
+		if listener, ok := listenerMap[listenerSpec.GetKey()]; ok {
+			updates = append(updates, sBlueprintListenerUpdate{listener: listener, spec: listenerSpec})
+			continue
+		}
+		creates = append(creates, listenerSpec)
+	}
+	return creates, updates
+}
+
+type sBlueprintBackendUpdate struct {
+	backend *SLoadbalancerBackend
+	weight  int
+}
+
+// 返回后端服务器组中需新增的虚拟机, 及端口或权重与蓝图不一致的已有虚拟机
+// 不在蓝图中的后端服务器保持不变
+func getBlueprintBackendChanges(port int, backends []api.SLoadbalancerBlueprintBackend, exists []SLoadbalancerBackend) ([]api.SLoadbalancerBlueprintBackend, []sBlueprintBackendUpdate) {
+	existMap := map[string]*SLoadbalancerBackend{}
+	for i := range exists {
+		if exists[i].BackendType == api.LB_BACKEND_GUEST {
+			existMap[exists[i].BackendId] = &exists[i]
+		}
+	}
+	adds, updates := []api.SLoadbalancerBlueprintBackend{}, []sBlueprintBackendUpdate{}
+	for _, backend := range backends {
+		lbb, ok := existMap[backend.ServerId]
+		if !ok {
+			adds = append(adds, backend)
+			continue
+		}
+		if lbb.Port != port || lbb.Weight != backend.Weight {
+			updates = append(updates, sBlueprintBackendUpdate{backend: lbb, weight: backend.Weight})
+		}
+	}
+	return adds, updates
+}
+
+// 蓝图中与负载均衡位于同一区域及云账号的后端虚拟机
+func (self *SLoadbalancerBlueprint) getLoadbalancerBackends(lb *SLoadbalancer) ([]api.SLoadbalancerBlueprintBackend, []string) {
+	ret, skipped := []api.SLoadbalancerBlueprintBackend{}, []string{}
+	for _, backend := range self.getSpec().Backends {
+		guestObj, err := GuestManager.FetchById(backend.ServerId)
+		if err != nil {
+			skipped = append(skipped, backend.ServerId)
+			continue
+		}
+		host, err := guestObj.(*SGuest).GetHost()
+		if err != nil || host.ManagerId != lb.ManagerId {
+			skipped = append(skipped, backend.ServerId)
+			continue
+		}
+		zone, err := host.GetZone()
+		if err != nil || zone.CloudregionId != lb.CloudregionId {
+			skipped = append(skipped, backend.ServerId)
+			continue
+		}
+		ret = append(ret, backend)
+	}
+	return ret, skipped
+}
+
+// 与API创建走相同的流程(权限校验, 配额及创建失败回滚), 任务中调用时上下文不含用户凭证, 需补充
+func doCreateFromBlueprint(ctx context.Context, userCred mcclient.TokenCredential, manager db.IModelManager, data jsonutils.JSONObject) (string, error) {
+	ctx = context.WithValue(ctx, appctx.APP_CONTEXT_KEY_AUTH_TOKEN, userCred)
+	ret, err := db.NewModelHandler(manager).Create(ctx, jsonutils.NewDict(), data, nil)
+	if err != nil {
+		return "", err
+	}
+	return ret.GetString("id")
+}
+
+func (self *SLoadbalancerBlueprint) createListener(ctx context.Context, userCred mcclient.TokenCredential, lb *SLoadbalancer, spec api.SLoadbalancerBlueprintListener, backends []api.SLoadbalancerBlueprintBackend) error {
+	lbbgBackends := []map[string]interface{}{}
+	for _, backend := range backends {
+		lbbgBackends = append(lbbgBackends, map[string]interface{}{
+			"id":           backend.ServerId,
+			"weight":       backend.Weight,
+			"port":         spec.BackendPort,
+			"backend_type": api.LB_BACKEND_GUEST,
+		})
+	}
+	lbbgData := jsonutils.Marshal(map[string]interface{}{
+		"name":            fmt.Sprintf("%s-%s-%d", self.Name, spec.ListenerType, spec.ListenerPort),
+		"loadbalancer_id": lb.Id,
+		"backends":        lbbgBackends,
+	})
+	lbbgId, err := doCreateFromBlueprint(ctx, userCred, LoadbalancerBackendGroupManager, lbbgData)
+	if err != nil {
+		return errors.Wrapf(err, "create backend group")
+	}
+
+	input := spec.ToCreateInput()
+	input.LoadbalancerId = lb.Id
+	input.BackendGroupId = lbbgId
+	_, err = doCreateFromBlueprint(ctx, userCred, LoadbalancerListenerManager, jsonutils.Marshal(input))
+	if err != nil {
+		return errors.Wrapf(err, "create listener")
+	}
+	return nil
+}
+
+// 已有监听的后端服务器组补充蓝图中缺失的虚拟机, 并按蓝图更新已有虚拟机的端口及权重
+func (lblis *SLoadbalancerListener) syncBackendsFromBlueprint(ctx context.Context, userCred mcclient.TokenCredential, spec api.SLoadbalancerBlueprintListener, backends []api.SLoadbalancerBlueprintBackend) error {
+	lbbg, err := lblis.GetLoadbalancerBackendGroup()
+	if err != nil {
+		return errors.Wrapf(err, "GetLoadbalancerBackendGroup")
+	}
+	exists, err := lbbg.GetBackends()
+	if err != nil {
+		return errors.Wrapf(err, "GetBackends")
+	}
+	adds, updates := getBlueprintBackendChanges(spec.BackendPort, backends, exists)
+	errs := []error{}
+	for _, update := range updates {
+		err = update.backend.updateFromBlueprint(ctx, userCred, spec.BackendPort, update.weight)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "update backend %s", update.backend.Name))
+		}
+	}
+	for _, backend := range adds {
+		data := jsonutils.Marshal(api.LoadbalancerBackendCreateInput{
+			BackendGroupId: lbbg.Id,
+			BackendId:      backend.ServerId,
+			BackendType:    api.LB_BACKEND_GUEST,
+			Weight:         backend.Weight,
+			Port:           spec.BackendPort,
+		})
+		_, err = doCreateFromBlueprint(ctx, userCred, LoadbalancerBackendManager, data)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "add backend %s", backend.ServerId))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func (lbb *SLoadbalancerBackend) updateFromBlueprint(ctx context.Context, userCred mcclient.TokenCredential, port, weight int) error {
+	lockman.LockObject(ctx, lbb)
+	defer lockman.ReleaseObject(ctx, lbb)
+
+	data := jsonutils.Marshal(api.LoadbalancerBackendUpdateInput{Port: &port, Weight: &weight}).(*jsonutils.JSONDict)
+	data, err := db.ValidateUpdateData(lbb, ctx, userCred, nil, data)
+	if err != nil {
+		return errors.Wrapf(err, "ValidateUpdateData")
+	}
+	diff, err := db.Update(lbb, func() error {
+		return data.Unmarshal(lbb)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	db.OpsLog.LogEvent(lbb, db.ACT_UPDATE, diff, userCred)
+	lbb.PostUpdate(ctx, userCred, nil, data)
+	return nil
+}
+
+func (lblis *SLoadbalancerListener) updateFromBlueprint(ctx context.Context, userCred mcclient.TokenCredential, spec api.SLoadbalancerBlueprintListener) error {
+	lockman.LockObject(ctx, lblis)
+	defer lockman.ReleaseObject(ctx, lblis)
+
+	data := jsonutils.Marshal(spec.ToUpdateInput()).(*jsonutils.JSONDict)
+	data, err := db.ValidateUpdateData(lblis, ctx, userCred, nil, data)
+	if err != nil {
+		return errors.Wrapf(err, "ValidateUpdateData")
+	}
+	diff, err := db.Update(lblis, func() error {
+		return data.Unmarshal(lblis)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	db.OpsLog.LogEvent(lblis, db.ACT_UPDATE, diff, userCred)
+	lblis.PostUpdate(ctx, userCred, nil, data)
+	return nil
+}
+
+// 负载均衡蓝图列表
+func (manager *SLoadbalancerBlueprintManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.LoadbalancerBlueprintListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, input.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.ListItemFilter")
+	}
+	return q, nil
+}
+
+func (manager *SLoadbalancerBlueprintManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.LoadbalancerBlueprintListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SLoadbalancerBlueprintManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SLoadbalancerBlueprintManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.LoadbalancerBlueprintDetails {
+	rows := make([]api.LoadbalancerBlueprintDetails, len(objs))
+	virtRows := manager.SSharableVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	blueprintIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.LoadbalancerBlueprintDetails{
+			SharableVirtualResourceDetails: virtRows[i],
+		}
+		blueprintIds[i] = objs[i].(*SLoadbalancerBlueprint).Id
+	}
+
+	q := LoadbalancerManager.Query().In("blueprint_id", blueprintIds)
+	q = q.AppendField(q.Field("blueprint_id"), sqlchemy.COUNT("loadbalancer_count")).GroupBy(q.Field("blueprint_id"))
+	counts := []struct {
+		BlueprintId       string
+		LoadbalancerCount int
+	}{}
+	err := q.All(&counts)
+	if err != nil {
+		log.Errorf("query loadbalancer count error: %v", err)
+		return rows
+	}
+	countMap := map[string]int{}
+	for _, cnt := range counts {
+		countMap[cnt.BlueprintId] = cnt.LoadbalancerCount
+	}
+	for i := range rows {
+		rows[i].LoadbalancerCount = countMap[blueprintIds[i]]
+	}
+	return rows
+}
@@ -0,0 +1,214 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestLoadbalancerBlueprintSpecValidate(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		spec api.SLoadbalancerBlueprintSpec
+		ok   bool
+	}{
+		{
+			name: "no listener",
+			spec: api.SLoadbalancerBlueprintSpec{},
+		},
+		{
+			name: "tcp and http",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{
+					{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 22, HealthCheck: api.LB_BOOL_OFF},
+					{ListenerType: api.LB_LISTENER_TYPE_HTTP, ListenerPort: 80, BackendPort: 8080, HealthCheckType: api.LB_HEALTH_CHECK_HTTP},
+				},
+				Backends: []api.SLoadbalancerBlueprintBackend{{ServerId: "vm-1"}},
+			},
+			ok: true,
+		},
+		{
+			name: "invalid listener port",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 70000}},
+			},
+		},
+		{
+			name: "invalid backend port",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 22, BackendPort: -1}},
+			},
+		},
+		{
+			name: "health check without type",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 22}},
+			},
+		},
+		{
+			name: "invalid listener type",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{{ListenerType: "sctp", ListenerPort: 22}},
+			},
+		},
+		{
+			name: "https without certificate",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{{ListenerType: api.LB_LISTENER_TYPE_HTTPS, ListenerPort: 443}},
+			},
+		},
+		{
+			name: "duplicate listener",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{
+					{ListenerType: api.LB_LISTENER_TYPE_HTTP, ListenerPort: 80},
+					{ListenerType: api.LB_LISTENER_TYPE_HTTP, ListenerPort: 80, BackendPort: 8080},
+				},
+			},
+		},
+		{
+			name: "backend without server",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 22}},
+				Backends:  []api.SLoadbalancerBlueprintBackend{{Weight: 10}},
+			},
+		},
+		{
+			name: "invalid backend weight",
+			spec: api.SLoadbalancerBlueprintSpec{
+				Listeners: []api.SLoadbalancerBlueprintListener{{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 22}},
+				Backends:  []api.SLoadbalancerBlueprintBackend{{ServerId: "vm-1", Weight: 300}},
+			},
+		},
+	} {
+		err := c.spec.Validate()
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v, got %v", c.name, c.ok, err)
+		}
+	}
+}
+
+func TestLoadbalancerBlueprintSpecDefaults(t *testing.T) {
+	spec := api.SLoadbalancerBlueprintSpec{
+		Listeners: []api.SLoadbalancerBlueprintListener{
+			{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 22, HealthCheckType: api.LB_HEALTH_CHECK_TCP},
+			{ListenerType: api.LB_LISTENER_TYPE_HTTP, ListenerPort: 80, BackendPort: 8080, Scheduler: api.LB_SCHEDULER_WRR, HealthCheck: api.LB_BOOL_OFF},
+		},
+		Backends: []api.SLoadbalancerBlueprintBackend{{ServerId: "vm-1"}, {ServerId: "vm-2", Weight: 10}},
+	}
+	err := spec.Validate()
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if spec.Listeners[0].BackendPort != 22 || spec.Listeners[0].Scheduler != api.LB_SCHEDULER_RR {
+		t.Errorf("want backend port and scheduler defaulted, got %d %s", spec.Listeners[0].BackendPort, spec.Listeners[0].Scheduler)
+	}
+	if spec.Listeners[1].BackendPort != 8080 || spec.Listeners[1].Scheduler != api.LB_SCHEDULER_WRR {
+		t.Errorf("want backend port and scheduler kept, got %d %s", spec.Listeners[1].BackendPort, spec.Listeners[1].Scheduler)
+	}
+	if spec.Backends[0].Weight != 100 || spec.Backends[1].Weight != 10 {
+		t.Errorf("unexpected backend weights %d %d", spec.Backends[0].Weight, spec.Backends[1].Weight)
+	}
+}
+
+func TestLoadbalancerBlueprintListenerToUpdateInput(t *testing.T) {
+	listener := api.SLoadbalancerBlueprintListener{
+		ListenerType:    api.LB_LISTENER_TYPE_HTTPS,
+		ListenerPort:    443,
+		Scheduler:       api.LB_SCHEDULER_RR,
+		CertificateId:   "cert-1",
+		HealthCheckRise: 3,
+	}
+	input := listener.ToUpdateInput()
+	if input.Scheduler == nil || *input.Scheduler != api.LB_SCHEDULER_RR {
+		t.Errorf("want scheduler updated")
+	}
+	if input.CertificateId == nil || *input.CertificateId != "cert-1" {
+		t.Errorf("want certificate updated")
+	}
+	if input.HealthCheckRise == nil || *input.HealthCheckRise != 3 {
+		t.Errorf("want health_check_rise updated")
+	}
+	// 蓝图中未设置的可选参数不覆盖已有监听的配置
+	if input.TLSCipherPolicy != nil || input.HealthCheckFail != nil || input.StickySessionCookieTimeout != nil {
+		t.Errorf("want unset fields left untouched")
+	}
+}
+
+func TestGetBlueprintListenerChanges(t *testing.T) {
+	spec := api.SLoadbalancerBlueprintSpec{
+		Listeners: []api.SLoadbalancerBlueprintListener{
+			{ListenerType: api.LB_LISTENER_TYPE_HTTP, ListenerPort: 80},
+			{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 80},
+			{ListenerType: api.LB_LISTENER_TYPE_TCP, ListenerPort: 22},
+		},
+	}
+	listeners := make([]SLoadbalancerListener, 3)
+	listeners[0].Id, listeners[0].ListenerType, listeners[0].ListenerPort = "lis-http", api.LB_LISTENER_TYPE_HTTP, 80
+	listeners[1].Id, listeners[1].ListenerType, listeners[1].ListenerPort = "lis-tcp", api.LB_LISTENER_TYPE_TCP, 22
+	listeners[2].Id, listeners[2].ListenerType, listeners[2].ListenerPort = "lis-other", api.LB_LISTENER_TYPE_UDP, 53
+
+	creates, updates := getBlueprintListenerChanges(spec, listeners)
+	if len(creates) != 1 || creates[0].GetKey() != "tcp:80" {
+		t.Errorf("want only tcp:80 created, got %v", creates)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("want 2 listeners updated, got %d", len(updates))
+	}
+	if updates[0].listener.Id != "lis-http" || updates[0].spec.GetKey() != "http:80" {
+		t.Errorf("unexpected update %s %s", updates[0].listener.Id, updates[0].spec.GetKey())
+	}
+	if updates[1].listener.Id != "lis-tcp" || updates[1].spec.GetKey() != "tcp:22" {
+		t.Errorf("unexpected update %s %s", updates[1].listener.Id, updates[1].spec.GetKey())
+	}
+
+	creates, updates = getBlueprintListenerChanges(spec, nil)
+	if len(creates) != 3 || len(updates) != 0 {
+		t.Errorf("want all listeners created on new loadbalancer, got %d %d", len(creates), len(updates))
+	}
+}
+
+func TestGetBlueprintBackendChanges(t *testing.T) {
+	backends := []api.SLoadbalancerBlueprintBackend{
+		{ServerId: "vm-same", Weight: 100},
+		{ServerId: "vm-weight", Weight: 50},
+		{ServerId: "vm-port", Weight: 100},
+		{ServerId: "vm-new", Weight: 100},
+		{ServerId: "host-1", Weight: 100},
+	}
+	exists := make([]SLoadbalancerBackend, 5)
+	for i, id := range []string{"vm-same", "vm-weight", "vm-port", "vm-manual"} {
+		exists[i].BackendId, exists[i].BackendType, exists[i].Port, exists[i].Weight = id, api.LB_BACKEND_GUEST, 8080, 100
+	}
+	exists[2].Port = 80
+	// 同Id的宿主机后端不视为蓝图中的虚拟机
+	exists[4].BackendId, exists[4].BackendType, exists[4].Port, exists[4].Weight = "host-1", api.LB_BACKEND_HOST, 8080, 100
+
+	adds, updates := getBlueprintBackendChanges(8080, backends, exists)
+	if len(adds) != 2 || adds[0].ServerId != "vm-new" || adds[1].ServerId != "host-1" {
+		t.Errorf("unexpected adds %v", adds)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("want 2 backends updated, got %d", len(updates))
+	}
+	if updates[0].backend.BackendId != "vm-weight" || updates[0].weight != 50 {
+		t.Errorf("unexpected update %s %d", updates[0].backend.BackendId, updates[0].weight)
+	}
+	if updates[1].backend.BackendId != "vm-port" || updates[1].weight != 100 {
+		t.Errorf("unexpected update %s %d", updates[1].backend.BackendId, updates[1].weight)
+	}
+}
@@ -17,6 +17,7 @@ package models
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
@@ -67,6 +68,9 @@ type SCachedLoadbalancerCertificate struct {
 	SCloudregionResourceBase // Region ID
 
 	SLoadbalancerCertificateResourceBase `width:"128" charset:"ascii" nullable:"false" create:"required"  index:"true" list:"user"`
+
+	// 云上证书过期时间
+	NotAfter time.Time `nullable:"true" list:"user"`
 }
 
 func (manager *SCachedLoadbalancerCertificateManager) ResourceScope() rbacscope.TRbacScope {
@@ -271,6 +275,7 @@ func (self *SCloudprovider) newFromCloudLoadbalancerCertificate(ctx context.Cont
 	}
 	lbcert.CertificateId = c.Id
 	lbcert.Name = ext.GetName()
+	lbcert.NotAfter = ext.GetExpireTime()
 
 	err := CachedLoadbalancerCertificateManager.TableSpec().Insert(ctx, lbcert)
 	if err != nil {
@@ -290,6 +295,9 @@ func (self *SCloudprovider) newFromCloudLoadbalancerCertificate(ctx context.Cont
 func (lbcert *SCachedLoadbalancerCertificate) SyncWithCloudLoadbalancerCertificate(ctx context.Context, userCred mcclient.TokenCredential, ext cloudprovider.ICloudLoadbalancerCertificate) error {
 	diff, err := db.Update(lbcert, func() error {
 		lbcert.Name = ext.GetName()
+		if expire := ext.GetExpireTime(); !expire.IsZero() {
+			lbcert.NotAfter = expire
+		}
 		return nil
 	})
 	if err != nil {
@@ -435,6 +443,10 @@ func (man *SCachedLoadbalancerCertificateManager) ListItemFilter(
 		return nil, errors.Wrap(err, "SLoadbalancerCertificateResourceBaseManager.ListItemFilter")
 	}
 
+	if query.ExpireWithinDays != nil {
+		q = q.LT("not_after", time.Now().AddDate(0, 0, *query.ExpireWithinDays))
+	}
+
 	return q, nil
 }
 
@@ -0,0 +1,297 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/httputils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// 证书续期钩子, 返回新的证书及私钥内容
+type ILoadbalancerCertificateRenewer interface {
+	// 钩子是否需要指定地址
+	NeedUrl() bool
+	Renew(ctx context.Context, lbcert *SLoadbalancerCertificate) (certificate string, privateKey string, err error)
+}
+
+var lbCertificateRenewers = map[string]ILoadbalancerCertificateRenewer{}
+
+// 注册证书续期钩子, 如ACME客户端等可通过此方式接入
+func RegisterLoadbalancerCertificateRenewer(hook string, renewer ILoadbalancerCertificateRenewer) {
+	lbCertificateRenewers[hook] = renewer
+}
+
+func init() {
+	RegisterLoadbalancerCertificateRenewer(api.LB_CERT_RENEW_HOOK_WEBHOOK, &sLbCertWebhookRenewer{})
+}
+
+type sLbCertWebhookRenewer struct {
+	// 为空时使用 isGuardedDialAddrAllowed
+	allowAddr func(ip net.IP) bool
+}
+
+func (self *sLbCertWebhookRenewer) NeedUrl() bool {
+	return true
+}
+
+func (self *sLbCertWebhookRenewer) getClient() *http.Client {
+	return newGuardedHttpClient(time.Minute, false, self.allowAddr)
+}
+
+// 以POST方式将证书信息发送到用户webhook, webhook需返回 {"certificate": "...", "private_key": "..."}
+func (self *sLbCertWebhookRenewer) Renew(ctx context.Context, lbcert *SLoadbalancerCertificate) (string, string, error) {
+	body := jsonutils.Marshal(map[string]interface{}{
+		"id":                        lbcert.Id,
+		"name":                      lbcert.Name,
+		"common_name":               lbcert.CommonName,
+		"subject_alternative_names": lbcert.SubjectAlternativeNames,
+		"not_after":                 lbcert.NotAfter,
+	})
+	_, resp, err := httputils.JSONRequest(self.getClient(), ctx, httputils.POST, lbcert.RenewHookUrl, nil, body, false)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "POST %s", lbcert.RenewHookUrl)
+	}
+	if resp == nil {
+		return "", "", errors.Errorf("empty response from %s", lbcert.RenewHookUrl)
+	}
+	certificate, _ := resp.GetString("certificate")
+	privateKey, _ := resp.GetString("private_key")
+	return certificate, privateKey, nil
+}
+
+func validateLoadbalancerCertificateRenewHook(hook, url string, renewBeforeDays *int) error {
+	if renewBeforeDays != nil && *renewBeforeDays < 0 {
+		return httperrors.NewInputParameterError("invalid renew_before_days %d", *renewBeforeDays)
+	}
+	if len(hook) == 0 {
+		return nil
+	}
+	renewer, ok := lbCertificateRenewers[hook]
+	if !ok {
+		return httperrors.NewInputParameterError("unsupported renew_hook %s", hook)
+	}
+	if renewer.NeedUrl() {
+		err := validateLoadbalancerCertificateRenewHookUrl(url)
+		if err != nil {
+			return httperrors.NewInputParameterError("renew_hook %s requires a valid http(s) renew_hook_url: %v", hook, err)
+		}
+	}
+	return nil
+}
+
+// 创建及更新时先检查字面地址, 域名在访问时解析后再检查
+func validateLoadbalancerCertificateRenewHookUrl(hookUrl string) error {
+	u, err := url.Parse(hookUrl)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if len(host) == 0 {
+		return errors.Errorf("empty host")
+	}
+	if strings.EqualFold(host, "localhost") {
+		return errors.Errorf("host %s is not allowed", host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !isGuardedDialAddrAllowed(ip) {
+		return errors.Errorf("host %s is not allowed", host)
+	}
+	return nil
+}
+
+func (lbcert *SLoadbalancerCertificate) getRenewBeforeDays() int {
+	if lbcert.RenewBeforeDays > 0 {
+		return lbcert.RenewBeforeDays
+	}
+	return options.Options.LbCertificateRenewBeforeDays
+}
+
+// 在days天内过期(包含已过期)的证书的过期时间上限
+func getLbCertificateExpireBefore(now time.Time, days int) time.Time {
+	return now.AddDate(0, 0, days)
+}
+
+// 配置了续期钩子且在续期提前天数内过期
+func (lbcert *SLoadbalancerCertificate) isRenewDue(now time.Time) bool {
+	if len(lbcert.RenewHook) == 0 || lbcert.NotAfter.IsZero() {
+		return false
+	}
+	return lbcert.NotAfter.Before(getLbCertificateExpireBefore(now, lbcert.getRenewBeforeDays()))
+}
+
+// 续期证书, 并替换所有已下发到云上的证书缓存
+func (lbcert *SLoadbalancerCertificate) PerformRenew(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.LoadbalancerCertificateRenewInput) (jsonutils.JSONObject, error) {
+	if lbcert.Status == api.LB_CERT_STATUS_RENEWING {
+		return nil, httperrors.NewInvalidStatusError("certificate is renewing")
+	}
+	if len(input.Certificate) > 0 || len(input.PrivateKey) > 0 {
+		_, err := parseLoadbalancerCertificate(input.Certificate, input.PrivateKey)
+		if err != nil {
+			return nil, httperrors.NewInputParameterError("invalid certificate: %v", err)
+		}
+	} else if len(lbcert.RenewHook) == 0 {
+		return nil, httperrors.NewMissingParameterError("certificate")
+	}
+	return nil, lbcert.StartRenewTask(ctx, userCred, input, "")
+}
+
+func (lbcert *SLoadbalancerCertificate) StartRenewTask(ctx context.Context, userCred mcclient.TokenCredential, input api.LoadbalancerCertificateRenewInput, parentTaskId string) error {
+	params := jsonutils.Marshal(input).(*jsonutils.JSONDict)
+	task, err := taskman.TaskManager.NewTask(ctx, "LoadbalancerCertificateRenewTask", lbcert, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask")
+	}
+	lbcert.SetStatus(userCred, api.LB_CERT_STATUS_RENEWING, "")
+	return task.ScheduleRun(nil)
+}
+
+// 替换证书内容, 未提供证书时通过续期钩子获取
+func (lbcert *SLoadbalancerCertificate) Renew(ctx context.Context, userCred mcclient.TokenCredential, certificate, privateKey string) error {
+	if len(certificate) == 0 && len(privateKey) == 0 {
+		renewer, ok := lbCertificateRenewers[lbcert.RenewHook]
+		if !ok {
+			return errors.Wrapf(cloudprovider.ErrNotSupported, "renew hook %q", lbcert.RenewHook)
+		}
+		var err error
+		certificate, privateKey, err = renewer.Renew(ctx, lbcert)
+		if err != nil {
+			return errors.Wrapf(err, "renew by %s", lbcert.RenewHook)
+		}
+	}
+	renewed, err := lbcert.getRenewedCertificate(certificate, privateKey)
+	if err != nil {
+		return err
+	}
+	info := &renewed.SCertificateResourceBase
+
+	// 先下发到云上, 全部成功后再替换本地证书, 失败重试时会重新下发
+	caches, err := lbcert.GetCachedCerts()
+	if err != nil {
+		return errors.Wrapf(err, "GetCachedCerts")
+	}
+	err = syncRenewedCertificateCaches(caches, func(cache *SCachedLoadbalancerCertificate) error {
+		return cache.syncRenewedCertificate(ctx, userCred, renewed)
+	})
+	if err != nil {
+		return err
+	}
+
+	oldNotAfter := lbcert.NotAfter
+	_, err = db.Update(lbcert, func() error {
+		lbcert.SCertificateResourceBase = *info
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	db.OpsLog.LogEvent(lbcert, db.ACT_UPDATE, fmt.Sprintf("renew certificate, not_after %s -> %s", oldNotAfter, info.NotAfter), userCred)
+	return nil
+}
+
+// 解析新证书, 返回替换证书内容后的副本
+func (lbcert *SLoadbalancerCertificate) getRenewedCertificate(certificate, privateKey string) (*SLoadbalancerCertificate, error) {
+	info, err := parseLoadbalancerCertificate(certificate, privateKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parseLoadbalancerCertificate")
+	}
+	if info.Fingerprint == lbcert.Fingerprint {
+		return nil, errors.Errorf("renewed certificate is the same as current one")
+	}
+	renewed := *lbcert
+	renewed.SCertificateResourceBase = *info
+	return &renewed, nil
+}
+
+// 将新证书下发到已同步到云上的证书缓存, 未同步到云上的缓存跳过
+func syncRenewedCertificateCaches(caches []SCachedLoadbalancerCertificate, sync func(cache *SCachedLoadbalancerCertificate) error) error {
+	errs := []error{}
+	for i := range caches {
+		if len(caches[i].ExternalId) == 0 {
+			continue
+		}
+		err := sync(&caches[i])
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "cache %s(%s)", caches[i].Name, caches[i].Id))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func (lbcert *SCachedLoadbalancerCertificate) syncRenewedCertificate(ctx context.Context, userCred mcclient.TokenCredential, cert *SLoadbalancerCertificate) error {
+	iRegion, err := lbcert.GetIRegion(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "GetIRegion")
+	}
+	iCert, err := iRegion.GetILoadBalancerCertificateById(lbcert.ExternalId)
+	if err != nil {
+		return errors.Wrapf(err, "GetILoadBalancerCertificateById(%s)", lbcert.ExternalId)
+	}
+	err = iCert.Sync(lbcert.Name, cert.PrivateKey, cert.Certificate)
+	if err != nil {
+		logclient.AddSimpleActionLog(lbcert, logclient.ACT_UPDATE, err, userCred, false)
+		return errors.Wrapf(err, "Sync")
+	}
+	_, err = db.Update(lbcert, func() error {
+		lbcert.NotAfter = cert.NotAfter
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	logclient.AddSimpleActionLog(lbcert, logclient.ACT_UPDATE, "renew certificate", userCred, true)
+	return nil
+}
+
+// 自动续期即将过期且配置了续期钩子的证书
+func (manager *SLoadbalancerCertificateManager) AutoRenewCertificates(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := manager.Query().IsNotEmpty("renew_hook").NotEquals("status", api.LB_CERT_STATUS_RENEWING)
+	certs := []SLoadbalancerCertificate{}
+	err := db.FetchModelObjects(manager, q, &certs)
+	if err != nil {
+		log.Errorf("AutoRenewCertificates fetch certificates error: %v", err)
+		return
+	}
+	now := time.Now()
+	for i := range certs {
+		if !certs[i].isRenewDue(now) {
+			continue
+		}
+		err = certs[i].StartRenewTask(ctx, userCred, api.LoadbalancerCertificateRenewInput{}, "")
+		if err != nil {
+			log.Errorf("start renew task for certificate %s error: %v", certs[i].Name, err)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/compute/options"
+)
+
+func newTestLbCertificate(t *testing.T, cn string, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    notAfter.AddDate(0, -3, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	priv := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return string(cert), string(priv)
+}
+
+func TestValidateLoadbalancerCertificateRenewHookUrl(t *testing.T) {
+	for _, c := range []struct {
+		url string
+		ok  bool
+	}{
+		{"https://hook.example.com/renew", true},
+		{"http://8.8.8.8:8080/renew", true},
+		{"ftp://hook.example.com/renew", false},
+		{"https:///renew", false},
+		{"http://localhost/renew", false},
+		{"http://127.0.0.1/renew", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://10.0.0.1/renew", false},
+		{"http://192.168.1.1/renew", false},
+		{"http://[::1]/renew", false},
+	} {
+		err := validateLoadbalancerCertificateRenewHookUrl(c.url)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v, got %v", c.url, c.ok, err)
+		}
+	}
+}
+
+func TestLbCertWebhookRenewerDial(t *testing.T) {
+	lookup := lookupGuardedDialIPs
+	defer func() {
+		lookupGuardedDialIPs = lookup
+	}()
+
+	cert, key := newTestLbCertificate(t, "www.example.com", time.Now().AddDate(1, 0, 0))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, jsonutils.Marshal(map[string]string{"certificate": cert, "private_key": key}).String())
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	resolved := map[string][]string{
+		"metadata.example.com": {"169.254.169.254"},
+		"internal.example.com": {"8.8.8.8", "10.0.0.1"},
+		"hook.example.com":     {"127.0.0.1"},
+	}
+	lookupGuardedDialIPs = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		addrs, ok := resolved[host]
+		if !ok {
+			return nil, errors.ErrNotFound
+		}
+		ret := []net.IPAddr{}
+		for _, addr := range addrs {
+			ret = append(ret, net.IPAddr{IP: net.ParseIP(addr)})
+		}
+		return ret, nil
+	}
+
+	lbcert := &SLoadbalancerCertificate{}
+	for _, host := range []string{"metadata.example.com", "internal.example.com", "hook.example.com"} {
+		lbcert.RenewHookUrl = fmt.Sprintf("http://%s:%s/renew", host, port)
+		_, _, err := (&sLbCertWebhookRenewer{}).Renew(context.Background(), lbcert)
+		if err == nil {
+			t.Errorf("%s: want dial rejected", host)
+		}
+	}
+
+	// 仅放行测试服务所在的本机地址
+	renewer := &sLbCertWebhookRenewer{
+		allowAddr: func(ip net.IP) bool {
+			return ip.IsLoopback()
+		},
+	}
+	gotCert, gotKey, err := renewer.Renew(context.Background(), lbcert)
+	if err != nil {
+		t.Fatalf("Renew: %v", err)
+	}
+	if gotCert != cert || gotKey != key {
+		t.Errorf("unexpected renewed certificate")
+	}
+}
+
+func TestLbCertificateIsRenewDue(t *testing.T) {
+	days := options.Options.LbCertificateRenewBeforeDays
+	defer func() {
+		options.Options.LbCertificateRenewBeforeDays = days
+	}()
+	options.Options.LbCertificateRenewBeforeDays = 30
+
+	now := time.Now()
+	hook := api.LB_CERT_RENEW_HOOK_WEBHOOK
+	for _, c := range []struct {
+		name            string
+		hook            string
+		renewBeforeDays int
+		expireInDays    int
+		expect          bool
+	}{
+		{"no hook", "", 0, 10, false},
+		{"default days not due", hook, 0, 40, false},
+		{"default days due", hook, 0, 10, true},
+		{"expired", hook, 0, -1, true},
+		{"custom days not due", hook, 7, 10, false},
+		{"custom days due", hook, 7, 5, true},
+	} {
+		cert := SLoadbalancerCertificate{RenewHook: c.hook, RenewBeforeDays: c.renewBeforeDays}
+		cert.NotAfter = now.AddDate(0, 0, c.expireInDays)
+		if got := cert.isRenewDue(now); got != c.expect {
+			t.Errorf("%s: want %v got %v", c.name, c.expect, got)
+		}
+	}
+	if (&SLoadbalancerCertificate{RenewHook: hook}).isRenewDue(now) {
+		t.Errorf("want certificate without expiry never due")
+	}
+
+	expireBefore := getLbCertificateExpireBefore(now, 30)
+	if !now.AddDate(0, 0, 29).Before(expireBefore) || now.AddDate(0, 0, 31).Before(expireBefore) {
+		t.Errorf("unexpected expire before %s", expireBefore)
+	}
+}
+
+func TestGetRenewedCertificate(t *testing.T) {
+	cert, key := newTestLbCertificate(t, "www.example.com", time.Now().AddDate(0, 0, 10))
+	lbcert := &SLoadbalancerCertificate{}
+	lbcert.Name = "cert"
+	renewed, err := lbcert.getRenewedCertificate(cert, key)
+	if err != nil {
+		t.Fatalf("getRenewedCertificate: %v", err)
+	}
+	if renewed.Name != "cert" || renewed.CommonName != "www.example.com" || renewed.Certificate != cert {
+		t.Errorf("unexpected renewed certificate %s %s", renewed.Name, renewed.CommonName)
+	}
+	if len(lbcert.Certificate) > 0 {
+		t.Errorf("want original certificate untouched")
+	}
+
+	lbcert.Fingerprint = renewed.Fingerprint
+	if _, err := lbcert.getRenewedCertificate(cert, key); err == nil {
+		t.Errorf("want error on same certificate")
+	}
+	otherCert, _ := newTestLbCertificate(t, "www.example.com", time.Now().AddDate(1, 0, 0))
+	if _, err := lbcert.getRenewedCertificate(otherCert, key); err == nil {
+		t.Errorf("want error on mismatched private key")
+	}
+}
+
+func TestSyncRenewedCertificateCaches(t *testing.T) {
+	caches := make([]SCachedLoadbalancerCertificate, 3)
+	caches[0].Id, caches[0].ExternalId = "c1", "ext-1"
+	caches[1].Id = "c2"
+	caches[2].Id, caches[2].ExternalId = "c3", "ext-3"
+
+	synced := []string{}
+	err := syncRenewedCertificateCaches(caches, func(cache *SCachedLoadbalancerCertificate) error {
+		synced = append(synced, cache.Id)
+		if cache.Id == "c3" {
+			return errors.ErrTimeout
+		}
+		return nil
+	})
+	if len(synced) != 2 || synced[0] != "c1" || synced[1] != "c3" {
+		t.Errorf("want only caches on cloud synced, got %v", synced)
+	}
+	if err == nil || errors.Cause(err) != errors.ErrTimeout {
+		t.Errorf("want sync error reported, got %v", err)
+	}
+
+	err = syncRenewedCertificateCaches(caches[:2], func(cache *SCachedLoadbalancerCertificate) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+}
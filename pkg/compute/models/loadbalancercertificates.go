@@ -26,6 +26,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"strings"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -69,6 +70,13 @@ type SLoadbalancerCertificate struct {
 	db.SExternalizedResourceBase
 
 	db.SCertificateResourceBase
+
+	// 证书续期钩子类型
+	RenewHook string `width:"16" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"user"`
+	// 证书续期钩子地址
+	RenewHookUrl string `width:"256" charset:"utf8" nullable:"true" list:"user" create:"admin_optional" update:"admin"`
+	// 证书过期前多少天自动续期
+	RenewBeforeDays int `nullable:"false" default:"0" list:"user" create:"optional" update:"user"`
 }
 
 func (lbcert *SLoadbalancerCertificate) GetCachedCerts() ([]SCachedLoadbalancerCertificate, error) {
@@ -87,6 +95,20 @@ func (lbcert *SLoadbalancerCertificate) ValidateUpdateData(ctx context.Context,
 	if err != nil {
 		return nil, errors.Wrap(err, "SVirtualResourceBase.ValidateUpdateData")
 	}
+	hook, url := lbcert.RenewHook, lbcert.RenewHookUrl
+	if input.RenewHook != nil {
+		hook = *input.RenewHook
+	}
+	if input.RenewHookUrl != nil {
+		url = *input.RenewHookUrl
+		if url != lbcert.RenewHookUrl && !userCred.HasSystemAdminPrivilege() {
+			return nil, httperrors.NewForbiddenError("only system admin can set renew_hook_url")
+		}
+	}
+	err = validateLoadbalancerCertificateRenewHook(hook, url, input.RenewBeforeDays)
+	if err != nil {
+		return nil, err
+	}
 	return input, nil
 }
 
@@ -203,6 +225,9 @@ func (man *SLoadbalancerCertificateManager) ListItemFilter(
 	if len(query.SubjectAlternativeNames) > 0 {
 		q = q.In("subject_alternative_names", query.SubjectAlternativeNames)
 	}
+	if query.ExpireWithinDays != nil {
+		q = q.LT("not_after", getLbCertificateExpireBefore(time.Now(), *query.ExpireWithinDays))
+	}
 
 	return q, nil
 }
@@ -242,28 +267,31 @@ func (man *SLoadbalancerCertificateManager) ValidateCreateData(ctx context.Conte
 	if len(input.PrivateKey) == 0 {
 		return nil, httperrors.NewMissingParameterError("private_key")
 	}
-	_, err := tls.X509KeyPair([]byte(input.Certificate), []byte(input.PrivateKey))
+	info, err := parseLoadbalancerCertificate(input.Certificate, input.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
-	p, _ := pem.Decode([]byte(input.Certificate))
-	c, err := x509.ParseCertificate(p.Bytes)
+	input.SubjectAlternativeNames = info.SubjectAlternativeNames
+	input.SignatureAlgorithm = info.SignatureAlgorithm
+	input.Fingerprint = info.Fingerprint
+	input.CommonName = info.CommonName
+	input.NotBefore = info.NotBefore
+	input.NotAfter = info.NotAfter
+	input.PublicKeyBitLen = info.PublicKeyBitLen
+	hook, url := "", ""
+	if input.RenewHook != nil {
+		hook = *input.RenewHook
+	}
+	if input.RenewHookUrl != nil {
+		url = *input.RenewHookUrl
+		if len(url) > 0 && !userCred.HasSystemAdminPrivilege() {
+			return nil, httperrors.NewForbiddenError("only system admin can set renew_hook_url")
+		}
+	}
+	err = validateLoadbalancerCertificateRenewHook(hook, url, input.RenewBeforeDays)
 	if err != nil {
 		return nil, err
 	}
-	input.SubjectAlternativeNames = strings.Join(c.DNSNames, " ")
-	input.SignatureAlgorithm = c.SignatureAlgorithm.String()
-	d := sha256.Sum256(c.Raw)
-	input.Fingerprint = api.LB_TLS_CERT_FINGERPRINT_ALGO_SHA256 + ":" + hex.EncodeToString(d[:])
-	input.CommonName = c.Subject.CommonName
-	input.NotBefore = c.NotBefore
-	input.NotAfter = c.NotAfter
-	switch pub := c.PublicKey.(type) {
-	case *rsa.PublicKey:
-		input.PublicKeyBitLen = pub.N.BitLen()
-	case *ecdsa.PublicKey:
-		input.PublicKeyBitLen = pub.X.BitLen()
-	}
 	input.SharableVirtualResourceCreateInput, err = man.SSharableVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.SharableVirtualResourceCreateInput)
 	if err != nil {
 		return nil, err
@@ -272,6 +300,37 @@ func (man *SLoadbalancerCertificateManager) ValidateCreateData(ctx context.Conte
 	return input, nil
 }
 
+// 解析证书内容并校验与私钥是否匹配
+func parseLoadbalancerCertificate(certificate, privateKey string) (*db.SCertificateResourceBase, error) {
+	_, err := tls.X509KeyPair([]byte(certificate), []byte(privateKey))
+	if err != nil {
+		return nil, err
+	}
+	p, _ := pem.Decode([]byte(certificate))
+	c, err := x509.ParseCertificate(p.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	info := &db.SCertificateResourceBase{
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+	}
+	info.SubjectAlternativeNames = strings.Join(c.DNSNames, " ")
+	info.SignatureAlgorithm = c.SignatureAlgorithm.String()
+	d := sha256.Sum256(c.Raw)
+	info.Fingerprint = api.LB_TLS_CERT_FINGERPRINT_ALGO_SHA256 + ":" + hex.EncodeToString(d[:])
+	info.CommonName = c.Subject.CommonName
+	info.NotBefore = c.NotBefore
+	info.NotAfter = c.NotAfter
+	switch pub := c.PublicKey.(type) {
+	case *rsa.PublicKey:
+		info.PublicKeyBitLen = pub.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.PublicKeyBitLen = pub.X.BitLen()
+	}
+	return info, nil
+}
+
 func (man *SLoadbalancerCertificateManager) InitializeData() error {
 	_, err := sqlchemy.GetDB().Exec(
 		fmt.Sprintf(
@@ -120,6 +120,9 @@ type SLoadbalancer struct {
 
 	// LB的其他配置信息
 	LBInfo jsonutils.JSONObject `charset:"utf8" length:"medium" nullable:"true" list:"user" update:"admin" create:"admin_optional" json:"lb_info"`
+
+	// 创建此负载均衡的蓝图ID
+	BlueprintId string `width:"36" charset:"ascii" nullable:"true" index:"true" list:"user" json:"blueprint_id"`
 }
 
 // 负载均衡实例列表
@@ -281,6 +284,9 @@ func (man *SLoadbalancerManager) ListItemFilter(
 	if len(query.LoadbalancerSpec) > 0 {
 		q = q.In("loadbalancer_spec", query.LoadbalancerSpec)
 	}
+	if len(query.BlueprintId) > 0 {
+		q = q.Equals("blueprint_id", query.BlueprintId)
+	}
 
 	return q, nil
 }
@@ -364,6 +370,11 @@ func (man *SLoadbalancerManager) ValidateCreateData(
 	query jsonutils.JSONObject,
 	input *api.LoadbalancerCreateInput,
 ) (*api.LoadbalancerCreateInput, error) {
+	var err error
+	input.Metadata, err = TagPolicyManager.validateCreateTags(ctx, ownerId, input.Metadata)
+	if err != nil {
+		return nil, err
+	}
 	if len(input.NetworkId) > 0 {
 		networks := strings.Split(input.NetworkId, ",")
 		if len(networks) > 1 {
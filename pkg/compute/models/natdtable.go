@@ -324,8 +324,12 @@ func (manager *SNatDEntryManager) FetchCustomizeColumns(
 }
 
 func (self *SNatDEntry) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.StartCreateDNatTask(ctx, userCred, "")
+}
+
+func (self *SNatDEntry) StartCreateDNatTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
 	var err = func() error {
-		task, err := taskman.TaskManager.NewTask(ctx, "SNatDEntryCreateTask", self, userCred, nil, "", "", nil)
+		task, err := taskman.TaskManager.NewTask(ctx, "SNatDEntryCreateTask", self, userCred, nil, parentTaskId, "", nil)
 		if err != nil {
 			return errors.Wrapf(err, "NewTask")
 		}
@@ -333,18 +337,19 @@ func (self *SNatDEntry) PostCreate(ctx context.Context, userCred mcclient.TokenC
 	}()
 	if err != nil {
 		self.SetStatus(userCred, api.NAT_STATUS_CREATE_FAILED, err.Error())
-		return
+		return err
 	}
 	self.SetStatus(userCred, api.NAT_STATUS_ALLOCATE, "")
+	return nil
 }
 
 func (self *SNatDEntry) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
-	return self.StartDeleteDNatTask(ctx, userCred)
+	return self.StartDeleteDNatTask(ctx, userCred, "")
 }
 
-func (self *SNatDEntry) StartDeleteDNatTask(ctx context.Context, userCred mcclient.TokenCredential) error {
+func (self *SNatDEntry) StartDeleteDNatTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
 	var err = func() error {
-		task, err := taskman.TaskManager.NewTask(ctx, "SNatDEntryDeleteTask", self, userCred, nil, "", "", nil)
+		task, err := taskman.TaskManager.NewTask(ctx, "SNatDEntryDeleteTask", self, userCred, nil, parentTaskId, "", nil)
 		if err != nil {
 			return errors.Wrapf(err, "NewTask")
 		}
@@ -0,0 +1,404 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/regutils"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+const (
+	NAT_RULE_TYPE_DNAT = "dnat"
+	NAT_RULE_TYPE_SNAT = "snat"
+)
+
+func natDRuleExternalKey(externalIp string, externalPort int) string {
+	return fmt.Sprintf("%s:%d", externalIp, externalPort)
+}
+
+func natDRuleKey(rule api.NatDRuleInput) string {
+	return fmt.Sprintf("%s/%s/%s:%d", natDRuleExternalKey(rule.ExternalIp, rule.ExternalPort), strings.ToLower(rule.IpProtocol), rule.InternalIp, rule.InternalPort)
+}
+
+func natSRuleKey(rule api.NatSRuleInput) string {
+	return fmt.Sprintf("%s/%s/%s", rule.Ip, rule.NetworkId, rule.SourceCidr)
+}
+
+func natSRuleSourceKey(rule api.NatSRuleInput) string {
+	if len(rule.SourceCidr) > 0 {
+		return rule.SourceCidr
+	}
+	return rule.NetworkId
+}
+
+func (self *SNatDEntry) toRuleInput() api.NatDRuleInput {
+	return api.NatDRuleInput{
+		ExternalIp:   self.ExternalIP,
+		ExternalPort: self.ExternalPort,
+		InternalIp:   self.InternalIP,
+		InternalPort: self.InternalPort,
+		IpProtocol:   strings.ToLower(self.IpProtocol),
+	}
+}
+
+func (self *SNatSEntry) toRuleInput() api.NatSRuleInput {
+	return api.NatSRuleInput{
+		Ip:         self.IP,
+		NetworkId:  self.NetworkId,
+		SourceCidr: self.SourceCIDR,
+	}
+}
+
+// 检查DNAT规则本身的合法性及规则间的冲突, 同一公网IP和端口只能有一条规则(与单条创建时的限制一致)
+func checkNatDRules(rules []api.NatDRuleInput) error {
+	externals := map[string]int{}
+	for i := range rules {
+		rule := rules[i]
+		if rule.ExternalPort < 1 || rule.ExternalPort > 65535 {
+			return httperrors.NewInputParameterError("dnat rule %d: invalid external port %d", i, rule.ExternalPort)
+		}
+		if rule.InternalPort < 1 || rule.InternalPort > 65535 {
+			return httperrors.NewInputParameterError("dnat rule %d: invalid internal port %d", i, rule.InternalPort)
+		}
+		if !regutils.MatchIPAddr(rule.InternalIp) {
+			return httperrors.NewInputParameterError("dnat rule %d: invalid internal ip address: %s", i, rule.InternalIp)
+		}
+		if !utils.IsInStringArray(strings.ToLower(rule.IpProtocol), []string{"tcp", "udp", "any"}) {
+			return httperrors.NewInputParameterError("dnat rule %d: invalid protocol %s", i, rule.IpProtocol)
+		}
+		key := natDRuleExternalKey(rule.ExternalIp, rule.ExternalPort)
+		if j, ok := externals[key]; ok {
+			return httperrors.NewInputParameterError("dnat rule %d conflicts with rule %d: same external ip and port %s", i, j, key)
+		}
+		externals[key] = i
+	}
+	return nil
+}
+
+// 检查SNAT规则间的冲突, 同一源网段或源子网只能有一条规则
+func checkNatSRules(rules []api.NatSRuleInput) error {
+	sources := map[string]int{}
+	for i := range rules {
+		if len(rules[i].SourceCidr) == 0 && len(rules[i].NetworkId) == 0 {
+			return httperrors.NewInputParameterError("snat rule %d: missing network_id or source_cidr", i)
+		}
+		if len(rules[i].SourceCidr) > 0 && len(rules[i].NetworkId) > 0 {
+			return httperrors.NewInputParameterError("snat rule %d: source_cidr and network_id conflict", i)
+		}
+		key := natSRuleSourceKey(rules[i])
+		if j, ok := sources[key]; ok {
+			return httperrors.NewInputParameterError("snat rule %d conflicts with rule %d: same source %s", i, j, key)
+		}
+		sources[key] = i
+	}
+	return nil
+}
+
+// 对比现有DNAT规则与期望规则, 返回需要新增和删除的规则
+func diffNatDRules(current []SNatDEntry, desired []api.NatDRuleInput) ([]api.NatDRuleInput, []SNatDEntry, int) {
+	wanted := map[string]bool{}
+	for i := range desired {
+		wanted[natDRuleKey(desired[i])] = true
+	}
+	exists := map[string]bool{}
+	removed := []SNatDEntry{}
+	for i := range current {
+		key := natDRuleKey(current[i].toRuleInput())
+		if wanted[key] && !exists[key] {
+			exists[key] = true
+			continue
+		}
+		removed = append(removed, current[i])
+	}
+	added := []api.NatDRuleInput{}
+	for i := range desired {
+		if !exists[natDRuleKey(desired[i])] {
+			added = append(added, desired[i])
+		}
+	}
+	return added, removed, len(exists)
+}
+
+// 对比现有SNAT规则与期望规则, 返回需要新增和删除的规则
+func diffNatSRules(current []SNatSEntry, desired []api.NatSRuleInput) ([]api.NatSRuleInput, []SNatSEntry, int) {
+	wanted := map[string]bool{}
+	for i := range desired {
+		wanted[natSRuleKey(desired[i])] = true
+	}
+	exists := map[string]bool{}
+	removed := []SNatSEntry{}
+	for i := range current {
+		key := natSRuleKey(current[i].toRuleInput())
+		if wanted[key] && !exists[key] {
+			exists[key] = true
+			continue
+		}
+		removed = append(removed, current[i])
+	}
+	added := []api.NatSRuleInput{}
+	for i := range desired {
+		if !exists[natSRuleKey(desired[i])] {
+			added = append(added, desired[i])
+		}
+	}
+	return added, removed, len(exists)
+}
+
+func (self *SNatGateway) validateRuleEip(userCred mcclient.TokenCredential, eipId *string) (*SElasticip, error) {
+	if len(*eipId) == 0 {
+		return nil, httperrors.NewMissingParameterError("eip")
+	}
+	_eip, err := validators.ValidateModel(userCred, ElasticipManager, eipId)
+	if err != nil {
+		return nil, err
+	}
+	eip := _eip.(*SElasticip)
+	if len(eip.AssociateId) > 0 && eip.AssociateId != self.Id {
+		return nil, httperrors.NewInputParameterError("eip %s has been binding to another instance", eip.Name)
+	}
+	return eip, nil
+}
+
+// 批量调整DNAT/SNAT规则, 以传入的规则为期望状态, 与现有规则对比后先删除多余规则再创建缺少的规则
+func (self *SNatGateway) PerformApplyRules(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.NatGatewayApplyRulesInput) (*api.NatGatewayApplyRulesOutput, error) {
+	if len(input.RuleTypes) == 0 {
+		return nil, httperrors.NewMissingParameterError("rule_types")
+	}
+	for _, ruleType := range input.RuleTypes {
+		if !utils.IsInStringArray(ruleType, []string{NAT_RULE_TYPE_DNAT, NAT_RULE_TYPE_SNAT}) {
+			return nil, httperrors.NewInputParameterError("invalid rule type %s", ruleType)
+		}
+	}
+	if !input.DryRun && self.Status != api.NAT_STAUTS_AVAILABLE {
+		return nil, httperrors.NewInvalidStatusError("can not apply rules in status %s", self.Status)
+	}
+
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	output := &api.NatGatewayApplyRulesOutput{}
+	dnatRemoved, snatRemoved := []SNatDEntry{}, []SNatSEntry{}
+	if utils.IsInStringArray(NAT_RULE_TYPE_DNAT, input.RuleTypes) {
+		for i := range input.DnatRules {
+			eip, err := self.validateRuleEip(userCred, &input.DnatRules[i].Eip)
+			if err != nil {
+				return nil, err
+			}
+			input.DnatRules[i].ExternalIp = eip.IpAddr
+			input.DnatRules[i].IpProtocol = strings.ToLower(input.DnatRules[i].IpProtocol)
+		}
+		err := checkNatDRules(input.DnatRules)
+		if err != nil {
+			return nil, err
+		}
+		dtable, err := self.GetDTable()
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		output.DnatAdded, dnatRemoved, output.DnatUnchanged = diffNatDRules(dtable, input.DnatRules)
+		for i := range dnatRemoved {
+			output.DnatRemoved = append(output.DnatRemoved, dnatRemoved[i].toRuleInput())
+		}
+	}
+	if utils.IsInStringArray(NAT_RULE_TYPE_SNAT, input.RuleTypes) {
+		for i := range input.SnatRules {
+			eip, err := self.validateRuleEip(userCred, &input.SnatRules[i].Eip)
+			if err != nil {
+				return nil, err
+			}
+			input.SnatRules[i].Ip = eip.IpAddr
+			err = checkNatSRules(input.SnatRules[i : i+1])
+			if err != nil {
+				return nil, err
+			}
+			err = self.validateSNatSource(userCred, &input.SnatRules[i].NetworkId, input.SnatRules[i].SourceCidr)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := checkNatSRules(input.SnatRules)
+		if err != nil {
+			return nil, err
+		}
+		stable, err := self.GetSTable()
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		output.SnatAdded, snatRemoved, output.SnatUnchanged = diffNatSRules(stable, input.SnatRules)
+		for i := range snatRemoved {
+			output.SnatRemoved = append(output.SnatRemoved, snatRemoved[i].toRuleInput())
+		}
+	}
+	if input.DryRun {
+		return output, nil
+	}
+
+	params := jsonutils.NewDict()
+	dnatDelete, snatDelete, dnatCreate, snatCreate := []string{}, []string{}, []string{}, []string{}
+	for i := range dnatRemoved {
+		dnatDelete = append(dnatDelete, dnatRemoved[i].Id)
+	}
+	for i := range snatRemoved {
+		snatDelete = append(snatDelete, snatRemoved[i].Id)
+	}
+	for i := range output.DnatAdded {
+		dnat, err := self.newDNatEntry(ctx, userCred, output.DnatAdded[i])
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		dnatCreate = append(dnatCreate, dnat.Id)
+	}
+	for i := range output.SnatAdded {
+		snat, err := self.newSNatEntry(ctx, userCred, output.SnatAdded[i])
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		snatCreate = append(snatCreate, snat.Id)
+	}
+	params.Set("dnat_delete", jsonutils.NewStringArray(dnatDelete))
+	params.Set("snat_delete", jsonutils.NewStringArray(snatDelete))
+	params.Set("dnat_create", jsonutils.NewStringArray(dnatCreate))
+	params.Set("snat_create", jsonutils.NewStringArray(snatCreate))
+	// 失败时据此清理未下发成功的规则
+	params.Set("dnat_created", jsonutils.NewStringArray(dnatCreate))
+	params.Set("snat_created", jsonutils.NewStringArray(snatCreate))
+	err := self.StartApplyRulesTask(ctx, userCred, params, "")
+	if err != nil {
+		self.CleanupUnappliedRules(ctx, userCred, dnatCreate, snatCreate)
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return output, nil
+}
+
+func (self *SNatGateway) StartApplyRulesTask(ctx context.Context, userCred mcclient.TokenCredential, params *jsonutils.JSONDict, parentTaskId string) error {
+	var err = func() error {
+		task, err := taskman.TaskManager.NewTask(ctx, "NatGatewayApplyRulesTask", self, userCred, params, parentTaskId, "", nil)
+		if err != nil {
+			return errors.Wrapf(err, "NewTask")
+		}
+		return task.ScheduleRun(nil)
+	}()
+	if err != nil {
+		return err
+	}
+	// 规则下发期间网关处于配置中, 避免并发调整
+	self.SetStatus(userCred, api.NAT_STATUS_DEPLOYING, "apply rules")
+	return nil
+}
+
+// 删除未成功下发到云上的新增规则记录
+func (self *SNatGateway) CleanupUnappliedRules(ctx context.Context, userCred mcclient.TokenCredential, dnatIds, snatIds []string) {
+	for _, id := range dnatIds {
+		obj, err := NatDEntryManager.FetchById(id)
+		if err != nil {
+			continue
+		}
+		dnat := obj.(*SNatDEntry)
+		if len(dnat.ExternalId) > 0 || dnat.Status == api.NAT_STAUTS_AVAILABLE {
+			continue
+		}
+		err = dnat.RealDelete(ctx, userCred)
+		if err != nil {
+			log.Errorf("cleanup dnat %s error: %v", dnat.Id, err)
+		}
+	}
+	for _, id := range snatIds {
+		obj, err := NatSEntryManager.FetchById(id)
+		if err != nil {
+			continue
+		}
+		snat := obj.(*SNatSEntry)
+		if len(snat.ExternalId) > 0 || snat.Status == api.NAT_STAUTS_AVAILABLE {
+			continue
+		}
+		err = snat.RealDelete(ctx, userCred)
+		if err != nil {
+			log.Errorf("cleanup snat %s error: %v", snat.Id, err)
+		}
+	}
+}
+
+func (self *SNatGateway) newDNatEntry(ctx context.Context, userCred mcclient.TokenCredential, rule api.NatDRuleInput) (*SNatDEntry, error) {
+	dnat := &SNatDEntry{}
+	dnat.SetModelManager(NatDEntryManager, dnat)
+	dnat.NatgatewayId = self.Id
+	dnat.DomainId = self.DomainId
+	dnat.Status = api.NAT_STATUS_ALLOCATE
+	dnat.ExternalIP = rule.ExternalIp
+	dnat.ExternalPort = rule.ExternalPort
+	dnat.InternalIP = rule.InternalIp
+	dnat.InternalPort = rule.InternalPort
+	dnat.IpProtocol = rule.IpProtocol
+
+	var err = func() error {
+		lockman.LockRawObject(ctx, NatDEntryManager.Keyword(), "name")
+		defer lockman.ReleaseRawObject(ctx, NatDEntryManager.Keyword(), "name")
+
+		var err error
+		dnat.Name, err = db.GenerateName(ctx, NatDEntryManager, self.GetOwnerId(), fmt.Sprintf("%s-dnat", self.Name))
+		if err != nil {
+			return err
+		}
+		return NatDEntryManager.TableSpec().Insert(ctx, dnat)
+	}()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Insert")
+	}
+	db.OpsLog.LogEvent(dnat, db.ACT_CREATE, dnat.GetShortDesc(ctx), userCred)
+	return dnat, nil
+}
+
+func (self *SNatGateway) newSNatEntry(ctx context.Context, userCred mcclient.TokenCredential, rule api.NatSRuleInput) (*SNatSEntry, error) {
+	snat := &SNatSEntry{}
+	snat.SetModelManager(NatSEntryManager, snat)
+	snat.NatgatewayId = self.Id
+	snat.DomainId = self.DomainId
+	snat.Status = api.NAT_STATUS_ALLOCATE
+	snat.IP = rule.Ip
+	snat.NetworkId = rule.NetworkId
+	snat.SourceCIDR = rule.SourceCidr
+
+	var err = func() error {
+		lockman.LockRawObject(ctx, NatSEntryManager.Keyword(), "name")
+		defer lockman.ReleaseRawObject(ctx, NatSEntryManager.Keyword(), "name")
+
+		var err error
+		snat.Name, err = db.GenerateName(ctx, NatSEntryManager, self.GetOwnerId(), fmt.Sprintf("%s-snat", self.Name))
+		if err != nil {
+			return err
+		}
+		return NatSEntryManager.TableSpec().Insert(ctx, snat)
+	}()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Insert")
+	}
+	db.OpsLog.LogEvent(snat, db.ACT_CREATE, snat.GetShortDesc(ctx), userCred)
+	return snat, nil
+}
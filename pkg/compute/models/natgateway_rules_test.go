@@ -0,0 +1,99 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestCheckNatDRules(t *testing.T) {
+	rule := func(ip string, port int, proto string) api.NatDRuleInput {
+		return api.NatDRuleInput{ExternalIp: ip, ExternalPort: port, InternalIp: "10.0.0.1", InternalPort: 80, IpProtocol: proto}
+	}
+	cases := []struct {
+		name  string
+		rules []api.NatDRuleInput
+		ok    bool
+	}{
+		{"empty", nil, true},
+		{"distinct", []api.NatDRuleInput{rule("1.1.1.1", 80, "tcp"), rule("1.1.1.1", 81, "udp")}, true},
+		{"same port", []api.NatDRuleInput{rule("1.1.1.1", 80, "tcp"), rule("1.1.1.1", 80, "udp")}, false},
+		{"invalid port", []api.NatDRuleInput{rule("1.1.1.1", 65536, "tcp")}, false},
+		{"invalid protocol", []api.NatDRuleInput{rule("1.1.1.1", 80, "icmp")}, false},
+	}
+	for _, c := range cases {
+		err := checkNatDRules(c.rules)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v got %v", c.name, c.ok, err)
+		}
+	}
+}
+
+func TestCheckNatSRules(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules []api.NatSRuleInput
+		ok    bool
+	}{
+		{"distinct", []api.NatSRuleInput{{NetworkId: "net1"}, {SourceCidr: "10.0.0.0/24"}}, true},
+		{"same network", []api.NatSRuleInput{{NetworkId: "net1"}, {NetworkId: "net1"}}, false},
+		{"same cidr", []api.NatSRuleInput{{SourceCidr: "10.0.0.0/24"}, {SourceCidr: "10.0.0.0/24"}}, false},
+		{"missing source", []api.NatSRuleInput{{}}, false},
+		{"both source", []api.NatSRuleInput{{NetworkId: "net1", SourceCidr: "10.0.0.0/24"}}, false},
+	}
+	for _, c := range cases {
+		err := checkNatSRules(c.rules)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v got %v", c.name, c.ok, err)
+		}
+	}
+}
+
+func TestDiffNatDRules(t *testing.T) {
+	current := []SNatDEntry{
+		{ExternalIP: "1.1.1.1", ExternalPort: 80, InternalIP: "10.0.0.1", InternalPort: 80, IpProtocol: "TCP"},
+		{ExternalIP: "1.1.1.1", ExternalPort: 22, InternalIP: "10.0.0.1", InternalPort: 22, IpProtocol: "tcp"},
+	}
+	desired := []api.NatDRuleInput{
+		{ExternalIp: "1.1.1.1", ExternalPort: 80, InternalIp: "10.0.0.1", InternalPort: 80, IpProtocol: "tcp"},
+		{ExternalIp: "1.1.1.1", ExternalPort: 22, InternalIp: "10.0.0.2", InternalPort: 22, IpProtocol: "tcp"},
+	}
+	added, removed, unchanged := diffNatDRules(current, desired)
+	if unchanged != 1 || len(added) != 1 || len(removed) != 1 {
+		t.Fatalf("want 1 added 1 removed 1 unchanged, got %d %d %d", len(added), len(removed), unchanged)
+	}
+	if added[0].InternalIp != "10.0.0.2" || removed[0].InternalIP != "10.0.0.1" || removed[0].ExternalPort != 22 {
+		t.Errorf("unexpected diff: added %v removed %v", added, removed)
+	}
+}
+
+func TestDiffNatSRules(t *testing.T) {
+	current := []SNatSEntry{
+		{IP: "1.1.1.1"},
+		{IP: "1.1.1.1", SourceCIDR: "10.0.0.0/24"},
+		{IP: "1.1.1.1", SourceCIDR: "10.0.0.0/24"},
+	}
+	current[0].NetworkId = "net1"
+	desired := []api.NatSRuleInput{
+		{Ip: "1.1.1.1", SourceCidr: "10.0.0.0/24"},
+		{Ip: "2.2.2.2", NetworkId: "net1"},
+	}
+	added, removed, unchanged := diffNatSRules(current, desired)
+	if unchanged != 1 || len(added) != 1 || len(removed) != 2 {
+		t.Fatalf("want 1 added 2 removed 1 unchanged, got %d %d %d", len(added), len(removed), unchanged)
+	}
+}
@@ -183,36 +183,9 @@ func (man *SNatSEntryManager) ValidateCreateData(ctx context.Context, userCred m
 	}
 	nat := _nat.(*SNatGateway)
 
-	if len(input.SourceCidr) > 0 {
-		cidr, err := netutils.NewIPV4Prefix(input.SourceCidr)
-		if err != nil {
-			return nil, httperrors.NewInputParameterError("input.SourceCidr")
-		}
-		vpc, err := nat.GetVpc()
-		if err != nil {
-			return nil, errors.Wrapf(err, "GetVpc")
-		}
-		vpcRange, err := netutils.NewIPV4Prefix(vpc.CidrBlock)
-		if err != nil {
-			return nil, errors.Wrapf(err, "vpc cidr %s", vpc.CidrBlock)
-		}
-
-		if !vpcRange.ToIPRange().ContainsRange(cidr.ToIPRange()) {
-			return nil, httperrors.NewInputParameterError("cidr %s is not in range vpc %s", input.SourceCidr, vpc.CidrBlock)
-		}
-	} else {
-		_network, err := validators.ValidateModel(userCred, NetworkManager, &input.NetworkId)
-		if err != nil {
-			return nil, err
-		}
-		network := _network.(*SNetwork)
-		vpc, _ := network.GetVpc()
-		if vpc == nil {
-			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "network.GetVpc"))
-		}
-		if vpc.Id != nat.VpcId {
-			return nil, httperrors.NewInputParameterError("network %s not in vpc %s", network.Name, vpc.Name)
-		}
+	err = nat.validateSNatSource(userCred, &input.NetworkId, input.SourceCidr)
+	if err != nil {
+		return nil, err
 	}
 
 	_eip, err := validators.ValidateModel(userCred, ElasticipManager, &input.Eip)
@@ -230,6 +203,42 @@ func (man *SNatSEntryManager) ValidateCreateData(ctx context.Context, userCred m
 	return input, nil
 }
 
+// 校验SNAT规则的源网段或源IP子网属于NAT网关所在的Vpc
+func (nat *SNatGateway) validateSNatSource(userCred mcclient.TokenCredential, networkId *string, sourceCidr string) error {
+	if len(sourceCidr) > 0 {
+		cidr, err := netutils.NewIPV4Prefix(sourceCidr)
+		if err != nil {
+			return httperrors.NewInputParameterError("input.SourceCidr")
+		}
+		vpc, err := nat.GetVpc()
+		if err != nil {
+			return errors.Wrapf(err, "GetVpc")
+		}
+		vpcRange, err := netutils.NewIPV4Prefix(vpc.CidrBlock)
+		if err != nil {
+			return errors.Wrapf(err, "vpc cidr %s", vpc.CidrBlock)
+		}
+
+		if !vpcRange.ToIPRange().ContainsRange(cidr.ToIPRange()) {
+			return httperrors.NewInputParameterError("cidr %s is not in range vpc %s", sourceCidr, vpc.CidrBlock)
+		}
+		return nil
+	}
+	_network, err := validators.ValidateModel(userCred, NetworkManager, networkId)
+	if err != nil {
+		return err
+	}
+	network := _network.(*SNetwork)
+	vpc, _ := network.GetVpc()
+	if vpc == nil {
+		return httperrors.NewGeneralError(errors.Wrapf(err, "network.GetVpc"))
+	}
+	if vpc.Id != nat.VpcId {
+		return httperrors.NewInputParameterError("network %s not in vpc %s", network.Name, vpc.Name)
+	}
+	return nil
+}
+
 func (manager *SNatSEntryManager) SyncNatSTable(ctx context.Context, userCred mcclient.TokenCredential, provider *SCloudprovider, nat *SNatGateway, extTable []cloudprovider.ICloudNatSEntry) compare.SyncResult {
 	syncOwnerId := provider.GetOwnerId()
 
@@ -414,8 +423,12 @@ func (manager *SNatSEntryManager) FetchCustomizeColumns(
 }
 
 func (self *SNatSEntry) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.StartCreateSNatTask(ctx, userCred, "")
+}
+
+func (self *SNatSEntry) StartCreateSNatTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
 	var err = func() error {
-		task, err := taskman.TaskManager.NewTask(ctx, "SNatSEntryCreateTask", self, userCred, nil, "", "", nil)
+		task, err := taskman.TaskManager.NewTask(ctx, "SNatSEntryCreateTask", self, userCred, nil, parentTaskId, "", nil)
 		if err != nil {
 			return errors.Wrapf(err, "NewTask")
 		}
@@ -423,18 +436,19 @@ func (self *SNatSEntry) PostCreate(ctx context.Context, userCred mcclient.TokenC
 	}()
 	if err != nil {
 		self.SetStatus(userCred, api.NAT_STATUS_CREATE_FAILED, err.Error())
-		return
+		return err
 	}
 	self.SetStatus(userCred, api.NAT_STATUS_ALLOCATE, "")
+	return nil
 }
 
 func (self *SNatSEntry) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
-	return self.StartDeleteSNatTask(ctx, userCred)
+	return self.StartDeleteSNatTask(ctx, userCred, "")
 }
 
-func (self *SNatSEntry) StartDeleteSNatTask(ctx context.Context, userCred mcclient.TokenCredential) error {
+func (self *SNatSEntry) StartDeleteSNatTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
 	var err = func() error {
-		task, err := taskman.TaskManager.NewTask(ctx, "SNatSEntryDeleteTask", self, userCred, nil, "", "", nil)
+		task, err := taskman.TaskManager.NewTask(ctx, "SNatSEntryDeleteTask", self, userCred, nil, parentTaskId, "", nil)
 		if err != nil {
 			return errors.Wrapf(err, "NewTask")
 		}
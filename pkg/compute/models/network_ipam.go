@@ -0,0 +1,234 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// 解析IP段, 格式为 start-end 或 cidr
+func parseNetworkIpRange(ipRange string) (netutils.IPV4AddrRange, error) {
+	if strings.Contains(ipRange, "/") {
+		prefix, err := netutils.NewIPV4Prefix(ipRange)
+		if err != nil {
+			return netutils.IPV4AddrRange{}, httperrors.NewInputParameterError("invalid ip range %s: %v", ipRange, err)
+		}
+		return prefix.ToIPRange(), nil
+	}
+	parts := strings.Split(ipRange, "-")
+	if len(parts) != 2 {
+		return netutils.IPV4AddrRange{}, httperrors.NewInputParameterError("invalid ip range %s", ipRange)
+	}
+	start, err := netutils.NewIPV4Addr(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return netutils.IPV4AddrRange{}, httperrors.NewInputParameterError("invalid ip range %s: %v", ipRange, err)
+	}
+	end, err := netutils.NewIPV4Addr(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return netutils.IPV4AddrRange{}, httperrors.NewInputParameterError("invalid ip range %s: %v", ipRange, err)
+	}
+	if start > end {
+		return netutils.IPV4AddrRange{}, httperrors.NewInputParameterError("invalid ip range %s: start after end", ipRange)
+	}
+	return netutils.NewIPV4AddrRange(start, end), nil
+}
+
+// 解析待预留的IP段, IP段须位于网络地址范围内, 且地址总数不超过上限
+func parseNetworkReserveIpRanges(netRange netutils.IPV4AddrRange, ipRanges []string) ([]netutils.IPV4AddrRange, error) {
+	ranges := []netutils.IPV4AddrRange{}
+	count := 0
+	for _, ipRange := range ipRanges {
+		r, err := parseNetworkIpRange(ipRange)
+		if err != nil {
+			return nil, err
+		}
+		if !netRange.ContainsRange(r) {
+			return nil, httperrors.NewInputParameterError("ip range %s not in network", ipRange)
+		}
+		count += r.AddressCount()
+		ranges = append(ranges, r)
+	}
+	if count > api.NETWORK_RESERVE_IP_RANGE_MAX {
+		return nil, httperrors.NewInputParameterError("too many addresses to reserve: %d > %d", count, api.NETWORK_RESERVE_IP_RANGE_MAX)
+	}
+	return ranges, nil
+}
+
+// 返回IP段中未被占用的IP及已被占用而跳过的IP, 重叠的IP段只计一次
+func getNetworkReservableIps(ranges []netutils.IPV4AddrRange, used map[string]bool) ([]string, []string) {
+	reservable, skipped := []string{}, []string{}
+	seen := map[string]bool{}
+	for _, r := range ranges {
+		for ip, n := r.StartIp(), r.AddressCount(); n > 0; ip, n = ip.StepUp(), n-1 {
+			ipstr := ip.String()
+			if seen[ipstr] {
+				continue
+			}
+			seen[ipstr] = true
+			if used[ipstr] {
+				skipped = append(skipped, ipstr)
+				continue
+			}
+			reservable = append(reservable, ipstr)
+		}
+	}
+	return reservable, skipped
+}
+
+// 预留IP段, 已被占用的IP会被跳过
+func (self *SNetwork) reserveIpRanges(ctx context.Context, userCred mcclient.TokenCredential, ipRanges []string, notes string, duration time.Duration, status string) (*api.NetworkReserveIpOutput, error) {
+	ranges, err := parseNetworkReserveIpRanges(self.getIPRange(), ipRanges)
+	if err != nil {
+		return nil, err
+	}
+
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	output := &api.NetworkReserveIpOutput{Reserved: []string{}}
+	var reservable []string
+	reservable, output.Skipped = getNetworkReservableIps(ranges, self.GetUsedAddresses())
+	for _, ipstr := range reservable {
+		err := ReservedipManager.ReserveIPWithDurationAndStatus(userCred, self, ipstr, notes, duration, status)
+		if err != nil {
+			if errors.Cause(err) == httperrors.ErrConflict {
+				output.Skipped = append(output.Skipped, ipstr)
+				continue
+			}
+			return output, httperrors.NewGeneralError(err)
+		}
+		output.Reserved = append(output.Reserved, ipstr)
+	}
+	return output, nil
+}
+
+// 按网络, 二层网络或可用区统计IP地址使用率
+func (manager *SNetworkManager) GetPropertyIpUtilization(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.NetworkIpUtilizationOutput, error) {
+	input := api.NetworkIpUtilizationInput{}
+	err := query.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal input: %v", err)
+	}
+	if len(input.GroupBy) == 0 {
+		input.GroupBy = api.NETWORK_IP_UTILIZATION_GROUP_BY_NETWORK
+	}
+	switch input.GroupBy {
+	case api.NETWORK_IP_UTILIZATION_GROUP_BY_NETWORK, api.NETWORK_IP_UTILIZATION_GROUP_BY_WIRE, api.NETWORK_IP_UTILIZATION_GROUP_BY_ZONE:
+	default:
+		return nil, httperrors.NewInputParameterError("invalid group_by %s", input.GroupBy)
+	}
+
+	q := manager.Query()
+	q, err = db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	networks := []SNetwork{}
+	err = db.FetchModelObjects(manager, q, &networks)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "FetchModelObjects"))
+	}
+	ret := &api.NetworkIpUtilizationOutput{GroupBy: input.GroupBy, Data: []api.NetworkIpUtilization{}}
+	if len(networks) == 0 {
+		return ret, nil
+	}
+
+	netIds := make([]string, len(networks))
+	wireIds := []string{}
+	for i := range networks {
+		netIds[i] = networks[i].Id
+		wireIds = append(wireIds, networks[i].WireId)
+	}
+	nics, err := manager.TotalNicCount(netIds)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "TotalNicCount"))
+	}
+
+	wires := map[string]SWire{}
+	zones := map[string]SZone{}
+	if input.GroupBy != api.NETWORK_IP_UTILIZATION_GROUP_BY_NETWORK {
+		err = db.FetchModelObjectsByIds(WireManager, "id", wireIds, &wires)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "fetch wires"))
+		}
+	}
+	if input.GroupBy == api.NETWORK_IP_UTILIZATION_GROUP_BY_ZONE {
+		zoneIds := []string{}
+		for _, wire := range wires {
+			zoneIds = append(zoneIds, wire.ZoneId)
+		}
+		err = db.FetchModelObjectsByIds(ZoneManager, "id", zoneIds, &zones)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "fetch zones"))
+		}
+	}
+
+	ret.Data = getNetworkIpUtilizations(input.GroupBy, networks, wires, zones, nics)
+	return ret, nil
+}
+
+// 按分组汇总网络的地址总数, 已用及预留数量, 空闲数量不小于0
+func getNetworkIpUtilizations(groupBy string, networks []SNetwork, wires map[string]SWire, zones map[string]SZone, nics map[string]api.SNetworkNics) []api.NetworkIpUtilization {
+	ret := []api.NetworkIpUtilization{}
+	idx := map[string]int{}
+	for i := range networks {
+		var id, name string
+		switch groupBy {
+		case api.NETWORK_IP_UTILIZATION_GROUP_BY_WIRE:
+			id, name = networks[i].WireId, wires[networks[i].WireId].Name
+		case api.NETWORK_IP_UTILIZATION_GROUP_BY_ZONE:
+			id = wires[networks[i].WireId].ZoneId
+			name = zones[id].Name
+		default:
+			id, name = networks[i].Id, networks[i].Name
+		}
+		pos, ok := idx[id]
+		if !ok {
+			pos = len(ret)
+			idx[id] = pos
+			ret = append(ret, api.NetworkIpUtilization{Id: id, Name: name})
+		}
+		stat := &ret[pos]
+		nic := nics[networks[i].Id]
+		stat.NetworkCount += 1
+		stat.Total += networks[i].GetTotalAddressCount()
+		stat.Reserved += nic.ReserveVnics
+		stat.Used += nic.Total - nic.ReserveVnics
+	}
+	for i := range ret {
+		stat := &ret[i]
+		stat.Free = stat.Total - stat.Used - stat.Reserved
+		if stat.Free < 0 {
+			stat.Free = 0
+		}
+		if stat.Total > 0 {
+			stat.Utilization = float64(stat.Used+stat.Reserved) / float64(stat.Total)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,166 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestParseNetworkIpRange(t *testing.T) {
+	for _, c := range []struct {
+		in    string
+		start string
+		end   string
+		fail  bool
+	}{
+		{in: "10.0.0.10-10.0.0.20", start: "10.0.0.10", end: "10.0.0.20"},
+		{in: "10.0.0.10 - 10.0.0.10", start: "10.0.0.10", end: "10.0.0.10"},
+		{in: "10.0.0.16/30", start: "10.0.0.16", end: "10.0.0.19"},
+		{in: "10.0.0.20-10.0.0.10", fail: true},
+		{in: "10.0.0.10", fail: true},
+		{in: "10.0.0.10-10.0.0.300", fail: true},
+		{in: "10.0.0.0/33", fail: true},
+	} {
+		r, err := parseNetworkIpRange(c.in)
+		if c.fail {
+			if err == nil {
+				t.Errorf("%s: want error", c.in)
+			}
+			continue
+		}
+		if err != nil || r.StartIp().String() != c.start || r.EndIp().String() != c.end {
+			t.Errorf("%s: want %s-%s, got %s %v", c.in, c.start, c.end, r.String(), err)
+		}
+	}
+}
+
+func TestParseNetworkReserveIpRanges(t *testing.T) {
+	net := SNetwork{GuestIpStart: "10.0.0.2", GuestIpEnd: "10.0.7.254"}
+	netRange := net.getIPRange()
+	for _, c := range []struct {
+		name   string
+		ranges []string
+		count  int
+		fail   bool
+	}{
+		{name: "in network", ranges: []string{"10.0.0.10-10.0.0.19", "10.0.1.0/28"}, count: 2},
+		{name: "out of network", ranges: []string{"10.0.0.1-10.0.0.10"}, fail: true},
+		{name: "too many", ranges: []string{"10.0.1.0-10.0.4.255", "10.0.5.0-10.0.5.1"}, fail: true},
+		{name: "at limit", ranges: []string{"10.0.1.0-10.0.4.255"}, count: 1},
+		{name: "invalid", ranges: []string{"10.0.0.10-10.0.0.19", "foo"}, fail: true},
+	} {
+		ranges, err := parseNetworkReserveIpRanges(netRange, c.ranges)
+		if c.fail {
+			if err == nil {
+				t.Errorf("%s: want error", c.name)
+			}
+			continue
+		}
+		if err != nil || len(ranges) != c.count {
+			t.Errorf("%s: want %d ranges, got %d %v", c.name, c.count, len(ranges), err)
+		}
+	}
+}
+
+func TestGetNetworkReservableIps(t *testing.T) {
+	r1, _ := parseNetworkIpRange("10.0.0.10-10.0.0.13")
+	r2, _ := parseNetworkIpRange("10.0.0.12-10.0.0.14")
+	used := map[string]bool{"10.0.0.11": true, "10.0.0.14": true}
+	reservable, skipped := getNetworkReservableIps([]netutils.IPV4AddrRange{r1, r2}, used)
+	if !reflect.DeepEqual(reservable, []string{"10.0.0.10", "10.0.0.12", "10.0.0.13"}) {
+		t.Errorf("unexpected reservable %v", reservable)
+	}
+	if !reflect.DeepEqual(skipped, []string{"10.0.0.11", "10.0.0.14"}) {
+		t.Errorf("unexpected skipped %v", skipped)
+	}
+}
+
+func TestGetNetworkIpUtilizations(t *testing.T) {
+	networks := make([]SNetwork, 3)
+	for i, net := range []struct{ id, wire, start, end string }{
+		{"net1", "wire1", "10.0.0.1", "10.0.0.10"},
+		{"net2", "wire1", "10.0.1.1", "10.0.1.10"},
+		{"net3", "wire2", "10.0.2.1", "10.0.2.4"},
+	} {
+		networks[i].Id, networks[i].Name = net.id, net.id
+		networks[i].WireId, networks[i].GuestIpStart, networks[i].GuestIpEnd = net.wire, net.start, net.end
+	}
+	wires := map[string]SWire{}
+	for _, wire := range []struct{ id, zone string }{{"wire1", "zone1"}, {"wire2", "zone1"}} {
+		w := SWire{}
+		w.Id, w.Name, w.ZoneId = wire.id, wire.id, wire.zone
+		wires[wire.id] = w
+	}
+	zone := SZone{}
+	zone.Id, zone.Name = "zone1", "zone1"
+	zones := map[string]SZone{"zone1": zone}
+	nics := map[string]api.SNetworkNics{
+		"net1": {Total: 5, ReserveVnics: 1},
+		"net2": {Total: 2},
+		// 地址数据异常时空闲数量不为负数
+		"net3": {Total: 6, ReserveVnics: 2},
+	}
+
+	ret := getNetworkIpUtilizations(api.NETWORK_IP_UTILIZATION_GROUP_BY_NETWORK, networks, wires, zones, nics)
+	if len(ret) != 3 {
+		t.Fatalf("want 3 networks, got %d", len(ret))
+	}
+	if ret[0].Total != 10 || ret[0].Used != 4 || ret[0].Reserved != 1 || ret[0].Free != 5 || ret[0].Utilization != 0.5 {
+		t.Errorf("unexpected net1 %+v", ret[0])
+	}
+	if ret[2].Total != 4 || ret[2].Free != 0 || ret[2].Utilization != 1.5 {
+		t.Errorf("unexpected net3 %+v", ret[2])
+	}
+
+	ret = getNetworkIpUtilizations(api.NETWORK_IP_UTILIZATION_GROUP_BY_WIRE, networks, wires, zones, nics)
+	if len(ret) != 2 || ret[0].Id != "wire1" || ret[0].NetworkCount != 2 || ret[0].Total != 20 || ret[0].Used != 6 || ret[0].Free != 13 {
+		t.Errorf("unexpected wire utilization %+v", ret)
+	}
+
+	ret = getNetworkIpUtilizations(api.NETWORK_IP_UTILIZATION_GROUP_BY_ZONE, networks, wires, zones, nics)
+	if len(ret) != 1 || ret[0].Name != "zone1" || ret[0].NetworkCount != 3 || ret[0].Total != 24 || ret[0].Reserved != 3 {
+		t.Errorf("unexpected zone utilization %+v", ret)
+	}
+}
+
+func TestDiffNetworkIpConflicts(t *testing.T) {
+	mac := "00:22:00:00:00:01"
+	dbConflicts := []SNetworkIpConflict{
+		newNetworkIpConflict("vm", "net1", mac, "10.0.0.2", "10.0.0.3", api.NETWORK_IP_CONFLICT_IP_IN_USE),
+		newNetworkIpConflict("vm", "", mac, "10.0.0.2", "192.168.0.3", api.NETWORK_IP_CONFLICT_NO_NETWORK),
+	}
+	conflicts := []SNetworkIpConflict{
+		newNetworkIpConflict("vm", "net1", mac, "10.0.0.2", "10.0.0.3", api.NETWORK_IP_CONFLICT_IP_IN_USE),
+		newNetworkIpConflict("vm", "net1", mac, "10.0.0.2", "10.0.0.4", api.NETWORK_IP_CONFLICT_IP_IN_USE),
+		newNetworkIpConflict("vm", "net1", mac, "10.0.0.2", "10.0.0.4", api.NETWORK_IP_CONFLICT_IP_IN_USE),
+	}
+	removes, adds := diffNetworkIpConflicts(dbConflicts, conflicts)
+	if len(removes) != 1 || removes[0].RemoteIp != "192.168.0.3" {
+		t.Errorf("want resolved conflict removed, got %v", removes)
+	}
+	if len(adds) != 1 || adds[0].RemoteIp != "10.0.0.4" {
+		t.Errorf("want only new conflict added once, got %v", adds)
+	}
+
+	removes, adds = diffNetworkIpConflicts(dbConflicts, nil)
+	if len(removes) != 2 || len(adds) != 0 {
+		t.Errorf("want all conflicts removed, got %d %d", len(removes), len(adds))
+	}
+}
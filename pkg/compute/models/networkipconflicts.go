@@ -0,0 +1,248 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/rbacscope"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-ignore
+type SNetworkIpConflictManager struct {
+	db.SStandaloneAnonResourceBaseManager
+	SNetworkResourceBaseManager
+}
+
+var NetworkIpConflictManager *SNetworkIpConflictManager
+
+func init() {
+	NetworkIpConflictManager = &SNetworkIpConflictManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SNetworkIpConflict{},
+			"network_ip_conflicts_tbl",
+			"network_ip_conflict",
+			"network_ip_conflicts",
+		),
+	}
+	NetworkIpConflictManager.SetVirtualObject(NetworkIpConflictManager)
+}
+
+// 同步时发现的本地虚拟机网卡记录与云上网卡的地址冲突
+type SNetworkIpConflict struct {
+	db.SStandaloneAnonResourceBase
+	SNetworkResourceBase
+
+	GuestId string `width:"36" charset:"ascii" nullable:"false" list:"admin" index:"true"`
+	Mac     string `width:"32" charset:"ascii" nullable:"false" list:"admin"`
+	// 本地记录的IP
+	LocalIp string `width:"16" charset:"ascii" nullable:"true" list:"admin"`
+	// 云上网卡的IP
+	RemoteIp string `width:"16" charset:"ascii" nullable:"true" list:"admin"`
+	// 冲突原因
+	Reason string `width:"16" charset:"ascii" nullable:"false" list:"admin"`
+}
+
+// 同步时判断云上IP是否被本地其他资源占用, 预留IP及虚拟机自身的网卡不视为冲突
+func (self *SNetwork) isAddressUsedByOthers(address string, guestId string) (bool, error) {
+	args := &usedAddressQueryArgs{
+		network:  self,
+		scope:    rbacscope.ScopeSystem,
+		addrOnly: true,
+	}
+	for _, provider := range usedAddressQueryProviders {
+		var q *sqlchemy.SQuery
+		switch provider {
+		case ReservedipManager:
+			continue
+		case GuestnetworkManager:
+			q = GuestnetworkManager.Query("ip_addr").Equals("network_id", self.Id).NotEquals("guest_id", guestId)
+		default:
+			q = provider.usedAddressQuery(args)
+		}
+		cnt, err := q.Equals("ip_addr", address).CountWithError()
+		if err != nil {
+			return false, errors.Wrap(err, "CountWithError")
+		}
+		if cnt > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newNetworkIpConflict(guestId, networkId, mac, localIp, remoteIp, reason string) SNetworkIpConflict {
+	conflict := SNetworkIpConflict{
+		GuestId:  guestId,
+		Mac:      mac,
+		LocalIp:  localIp,
+		RemoteIp: remoteIp,
+		Reason:   reason,
+	}
+	conflict.NetworkId = networkId
+	return conflict
+}
+
+func (conflict *SNetworkIpConflict) key() string {
+	return conflict.Mac + "/" + conflict.RemoteIp + "/" + conflict.Reason
+}
+
+// 返回需删除的已消除冲突及需新增的冲突, 已记录的冲突保持不变
+func diffNetworkIpConflicts(dbConflicts []SNetworkIpConflict, conflicts []SNetworkIpConflict) ([]*SNetworkIpConflict, []*SNetworkIpConflict) {
+	current := map[string]bool{}
+	for i := range conflicts {
+		current[conflicts[i].key()] = true
+	}
+	removes, exists := []*SNetworkIpConflict{}, map[string]bool{}
+	for i := range dbConflicts {
+		if current[dbConflicts[i].key()] {
+			exists[dbConflicts[i].key()] = true
+			continue
+		}
+		removes = append(removes, &dbConflicts[i])
+	}
+	adds := []*SNetworkIpConflict{}
+	for i := range conflicts {
+		if exists[conflicts[i].key()] {
+			continue
+		}
+		exists[conflicts[i].key()] = true
+		adds = append(adds, &conflicts[i])
+	}
+	return removes, adds
+}
+
+// 以本次同步发现的冲突替换虚拟机已有的冲突记录, 已消除的冲突会被删除
+func (manager *SNetworkIpConflictManager) syncGuestConflicts(ctx context.Context, guestId string, conflicts []SNetworkIpConflict) error {
+	q := manager.Query().Equals("guest_id", guestId)
+	dbConflicts := []SNetworkIpConflict{}
+	err := db.FetchModelObjects(manager, q, &dbConflicts)
+	if err != nil {
+		return errors.Wrapf(err, "FetchModelObjects")
+	}
+	removes, adds := diffNetworkIpConflicts(dbConflicts, conflicts)
+	for _, conflict := range removes {
+		err = conflict.Delete(ctx, nil)
+		if err != nil {
+			return errors.Wrapf(err, "delete conflict %s", conflict.Id)
+		}
+	}
+	for _, conflict := range adds {
+		log.Warningf("guest %s nic %s ip conflict: local %s remote %s (%s)", guestId, conflict.Mac, conflict.LocalIp, conflict.RemoteIp, conflict.Reason)
+		err = manager.TableSpec().Insert(ctx, conflict)
+		if err != nil {
+			return errors.Wrapf(err, "Insert")
+		}
+	}
+	return nil
+}
+
+func (manager *SNetworkIpConflictManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return nil, httperrors.NewUnsupportOperationError("network ip conflicts are detected during sync")
+}
+
+func (manager *SNetworkIpConflictManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.NetworkIpConflictListInput) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStandaloneAnonResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err = manager.SNetworkResourceBaseManager.ListItemFilter(ctx, q, userCred, input.NetworkFilterListInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(input.GuestId) > 0 {
+		q = q.In("guest_id", input.GuestId)
+	}
+	if len(input.Reason) > 0 {
+		q = q.In("reason", input.Reason)
+	}
+	return q, nil
+}
+
+func (manager *SNetworkIpConflictManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.NetworkIpConflictListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SNetworkResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.NetworkFilterListInput)
+	if err != nil {
+		return nil, err
+	}
+	return manager.SStandaloneAnonResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StandaloneAnonResourceListInput)
+}
+
+func (manager *SNetworkIpConflictManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	return db.ApplyListItemExportKeys(ctx, q, userCred, keys,
+		&manager.SStandaloneAnonResourceBaseManager,
+		&manager.SNetworkResourceBaseManager,
+	)
+}
+
+func (manager *SNetworkIpConflictManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStandaloneAnonResourceBaseManager,
+		&manager.SNetworkResourceBaseManager,
+	)
+}
+
+func (manager *SNetworkIpConflictManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.NetworkIpConflictDetails {
+	ret := make([]api.NetworkIpConflictDetails, len(objs))
+	netCols := manager.SNetworkResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	stdaCols := manager.SStandaloneAnonResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	guestIds := make([]string, len(objs))
+	for i := range objs {
+		ret[i].NetworkResourceInfo = netCols[i]
+		ret[i].StandaloneAnonResourceDetails = stdaCols[i]
+		guestIds[i] = objs[i].(*SNetworkIpConflict).GuestId
+	}
+	guests := map[string]SGuest{}
+	err := db.FetchModelObjectsByIds(GuestManager, "id", guestIds, &guests)
+	if err != nil {
+		log.Errorf("FetchModelObjectsByIds guests error: %v", err)
+		return ret
+	}
+	for i := range ret {
+		if guest, ok := guests[guestIds[i]]; ok {
+			ret[i].Guest = guest.Name
+		}
+	}
+	return ret
+}
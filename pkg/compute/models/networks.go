@@ -726,6 +726,9 @@ func (manager *SNetworkManager) newFromCloudNetwork(ctx context.Context, userCre
 	syncVirtualResourceMetadata(ctx, userCred, &net, extNet)
 	SyncCloudProject(userCred, &net, syncOwnerId, extNet, vpc.ManagerId)
 
+	extTags, _ := extNet.GetTags()
+	SchedtagRuleManager.attachSyncedResource(ctx, userCred, &net, provider, vpc.CloudregionId, wire.ZoneId, extTags)
+
 	if provider != nil {
 		shareInfo := provider.getAccountShareInfo()
 		if utils.IsInStringArray(provider.Provider, api.PRIVATE_CLOUD_PROVIDERS) && extNet.GetPublicScope() == rbacscope.ScopeNone {
@@ -1184,7 +1187,7 @@ func (nm *SNetworkManager) TotalNicCount(netIds []string) (map[string]api.SNetwo
 // 预留IP
 // 预留的IP不会被调度使用
 func (self *SNetwork) PerformReserveIp(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input *api.NetworkReserveIpInput) (jsonutils.JSONObject, error) {
-	if len(input.Ips) == 0 {
+	if len(input.Ips) == 0 && len(input.IpRanges) == 0 {
 		return nil, httperrors.NewMissingParameterError("ips")
 	}
 
@@ -1203,7 +1206,14 @@ func (self *SNetwork) PerformReserveIp(ctx context.Context, userCred mcclient.To
 			return nil, err
 		}
 	}
-	return nil, nil
+	if len(input.IpRanges) == 0 {
+		return nil, nil
+	}
+	output, err := self.reserveIpRanges(ctx, userCred, input.IpRanges, input.Notes, duration, input.Status)
+	if err != nil {
+		return nil, err
+	}
+	return jsonutils.Marshal(output), nil
 }
 
 func (self *SNetwork) reserveIpWithDuration(ctx context.Context, userCred mcclient.TokenCredential, ipstr string, notes string, duration time.Duration) error {
@@ -0,0 +1,169 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SOperationApprovalPolicyManager struct {
+	db.SEnabledStatusDomainLevelResourceBaseManager
+}
+
+var OperationApprovalPolicyManager *SOperationApprovalPolicyManager
+
+func init() {
+	OperationApprovalPolicyManager = &SOperationApprovalPolicyManager{
+		SEnabledStatusDomainLevelResourceBaseManager: db.NewEnabledStatusDomainLevelResourceBaseManager(
+			SOperationApprovalPolicy{},
+			"operation_approval_policies_tbl",
+			"operation_approval_policy",
+			"operation_approval_policies",
+		),
+	}
+	OperationApprovalPolicyManager.SetVirtualObject(OperationApprovalPolicyManager)
+}
+
+// 域内高危操作审批策略, 命中的操作需由发起人之外的域管理员审批后才会执行
+type SOperationApprovalPolicy struct {
+	db.SEnabledStatusDomainLevelResourceBase
+
+	// 需要审批的操作
+	Actions *api.SOperationApprovalActions `nullable:"false" list:"domain" create:"domain_required" update:"domain"`
+	// 审批请求有效期, 单位小时
+	ExpireHours int `nullable:"false" default:"24" list:"domain" create:"domain_optional" update:"domain"`
+}
+
+func validateOperationApprovalActions(actions api.SOperationApprovalActions) error {
+	if len(actions) == 0 {
+		return httperrors.NewMissingParameterError("actions")
+	}
+	for _, action := range actions {
+		if !utils.IsInStringArray(action, api.OPERATION_APPROVAL_ACTIONS) {
+			return httperrors.NewInputParameterError("invalid action %s", action)
+		}
+	}
+	return nil
+}
+
+func (manager *SOperationApprovalPolicyManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.OperationApprovalPolicyCreateInput) (api.OperationApprovalPolicyCreateInput, error) {
+	var err error
+	err = validateOperationApprovalActions(input.Actions)
+	if err != nil {
+		return input, err
+	}
+	if input.ExpireHours < 0 {
+		return input, httperrors.NewInputParameterError("expire_hours must not be negative")
+	}
+	if input.ExpireHours == 0 {
+		input.ExpireHours = api.OPERATION_APPROVAL_DEFAULT_EXPIRE_HOURS
+	}
+	input.EnabledStatusDomainLevelResourceCreateInput, err = manager.SEnabledStatusDomainLevelResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusDomainLevelResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (policy *SOperationApprovalPolicy) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.OperationApprovalPolicyUpdateInput) (api.OperationApprovalPolicyUpdateInput, error) {
+	var err error
+	if len(input.Actions) > 0 {
+		err = validateOperationApprovalActions(input.Actions)
+		if err != nil {
+			return input, err
+		}
+	}
+	if input.ExpireHours != nil && *input.ExpireHours <= 0 {
+		return input, httperrors.NewInputParameterError("expire_hours must be greater than 0")
+	}
+	input.EnabledStatusDomainLevelResourceBaseUpdateInput, err = policy.SEnabledStatusDomainLevelResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusDomainLevelResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (manager *SOperationApprovalPolicyManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.OperationApprovalPolicyListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Action) > 0 {
+		q = q.Filter(sqlchemy.ContainsAny(q.Field("actions"), query.Action))
+	}
+	return q, nil
+}
+
+func (manager *SOperationApprovalPolicyManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.OperationApprovalPolicyListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SOperationApprovalPolicyManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SOperationApprovalPolicyManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.OperationApprovalPolicyDetails {
+	rows := make([]api.OperationApprovalPolicyDetails, len(objs))
+	domainRows := manager.SEnabledStatusDomainLevelResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.OperationApprovalPolicyDetails{
+			EnabledStatusDomainLevelResourceDetails: domainRows[i],
+		}
+	}
+	return rows
+}
+
+// 返回域内要求审批该操作的已启用策略, 多条策略时取最短有效期
+func (manager *SOperationApprovalPolicyManager) getPolicy(domainId, action string) (*SOperationApprovalPolicy, error) {
+	q := manager.Query().Equals("domain_id", domainId).IsTrue("enabled")
+	policies := []SOperationApprovalPolicy{}
+	err := db.FetchModelObjects(manager, q, &policies)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	var ret *SOperationApprovalPolicy
+	for i := range policies {
+		if !policies[i].hasAction(action) {
+			continue
+		}
+		if ret == nil || policies[i].ExpireHours < ret.ExpireHours {
+			ret = &policies[i]
+		}
+	}
+	return ret, nil
+}
+
+func (policy *SOperationApprovalPolicy) hasAction(action string) bool {
+	return policy.Actions != nil && utils.IsInStringArray(action, *policy.Actions)
+}
@@ -0,0 +1,419 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	napi "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SOperationApprovalManager struct {
+	db.SStatusDomainLevelResourceBaseManager
+}
+
+var OperationApprovalManager *SOperationApprovalManager
+
+func init() {
+	OperationApprovalManager = &SOperationApprovalManager{
+		SStatusDomainLevelResourceBaseManager: db.NewStatusDomainLevelResourceBaseManager(
+			SOperationApproval{},
+			"operation_approvals_tbl",
+			"operation_approval",
+			"operation_approvals",
+		),
+	}
+	OperationApprovalManager.SetVirtualObject(OperationApprovalManager)
+}
+
+// 高危操作审批请求, 由受审批策略保护的操作自动创建, 审批通过后以审批人身份执行原操作
+type SOperationApproval struct {
+	db.SStatusDomainLevelResourceBase
+
+	// 待审批的操作
+	Action string `width:"64" charset:"ascii" nullable:"false" list:"domain" index:"true"`
+	// 操作的资源类型
+	ResourceType string `width:"64" charset:"ascii" nullable:"false" list:"domain"`
+	// 操作的资源ID
+	ResourceIds jsonutils.JSONObject `nullable:"true" list:"domain"`
+	// 操作参数
+	Params jsonutils.JSONObject `nullable:"true" get:"domain"`
+
+	RequesterId string `width:"128" charset:"ascii" nullable:"false" list:"domain" index:"true"`
+	Requester   string `width:"128" charset:"utf8" nullable:"false" list:"domain"`
+	ApproverId  string `width:"128" charset:"ascii" nullable:"true" list:"domain"`
+	Approver    string `width:"128" charset:"utf8" nullable:"true" list:"domain"`
+	// 审批意见或执行失败原因
+	Reason string `charset:"utf8" nullable:"true" list:"domain"`
+
+	// 过期时间, 过期后不能再审批
+	ExpiredAt time.Time `nullable:"false" list:"domain" index:"true"`
+}
+
+type fOperationApprovalExecutor func(ctx context.Context, userCred mcclient.TokenCredential, approval *SOperationApproval) error
+
+var operationApprovalExecutors = map[string]fOperationApprovalExecutor{}
+
+func registerOperationApprovalExecutor(action string, executor fOperationApprovalExecutor) {
+	operationApprovalExecutors[action] = executor
+}
+
+func (manager *SOperationApprovalManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input apis.StatusDomainLevelResourceCreateInput) (apis.StatusDomainLevelResourceCreateInput, error) {
+	return input, httperrors.NewUnsupportOperationError("approval requests are created by operations guarded by approval policies")
+}
+
+func normalizeApprovalResourceIds(resourceIds []string) []string {
+	ids := make([]string, len(resourceIds))
+	copy(ids, resourceIds)
+	sort.Strings(ids)
+	return ids
+}
+
+func approvalParamsString(params jsonutils.JSONObject) string {
+	if params == nil || params == jsonutils.JSONNull {
+		return ""
+	}
+	return params.String()
+}
+
+// 是否为同一资源以相同参数执行的同一操作
+func (approval *SOperationApproval) isSameOperation(action, resourceType string, resourceIds []string, params jsonutils.JSONObject) bool {
+	if approval.Action != action || approval.ResourceType != resourceType {
+		return false
+	}
+	if jsonutils.Marshal(normalizeApprovalResourceIds(approval.getResourceIds())).String() != jsonutils.Marshal(normalizeApprovalResourceIds(resourceIds)).String() {
+		return false
+	}
+	return approvalParamsString(approval.Params) == approvalParamsString(params)
+}
+
+// 查找同一操作未过期的待审批请求, 避免重试时重复创建
+func (manager *SOperationApprovalManager) fetchPendingApproval(domainId, action, resourceType string, resourceIds []string, params jsonutils.JSONObject) (*SOperationApproval, error) {
+	q := manager.Query().Equals("domain_id", domainId).Equals("status", api.OPERATION_APPROVAL_STATUS_PENDING).
+		Equals("action", action).Equals("resource_type", resourceType).GT("expired_at", time.Now().UTC())
+	approvals := []SOperationApproval{}
+	err := db.FetchModelObjects(manager, q, &approvals)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchModelObjects")
+	}
+	for i := range approvals {
+		if approvals[i].isSameOperation(action, resourceType, resourceIds, params) {
+			return &approvals[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// 若域内策略要求审批该操作则返回待审批请求(已存在相同请求时直接返回), 否则返回nil由调用方直接执行
+func (manager *SOperationApprovalManager) requireApproval(ctx context.Context, userCred mcclient.TokenCredential, domainId, action, resourceType string, resourceIds []string, params jsonutils.JSONObject) (*SOperationApproval, error) {
+	policy, err := OperationApprovalPolicyManager.getPolicy(domainId, action)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "getPolicy"))
+	}
+	if policy == nil {
+		return nil, nil
+	}
+	approval, err := manager.fetchPendingApproval(domainId, action, resourceType, resourceIds, params)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "fetchPendingApproval"))
+	}
+	if approval != nil {
+		return approval, nil
+	}
+	approval = &SOperationApproval{}
+	approval.SetModelManager(manager, approval)
+	approval.DomainId = domainId
+	approval.Status = api.OPERATION_APPROVAL_STATUS_PENDING
+	approval.Action = action
+	approval.ResourceType = resourceType
+	approval.ResourceIds = jsonutils.Marshal(normalizeApprovalResourceIds(resourceIds))
+	approval.Params = params
+	approval.RequesterId = userCred.GetUserId()
+	approval.Requester = userCred.GetUserName()
+	approval.ExpiredAt = time.Now().UTC().Add(time.Duration(policy.ExpireHours) * time.Hour)
+	approval.Name, err = db.GenerateName(ctx, manager, &db.SOwnerId{DomainId: domainId}, action)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GenerateName")
+	}
+	err = manager.TableSpec().Insert(ctx, approval)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "Insert"))
+	}
+	db.OpsLog.LogEvent(approval, db.ACT_CREATE, approval.GetShortDesc(ctx), userCred)
+	logclient.AddSimpleActionLog(approval, logclient.ACT_REQUEST_APPROVAL, resourceIds, userCred, true)
+	notifyclient.EventNotify(ctx, userCred, notifyclient.SEventNotifyParam{
+		Obj:    approval,
+		Action: napi.ActionCreate,
+	})
+	return approval, nil
+}
+
+func (approval *SOperationApproval) getResourceIds() []string {
+	ids := []string{}
+	if approval.ResourceIds != nil {
+		approval.ResourceIds.Unmarshal(&ids)
+	}
+	return ids
+}
+
+func (approval *SOperationApproval) isExpired(now time.Time) bool {
+	return !approval.ExpiredAt.IsZero() && approval.ExpiredAt.Before(now)
+}
+
+func (approval *SOperationApproval) setResult(userCred mcclient.TokenCredential, status, reason string) error {
+	_, err := db.Update(approval, func() error {
+		approval.Status = status
+		approval.Reason = reason
+		if status != api.OPERATION_APPROVAL_STATUS_EXPIRED {
+			approval.ApproverId = userCred.GetUserId()
+			approval.Approver = userCred.GetUserName()
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	db.OpsLog.LogEvent(approval, db.ACT_UPDATE_STATUS, status, userCred)
+	return nil
+}
+
+func (approval *SOperationApproval) validatePending(userCred mcclient.TokenCredential) error {
+	if approval.Status != api.OPERATION_APPROVAL_STATUS_PENDING {
+		return httperrors.NewInvalidStatusError("approval request is %s", approval.Status)
+	}
+	if approval.isExpired(time.Now().UTC()) {
+		approval.setResult(userCred, api.OPERATION_APPROVAL_STATUS_EXPIRED, "")
+		return httperrors.NewInvalidStatusError("approval request expired at %s", approval.ExpiredAt)
+	}
+	return nil
+}
+
+// 审批通过并执行原操作, 审批人不能是发起人
+func (approval *SOperationApproval) PerformApprove(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.OperationApprovalApproveInput) (jsonutils.JSONObject, error) {
+	err := approval.validatePending(userCred)
+	if err != nil {
+		return nil, err
+	}
+	if userCred.GetUserId() == approval.RequesterId {
+		return nil, httperrors.NewForbiddenError("requester cannot approve own request")
+	}
+	if !db.IsDomainAllowPerform(ctx, userCred, approval, "approve") {
+		return nil, httperrors.NewForbiddenError("only domain admin can approve")
+	}
+	executor, ok := operationApprovalExecutors[approval.Action]
+	if !ok {
+		return nil, httperrors.NewNotSupportedError("unsupported action %s", approval.Action)
+	}
+	err = approval.setResult(userCred, api.OPERATION_APPROVAL_STATUS_EXECUTING, input.Reason)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	err = executor(ctx, userCred, approval)
+	if err != nil {
+		approval.setResult(userCred, api.OPERATION_APPROVAL_STATUS_EXECUTE_FAILED, err.Error())
+		logclient.AddSimpleActionLog(approval, logclient.ACT_APPROVE, err, userCred, false)
+		notifyclient.EventNotify(ctx, userCred, notifyclient.SEventNotifyParam{
+			Obj:    approval,
+			Action: napi.ActionApprove,
+			IsFail: true,
+		})
+		return nil, err
+	}
+	approval.setResult(userCred, api.OPERATION_APPROVAL_STATUS_EXECUTED, input.Reason)
+	logclient.AddSimpleActionLog(approval, logclient.ACT_APPROVE, input, userCred, true)
+	notifyclient.EventNotify(ctx, userCred, notifyclient.SEventNotifyParam{
+		Obj:    approval,
+		Action: napi.ActionApprove,
+	})
+	return nil, nil
+}
+
+// 驳回审批请求, 发起人也可以驳回以撤销请求
+func (approval *SOperationApproval) PerformReject(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.OperationApprovalRejectInput) (jsonutils.JSONObject, error) {
+	err := approval.validatePending(userCred)
+	if err != nil {
+		return nil, err
+	}
+	if userCred.GetUserId() != approval.RequesterId && !db.IsDomainAllowPerform(ctx, userCred, approval, "reject") {
+		return nil, httperrors.NewForbiddenError("only domain admin or requester can reject")
+	}
+	err = approval.setResult(userCred, api.OPERATION_APPROVAL_STATUS_REJECTED, input.Reason)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddSimpleActionLog(approval, logclient.ACT_REJECT, input, userCred, true)
+	notifyclient.EventNotify(ctx, userCred, notifyclient.SEventNotifyParam{
+		Obj:    approval,
+		Action: napi.ActionReject,
+	})
+	return nil, nil
+}
+
+// 定时将过期未审批的请求置为过期
+func (manager *SOperationApprovalManager) ExpireOperationApprovals(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := manager.Query().Equals("status", api.OPERATION_APPROVAL_STATUS_PENDING).LT("expired_at", time.Now().UTC())
+	approvals := []SOperationApproval{}
+	err := db.FetchModelObjects(manager, q, &approvals)
+	if err != nil {
+		log.Errorf("fetch expired operation approvals error: %v", err)
+		return
+	}
+	for i := range approvals {
+		err = approvals[i].setResult(userCred, api.OPERATION_APPROVAL_STATUS_EXPIRED, "")
+		if err != nil {
+			log.Errorf("expire operation approval %s error: %v", approvals[i].Name, err)
+		}
+	}
+}
+
+func (manager *SOperationApprovalManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.OperationApprovalListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusDomainLevelResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusDomainLevelResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Action) > 0 {
+		q = q.In("action", query.Action)
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.In("resource_type", query.ResourceType)
+	}
+	if query.Mine != nil && *query.Mine {
+		q = q.Equals("requester_id", userCred.GetUserId())
+	}
+	return q, nil
+}
+
+func (manager *SOperationApprovalManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.OperationApprovalListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusDomainLevelResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusDomainLevelResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SOperationApprovalManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusDomainLevelResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SOperationApprovalManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.OperationApprovalDetails {
+	rows := make([]api.OperationApprovalDetails, len(objs))
+	domainRows := manager.SStatusDomainLevelResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.OperationApprovalDetails{
+			StatusDomainLevelResourceDetails: domainRows[i],
+		}
+	}
+	return rows
+}
+
+func init() {
+	registerOperationApprovalExecutor(api.OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER, func(ctx context.Context, userCred mcclient.TokenCredential, approval *SOperationApproval) error {
+		if approval.ResourceType == CloudaccountManager.Keyword() {
+			for _, id := range approval.getResourceIds() {
+				obj, err := CloudaccountManager.FetchById(id)
+				if err != nil {
+					return httperrors.NewResourceNotFoundError2(CloudaccountManager.Keyword(), id)
+				}
+				account := obj.(*SCloudaccount)
+				err = account.ValidateDeleteCondition(ctx, nil)
+				if err != nil {
+					return err
+				}
+				err = account.StartCloudaccountDeleteTask(ctx, userCred, "")
+				if err != nil {
+					return errors.Wrapf(err, "StartCloudaccountDeleteTask %s", account.Name)
+				}
+			}
+			return nil
+		}
+		opts := api.CloudproviderDeleteInput{}
+		if approval.Params != nil {
+			approval.Params.Unmarshal(&opts)
+		}
+		for _, id := range approval.getResourceIds() {
+			provider, err := CloudproviderManager.FetchById(id)
+			if err != nil {
+				return httperrors.NewResourceNotFoundError2(CloudproviderManager.Keyword(), id)
+			}
+			cp := provider.(*SCloudprovider)
+			err = cp.ValidateDeleteCondition(ctx, nil)
+			if err != nil {
+				return err
+			}
+			err = cp.StartCloudproviderDeleteTask(ctx, userCred, opts.Archive, "")
+			if err != nil {
+				return errors.Wrapf(err, "StartCloudproviderDeleteTask %s", cp.Name)
+			}
+		}
+		return nil
+	})
+	deleteServers := func(ctx context.Context, userCred mcclient.TokenCredential, approval *SOperationApproval) error {
+		opts := api.ServerDeleteInput{}
+		if approval.Params != nil {
+			approval.Params.Unmarshal(&opts)
+		}
+		errs := []error{}
+		for _, id := range approval.getResourceIds() {
+			obj, err := GuestManager.FetchById(id)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "fetch server %s", id))
+				continue
+			}
+			err = obj.(*SGuest).startBatchDelete(ctx, userCred, opts)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "delete server %s", obj.GetName()))
+			}
+		}
+		return errors.NewAggregate(errs)
+	}
+	registerOperationApprovalExecutor(api.OPERATION_APPROVAL_ACTION_DELETE_SERVER, deleteServers)
+	registerOperationApprovalExecutor(api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER, deleteServers)
+	registerOperationApprovalExecutor(api.OPERATION_APPROVAL_ACTION_CHANGE_PROJECT_CROSS_DOMAIN, func(ctx context.Context, userCred mcclient.TokenCredential, approval *SOperationApproval) error {
+		input := apis.PerformChangeProjectOwnerInput{}
+		if approval.Params != nil {
+			approval.Params.Unmarshal(&input)
+		}
+		for _, id := range approval.getResourceIds() {
+			obj, err := GuestManager.FetchById(id)
+			if err != nil {
+				return httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), id)
+			}
+			_, err = obj.(*SGuest).changeOwner(ctx, userCred, jsonutils.NewDict(), input)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
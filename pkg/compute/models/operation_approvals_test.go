@@ -0,0 +1,98 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestOperationApprovalPolicyHasAction(t *testing.T) {
+	cases := []struct {
+		actions *api.SOperationApprovalActions
+		action  string
+		want    bool
+	}{
+		{
+			actions: nil,
+			action:  api.OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER,
+			want:    false,
+		},
+		{
+			actions: &api.SOperationApprovalActions{api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER},
+			action:  api.OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER,
+			want:    false,
+		},
+		{
+			actions: &api.SOperationApprovalActions{api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER, api.OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER},
+			action:  api.OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER,
+			want:    true,
+		},
+	}
+	for i, c := range cases {
+		policy := SOperationApprovalPolicy{Actions: c.actions}
+		if got := policy.hasAction(c.action); got != c.want {
+			t.Errorf("case %d: want %v got %v", i, c.want, got)
+		}
+	}
+}
+
+func TestOperationApprovalIsExpired(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		expiredAt time.Time
+		want      bool
+	}{
+		{time.Time{}, false},
+		{now.Add(time.Hour), false},
+		{now.Add(-time.Hour), true},
+	}
+	for i, c := range cases {
+		approval := SOperationApproval{ExpiredAt: c.expiredAt}
+		if got := approval.isExpired(now); got != c.want {
+			t.Errorf("case %d: want %v got %v", i, c.want, got)
+		}
+	}
+}
+
+func TestOperationApprovalIsSameOperation(t *testing.T) {
+	approval := SOperationApproval{
+		Action:       api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER,
+		ResourceType: "server",
+		ResourceIds:  jsonutils.Marshal([]string{"a", "b"}),
+		Params:       jsonutils.Marshal(map[string]bool{"purge": true}),
+	}
+	cases := []struct {
+		action string
+		ids    []string
+		params jsonutils.JSONObject
+		want   bool
+	}{
+		{api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER, []string{"b", "a"}, jsonutils.Marshal(map[string]bool{"purge": true}), true},
+		{api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER, []string{"a"}, jsonutils.Marshal(map[string]bool{"purge": true}), false},
+		{api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER, []string{"a", "b"}, jsonutils.Marshal(map[string]bool{"purge": false}), false},
+		{api.OPERATION_APPROVAL_ACTION_BATCH_DELETE_SERVER, []string{"a", "b"}, nil, false},
+		{api.OPERATION_APPROVAL_ACTION_PURGE_CLOUDPROVIDER, []string{"a", "b"}, jsonutils.Marshal(map[string]bool{"purge": true}), false},
+	}
+	for i, c := range cases {
+		if got := approval.isSameOperation(c.action, "server", c.ids, c.params); got != c.want {
+			t.Errorf("case %d: want %v got %v", i, c.want, got)
+		}
+	}
+}
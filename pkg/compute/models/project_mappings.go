@@ -16,6 +16,7 @@ package models
 
 import (
 	"context"
+	"database/sql"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/errors"
@@ -108,6 +109,10 @@ func (manager *SProjectMappingManager) ValidateCreateData(
 			input.Rules[i].Domain = tenant.Domain
 			input.Rules[i].Project = tenant.Name
 		}
+		err = validateRuleAutoCreateDomain(ctx, userCred, &input.Rules[i])
+		if err != nil {
+			return input, err
+		}
 	}
 	input.SetEnabled()
 	input.Status = api.PROJECT_MAPPING_STATUS_AVAILABLE
@@ -118,6 +123,24 @@ func (manager *SProjectMappingManager) ValidateCreateData(
 	return input, nil
 }
 
+func validateRuleAutoCreateDomain(ctx context.Context, userCred mcclient.TokenCredential, rule *api.ProjectMappingRuleInfo) error {
+	if len(rule.AutoCreateDomainId) == 0 {
+		return nil
+	}
+	domain, err := db.TenantCacheManager.FetchDomainByIdOrName(ctx, rule.AutoCreateDomainId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return httperrors.NewResourceNotFoundError2("domains", rule.AutoCreateDomainId)
+		}
+		return httperrors.NewGeneralError(err)
+	}
+	if domain.Id != userCred.GetProjectDomainId() && db.IsAdminAllowCreate(userCred, ProjectMappingManager).Result.IsDeny() {
+		return httperrors.NewForbiddenError("not allow to auto create project in domain %s", domain.Name)
+	}
+	rule.AutoCreateDomainId = domain.Id
+	return nil
+}
+
 func (self *SProjectMapping) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	self.SEnabledStatusInfrasResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
 	self.refreshMapping()
@@ -240,6 +263,10 @@ func (self *SProjectMapping) ValidateUpdateData(ctx context.Context, userCred mc
 			input.Rules[i].Domain = tenant.Domain
 			input.Rules[i].Project = tenant.Name
 		}
+		err = validateRuleAutoCreateDomain(ctx, userCred, &input.Rules[i])
+		if err != nil {
+			return input, err
+		}
 	}
 	input.EnabledStatusInfrasResourceBaseUpdateInput, err = self.SEnabledStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusInfrasResourceBaseUpdateInput)
 	return input, err
@@ -0,0 +1,42 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/util/tagutils"
+)
+
+func TestProjectMappingRuleValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		rule api.ProjectMappingRuleInfo
+		ok   bool
+	}{
+		{"empty", api.ProjectMappingRuleInfo{ProjectId: "p"}, false},
+		{"tag", api.ProjectMappingRuleInfo{Tags: tagutils.TTagSet{{Key: "env", Value: "prod"}}, ProjectId: "p"}, true},
+		{"tag auto create", api.ProjectMappingRuleInfo{Tags: tagutils.TTagSet{{Key: "team"}}, AutoCreateProject: true, AutoCreateDomainId: "d"}, true},
+		{"tag value auto create", api.ProjectMappingRuleInfo{Tags: tagutils.TTagSet{{Key: "env", Value: "prod"}}, AutoCreateProject: true}, false},
+		{"domain without auto create", api.ProjectMappingRuleInfo{Tags: tagutils.TTagSet{{Key: "team"}}, ProjectId: "p", AutoCreateDomainId: "d"}, false},
+	}
+	for _, c := range cases {
+		err := c.rule.Validate()
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v, got %v", c.name, c.ok, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	napi "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	npk "yunion.io/x/onecloud/pkg/mcclient/modules/notify"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SProviderAlertRuleManager struct {
+	db.SEnabledStatusDomainLevelResourceBaseManager
+}
+
+var ProviderAlertRuleManager *SProviderAlertRuleManager
+
+func init() {
+	ProviderAlertRuleManager = &SProviderAlertRuleManager{
+		SEnabledStatusDomainLevelResourceBaseManager: db.NewEnabledStatusDomainLevelResourceBaseManager(
+			SProviderAlertRule{},
+			"provider_alert_rules_tbl",
+			"provider_alert_rule",
+			"provider_alert_rules",
+		),
+	}
+	ProviderAlertRuleManager.SetVirtualObject(ProviderAlertRuleManager)
+}
+
+// 云账号及订阅健康状态告警规则, 按域配置
+type SProviderAlertRule struct {
+	db.SEnabledStatusDomainLevelResourceBase
+
+	// 告警规则类型
+	RuleType string `width:"32" charset:"ascii" nullable:"false" list:"domain" create:"domain_required" index:"true"`
+	// 连续同步失败次数阈值
+	Threshold int `nullable:"false" default:"0" list:"domain" create:"domain_optional" update:"domain"`
+	// 通知方式
+	Notification *api.SProviderAlertNotification `nullable:"true" list:"domain" create:"domain_optional" update:"domain"`
+}
+
+func validateProviderAlertNotification(notification api.SProviderAlertNotification) error {
+	for _, channel := range notification.Channels {
+		if !utils.IsInStringArray(channel, []string{
+			string(npk.NotifyByEmail),
+			string(npk.NotifyByMobile),
+			string(npk.NotifyByDingTalk),
+			string(npk.NotifyByFeishu),
+			string(npk.NotifyByWorkwx),
+			string(npk.NotifyByWebConsole),
+			string(npk.NotifyByWebhook),
+		}) {
+			return httperrors.NewInputParameterError("invalid notify channel %s", channel)
+		}
+	}
+	if len(notification.Channels) > 0 && len(notification.Receivers) == 0 {
+		return httperrors.NewMissingParameterError("notification.receivers")
+	}
+	return nil
+}
+
+func (manager *SProviderAlertRuleManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ProviderAlertRuleCreateInput) (api.ProviderAlertRuleCreateInput, error) {
+	var err error
+	if !utils.IsInStringArray(input.RuleType, api.PROVIDER_ALERT_RULE_TYPES) {
+		return input, httperrors.NewInputParameterError("invalid rule_type %q, must be one of %s", input.RuleType, strings.Join(api.PROVIDER_ALERT_RULE_TYPES, ","))
+	}
+	if input.RuleType == api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED {
+		if input.Threshold < 0 {
+			return input, httperrors.NewInputParameterError("threshold must be greater than 0")
+		}
+		if input.Threshold == 0 {
+			input.Threshold = api.PROVIDER_ALERT_RULE_DEFAULT_SYNC_FAILED_THRESHOLD
+		}
+	} else {
+		input.Threshold = 0
+	}
+	err = validateProviderAlertNotification(input.Notification)
+	if err != nil {
+		return input, err
+	}
+	input.EnabledStatusDomainLevelResourceCreateInput, err = manager.SEnabledStatusDomainLevelResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusDomainLevelResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (rule *SProviderAlertRule) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ProviderAlertRuleUpdateInput) (api.ProviderAlertRuleUpdateInput, error) {
+	var err error
+	if input.Threshold != nil {
+		if rule.RuleType != api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED {
+			return input, httperrors.NewInputParameterError("threshold only supported by %s rule", api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED)
+		}
+		if *input.Threshold <= 0 {
+			return input, httperrors.NewInputParameterError("threshold must be greater than 0")
+		}
+	}
+	if input.Notification != nil {
+		err = validateProviderAlertNotification(*input.Notification)
+		if err != nil {
+			return input, err
+		}
+	}
+	input.EnabledStatusDomainLevelResourceBaseUpdateInput, err = rule.SEnabledStatusDomainLevelResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusDomainLevelResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+// 云账号告警规则列表
+func (manager *SProviderAlertRuleManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.ProviderAlertRuleListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter")
+	}
+	if len(query.RuleType) > 0 {
+		q = q.In("rule_type", query.RuleType)
+	}
+	return q, nil
+}
+
+func (manager *SProviderAlertRuleManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.ProviderAlertRuleListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SProviderAlertRuleManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SProviderAlertRuleManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.ProviderAlertRuleDetails {
+	rows := make([]api.ProviderAlertRuleDetails, len(objs))
+	domainRows := manager.SEnabledStatusDomainLevelResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.ProviderAlertRuleDetails{
+			EnabledStatusDomainLevelResourceDetails: domainRows[i],
+		}
+	}
+	return rows
+}
+
+func (manager *SProviderAlertRuleManager) getEnabledRules(ruleType, domainId string) ([]SProviderAlertRule, error) {
+	q := manager.Query().Equals("rule_type", ruleType).Equals("domain_id", domainId).IsTrue("enabled")
+	rules := []SProviderAlertRule{}
+	err := db.FetchModelObjects(manager, q, &rules)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return rules, nil
+}
+
+// 判断告警规则是否需要触发, 连续同步失败只在次数恰好达到阈值时触发一次, 避免重复告警
+func (rule *SProviderAlertRule) isTriggered(failedCount int) bool {
+	if rule.RuleType != api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED {
+		return true
+	}
+	threshold := rule.Threshold
+	if threshold <= 0 {
+		threshold = api.PROVIDER_ALERT_RULE_DEFAULT_SYNC_FAILED_THRESHOLD
+	}
+	return failedCount == threshold
+}
+
+func (rule *SProviderAlertRule) notify(ctx context.Context, obj db.IModel, reason string) {
+	data := jsonutils.NewDict()
+	data.Add(jsonutils.NewString(obj.GetId()), "id")
+	data.Add(jsonutils.NewString(obj.GetName()), "name")
+	data.Add(jsonutils.NewString(obj.Keyword()), "resource_type")
+	data.Add(jsonutils.NewString(rule.RuleType), "rule_type")
+	data.Add(jsonutils.NewString(rule.Name), "rule")
+	data.Add(jsonutils.NewString(reason), "reason")
+	notification := api.SProviderAlertNotification{}
+	if rule.Notification != nil {
+		notification = *rule.Notification
+	}
+	if len(notification.Receivers) == 0 {
+		notifyclient.SystemExceptionNotify(ctx, napi.ActionSystemException, obj.Keyword(), data)
+		return
+	}
+	event := strings.ToUpper(rule.RuleType)
+	if len(notification.Channels) == 0 {
+		notifyclient.NotifyWithCtx(ctx, notification.Receivers, false, npk.NotifyPriorityCritical, event, data)
+		return
+	}
+	for _, channel := range notification.Channels {
+		notifyclient.RawNotifyWithCtx(ctx, notification.Receivers, false, npk.TNotifyChannel(channel), npk.NotifyPriorityCritical, event, data)
+	}
+}
+
+// 按域内已启用的告警规则发送通知, failedCount 仅用于连续同步失败类型
+func (manager *SProviderAlertRuleManager) fire(ctx context.Context, userCred mcclient.TokenCredential, ruleType, domainId string, obj db.IModel, reason string, failedCount int) {
+	rules, err := manager.getEnabledRules(ruleType, domainId)
+	if err != nil {
+		log.Errorf("get %s alert rules for domain %s error: %v", ruleType, domainId, err)
+		return
+	}
+	fired := false
+	for i := range rules {
+		if !rules[i].isTriggered(failedCount) {
+			continue
+		}
+		rules[i].notify(ctx, obj, reason)
+		fired = true
+	}
+	if fired {
+		logclient.AddSimpleActionLog(obj, logclient.ACT_PROVIDER_ALERT, reason, userCred, false)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestProviderAlertRuleIsTriggered(t *testing.T) {
+	cases := []struct {
+		ruleType    string
+		threshold   int
+		failedCount int
+		want        bool
+	}{
+		{api.PROVIDER_ALERT_RULE_TYPE_DISCONNECTED, 0, 0, true},
+		{api.PROVIDER_ALERT_RULE_TYPE_ARREARS, 0, 0, true},
+		{api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED, 0, 2, false},
+		{api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED, 0, 3, true},
+		{api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED, 5, 3, false},
+		{api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED, 5, 5, true},
+		{api.PROVIDER_ALERT_RULE_TYPE_SYNC_FAILED, 5, 6, false},
+	}
+	for _, c := range cases {
+		rule := SProviderAlertRule{RuleType: c.ruleType, Threshold: c.threshold}
+		if got := rule.isTriggered(c.failedCount); got != c.want {
+			t.Errorf("%s threshold %d failed %d: want %v, got %v", c.ruleType, c.threshold, c.failedCount, c.want, got)
+		}
+	}
+}
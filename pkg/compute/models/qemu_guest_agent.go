@@ -16,6 +16,8 @@ package models
 
 import (
 	"context"
+	"encoding/base64"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/errors"
@@ -89,3 +91,83 @@ func (self *SGuest) PerformQgaCommand(
 
 	return self.GetDriver().RequestQgaCommand(ctx, userCred, jsonutils.Marshal(input), host, self)
 }
+
+func (self *SGuest) requestQga(ctx context.Context, userCred mcclient.TokenCredential, host *SHost, execute string, args map[string]interface{}) (jsonutils.JSONObject, error) {
+	cmd := map[string]interface{}{"execute": execute}
+	if len(args) > 0 {
+		cmd["arguments"] = args
+	}
+	input := api.ServerQgaCommandInput{Command: jsonutils.Marshal(cmd).String()}
+	res, err := self.GetDriver().RequestQgaCommand(ctx, userCred, jsonutils.Marshal(input), host, self)
+	if err != nil {
+		return nil, errors.Wrapf(err, "qga %s", execute)
+	}
+	if res == nil {
+		return nil, errors.Errorf("qga %s: empty response", execute)
+	}
+	return res, nil
+}
+
+type sQgaExecResult struct {
+	ExitCode  int
+	Output    string
+	ErrOutput string
+	Truncated bool
+}
+
+// 通过guest-exec执行命令, 并在超时时间内轮询guest-exec-status获取输出
+func (self *SGuest) qgaExec(ctx context.Context, userCred mcclient.TokenCredential, host *SHost, path string, args []string, timeout time.Duration) (*sQgaExecResult, error) {
+	res, err := self.requestQga(ctx, userCred, host, "guest-exec", map[string]interface{}{
+		"path":           path,
+		"arg":            args,
+		"capture-output": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pid, err := res.Int("pid")
+	if err != nil {
+		return nil, errors.Wrapf(err, "guest-exec pid")
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := self.requestQga(ctx, userCred, host, "guest-exec-status", map[string]interface{}{"pid": pid})
+		if err != nil {
+			return nil, err
+		}
+		if jsonutils.QueryBoolean(status, "exited", false) {
+			ret := &sQgaExecResult{}
+			exitCode, _ := status.Int("exitcode")
+			ret.ExitCode = int(exitCode)
+			outData, _ := status.GetString("out-data")
+			out, err := base64.StdEncoding.DecodeString(outData)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decode out-data")
+			}
+			ret.Output = string(out)
+			errData, _ := status.GetString("err-data")
+			errOut, err := base64.StdEncoding.DecodeString(errData)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decode err-data")
+			}
+			ret.ErrOutput = string(errOut)
+			ret.Truncated = jsonutils.QueryBoolean(status, "out-truncated", false) || jsonutils.QueryBoolean(status, "err-truncated", false)
+			return ret, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Wrapf(httperrors.ErrTimeout, "wait %s pid %d", path, pid)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (self *SGuest) qgaExecOutput(ctx context.Context, userCred mcclient.TokenCredential, host *SHost, path string, args []string) (string, error) {
+	ret, err := self.qgaExec(ctx, userCred, host, path, args, time.Minute)
+	if err != nil {
+		return "", err
+	}
+	if ret.ExitCode != 0 {
+		return "", errors.Errorf("%s exit code %d: %s", path, ret.ExitCode, ret.ErrOutput)
+	}
+	return ret.Output, nil
+}
@@ -0,0 +1,493 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/gotypes"
+	"yunion.io/x/pkg/util/billing"
+	"yunion.io/x/pkg/util/rbacscope"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SQuotaReservationManager struct {
+	db.SStatusStandaloneResourceBaseManager
+	db.SProjectizedResourceBaseManager
+}
+
+var QuotaReservationManager *SQuotaReservationManager
+
+func init() {
+	QuotaReservationManager = &SQuotaReservationManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SQuotaReservation{},
+			"quota_reservations_tbl",
+			"quota_reservation",
+			"quota_reservations",
+		),
+	}
+	QuotaReservationManager.SetVirtualObject(QuotaReservationManager)
+	quotas.RegisterPendingQuotaCheckHook(QuotaReservationManager.consumeReservations)
+	quotas.RegisterCancelPendingUsageHook(QuotaReservationManager.settleReservations)
+}
+
+// 配额预留, 预留期间预留额度作为待创建资源从其他请求的可用配额中扣除,
+// 本项目新建资源时优先消耗预留额度
+type SQuotaReservation struct {
+	db.SStatusStandaloneResourceBase
+	db.SProjectizedResourceBase
+
+	// 预留的虚拟机数量
+	Count int `nullable:"false" default:"0" list:"user" create:"optional"`
+	// 预留的CPU核数
+	Cpu int `nullable:"false" default:"0" list:"user" create:"optional"`
+	// 预留的内存大小, 单位MB
+	Memory int `nullable:"false" default:"0" list:"user" create:"optional"`
+	// 预留的存储大小, 单位MB
+	Storage int `nullable:"false" default:"0" list:"user" create:"optional"`
+
+	// 已被本项目新建资源消耗的额度
+	UsedCount   int `nullable:"false" default:"0" list:"user"`
+	UsedCpu     int `nullable:"false" default:"0" list:"user"`
+	UsedMemory  int `nullable:"false" default:"0" list:"user"`
+	UsedStorage int `nullable:"false" default:"0" list:"user"`
+
+	// 已转给本项目创建中资源的额度, 创建成功后计入已消耗, 失败时退回预留
+	HeldCount   int `nullable:"false" default:"0" list:"user"`
+	HeldCpu     int `nullable:"false" default:"0" list:"user"`
+	HeldMemory  int `nullable:"false" default:"0" list:"user"`
+	HeldStorage int `nullable:"false" default:"0" list:"user"`
+
+	// 过期时间
+	ExpiredAt time.Time `nullable:"false" list:"user" create:"optional" index:"true"`
+}
+
+func (manager *SQuotaReservationManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.QuotaReservationCreateInput) (api.QuotaReservationCreateInput, error) {
+	var err error
+	if input.Count < 0 || input.Cpu < 0 || input.Memory < 0 || input.Storage < 0 {
+		return input, httperrors.NewInputParameterError("reserved amount must not be negative")
+	}
+	if input.Count == 0 && input.Cpu == 0 && input.Memory == 0 && input.Storage == 0 {
+		return input, httperrors.NewMissingParameterError("count, cpu, memory or storage")
+	}
+	if !consts.EnableQuotaCheck() {
+		return input, httperrors.NewNotSupportedError("quota reservation requires enable_quota_check")
+	}
+	now := time.Now().UTC()
+	if input.ExpiredAt.IsZero() {
+		if len(input.Duration) == 0 {
+			input.Duration = api.QUOTA_RESERVATION_DEFAULT_DURATION
+		}
+		bc, err := billing.ParseBillingCycle(input.Duration)
+		if err != nil {
+			return input, httperrors.NewInputParameterError("invalid duration %s", input.Duration)
+		}
+		input.ExpiredAt = bc.EndAt(now)
+	}
+	if !input.ExpiredAt.After(now) {
+		return input, httperrors.NewInputParameterError("expired_at must be in the future")
+	}
+	input.Status = api.QUOTA_RESERVATION_STATUS_ACTIVE
+	input.StatusStandaloneResourceCreateInput, err = manager.SStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+
+	// 以待创建资源的方式占用配额, 创建失败时由框架自动取消
+	pendingUsage := SQuota{
+		Count:   input.Count,
+		Cpu:     input.Cpu,
+		Memory:  input.Memory,
+		Storage: input.Storage,
+	}
+	pendingUsage.SetKeys(quotaReservationKeys(ownerId))
+	err = quotas.CheckSetPendingQuota(ctx, userCred, &pendingUsage)
+	if err != nil {
+		return input, httperrors.NewOutOfQuotaError("%v", err)
+	}
+	return input, nil
+}
+
+func quotaReservationKeys(ownerId mcclient.IIdentityProvider) SComputeResourceKeys {
+	keys := SComputeResourceKeys{}
+	keys.SBaseProjectQuotaKeys = quotas.OwnerIdProjectQuotaKeys(rbacscope.ScopeProject, ownerId)
+	return keys
+}
+
+func (manager *SQuotaReservationManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.QuotaReservationListInput) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	return q, nil
+}
+
+func (manager *SQuotaReservationManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.QuotaReservationListInput) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SQuotaReservationManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+	)
+}
+
+func (manager *SQuotaReservationManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.QuotaReservationDetails {
+	rows := make([]api.QuotaReservationDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.QuotaReservationDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+			ProjectizedResourceInfo:         projRows[i],
+		}
+		remaining := objs[i].(*SQuotaReservation).getRemaining()
+		rows[i].RemainingCount = remaining.Count
+		rows[i].RemainingCpu = remaining.Cpu
+		rows[i].RemainingMemory = remaining.Memory
+		rows[i].RemainingStorage = remaining.Storage
+	}
+	return rows
+}
+
+// 剩余未消耗的预留额度, 带有预留的配额键值, 用于取消对应的待创建资源
+func (reservation *SQuotaReservation) getRemaining() *SQuota {
+	remaining := &SQuota{
+		Count:   nonNegative(reservation.Count - reservation.UsedCount - reservation.HeldCount),
+		Cpu:     nonNegative(reservation.Cpu - reservation.UsedCpu - reservation.HeldCpu),
+		Memory:  nonNegative(reservation.Memory - reservation.UsedMemory - reservation.HeldMemory),
+		Storage: nonNegative(reservation.Storage - reservation.UsedStorage - reservation.HeldStorage),
+	}
+	remaining.SetKeys(quotaReservationKeys(reservation.GetOwnerId()))
+	return remaining
+}
+
+func (reservation *SQuotaReservation) getHeld() *SQuota {
+	return &SQuota{
+		Count:   reservation.HeldCount,
+		Cpu:     reservation.HeldCpu,
+		Memory:  reservation.HeldMemory,
+		Storage: reservation.HeldStorage,
+	}
+}
+
+// 计算一次资源请求可以从预留中消耗的额度
+func takeQuotaReservation(remaining, request *SQuota) *SQuota {
+	minInt := func(a, b int) int {
+		if a < b {
+			return a
+		}
+		return b
+	}
+	take := &SQuota{}
+	take.Count = minInt(remaining.Count, nonNegative(request.Count))
+	take.Cpu = minInt(remaining.Cpu, nonNegative(request.Cpu))
+	take.Memory = minInt(remaining.Memory, nonNegative(request.Memory))
+	take.Storage = minInt(remaining.Storage, nonNegative(request.Storage))
+	return take
+}
+
+// 释放剩余的预留额度, 并将预留置为指定状态
+func (reservation *SQuotaReservation) release(ctx context.Context, userCred mcclient.TokenCredential, status string) error {
+	lockman.LockRawObject(ctx, QuotaReservationManager.Keyword(), reservation.ProjectId)
+	defer lockman.ReleaseRawObject(ctx, QuotaReservationManager.Keyword(), reservation.ProjectId)
+
+	if reservation.Status != api.QUOTA_RESERVATION_STATUS_ACTIVE {
+		return nil
+	}
+	remaining := reservation.getRemaining()
+	if !remaining.IsEmpty() {
+		err := quotas.CancelPendingUsage(ctx, userCred, reservation.getRemaining(), remaining, false)
+		if err != nil {
+			return errors.Wrap(err, "CancelPendingUsage")
+		}
+	}
+	return reservation.SetStatus(userCred, status, "")
+}
+
+func (reservation *SQuotaReservation) PerformRelease(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.QuotaReservationReleaseInput) (jsonutils.JSONObject, error) {
+	if reservation.Status != api.QUOTA_RESERVATION_STATUS_ACTIVE {
+		return nil, httperrors.NewInvalidStatusError("cannot release quota reservation in status %s", reservation.Status)
+	}
+	err := reservation.release(ctx, userCred, api.QUOTA_RESERVATION_STATUS_RELEASED)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddActionLogWithContext(ctx, reservation, logclient.ACT_QUOTA_RESERVATION_RELEASE, nil, userCred, true)
+	return nil, nil
+}
+
+func (reservation *SQuotaReservation) PreDelete(ctx context.Context, userCred mcclient.TokenCredential) {
+	err := reservation.release(ctx, userCred, api.QUOTA_RESERVATION_STATUS_RELEASED)
+	if err != nil {
+		log.Errorf("release quota reservation %s before delete error: %v", reservation.Name, err)
+	}
+	reservation.SStatusStandaloneResourceBase.PreDelete(ctx, userCred)
+}
+
+// 本项目新建资源时, 将请求的额度从预留中转出, 避免被自己的预留阻塞
+// 转出的额度先记为占用, 由 settleReservations 在创建成功后计入消耗或失败后退回
+// 预留本身的配额检查使用仅包含项目的配额键值, 不会进入此流程
+func (manager *SQuotaReservationManager) consumeReservations(ctx context.Context, userCred mcclient.TokenCredential, quota quotas.IQuota) error {
+	request, ok := quota.(*SQuota)
+	if !ok {
+		return nil
+	}
+	keys := request.GetKeys()
+	if gotypes.IsNil(keys) || quotas.IsBaseProjectQuotaKeys(keys) {
+		return nil
+	}
+	projectId := request.ProjectId
+	if len(projectId) == 0 {
+		return nil
+	}
+
+	lockman.LockRawObject(ctx, manager.Keyword(), projectId)
+	defer lockman.ReleaseRawObject(ctx, manager.Keyword(), projectId)
+
+	q := manager.Query().Equals("tenant_id", projectId).Equals("status", api.QUOTA_RESERVATION_STATUS_ACTIVE)
+	q = q.GT("expired_at", time.Now().UTC()).Asc("expired_at")
+	reservations := []SQuotaReservation{}
+	err := db.FetchModelObjects(manager, q, &reservations)
+	if err != nil {
+		return errors.Wrap(err, "fetch quota reservations")
+	}
+	left := &SQuota{
+		Count:   request.Count,
+		Cpu:     request.Cpu,
+		Memory:  request.Memory,
+		Storage: request.Storage,
+	}
+	for i := range reservations {
+		if left.IsEmpty() {
+			break
+		}
+		reservation := &reservations[i]
+		remaining := reservation.getRemaining()
+		take := takeQuotaReservation(remaining, left)
+		if take.IsEmpty() {
+			continue
+		}
+		err := quotas.CancelPendingUsage(ctx, userCred, remaining, take, false)
+		if err != nil {
+			return errors.Wrapf(err, "consume quota reservation %s", reservation.Name)
+		}
+		_, err = db.Update(reservation, func() error {
+			reservation.HeldCount += take.Count
+			reservation.HeldCpu += take.Cpu
+			reservation.HeldMemory += take.Memory
+			reservation.HeldStorage += take.Storage
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "update quota reservation %s", reservation.Name)
+		}
+		if reservation.getRemaining().IsEmpty() {
+			reservation.SetStatus(userCred, api.QUOTA_RESERVATION_STATUS_EXHAUSTED, "")
+		}
+		left.Sub(take)
+	}
+	return nil
+}
+
+// 待创建资源转为已用时将占用的额度计入消耗, 取消时退回仍有效的预留
+func (manager *SQuotaReservationManager) settleReservations(ctx context.Context, userCred mcclient.TokenCredential, cancelUsage quotas.IQuota, save bool) {
+	request, ok := cancelUsage.(*SQuota)
+	if !ok {
+		return
+	}
+	keys := request.GetKeys()
+	if gotypes.IsNil(keys) || quotas.IsBaseProjectQuotaKeys(keys) {
+		return
+	}
+	projectId := request.ProjectId
+	if len(projectId) == 0 {
+		return
+	}
+
+	lockman.LockRawObject(ctx, manager.Keyword(), projectId)
+	defer lockman.ReleaseRawObject(ctx, manager.Keyword(), projectId)
+
+	q := manager.Query().Equals("tenant_id", projectId)
+	q = q.Filter(sqlchemy.OR(
+		sqlchemy.GT(q.Field("held_count"), 0),
+		sqlchemy.GT(q.Field("held_cpu"), 0),
+		sqlchemy.GT(q.Field("held_memory"), 0),
+		sqlchemy.GT(q.Field("held_storage"), 0),
+	)).Asc("expired_at")
+	reservations := []SQuotaReservation{}
+	err := db.FetchModelObjects(manager, q, &reservations)
+	if err != nil {
+		log.Errorf("fetch held quota reservations error: %v", err)
+		return
+	}
+	left := &SQuota{
+		Count:   request.Count,
+		Cpu:     request.Cpu,
+		Memory:  request.Memory,
+		Storage: request.Storage,
+	}
+	now := time.Now().UTC()
+	for i := range reservations {
+		if left.IsEmpty() {
+			break
+		}
+		reservation := &reservations[i]
+		settle := takeQuotaReservation(reservation.getHeld(), left)
+		if settle.IsEmpty() {
+			continue
+		}
+		restore := !save && utils.IsInStringArray(reservation.Status, []string{api.QUOTA_RESERVATION_STATUS_ACTIVE, api.QUOTA_RESERVATION_STATUS_EXHAUSTED}) && reservation.ExpiredAt.After(now)
+		if restore {
+			pending := &SQuota{Count: settle.Count, Cpu: settle.Cpu, Memory: settle.Memory, Storage: settle.Storage}
+			pending.SetKeys(quotaReservationKeys(reservation.GetOwnerId()))
+			err := quotas.AddPendingUsage(ctx, userCred, pending)
+			if err != nil {
+				log.Errorf("restore quota reservation %s error: %v", reservation.Name, err)
+				restore = false
+			}
+		}
+		_, err = db.Update(reservation, func() error {
+			reservation.HeldCount -= settle.Count
+			reservation.HeldCpu -= settle.Cpu
+			reservation.HeldMemory -= settle.Memory
+			reservation.HeldStorage -= settle.Storage
+			if save {
+				reservation.UsedCount += settle.Count
+				reservation.UsedCpu += settle.Cpu
+				reservation.UsedMemory += settle.Memory
+				reservation.UsedStorage += settle.Storage
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("settle quota reservation %s error: %v", reservation.Name, err)
+			continue
+		}
+		if restore && reservation.Status == api.QUOTA_RESERVATION_STATUS_EXHAUSTED {
+			reservation.SetStatus(userCred, api.QUOTA_RESERVATION_STATUS_ACTIVE, "restore canceled usage")
+		}
+		left.Sub(settle)
+	}
+}
+
+// 定时释放已过期的配额预留
+func (manager *SQuotaReservationManager) ExpireQuotaReservations(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := manager.Query().Equals("status", api.QUOTA_RESERVATION_STATUS_ACTIVE).LE("expired_at", time.Now().UTC())
+	reservations := []SQuotaReservation{}
+	err := db.FetchModelObjects(manager, q, &reservations)
+	if err != nil {
+		log.Errorf("fetch expired quota reservations error: %v", err)
+		return
+	}
+	for i := range reservations {
+		err := reservations[i].release(ctx, userCred, api.QUOTA_RESERVATION_STATUS_EXPIRED)
+		if err != nil {
+			log.Errorf("expire quota reservation %s error: %v", reservations[i].Name, err)
+		}
+	}
+}
+
+// 预测按当前用量, 待创建资源及预留计算, 项目还能创建多少台指定规格的虚拟机
+func (manager *SQuotaReservationManager) GetPropertyForecast(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.QuotaForecastOutput, error) {
+	input := api.QuotaForecastInput{}
+	err := query.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal input error: %v", err)
+	}
+	if input.VcpuCount < 0 || input.VmemSize < 0 || input.DiskSize < 0 || input.Count < 0 {
+		return nil, httperrors.NewInputParameterError("forecast parameters must not be negative")
+	}
+	ownerId, _, err, _ := db.FetchCheckQueryOwnerScope(ctx, userCred, query, manager, policy.PolicyActionGet, true)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &SQuota{
+		Count:   1,
+		Cpu:     input.VcpuCount,
+		Memory:  input.VmemSize,
+		Storage: input.DiskSize,
+	}
+	request.SetKeys(quotaReservationKeys(ownerId))
+	ret := &api.QuotaForecastOutput{}
+	ret.Allocable, err = quotas.GetQuotaCount(ctx, request, SComputeResourceKeys{})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+
+	reservations := []SQuotaReservation{}
+	q := manager.Query().Equals("tenant_id", ownerId.GetProjectId()).Equals("status", api.QUOTA_RESERVATION_STATUS_ACTIVE)
+	err = db.FetchModelObjects(manager, q, &reservations)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	reserved := &SQuota{}
+	for i := range reservations {
+		reserved.Add(reservations[i].getRemaining())
+	}
+	ret.ReservedCount = reserved.Count
+	ret.ReservedCpu = reserved.Cpu
+	ret.ReservedMemory = reserved.Memory
+	ret.ReservedStorage = reserved.Storage
+
+	// 本项目的预留额度可以被本项目新建的资源消耗
+	if ret.Allocable >= 0 && !reserved.IsEmpty() {
+		if cnt := reserved.Allocable(request); cnt > 0 {
+			ret.Allocable += cnt
+		}
+	}
+	ret.Satisfied = ret.Allocable < 0 || ret.Allocable >= input.Count
+	return ret, nil
+}
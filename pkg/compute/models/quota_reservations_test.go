@@ -0,0 +1,49 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestTakeQuotaReservation(t *testing.T) {
+	cases := []struct {
+		remaining SQuota
+		request   SQuota
+		want      SQuota
+	}{
+		{
+			remaining: SQuota{Count: 10, Cpu: 40, Memory: 81920, Storage: 409600},
+			request:   SQuota{Count: 1, Cpu: 4, Memory: 8192, Storage: 40960},
+			want:      SQuota{Count: 1, Cpu: 4, Memory: 8192, Storage: 40960},
+		},
+		{
+			remaining: SQuota{Count: 1, Cpu: 2},
+			request:   SQuota{Count: 2, Cpu: 8, Memory: 8192},
+			want:      SQuota{Count: 1, Cpu: 2},
+		},
+		{
+			remaining: SQuota{Cpu: 8},
+			request:   SQuota{Count: 1, Cpu: -1, Memory: 1024},
+			want:      SQuota{},
+		},
+	}
+	for i, c := range cases {
+		take := takeQuotaReservation(&c.remaining, &c.request)
+		if take.Count != c.want.Count || take.Cpu != c.want.Cpu || take.Memory != c.want.Memory || take.Storage != c.want.Storage {
+			t.Errorf("case %d: want %+v got %+v", i, c.want, *take)
+		}
+	}
+}
@@ -171,11 +171,15 @@ type IDBInstanceDriver interface {
 	RequestCreateDBInstanceFromBackup(ctx context.Context, userCred mcclient.TokenCredential, dbinstance *SDBInstance, task taskman.ITask) error
 	RequestCreateDBInstanceBackup(ctx context.Context, userCred mcclient.TokenCredential, instance *SDBInstance, backup *SDBInstanceBackup, task taskman.ITask) error
 	RequestChangeDBInstanceConfig(ctx context.Context, userCred mcclient.TokenCredential, instance *SDBInstance, input *api.SDBInstanceChangeConfigInput, task taskman.ITask) error
+	ValidateChangeDBInstanceConfigData(ctx context.Context, userCred mcclient.TokenCredential, instance *SDBInstance, input *api.SDBInstanceChangeConfigInput) error
+	RequestUpdateDBInstanceParameters(ctx context.Context, userCred mcclient.TokenCredential, instance *SDBInstance, input api.DBInstanceUpdateParametersInput, task taskman.ITask) error
 
 	IsSupportedDBInstance() bool
 	IsSupportedDBInstanceAutoRenew() bool
 	IsSupportDBInstancePublicConnection() bool
 	IsSupportKeepDBInstanceManualBackup() bool
+	// 是否支持修改实例参数
+	IsSupportDBInstanceParameterUpdate() bool
 
 	InitDBInstanceUser(ctx context.Context, dbinstance *SDBInstance, task taskman.ITask, desc *cloudprovider.SManagedDBInstanceCreateConfig) error
 	GetRdsSupportSecgroupCount() int
@@ -196,6 +200,8 @@ type IDBInstanceDriver interface {
 type IWafDriver interface {
 	ValidateCreateWafInstanceData(ctx context.Context, userCred mcclient.TokenCredential, input api.WafInstanceCreateInput) (api.WafInstanceCreateInput, error)
 	ValidateCreateWafRuleData(ctx context.Context, userCred mcclient.TokenCredential, waf *SWafInstance, input api.WafRuleCreateInput) (api.WafRuleCreateInput, error)
+	// 检查WAF模板规则能否应用到此平台的WAF实例
+	ValidateApplyWafPolicyTemplate(ctx context.Context, userCred mcclient.TokenCredential, waf *SWafInstance, rules api.WafPolicyTemplateRules) error
 }
 
 type INasDriver interface {
@@ -218,6 +224,8 @@ type IElasticcacheDriver interface {
 	IsSupportedElasticcacheSecgroup() bool
 	IsSupportedElasticcacheAutoRenew() bool
 	GetMaxElasticcacheSecurityGroupCount() int
+	// 指定引擎的弹性缓存支持的管理操作
+	GetElasticcacheManageCapability(engine string) api.ElasticcacheManageCapability
 
 	AllowCreateElasticcacheBackup(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, elasticcache *SElasticcache) error
 	AllowUpdateElasticcacheAuthMode(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, elasticcache *SElasticcache) error
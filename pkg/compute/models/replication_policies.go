@@ -0,0 +1,357 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SReplicationPolicyManager struct {
+	db.SEnabledStatusInfrasResourceBaseManager
+	SCloudregionResourceBaseManager
+}
+
+var ReplicationPolicyManager *SReplicationPolicyManager
+
+func init() {
+	ReplicationPolicyManager = &SReplicationPolicyManager{
+		SEnabledStatusInfrasResourceBaseManager: db.NewEnabledStatusInfrasResourceBaseManager(
+			SReplicationPolicy{},
+			"replication_policies_tbl",
+			"replication_policy",
+			"replication_policies",
+		),
+	}
+	ReplicationPolicyManager.SetVirtualObject(ReplicationPolicyManager)
+}
+
+// 复制策略, 源区域缓存的镜像自动缓存到目标区域
+type SReplicationPolicy struct {
+	db.SEnabledStatusInfrasResourceBase
+	// 源区域
+	SCloudregionResourceBase `width:"36" charset:"ascii" nullable:"false" list:"domain" create:"required"`
+
+	// 复制的资源类型
+	ResourceType string `width:"16" charset:"ascii" nullable:"false" list:"domain" create:"required" index:"true"`
+	// 复制目标
+	Targets *api.SReplicationTargets `length:"text" list:"domain" create:"required" update:"domain"`
+}
+
+func (manager *SReplicationPolicyManager) validateTargets(userCred mcclient.TokenCredential, resourceType string, source *SCloudregion, targets []api.SReplicationTarget) ([]api.SReplicationTarget, error) {
+	if len(targets) == 0 {
+		return nil, httperrors.NewMissingParameterError("targets")
+	}
+	ret := []api.SReplicationTarget{}
+	keys := map[string]bool{}
+	for i := range targets {
+		target := targets[i]
+		regionObj, err := validators.ValidateModel(userCred, CloudregionManager, &target.CloudregionId)
+		if err != nil {
+			return nil, err
+		}
+		region := regionObj.(*SCloudregion)
+		if len(target.ManagerId) > 0 {
+			providerObj, err := validators.ValidateModel(userCred, CloudproviderManager, &target.ManagerId)
+			if err != nil {
+				return nil, err
+			}
+			provider := providerObj.(*SCloudprovider)
+			if provider.Provider != region.Provider {
+				return nil, httperrors.NewInputParameterError("cloudprovider %s does not belong to region %s", provider.Name, region.Name)
+			}
+		}
+		key := target.CloudregionId + "/" + target.ManagerId
+		if keys[key] {
+			return nil, httperrors.NewDuplicateResourceError("duplicate target %s", key)
+		}
+		keys[key] = true
+		ret = append(ret, target)
+	}
+	return ret, nil
+}
+
+func (manager *SReplicationPolicyManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ReplicationPolicyCreateInput) (api.ReplicationPolicyCreateInput, error) {
+	if !utils.IsInStringArray(input.ResourceType, api.REPLICATION_RESOURCE_TYPES) {
+		return input, httperrors.NewInputParameterError("invalid resource_type %s, supported: %s", input.ResourceType, api.REPLICATION_RESOURCE_TYPES)
+	}
+	regionObj, err := validators.ValidateModel(userCred, CloudregionManager, &input.CloudregionId)
+	if err != nil {
+		return input, err
+	}
+	input.Targets, err = manager.validateTargets(userCred, input.ResourceType, regionObj.(*SCloudregion), input.Targets)
+	if err != nil {
+		return input, err
+	}
+	input.EnabledStatusInfrasResourceBaseCreateInput, err = manager.SEnabledStatusInfrasResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusInfrasResourceBaseCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ValidateCreateData")
+	}
+	input.Status = api.REPLICATION_POLICY_STATUS_READY
+	return input, nil
+}
+
+func (self *SReplicationPolicy) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ReplicationPolicyUpdateInput) (api.ReplicationPolicyUpdateInput, error) {
+	var err error
+	if len(input.Targets) > 0 {
+		region, err := self.GetRegion()
+		if err != nil {
+			return input, httperrors.NewGeneralError(err)
+		}
+		input.Targets, err = ReplicationPolicyManager.validateTargets(userCred, self.ResourceType, region, input.Targets)
+		if err != nil {
+			return input, err
+		}
+	}
+	input.EnabledStatusInfrasResourceBaseUpdateInput, err = self.SEnabledStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusInfrasResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+func (self *SReplicationPolicy) GetRecords() ([]SReplicationRecord, error) {
+	q := ReplicationRecordManager.Query().Equals("replication_policy_id", self.Id)
+	ret := []SReplicationRecord{}
+	err := db.FetchModelObjects(ReplicationRecordManager, q, &ret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.FetchModelObjects")
+	}
+	return ret, nil
+}
+
+func (self *SReplicationPolicy) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := ReplicationRecordManager.Query().Equals("replication_policy_id", self.Id).
+		In("status", []string{api.REPLICATION_RECORD_STATUS_PENDING, api.REPLICATION_RECORD_STATUS_REPLICATING}).CountWithError()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("replication policy has %d replicating records", cnt)
+	}
+	return self.SEnabledStatusInfrasResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (self *SReplicationPolicy) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	records, err := self.GetRecords()
+	if err != nil {
+		return errors.Wrapf(err, "GetRecords")
+	}
+	for i := range records {
+		err = records[i].Delete(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "delete record %s", records[i].Id)
+		}
+	}
+	return self.SEnabledStatusInfrasResourceBase.Delete(ctx, userCred)
+}
+
+// 资源在源区域创建完成后, 按照启用的复制策略生成复制记录并开始复制
+func (manager *SReplicationPolicyManager) StartReplication(ctx context.Context, userCred mcclient.TokenCredential, resourceType, resourceId, resourceName, regionId, managerId string) {
+	q := manager.Query().Equals("resource_type", resourceType).Equals("cloudregion_id", regionId).IsTrue("enabled")
+	policies := []SReplicationPolicy{}
+	err := db.FetchModelObjects(manager, q, &policies)
+	if err != nil {
+		log.Errorf("fetch replication policies for %s %s error: %v", resourceType, resourceId, err)
+		return
+	}
+	for i := range policies {
+		if policies[i].Targets == nil {
+			continue
+		}
+		for _, target := range *policies[i].Targets {
+			// 同区域同订阅无需复制
+			if target.CloudregionId == regionId && target.ManagerId == managerId {
+				continue
+			}
+			record, err := ReplicationRecordManager.newRecord(ctx, userCred, &policies[i], resourceId, resourceName, target)
+			if err != nil {
+				log.Errorf("create replication record of %s %s to %s error: %v", resourceType, resourceId, target.CloudregionId, err)
+				continue
+			}
+			if record == nil {
+				continue
+			}
+			err = record.StartReplicateTask(ctx, userCred)
+			if err != nil {
+				record.SetStatus(userCred, api.REPLICATION_RECORD_STATUS_FAILED, err.Error())
+			}
+		}
+	}
+}
+
+// 复制策略列表
+func (manager *SReplicationPolicyManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.ReplicationPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.ListItemFilter(ctx, q, userCred, input.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SCloudregionResourceBaseManager.ListItemFilter(ctx, q, userCred, input.RegionalFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.ListItemFilter")
+	}
+	if len(input.ResourceType) > 0 {
+		q = q.In("resource_type", input.ResourceType)
+	}
+	return q, nil
+}
+
+func (manager *SReplicationPolicyManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.ReplicationPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SCloudregionResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.RegionalFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SReplicationPolicyManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	q, err = manager.SCloudregionResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SReplicationPolicyManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ListItemExportKeys")
+	}
+	if keys.ContainsAny(manager.SCloudregionResourceBaseManager.GetExportKeys()...) {
+		q, err = manager.SCloudregionResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+		if err != nil {
+			return nil, errors.Wrap(err, "SCloudregionResourceBaseManager.ListItemExportKeys")
+		}
+	}
+	return q, nil
+}
+
+func (manager *SReplicationPolicyManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ReplicationPolicyDetails {
+	rows := make([]api.ReplicationPolicyDetails, len(objs))
+
+	stdRows := manager.SEnabledStatusInfrasResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	regionRows := manager.SCloudregionResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	policyIds, regionIds, managerIds := make([]string, len(objs)), []string{}, []string{}
+	for i := range rows {
+		rows[i] = api.ReplicationPolicyDetails{
+			EnabledStatusInfrasResourceBaseDetails: stdRows[i],
+			CloudregionResourceInfo:                regionRows[i],
+			RecordStatus:                           map[string]int{},
+		}
+		policy := objs[i].(*SReplicationPolicy)
+		policyIds[i] = policy.Id
+		if policy.Targets != nil {
+			for _, target := range *policy.Targets {
+				regionIds = append(regionIds, target.CloudregionId)
+				managerIds = append(managerIds, target.ManagerId)
+			}
+		}
+	}
+
+	regionNames, err := db.FetchIdNameMap2(CloudregionManager, regionIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 cloudregions error: %v", err)
+		return rows
+	}
+	managerNames, err := db.FetchIdNameMap2(CloudproviderManager, managerIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 cloudproviders error: %v", err)
+		return rows
+	}
+	for i := range rows {
+		policy := objs[i].(*SReplicationPolicy)
+		if policy.Targets == nil {
+			continue
+		}
+		for _, target := range *policy.Targets {
+			rows[i].TargetDetails = append(rows[i].TargetDetails, api.ReplicationTargetDetails{
+				SReplicationTarget: target,
+				Cloudregion:        regionNames[target.CloudregionId],
+				Manager:            managerNames[target.ManagerId],
+			})
+		}
+	}
+
+	q := ReplicationRecordManager.Query().In("replication_policy_id", policyIds)
+	q = q.AppendField(q.Field("replication_policy_id"), q.Field("status"), sqlchemy.COUNT("count"))
+	q = q.GroupBy(q.Field("replication_policy_id"), q.Field("status"))
+	stats := []struct {
+		ReplicationPolicyId string
+		Status              string
+		Count               int
+	}{}
+	err = q.All(&stats)
+	if err != nil {
+		log.Errorf("query replication record stats error: %v", err)
+		return rows
+	}
+	for i := range rows {
+		for _, stat := range stats {
+			if stat.ReplicationPolicyId == policyIds[i] {
+				rows[i].RecordStatus[stat.Status] = stat.Count
+			}
+		}
+	}
+	return rows
+}
@@ -0,0 +1,309 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SReplicationRecordManager struct {
+	db.SStatusStandaloneResourceBaseManager
+	db.SProjectizedResourceBaseManager
+}
+
+var ReplicationRecordManager *SReplicationRecordManager
+
+func init() {
+	ReplicationRecordManager = &SReplicationRecordManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SReplicationRecord{},
+			"replication_records_tbl",
+			"replication_record",
+			"replication_records",
+		),
+	}
+	ReplicationRecordManager.SetVirtualObject(ReplicationRecordManager)
+}
+
+// 复制记录, 记录资源复制到每个目标的状态
+type SReplicationRecord struct {
+	db.SStatusStandaloneResourceBase
+	// 复制记录归属触发镜像缓存的用户项目
+	db.SProjectizedResourceBase
+
+	// 复制策略ID
+	ReplicationPolicyId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	// 资源类型
+	ResourceType string `width:"16" charset:"ascii" nullable:"false" list:"user"`
+	// 源快照或镜像ID
+	ResourceId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	// 目标区域ID
+	TargetCloudregionId string `width:"36" charset:"ascii" nullable:"false" list:"user"`
+	// 目标云订阅ID
+	TargetManagerId string `width:"36" charset:"ascii" nullable:"true" list:"user"`
+	// 目标镜像缓存ID
+	TargetStoragecacheId string `width:"36" charset:"ascii" nullable:"true" list:"user"`
+	// 目标资源的云上ID
+	TargetExternalId string `width:"256" charset:"utf8" nullable:"true" list:"user"`
+}
+
+func (manager *SReplicationRecordManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return nil, httperrors.NewUnsupportOperationError("replication records are created by replication policies")
+}
+
+func (manager *SReplicationRecordManager) newRecord(ctx context.Context, ownerId mcclient.IIdentityProvider, policy *SReplicationPolicy, resourceId, resourceName string, target api.SReplicationTarget) (*SReplicationRecord, error) {
+	lockman.LockRawObject(ctx, manager.Keyword(), resourceId)
+	defer lockman.ReleaseRawObject(ctx, manager.Keyword(), resourceId)
+
+	q := manager.Query().Equals("replication_policy_id", policy.Id).Equals("resource_id", resourceId).
+		Equals("target_cloudregion_id", target.CloudregionId)
+	if len(target.ManagerId) > 0 {
+		q = q.Equals("target_manager_id", target.ManagerId)
+	} else {
+		q = q.IsNullOrEmpty("target_manager_id")
+	}
+	cnt, err := q.CountWithError()
+	if err != nil {
+		return nil, errors.Wrapf(err, "CountWithError")
+	}
+	// 已经复制过, 避免多个策略之间循环复制
+	if cnt > 0 {
+		return nil, nil
+	}
+
+	record := &SReplicationRecord{}
+	record.SetModelManager(manager, record)
+	record.Name = fmt.Sprintf("%s-%s", resourceName, target.CloudregionId)
+	record.Status = api.REPLICATION_RECORD_STATUS_PENDING
+	record.ReplicationPolicyId = policy.Id
+	record.ResourceType = policy.ResourceType
+	record.ResourceId = resourceId
+	record.TargetCloudregionId = target.CloudregionId
+	record.TargetManagerId = target.ManagerId
+	record.ProjectId = ownerId.GetProjectId()
+	record.DomainId = ownerId.GetProjectDomainId()
+	err = manager.TableSpec().Insert(ctx, record)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Insert")
+	}
+	return record, nil
+}
+
+func (self *SReplicationRecord) StartReplicateTask(ctx context.Context, userCred mcclient.TokenCredential) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "ReplicationRecordTask", self, userCred, nil, "", "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask")
+	}
+	self.SetStatus(userCred, api.REPLICATION_RECORD_STATUS_REPLICATING, "")
+	return task.ScheduleRun(nil)
+}
+
+// 重新复制失败的记录
+func (self *SReplicationRecord) PerformRetry(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ReplicationRecordRetryInput) (jsonutils.JSONObject, error) {
+	if self.Status != api.REPLICATION_RECORD_STATUS_FAILED {
+		return nil, httperrors.NewInvalidStatusError("cannot retry replication in status %s", self.Status)
+	}
+	return nil, self.StartReplicateTask(ctx, userCred)
+}
+
+func (self *SReplicationRecord) GetTargetRegion() (*SCloudregion, error) {
+	region, err := CloudregionManager.FetchById(self.TargetCloudregionId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "CloudregionManager.FetchById(%s)", self.TargetCloudregionId)
+	}
+	return region.(*SCloudregion), nil
+}
+
+// 查找目标区域中属于目标云订阅的镜像缓存
+func (self *SReplicationRecord) GetTargetStoragecache() (*SStoragecache, error) {
+	region, err := self.GetTargetRegion()
+	if err != nil {
+		return nil, err
+	}
+	caches, err := region.GetStoragecaches()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetStoragecaches")
+	}
+	for i := range caches {
+		if caches[i].ManagerId == self.TargetManagerId {
+			caches[i].SetModelManager(StoragecacheManager, &caches[i])
+			return &caches[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storagecache of %s in region %s", self.TargetManagerId, region.Name)
+}
+
+// 将镜像缓存到目标区域, 缓存完成后回调父任务
+func (self *SReplicationRecord) StartImageCache(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	cache, err := self.GetTargetStoragecache()
+	if err != nil {
+		return err
+	}
+	_, err = db.Update(self, func() error {
+		self.TargetStoragecacheId = cache.Id
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "db.Update")
+	}
+	return cache.StartImageCacheTask(ctx, userCred, api.CacheImageInput{ImageId: self.ResourceId, ParentTaskId: parentTaskId})
+}
+
+func (self *SReplicationRecord) SetTargetExternalId(externalId string) error {
+	_, err := db.Update(self, func() error {
+		self.TargetExternalId = externalId
+		return nil
+	})
+	return err
+}
+
+// 复制记录列表
+func (manager *SReplicationRecordManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.ReplicationRecordListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, input.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	if len(input.ReplicationPolicyId) > 0 {
+		policyObj, err := ReplicationPolicyManager.FetchByIdOrName(userCred, input.ReplicationPolicyId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(ReplicationPolicyManager.Keyword(), input.ReplicationPolicyId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("replication_policy_id", policyObj.GetId())
+	}
+	if len(input.ResourceType) > 0 {
+		q = q.In("resource_type", input.ResourceType)
+	}
+	if len(input.ResourceId) > 0 {
+		q = q.In("resource_id", input.ResourceId)
+	}
+	if len(input.TargetCloudregionId) > 0 {
+		q = q.In("target_cloudregion_id", input.TargetCloudregionId)
+	}
+	return q, nil
+}
+
+func (manager *SReplicationRecordManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.ReplicationRecordListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SReplicationRecordManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+	)
+}
+
+func (manager *SReplicationRecordManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	return db.ApplyListItemExportKeys(ctx, q, userCred, keys,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+	)
+}
+
+func (manager *SReplicationRecordManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ReplicationRecordDetails {
+	rows := make([]api.ReplicationRecordDetails, len(objs))
+
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	policyIds, regionIds, managerIds := make([]string, len(objs)), make([]string, len(objs)), make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.ReplicationRecordDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+			ProjectizedResourceInfo:         projRows[i],
+		}
+		record := objs[i].(*SReplicationRecord)
+		policyIds[i] = record.ReplicationPolicyId
+		regionIds[i] = record.TargetCloudregionId
+		managerIds[i] = record.TargetManagerId
+	}
+
+	policyNames, err := db.FetchIdNameMap2(ReplicationPolicyManager, policyIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 replication policies error: %v", err)
+		return rows
+	}
+	regionNames, err := db.FetchIdNameMap2(CloudregionManager, regionIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 cloudregions error: %v", err)
+		return rows
+	}
+	managerNames, err := db.FetchIdNameMap2(CloudproviderManager, managerIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 cloudproviders error: %v", err)
+		return rows
+	}
+	for i := range rows {
+		rows[i].ReplicationPolicy = policyNames[policyIds[i]]
+		rows[i].TargetCloudregion = regionNames[regionIds[i]]
+		rows[i].TargetManager = managerNames[managerIds[i]]
+	}
+	return rows
+}
@@ -0,0 +1,308 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SResourceLockManager struct {
+	db.SStatusStandaloneResourceBaseManager
+	db.SProjectizedResourceBaseManager
+}
+
+var ResourceLockManager *SResourceLockManager
+
+func init() {
+	ResourceLockManager = &SResourceLockManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SResourceLock{},
+			"resource_locks_tbl",
+			"resource_lock",
+			"resource_locks",
+		),
+	}
+	ResourceLockManager.SetVirtualObject(ResourceLockManager)
+}
+
+// 资源锁, 锁定期间禁止删除或关机
+type SResourceLock struct {
+	db.SStatusStandaloneResourceBase
+	db.SProjectizedResourceBase
+
+	// 被锁定的资源类型
+	ResourceType string `width:"16" charset:"ascii" nullable:"false" list:"user" create:"required"`
+	// 被锁定的资源ID
+	ResourceId string `width:"36" charset:"ascii" nullable:"false" list:"user" create:"required" index:"true"`
+	// 锁类型
+	LockType string `width:"16" charset:"ascii" nullable:"false" list:"user" create:"required"`
+}
+
+func (manager *SResourceLockManager) getResourceManager(resourceType string) db.IStandaloneModelManager {
+	switch resourceType {
+	case api.RESOURCE_LOCK_RESOURCE_SERVER:
+		return GuestManager
+	case api.RESOURCE_LOCK_RESOURCE_DISK:
+		return DiskManager
+	case api.RESOURCE_LOCK_RESOURCE_EIP:
+		return ElasticipManager
+	}
+	return nil
+}
+
+func (manager *SResourceLockManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ResourceLockCreateInput) (api.ResourceLockCreateInput, error) {
+	if !utils.IsInStringArray(input.ResourceType, api.RESOURCE_LOCK_RESOURCES) {
+		return input, httperrors.NewInputParameterError("invalid resource_type %s, supported: %s", input.ResourceType, api.RESOURCE_LOCK_RESOURCES)
+	}
+	if !utils.IsInStringArray(input.LockType, api.RESOURCE_LOCK_TYPES) {
+		return input, httperrors.NewInputParameterError("invalid lock_type %s, supported: %s", input.LockType, api.RESOURCE_LOCK_TYPES)
+	}
+	if input.LockType == api.RESOURCE_LOCK_TYPE_STOP && input.ResourceType != api.RESOURCE_LOCK_RESOURCE_SERVER {
+		return input, httperrors.NewInputParameterError("stop lock only applies to server")
+	}
+	obj, err := validators.ValidateModel(userCred, manager.getResourceManager(input.ResourceType), &input.ResourceId)
+	if err != nil {
+		return input, err
+	}
+	cnt, err := manager.Query().Equals("resource_id", input.ResourceId).Equals("lock_type", input.LockType).CountWithError()
+	if err != nil {
+		return input, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return input, httperrors.NewDuplicateResourceError("%s %s already has %s lock", input.ResourceType, obj.GetName(), input.LockType)
+	}
+	if len(input.Name) == 0 && len(input.GenerateName) == 0 {
+		input.GenerateName = fmt.Sprintf("%s-%s-lock", obj.GetName(), input.LockType)
+	}
+	input.StatusStandaloneResourceCreateInput, err = manager.SStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ValidateCreateData")
+	}
+	input.Status = api.RESOURCE_LOCK_STATUS_LOCKED
+	return input, nil
+}
+
+func (self *SResourceLock) getResource() (db.IVirtualModel, error) {
+	manager := ResourceLockManager.getResourceManager(self.ResourceType)
+	if manager == nil {
+		return nil, errors.Wrapf(cloudprovider.ErrNotSupported, "resource type %s", self.ResourceType)
+	}
+	obj, err := manager.FetchById(self.ResourceId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s.FetchById(%s)", manager.Keyword(), self.ResourceId)
+	}
+	return obj.(db.IVirtualModel), nil
+}
+
+// 资源锁归属于被锁定资源所在的项目
+func (self *SResourceLock) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	resource, err := self.getResource()
+	if err != nil {
+		return err
+	}
+	owner := resource.GetOwnerId()
+	self.ProjectId = owner.GetProjectId()
+	self.DomainId = owner.GetProjectDomainId()
+	return self.SStatusStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+// 检查资源是否被锁定, 资源被锁定时返回ForbiddenError
+func (manager *SResourceLockManager) checkLocked(resourceType, lockType string, resourceIds []string) error {
+	if len(resourceIds) == 0 {
+		return nil
+	}
+	lock := &SResourceLock{}
+	q := manager.Query().Equals("resource_type", resourceType).Equals("lock_type", lockType).In("resource_id", resourceIds)
+	err := q.First(lock)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil
+		}
+		return httperrors.NewGeneralError(err)
+	}
+	return httperrors.NewForbiddenError("%s %s is locked by %s lock %s, remove the lock first", resourceType, lock.ResourceId, lockType, lock.Name)
+}
+
+// 主机删除时一并删除的磁盘及自动释放的EIP被锁定时, 主机同样不能删除
+func (guest *SGuest) validateDeleteLock() error {
+	err := ResourceLockManager.checkLocked(api.RESOURCE_LOCK_RESOURCE_SERVER, api.RESOURCE_LOCK_TYPE_DELETE, []string{guest.Id})
+	if err != nil {
+		return err
+	}
+	disks, err := guest.GetDisks()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	diskIds := []string{}
+	for i := range disks {
+		diskIds = append(diskIds, disks[i].Id)
+	}
+	err = ResourceLockManager.checkLocked(api.RESOURCE_LOCK_RESOURCE_DISK, api.RESOURCE_LOCK_TYPE_DELETE, diskIds)
+	if err != nil {
+		return err
+	}
+	eip, _ := guest.GetElasticIp()
+	if eip != nil && eip.AutoDellocate.IsTrue() {
+		return ResourceLockManager.checkLocked(api.RESOURCE_LOCK_RESOURCE_EIP, api.RESOURCE_LOCK_TYPE_DELETE, []string{eip.Id})
+	}
+	return nil
+}
+
+func (guest *SGuest) validateStopLock() error {
+	return ResourceLockManager.checkLocked(api.RESOURCE_LOCK_RESOURCE_SERVER, api.RESOURCE_LOCK_TYPE_STOP, []string{guest.Id})
+}
+
+func (disk *SDisk) validateDeleteLock() error {
+	return ResourceLockManager.checkLocked(api.RESOURCE_LOCK_RESOURCE_DISK, api.RESOURCE_LOCK_TYPE_DELETE, []string{disk.Id})
+}
+
+func (eip *SElasticip) validateDeleteLock() error {
+	return ResourceLockManager.checkLocked(api.RESOURCE_LOCK_RESOURCE_EIP, api.RESOURCE_LOCK_TYPE_DELETE, []string{eip.Id})
+}
+
+// 资源被清除后删除其上的资源锁
+func (manager *SResourceLockManager) deleteByResource(ctx context.Context, userCred mcclient.TokenCredential, resourceId string) error {
+	locks := []SResourceLock{}
+	err := db.FetchModelObjects(manager, manager.Query().Equals("resource_id", resourceId), &locks)
+	if err != nil {
+		return errors.Wrap(err, "fetch resource locks")
+	}
+	for i := range locks {
+		lockman.LockObject(ctx, &locks[i])
+		err := locks[i].Delete(ctx, userCred)
+		lockman.ReleaseObject(ctx, &locks[i])
+		if err != nil {
+			return errors.Wrapf(err, "delete resource lock %s", locks[i].Id)
+		}
+	}
+	return nil
+}
+
+// 资源锁列表
+func (manager *SResourceLockManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ResourceLockListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.In("resource_type", query.ResourceType)
+	}
+	if len(query.ResourceId) > 0 {
+		q = q.In("resource_id", query.ResourceId)
+	}
+	if len(query.LockType) > 0 {
+		q = q.In("lock_type", query.LockType)
+	}
+	return q, nil
+}
+
+func (manager *SResourceLockManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ResourceLockListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SResourceLockManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+	)
+}
+
+func (manager *SResourceLockManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	return db.ApplyListItemExportKeys(ctx, q, userCred, keys,
+		&manager.SStatusStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+	)
+}
+
+func (manager *SResourceLockManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ResourceLockDetails {
+	rows := make([]api.ResourceLockDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	resourceIds := map[string][]string{}
+	for i := range rows {
+		rows[i] = api.ResourceLockDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+			ProjectizedResourceInfo:         projRows[i],
+		}
+		lock := objs[i].(*SResourceLock)
+		resourceIds[lock.ResourceType] = append(resourceIds[lock.ResourceType], lock.ResourceId)
+	}
+	names := map[string]map[string]string{}
+	for resourceType, ids := range resourceIds {
+		idNames, err := db.FetchIdNameMap2(manager.getResourceManager(resourceType), ids)
+		if err != nil {
+			log.Errorf("FetchIdNameMap2 %s error: %v", resourceType, err)
+			continue
+		}
+		names[resourceType] = idNames
+	}
+	for i := range rows {
+		lock := objs[i].(*SResourceLock)
+		rows[i].Resource = names[lock.ResourceType][lock.ResourceId]
+	}
+	return rows
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestResourceLockGetResourceManager(t *testing.T) {
+	cases := []struct {
+		resourceType string
+		want         string
+	}{
+		{api.RESOURCE_LOCK_RESOURCE_SERVER, "server"},
+		{api.RESOURCE_LOCK_RESOURCE_DISK, "disk"},
+		{api.RESOURCE_LOCK_RESOURCE_EIP, "eip"},
+		{"network", ""},
+	}
+	for _, c := range cases {
+		manager := ResourceLockManager.getResourceManager(c.resourceType)
+		got := ""
+		if manager != nil {
+			got = manager.Keyword()
+		}
+		if got != c.want {
+			t.Errorf("resource type %s: want manager %q, got %q", c.resourceType, c.want, got)
+		}
+	}
+}
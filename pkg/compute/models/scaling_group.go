@@ -123,6 +123,40 @@ func (sgm *SScalingGroupManager) ValidateCreateData(ctx context.Context, userCre
 			"desire_instance_number should between min_instance_number and max_instance_number")
 	}
 
+	// check targets
+	if len(input.Targets) > 0 {
+		hasWeight := false
+		for i := range input.Targets {
+			err := sgm.validateTarget(ctx, userCred, ownerId, &input.Targets[i])
+			if err != nil {
+				return input, errors.Wrapf(err, "targets.%d", i)
+			}
+			if *input.Targets[i].Weight > 0 {
+				hasWeight = true
+			}
+		}
+		if !hasWeight {
+			return input, httperrors.NewInputParameterError("at least one target should have positive weight")
+		}
+		// 未指定默认配置时使用第一个目标
+		if len(input.Cloudregion) == 0 && len(input.CloudregionId) == 0 {
+			first := input.Targets[0]
+			input.Hypervisor = first.Hypervisor
+			input.CloudregionId = first.CloudregionId
+			input.Networks = []string{first.NetworkId}
+			input.GuestTemplateId = first.GuestTemplateId
+			network, err := NetworkManager.FetchById(first.NetworkId)
+			if err != nil {
+				return input, errors.Wrap(err, "NetworkManager.FetchById")
+			}
+			vpc, err := network.(*SNetwork).GetVpc()
+			if err != nil {
+				return input, errors.Wrap(err, "GetVpc")
+			}
+			input.VpcId = vpc.Id
+		}
+	}
+
 	// check cloudregion
 	idOrName := input.Cloudregion
 	if len(input.CloudregionId) != 0 {
@@ -256,7 +290,11 @@ func (sg *SScalingGroup) Delete(ctx context.Context, userCred mcclient.TokenCred
 }
 
 func (sg *SScalingGroup) RealDelete(ctx context.Context, userCred mcclient.TokenCredential) error {
-	err := db.DeleteModel(ctx, userCred, sg)
+	err := sg.removeTargets(ctx, userCred)
+	if err != nil {
+		return errors.Wrap(err, "removeTargets")
+	}
+	err = db.DeleteModel(ctx, userCred, sg)
 	if err != nil {
 		return errors.Wrap(err, "db.DeleteModel")
 	}
@@ -370,6 +408,7 @@ func (sgm *SScalingGroupManager) FetchCustomizeColumns(
 		n, _ = sg.ScalingPolicyNumber()
 		rows[i].ScalingPolicyNumber = n
 		rows[i].Brand = Hypervisor2Brand(sg.Hypervisor)
+		rows[i].Targets = sg.getTargetDetails()
 		nets, err := sg.Networks()
 		if err != nil {
 			log.Errorf("sg.Networks error: %s", err)
@@ -621,6 +660,20 @@ func (sg *SScalingGroup) PostCreate(ctx context.Context, userCred mcclient.Token
 			return
 		}
 	}
+	// attach with targets
+	if data.Contains("targets") {
+		targets := []api.ScalingGroupTargetInput{}
+		data.Unmarshal(&targets, "targets")
+		for i := range targets {
+			_, err := ScalingGroupTargetManager.Attach(ctx, sg.Id, targets[i])
+			if err != nil {
+				reason := fmt.Sprintf("Attach ScalingGroup '%s' with target in cloudregion '%s' failed: %s", sg.Id, targets[i].CloudregionId, err.Error())
+				sg.SetStatus(userCred, api.SG_STATUS_CREATE_FAILED, reason)
+				logclient.AddActionLogWithContext(ctx, sg, logclient.ACT_CREATE, reason, userCred, false)
+				return
+			}
+		}
+	}
 	now := time.Now()
 	db.Update(sg, func() error {
 		sg.Status = api.SG_STATUS_READY
@@ -53,19 +53,22 @@ type SScalingGroupGuest struct {
 	ScalingGroupId string            `width:"36" charset:"ascii" nullable:"false"`
 	GuestStatus    string            `width:"36" charset:"ascii" nullable:"false" index:"true"`
 	Manual         tristate.TriState `default:"false"`
+	// 实例所属的分布目标, 单一配置的伸缩组为空
+	TargetId string `width:"36" charset:"ascii" nullable:"false" default:""`
 }
 
 func (sggm *SScalingGroupGuestManager) GetSlaveFieldName() string {
 	return "scaling_group_id"
 }
 
-func (sggm *SScalingGroupGuestManager) Attach(ctx context.Context, scaligGroupId, guestId string, manual bool) error {
+func (sggm *SScalingGroupGuestManager) Attach(ctx context.Context, scaligGroupId, guestId, targetId string, manual bool) error {
 	sgg := &SScalingGroupGuest{
 		SGuestJointsBase: SGuestJointsBase{
 			GuestId: guestId,
 		},
 		ScalingGroupId: scaligGroupId,
 		GuestStatus:    compute.SG_GUEST_STATUS_JOINING,
+		TargetId:       targetId,
 	}
 	if manual {
 		sgg.Manual = tristate.True
@@ -0,0 +1,428 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// 伸缩组的实例分布目标, 一个伸缩组可以按权重在多个平台/区域间分布实例
+type SScalingGroupTargetManager struct {
+	db.SStandaloneAnonResourceBaseManager
+}
+
+var ScalingGroupTargetManager *SScalingGroupTargetManager
+
+func init() {
+	ScalingGroupTargetManager = &SScalingGroupTargetManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SScalingGroupTarget{},
+			"scalinggrouptargets_tbl",
+			"scalinggrouptarget",
+			"scalinggrouptargets",
+		),
+	}
+	ScalingGroupTargetManager.SetVirtualObject(ScalingGroupTargetManager)
+}
+
+type SScalingGroupTarget struct {
+	db.SStandaloneAnonResourceBase
+
+	ScalingGroupId  string `width:"36" charset:"ascii" nullable:"false" index:"true"`
+	Hypervisor      string `width:"16" charset:"ascii" nullable:"false"`
+	CloudregionId   string `width:"36" charset:"ascii" nullable:"false"`
+	NetworkId       string `width:"36" charset:"ascii" nullable:"false"`
+	GuestTemplateId string `width:"36" charset:"ascii" nullable:"false"`
+
+	// 权重, 为0时仅用于故障转移
+	Weight int `nullable:"false" default:"1"`
+	// 故障转移优先级, 数值越小越优先
+	Priority int `nullable:"false" default:"0"`
+
+	// 冷却截止时间, 在此之前不再向该目标扩容
+	UnavailableTill time.Time `nullable:"true"`
+	LastFailure     string    `width:"256" charset:"utf8" nullable:"true"`
+}
+
+func (sgm *SScalingGroupManager) validateTarget(ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, target *api.ScalingGroupTargetInput) error {
+	if len(target.Hypervisor) == 0 {
+		return httperrors.NewMissingParameterError("hypervisor")
+	}
+	if target.Weight == nil {
+		weight := 1
+		target.Weight = &weight
+	}
+	if *target.Weight < 0 {
+		return httperrors.NewInputParameterError("invalid target weight %d", *target.Weight)
+	}
+
+	idOrName := target.Cloudregion
+	if len(target.CloudregionId) > 0 {
+		idOrName = target.CloudregionId
+	}
+	region, err := CloudregionManager.FetchByIdOrName(userCred, idOrName)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return httperrors.NewResourceNotFoundError2(CloudregionManager.Keyword(), idOrName)
+		}
+		return errors.Wrap(err, "CloudregionManager.FetchByIdOrName")
+	}
+	target.CloudregionId = region.GetId()
+
+	idOrName = target.Network
+	if len(target.NetworkId) > 0 {
+		idOrName = target.NetworkId
+	}
+	netObj, err := NetworkManager.FetchByIdOrName(userCred, idOrName)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return httperrors.NewResourceNotFoundError2(NetworkManager.Keyword(), idOrName)
+		}
+		return errors.Wrap(err, "NetworkManager.FetchByIdOrName")
+	}
+	network := netObj.(*SNetwork)
+	vpc, err := network.GetVpc()
+	if err != nil {
+		return errors.Wrapf(err, "GetVpc of network %s", network.Name)
+	}
+	if vpc.CloudregionId != target.CloudregionId {
+		return httperrors.NewInputParameterError("network %s not in cloudregion %s", network.Name, region.GetName())
+	}
+	target.NetworkId = network.Id
+
+	idOrName = target.GuestTemplate
+	if len(target.GuestTemplateId) > 0 {
+		idOrName = target.GuestTemplateId
+	}
+	gtObj, err := GuestTemplateManager.FetchByIdOrName(userCred, idOrName)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return httperrors.NewResourceNotFoundError2(GuestTemplateManager.Keyword(), idOrName)
+		}
+		return errors.Wrap(err, "GuestTemplateManager.FetchByIdOrName")
+	}
+	gt := gtObj.(*SGuestTemplate)
+	if ok, reason := gt.Validate(ctx, userCred, ownerId,
+		SGuestTemplateValidate{target.Hypervisor, target.CloudregionId, vpc.Id, []string{network.Id}}); !ok {
+		return httperrors.NewInputParameterError("the guest template %s is not valid in cloudregion %s, reason: %s",
+			idOrName, region.GetName(), reason)
+	}
+	target.GuestTemplateId = gt.Id
+	return nil
+}
+
+func (sgm *SScalingGroupTargetManager) Attach(ctx context.Context, scalingGroupId string, input api.ScalingGroupTargetInput) (*SScalingGroupTarget, error) {
+	target := &SScalingGroupTarget{
+		ScalingGroupId:  scalingGroupId,
+		Hypervisor:      input.Hypervisor,
+		CloudregionId:   input.CloudregionId,
+		NetworkId:       input.NetworkId,
+		GuestTemplateId: input.GuestTemplateId,
+		Priority:        input.Priority,
+	}
+	if input.Weight != nil {
+		target.Weight = *input.Weight
+	}
+	target.SetModelManager(sgm, target)
+	err := sgm.TableSpec().Insert(ctx, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "Insert")
+	}
+	return target, nil
+}
+
+func (target *SScalingGroupTarget) IsAvailable(now time.Time) bool {
+	return !target.UnavailableTill.After(now)
+}
+
+func (target *SScalingGroupTarget) GetStatus(now time.Time) string {
+	if target.IsAvailable(now) {
+		return api.SG_TARGET_STATUS_AVAILABLE
+	}
+	return api.SG_TARGET_STATUS_UNAVAILABLE
+}
+
+// MarkUnavailable 目标创建失败或实例均不健康时进入冷却, 冷却期内扩容会转移到其他目标
+func (target *SScalingGroupTarget) MarkUnavailable(reason string, cooldown time.Duration) error {
+	if len(reason) > 256 {
+		reason = reason[:256]
+	}
+	_, err := db.Update(target, func() error {
+		target.UnavailableTill = time.Now().Add(cooldown)
+		target.LastFailure = reason
+		return nil
+	})
+	return err
+}
+
+func (target *SScalingGroupTarget) GetGuestTemplate() (*SGuestTemplate, error) {
+	obj, err := GuestTemplateManager.FetchById(target.GuestTemplateId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch guest template %s", target.GuestTemplateId)
+	}
+	return obj.(*SGuestTemplate), nil
+}
+
+// ValidateTemplate 检查目标的主机模板在目标区域和网络中是否仍然可用
+func (target *SScalingGroupTarget) ValidateTemplate(ctx context.Context, userCred mcclient.TokenCredential) (*SGuestTemplate, error) {
+	gt, err := target.GetGuestTemplate()
+	if err != nil {
+		return nil, err
+	}
+	netObj, err := NetworkManager.FetchById(target.NetworkId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch network %s", target.NetworkId)
+	}
+	vpc, err := netObj.(*SNetwork).GetVpc()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetVpc")
+	}
+	if ok, reason := gt.Validate(ctx, userCred, gt.GetOwnerId(),
+		SGuestTemplateValidate{target.Hypervisor, target.CloudregionId, vpc.Id, []string{target.NetworkId}}); !ok {
+		return nil, errors.Error(reason)
+	}
+	return gt, nil
+}
+
+func (sg *SScalingGroup) Targets() ([]SScalingGroupTarget, error) {
+	q := ScalingGroupTargetManager.Query().Equals("scaling_group_id", sg.Id).Asc("priority")
+	targets := make([]SScalingGroupTarget, 0)
+	err := db.FetchModelObjects(ScalingGroupTargetManager, q, &targets)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return targets, nil
+}
+
+// TargetInstanceCounts 统计各分布目标内的实例数, 不属于任何目标的实例计入空字符串
+func (sg *SScalingGroup) TargetInstanceCounts() (map[string]int, error) {
+	q := ScalingGroupGuestManager.Query("target_id").Equals("scaling_group_id", sg.Id)
+	q = q.AppendField(sqlchemy.COUNT("count")).GroupBy(q.Field("target_id"))
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "Rows")
+	}
+	defer rows.Close()
+	ret := map[string]int{}
+	for rows.Next() {
+		var targetId string
+		var count int
+		err := rows.Scan(&targetId, &count)
+		if err != nil {
+			return nil, errors.Wrap(err, "rows.Scan")
+		}
+		ret[targetId] = count
+	}
+	return ret, nil
+}
+
+func (sg *SScalingGroup) getTargetDetails() []api.ScalingGroupTargetDetails {
+	targets, err := sg.Targets()
+	if err != nil {
+		log.Errorf("sg.Targets error: %s", err)
+		return nil
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	counts, err := sg.TargetInstanceCounts()
+	if err != nil {
+		log.Errorf("sg.TargetInstanceCounts error: %s", err)
+	}
+	now := time.Now()
+	ret := make([]api.ScalingGroupTargetDetails, 0, len(targets))
+	for i := range targets {
+		t := targets[i]
+		detail := api.ScalingGroupTargetDetails{
+			Id:              t.Id,
+			Hypervisor:      t.Hypervisor,
+			CloudregionId:   t.CloudregionId,
+			NetworkId:       t.NetworkId,
+			GuestTemplateId: t.GuestTemplateId,
+			Weight:          t.Weight,
+			Priority:        t.Priority,
+			Status:          t.GetStatus(now),
+			UnavailableTill: t.UnavailableTill,
+			LastFailure:     t.LastFailure,
+			InstanceNumber:  counts[t.Id],
+		}
+		if region, _ := CloudregionManager.FetchById(t.CloudregionId); region != nil {
+			detail.Cloudregion = region.GetName()
+		}
+		if network, _ := NetworkManager.FetchById(t.NetworkId); network != nil {
+			detail.Network = network.GetName()
+		}
+		if gt, _ := GuestTemplateManager.FetchById(t.GuestTemplateId); gt != nil {
+			detail.GuestTemplate = gt.GetName()
+		}
+		ret = append(ret, detail)
+	}
+	return ret
+}
+
+func (sg *SScalingGroup) PerformAddTarget(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupTargetInput) (jsonutils.JSONObject, error) {
+	err := ScalingGroupManager.validateTarget(ctx, userCred, sg.GetOwnerId(), &input)
+	if err != nil {
+		return nil, err
+	}
+	target, err := ScalingGroupTargetManager.Attach(ctx, sg.Id, input)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddActionLogWithContext(ctx, sg, logclient.ACT_SCALING_GROUP_ADD_TARGET, target, userCred, true)
+	return nil, nil
+}
+
+func (sg *SScalingGroup) PerformRemoveTarget(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupRemoveTargetInput) (jsonutils.JSONObject, error) {
+	targets, err := sg.Targets()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	var target *SScalingGroupTarget
+	hasWeight := false
+	for i := range targets {
+		if targets[i].Id == input.TargetId {
+			target = &targets[i]
+		} else if targets[i].Weight > 0 {
+			hasWeight = true
+		}
+	}
+	if target == nil {
+		return nil, httperrors.NewResourceNotFoundError2(ScalingGroupTargetManager.Keyword(), input.TargetId)
+	}
+	if len(targets) > 1 && !hasWeight {
+		return nil, httperrors.NewConflictError("at least one remaining target should have positive weight")
+	}
+	err = db.DeleteModel(ctx, userCred, target)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	logclient.AddActionLogWithContext(ctx, sg, logclient.ACT_SCALING_GROUP_REMOVE_TARGET, target, userCred, true)
+	return nil, nil
+}
+
+func (sg *SScalingGroup) removeTargets(ctx context.Context, userCred mcclient.TokenCredential) error {
+	targets, err := sg.Targets()
+	if err != nil {
+		return err
+	}
+	for i := range targets {
+		err := db.DeleteModel(ctx, userCred, &targets[i])
+		if err != nil {
+			return errors.Wrapf(err, "delete target %s", targets[i].Id)
+		}
+	}
+	return nil
+}
+
+// PlanScaleOut 按权重将新增的num个实例分配到各目标, 每次选择加入后负载(实例数/权重)最低的目标
+// 权重为0的目标不参与常规分配, 仅用于故障转移
+func PlanScaleOut(targets []SScalingGroupTarget, counts map[string]int, num int) map[string]int {
+	ret := map[string]int{}
+	for n := 0; n < num; n++ {
+		var best *SScalingGroupTarget
+		bestLoad := 0.0
+		for i := range targets {
+			t := &targets[i]
+			if t.Weight <= 0 {
+				continue
+			}
+			load := float64(counts[t.Id]+ret[t.Id]+1) / float64(t.Weight)
+			if best == nil || load < bestLoad || (load == bestLoad && t.Priority < best.Priority) {
+				best, bestLoad = t, load
+			}
+		}
+		if best == nil {
+			break
+		}
+		ret[best.Id] += 1
+	}
+	return ret
+}
+
+// FailoverTargets 返回可用于故障转移的目标, 按优先级排序
+func FailoverTargets(targets []SScalingGroupTarget, excluded map[string]bool) []SScalingGroupTarget {
+	ret := make([]SScalingGroupTarget, 0, len(targets))
+	for i := range targets {
+		if !excluded[targets[i].Id] {
+			ret = append(ret, targets[i])
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Priority != ret[j].Priority {
+			return ret[i].Priority < ret[j].Priority
+		}
+		return ret[i].Weight > ret[j].Weight
+	})
+	return ret
+}
+
+// PlanScaleIn 选择需要缩容的num个实例所在的目标, 每次从负载(实例数/权重)最高的目标中移除
+// 不属于任何目标或权重为0的实例最先移除, 负载相同时优先移除优先级低的目标
+func PlanScaleIn(targets []SScalingGroupTarget, counts map[string]int, num int) map[string]int {
+	targetMap := make(map[string]*SScalingGroupTarget, len(targets))
+	for i := range targets {
+		targetMap[targets[i].Id] = &targets[i]
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ret := map[string]int{}
+	for n := 0; n < num; n++ {
+		bestKey, found := "", false
+		bestLoad, bestPriority := 0.0, 0
+		for _, k := range keys {
+			remain := counts[k] - ret[k]
+			if remain <= 0 {
+				continue
+			}
+			load, priority := math.Inf(1), math.MaxInt32
+			if t, ok := targetMap[k]; ok {
+				priority = t.Priority
+				if t.Weight > 0 {
+					load = float64(remain) / float64(t.Weight)
+				}
+			}
+			if !found || load > bestLoad || (load == bestLoad && priority > bestPriority) {
+				bestKey, bestLoad, bestPriority, found = k, load, priority, true
+			}
+		}
+		if !found {
+			break
+		}
+		ret[bestKey] += 1
+	}
+	return ret
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestScalingTarget(id string, weight, priority int) SScalingGroupTarget {
+	t := SScalingGroupTarget{Weight: weight, Priority: priority}
+	t.Id = id
+	return t
+}
+
+func TestPlanScaleOut(t *testing.T) {
+	targets := []SScalingGroupTarget{
+		newTestScalingTarget("a", 2, 0),
+		newTestScalingTarget("b", 1, 1),
+		newTestScalingTarget("burst", 0, 2),
+	}
+	cases := []struct {
+		counts map[string]int
+		num    int
+		want   map[string]int
+	}{
+		{map[string]int{}, 3, map[string]int{"a": 2, "b": 1}},
+		{map[string]int{"a": 4}, 3, map[string]int{"b": 2, "a": 1}},
+		{map[string]int{"a": 1, "b": 1}, 1, map[string]int{"a": 1}},
+	}
+	for _, c := range cases {
+		got := PlanScaleOut(targets, c.counts, c.num)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("counts %v num %d: want %v, got %v", c.counts, c.num, c.want, got)
+		}
+	}
+}
+
+func TestPlanScaleIn(t *testing.T) {
+	targets := []SScalingGroupTarget{
+		newTestScalingTarget("a", 2, 0),
+		newTestScalingTarget("b", 1, 1),
+		newTestScalingTarget("burst", 0, 2),
+	}
+	cases := []struct {
+		counts map[string]int
+		num    int
+		want   map[string]int
+	}{
+		{map[string]int{"a": 2, "b": 1, "burst": 2}, 2, map[string]int{"burst": 2}},
+		{map[string]int{"a": 2, "b": 2, "": 1}, 2, map[string]int{"": 1, "b": 1}},
+		{map[string]int{"a": 2, "b": 1}, 2, map[string]int{"a": 1, "b": 1}},
+		{map[string]int{"a": 1}, 3, map[string]int{"a": 1}},
+	}
+	for _, c := range cases {
+		got := PlanScaleIn(targets, c.counts, c.num)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("counts %v num %d: want %v, got %v", c.counts, c.num, c.want, got)
+		}
+	}
+}
+
+func TestFailoverTargets(t *testing.T) {
+	targets := []SScalingGroupTarget{
+		newTestScalingTarget("a", 1, 1),
+		newTestScalingTarget("b", 0, 0),
+		newTestScalingTarget("c", 2, 1),
+	}
+	got := FailoverTargets(targets, map[string]bool{"a": true})
+	ids := []string{}
+	for i := range got {
+		ids = append(ids, got[i].Id)
+	}
+	if !reflect.DeepEqual(ids, []string{"b", "c"}) {
+		t.Errorf("unexpected failover order %v", ids)
+	}
+}
@@ -0,0 +1,452 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+	"yunion.io/x/onecloud/pkg/util/tagutils"
+)
+
+type SSchedtagRuleManager struct {
+	db.SEnabledStatusStandaloneResourceBaseManager
+	SSchedtagResourceBaseManager
+}
+
+var SchedtagRuleManager *SSchedtagRuleManager
+
+func init() {
+	SchedtagRuleManager = &SSchedtagRuleManager{
+		SEnabledStatusStandaloneResourceBaseManager: db.NewEnabledStatusStandaloneResourceBaseManager(
+			SSchedtagRule{},
+			"schedtag_rules_tbl",
+			"schedtag_rule",
+			"schedtag_rules",
+		),
+	}
+	SchedtagRuleManager.SetVirtualObject(SchedtagRuleManager)
+}
+
+// 调度标签自动关联规则, 同步新建宿主机/存储/网络时, 按云平台/区域/可用区/云上标签匹配并自动关联调度标签
+type SSchedtagRule struct {
+	db.SEnabledStatusStandaloneResourceBase
+	SSchedtagResourceBase
+
+	// 匹配的云平台
+	Provider string `width:"64" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+	// 匹配的区域
+	CloudregionId string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+	// 匹配的可用区
+	ZoneId string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+	// 匹配的云上标签
+	Tags *api.SchedtagRuleTags `nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+}
+
+func validateSchedtagRuleProvider(provider string) error {
+	if len(provider) > 0 && !utils.IsInStringArray(provider, api.CLOUD_PROVIDERS) {
+		return httperrors.NewInputParameterError("invalid provider %s", provider)
+	}
+	return nil
+}
+
+func validateSchedtagRuleTags(tags api.SchedtagRuleTags) error {
+	for _, tag := range tags {
+		if len(tag.Key) == 0 {
+			return httperrors.NewInputParameterError("missing tag key")
+		}
+	}
+	return nil
+}
+
+func (manager *SSchedtagRuleManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.SchedtagRuleCreateInput) (api.SchedtagRuleCreateInput, error) {
+	var err error
+	var schedtag *SSchedtag
+	schedtag, input.SchedtagResourceInput, err = ValidateSchedtagResourceInput(userCred, input.SchedtagResourceInput)
+	if err != nil {
+		return input, err
+	}
+	if !utils.IsInStringArray(schedtag.ResourceType, api.SCHEDTAG_RULE_RESOURCE_TYPES) {
+		return input, httperrors.NewInputParameterError("schedtag %s resource type %s not supported, must be one of %s", schedtag.Name, schedtag.ResourceType, strings.Join(api.SCHEDTAG_RULE_RESOURCE_TYPES, ","))
+	}
+	err = validateSchedtagRuleProvider(input.Provider)
+	if err != nil {
+		return input, err
+	}
+	if len(input.CloudregionId) > 0 {
+		region, _, err := ValidateCloudregionResourceInput(userCred, api.CloudregionResourceInput{CloudregionId: input.CloudregionId})
+		if err != nil {
+			return input, err
+		}
+		input.CloudregionId = region.Id
+	}
+	if len(input.ZoneId) > 0 {
+		zone, _, err := ValidateZoneResourceInput(userCred, api.ZoneResourceInput{ZoneId: input.ZoneId})
+		if err != nil {
+			return input, err
+		}
+		if len(input.CloudregionId) > 0 && zone.CloudregionId != input.CloudregionId {
+			return input, httperrors.NewInputParameterError("zone %s not in cloudregion %s", zone.Name, input.CloudregionId)
+		}
+		input.ZoneId = zone.Id
+	}
+	err = validateSchedtagRuleTags(input.Tags)
+	if err != nil {
+		return input, err
+	}
+	input.EnabledStatusStandaloneResourceCreateInput, err = manager.SEnabledStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (rule *SSchedtagRule) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SchedtagRuleUpdateInput) (api.SchedtagRuleUpdateInput, error) {
+	var err error
+	if input.Provider != nil {
+		err = validateSchedtagRuleProvider(*input.Provider)
+		if err != nil {
+			return input, err
+		}
+	}
+	if input.CloudregionId != nil && len(*input.CloudregionId) > 0 {
+		region, _, err := ValidateCloudregionResourceInput(userCred, api.CloudregionResourceInput{CloudregionId: *input.CloudregionId})
+		if err != nil {
+			return input, err
+		}
+		input.CloudregionId = &region.Id
+	}
+	if input.ZoneId != nil && len(*input.ZoneId) > 0 {
+		zone, _, err := ValidateZoneResourceInput(userCred, api.ZoneResourceInput{ZoneId: *input.ZoneId})
+		if err != nil {
+			return input, err
+		}
+		input.ZoneId = &zone.Id
+	}
+	err = validateSchedtagRuleTags(input.Tags)
+	if err != nil {
+		return input, err
+	}
+	input.EnabledStatusStandaloneResourceBaseUpdateInput, err = rule.SEnabledStatusStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusStandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusStandaloneResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+// 调度标签自动关联规则列表
+func (manager *SSchedtagRuleManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.SchedtagRuleListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SSchedtagResourceBaseManager.ListItemFilter(ctx, q, userCred, query.SchedtagFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSchedtagResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Provider) > 0 {
+		q = q.In("provider", query.Provider)
+	}
+	if len(query.CloudregionId) > 0 {
+		region, _, err := ValidateCloudregionResourceInput(userCred, api.CloudregionResourceInput{CloudregionId: query.CloudregionId})
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("cloudregion_id", region.Id)
+	}
+	if len(query.ZoneId) > 0 {
+		zone, _, err := ValidateZoneResourceInput(userCred, api.ZoneResourceInput{ZoneId: query.ZoneId})
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("zone_id", zone.Id)
+	}
+
+	return q, nil
+}
+
+func (manager *SSchedtagRuleManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.SchedtagRuleListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SSchedtagResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.SchedtagFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSchedtagResourceBaseManager.OrderByExtraFields")
+	}
+
+	return q, nil
+}
+
+func (manager *SSchedtagRuleManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	q, err = manager.SSchedtagResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SSchedtagRuleManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.SchedtagRuleDetails {
+	rows := make([]api.SchedtagRuleDetails, len(objs))
+
+	stdRows := manager.SEnabledStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	tagRows := manager.SSchedtagResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	regionIds := make([]string, 0)
+	zoneIds := make([]string, 0)
+	for i := range objs {
+		rule := objs[i].(*SSchedtagRule)
+		if len(rule.CloudregionId) > 0 {
+			regionIds = append(regionIds, rule.CloudregionId)
+		}
+		if len(rule.ZoneId) > 0 {
+			zoneIds = append(zoneIds, rule.ZoneId)
+		}
+	}
+	regions, err := db.FetchIdNameMap2(CloudregionManager, regionIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 cloudregions error: %v", err)
+	}
+	zones, err := db.FetchIdNameMap2(ZoneManager, zoneIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 zones error: %v", err)
+	}
+
+	for i := range rows {
+		rule := objs[i].(*SSchedtagRule)
+		rows[i] = api.SchedtagRuleDetails{
+			EnabledStatusStandaloneResourceDetails: stdRows[i],
+			SchedtagResourceInfo:                   tagRows[i],
+			Cloudregion:                            regions[rule.CloudregionId],
+			Zone:                                   zones[rule.ZoneId],
+		}
+	}
+
+	return rows
+}
+
+// 判断同步资源是否满足规则, 未设置的匹配条件视为匹配任意值
+func (rule *SSchedtagRule) isMatch(provider, regionId, zoneId string, extTags map[string]string) bool {
+	if len(rule.Provider) > 0 && rule.Provider != provider {
+		return false
+	}
+	if len(rule.CloudregionId) > 0 && rule.CloudregionId != regionId {
+		return false
+	}
+	if len(rule.ZoneId) > 0 && rule.ZoneId != zoneId {
+		return false
+	}
+	if rule.Tags != nil && len(*rule.Tags) > 0 {
+		return tagutils.TTagSet(*rule.Tags).Contains(tagutils.Map2Tagset(extTags))
+	}
+	return true
+}
+
+func (manager *SSchedtagRuleManager) getEnabledRulesByResource(resType string) ([]SSchedtagRule, error) {
+	q := manager.Query().IsTrue("enabled")
+	schedtags := SchedtagManager.Query().SubQuery()
+	q = q.Join(schedtags, sqlchemy.AND(
+		sqlchemy.Equals(q.Field("schedtag_id"), schedtags.Field("id")),
+		sqlchemy.Equals(schedtags.Field("resource_type"), resType)))
+	rules := make([]SSchedtagRule, 0)
+	err := db.FetchModelObjects(manager, q, &rules)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return rules, nil
+}
+
+// 为资源关联调度标签, 已关联时跳过, 返回是否新关联
+func attachSchedtagIfNotExists(ctx context.Context, userCred mcclient.TokenCredential, obj IModelWithSchedtag, schedtag *SSchedtag) (bool, error) {
+	jointMan := obj.GetSchedtagJointManager()
+	cnt, err := jointMan.Query().Equals(jointMan.GetResourceIdKey(jointMan), obj.GetId()).Equals("schedtag_id", schedtag.Id).CountWithError()
+	if err != nil {
+		return false, errors.Wrap(err, "CountWithError")
+	}
+	if cnt > 0 {
+		return false, nil
+	}
+	_, err = InsertJointResourceSchedtag(ctx, jointMan, obj.GetId(), schedtag.Id)
+	if err != nil {
+		return false, errors.Wrapf(err, "InsertJointResourceSchedtag %s %s", obj.GetId(), schedtag.Id)
+	}
+	db.OpsLog.LogAttachEvent(ctx, obj, schedtag, userCred, nil)
+	if err := obj.ClearSchedDescCache(); err != nil {
+		log.Errorf("Resource %s/%s ClearSchedDescCache error: %v", obj.Keyword(), obj.GetId(), err)
+	}
+	return true, nil
+}
+
+// 同步新建宿主机/存储/网络后, 按已启用的规则自动关联调度标签, 失败只记录日志, 不影响同步
+func (manager *SSchedtagRuleManager) attachSyncedResource(ctx context.Context, userCred mcclient.TokenCredential, obj IModelWithSchedtag, provider *SCloudprovider, regionId, zoneId string, extTags map[string]string) {
+	rules, err := manager.getEnabledRulesByResource(obj.KeywordPlural())
+	if err != nil {
+		log.Errorf("get schedtag rules for %s error: %v", obj.KeywordPlural(), err)
+		return
+	}
+	providerName := ""
+	if provider != nil {
+		providerName = provider.Provider
+	}
+	for i := range rules {
+		if !rules[i].isMatch(providerName, regionId, zoneId, extTags) {
+			continue
+		}
+		schedtag := rules[i].GetSchedtag()
+		if schedtag == nil {
+			continue
+		}
+		_, err := attachSchedtagIfNotExists(ctx, userCred, obj, schedtag)
+		if err != nil {
+			log.Errorf("attach schedtag %s to %s %s by rule %s error: %v", schedtag.Name, obj.Keyword(), obj.GetName(), rules[i].Name, err)
+		}
+	}
+}
+
+type iSchedtagRuleResource interface {
+	IModelWithSchedtag
+	GetAllCloudMetadata() (map[string]string, error)
+}
+
+// 查询规则可能匹配的已同步资源, 云平台/区域/可用区在数据库中过滤, 云上标签由调用者匹配
+func (rule *SSchedtagRule) getCandidateResources(resType string) ([]iSchedtagRuleResource, error) {
+	providers := CloudproviderManager.Query("id")
+	if len(rule.Provider) > 0 {
+		providers = providers.Equals("provider", rule.Provider)
+	}
+	zones := ZoneManager.Query("id")
+	if len(rule.CloudregionId) > 0 {
+		zones = zones.Equals("cloudregion_id", rule.CloudregionId)
+	}
+	if len(rule.ZoneId) > 0 {
+		zones = zones.Equals("id", rule.ZoneId)
+	}
+
+	ret := make([]iSchedtagRuleResource, 0)
+	switch resType {
+	case HostManager.KeywordPlural():
+		q := HostManager.Query().In("manager_id", providers.SubQuery()).In("zone_id", zones.SubQuery())
+		hosts := make([]SHost, 0)
+		err := db.FetchModelObjects(HostManager, q, &hosts)
+		if err != nil {
+			return nil, errors.Wrap(err, "FetchModelObjects hosts")
+		}
+		for i := range hosts {
+			ret = append(ret, &hosts[i])
+		}
+	case StorageManager.KeywordPlural():
+		q := StorageManager.Query().In("manager_id", providers.SubQuery()).In("zone_id", zones.SubQuery())
+		storages := make([]SStorage, 0)
+		err := db.FetchModelObjects(StorageManager, q, &storages)
+		if err != nil {
+			return nil, errors.Wrap(err, "FetchModelObjects storages")
+		}
+		for i := range storages {
+			ret = append(ret, &storages[i])
+		}
+	case NetworkManager.KeywordPlural():
+		vpcs := VpcManager.Query("id").In("manager_id", providers.SubQuery())
+		if len(rule.CloudregionId) > 0 {
+			vpcs = vpcs.Equals("cloudregion_id", rule.CloudregionId)
+		}
+		wires := WireManager.Query("id").In("vpc_id", vpcs.SubQuery())
+		if len(rule.ZoneId) > 0 {
+			wires = wires.Equals("zone_id", rule.ZoneId)
+		}
+		q := NetworkManager.Query().In("wire_id", wires.SubQuery())
+		networks := make([]SNetwork, 0)
+		err := db.FetchModelObjects(NetworkManager, q, &networks)
+		if err != nil {
+			return nil, errors.Wrap(err, "FetchModelObjects networks")
+		}
+		for i := range networks {
+			ret = append(ret, &networks[i])
+		}
+	default:
+		return nil, httperrors.NewNotSupportedError("resource type %s not supported", resType)
+	}
+	return ret, nil
+}
+
+// 将规则应用到已同步的匹配资源
+func (rule *SSchedtagRule) PerformApply(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (*api.SchedtagRuleApplyOutput, error) {
+	schedtag := rule.GetSchedtag()
+	if schedtag == nil {
+		return nil, httperrors.NewResourceNotFoundError("schedtag %s not found", rule.SchedtagId)
+	}
+	resources, err := rule.getCandidateResources(schedtag.ResourceType)
+	if err != nil {
+		return nil, errors.Wrap(err, "getCandidateResources")
+	}
+	ret := &api.SchedtagRuleApplyOutput{}
+	for i := range resources {
+		if rule.Tags != nil && len(*rule.Tags) > 0 {
+			extTags, err := resources[i].GetAllCloudMetadata()
+			if err != nil {
+				return nil, errors.Wrapf(err, "GetAllCloudMetadata for %s", resources[i].GetId())
+			}
+			if !tagutils.TTagSet(*rule.Tags).Contains(tagutils.Map2Tagset(extTags)) {
+				continue
+			}
+		}
+		attached, err := attachSchedtagIfNotExists(ctx, userCred, resources[i], schedtag)
+		if err != nil {
+			return nil, errors.Wrapf(err, "attach schedtag to %s", resources[i].GetName())
+		}
+		if attached {
+			ret.AttachedCount++
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/util/tagutils"
+)
+
+func TestSchedtagRuleIsMatch(t *testing.T) {
+	tags := api.SchedtagRuleTags{
+		tagutils.STag{Key: "env", Value: "prod"},
+		tagutils.STag{Key: "team"},
+	}
+	cases := []struct {
+		name     string
+		rule     SSchedtagRule
+		provider string
+		regionId string
+		zoneId   string
+		extTags  map[string]string
+		want     bool
+	}{
+		{"match all", SSchedtagRule{}, api.CLOUD_PROVIDER_ALIYUN, "region1", "zone1", nil, true},
+		{"provider", SSchedtagRule{Provider: api.CLOUD_PROVIDER_ALIYUN}, api.CLOUD_PROVIDER_ALIYUN, "region1", "zone1", nil, true},
+		{"provider mismatch", SSchedtagRule{Provider: api.CLOUD_PROVIDER_ALIYUN}, api.CLOUD_PROVIDER_AWS, "region1", "zone1", nil, false},
+		{"region mismatch", SSchedtagRule{CloudregionId: "region2"}, api.CLOUD_PROVIDER_ALIYUN, "region1", "zone1", nil, false},
+		{"zone", SSchedtagRule{CloudregionId: "region1", ZoneId: "zone1"}, api.CLOUD_PROVIDER_ALIYUN, "region1", "zone1", nil, true},
+		{"regional network", SSchedtagRule{ZoneId: "zone1"}, api.CLOUD_PROVIDER_ALIYUN, "region1", "", nil, false},
+		{"tags", SSchedtagRule{Tags: &tags}, api.CLOUD_PROVIDER_ALIYUN, "region1", "zone1", map[string]string{"env": "prod", "team": "infra", "owner": "ops"}, true},
+		{"tag value mismatch", SSchedtagRule{Tags: &tags}, api.CLOUD_PROVIDER_ALIYUN, "region1", "zone1", map[string]string{"env": "dev", "team": "infra"}, false},
+		{"tag missing", SSchedtagRule{Tags: &tags}, api.CLOUD_PROVIDER_ALIYUN, "region1", "zone1", map[string]string{"env": "prod"}, false},
+	}
+	for _, c := range cases {
+		if got := c.rule.isMatch(c.provider, c.regionId, c.zoneId, c.extTags); got != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
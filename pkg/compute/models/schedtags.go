@@ -375,6 +375,13 @@ func (self *SSchedtag) ValidateDeleteCondition(ctx context.Context, info jsonuti
 	if cnt > 0 {
 		return httperrors.NewNotEmptyError("tag has dynamic rules")
 	}
+	cnt, err = self.getSchedtagRuleCount()
+	if err != nil {
+		return httperrors.NewInternalServerError("getSchedtagRuleCount fail %s", err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("tag has propagation rules")
+	}
 	cnt, err = self.getSchedPoliciesCount()
 	if err != nil {
 		return httperrors.NewInternalServerError("getSchedPoliciesCount fail %s", err)
@@ -453,6 +460,10 @@ func (self *SSchedtag) getDynamicSchedtagCount() (int, error) {
 	return DynamicschedtagManager.Query().Equals("schedtag_id", self.Id).CountWithError()
 }
 
+func (self *SSchedtag) getSchedtagRuleCount() (int, error) {
+	return SchedtagRuleManager.Query().Equals("schedtag_id", self.Id).CountWithError()
+}
+
 func (self *SSchedtag) getMoreColumns(out api.SchedtagDetails) api.SchedtagDetails {
 	out.ProjectId = self.SScopedResourceBase.ProjectId
 	cnt, _ := self.GetObjectCount()
@@ -468,6 +479,7 @@ func (self *SSchedtag) getMoreColumns(out api.SchedtagDetails) api.SchedtagDetai
 	}
 	out.DynamicSchedtagCount, _ = self.getDynamicSchedtagCount()
 	out.SchedpolicyCount, _ = self.getSchedPoliciesCount()
+	out.SchedtagRuleCount, _ = self.getSchedtagRuleCount()
 
 	// resource_count = row.host_count || row.other_count || '0'
 	if out.HostCount > 0 {
@@ -0,0 +1,195 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// 设置安全组规则漂移自动修复
+func (self *SSecurityGroup) PerformSetDriftRemediation(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SecgroupDriftRemediationInput) (jsonutils.JSONObject, error) {
+	_, err := db.Update(self, func() error {
+		self.AutoRemediateDrift = input.AutoRemediateDrift
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, input, userCred)
+	logclient.AddSimpleActionLog(self, logclient.ACT_UPDATE, input, userCred, true)
+	return nil, nil
+}
+
+// 检测安全组缓存规则漂移
+func (self *SSecurityGroupCache) PerformCheckDrift(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SecgroupCacheCheckDriftInput) (*api.SecgroupCacheDriftOutput, error) {
+	if len(self.ExternalId) == 0 {
+		return nil, httperrors.NewInvalidStatusError("secgroup cache %s has not been created on cloud", self.Name)
+	}
+	secgroup, err := self.GetSecgroup()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "GetSecgroup"))
+	}
+	ret, err := self.checkDrift(ctx, userCred, input.Remediate || secgroup.AutoRemediateDrift)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return ret, nil
+}
+
+// 周期性比对本地安全组规则与云上实际规则, 记录漂移并按需自动修复
+func (manager *SSecurityGroupCacheManager) DriftCheck(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := manager.Query().Equals("status", api.SECGROUP_CACHE_STATUS_READY).IsNotEmpty("external_id").IsNotEmpty("manager_id")
+	secgroups := SecurityGroupManager.Query().SubQuery()
+	q = q.Join(secgroups, sqlchemy.Equals(q.Field("secgroup_id"), secgroups.Field("id"))).
+		Filter(sqlchemy.IsFalse(secgroups.Field("is_dirty"))).
+		Filter(sqlchemy.Equals(secgroups.Field("status"), api.SECGROUP_STATUS_READY))
+	// 每轮只检查最久未检查的部分缓存, 避免集中调用云上接口
+	q = q.Asc(q.Field("drift_checked_at"))
+	if options.Options.SecgroupDriftCheckBatchSize > 0 {
+		q = q.Limit(options.Options.SecgroupDriftCheckBatchSize)
+	}
+	caches := []SSecurityGroupCache{}
+	err := db.FetchModelObjects(manager, q, &caches)
+	if err != nil {
+		log.Errorf("DriftCheck fetch secgroup caches error: %v", err)
+		return
+	}
+	for i := range caches {
+		secgroup, err := caches[i].GetSecgroup()
+		if err != nil {
+			log.Errorf("secgroup cache %s GetSecgroup error: %v", caches[i].Id, err)
+			continue
+		}
+		_, err = caches[i].checkDrift(ctx, userCred, secgroup.AutoRemediateDrift)
+		if err != nil {
+			log.Errorf("check secgroup cache %s(%s) drift error: %v", caches[i].Name, caches[i].Id, err)
+		}
+	}
+}
+
+func (self *SSecurityGroupCache) getRuleDrift(ctx context.Context) (*api.SSecgroupRuleDrift, error) {
+	region, err := self.GetRegion()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetRegion")
+	}
+	iSecgroup, err := self.GetISecurityGroup(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetISecurityGroup")
+	}
+	rules, err := iSecgroup.GetRules()
+	if err != nil {
+		return nil, errors.Wrapf(err, "iSecgroup.GetRules")
+	}
+	localRules, _, err := self.getSecurityRuleSet(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getSecurityRuleSet")
+	}
+
+	src := cloudprovider.NewSecRuleInfo(GetRegionDriver(api.CLOUD_PROVIDER_ONECLOUD))
+	src.Rules = localRules
+
+	dest := cloudprovider.NewSecRuleInfo(GetRegionDriver(region.Provider))
+	dest.Rules = rules
+
+	return compareSecgroupRuleDrift(src, dest), nil
+}
+
+// 本地规则在云上缺失的记为Missing, 云上多出的记为Extra
+func compareSecgroupRuleDrift(src, dest cloudprovider.SecRuleInfo) *api.SSecgroupRuleDrift {
+	_, inAdds, outAdds, inDels, outDels := cloudprovider.CompareRules(src, dest, false)
+	drift := &api.SSecgroupRuleDrift{}
+	for _, rules := range []cloudprovider.SecurityRuleSet{inAdds, outAdds} {
+		for i := range rules {
+			drift.Missing = append(drift.Missing, rules[i].String())
+		}
+	}
+	for _, rules := range []cloudprovider.SecurityRuleSet{inDels, outDels} {
+		for i := range rules {
+			drift.Extra = append(drift.Extra, rules[i].String())
+		}
+	}
+	return drift
+}
+
+// 返回是否记录漂移事件及是否重新下发本地规则
+// 只在漂移首次出现或内容变化时记录审计事件, 避免周期检测重复刷日志
+func getSecgroupDriftActions(prev, drift *api.SSecgroupRuleDrift, remediate bool) (bool, bool) {
+	if drift.IsZero() {
+		return false, false
+	}
+	record := prev == nil || prev.String() != drift.String()
+	return record, remediate
+}
+
+func (self *SSecurityGroupCache) checkDrift(ctx context.Context, userCred mcclient.TokenCredential, remediate bool) (*api.SecgroupCacheDriftOutput, error) {
+	// 比对及修复均需调用云上接口, 不持有缓存锁, 仅在更新检测结果时加锁
+	drift, err := self.getRuleDrift(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getRuleDrift")
+	}
+	ret := &api.SecgroupCacheDriftOutput{DriftStatus: api.SECGROUP_CACHE_DRIFT_STATUS_IN_SYNC}
+	record, remediate := getSecgroupDriftActions(self.Drift, drift, remediate)
+	if !drift.IsZero() {
+		ret.DriftStatus = api.SECGROUP_CACHE_DRIFT_STATUS_DRIFTED
+		ret.Drift = drift
+	}
+	if record {
+		db.OpsLog.LogEvent(self, db.ACT_UPDATE, drift, userCred)
+		logclient.AddSimpleActionLog(self, logclient.ACT_SECGROUP_DRIFT, drift, userCred, false)
+	}
+	if remediate {
+		err = self.SyncRules(ctx, false)
+		if err != nil {
+			logclient.AddSimpleActionLog(self, logclient.ACT_SECGROUP_REMEDIATE_DRIFT, err, userCred, false)
+		} else {
+			notes := fmt.Sprintf("reapply local rules, missing %d extra %d", len(drift.Missing), len(drift.Extra))
+			db.OpsLog.LogEvent(self, db.ACT_UPDATE, notes, userCred)
+			logclient.AddSimpleActionLog(self, logclient.ACT_SECGROUP_REMEDIATE_DRIFT, drift, userCred, true)
+			ret.DriftStatus = api.SECGROUP_CACHE_DRIFT_STATUS_IN_SYNC
+			ret.Drift = nil
+			ret.Remediated = true
+		}
+	}
+
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	_, err = db.Update(self, func() error {
+		self.DriftStatus = ret.DriftStatus
+		self.Drift = ret.Drift
+		self.DriftCheckedAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.Update")
+	}
+	return ret, nil
+}
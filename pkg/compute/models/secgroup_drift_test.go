@@ -0,0 +1,136 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/pkg/util/secrules"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+type sTestSecDriver struct {
+	minPriority int
+	maxPriority int
+}
+
+func (self sTestSecDriver) GetDefaultSecurityGroupInRule() cloudprovider.SecurityRule {
+	return cloudprovider.SecurityRule{SecurityRule: *secrules.MustParseSecurityRule("in:deny any")}
+}
+
+func (self sTestSecDriver) GetDefaultSecurityGroupOutRule() cloudprovider.SecurityRule {
+	return cloudprovider.SecurityRule{SecurityRule: *secrules.MustParseSecurityRule("out:allow any")}
+}
+
+func (self sTestSecDriver) GetSecurityGroupRuleMaxPriority() int {
+	return self.maxPriority
+}
+
+func (self sTestSecDriver) GetSecurityGroupRuleMinPriority() int {
+	return self.minPriority
+}
+
+func (self sTestSecDriver) IsOnlySupportAllowRules() bool {
+	return false
+}
+
+func (self sTestSecDriver) IsSupportPeerSecgroup() bool {
+	return false
+}
+
+func newTestSecRules(rules ...string) cloudprovider.SecurityRuleSet {
+	ret := cloudprovider.SecurityRuleSet{}
+	for _, rule := range rules {
+		r := secrules.MustParseSecurityRule(rule)
+		r.Priority = 1
+		ret = append(ret, cloudprovider.SecurityRule{SecurityRule: *r, ExternalId: rule})
+	}
+	return ret
+}
+
+func TestCompareSecgroupRuleDrift(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		local   []string
+		cloud   []string
+		missing []string
+		extra   []string
+	}{
+		{
+			name:  "in sync",
+			local: []string{"in:allow tcp 22", "in:allow tcp 443"},
+			cloud: []string{"in:allow tcp 22", "in:allow tcp 443"},
+		},
+		{
+			name:    "rule removed on cloud",
+			local:   []string{"in:allow tcp 22", "in:allow tcp 443"},
+			cloud:   []string{"in:allow tcp 22"},
+			missing: []string{"in:allow tcp 443"},
+		},
+		{
+			name:  "rule added on cloud",
+			local: []string{"in:allow tcp 22"},
+			cloud: []string{"in:allow tcp 22", "in:allow tcp 3389"},
+			extra: []string{"in:allow tcp 3389"},
+		},
+		{
+			name:    "rule changed on cloud",
+			local:   []string{"in:allow tcp 22", "out:deny tcp 25"},
+			cloud:   []string{"in:allow tcp 22", "out:deny tcp 587"},
+			missing: []string{"out:deny tcp 25"},
+			extra:   []string{"out:deny tcp 587"},
+		},
+	} {
+		driver := sTestSecDriver{minPriority: 1, maxPriority: 100}
+		src, dest := cloudprovider.NewSecRuleInfo(driver), cloudprovider.NewSecRuleInfo(driver)
+		src.Rules, dest.Rules = newTestSecRules(c.local...), newTestSecRules(c.cloud...)
+		drift := compareSecgroupRuleDrift(src, dest)
+		if !reflect.DeepEqual(drift.Missing, c.missing) || !reflect.DeepEqual(drift.Extra, c.extra) {
+			t.Errorf("%s: want missing %v extra %v, got missing %v extra %v", c.name, c.missing, c.extra, drift.Missing, drift.Extra)
+		}
+		if drift.IsZero() != (len(c.missing) == 0 && len(c.extra) == 0) {
+			t.Errorf("%s: unexpected IsZero %v", c.name, drift.IsZero())
+		}
+	}
+}
+
+func TestGetSecgroupDriftActions(t *testing.T) {
+	drift := &api.SSecgroupRuleDrift{Missing: []string{"in:allow tcp 443"}}
+	other := &api.SSecgroupRuleDrift{Extra: []string{"in:allow tcp 3389"}}
+	for _, c := range []struct {
+		name      string
+		prev      *api.SSecgroupRuleDrift
+		drift     *api.SSecgroupRuleDrift
+		remediate bool
+		record    bool
+		sync      bool
+	}{
+		{"in sync", nil, &api.SSecgroupRuleDrift{}, true, false, false},
+		{"in sync after drift", drift, &api.SSecgroupRuleDrift{}, true, false, false},
+		{"first drift", nil, drift, false, true, false},
+		{"first drift remediate", nil, drift, true, true, true},
+		{"same drift again", drift, &api.SSecgroupRuleDrift{Missing: []string{"in:allow tcp 443"}}, false, false, false},
+		{"same drift again remediate", drift, drift, true, false, true},
+		{"drift changed", drift, other, false, true, false},
+	} {
+		record, sync := getSecgroupDriftActions(c.prev, c.drift, c.remediate)
+		if record != c.record || sync != c.sync {
+			t.Errorf("%s: want record %v sync %v, got %v %v", c.name, c.record, c.sync, record, sync)
+		}
+	}
+}
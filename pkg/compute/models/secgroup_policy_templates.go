@@ -0,0 +1,439 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/secrules"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-model-singular=secgroup_policy_template
+// +onecloud:swagger-gen-model-plural=secgroup_policy_templates
+type SSecurityPolicyTemplateManager struct {
+	db.SSharableVirtualResourceBaseManager
+}
+
+var SecurityPolicyTemplateManager *SSecurityPolicyTemplateManager
+
+func init() {
+	SecurityPolicyTemplateManager = &SSecurityPolicyTemplateManager{
+		SSharableVirtualResourceBaseManager: db.NewSharableVirtualResourceBaseManager(
+			SSecurityPolicyTemplate{},
+			"secgroup_policy_templates_tbl",
+			"secgroup_policy_template",
+			"secgroup_policy_templates",
+		),
+	}
+	SecurityPolicyTemplateManager.SetVirtualObject(SecurityPolicyTemplateManager)
+}
+
+// 安全策略模板, 以与平台无关的形式描述安全组规则, 应用到虚拟机时生成本地安全组, 由安全组缓存转换为各平台的规则
+type SSecurityPolicyTemplate struct {
+	db.SSharableVirtualResourceBase
+
+	// 与平台无关的规则列表
+	Rules *api.SecurityPolicyRules `nullable:"true" list:"user" update:"user" create:"required"`
+}
+
+func validatePolicyRules(rules api.SecurityPolicyRules) error {
+	if len(rules) == 0 {
+		return httperrors.NewMissingParameterError("rules")
+	}
+	for i := range rules {
+		if len(rules[i].PeerSecgroupId) > 0 {
+			return httperrors.NewInputParameterError("rule %d is invalid: peer_secgroup_id is not supported in policy template", i)
+		}
+		if rules[i].Priority == nil {
+			priority := 1
+			rules[i].Priority = &priority
+		}
+		err := rules[i].Check()
+		if err != nil {
+			return httperrors.NewInputParameterError("rule %d is invalid: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func (manager *SSecurityPolicyTemplateManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.SecurityPolicyTemplateCreateInput,
+) (api.SecurityPolicyTemplateCreateInput, error) {
+	err := validatePolicyRules(input.Rules)
+	if err != nil {
+		return input, err
+	}
+	input.SharableVirtualResourceCreateInput, err = manager.SSharableVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.SharableVirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	input.Status = api.SECGROUP_STATUS_READY
+	return input, nil
+}
+
+func (self *SSecurityPolicyTemplate) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.SecurityPolicyTemplateUpdateInput,
+) (api.SecurityPolicyTemplateUpdateInput, error) {
+	if input.Rules != nil {
+		err := validatePolicyRules(input.Rules)
+		if err != nil {
+			return input, err
+		}
+	}
+	var err error
+	input.SharableVirtualResourceBaseUpdateInput, err = self.SSharableVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.SharableVirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SSecurityPolicyTemplate) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SSharableVirtualResourceBase.PostUpdate(ctx, userCred, query, data)
+
+	if !data.Contains("rules") {
+		return
+	}
+	secgroups, err := self.GetSecgroups()
+	if err != nil {
+		logclient.AddSimpleActionLog(self, logclient.ACT_UPDATE, errors.Wrapf(err, "GetSecgroups"), userCred, false)
+		return
+	}
+	for i := range secgroups {
+		err = secgroups[i].replaceRules(ctx, userCred, self.getRules())
+		if err != nil {
+			logclient.AddSimpleActionLog(&secgroups[i], logclient.ACT_UPDATE, errors.Wrapf(err, "replace rules from policy template %s", self.Name), userCred, false)
+		}
+	}
+}
+
+func (self *SSecurityPolicyTemplate) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := self.GetSecgroupQuery().CountWithError()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("policy template %s is used by %d secgroups", self.Name, cnt)
+	}
+	return self.SSharableVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (self *SSecurityPolicyTemplate) getRules() api.SecurityPolicyRules {
+	if self.Rules == nil {
+		return api.SecurityPolicyRules{}
+	}
+	return *self.Rules
+}
+
+func (self *SSecurityPolicyTemplate) GetSecgroupQuery() *sqlchemy.SQuery {
+	return SecurityGroupManager.Query().Equals("policy_template_id", self.Id)
+}
+
+func (self *SSecurityPolicyTemplate) GetSecgroups() ([]SSecurityGroup, error) {
+	secgroups := []SSecurityGroup{}
+	err := db.FetchModelObjects(SecurityGroupManager, self.GetSecgroupQuery(), &secgroups)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.FetchModelObjects")
+	}
+	return secgroups, nil
+}
+
+// 检查规则在各平台上能否表达, 未指定平台时检查所有平台
+func (self *SSecurityPolicyTemplate) GetDetailsCompatibility(ctx context.Context, userCred mcclient.TokenCredential, query api.SecurityPolicyTemplateCompatibilityInput) ([]api.SecurityPolicyRuleCompatibility, error) {
+	providers := query.Providers
+	if len(providers) == 0 {
+		for provider := range regionDrivers {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+	}
+	ret := []api.SecurityPolicyRuleCompatibility{}
+	for _, provider := range providers {
+		if _, ok := regionDrivers[provider]; !ok {
+			return nil, httperrors.NewInputParameterError("invalid provider %s", provider)
+		}
+		ret = append(ret, checkSecurityRulesCompatibility(provider, self.getRules())...)
+	}
+	return ret, nil
+}
+
+// 检查与平台无关的规则在指定平台上无法表达或会被转换的部分
+func checkSecurityRulesCompatibility(provider string, rules []api.SSecgroupRuleResource) []api.SecurityPolicyRuleCompatibility {
+	return checkDriverSecurityRulesCompatibility(GetRegionDriver(provider), provider, rules)
+}
+
+func checkDriverSecurityRulesCompatibility(driver IRegionDriver, provider string, rules []api.SSecgroupRuleResource) []api.SecurityPolicyRuleCompatibility {
+	ret := []api.SecurityPolicyRuleCompatibility{}
+	for i := range rules {
+		reason := ""
+		if len(rules[i].PeerSecgroupId) > 0 && !driver.IsSupportPeerSecgroup() {
+			reason = "peer security group rule is not supported and will be ignored"
+		} else if rules[i].Action == string(secrules.SecurityRuleDeny) && driver.IsOnlySupportAllowRules() {
+			reason = "only allow rules are supported, deny rule will be converted into complementary allow rules"
+		}
+		if len(reason) > 0 {
+			ret = append(ret, api.SecurityPolicyRuleCompatibility{
+				Provider: provider,
+				Index:    i,
+				Rule:     rules[i],
+				Reason:   reason,
+			})
+		}
+	}
+	return ret
+}
+
+// 将模板应用到虚拟机, 为虚拟机所在项目生成(或复用)对应的安全组并绑定
+func (self *SSecurityPolicyTemplate) PerformApply(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SecurityPolicyTemplateApplyInput) (*api.SecurityPolicyTemplateApplyOutput, error) {
+	if len(input.ServerIds) == 0 {
+		return nil, httperrors.NewMissingParameterError("server_ids")
+	}
+	guests := []*SGuest{}
+	for i := range input.ServerIds {
+		guest, err := validators.ValidateModel(userCred, GuestManager, &input.ServerIds[i])
+		if err != nil {
+			return nil, err
+		}
+		guests = append(guests, guest.(*SGuest))
+	}
+	ret := &api.SecurityPolicyTemplateApplyOutput{}
+	for _, guest := range guests {
+		result := api.SecurityPolicyTemplateApplyResult{ServerId: guest.Id}
+		secgroup, unsupported, err := self.applyToGuest(ctx, userCred, guest)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if secgroup != nil {
+			result.SecgroupId = secgroup.Id
+		}
+		result.UnsupportedRules = unsupported
+		ret.Results = append(ret.Results, result)
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_APPLY_POLICY_TEMPLATE, ret, userCred, true)
+	return ret, nil
+}
+
+func (self *SSecurityPolicyTemplate) applyToGuest(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest) (*SSecurityGroup, []api.SecurityPolicyRuleCompatibility, error) {
+	if guest.GetDriver().GetMaxSecurityGroupCount() == 0 {
+		return nil, nil, httperrors.NewUnsupportOperationError("hypervisor %s not support security group", guest.Hypervisor)
+	}
+	region, err := guest.getRegion()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "getRegion")
+	}
+	unsupported := checkSecurityRulesCompatibility(region.Provider, self.getRules())
+
+	secgroup, err := self.getOrCreateSecgroup(ctx, userCred, guest.GetOwnerId())
+	if err != nil {
+		return nil, unsupported, errors.Wrapf(err, "getOrCreateSecgroup")
+	}
+	secgroups, err := guest.GetSecgroups()
+	if err != nil {
+		return secgroup, unsupported, errors.Wrapf(err, "GetSecgroups")
+	}
+	for i := range secgroups {
+		if secgroups[i].Id == secgroup.Id {
+			return secgroup, unsupported, nil
+		}
+	}
+	_, err = guest.PerformAddSecgroup(ctx, userCred, nil, api.GuestAddSecgroupInput{SecgroupIds: []string{secgroup.Id}})
+	if err != nil {
+		return secgroup, unsupported, err
+	}
+	return secgroup, unsupported, nil
+}
+
+func (self *SSecurityPolicyTemplate) getOrCreateSecgroup(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider) (*SSecurityGroup, error) {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	secgroup := &SSecurityGroup{}
+	secgroup.SetModelManager(SecurityGroupManager, secgroup)
+	err := self.GetSecgroupQuery().Equals("tenant_id", ownerId.GetProjectId()).First(secgroup)
+	if err == nil {
+		return secgroup, nil
+	}
+	if errors.Cause(err) != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "query secgroup")
+	}
+
+	input := api.SSecgroupCreateInput{}
+	input.GenerateName = self.Name
+	input.Description = self.Description
+	for _, rule := range self.getRules() {
+		input.Rules = append(input.Rules, api.SSecgroupRuleCreateInput{SSecgroupRuleResource: rule})
+	}
+	data := jsonutils.Marshal(input)
+	model, err := db.DoCreate(SecurityGroupManager, ctx, userCred, nil, data, ownerId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.DoCreate")
+	}
+	func() {
+		lockman.LockObject(ctx, model)
+		defer lockman.ReleaseObject(ctx, model)
+
+		model.PostCreate(ctx, userCred, ownerId, nil, data)
+	}()
+	secgroup = model.(*SSecurityGroup)
+	_, err = db.Update(secgroup, func() error {
+		secgroup.PolicyTemplateId = self.Id
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.Update")
+	}
+	db.OpsLog.LogEvent(secgroup, db.ACT_CREATE, secgroup.GetShortDesc(ctx), userCred)
+	logclient.AddActionLogWithContext(ctx, secgroup, logclient.ACT_CREATE, "", userCred, true)
+	return secgroup, nil
+}
+
+// 使用模板规则替换安全组的全部规则, 并同步到各平台
+func (self *SSecurityGroup) replaceRules(ctx context.Context, userCred mcclient.TokenCredential, rules api.SecurityPolicyRules) error {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	oldRules, err := self.getSecurityRules()
+	if err != nil {
+		return errors.Wrapf(err, "getSecurityRules")
+	}
+	for i := range oldRules {
+		_, err = db.Update(&oldRules[i], func() error {
+			oldRules[i].IsDirty = true
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "mark rule %s dirty", oldRules[i].Id)
+		}
+		err = oldRules[i].Delete(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "delete rule %s", oldRules[i].Id)
+		}
+	}
+	for _, r := range rules {
+		rule := &SSecurityGroupRule{
+			Priority:    int64(*r.Priority),
+			Protocol:    r.Protocol,
+			Ports:       r.Ports,
+			Direction:   r.Direction,
+			CIDR:        r.CIDR,
+			Action:      r.Action,
+			Description: r.Description,
+			IsDirty:     true,
+		}
+		rule.SecgroupId = self.Id
+		err = SecurityGroupRuleManager.TableSpec().Insert(ctx, rule)
+		if err != nil {
+			return errors.Wrapf(err, "Insert rule")
+		}
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_UPDATE, rules, userCred, true)
+	self.DoSync(ctx, userCred)
+	return nil
+}
+
+// 安全策略模板列表
+func (manager *SSecurityPolicyTemplateManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.SecurityPolicyTemplateListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, input.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.ListItemFilter")
+	}
+	return q, nil
+}
+
+func (manager *SSecurityPolicyTemplateManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.SecurityPolicyTemplateListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SSecurityPolicyTemplateManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SSecurityPolicyTemplateManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.SecurityPolicyTemplateDetails {
+	rows := make([]api.SecurityPolicyTemplateDetails, len(objs))
+	virtRows := manager.SSharableVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	templateIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.SecurityPolicyTemplateDetails{
+			SharableVirtualResourceDetails: virtRows[i],
+		}
+		templateIds[i] = objs[i].(*SSecurityPolicyTemplate).Id
+	}
+
+	q := SecurityGroupManager.Query().In("policy_template_id", templateIds)
+	q = q.AppendField(q.Field("policy_template_id"), sqlchemy.COUNT("secgroup_count")).GroupBy(q.Field("policy_template_id"))
+	counts := []struct {
+		PolicyTemplateId string
+		SecgroupCount    int
+	}{}
+	err := q.All(&counts)
+	if err != nil {
+		return rows
+	}
+	countMap := map[string]int{}
+	for _, cnt := range counts {
+		countMap[cnt.PolicyTemplateId] = cnt.SecgroupCount
+	}
+	for i := range rows {
+		rows[i].SecgroupCount = countMap[templateIds[i]]
+	}
+	return rows
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/pkg/util/secrules"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+type fakeSecgroupRegionDriver struct {
+	IRegionDriver
+
+	supportPeerSecgroup bool
+	onlyAllowRules      bool
+}
+
+func (driver *fakeSecgroupRegionDriver) IsSupportPeerSecgroup() bool {
+	return driver.supportPeerSecgroup
+}
+
+func (driver *fakeSecgroupRegionDriver) IsOnlySupportAllowRules() bool {
+	return driver.onlyAllowRules
+}
+
+func TestValidatePolicyRules(t *testing.T) {
+	if err := validatePolicyRules(api.SecurityPolicyRules{}); err == nil {
+		t.Errorf("want error on empty rules")
+	}
+	rules := api.SecurityPolicyRules{
+		{Direction: "in", Action: "allow", Protocol: "tcp", Ports: "22"},
+		{Direction: "in", Action: "deny", Protocol: "any", CIDR: "10.0.0.0/8"},
+	}
+	if err := validatePolicyRules(rules); err != nil {
+		t.Fatalf("validatePolicyRules: %v", err)
+	}
+	if rules[0].Priority == nil || *rules[0].Priority != 1 {
+		t.Errorf("want default priority 1, got %v", rules[0].Priority)
+	}
+	if rules[0].CIDR != "0.0.0.0/0" {
+		t.Errorf("want default cidr 0.0.0.0/0, got %s", rules[0].CIDR)
+	}
+	for _, rule := range []api.SSecgroupRuleResource{
+		{Direction: "in", Action: "allow", Protocol: "tcp", PeerSecgroupId: "sg"},
+		{Direction: "in", Action: "allow", Protocol: "tcp", Ports: "70000"},
+		{Direction: "in", Action: "allow", Protocol: "tcp", CIDR: "10.0.0.300/8"},
+	} {
+		if err := validatePolicyRules(api.SecurityPolicyRules{rule}); err == nil {
+			t.Errorf("want error on invalid rule %s", api.SecurityPolicyRules{rule}.String())
+		}
+	}
+}
+
+func TestCheckDriverSecurityRulesCompatibility(t *testing.T) {
+	rules := []api.SSecgroupRuleResource{
+		{Direction: "in", Action: string(secrules.SecurityRuleAllow), Protocol: "tcp", Ports: "22"},
+		{Direction: "in", Action: string(secrules.SecurityRuleDeny), Protocol: "any"},
+		{Direction: "in", Action: string(secrules.SecurityRuleAllow), Protocol: "any", PeerSecgroupId: "sg"},
+	}
+	cases := []struct {
+		name   string
+		driver *fakeSecgroupRegionDriver
+		index  []int
+	}{
+		{"full support", &fakeSecgroupRegionDriver{supportPeerSecgroup: true}, []int{}},
+		{"allow rules only", &fakeSecgroupRegionDriver{supportPeerSecgroup: true, onlyAllowRules: true}, []int{1}},
+		{"no peer secgroup", &fakeSecgroupRegionDriver{}, []int{2}},
+		{"allow rules only without peer secgroup", &fakeSecgroupRegionDriver{onlyAllowRules: true}, []int{1, 2}},
+	}
+	for _, c := range cases {
+		ret := checkDriverSecurityRulesCompatibility(c.driver, "provider", rules)
+		if len(ret) != len(c.index) {
+			t.Errorf("%s: want %d incompatible rules, got %d", c.name, len(c.index), len(ret))
+			continue
+		}
+		for i := range ret {
+			if ret[i].Index != c.index[i] || ret[i].Provider != "provider" || len(ret[i].Reason) == 0 {
+				t.Errorf("%s: unexpected compatibility %+v", c.name, ret[i])
+			}
+		}
+	}
+}
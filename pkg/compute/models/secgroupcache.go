@@ -18,6 +18,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
@@ -61,6 +62,13 @@ type SSecurityGroupCache struct {
 	// 虚拟私有网络外部Id
 	VpcId             string `width:"128" charset:"ascii" list:"user" create:"required"`
 	ExternalProjectId string `width:"128" charset:"ascii" list:"user" create:"optional"`
+
+	// 规则漂移状态
+	DriftStatus string `width:"16" charset:"ascii" nullable:"true" list:"user"`
+	// 规则漂移详情
+	Drift *api.SSecgroupRuleDrift `nullable:"true" list:"user"`
+	// 最近一次漂移检测时间
+	DriftCheckedAt time.Time `nullable:"true" list:"user"`
 }
 
 var SecurityGroupCacheManager *SSecurityGroupCacheManager
@@ -117,6 +125,10 @@ func (manager *SSecurityGroupCacheManager) ListItemFilter(
 		return nil, errors.Wrap(err, "SSecurityGroupResourceBaseManager.ListItemFilter")
 	}
 
+	if len(query.DriftStatus) > 0 {
+		q = q.In("drift_status", query.DriftStatus)
+	}
+
 	return q, nil
 }
 
@@ -403,7 +415,8 @@ func (self *SSecurityGroupCache) syncWithCloudSecurityGroup(ctx context.Context,
 	if err != nil {
 		return nil, errors.Wrapf(err, "GetSecgroupCacheCount")
 	}
-	if cacheCount > 1 {
+	// 开启漂移自动修复时以本地规则为准, 不从云上回写规则
+	if cacheCount > 1 || secgroup.AutoRemediateDrift {
 		return nil, nil
 	}
 	dest := cloudprovider.NewSecRuleInfo(GetRegionDriver(provider.Provider))
@@ -564,6 +577,31 @@ func (self *SSecurityGroupCache) GetDetailsReferences(ctx context.Context, userC
 	return iSecgroup.GetReferences()
 }
 
+// 获取本地安全组中在此缓存所属平台上无法表达或会被转换的规则
+func (self *SSecurityGroupCache) GetDetailsUnsupportedRules(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) ([]api.SecurityPolicyRuleCompatibility, error) {
+	ret, err := self.getUnsupportedRules()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return ret, nil
+}
+
+func (self *SSecurityGroupCache) getUnsupportedRules() ([]api.SecurityPolicyRuleCompatibility, error) {
+	secgroup, err := self.GetSecgroup()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetSecgroup")
+	}
+	rules, err := secgroup.getSecurityRules()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getSecurityRules")
+	}
+	resources := []api.SSecgroupRuleResource{}
+	for i := range rules {
+		resources = append(resources, rules[i].toRuleResource())
+	}
+	return checkSecurityRulesCompatibility(self.GetProviderName(), resources), nil
+}
+
 func (self *SSecurityGroupCache) StartSyncstatusTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
 	return StartResourceSyncStatusTask(ctx, userCred, self, "SecurityGroupCacheSyncstatusTask", "")
 }
@@ -894,6 +932,9 @@ func (self *SSecurityGroupCache) SyncRules(ctx context.Context, skipSyncRule boo
 	if err != nil {
 		return errors.Wrapf(err, "iSecgroup.SyncRules")
 	}
+	if unsupported, _ := self.getUnsupportedRules(); len(unsupported) > 0 {
+		log.Warningf("secgroup cache %s(%s) has rules not supported by %s: %s", self.Name, self.Id, self.GetProviderName(), jsonutils.Marshal(unsupported))
+	}
 	for i := range caches {
 		err = caches[i].SyncRules(ctx, skipSyncRule)
 		if err != nil {
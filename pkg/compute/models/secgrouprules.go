@@ -399,6 +399,20 @@ func (self *SSecurityGroupRule) toRule() (*secrules.SecurityRule, error) {
 	return &rule, rule.ValidateRule()
 }
 
+func (self *SSecurityGroupRule) toRuleResource() api.SSecgroupRuleResource {
+	priority := int(self.Priority)
+	return api.SSecgroupRuleResource{
+		Priority:       &priority,
+		Protocol:       self.Protocol,
+		Ports:          self.Ports,
+		Direction:      self.Direction,
+		CIDR:           self.CIDR,
+		Action:         self.Action,
+		Description:    self.Description,
+		PeerSecgroupId: self.PeerSecgroupId,
+	}
+}
+
 func (self *SSecurityGroupRule) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	self.SResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
 
@@ -75,6 +75,12 @@ const (
 type SSecurityGroup struct {
 	db.SSharableVirtualResourceBase
 	IsDirty bool `nullable:"false" default:"false"`
+
+	// 生成此安全组的安全策略模板ID
+	PolicyTemplateId string `width:"36" charset:"ascii" nullable:"true" index:"true" list:"user"`
+
+	// 检测到云上规则漂移时是否自动以本地规则覆盖
+	AutoRemediateDrift bool `nullable:"false" default:"false" list:"user"`
 }
 
 // 安全组列表
@@ -120,6 +126,16 @@ func (manager *SSecurityGroupManager) ListItemFilter(
 		}
 		q = q.In("id", secgroupIds)
 	}
+	if len(input.PolicyTemplateId) > 0 {
+		template, err := SecurityPolicyTemplateManager.FetchByIdOrName(userCred, input.PolicyTemplateId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(SecurityPolicyTemplateManager.Keyword(), input.PolicyTemplateId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("policy_template_id", template.GetId())
+	}
 	serverStr := input.ServerId
 	if len(serverStr) > 0 {
 		guest, _, err := ValidateGuestResourceInput(userCred, input.ServerResourceInput)
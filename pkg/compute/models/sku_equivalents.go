@@ -0,0 +1,208 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type sEquivalentSku struct {
+	SServerSku
+	Score int
+}
+
+func isArmSkuArch(arch string) bool {
+	arch = strings.ToLower(arch)
+	return strings.Contains(arch, "arm") || strings.Contains(arch, "aarch")
+}
+
+// 判断目标套餐能否替代源套餐并给出匹配度
+// CPU架构及是否带GPU须一致, CPU和内存不小于源套餐且不超过两倍, 规格越接近匹配度越高, 套餐类型不同时降低匹配度
+func getSkuEquivalenceScore(src, dst *SServerSku) (int, bool) {
+	if src.CpuCoreCount <= 0 || src.MemorySizeMB <= 0 {
+		return 0, false
+	}
+	if isArmSkuArch(src.CpuArch) != isArmSkuArch(dst.CpuArch) {
+		return 0, false
+	}
+	if (src.GpuCount > 0) != (dst.GpuCount > 0) || dst.GpuCount < src.GpuCount {
+		return 0, false
+	}
+	if dst.CpuCoreCount < src.CpuCoreCount || dst.CpuCoreCount > src.CpuCoreCount*2 {
+		return 0, false
+	}
+	if dst.MemorySizeMB < src.MemorySizeMB || dst.MemorySizeMB > src.MemorySizeMB*2 {
+		return 0, false
+	}
+	score := 100
+	score -= (dst.CpuCoreCount - src.CpuCoreCount) * 25 / src.CpuCoreCount
+	score -= (dst.MemorySizeMB - src.MemorySizeMB) * 25 / src.MemorySizeMB
+	if len(src.InstanceTypeCategory) > 0 && len(dst.InstanceTypeCategory) > 0 && src.InstanceTypeCategory != dst.InstanceTypeCategory {
+		score -= 20
+	}
+	return score, true
+}
+
+// 匹配度高的优先, 匹配度相同时有价格且更便宜的优先
+func sortEquivalentSkus(skus []sEquivalentSku) {
+	sort.SliceStable(skus, func(i, j int) bool {
+		if skus[i].Score != skus[j].Score {
+			return skus[i].Score > skus[j].Score
+		}
+		pi, pj := skus[i].HourlyPrice, skus[j].HourlyPrice
+		if (pi > 0) != (pj > 0) {
+			return pi > 0
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		if skus[i].Provider != skus[j].Provider {
+			return skus[i].Provider < skus[j].Provider
+		}
+		return skus[i].Name < skus[j].Name
+	})
+}
+
+// 查找其他平台(或指定平台、区域)中与源套餐等价的套餐, 供跨云克隆、迁移及成本比较选择目标套餐
+func (manager *SServerSkuManager) findEquivalentSkus(src *SServerSku, providers, regionIds []string, limit int) ([]sEquivalentSku, error) {
+	q := manager.Query().IsTrue("enabled").Equals("postpaid_status", api.SkuStatusAvailable)
+	q = q.GE("cpu_core_count", src.CpuCoreCount).LE("cpu_core_count", src.CpuCoreCount*2)
+	q = q.GE("memory_size_mb", src.MemorySizeMB).LE("memory_size_mb", src.MemorySizeMB*2)
+	if len(providers) > 0 {
+		q = q.In("provider", providers)
+	} else if len(src.Provider) > 0 {
+		q = q.NotEquals("provider", src.Provider)
+	}
+	if len(regionIds) > 0 {
+		q = q.In("cloudregion_id", regionIds)
+	}
+	if len(src.Id) > 0 {
+		q = q.NotEquals("id", src.Id)
+	}
+	skus := []SServerSku{}
+	err := db.FetchModelObjects(manager, q, &skus)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	ret := []sEquivalentSku{}
+	for i := range skus {
+		score, ok := getSkuEquivalenceScore(src, &skus[i])
+		if ok {
+			ret = append(ret, sEquivalentSku{SServerSku: skus[i], Score: score})
+		}
+	}
+	sortEquivalentSkus(ret)
+	// 同一区域的同名套餐按可用区重复出现, 仅保留一个
+	uniq := map[string]bool{}
+	result := []sEquivalentSku{}
+	for i := range ret {
+		key := ret[i].CloudregionId + "/" + ret[i].Name
+		if uniq[key] {
+			continue
+		}
+		uniq[key] = true
+		result = append(result, ret[i])
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (self *SServerSku) toEquivalent(regions map[string]string) api.ServerSkuEquivalent {
+	return api.ServerSkuEquivalent{
+		Id:                   self.Id,
+		Name:                 self.Name,
+		Provider:             self.Provider,
+		CloudregionId:        self.CloudregionId,
+		Cloudregion:          regions[self.CloudregionId],
+		InstanceTypeFamily:   self.InstanceTypeFamily,
+		InstanceTypeCategory: self.InstanceTypeCategory,
+		CpuArch:              self.CpuArch,
+		CpuCoreCount:         self.CpuCoreCount,
+		MemorySizeMB:         self.MemorySizeMB,
+		GpuCount:             self.GpuCount,
+		HourlyPrice:          self.HourlyPrice,
+		Currency:             self.Currency,
+	}
+}
+
+func (manager *SServerSkuManager) GetPropertyEquivalents(ctx context.Context, userCred mcclient.TokenCredential, input api.ServerSkuEquivalentInput) (*api.ServerSkuEquivalentOutput, error) {
+	src := &SServerSku{}
+	if len(input.SkuId) > 0 {
+		skuObj, err := validators.ValidateModel(userCred, manager, &input.SkuId)
+		if err != nil {
+			return nil, err
+		}
+		src = skuObj.(*SServerSku)
+	} else {
+		if input.CpuCoreCount <= 0 || input.MemorySizeMB <= 0 {
+			return nil, httperrors.NewMissingParameterError("sku_id or cpu_core_count and memory_size_mb")
+		}
+		src.CpuCoreCount = input.CpuCoreCount
+		src.MemorySizeMB = input.MemorySizeMB
+		src.InstanceTypeCategory = input.InstanceTypeCategory
+		src.CpuArch = input.CpuArch
+	}
+	for i := range input.CloudregionId {
+		_, err := validators.ValidateModel(userCred, CloudregionManager, &input.CloudregionId[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if input.Limit <= 0 {
+		input.Limit = 20
+	}
+
+	skus, err := manager.findEquivalentSkus(src, input.Provider, input.CloudregionId, input.Limit)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	regionIds := []string{src.CloudregionId}
+	for i := range skus {
+		regionIds = append(regionIds, skus[i].CloudregionId)
+	}
+	regions, err := db.FetchIdNameMap2(CloudregionManager, regionIds)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+
+	ret := &api.ServerSkuEquivalentOutput{Data: []api.ServerSkuEquivalent{}}
+	if len(src.Id) > 0 {
+		source := src.toEquivalent(regions)
+		source.Score = 100
+		ret.Source = &source
+	}
+	for i := range skus {
+		item := skus[i].toEquivalent(regions)
+		item.Score = skus[i].Score
+		if src.HourlyPrice > 0 && skus[i].HourlyPrice > 0 && src.Currency == skus[i].Currency {
+			delta := skus[i].HourlyPrice - src.HourlyPrice
+			item.PriceDelta = &delta
+		}
+		ret.Data = append(ret.Data, item)
+	}
+	return ret, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func newTestSku(name string, cpu, mem int, category, arch string, price float64) SServerSku {
+	sku := SServerSku{
+		CpuCoreCount:         cpu,
+		MemorySizeMB:         mem,
+		InstanceTypeCategory: category,
+		CpuArch:              arch,
+		HourlyPrice:          price,
+	}
+	sku.Name = name
+	return sku
+}
+
+func TestGetSkuEquivalenceScore(t *testing.T) {
+	src := newTestSku("src", 2, 4096, "general_purpose", "x86", 0)
+	cases := []struct {
+		dst   SServerSku
+		score int
+		ok    bool
+	}{
+		{newTestSku("same", 2, 4096, "general_purpose", "", 0), 100, true},
+		{newTestSku("bigger", 4, 8192, "general_purpose", "x86", 0), 50, true},
+		{newTestSku("other category", 2, 4096, "compute_optimized", "x86", 0), 80, true},
+		{newTestSku("smaller", 1, 4096, "general_purpose", "x86", 0), 0, false},
+		{newTestSku("too big", 8, 4096, "general_purpose", "x86", 0), 0, false},
+		{newTestSku("arm", 2, 4096, "general_purpose", "arm", 0), 0, false},
+	}
+	for _, c := range cases {
+		score, ok := getSkuEquivalenceScore(&src, &c.dst)
+		if score != c.score || ok != c.ok {
+			t.Errorf("%s: want (%d, %v), got (%d, %v)", c.dst.Name, c.score, c.ok, score, ok)
+		}
+	}
+
+	gpu := newTestSku("gpu", 2, 4096, "", "x86", 0)
+	gpu.GpuCount = 1
+	if _, ok := getSkuEquivalenceScore(&src, &gpu); ok {
+		t.Errorf("gpu sku should not be equivalent to non-gpu sku")
+	}
+}
+
+func TestSortEquivalentSkus(t *testing.T) {
+	skus := []sEquivalentSku{
+		{SServerSku: newTestSku("no-price", 2, 4096, "", "", 0), Score: 100},
+		{SServerSku: newTestSku("expensive", 2, 4096, "", "", 0.2), Score: 100},
+		{SServerSku: newTestSku("cheap", 2, 4096, "", "", 0.1), Score: 100},
+		{SServerSku: newTestSku("bigger", 4, 4096, "", "", 0.01), Score: 75},
+	}
+	sortEquivalentSkus(skus)
+	want := []string{"cheap", "expensive", "no-price", "bigger"}
+	for i := range want {
+		if skus[i].Name != want[i] {
+			t.Errorf("index %d: want %s, got %s", i, want[i], skus[i].Name)
+		}
+	}
+}
@@ -18,16 +18,20 @@ import (
 	"context"
 	"database/sql"
 	"strings"
+	"time"
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/timeutils"
 	"yunion.io/x/pkg/utils"
 	"yunion.io/x/sqlchemy"
 
 	apis "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 )
@@ -81,24 +85,150 @@ func PerformActionSyncSkus(ctx context.Context, userCred mcclient.TokenCredentia
 	}
 
 	// start cloudregion skus sync tasks
-	params := jsonutils.NewDict()
-	params.Set("resource", jsonutils.NewString(resourceKey))
 	// replaced with NewParallelTask??
 	ret := jsonutils.NewDict()
 	taskIds := jsonutils.NewArray()
 	for i := range regions {
-		task, err := taskman.TaskManager.NewTask(ctx, "CloudRegionSyncSkusTask", &regions[i], userCred, params, "", "", nil)
+		taskId, err := regions[i].startSyncSkusTask(ctx, userCred, resourceKey)
 		if err != nil {
-			return nil, errors.Wrapf(err, "CloudRegionSyncSkusTask")
+			return nil, err
 		}
+		taskIds.Add(jsonutils.NewString(taskId))
+	}
+	ret.Set("tasks", taskIds)
+	return ret, nil
+}
+
+func (self *SCloudregion) startSyncSkusTask(ctx context.Context, userCred mcclient.TokenCredential, resourceKey string) (string, error) {
+	params := jsonutils.NewDict()
+	params.Set("resource", jsonutils.NewString(resourceKey))
+	task, err := taskman.TaskManager.NewTask(ctx, "CloudRegionSyncSkusTask", self, userCred, params, "", "", nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "CloudRegionSyncSkusTask")
+	}
+	task.ScheduleRun(nil)
+	return task.GetId(), nil
+}
+
+// 区域支持按需同步的套餐类型
+func (self *SCloudregion) getSkuResources() []string {
+	resources := []string{ServerSkuManager.Keyword()}
+	driver := self.GetDriver()
+	if driver.IsSupportedElasticcache() {
+		resources = append(resources, ElasticcacheSkuManager.Keyword())
+	}
+	if driver.IsSupportedDBInstance() {
+		resources = append(resources, DBInstanceSkuManager.Keyword())
+	}
+	return resources
+}
 
-		task.ScheduleRun(nil)
-		taskIds.Add(jsonutils.NewString(task.GetId()))
+// 仅同步指定区域的套餐, 不依赖全局定时同步
+func (self *SCloudregion) performSyncSkus(ctx context.Context, userCred mcclient.TokenCredential, resources []string) (jsonutils.JSONObject, error) {
+	if !utils.IsInStringArray(self.Provider, cloudprovider.GetPublicProviders()) {
+		return nil, httperrors.NewUnsupportOperationError("region %s of %s not support sync skus", self.Name, self.Provider)
+	}
+	supported := self.getSkuResources()
+	if len(resources) == 0 {
+		resources = supported
+	}
+	for _, res := range resources {
+		if !utils.IsInStringArray(res, supported) {
+			return nil, httperrors.NewInputParameterError("region %s not support sync %s", self.Name, res)
+		}
+	}
+	ret := jsonutils.NewDict()
+	taskIds := jsonutils.NewArray()
+	for _, res := range resources {
+		taskId, err := self.startSyncSkusTask(ctx, userCred, res)
+		if err != nil {
+			return nil, err
+		}
+		taskIds.Add(jsonutils.NewString(taskId))
 	}
 	ret.Set("tasks", taskIds)
 	return ret, nil
 }
 
+func (self *SCloudregion) getSkuSyncedAt(resourceKey string) time.Time {
+	switch resourceKey {
+	case ServerSkuManager.Keyword():
+		return self.ServerSkuSyncedAt
+	case ElasticcacheSkuManager.Keyword():
+		return self.ElasticcacheSkuSyncedAt
+	case DBInstanceSkuManager.Keyword():
+		return self.DBInstanceSkuSyncedAt
+	}
+	return time.Time{}
+}
+
+// 记录区域套餐同步成功时间
+func (self *SCloudregion) markSkuSynced(resourceKey string) {
+	_, err := db.Update(self, func() error {
+		now := timeutils.UtcNow()
+		switch resourceKey {
+		case ServerSkuManager.Keyword():
+			self.ServerSkuSyncedAt = now
+		case ElasticcacheSkuManager.Keyword():
+			self.ElasticcacheSkuSyncedAt = now
+		case DBInstanceSkuManager.Keyword():
+			self.DBInstanceSkuSyncedAt = now
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("mark %s synced for region %s error: %v", resourceKey, self.Name, err)
+	}
+}
+
+// 在deadline之前未成功同步的套餐类型, 从未同步过的同样视为过期
+func (self *SCloudregion) getStaleSkuResources(resources []string, deadline time.Time) []string {
+	stale := []string{}
+	for _, res := range resources {
+		if self.getSkuSyncedAt(res).Before(deadline) {
+			stale = append(stale, res)
+		}
+	}
+	return stale
+}
+
+func GetPropertySkuFreshness(ctx context.Context, userCred mcclient.TokenCredential, input apis.CloudregionSkuFreshnessInput) (*apis.CloudregionSkuFreshnessOutput, error) {
+	q := CloudregionManager.Query()
+	if len(input.Provider) > 0 {
+		q = q.Equals("provider", input.Provider)
+	} else {
+		q = q.In("provider", cloudprovider.GetPublicProviders())
+	}
+	if len(input.CloudregionIds) > 0 {
+		q = q.Filter(sqlchemy.OR(sqlchemy.In(q.Field("id"), input.CloudregionIds), sqlchemy.In(q.Field("name"), input.CloudregionIds)))
+	}
+	regions := []SCloudregion{}
+	err := db.FetchModelObjects(CloudregionManager, q, &regions)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+
+	staleHours := input.StaleHours
+	if staleHours <= 0 {
+		staleHours = options.Options.SyncSkusDay * 24
+	}
+	deadline := time.Now().Add(-time.Duration(staleHours) * time.Hour)
+	ret := &apis.CloudregionSkuFreshnessOutput{Data: []apis.CloudregionSkuFreshness{}}
+	for i := range regions {
+		region := regions[i]
+		ret.Data = append(ret.Data, apis.CloudregionSkuFreshness{
+			Id:                      region.Id,
+			Name:                    region.Name,
+			Provider:                region.Provider,
+			ServerSkuSyncedAt:       region.ServerSkuSyncedAt,
+			ElasticcacheSkuSyncedAt: region.ElasticcacheSkuSyncedAt,
+			DBInstanceSkuSyncedAt:   region.DBInstanceSkuSyncedAt,
+			StaleResources:          region.getStaleSkuResources(region.getSkuResources(), deadline),
+		})
+	}
+	return ret, nil
+}
+
 func GetPropertySkusSyncTasks(ctx context.Context, userCred mcclient.TokenCredential, query apis.SkuTaskQueryInput) (jsonutils.JSONObject, error) {
 	tasks := []taskman.STask{}
 	q := taskman.TaskManager.Query()
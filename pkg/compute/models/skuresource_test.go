@@ -0,0 +1,44 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetStaleSkuResources(t *testing.T) {
+	now := time.Now()
+	region := SCloudregion{
+		ServerSkuSyncedAt:       now.Add(-time.Hour),
+		ElasticcacheSkuSyncedAt: now.Add(-48 * time.Hour),
+	}
+	resources := []string{"serversku", "elasticcachesku", "dbinstance_sku"}
+	cases := []struct {
+		deadline time.Time
+		want     []string
+	}{
+		{now.Add(-24 * time.Hour), []string{"elasticcachesku", "dbinstance_sku"}},
+		{now.Add(-72 * time.Hour), []string{"dbinstance_sku"}},
+		{now, resources},
+	}
+	for _, c := range cases {
+		got := region.getStaleSkuResources(resources, c.deadline)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("deadline %s: want %v, got %v", c.deadline, c.want, got)
+		}
+	}
+}
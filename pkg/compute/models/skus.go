@@ -109,6 +109,11 @@ type SServerSku struct {
 	GpuMaxCount   int               `nullable:"true" list:"user" create:"admin_optional" update:"admin"`
 
 	Provider string `width:"64" charset:"ascii" nullable:"true" list:"user" default:"OneCloud" create:"admin_optional"`
+
+	// 按量付费参考价格(每小时), 由SKU元数据同步或管理员设置
+	HourlyPrice float64 `nullable:"false" default:"0" list:"user" update:"admin" create:"admin_optional"`
+	// 币种
+	Currency string `width:"8" charset:"ascii" nullable:"true" list:"user" update:"admin" create:"admin_optional"`
 }
 
 func (manager *SServerSkuManager) FetchUniqValues(ctx context.Context, data jsonutils.JSONObject) jsonutils.JSONObject {
@@ -603,6 +608,9 @@ func (self *SServerSku) ValidateUpdateData(ctx context.Context, userCred mcclien
 	if err != nil {
 		return input, errors.Wrap(err, "SEnabledStatusStandaloneResourceBase.ValidateUpdateData")
 	}
+	if input.HourlyPrice != nil && *input.HourlyPrice < 0 {
+		return input, httperrors.NewInputParameterError("invalid hourly_price %f", *input.HourlyPrice)
+	}
 
 	return input, nil
 }
@@ -1148,6 +1156,10 @@ func (self *SServerSku) syncWithCloudSku(ctx context.Context, userCred mcclient.
 		self.CpuArch = extSku.CpuArch
 		self.SysDiskType = extSku.SysDiskType
 		self.DataDiskTypes = extSku.DataDiskTypes
+		if extSku.HourlyPrice > 0 {
+			self.HourlyPrice = extSku.HourlyPrice
+			self.Currency = extSku.Currency
+		}
 		return nil
 	})
 	return err
@@ -1229,6 +1241,9 @@ func (manager *SServerSkuManager) SyncServerSkus(ctx context.Context, userCred m
 			syncResult.Add()
 		}
 	}
+	if !syncResult.IsError() {
+		region.markSkuSynced(manager.Keyword())
+	}
 
 	// notfiy sched manager
 	_, err = scheduler.SchedManager.SyncSku(auth.GetAdminSession(ctx, options.Options.Region), false)
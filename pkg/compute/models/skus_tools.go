@@ -542,13 +542,16 @@ func SyncServerSkus(ctx context.Context, userCred mcclient.TokenCredential, isSt
 			skuIndex[region.ExternalId] = newMd5
 		}
 
+		// 套餐为空或未变化时无需同步, 同样视为已同步
 		if newMd5 == EMPTY_MD5 {
 			log.Infof("%s Server Skus is empty skip syncing", region.Name)
+			region.markSkuSynced(ServerSkuManager.Keyword())
 			continue
 		}
 
 		if len(oldMd5) > 0 && newMd5 == oldMd5 {
 			log.Infof("%s Server Skus not Changed skip syncing", region.Name)
+			region.markSkuSynced(ServerSkuManager.Keyword())
 			continue
 		}
 
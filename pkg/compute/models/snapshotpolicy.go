@@ -27,6 +27,7 @@ import (
 	"yunion.io/x/pkg/tristate"
 	"yunion.io/x/pkg/util/compare"
 	"yunion.io/x/pkg/util/sets"
+	"yunion.io/x/pkg/utils"
 	"yunion.io/x/sqlchemy"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
@@ -61,6 +62,9 @@ type SSnapshotPolicy struct {
 	// 0~23
 	TimePoints  uint32            `charset:"utf8" create:"required" list:"user" get:"user"`
 	IsActivated tristate.TriState `list:"user" get:"user" create:"optional" default:"true"`
+
+	// 快照对象类型, disk或server
+	ResourceType string `width:"16" charset:"ascii" nullable:"false" default:"disk" list:"user" get:"user" create:"optional"`
 }
 
 var SnapshotPolicyManager *SSnapshotPolicyManager
@@ -118,9 +122,9 @@ func (manager *SSnapshotPolicyManager) CustomizeFilterList(ctx context.Context,
 }
 
 // ==================================================== fetch ==========================================================
-func (manager *SSnapshotPolicyManager) GetSnapshotPoliciesAt(week, timePoint uint32) ([]string, error) {
+func (manager *SSnapshotPolicyManager) GetSnapshotPoliciesAt(resourceType string, week, timePoint uint32) ([]string, error) {
 
-	q := manager.Query("id")
+	q := manager.Query("id").Equals("resource_type", resourceType)
 	q = q.Filter(sqlchemy.Equals(sqlchemy.AND_Val("", q.Field("repeat_weekdays"), 1<<week), 1<<week))
 	q = q.Filter(sqlchemy.Equals(sqlchemy.AND_Val("", q.Field("time_points"), 1<<timePoint), 1<<timePoint))
 	q = q.Equals("is_activated", true)
@@ -207,6 +211,13 @@ func (manager *SSnapshotPolicyManager) ValidateCreateData(ctx context.Context, u
 		return nil, httperrors.NewInputParameterError("%v", err)
 	}
 
+	if len(input.ResourceType) == 0 {
+		input.ResourceType = api.SNAPSHOT_POLICY_RESOURCE_TYPE_DISK
+	}
+	if !utils.IsInStringArray(input.ResourceType, api.SNAPSHOT_POLICY_RESOURCE_TYPES) {
+		return nil, httperrors.NewInputParameterError("invalid resource_type %s, supported: %s", input.ResourceType, api.SNAPSHOT_POLICY_RESOURCE_TYPES)
+	}
+
 	internalInput := manager.sSnapshotPolicyCreateInputToInternal(input)
 	data = internalInput.JSON(internalInput)
 	return data, nil
@@ -308,6 +319,10 @@ func (sp *SSnapshotPolicy) DetachAfterDelete(ctx context.Context, userCred mccli
 	if err != nil {
 		return errors.Wrap(err, "detach after delete failed")
 	}
+	err = SnapshotPolicyGuestManager.detachBySnapshotpolicy(ctx, userCred, sp.Id)
+	if err != nil {
+		return errors.Wrap(err, "detach guests after delete failed")
+	}
 	return nil
 }
 
@@ -320,6 +335,13 @@ func (sp *SSnapshotPolicy) CustomizeDelete(ctx context.Context, userCred mcclien
 	if count != 0 {
 		return httperrors.NewBadRequestError("Couldn't delete snapshot policy binding to disks")
 	}
+	count, err = SnapshotPolicyGuestManager.Query().Equals("snapshotpolicy_id", sp.Id).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "unable to count binding servers")
+	}
+	if count != 0 {
+		return httperrors.NewBadRequestError("Couldn't delete snapshot policy binding to servers")
+	}
 	sp.SetStatus(userCred, api.SNAPSHOT_POLICY_DELETING, "")
 	return sp.StartSnapshotPolicyDeleteTask(ctx, userCred, jsonutils.NewDict(), "")
 }
@@ -372,6 +394,7 @@ func (sp *SSnapshotPolicy) getMoreDetails(out api.SnapshotPolicyDetails) api.Sna
 	out.RepeatWeekdaysDisplay = SnapshotPolicyManager.RepeatWeekdaysToIntArray(sp.RepeatWeekdays)
 	out.TimePointsDisplay = SnapshotPolicyManager.TimePointsToIntArray(sp.TimePoints)
 	out.BindingDiskCount, _ = SnapshotPolicyDiskManager.FetchDiskCountBySPID(sp.Id)
+	out.BindingServerCount, _ = SnapshotPolicyGuestManager.Query().Equals("snapshotpolicy_id", sp.Id).CountWithError()
 	return out
 }
 
@@ -703,6 +726,7 @@ func (manager *SSnapshotPolicyManager) sSnapshotPolicyCreateInputToInternal(inpu
 		ProjectId:     input.ProjectId,
 		DomainId:      input.DomainId,
 		RetentionDays: input.RetentionDays,
+		ResourceType:  input.ResourceType,
 	}
 
 	ret.RepeatWeekdays = manager.RepeatWeekdaysParseIntArray(input.RepeatWeekdays)
@@ -827,6 +851,9 @@ func (sp *SSnapshotPolicy) PerformCache(ctx context.Context, userCred mcclient.T
 func (sp *SSnapshotPolicy) PerformBindDisks(ctx context.Context, userCred mcclient.TokenCredential,
 	query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
 
+	if sp.ResourceType == api.SNAPSHOT_POLICY_RESOURCE_TYPE_SERVER {
+		return nil, httperrors.NewUnsupportOperationError("server snapshot policy can not bind disks")
+	}
 	disks := jsonutils.GetArrayOfPrefix(data, "disk")
 	if len(disks) == 0 {
 		return nil, httperrors.NewMissingParameterError("disk.0 disk.1 ... ")
@@ -844,6 +871,14 @@ func (sp *SSnapshotPolicy) PerformBindDisks(ctx context.Context, userCred mcclie
 		diskSlice[i] = disk
 	}
 
+	err := sp.bindDisks(ctx, userCred, diskSlice)
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (sp *SSnapshotPolicy) bindDisks(ctx context.Context, userCred mcclient.TokenCredential, diskSlice []*SDisk) error {
 	taskDisk := make([]*SDisk, 0, len(diskSlice))
 	taskSpd := make([]*SSnapshotPolicyDisk, 0, len(diskSlice))
 	for _, disk := range diskSlice {
@@ -856,7 +891,7 @@ func (sp *SSnapshotPolicy) PerformBindDisks(ctx context.Context, userCred mcclie
 			continue
 		}
 		if err != nil {
-			return nil, fmt.Errorf("oper for database error")
+			return fmt.Errorf("oper for database error")
 		}
 		taskDisk = append(taskDisk, disk)
 		taskSpd = append(taskSpd, spd)
@@ -875,7 +910,7 @@ func (sp *SSnapshotPolicy) PerformBindDisks(ctx context.Context, userCred mcclie
 		}
 	}
 
-	return nil, nil
+	return nil
 }
 
 func (sp *SSnapshotPolicy) PerformUnbindDisks(ctx context.Context, userCred mcclient.TokenCredential,
@@ -944,6 +979,9 @@ func (manager *SSnapshotPolicyManager) ListItemFilter(
 			q = q.IsFalse("is_activated")
 		}
 	}
+	if len(input.ResourceType) > 0 {
+		q = q.In("resource_type", input.ResourceType)
+	}
 	return q, nil
 }
 
@@ -0,0 +1,208 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// 检查快照策略是否满足模板策略的要求, 返回不满足的原因
+func (sp *SSnapshotPolicy) complianceIssues(template *SSnapshotPolicy) []string {
+	issues := []string{}
+	if sp.IsActivated.IsFalse() {
+		issues = append(issues, api.SNAPSHOT_POLICY_COMPLIANCE_INACTIVE)
+	}
+	// -1 表示永久保留
+	if template.RetentionDays == -1 && sp.RetentionDays != -1 || sp.RetentionDays != -1 && sp.RetentionDays < template.RetentionDays {
+		issues = append(issues, api.SNAPSHOT_POLICY_COMPLIANCE_RETENTION_TOO_SHORT)
+	}
+	if sp.RepeatWeekdays&template.RepeatWeekdays != template.RepeatWeekdays {
+		issues = append(issues, api.SNAPSHOT_POLICY_COMPLIANCE_WEEKDAYS_UNCOVERED)
+	}
+	return issues
+}
+
+func (sp *SSnapshotPolicy) getDiskCompliances(ctx context.Context, userCred mcclient.TokenCredential, scope api.SnapshotPolicyDiskScopeInput) ([]api.SnapshotPolicyDiskCompliance, error) {
+	// 与绑定磁盘保持一致, 主机快照策略不能作为磁盘的合规模板
+	if sp.ResourceType == api.SNAPSHOT_POLICY_RESOURCE_TYPE_SERVER {
+		return nil, httperrors.NewUnsupportOperationError("server snapshot policy %s can not bind disks", sp.Name)
+	}
+	q := DiskManager.Query()
+	q, err := db.ListItemQueryFilters(DiskManager, ctx, q, userCred, jsonutils.Marshal(scope), policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	disks := []SDisk{}
+	err = db.FetchModelObjects(DiskManager, q, &disks)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	ret := []api.SnapshotPolicyDiskCompliance{}
+	if len(disks) == 0 {
+		return ret, nil
+	}
+	diskIds, projectIds := []string{}, []string{}
+	for i := range disks {
+		diskIds = append(diskIds, disks[i].Id)
+		projectIds = append(projectIds, disks[i].ProjectId)
+	}
+
+	spds := []SSnapshotPolicyDisk{}
+	spdq := SnapshotPolicyDiskManager.Query().In("disk_id", diskIds).NotEquals("status", api.SNAPSHOT_POLICY_DISK_DELETING)
+	err = db.FetchModelObjects(SnapshotPolicyDiskManager, spdq, &spds)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch snapshotpolicydisks")
+	}
+	diskPolicies, spIds := map[string][]string{}, []string{}
+	for _, spd := range spds {
+		diskPolicies[spd.DiskId] = append(diskPolicies[spd.DiskId], spd.SnapshotpolicyId)
+		spIds = append(spIds, spd.SnapshotpolicyId)
+	}
+	policies := map[string]SSnapshotPolicy{}
+	err = db.FetchModelObjectsByIds(SnapshotPolicyManager, "id", spIds, &policies)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch snapshotpolicies")
+	}
+
+	guestdisks := []SGuestdisk{}
+	err = db.FetchModelObjects(GuestdiskManager, GuestdiskManager.Query().In("disk_id", diskIds), &guestdisks)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch guestdisks")
+	}
+	diskGuest, guestIds := map[string]string{}, []string{}
+	for _, gd := range guestdisks {
+		diskGuest[gd.DiskId] = gd.GuestId
+		guestIds = append(guestIds, gd.GuestId)
+	}
+	guestNames, err := db.FetchIdNameMap2(GuestManager, guestIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch guest names")
+	}
+	projectNames, err := db.FetchIdNameMap2(db.TenantCacheManager, projectIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch project names")
+	}
+
+	for i := range disks {
+		disk := &disks[i]
+		compliance := api.SnapshotPolicyDiskCompliance{
+			DiskId:            disk.Id,
+			Disk:              disk.Name,
+			GuestId:           diskGuest[disk.Id],
+			Guest:             guestNames[diskGuest[disk.Id]],
+			TenantId:          disk.ProjectId,
+			Tenant:            projectNames[disk.ProjectId],
+			DomainId:          disk.DomainId,
+			SnapshotpolicyIds: diskPolicies[disk.Id],
+		}
+		if len(compliance.SnapshotpolicyIds) == 0 {
+			compliance.Reasons = []string{api.SNAPSHOT_POLICY_COMPLIANCE_NO_POLICY}
+		}
+		for _, spId := range compliance.SnapshotpolicyIds {
+			attached, ok := policies[spId]
+			if !ok {
+				continue
+			}
+			issues := attached.complianceIssues(sp)
+			if len(issues) == 0 {
+				compliance.Compliant = true
+				compliance.Reasons = nil
+				break
+			}
+			for _, issue := range issues {
+				if !utils.IsInStringArray(issue, compliance.Reasons) {
+					compliance.Reasons = append(compliance.Reasons, issue)
+				}
+			}
+		}
+		ret = append(ret, compliance)
+	}
+	return ret, nil
+}
+
+// 以当前快照策略为模板, 检查指定范围内的磁盘是否绑定了满足要求的快照策略
+func (sp *SSnapshotPolicy) GetDetailsCompliance(ctx context.Context, userCred mcclient.TokenCredential, query api.SnapshotPolicyComplianceInput) (api.SnapshotPolicyComplianceOutput, error) {
+	output := api.SnapshotPolicyComplianceOutput{SnapshotpolicyId: sp.Id, Data: []api.SnapshotPolicyDiskCompliance{}}
+	compliances, err := sp.getDiskCompliances(ctx, userCred, query.SnapshotPolicyDiskScopeInput)
+	if err != nil {
+		return output, err
+	}
+	nonCompliantGuests := map[string]bool{}
+	for _, compliance := range compliances {
+		output.Total++
+		if compliance.Compliant {
+			output.Compliant++
+			if query.NonCompliantOnly {
+				continue
+			}
+		} else {
+			output.NonCompliant++
+			if len(compliance.GuestId) > 0 {
+				nonCompliantGuests[compliance.GuestId] = true
+			}
+		}
+		output.Data = append(output.Data, compliance)
+	}
+	output.NonCompliantGuests = len(nonCompliantGuests)
+	return output, nil
+}
+
+// 将当前快照策略绑定到指定范围内所有不合规的磁盘
+func (sp *SSnapshotPolicy) PerformApplyToNonCompliant(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SnapshotPolicyApplyToNonCompliantInput) (api.SnapshotPolicyApplyToNonCompliantOutput, error) {
+	output := api.SnapshotPolicyApplyToNonCompliantOutput{DiskIds: []string{}}
+	if sp.IsActivated.IsFalse() {
+		return output, httperrors.NewInvalidStatusError("snapshot policy %s is not activated", sp.Name)
+	}
+	compliances, err := sp.getDiskCompliances(ctx, userCred, input.SnapshotPolicyDiskScopeInput)
+	if err != nil {
+		return output, err
+	}
+	diskIds := []string{}
+	for _, compliance := range compliances {
+		if !compliance.Compliant {
+			diskIds = append(diskIds, compliance.DiskId)
+		}
+	}
+	if len(diskIds) == 0 {
+		return output, nil
+	}
+	disks := []SDisk{}
+	err = db.FetchModelObjects(DiskManager, DiskManager.Query().In("id", diskIds), &disks)
+	if err != nil {
+		return output, httperrors.NewGeneralError(errors.Wrap(err, "fetch disks"))
+	}
+	diskSlice := make([]*SDisk, len(disks))
+	for i := range disks {
+		diskSlice[i] = &disks[i]
+		output.DiskIds = append(output.DiskIds, disks[i].Id)
+	}
+	err = sp.bindDisks(ctx, userCred, diskSlice)
+	if err != nil {
+		return output, httperrors.NewGeneralError(err)
+	}
+	logclient.AddActionLogWithContext(ctx, sp, logclient.ACT_BIND_DISK, output.DiskIds, userCred, true)
+	return output, nil
+}
@@ -0,0 +1,281 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type SSnapshotPolicyGuestManager struct {
+	SGuestJointsManager
+}
+
+var SnapshotPolicyGuestManager *SSnapshotPolicyGuestManager
+
+func init() {
+	db.InitManager(func() {
+		SnapshotPolicyGuestManager = &SSnapshotPolicyGuestManager{
+			SGuestJointsManager: NewGuestJointsManager(
+				SSnapshotPolicyGuest{},
+				"snapshotpolicyguests_tbl",
+				"snapshotpolicyguest",
+				"snapshotpolicyguests",
+				SnapshotPolicyManager,
+			),
+		}
+		SnapshotPolicyGuestManager.SetVirtualObject(SnapshotPolicyGuestManager)
+	})
+}
+
+// 主机快照策略与主机的绑定关系
+type SSnapshotPolicyGuest struct {
+	SGuestJointsBase
+
+	SnapshotpolicyId string `width:"36" charset:"ascii" nullable:"false" index:"true"`
+}
+
+func (manager *SSnapshotPolicyGuestManager) GetSlaveFieldName() string {
+	return "snapshotpolicy_id"
+}
+
+func (spg *SSnapshotPolicyGuest) Detach(ctx context.Context, userCred mcclient.TokenCredential) error {
+	return db.DetachJoint(ctx, userCred, spg)
+}
+
+func (manager *SSnapshotPolicyGuestManager) attach(ctx context.Context, sp *SSnapshotPolicy, guest *SGuest) error {
+	lockman.LockJointObject(ctx, guest, sp)
+	defer lockman.ReleaseJointObject(ctx, guest, sp)
+
+	cnt, err := manager.Query().Equals("snapshotpolicy_id", sp.Id).Equals("guest_id", guest.Id).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "CountWithError")
+	}
+	if cnt > 0 {
+		return nil
+	}
+	spg := &SSnapshotPolicyGuest{}
+	spg.SetModelManager(manager, spg)
+	spg.GuestId = guest.Id
+	spg.SnapshotpolicyId = sp.Id
+	return manager.TableSpec().Insert(ctx, spg)
+}
+
+func (manager *SSnapshotPolicyGuestManager) fetch(spId, guestId string) ([]SSnapshotPolicyGuest, error) {
+	q := manager.Query()
+	if len(spId) > 0 {
+		q = q.Equals("snapshotpolicy_id", spId)
+	}
+	if len(guestId) > 0 {
+		q = q.Equals("guest_id", guestId)
+	}
+	spgs := []SSnapshotPolicyGuest{}
+	err := db.FetchModelObjects(manager, q, &spgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return spgs, nil
+}
+
+func (manager *SSnapshotPolicyGuestManager) detach(ctx context.Context, userCred mcclient.TokenCredential, spId, guestId string) error {
+	spgs, err := manager.fetch(spId, guestId)
+	if err != nil {
+		return err
+	}
+	for i := range spgs {
+		err := spgs[i].Detach(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "detach snapshot policy %s from guest %s", spgs[i].SnapshotpolicyId, spgs[i].GuestId)
+		}
+	}
+	return nil
+}
+
+func (manager *SSnapshotPolicyGuestManager) detachBySnapshotpolicy(ctx context.Context, userCred mcclient.TokenCredential, spId string) error {
+	return manager.detach(ctx, userCred, spId, "")
+}
+
+func (manager *SSnapshotPolicyGuestManager) deleteByGuest(ctx context.Context, userCred mcclient.TokenCredential, guestId string) error {
+	return manager.detach(ctx, userCred, "", guestId)
+}
+
+func (sp *SSnapshotPolicy) fetchServers(userCred mcclient.TokenCredential, servers []string) ([]*SGuest, error) {
+	if sp.ResourceType != api.SNAPSHOT_POLICY_RESOURCE_TYPE_SERVER {
+		return nil, httperrors.NewUnsupportOperationError("disk snapshot policy can not bind servers")
+	}
+	if len(servers) == 0 {
+		return nil, httperrors.NewMissingParameterError("servers")
+	}
+	guests := make([]*SGuest, len(servers))
+	for i := range servers {
+		guestObj, err := validators.ValidateModel(userCred, GuestManager, &servers[i])
+		if err != nil {
+			return nil, err
+		}
+		guests[i] = guestObj.(*SGuest)
+	}
+	return guests, nil
+}
+
+// 绑定主机, 按照策略定时创建主机快照
+func (sp *SSnapshotPolicy) PerformBindServers(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SnapshotPolicyBindServersInput) (jsonutils.JSONObject, error) {
+	guests, err := sp.fetchServers(userCred, input.Servers)
+	if err != nil {
+		return nil, err
+	}
+	for _, guest := range guests {
+		if !utils.IsInStringArray(guest.Hypervisor, supportInstanceSnapshotHypervisors) {
+			return nil, httperrors.NewUnsupportOperationError("guest %s hypervisor %s can't create instance snapshot", guest.Name, guest.Hypervisor)
+		}
+	}
+	for _, guest := range guests {
+		err := SnapshotPolicyGuestManager.attach(ctx, sp, guest)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		db.OpsLog.LogEvent(guest, db.ACT_SNAPSHOT_POLICY_BIND_SERVER, sp.GetShortDesc(ctx), userCred)
+	}
+	logclient.AddActionLogWithContext(ctx, sp, logclient.ACT_BIND_SERVER, input, userCred, true)
+	return nil, nil
+}
+
+// 解绑主机
+func (sp *SSnapshotPolicy) PerformUnbindServers(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SnapshotPolicyBindServersInput) (jsonutils.JSONObject, error) {
+	guests, err := sp.fetchServers(userCred, input.Servers)
+	if err != nil {
+		return nil, err
+	}
+	for _, guest := range guests {
+		err := SnapshotPolicyGuestManager.detach(ctx, userCred, sp.Id, guest.Id)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		db.OpsLog.LogEvent(guest, db.ACT_SNAPSHOT_POLICY_UNBIND_SERVER, sp.GetShortDesc(ctx), userCred)
+	}
+	logclient.AddActionLogWithContext(ctx, sp, logclient.ACT_UNBIND_SERVER, input, userCred, true)
+	return nil, nil
+}
+
+// 按照快照策略创建主机快照, 过期时间由保留天数计算
+func (self *SGuest) createInstanceSnapshotAuto(ctx context.Context, userCred mcclient.TokenCredential, sp *SSnapshotPolicy) (*SInstanceSnapshot, error) {
+	lockman.LockClass(ctx, InstanceSnapshotManager, self.ProjectId)
+	defer lockman.ReleaseClass(ctx, InstanceSnapshotManager, self.ProjectId)
+
+	pendingUsage, params, err := self.validateCreateInstanceSnapshot(ctx, userCred, nil, api.ServerCreateSnapshotParams{GenerateName: "Auto-" + self.Name})
+	if err != nil {
+		return nil, errors.Wrap(err, "validateCreateInstanceSnapshot")
+	}
+	isp, err := InstanceSnapshotManager.CreateInstanceSnapshot(ctx, userCred, self, params.Name, false, false)
+	if err != nil {
+		quotas.CancelPendingUsage(ctx, userCred, pendingUsage, pendingUsage, false)
+		return nil, errors.Wrap(err, "CreateInstanceSnapshot")
+	}
+	_, err = db.Update(isp, func() error {
+		isp.SnapshotpolicyId = sp.Id
+		if sp.RetentionDays > 0 {
+			isp.ExpiredAt = time.Now().AddDate(0, 0, sp.RetentionDays)
+		}
+		return nil
+	})
+	if err != nil {
+		quotas.CancelPendingUsage(ctx, userCred, pendingUsage, pendingUsage, false)
+		return nil, errors.Wrap(err, "set snapshot policy of instance snapshot")
+	}
+	err = self.InheritTo(ctx, isp)
+	if err != nil {
+		quotas.CancelPendingUsage(ctx, userCred, pendingUsage, pendingUsage, false)
+		return nil, errors.Wrap(err, "InheritTo")
+	}
+	err = self.InstaceCreateSnapshot(ctx, userCred, isp, pendingUsage)
+	if err != nil {
+		quotas.CancelPendingUsage(ctx, userCred, pendingUsage, pendingUsage, false)
+		return nil, errors.Wrap(err, "InstaceCreateSnapshot")
+	}
+	return isp, nil
+}
+
+// 定时任务: 按照主机快照策略创建主机快照并清理过期的主机快照
+func (manager *SInstanceSnapshotManager) AutoInstanceSnapshot(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	manager.cleanExpiredAutoInstanceSnapshots(ctx, userCred, time.Now())
+
+	week, timePoint := getSnapshotPolicyTimePoint(time.Now())
+	spIds, err := SnapshotPolicyManager.GetSnapshotPoliciesAt(api.SNAPSHOT_POLICY_RESOURCE_TYPE_SERVER, week, timePoint)
+	if err != nil {
+		log.Errorf("Get instance snapshot policies failed: %s", err)
+		return
+	}
+	if len(spIds) == 0 {
+		return
+	}
+	spgs := []SSnapshotPolicyGuest{}
+	err = db.FetchModelObjects(SnapshotPolicyGuestManager, SnapshotPolicyGuestManager.Query().In("snapshotpolicy_id", spIds), &spgs)
+	if err != nil {
+		log.Errorf("Fetch snapshot policy guests failed: %s", err)
+		return
+	}
+	for i := range spgs {
+		guest := GuestManager.FetchGuestById(spgs[i].GuestId)
+		if guest == nil {
+			continue
+		}
+		sp, err := SnapshotPolicyManager.FetchSnapshotPolicyById(spgs[i].SnapshotpolicyId)
+		if err != nil {
+			log.Errorf("FetchSnapshotPolicyById %s failed: %s", spgs[i].SnapshotpolicyId, err)
+			continue
+		}
+		isp, err := guest.createInstanceSnapshotAuto(ctx, userCred, sp)
+		if err != nil {
+			db.OpsLog.LogEvent(guest, db.ACT_SERVER_AUTO_SNAPSHOT_FAIL, err.Error(), userCred)
+			reason := fmt.Sprintf("Server auto create instance snapshot failed: %s", err.Error())
+			notifyclient.NotifySystemErrorWithCtx(ctx, guest.Id, guest.Name, db.ACT_SERVER_AUTO_SNAPSHOT_FAIL, reason)
+			continue
+		}
+		db.OpsLog.LogEvent(guest, db.ACT_SERVER_AUTO_SNAPSHOT, "server auto snapshot "+isp.Name, userCred)
+		sp.ExecuteNotify(ctx, userCred, guest.GetName())
+	}
+}
+
+func (manager *SInstanceSnapshotManager) cleanExpiredAutoInstanceSnapshots(ctx context.Context, userCred mcclient.TokenCredential, now time.Time) {
+	q := manager.Query().IsNotEmpty("snapshotpolicy_id").IsNotNull("expired_at").LT("expired_at", now).
+		Equals("status", api.INSTANCE_SNAPSHOT_READY).Equals("ref_count", 0)
+	isps := []SInstanceSnapshot{}
+	err := db.FetchModelObjects(manager, q, &isps)
+	if err != nil {
+		log.Errorf("Fetch expired instance snapshots failed: %s", err)
+		return
+	}
+	for i := range isps {
+		err := isps[i].StartInstanceSnapshotDeleteTask(ctx, userCred, "")
+		if err != nil {
+			log.Errorf("delete expired instance snapshot %s failed: %s", isps[i].Name, err)
+		}
+	}
+}
@@ -206,3 +206,45 @@ func TestSSnapshotPolicy_ComputeNextSyncTime(t *testing.T) {
 		}
 	})
 }
+
+func TestSSnapshotPolicy_complianceIssues(t *testing.T) {
+	template := &SSnapshotPolicy{
+		RepeatWeekdays: SnapshotPolicyManager.RepeatWeekdaysParseIntArray([]int{1, 3}),
+		RetentionDays:  7,
+	}
+	cases := []struct {
+		in   *SSnapshotPolicy
+		want int
+	}{
+		{
+			in: &SSnapshotPolicy{
+				RepeatWeekdays: SnapshotPolicyManager.RepeatWeekdaysParseIntArray([]int{1, 3, 5}),
+				RetentionDays:  7,
+				IsActivated:    tristate.True,
+			},
+			want: 0,
+		},
+		{
+			in: &SSnapshotPolicy{
+				RepeatWeekdays: SnapshotPolicyManager.RepeatWeekdaysParseIntArray([]int{1, 3}),
+				RetentionDays:  -1,
+				IsActivated:    tristate.True,
+			},
+			want: 0,
+		},
+		{
+			in: &SSnapshotPolicy{
+				RepeatWeekdays: SnapshotPolicyManager.RepeatWeekdaysParseIntArray([]int{1}),
+				RetentionDays:  3,
+				IsActivated:    tristate.False,
+			},
+			want: 3,
+		},
+	}
+	for _, c := range cases {
+		issues := c.in.complianceIssues(template)
+		if len(issues) != c.want {
+			t.Fatalf("want %d issues, real: %v", c.want, issues)
+		}
+	}
+}
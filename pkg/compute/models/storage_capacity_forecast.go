@@ -0,0 +1,414 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	napi "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+const (
+	STORAGE_METADATA_CAPACITY_ALERT_AT = "__capacity_forecast_alert_at"
+)
+
+type SStorageCapacitySampleManager struct {
+	db.SResourceBaseManager
+}
+
+// 存储容量的历史采样, 容量单位均为MB
+type SStorageCapacitySample struct {
+	db.SResourceBase
+
+	RowId     int64     `primary:"true" auto_increment:"true"`
+	StorageId string    `width:"36" charset:"ascii" nullable:"false" index:"true"`
+	ZoneId    string    `width:"36" charset:"ascii" nullable:"true"`
+	SampledAt time.Time `nullable:"false" index:"true"`
+
+	// 扣除预留后的物理容量
+	Capacity int64 `nullable:"false" default:"0"`
+	// 超售后的容量
+	VirtualCapacity int64 `nullable:"false" default:"0"`
+	// 已分配容量
+	Used int64 `nullable:"false" default:"0"`
+	// 实际使用容量
+	ActualUsed int64 `nullable:"false" default:"0"`
+}
+
+var StorageCapacitySampleManager *SStorageCapacitySampleManager
+
+func init() {
+	StorageCapacitySampleManager = &SStorageCapacitySampleManager{
+		SResourceBaseManager: db.NewResourceBaseManager(
+			SStorageCapacitySample{},
+			"storage_capacity_samples_tbl",
+			"storage_capacity_sample",
+			"storage_capacity_samples",
+		),
+	}
+	StorageCapacitySampleManager.SetVirtualObject(StorageCapacitySampleManager)
+}
+
+func (manager *SStorageCapacitySampleManager) fetchSamples(storageIds []string, since time.Time) ([]SStorageCapacitySample, error) {
+	q := manager.Query().In("storage_id", storageIds).GE("sampled_at", since).Asc("sampled_at")
+	samples := []SStorageCapacitySample{}
+	err := db.FetchModelObjects(manager, q, &samples)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return samples, nil
+}
+
+func (manager *SStorageCapacitySampleManager) purgeSamples(before time.Time) error {
+	_, err := sqlchemy.GetDB().Exec(
+		fmt.Sprintf(
+			"delete from %s where sampled_at < ?",
+			manager.TableSpec().Name(),
+		), before,
+	)
+	return err
+}
+
+type sCapacityPoint struct {
+	at       time.Time
+	capacity int64
+	used     int64
+}
+
+// 对使用量做最小二乘线性拟合, 以最近一次采样为起点推算耗尽时间
+func forecastCapacityTrend(points []sCapacityPoint) api.StorageCapacityTrend {
+	trend := api.StorageCapacityTrend{}
+	if len(points) == 0 {
+		return trend
+	}
+	last := points[len(points)-1]
+	trend.Capacity = last.capacity
+	trend.Used = last.used
+	if last.capacity > 0 {
+		trend.UsedRate = float64(last.used) / float64(last.capacity)
+	}
+	if len(points) < 2 {
+		return trend
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	start := points[0].at
+	for _, p := range points {
+		x := p.at.Sub(start).Hours() / 24
+		y := float64(p.used)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return trend
+	}
+	trend.GrowthPerDay = (n*sumXY - sumX*sumY) / denominator
+	if trend.GrowthPerDay <= 0 || last.capacity <= 0 {
+		return trend
+	}
+	days := float64(last.capacity-last.used) / trend.GrowthPerDay
+	if days < 0 {
+		days = 0
+	}
+	// 超过百年的预测没有意义
+	if days > 36500 {
+		return trend
+	}
+	exhaustAt := last.at.Add(time.Duration(days * 24 * float64(time.Hour)))
+	trend.DaysToExhaust = &days
+	trend.ExhaustAt = &exhaustAt
+	return trend
+}
+
+func newStorageCapacityForecast(samples []SStorageCapacitySample) api.StorageCapacityForecast {
+	ret := api.StorageCapacityForecast{SampleCount: len(samples)}
+	if len(samples) == 0 {
+		return ret
+	}
+	ret.SampleSince = samples[0].SampledAt
+	allocated, actual := []sCapacityPoint{}, []sCapacityPoint{}
+	hasActual := false
+	for _, s := range samples {
+		allocated = append(allocated, sCapacityPoint{at: s.SampledAt, capacity: s.VirtualCapacity, used: s.Used})
+		actual = append(actual, sCapacityPoint{at: s.SampledAt, capacity: s.Capacity, used: s.ActualUsed})
+		if s.ActualUsed > 0 {
+			hasActual = true
+		}
+	}
+	ret.Allocated = forecastCapacityTrend(allocated)
+	ret.ExhaustAt = ret.Allocated.ExhaustAt
+	if hasActual {
+		trend := forecastCapacityTrend(actual)
+		ret.Actual = &trend
+		if trend.ExhaustAt != nil && (ret.ExhaustAt == nil || trend.ExhaustAt.Before(*ret.ExhaustAt)) {
+			ret.ExhaustAt = trend.ExhaustAt
+		}
+	}
+	return ret
+}
+
+// 将同一可用区内同一批次的采样合并
+func mergeZoneCapacitySamples(samples []SStorageCapacitySample) map[string][]SStorageCapacitySample {
+	merged := map[string]map[int64]*SStorageCapacitySample{}
+	for _, s := range samples {
+		if _, ok := merged[s.ZoneId]; !ok {
+			merged[s.ZoneId] = map[int64]*SStorageCapacitySample{}
+		}
+		key := s.SampledAt.Unix()
+		m, ok := merged[s.ZoneId][key]
+		if !ok {
+			m = &SStorageCapacitySample{ZoneId: s.ZoneId, SampledAt: s.SampledAt}
+			merged[s.ZoneId][key] = m
+		}
+		m.Capacity += s.Capacity
+		m.VirtualCapacity += s.VirtualCapacity
+		m.Used += s.Used
+		m.ActualUsed += s.ActualUsed
+	}
+	ret := map[string][]SStorageCapacitySample{}
+	for zoneId, batches := range merged {
+		for _, s := range batches {
+			ret[zoneId] = append(ret[zoneId], *s)
+		}
+		sort.Slice(ret[zoneId], func(i, j int) bool {
+			return ret[zoneId][i].SampledAt.Before(ret[zoneId][j].SampledAt)
+		})
+	}
+	return ret
+}
+
+func getStorageCapacitySampleSince(sampleDays int) time.Time {
+	if sampleDays <= 0 {
+		sampleDays = api.STORAGE_CAPACITY_FORECAST_DEFAULT_SAMPLE_DAYS
+	}
+	return time.Now().UTC().AddDate(0, 0, -sampleDays)
+}
+
+func (self *SStorage) getCapacityForecast(sampleDays int) (api.StorageCapacityForecast, error) {
+	samples, err := StorageCapacitySampleManager.fetchSamples([]string{self.Id}, getStorageCapacitySampleSince(sampleDays))
+	if err != nil {
+		return api.StorageCapacityForecast{}, errors.Wrap(err, "fetchSamples")
+	}
+	ret := newStorageCapacityForecast(samples)
+	ret.Id, ret.Name, ret.ZoneId = self.Id, self.Name, self.ZoneId
+	if zone, _ := self.getZone(); zone != nil {
+		ret.Zone = zone.Name
+	}
+	return ret, nil
+}
+
+// 获取存储的容量趋势预测
+func (self *SStorage) GetDetailsCapacityForecast(ctx context.Context, userCred mcclient.TokenCredential, query api.StorageCapacityForecastInput) (api.StorageCapacityForecast, error) {
+	ret, err := self.getCapacityForecast(query.SampleDays)
+	if err != nil {
+		return ret, httperrors.NewGeneralError(err)
+	}
+	return ret, nil
+}
+
+// 按存储或可用区获取容量趋势预测, 结果按预计耗尽时间升序排列
+func (manager *SStorageManager) GetPropertyCapacityForecast(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (*api.StorageCapacityForecastOutput, error) {
+	input := api.StorageCapacityForecastListInput{}
+	err := query.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal input: %v", err)
+	}
+	if len(input.GroupBy) == 0 {
+		input.GroupBy = api.STORAGE_CAPACITY_FORECAST_GROUP_BY_STORAGE
+	}
+	switch input.GroupBy {
+	case api.STORAGE_CAPACITY_FORECAST_GROUP_BY_STORAGE, api.STORAGE_CAPACITY_FORECAST_GROUP_BY_ZONE:
+	default:
+		return nil, httperrors.NewInputParameterError("invalid group_by %s", input.GroupBy)
+	}
+
+	q := manager.Query()
+	q, err = db.ListItemQueryFilters(manager, ctx, q, userCred, query, policy.PolicyActionList)
+	if err != nil {
+		return nil, err
+	}
+	storages := []SStorage{}
+	err = db.FetchModelObjects(manager, q, &storages)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "FetchModelObjects"))
+	}
+	ret := &api.StorageCapacityForecastOutput{GroupBy: input.GroupBy, Data: []api.StorageCapacityForecast{}}
+	if len(storages) == 0 {
+		return ret, nil
+	}
+
+	storageIds, zoneIds := []string{}, []string{}
+	storageMap := map[string]*SStorage{}
+	for i := range storages {
+		storageIds = append(storageIds, storages[i].Id)
+		zoneIds = append(zoneIds, storages[i].ZoneId)
+		storageMap[storages[i].Id] = &storages[i]
+	}
+	zones := map[string]SZone{}
+	err = db.FetchModelObjectsByIds(ZoneManager, "id", zoneIds, &zones)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "FetchModelObjectsByIds"))
+	}
+	samples, err := StorageCapacitySampleManager.fetchSamples(storageIds, getStorageCapacitySampleSince(input.SampleDays))
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+
+	switch input.GroupBy {
+	case api.STORAGE_CAPACITY_FORECAST_GROUP_BY_STORAGE:
+		grouped := map[string][]SStorageCapacitySample{}
+		for _, s := range samples {
+			grouped[s.StorageId] = append(grouped[s.StorageId], s)
+		}
+		for _, storageId := range storageIds {
+			forecast := newStorageCapacityForecast(grouped[storageId])
+			storage := storageMap[storageId]
+			forecast.Id, forecast.Name, forecast.ZoneId = storage.Id, storage.Name, storage.ZoneId
+			forecast.Zone = zones[storage.ZoneId].Name
+			ret.Data = append(ret.Data, forecast)
+		}
+	case api.STORAGE_CAPACITY_FORECAST_GROUP_BY_ZONE:
+		for zoneId, zoneSamples := range mergeZoneCapacitySamples(samples) {
+			forecast := newStorageCapacityForecast(zoneSamples)
+			forecast.Id, forecast.Name = zoneId, zones[zoneId].Name
+			forecast.ZoneId, forecast.Zone = zoneId, zones[zoneId].Name
+			ret.Data = append(ret.Data, forecast)
+		}
+	}
+
+	if input.ExhaustWithinDays > 0 {
+		deadline := time.Now().UTC().AddDate(0, 0, input.ExhaustWithinDays)
+		data := []api.StorageCapacityForecast{}
+		for _, forecast := range ret.Data {
+			if forecast.ExhaustAt != nil && forecast.ExhaustAt.Before(deadline) {
+				data = append(data, forecast)
+			}
+		}
+		ret.Data = data
+	}
+	sort.SliceStable(ret.Data, func(i, j int) bool {
+		ei, ej := ret.Data[i].ExhaustAt, ret.Data[j].ExhaustAt
+		if ei == nil || ej == nil {
+			return ej == nil && ei != nil
+		}
+		return ei.Before(*ej)
+	})
+	return ret, nil
+}
+
+func (manager *SStorageManager) getAllocatedCapacities() (map[string]int64, error) {
+	disks := DiskManager.Query().SubQuery()
+	q := disks.Query(disks.Field("storage_id"), sqlchemy.SUM("used", disks.Field("disk_size"))).GroupBy(disks.Field("storage_id"))
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "q.Rows")
+	}
+	defer rows.Close()
+	ret := map[string]int64{}
+	for rows.Next() {
+		var storageId string
+		var used sql.NullInt64
+		err = rows.Scan(&storageId, &used)
+		if err != nil {
+			return nil, errors.Wrap(err, "rows.Scan")
+		}
+		ret[storageId] = used.Int64
+	}
+	return ret, nil
+}
+
+// 定时采样各存储的容量, 并对预计即将耗尽的存储发出告警
+func (manager *SStorageManager) SampleCapacity(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	storages := []SStorage{}
+	q := manager.Query().GT("capacity", 0)
+	err := db.FetchModelObjects(manager, q, &storages)
+	if err != nil {
+		log.Errorf("fetch storages for capacity sample error: %v", err)
+		return
+	}
+	allocated, err := manager.getAllocatedCapacities()
+	if err != nil {
+		log.Errorf("getAllocatedCapacities error: %v", err)
+		return
+	}
+	now := time.Now().UTC()
+	for i := range storages {
+		storage := &storages[i]
+		sample := &SStorageCapacitySample{
+			StorageId:       storage.Id,
+			ZoneId:          storage.ZoneId,
+			SampledAt:       now,
+			Capacity:        storage.GetCapacity(),
+			VirtualCapacity: int64(float32(storage.GetCapacity()) * storage.GetOvercommitBound()),
+			Used:            allocated[storage.Id],
+			ActualUsed:      storage.ActualCapacityUsed,
+		}
+		sample.SetModelManager(StorageCapacitySampleManager, sample)
+		err = StorageCapacitySampleManager.TableSpec().Insert(ctx, sample)
+		if err != nil {
+			log.Errorf("insert capacity sample for storage %s error: %v", storage.Name, err)
+			continue
+		}
+		storage.checkCapacityForecast(ctx, userCred, now)
+	}
+
+	err = StorageCapacitySampleManager.purgeSamples(now.AddDate(0, 0, -options.Options.StorageCapacitySampleRetentionDays))
+	if err != nil {
+		log.Errorf("purge storage capacity samples error: %v", err)
+	}
+}
+
+func (self *SStorage) checkCapacityForecast(ctx context.Context, userCred mcclient.TokenCredential, now time.Time) {
+	alertDays := options.Options.StorageCapacityForecastAlertDays
+	if alertDays <= 0 {
+		return
+	}
+	forecast, err := self.getCapacityForecast(api.STORAGE_CAPACITY_FORECAST_DEFAULT_SAMPLE_DAYS)
+	if err != nil {
+		log.Errorf("get capacity forecast of storage %s error: %v", self.Name, err)
+		return
+	}
+	if forecast.ExhaustAt == nil || forecast.ExhaustAt.After(now.AddDate(0, 0, alertDays)) {
+		return
+	}
+	// 每天最多告警一次
+	if alertAt, err := time.Parse(time.RFC3339, self.GetMetadata(ctx, STORAGE_METADATA_CAPACITY_ALERT_AT, nil)); err == nil && now.Sub(alertAt) < 24*time.Hour {
+		return
+	}
+	data := jsonutils.Marshal(self).(*jsonutils.JSONDict)
+	data.Add(jsonutils.Marshal(forecast), "capacity_forecast")
+	data.Add(jsonutils.NewString(fmt.Sprintf("storage %s capacity is expected to be exhausted at %s", self.Name, forecast.ExhaustAt.Format(time.RFC3339))), "reason")
+	notifyclient.SystemExceptionNotify(ctx, napi.ActionSystemException, StorageManager.Keyword(), data)
+	self.SetMetadata(ctx, STORAGE_METADATA_CAPACITY_ALERT_AT, now.Format(time.RFC3339), userCred)
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestForecastCapacityTrend(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := func(capacity int64, used ...int64) []sCapacityPoint {
+		ret := []sCapacityPoint{}
+		for i := range used {
+			ret = append(ret, sCapacityPoint{at: start.AddDate(0, 0, i), capacity: capacity, used: used[i]})
+		}
+		return ret
+	}
+	cases := []struct {
+		name   string
+		points []sCapacityPoint
+		growth float64
+		days   float64
+	}{
+		{name: "linear growth", points: points(1000, 100, 200, 300, 400), growth: 100, days: 6},
+		{name: "flat", points: points(1000, 500, 500, 500), growth: 0, days: -1},
+		{name: "shrinking", points: points(1000, 500, 400, 300), growth: -100, days: -1},
+		{name: "single sample", points: points(1000, 500), growth: 0, days: -1},
+		{name: "already full", points: points(1000, 900, 1000, 1100), growth: 100, days: 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			trend := forecastCapacityTrend(c.points)
+			if math.Abs(trend.GrowthPerDay-c.growth) > 1e-6 {
+				t.Fatalf("growth want %f, got %f", c.growth, trend.GrowthPerDay)
+			}
+			if c.days < 0 {
+				if trend.DaysToExhaust != nil {
+					t.Fatalf("expect no exhaustion, got %f days", *trend.DaysToExhaust)
+				}
+				return
+			}
+			if trend.DaysToExhaust == nil || math.Abs(*trend.DaysToExhaust-c.days) > 1e-6 {
+				t.Fatalf("days to exhaust want %f, got %v", c.days, trend.DaysToExhaust)
+			}
+			last := c.points[len(c.points)-1].at
+			if !trend.ExhaustAt.Equal(last.Add(time.Duration(c.days * 24 * float64(time.Hour)))) {
+				t.Fatalf("unexpected exhaust at %s", trend.ExhaustAt)
+			}
+		})
+	}
+}
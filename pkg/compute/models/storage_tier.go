@@ -0,0 +1,168 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+var storageTierByType = map[string]string{}
+
+func init() {
+	tiers := map[string][]string{
+		api.STORAGE_TIER_NVME: {
+			api.STORAGE_IO1_SSD, api.STORAGE_IO2_SSD,
+			api.STORAGE_CLOUD_ESSD, api.STORAGE_CLOUD_ESSD_PL2, api.STORAGE_CLOUD_ESSD_PL3, api.STORAGE_LOCAL_SSD_PRO,
+			api.STORAGE_CLOUD_HSSD,
+			api.STORAGE_HUAWEI_ESSD,
+			api.STORAGE_GOOGLE_LOCAL_SSD,
+			api.STORAGE_JDCLOUD_IO1,
+			"ultrassd_lrs", "pd-extreme", "nvme",
+		},
+		api.STORAGE_TIER_SSD: {
+			api.STORAGE_GP2_SSD, api.STORAGE_GP3_SSD,
+			api.STORAGE_CLOUD_SSD, api.STORAGE_EPHEMERAL_SSD,
+			api.STORAGE_STANDARDSSD_LRS, api.STORAGE_PREMIUM_LRS,
+			api.STORAGE_LOCAL_SSD, api.STORAGE_CLOUD_PREMIUM,
+			api.STORAGE_HUAWEI_SSD, api.STORAGE_HUAWEI_GPSSD,
+			api.STORAGE_UCLOUD_CLOUD_SSD, api.STORAGE_UCLOUD_LOCAL_SSD,
+			api.STORAGE_GOOGLE_PD_SSD, api.STORAGE_GOOGLE_PD_BALANCED,
+			api.STORAGE_JDCLOUD_GP1, api.STORAGE_JDCLOUD_SSD,
+			api.STORAGE_ECLOUD_SSD, api.STORAGE_ECLOUD_SSDEBS,
+		},
+		api.STORAGE_TIER_HDD: {
+			api.STORAGE_ST1_HDD, api.STORAGE_SC1_HDD, api.STORAGE_STANDARD_HDD,
+			api.STORAGE_CLOUD_EFFICIENCY, api.STORAGE_LOCAL_HDD_PRO,
+			api.STORAGE_STANDARD_LRS,
+			api.STORAGE_LOCAL_BASIC, api.STORAGE_LOCAL_PRO, api.STORAGE_CLOUD_BASIC,
+			api.STORAGE_HUAWEI_SAS, api.STORAGE_HUAWEI_SATA,
+			api.STORAGE_UCLOUD_CLOUD_NORMAL, api.STORAGE_UCLOUD_LOCAL_NORMAL,
+			api.STORAGE_GOOGLE_PD_STANDARD,
+			api.STORAGE_JDCLOUD_STD, api.STORAGE_JDCLOUD_PHD,
+			api.STORAGE_ECLOUD_CAPEBS, api.STORAGE_ECLOUD_EBS,
+		},
+	}
+	for tier, storageTypes := range tiers {
+		for _, storageType := range storageTypes {
+			storageTierByType[strings.ToLower(storageType)] = tier
+		}
+	}
+}
+
+// 根据存储类型识别性能分级, 无法识别时按介质类型判断
+func classifyStorageTier(storageType, mediumType string) string {
+	if tier, ok := storageTierByType[strings.ToLower(storageType)]; ok {
+		return tier
+	}
+	if mediumType == api.DISK_TYPE_SSD {
+		return api.STORAGE_TIER_SSD
+	}
+	return api.STORAGE_TIER_HDD
+}
+
+func storageTierRank(tier string) int {
+	for i, t := range api.STORAGE_TIERS {
+		if t == tier {
+			return i
+		}
+	}
+	return -1
+}
+
+// 存储分级是否满足要求, 高分级可以满足低分级的要求
+func isStorageTierSatisfied(required, actual string) bool {
+	if len(required) == 0 {
+		return true
+	}
+	if len(actual) == 0 {
+		return false
+	}
+	return storageTierRank(actual) >= storageTierRank(required)
+}
+
+func storageTiersSatisfied(required string) []string {
+	tiers := []string{}
+	for _, tier := range api.STORAGE_TIERS {
+		if isStorageTierSatisfied(required, tier) {
+			tiers = append(tiers, tier)
+		}
+	}
+	return tiers
+}
+
+func validateStorageTier(tier string) error {
+	if len(tier) > 0 && !utils.IsInStringArray(tier, api.STORAGE_TIERS) {
+		return httperrors.NewInputParameterError("invalid storage tier %s, must be one of %s", tier, api.STORAGE_TIERS)
+	}
+	return nil
+}
+
+// 按存储分级选择存储类型, 优先选择满足要求的最低分级
+func (manager *SStorageManager) getStorageTypeByTier(tier string, storageTypes []string, zoneId, regionId string) (*SStorage, error) {
+	q := manager.Query().IsTrue("enabled").
+		In("status", []string{api.STORAGE_ENABLED, api.STORAGE_ONLINE}).
+		In("tier", storageTiersSatisfied(tier))
+	if len(storageTypes) > 0 {
+		q = q.In("storage_type", storageTypes)
+	} else {
+		q = q.IsNullOrEmpty("manager_id")
+	}
+	if len(zoneId) > 0 {
+		q = q.Equals("zone_id", zoneId)
+	} else if len(regionId) > 0 {
+		zones := ZoneManager.Query("id").Equals("cloudregion_id", regionId).SubQuery()
+		q = q.Filter(sqlchemy.In(q.Field("zone_id"), zones))
+	}
+	storages := []SStorage{}
+	err := db.FetchModelObjects(manager, q, &storages)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	if len(storages) == 0 {
+		return nil, httperrors.NewResourceNotFoundError("no available storage satisfy tier %s", tier)
+	}
+	sort.SliceStable(storages, func(i, j int) bool {
+		ri, rj := storageTierRank(storages[i].Tier), storageTierRank(storages[j].Tier)
+		if ri != rj {
+			return ri < rj
+		}
+		return storages[i].StorageType < storages[j].StorageType
+	})
+	return &storages[0], nil
+}
+
+// 根据磁盘的存储分级要求补全存储类型和介质类型
+func fillDiskConfigByStorageTier(diskConfig *api.DiskConfig, storageTypes []string, zoneId, regionId string) error {
+	if len(diskConfig.StorageTier) == 0 || len(diskConfig.Backend) > 0 {
+		return nil
+	}
+	storage, err := StorageManager.getStorageTypeByTier(diskConfig.StorageTier, storageTypes, zoneId, regionId)
+	if err != nil {
+		return err
+	}
+	diskConfig.Backend = storage.StorageType
+	if len(diskConfig.Medium) == 0 {
+		diskConfig.Medium = storage.MediumType
+	}
+	return nil
+}
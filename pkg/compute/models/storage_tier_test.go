@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestClassifyStorageTier(t *testing.T) {
+	cases := []struct {
+		storageType string
+		mediumType  string
+		want        string
+	}{
+		{api.STORAGE_IO2_SSD, api.DISK_TYPE_SSD, api.STORAGE_TIER_NVME},
+		{api.STORAGE_CLOUD_ESSD, api.DISK_TYPE_SSD, api.STORAGE_TIER_NVME},
+		{"UltraSSD_LRS", api.DISK_TYPE_SSD, api.STORAGE_TIER_NVME},
+		{api.STORAGE_GP3_SSD, api.DISK_TYPE_SSD, api.STORAGE_TIER_SSD},
+		{api.STORAGE_HUAWEI_SSD, api.DISK_TYPE_SSD, api.STORAGE_TIER_SSD},
+		{api.STORAGE_GOOGLE_PD_BALANCED, api.DISK_TYPE_SSD, api.STORAGE_TIER_SSD},
+		{api.STORAGE_ST1_HDD, api.DISK_TYPE_ROTATE, api.STORAGE_TIER_HDD},
+		{api.STORAGE_HUAWEI_SATA, api.DISK_TYPE_ROTATE, api.STORAGE_TIER_HDD},
+		{api.STORAGE_LOCAL, api.DISK_TYPE_SSD, api.STORAGE_TIER_SSD},
+		{api.STORAGE_RBD, api.DISK_TYPE_ROTATE, api.STORAGE_TIER_HDD},
+		{api.STORAGE_NFS, api.DISK_TYPE_HYBRID, api.STORAGE_TIER_HDD},
+	}
+	for _, c := range cases {
+		if got := classifyStorageTier(c.storageType, c.mediumType); got != c.want {
+			t.Errorf("classifyStorageTier(%s, %s) = %s, want %s", c.storageType, c.mediumType, got, c.want)
+		}
+	}
+}
+
+func TestIsStorageTierSatisfied(t *testing.T) {
+	cases := []struct {
+		required string
+		actual   string
+		want     bool
+	}{
+		{"", "", true},
+		{"", api.STORAGE_TIER_HDD, true},
+		{api.STORAGE_TIER_SSD, "", false},
+		{api.STORAGE_TIER_SSD, api.STORAGE_TIER_HDD, false},
+		{api.STORAGE_TIER_SSD, api.STORAGE_TIER_SSD, true},
+		{api.STORAGE_TIER_SSD, api.STORAGE_TIER_NVME, true},
+		{api.STORAGE_TIER_NVME, api.STORAGE_TIER_SSD, false},
+		{api.STORAGE_TIER_HDD, api.STORAGE_TIER_NVME, true},
+	}
+	for _, c := range cases {
+		if got := isStorageTierSatisfied(c.required, c.actual); got != c.want {
+			t.Errorf("isStorageTierSatisfied(%q, %q) = %v, want %v", c.required, c.actual, got, c.want)
+		}
+	}
+}
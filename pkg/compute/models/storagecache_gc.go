@@ -0,0 +1,286 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// 参与回收评估的缓存镜像
+type sStoragecacheGcImage struct {
+	CachedimageId string
+	Name          string
+	Size          int64
+	LastUsedAt    time.Time
+	// 仍被磁盘或光驱引用的镜像不可回收
+	InUse bool
+}
+
+type sStoragecacheGcPolicy struct {
+	KeepLastN  int
+	UnusedDays int
+	MaxSizeMb  int64
+}
+
+// 按回收策略选出待回收的镜像:
+// 最近使用的 KeepLastN 个及仍在使用的镜像始终保留;
+// 其余超过 UnusedDays 未使用的镜像回收;
+// 剩余总大小仍超过 MaxSizeMb 时, 按最近最少使用继续回收
+func selectStoragecacheGcCandidates(images []sStoragecacheGcImage, policy sStoragecacheGcPolicy, now time.Time) []api.StoragecacheGcCandidate {
+	sorted := make([]sStoragecacheGcImage, len(images))
+	copy(sorted, images)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LastUsedAt.After(sorted[j].LastUsedAt)
+	})
+
+	reasons := make([]string, len(sorted))
+	var total int64 = 0
+	for i := range sorted {
+		protected := sorted[i].InUse || i < policy.KeepLastN
+		if !protected && policy.UnusedDays > 0 && now.Sub(sorted[i].LastUsedAt) > time.Duration(policy.UnusedDays)*24*time.Hour {
+			reasons[i] = api.STORAGECACHE_GC_REASON_UNUSED
+			continue
+		}
+		total += sorted[i].Size
+	}
+	if policy.MaxSizeMb > 0 {
+		maxSize := policy.MaxSizeMb * 1024 * 1024
+		for i := len(sorted) - 1; i >= 0 && total > maxSize; i-- {
+			if sorted[i].InUse || i < policy.KeepLastN || len(reasons[i]) > 0 {
+				continue
+			}
+			reasons[i] = api.STORAGECACHE_GC_REASON_EXCEED_SIZE
+			total -= sorted[i].Size
+		}
+	}
+
+	ret := []api.StoragecacheGcCandidate{}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if len(reasons[i]) == 0 {
+			continue
+		}
+		ret = append(ret, api.StoragecacheGcCandidate{
+			CachedimageId: sorted[i].CachedimageId,
+			Name:          sorted[i].Name,
+			Size:          sorted[i].Size,
+			LastUsedAt:    sorted[i].LastUsedAt,
+			Reason:        reasons[i],
+		})
+	}
+	return ret
+}
+
+func (self *SStoragecache) getGcPolicy() sStoragecacheGcPolicy {
+	return sStoragecacheGcPolicy{
+		KeepLastN:  self.GcKeepLastN,
+		UnusedDays: self.GcUnusedDays,
+		MaxSizeMb:  self.GcMaxSizeMb,
+	}
+}
+
+// 仅自定义镜像参与回收, 公有云系统镜像不可删除
+func (self *SStoragecache) getGcImages() ([]sStoragecacheGcImage, error) {
+	scimgs, err := self.getCustomdCachedImages()
+	if err != nil {
+		return nil, errors.Wrap(err, "getCustomdCachedImages")
+	}
+	ret := []sStoragecacheGcImage{}
+	for i := range scimgs {
+		if scimgs[i].Status != api.CACHED_IMAGE_STATUS_ACTIVE {
+			continue
+		}
+		image := scimgs[i].GetCachedimage()
+		if image == nil {
+			continue
+		}
+		cnt, err := scimgs[i].getReferenceCount()
+		if err != nil {
+			return nil, errors.Wrapf(err, "getReferenceCount of %s", image.Name)
+		}
+		lastUsed := scimgs[i].CreatedAt
+		for _, t := range []time.Time{image.LastRef, scimgs[i].LastDownload} {
+			if t.After(lastUsed) {
+				lastUsed = t
+			}
+		}
+		ret = append(ret, sStoragecacheGcImage{
+			CachedimageId: image.Id,
+			Name:          image.Name,
+			Size:          image.Size,
+			LastUsedAt:    lastUsed,
+			InUse:         cnt > 0 || !scimgs[i].isDownloadSessionExpire(),
+		})
+	}
+	return ret, nil
+}
+
+func (self *SStoragecache) GetGcReport(dryRun bool) (*api.StoragecacheGcReport, error) {
+	images, err := self.getGcImages()
+	if err != nil {
+		return nil, err
+	}
+	ret := &api.StoragecacheGcReport{
+		StoragecacheId: self.Id,
+		DryRun:         dryRun,
+		Candidates:     selectStoragecacheGcCandidates(images, self.getGcPolicy(), time.Now()),
+	}
+	for i := range images {
+		ret.TotalSize += images[i].Size
+	}
+	for i := range ret.Candidates {
+		ret.ReclaimSize += ret.Candidates[i].Size
+	}
+	return ret, nil
+}
+
+// 设置缓存镜像回收策略
+func (self *SStoragecache) PerformSetGcPolicy(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.StoragecacheSetGcPolicyInput) (jsonutils.JSONObject, error) {
+	if input.GcKeepLastN != nil && *input.GcKeepLastN < 0 {
+		return nil, httperrors.NewInputParameterError("gc_keep_last_n must not be negative")
+	}
+	if input.GcUnusedDays != nil && *input.GcUnusedDays < 0 {
+		return nil, httperrors.NewInputParameterError("gc_unused_days must not be negative")
+	}
+	if input.GcMaxSizeMb != nil && *input.GcMaxSizeMb < 0 {
+		return nil, httperrors.NewInputParameterError("gc_max_size_mb must not be negative")
+	}
+	enabled := self.GcEnabled.IsTrue()
+	if input.GcEnabled != nil {
+		enabled = *input.GcEnabled
+	}
+	unusedDays, maxSizeMb := self.GcUnusedDays, self.GcMaxSizeMb
+	if input.GcUnusedDays != nil {
+		unusedDays = *input.GcUnusedDays
+	}
+	if input.GcMaxSizeMb != nil {
+		maxSizeMb = *input.GcMaxSizeMb
+	}
+	if enabled && unusedDays == 0 && maxSizeMb == 0 {
+		return nil, httperrors.NewInputParameterError("gc_unused_days or gc_max_size_mb is required when gc is enabled")
+	}
+	diff, err := db.Update(self, func() error {
+		if input.GcEnabled != nil {
+			self.GcEnabled = tristate.NewFromBool(*input.GcEnabled)
+		}
+		if input.GcKeepLastN != nil {
+			self.GcKeepLastN = *input.GcKeepLastN
+		}
+		if input.GcUnusedDays != nil {
+			self.GcUnusedDays = *input.GcUnusedDays
+		}
+		if input.GcMaxSizeMb != nil {
+			self.GcMaxSizeMb = *input.GcMaxSizeMb
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Update")
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, diff, userCred)
+	logclient.AddSimpleActionLog(self, logclient.ACT_SET_GC_POLICY, diff, userCred, true)
+	return nil, nil
+}
+
+// 按回收策略回收缓存镜像, dry_run 时仅返回待回收列表
+func (self *SStoragecache) PerformGc(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.StoragecacheGcInput) (*api.StoragecacheGcReport, error) {
+	policy := self.getGcPolicy()
+	if policy.UnusedDays == 0 && policy.MaxSizeMb == 0 {
+		return nil, httperrors.NewInvalidStatusError("storagecache %s has no gc policy", self.Name)
+	}
+	report, err := self.GetGcReport(input.DryRun)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if input.DryRun || len(report.Candidates) == 0 {
+		return report, nil
+	}
+	err = self.StartGcTask(ctx, userCred, report)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return report, nil
+}
+
+func (self *SStoragecache) StartGcTask(ctx context.Context, userCred mcclient.TokenCredential, report *api.StoragecacheGcReport) error {
+	imageIds := []string{}
+	for _, candidate := range report.Candidates {
+		imageIds = append(imageIds, candidate.CachedimageId)
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewStringArray(imageIds), "image_ids")
+	params.Add(jsonutils.Marshal(report), "report")
+	task, err := taskman.TaskManager.NewTask(ctx, "StoragecacheGcTask", self, userCred, params, "", "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	return task.ScheduleRun(nil)
+}
+
+// 定时按回收策略清理启用了回收的存储缓存
+func (manager *SStoragecacheManager) AutoGcCachedImages(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	caches := []SStoragecache{}
+	q := manager.Query().IsTrue("gc_enabled")
+	err := db.FetchModelObjects(manager, q, &caches)
+	if err != nil {
+		log.Errorf("fetch gc enabled storagecaches error: %v", err)
+		return
+	}
+	for i := range caches {
+		cache := &caches[i]
+		if cache.GcUnusedDays == 0 && cache.GcMaxSizeMb == 0 {
+			continue
+		}
+		report, err := cache.GetGcReport(false)
+		if err != nil {
+			log.Errorf("GetGcReport for storagecache %s error: %v", cache.Name, err)
+			continue
+		}
+		if len(report.Candidates) == 0 {
+			continue
+		}
+		err = cache.StartGcTask(ctx, userCred, report)
+		if err != nil {
+			log.Errorf("StartGcTask for storagecache %s error: %v", cache.Name, err)
+		}
+	}
+}
+
+// 回收单个缓存镜像, 镜像在回收期间被重新引用时跳过
+func (self *SStoragecache) MarkGcImageDeleting(ctx context.Context, userCred mcclient.TokenCredential, imageId string) (*SStoragecachedimage, error) {
+	scimg := StoragecachedimageManager.GetStoragecachedimage(self.Id, imageId)
+	if scimg == nil {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "cached image %s", imageId)
+	}
+	err := scimg.markDeleting(ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "markDeleting")
+	}
+	return scimg, nil
+}
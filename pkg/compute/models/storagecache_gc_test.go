@@ -0,0 +1,80 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestSelectStoragecacheGcCandidates(t *testing.T) {
+	now := time.Now()
+	mb := int64(1024 * 1024)
+	images := []sStoragecacheGcImage{
+		{CachedimageId: "a", Size: 100 * mb, LastUsedAt: now.Add(-1 * time.Hour)},
+		{CachedimageId: "b", Size: 100 * mb, LastUsedAt: now.Add(-48 * time.Hour)},
+		{CachedimageId: "c", Size: 100 * mb, LastUsedAt: now.Add(-10 * 24 * time.Hour), InUse: true},
+		{CachedimageId: "d", Size: 100 * mb, LastUsedAt: now.Add(-20 * 24 * time.Hour)},
+		{CachedimageId: "e", Size: 100 * mb, LastUsedAt: now.Add(-30 * 24 * time.Hour)},
+	}
+	cases := []struct {
+		name   string
+		policy sStoragecacheGcPolicy
+		want   map[string]string
+	}{
+		{
+			name:   "unused",
+			policy: sStoragecacheGcPolicy{UnusedDays: 7},
+			want:   map[string]string{"d": api.STORAGECACHE_GC_REASON_UNUSED, "e": api.STORAGECACHE_GC_REASON_UNUSED},
+		},
+		{
+			name:   "keep last n",
+			policy: sStoragecacheGcPolicy{KeepLastN: 4, UnusedDays: 1},
+			want:   map[string]string{"e": api.STORAGECACHE_GC_REASON_UNUSED},
+		},
+		{
+			name:   "max size",
+			policy: sStoragecacheGcPolicy{MaxSizeMb: 250},
+			want:   map[string]string{"e": api.STORAGECACHE_GC_REASON_EXCEED_SIZE, "d": api.STORAGECACHE_GC_REASON_EXCEED_SIZE, "b": api.STORAGECACHE_GC_REASON_EXCEED_SIZE},
+		},
+		{
+			name:   "unused and max size",
+			policy: sStoragecacheGcPolicy{KeepLastN: 1, UnusedDays: 25, MaxSizeMb: 250},
+			want:   map[string]string{"e": api.STORAGECACHE_GC_REASON_UNUSED, "d": api.STORAGECACHE_GC_REASON_EXCEED_SIZE, "b": api.STORAGECACHE_GC_REASON_EXCEED_SIZE},
+		},
+		{
+			name:   "no policy",
+			policy: sStoragecacheGcPolicy{},
+			want:   map[string]string{},
+		},
+	}
+	for _, c := range cases {
+		got := selectStoragecacheGcCandidates(images, c.policy, now)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: want %d candidates, got %v", c.name, len(c.want), got)
+			continue
+		}
+		for i, candidate := range got {
+			if c.want[candidate.CachedimageId] != candidate.Reason {
+				t.Errorf("%s: unexpected candidate %s reason %s", c.name, candidate.CachedimageId, candidate.Reason)
+			}
+			if i > 0 && candidate.LastUsedAt.Before(got[i-1].LastUsedAt) {
+				t.Errorf("%s: candidates should be ordered by least recently used", c.name)
+			}
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/serialx/hashring"
 
@@ -26,6 +27,7 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
 	"yunion.io/x/pkg/util/compare"
 	"yunion.io/x/pkg/util/imagetools"
 	"yunion.io/x/sqlchemy"
@@ -67,6 +69,17 @@ type SStoragecache struct {
 
 	// 镜像存储地址
 	Path string `width:"256" charset:"utf8" nullable:"true" list:"user" update:"admin" create:"admin_optional"` // = Column(VARCHAR(256, charset='utf8'), nullable=True)
+
+	// 是否启用缓存镜像定时回收
+	GcEnabled tristate.TriState `default:"false" list:"user"`
+	// 始终保留最近使用的镜像个数
+	GcKeepLastN int `nullable:"false" default:"0" list:"user"`
+	// 超过指定天数未使用的镜像可被回收
+	GcUnusedDays int `nullable:"false" default:"0" list:"user"`
+	// 缓存镜像总大小上限, 单位MB
+	GcMaxSizeMb int64 `nullable:"false" default:"0" list:"user"`
+	// 上次回收时间
+	GcLastRunAt time.Time `nullable:"true" list:"user"`
 }
 
 func (self *SStoragecache) getStorages() []SStorage {
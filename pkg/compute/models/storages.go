@@ -86,6 +86,9 @@ type SStorage struct {
 	// 介质类型
 	// example: ssd
 	MediumType string `width:"32" charset:"ascii" nullable:"false" list:"user" update:"domain" create:"domain_required"`
+	// 性能分级
+	// example: ssd
+	Tier string `width:"16" charset:"ascii" nullable:"true" list:"user" update:"domain" create:"domain_optional"`
 	// 超售比
 	Cmtbound float32 `nullable:"true" default:"1" list:"domain" update:"domain"`
 	// 存储配置信息
@@ -113,6 +116,9 @@ func (self *SStorage) ValidateUpdateData(ctx context.Context, userCred mcclient.
 	if err != nil {
 		return input, err
 	}
+	if err := validateStorageTier(input.Tier); err != nil {
+		return input, err
+	}
 	input.StorageConf = jsonutils.NewDict()
 	if self.StorageConf != nil {
 		input.StorageConf.Update(jsonutils.Marshal(self.StorageConf))
@@ -217,6 +223,12 @@ func (manager *SStorageManager) ValidateCreateData(
 	if !utils.IsInStringArray(input.MediumType, api.DISK_TYPES) {
 		return input, httperrors.NewInputParameterError("Invalid medium type %s", input.MediumType)
 	}
+	if len(input.Tier) == 0 {
+		input.Tier = classifyStorageTier(input.StorageType, input.MediumType)
+	}
+	if err := validateStorageTier(input.Tier); err != nil {
+		return input, err
+	}
 	if len(input.ZoneId) == 0 {
 		return input, httperrors.NewMissingParameterError("zone_id")
 	}
@@ -874,6 +886,7 @@ func (self *SStorage) syncWithCloudStorage(ctx context.Context, userCred mcclien
 		self.Status = extStorage.GetStatus()
 		self.StorageType = extStorage.GetStorageType()
 		self.MediumType = extStorage.GetMediumType()
+		self.Tier = classifyStorageTier(self.StorageType, self.MediumType)
 		if capacity := extStorage.GetCapacityMB(); capacity != 0 {
 			self.Capacity = capacity
 		}
@@ -913,6 +926,7 @@ func (manager *SStorageManager) newFromCloudStorage(ctx context.Context, userCre
 	storage.ZoneId = zone.Id
 	storage.StorageType = extStorage.GetStorageType()
 	storage.MediumType = extStorage.GetMediumType()
+	storage.Tier = classifyStorageTier(storage.StorageType, storage.MediumType)
 	storage.StorageConf = extStorage.GetStorageConf()
 	storage.Capacity = extStorage.GetCapacityMB()
 	storage.ActualCapacityUsed = extStorage.GetCapacityUsedMB()
@@ -943,6 +957,9 @@ func (manager *SStorageManager) newFromCloudStorage(ctx context.Context, userCre
 
 	SyncCloudDomain(userCred, &storage, provider.GetOwnerId())
 
+	extTags, _ := extStorage.GetTags()
+	SchedtagRuleManager.attachSyncedResource(ctx, userCred, &storage, provider, zone.CloudregionId, zone.Id, extTags)
+
 	if provider != nil {
 		storage.SyncShareState(ctx, userCred, provider.getAccountShareInfo())
 	}
@@ -1389,6 +1406,12 @@ func (manager *SStorageManager) InitializeData() error {
 		return err
 	}
 	for _, s := range storages {
+		if len(s.Tier) == 0 {
+			db.Update(&s, func() error {
+				s.Tier = classifyStorageTier(s.StorageType, s.MediumType)
+				return nil
+			})
+		}
 		if len(s.ZoneId) == 0 {
 			zoneId := ""
 			hosts, _ := s.GetAttachedHosts()
@@ -1567,6 +1590,10 @@ func (manager *SStorageManager) ListItemFilter(
 		q = qf("storage_type", api.STORAGE_BAREMETAL)
 	}
 
+	if len(query.Tier) > 0 {
+		q = q.In("tier", query.Tier)
+	}
+
 	return q, err
 }
 
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"runtime/debug"
 	"strconv"
+	"sync"
 
 	"github.com/serialx/hashring"
 
@@ -28,27 +29,51 @@ import (
 	api "yunion.io/x/onecloud/pkg/apis/notify"
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/compute/options"
 )
 
 var (
 	syncAccountWorker *appsrv.SWorkerManager
-	syncWorkers       []*appsrv.SWorkerManager
-	syncWorkerRing    *hashring.HashRing
+	syncWorkers       *sSyncWorkerPool
+
+	// 按云平台类型隔离的同步队列, 避免某一平台同步缓慢时阻塞其他平台
+	vendorSyncWorkers     = map[string]*sSyncWorkerPool{}
+	vendorSyncWorkersLock = &sync.Mutex{}
 )
 
-func InitSyncWorkers(count int) {
-	syncWorkers = make([]*appsrv.SWorkerManager, count)
-	syncWorkerIndexes := make([]string, count)
-	for i := range syncWorkers {
-		syncWorkers[i] = appsrv.NewWorkerManager(
-			fmt.Sprintf("syncWorkerManager-%d", i+1),
+type sSyncWorkerPool struct {
+	workers []*appsrv.SWorkerManager
+	ring    *hashring.HashRing
+}
+
+// 每个worker串行执行, 相同key的同步任务总是分配到同一worker
+func newSyncWorkerPool(name string, count int, backlog int) *sSyncWorkerPool {
+	pool := &sSyncWorkerPool{
+		workers: make([]*appsrv.SWorkerManager, count),
+	}
+	indexes := make([]string, count)
+	for i := range pool.workers {
+		pool.workers[i] = appsrv.NewWorkerManager(
+			fmt.Sprintf("%s-%d", name, i+1),
 			1,
-			2048,
+			backlog,
 			true,
 		)
-		syncWorkerIndexes[i] = strconv.Itoa(i)
+		indexes[i] = strconv.Itoa(i)
 	}
-	syncWorkerRing = hashring.New(syncWorkerIndexes)
+	pool.ring = hashring.New(indexes)
+	return pool
+}
+
+func (pool *sSyncWorkerPool) getWorker(key string) *appsrv.SWorkerManager {
+	nodeIdxStr, _ := pool.ring.GetNode(key)
+	nodeIdx, _ := strconv.Atoi(nodeIdxStr)
+	log.Debugf("run sync task at %d len %d", nodeIdx, len(pool.workers))
+	return pool.workers[nodeIdx]
+}
+
+func InitSyncWorkers(count int) {
+	syncWorkers = newSyncWorkerPool("syncWorkerManager", count, 2048)
 	syncAccountWorker = appsrv.NewWorkerManager(
 		"cloudAccountProbeWorkerManager",
 		1,
@@ -57,6 +82,27 @@ func InitSyncWorkers(count int) {
 	)
 }
 
+func getSyncWorkerPool(providerType string) *sSyncWorkerPool {
+	count := options.Options.CloudSyncVendorWorkerCount
+	if count <= 0 || len(providerType) == 0 {
+		return syncWorkers
+	}
+
+	vendorSyncWorkersLock.Lock()
+	defer vendorSyncWorkersLock.Unlock()
+
+	pool, ok := vendorSyncWorkers[providerType]
+	if !ok {
+		backlog := options.Options.CloudSyncVendorQueueSize / count
+		if backlog <= 0 {
+			backlog = 1
+		}
+		pool = newSyncWorkerPool(fmt.Sprintf("syncWorkerManager-%s", providerType), count, backlog)
+		vendorSyncWorkers[providerType] = pool
+	}
+	return pool
+}
+
 type resSyncTask struct {
 	syncFunc func()
 	key      string
@@ -70,22 +116,21 @@ func (t *resSyncTask) Dump() string {
 	return fmt.Sprintf("key: %s", t.key)
 }
 
-func RunSyncCloudproviderRegionTask(ctx context.Context, key string, syncFunc func()) {
-	nodeIdxStr, _ := syncWorkerRing.GetNode(key)
-	nodeIdx, _ := strconv.Atoi(nodeIdxStr)
+// 队列已满时返回false, 由调用方等待下次调度, 不转入其他平台或全局队列, 以免缓慢的平台占满共享队列
+func RunSyncCloudproviderRegionTask(ctx context.Context, providerType string, key string, syncFunc func()) bool {
 	task := resSyncTask{
 		syncFunc: syncFunc,
 		key:      key,
 	}
-	log.Debugf("run sync task at %d len %d", nodeIdx, len(syncWorkers))
-	syncWorkers[nodeIdx].Run(&task, nil, func(err error) {
+	onErr := func(err error) {
 		data := jsonutils.NewDict()
 		data.Add(jsonutils.NewString("SyncCloudproviderRegion"), "task_name")
 		data.Add(jsonutils.NewString(key), "task_id")
 		data.Add(jsonutils.NewString(string(debug.Stack())), "stack")
 		data.Add(jsonutils.NewString(err.Error()), "error")
 		notifyclient.SystemExceptionNotify(context.TODO(), api.ActionSystemPanic, api.TOPIC_RESOURCE_TASK, data)
-	})
+	}
+	return getSyncWorkerPool(providerType).getWorker(key).Run(&task, nil, onErr)
 }
 
 func RunSyncCloudAccountTask(ctx context.Context, probeFunc func()) {
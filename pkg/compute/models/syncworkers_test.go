@@ -0,0 +1,85 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/compute/options"
+)
+
+func TestGetSyncWorkerPool(t *testing.T) {
+	InitSyncWorkers(2)
+	count := options.Options.CloudSyncVendorWorkerCount
+	defer func() {
+		options.Options.CloudSyncVendorWorkerCount = count
+	}()
+
+	options.Options.CloudSyncVendorWorkerCount = 0
+	if getSyncWorkerPool("VMware") != syncWorkers {
+		t.Errorf("want global pool when vendor workers disabled")
+	}
+
+	options.Options.CloudSyncVendorWorkerCount = 2
+	options.Options.CloudSyncVendorQueueSize = 10
+	vmware := getSyncWorkerPool("VMware")
+	if vmware == syncWorkers || len(vmware.workers) != 2 {
+		t.Fatalf("want dedicated pool with 2 workers")
+	}
+	if getSyncWorkerPool("VMware") != vmware {
+		t.Errorf("want same pool for same provider type")
+	}
+	if getSyncWorkerPool("Aliyun") == vmware {
+		t.Errorf("want isolated pool for different provider type")
+	}
+	if vmware.getWorker("1") != vmware.getWorker("1") {
+		t.Errorf("want same worker for same key")
+	}
+}
+
+func TestRunSyncCloudproviderRegionTaskQueueFull(t *testing.T) {
+	InitSyncWorkers(2)
+	count, size := options.Options.CloudSyncVendorWorkerCount, options.Options.CloudSyncVendorQueueSize
+	defer func() {
+		options.Options.CloudSyncVendorWorkerCount = count
+		options.Options.CloudSyncVendorQueueSize = size
+	}()
+	options.Options.CloudSyncVendorWorkerCount = 1
+	options.Options.CloudSyncVendorQueueSize = 1
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	defer close(release)
+	block := func() {
+		started <- struct{}{}
+		<-release
+	}
+	if !RunSyncCloudproviderRegionTask(context.Background(), "SlowVendor", "1", block) {
+		t.Fatalf("want running task accepted")
+	}
+	<-started
+	if !RunSyncCloudproviderRegionTask(context.Background(), "SlowVendor", "2", block) {
+		t.Fatalf("want queued task accepted")
+	}
+	if RunSyncCloudproviderRegionTask(context.Background(), "SlowVendor", "3", block) {
+		t.Fatalf("want task rejected when vendor queue is full")
+	}
+	for i := range syncWorkers.workers {
+		if cnt := syncWorkers.workers[i].ActiveWorkerCount(); cnt != 0 {
+			t.Errorf("want global sync workers untouched, worker %d has %d active", i, cnt)
+		}
+	}
+}
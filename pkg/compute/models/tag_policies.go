@@ -0,0 +1,397 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type STagPolicyManager struct {
+	db.SEnabledStatusDomainLevelResourceBaseManager
+}
+
+var TagPolicyManager *STagPolicyManager
+
+func init() {
+	TagPolicyManager = &STagPolicyManager{
+		SEnabledStatusDomainLevelResourceBaseManager: db.NewEnabledStatusDomainLevelResourceBaseManager(
+			STagPolicy{},
+			"tag_policies_tbl",
+			"tag_policy",
+			"tag_policies",
+		),
+	}
+	TagPolicyManager.SetVirtualObject(TagPolicyManager)
+}
+
+// 域内资源必需标签策略, 创建时缺失标签将被拒绝, 同步的资源缺失标签时被标记为不合规
+type STagPolicy struct {
+	db.SEnabledStatusDomainLevelResourceBase
+
+	// 必需的标签键
+	Keys *api.STagPolicyKeys `nullable:"false" list:"domain" create:"domain_required" update:"domain"`
+	// 缺失标签时是否从所属项目继承标签值并同步到云上资源
+	PushInheritedTags bool `nullable:"false" default:"false" list:"domain" create:"domain_optional" update:"domain"`
+}
+
+func validateTagPolicyKeys(keys api.STagPolicyKeys) (api.STagPolicyKeys, error) {
+	if len(keys) == 0 {
+		return nil, httperrors.NewMissingParameterError("keys")
+	}
+	ret := api.STagPolicyKeys{}
+	for _, key := range keys {
+		key = strings.TrimPrefix(strings.TrimSpace(key), db.USER_TAG_PREFIX)
+		if len(key) == 0 || strings.HasPrefix(key, db.SYS_TAG_PREFIX) {
+			return nil, httperrors.NewInputParameterError("invalid tag key %q", key)
+		}
+		if !utils.IsInStringArray(key, ret) {
+			ret = append(ret, key)
+		}
+	}
+	return ret, nil
+}
+
+func (manager *STagPolicyManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.TagPolicyCreateInput) (api.TagPolicyCreateInput, error) {
+	var err error
+	input.Keys, err = validateTagPolicyKeys(input.Keys)
+	if err != nil {
+		return input, err
+	}
+	input.EnabledStatusDomainLevelResourceCreateInput, err = manager.SEnabledStatusDomainLevelResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusDomainLevelResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (policy *STagPolicy) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.TagPolicyUpdateInput) (api.TagPolicyUpdateInput, error) {
+	var err error
+	if len(input.Keys) > 0 {
+		input.Keys, err = validateTagPolicyKeys(input.Keys)
+		if err != nil {
+			return input, err
+		}
+	}
+	input.EnabledStatusDomainLevelResourceBaseUpdateInput, err = policy.SEnabledStatusDomainLevelResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusDomainLevelResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (manager *STagPolicyManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.TagPolicyListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Key) > 0 {
+		q = q.Filter(sqlchemy.ContainsAny(q.Field("keys"), query.Key))
+	}
+	return q, nil
+}
+
+func (manager *STagPolicyManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.TagPolicyListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusDomainLevelResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusDomainLevelResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *STagPolicyManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusDomainLevelResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *STagPolicyManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.TagPolicyDetails {
+	rows := make([]api.TagPolicyDetails, len(objs))
+	domainRows := manager.SEnabledStatusDomainLevelResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.TagPolicyDetails{
+			EnabledStatusDomainLevelResourceDetails: domainRows[i],
+		}
+	}
+	return rows
+}
+
+// 标签策略缓存有效期, 策略变更时主动失效
+const tagPolicyCacheTTL = 5 * time.Minute
+
+// 域内已启用策略要求的标签键, 以及允许从项目继承的标签键
+type sTagPolicyKeys struct {
+	required    []string
+	inheritable []string
+}
+
+type sTagPolicyCacheStore struct {
+	lock sync.RWMutex
+
+	// 域ID -> 标签键
+	domains map[string]*sTagPolicyKeys
+
+	expiredAt time.Time
+}
+
+var tagPolicyCaches = &sTagPolicyCacheStore{
+	domains: map[string]*sTagPolicyKeys{},
+}
+
+func groupTagPolicyKeys(policies []STagPolicy) map[string]*sTagPolicyKeys {
+	ret := map[string]*sTagPolicyKeys{}
+	for i := range policies {
+		if policies[i].Keys == nil {
+			continue
+		}
+		keys, ok := ret[policies[i].DomainId]
+		if !ok {
+			keys = &sTagPolicyKeys{required: []string{}, inheritable: []string{}}
+			ret[policies[i].DomainId] = keys
+		}
+		for _, key := range *policies[i].Keys {
+			if !utils.IsInStringArray(key, keys.required) {
+				keys.required = append(keys.required, key)
+			}
+			if policies[i].PushInheritedTags && !utils.IsInStringArray(key, keys.inheritable) {
+				keys.inheritable = append(keys.inheritable, key)
+			}
+		}
+	}
+	return ret
+}
+
+func (store *sTagPolicyCacheStore) refresh() error {
+	q := TagPolicyManager.Query().IsTrue("enabled")
+	policies := []STagPolicy{}
+	err := db.FetchModelObjects(TagPolicyManager, q, &policies)
+	if err != nil {
+		return errors.Wrap(err, "FetchModelObjects")
+	}
+	domains := groupTagPolicyKeys(policies)
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.domains = domains
+	store.expiredAt = time.Now().Add(tagPolicyCacheTTL)
+	return nil
+}
+
+func (store *sTagPolicyCacheStore) invalidate() {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.expiredAt = time.Time{}
+}
+
+func (store *sTagPolicyCacheStore) lookup(domainId string) (*sTagPolicyKeys, bool) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	if time.Now().After(store.expiredAt) {
+		return nil, false
+	}
+	return store.domains[domainId], true
+}
+
+// 一次加载所有域的策略, 避免同步时每个资源都查询一次
+func (store *sTagPolicyCacheStore) get(domainId string) (*sTagPolicyKeys, error) {
+	keys, ok := store.lookup(domainId)
+	if !ok {
+		err := store.refresh()
+		if err != nil {
+			return nil, errors.Wrap(err, "refresh")
+		}
+		keys, _ = store.lookup(domainId)
+	}
+	if keys == nil {
+		return &sTagPolicyKeys{}, nil
+	}
+	return keys, nil
+}
+
+func (policy *STagPolicy) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	policy.SEnabledStatusDomainLevelResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+	tagPolicyCaches.invalidate()
+}
+
+func (policy *STagPolicy) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	policy.SEnabledStatusDomainLevelResourceBase.PostUpdate(ctx, userCred, query, data)
+	tagPolicyCaches.invalidate()
+}
+
+func (policy *STagPolicy) PostDelete(ctx context.Context, userCred mcclient.TokenCredential) {
+	policy.SEnabledStatusDomainLevelResourceBase.PostDelete(ctx, userCred)
+	tagPolicyCaches.invalidate()
+}
+
+func (policy *STagPolicy) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	ret, err := policy.SEnabledStatusDomainLevelResourceBase.PerformEnable(ctx, userCred, query, input)
+	tagPolicyCaches.invalidate()
+	return ret, err
+}
+
+func (policy *STagPolicy) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	ret, err := policy.SEnabledStatusDomainLevelResourceBase.PerformDisable(ctx, userCred, query, input)
+	tagPolicyCaches.invalidate()
+	return ret, err
+}
+
+// tags的键为去掉前缀后的标签键, 值为空的标签视为缺失
+func getMissingTagKeys(required []string, tags map[string]string) []string {
+	missing := []string{}
+	for _, key := range required {
+		if len(tags[key]) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// 从项目标签中取出可继承的缺失标签值
+func getInheritedTags(ctx context.Context, projectId string, missing, inheritable []string) map[string]string {
+	ret := map[string]string{}
+	if len(projectId) == 0 || len(inheritable) == 0 {
+		return ret
+	}
+	project, err := db.TenantCacheManager.FetchTenantById(ctx, projectId)
+	if err != nil {
+		log.Errorf("fetch project %s for inherited tags error: %v", projectId, err)
+		return ret
+	}
+	for _, tag := range project.GetTags() {
+		key := strings.TrimPrefix(tag.Key, db.USER_TAG_PREFIX)
+		if len(tag.Value) > 0 && utils.IsInStringArray(key, missing) && utils.IsInStringArray(key, inheritable) {
+			ret[key] = tag.Value
+		}
+	}
+	return ret
+}
+
+// 创建时检查必需标签, 策略允许时从项目继承缺失的标签
+func (manager *STagPolicyManager) validateCreateTags(ctx context.Context, ownerId mcclient.IIdentityProvider, metadata map[string]string) (map[string]string, error) {
+	keys, err := tagPolicyCaches.get(ownerId.GetProjectDomainId())
+	if err != nil {
+		return metadata, httperrors.NewGeneralError(err)
+	}
+	required, inheritable := keys.required, keys.inheritable
+	if len(required) == 0 {
+		return metadata, nil
+	}
+	tags := map[string]string{}
+	for k, v := range metadata {
+		tags[strings.TrimPrefix(k, db.USER_TAG_PREFIX)] = v
+	}
+	missing := getMissingTagKeys(required, tags)
+	if len(missing) == 0 {
+		return metadata, nil
+	}
+	inherited := getInheritedTags(ctx, ownerId.GetProjectId(), missing, inheritable)
+	if len(inherited) > 0 && metadata == nil {
+		metadata = map[string]string{}
+	}
+	for k, v := range inherited {
+		metadata[db.USER_TAG_PREFIX+k] = v
+		tags[k] = v
+	}
+	missing = getMissingTagKeys(required, tags)
+	if len(missing) > 0 {
+		return metadata, httperrors.NewInputParameterError("missing required tags %s", strings.Join(missing, ","))
+	}
+	return metadata, nil
+}
+
+// 标签策略覆盖的资源类型, 创建时校验必需标签, 同步时检查合规
+var tagPolicyResourceTypes = []string{
+	"server",
+	"disk",
+	"eip",
+	"bucket",
+	"dbinstance",
+	"elasticcache",
+	"loadbalancer",
+}
+
+// 检查同步的资源是否满足标签策略, 策略允许时将项目标签推送到云上, 仍缺失的标签记录在系统元数据中
+func checkTagPolicyCompliance(ctx context.Context, userCred mcclient.TokenCredential, model db.IVirtualModel) {
+	if !utils.IsInStringArray(model.Keyword(), tagPolicyResourceTypes) {
+		return
+	}
+	keys, err := tagPolicyCaches.get(model.GetOwnerId().GetProjectDomainId())
+	if err != nil {
+		log.Errorf("get tag policies for %s %s error: %v", model.Keyword(), model.GetName(), err)
+		return
+	}
+	required, inheritable := keys.required, keys.inheritable
+	metaKeys := []string{api.VM_METADATA_TAG_POLICY_MISSING}
+	for _, key := range required {
+		metaKeys = append(metaKeys, db.USER_TAG_PREFIX+key, db.CLOUD_TAG_PREFIX+key)
+	}
+	// 一次查询取出必需标签及合规记录
+	meta, err := db.Metadata.GetAll(ctx, model, metaKeys, "", userCred)
+	if err != nil {
+		log.Errorf("get tags of %s %s error: %v", model.Keyword(), model.GetName(), err)
+		return
+	}
+	tags := map[string]string{}
+	for _, prefix := range []string{db.CLOUD_TAG_PREFIX, db.USER_TAG_PREFIX} {
+		for k, v := range meta {
+			if strings.HasPrefix(k, prefix) && len(v) > 0 {
+				tags[strings.TrimPrefix(k, prefix)] = v
+			}
+		}
+	}
+	missing := getMissingTagKeys(required, tags)
+	inherited := getInheritedTags(ctx, model.GetOwnerId().GetProjectId(), missing, inheritable)
+	if len(inherited) > 0 {
+		store := map[string]interface{}{}
+		for k, v := range inherited {
+			store[db.USER_TAG_PREFIX+k] = v
+			tags[k] = v
+		}
+		// 设置用户标签后会通过OnMetadataUpdated同步到云上
+		err = model.SetUserMetadataValues(ctx, store, userCred)
+		if err != nil {
+			log.Errorf("set inherited tags of %s %s error: %v", model.Keyword(), model.GetName(), err)
+		} else {
+			missing = getMissingTagKeys(required, tags)
+		}
+	}
+	if meta[api.VM_METADATA_TAG_POLICY_MISSING] == strings.Join(missing, ",") {
+		return
+	}
+	err = model.SetMetadata(ctx, api.VM_METADATA_TAG_POLICY_MISSING, strings.Join(missing, ","), userCred)
+	if err != nil {
+		log.Errorf("set tag policy compliance of %s %s error: %v", model.Keyword(), model.GetName(), err)
+	}
+}
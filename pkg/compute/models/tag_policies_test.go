@@ -0,0 +1,83 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestGetMissingTagKeys(t *testing.T) {
+	cases := []struct {
+		name     string
+		required []string
+		tags     map[string]string
+		want     []string
+	}{
+		{
+			name:     "all present",
+			required: []string{"owner", "cost-center"},
+			tags:     map[string]string{"owner": "alice", "cost-center": "rd"},
+			want:     []string{},
+		},
+		{
+			name:     "missing and empty",
+			required: []string{"owner", "cost-center"},
+			tags:     map[string]string{"owner": ""},
+			want:     []string{"owner", "cost-center"},
+		},
+		{
+			name:     "no policy",
+			required: []string{},
+			tags:     nil,
+			want:     []string{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := getMissingTagKeys(c.required, c.tags)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestGroupTagPolicyKeys(t *testing.T) {
+	newPolicy := func(domainId string, push bool, keys ...string) STagPolicy {
+		policy := STagPolicy{PushInheritedTags: push}
+		policy.DomainId = domainId
+		if len(keys) > 0 {
+			policyKeys := api.STagPolicyKeys(keys)
+			policy.Keys = &policyKeys
+		}
+		return policy
+	}
+	got := groupTagPolicyKeys([]STagPolicy{
+		newPolicy("d1", false, "owner"),
+		newPolicy("d1", true, "owner", "cost-center"),
+		newPolicy("d2", false, "owner"),
+		newPolicy("d3", true),
+	})
+	want := map[string]*sTagPolicyKeys{
+		"d1": {required: []string{"owner", "cost-center"}, inheritable: []string{"owner", "cost-center"}},
+		"d2": {required: []string{"owner"}, inheritable: []string{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
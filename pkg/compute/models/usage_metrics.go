@@ -0,0 +1,120 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/rbacscope"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// 按云订阅, 区域及项目汇总的资源用量, 用于导出监控指标
+type SUsageMetricRow struct {
+	ManagerId     string
+	CloudregionId string
+	ProjectId     string `json:"tenant_id"`
+	DomainId      string
+
+	Count int
+	Cpu   int
+	// 内存大小, 单位MB
+	Memory int64
+	// 存储大小, 单位MB
+	Storage int64
+	// 带宽, 单位Mbps
+	Bandwidth int64
+}
+
+func filterUsageMetricOwner(q *sqlchemy.SQuery, scope rbacscope.TRbacScope, ownerId mcclient.IIdentityProvider) *sqlchemy.SQuery {
+	switch scope {
+	case rbacscope.ScopeDomain:
+		q = q.Equals("domain_id", ownerId.GetProjectDomainId())
+	case rbacscope.ScopeProject:
+		q = q.Equals("tenant_id", ownerId.GetProjectId())
+	}
+	return q
+}
+
+// 虚拟机数量, CPU核数及内存, 区域和云订阅取自宿主机
+func (manager *SGuestManager) GetUsageMetrics(scope rbacscope.TRbacScope, ownerId mcclient.IIdentityProvider) ([]SUsageMetricRow, error) {
+	guests := filterUsageMetricOwner(manager.Query().IsFalse("pending_deleted"), scope, ownerId).SubQuery()
+	hosts := HostManager.Query().SubQuery()
+	zones := ZoneManager.Query().SubQuery()
+	q := guests.Query(
+		hosts.Field("manager_id"),
+		zones.Field("cloudregion_id"),
+		guests.Field("tenant_id"),
+		guests.Field("domain_id"),
+		sqlchemy.COUNT("count", guests.Field("id")),
+		sqlchemy.SUM("cpu", guests.Field("vcpu_count")),
+		sqlchemy.SUM("memory", guests.Field("vmem_size")),
+	)
+	q = q.LeftJoin(hosts, sqlchemy.Equals(guests.Field("host_id"), hosts.Field("id")))
+	q = q.LeftJoin(zones, sqlchemy.Equals(hosts.Field("zone_id"), zones.Field("id")))
+	q = q.GroupBy(hosts.Field("manager_id"), zones.Field("cloudregion_id"), guests.Field("tenant_id"), guests.Field("domain_id"))
+	rows := []SUsageMetricRow{}
+	err := q.All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "query guest usage metrics")
+	}
+	return rows, nil
+}
+
+// 磁盘数量及容量, 区域和云订阅取自存储
+func (manager *SDiskManager) GetUsageMetrics(scope rbacscope.TRbacScope, ownerId mcclient.IIdentityProvider) ([]SUsageMetricRow, error) {
+	disks := filterUsageMetricOwner(manager.Query().IsFalse("pending_deleted"), scope, ownerId).SubQuery()
+	storages := StorageManager.Query().SubQuery()
+	zones := ZoneManager.Query().SubQuery()
+	q := disks.Query(
+		storages.Field("manager_id"),
+		zones.Field("cloudregion_id"),
+		disks.Field("tenant_id"),
+		disks.Field("domain_id"),
+		sqlchemy.COUNT("count", disks.Field("id")),
+		sqlchemy.SUM("storage", disks.Field("disk_size")),
+	)
+	q = q.LeftJoin(storages, sqlchemy.Equals(disks.Field("storage_id"), storages.Field("id")))
+	q = q.LeftJoin(zones, sqlchemy.Equals(storages.Field("zone_id"), zones.Field("id")))
+	q = q.GroupBy(storages.Field("manager_id"), zones.Field("cloudregion_id"), disks.Field("tenant_id"), disks.Field("domain_id"))
+	rows := []SUsageMetricRow{}
+	err := q.All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "query disk usage metrics")
+	}
+	return rows, nil
+}
+
+// 弹性公网IP数量及带宽
+func (manager *SElasticipManager) GetUsageMetrics(scope rbacscope.TRbacScope, ownerId mcclient.IIdentityProvider) ([]SUsageMetricRow, error) {
+	eips := filterUsageMetricOwner(manager.Query().Equals("mode", api.EIP_MODE_STANDALONE_EIP), scope, ownerId).SubQuery()
+	q := eips.Query(
+		eips.Field("manager_id"),
+		eips.Field("cloudregion_id"),
+		eips.Field("tenant_id"),
+		eips.Field("domain_id"),
+		sqlchemy.COUNT("count", eips.Field("id")),
+		sqlchemy.SUM("bandwidth", eips.Field("bandwidth")),
+	)
+	q = q.GroupBy(eips.Field("manager_id"), eips.Field("cloudregion_id"), eips.Field("tenant_id"), eips.Field("domain_id"))
+	rows := []SUsageMetricRow{}
+	err := q.All(&rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "query eip usage metrics")
+	}
+	return rows, nil
+}
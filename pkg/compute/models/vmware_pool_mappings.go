@@ -0,0 +1,315 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SVmwarePoolMappingManager struct {
+	db.SStandaloneResourceBaseManager
+	db.SProjectizedResourceBaseManager
+	SManagedResourceBaseManager
+}
+
+var VmwarePoolMappingManager *SVmwarePoolMappingManager
+
+func init() {
+	VmwarePoolMappingManager = &SVmwarePoolMappingManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SVmwarePoolMapping{},
+			"vmware_pool_mappings_tbl",
+			"vmware_pool_mapping",
+			"vmware_pool_mappings",
+		),
+	}
+	VmwarePoolMappingManager.SetVirtualObject(VmwarePoolMappingManager)
+}
+
+// VMware资源池/文件夹与项目的映射, 映射所属项目即目标项目
+type SVmwarePoolMapping struct {
+	db.SStandaloneResourceBase
+	db.SProjectizedResourceBase
+	SManagedResourceBase
+
+	// 映射类型
+	MappingType string `width:"16" charset:"ascii" nullable:"false" list:"user" create:"required"`
+	// 资源池名称或虚拟机文件夹路径
+	Path string `width:"256" charset:"utf8" nullable:"false" list:"user" create:"required"`
+}
+
+// 可获取vSphere对象路径的云上资源
+type ICloudVMPath interface {
+	GetPath() []string
+}
+
+func (manager *SVmwarePoolMappingManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.VmwarePoolMappingCreateInput) (api.VmwarePoolMappingCreateInput, error) {
+	if len(input.CloudproviderId) == 0 {
+		return input, httperrors.NewMissingParameterError("cloudprovider_id")
+	}
+	providerObj, err := validators.ValidateModel(userCred, CloudproviderManager, &input.CloudproviderId)
+	if err != nil {
+		return input, err
+	}
+	provider := providerObj.(*SCloudprovider)
+	if provider.Provider != api.CLOUD_PROVIDER_VMWARE {
+		return input, httperrors.NewInputParameterError("cloudprovider %s is not a vCenter", provider.Name)
+	}
+	input.ManagerId = provider.Id
+	if !utils.IsInStringArray(input.MappingType, api.VMWARE_POOL_MAPPING_TYPES) {
+		return input, httperrors.NewInputParameterError("invalid mapping_type %s, supported: %s", input.MappingType, api.VMWARE_POOL_MAPPING_TYPES)
+	}
+	input.Path = strings.Trim(input.Path, "/")
+	if len(input.Path) == 0 {
+		return input, httperrors.NewMissingParameterError("path")
+	}
+	cnt, err := manager.Query().Equals("manager_id", provider.Id).Equals("mapping_type", input.MappingType).Equals("path", input.Path).CountWithError()
+	if err != nil {
+		return input, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return input, httperrors.NewDuplicateResourceError("%s %s of %s already mapped", input.MappingType, input.Path, provider.Name)
+	}
+	if len(input.Name) == 0 && len(input.GenerateName) == 0 {
+		input.GenerateName = fmt.Sprintf("%s-%s", input.MappingType, strings.ReplaceAll(input.Path, "/", "-"))
+	}
+	input.StandaloneResourceCreateInput, err = manager.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStandaloneResourceBaseManager.ValidateCreateData")
+	}
+	return input, nil
+}
+
+func (self *SVmwarePoolMapping) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	self.ProjectId = ownerId.GetProjectId()
+	self.DomainId = ownerId.GetProjectDomainId()
+	return self.SStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (manager *SVmwarePoolMappingManager) purgeAll(ctx context.Context, userCred mcclient.TokenCredential, providerId string) error {
+	_, err := sqlchemy.GetDB().Exec(
+		fmt.Sprintf("delete from %s where manager_id = ?", manager.TableSpec().Name()),
+		providerId,
+	)
+	return err
+}
+
+func (manager *SVmwarePoolMappingManager) getMappings(managerId string) ([]SVmwarePoolMapping, error) {
+	q := manager.Query().Equals("manager_id", managerId).Asc("created_at")
+	ret := []SVmwarePoolMapping{}
+	err := db.FetchModelObjects(manager, q, &ret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.FetchModelObjects")
+	}
+	return ret, nil
+}
+
+// 从虚拟机的vSphere路径中提取所在文件夹(相对于数据中心的虚拟机根目录)
+// 如 Datacenters/dc1/vm/dev/web/vm1 => dev/web
+func getVmwareVMFolder(path []string) string {
+	for i := 1; i < len(path)-1; i++ {
+		if path[i] == "vm" {
+			return strings.Join(path[i+1:len(path)-1], "/")
+		}
+	}
+	return ""
+}
+
+// 文件夹映射优先, 按最长路径匹配, 子文件夹继承父文件夹的映射; 其次按资源池名称匹配
+func matchVmwarePoolMapping(mappings []SVmwarePoolMapping, folder, pool string) *SVmwarePoolMapping {
+	var match *SVmwarePoolMapping
+	for i := range mappings {
+		mapping := &mappings[i]
+		if mapping.MappingType != api.VMWARE_POOL_MAPPING_TYPE_FOLDER || len(folder) == 0 {
+			continue
+		}
+		if folder != mapping.Path && !strings.HasPrefix(folder, mapping.Path+"/") {
+			continue
+		}
+		if match == nil || len(mapping.Path) > len(match.Path) {
+			match = mapping
+		}
+	}
+	if match != nil {
+		return match
+	}
+	for i := range mappings {
+		if mappings[i].MappingType == api.VMWARE_POOL_MAPPING_TYPE_RESOURCE_POOL && len(pool) > 0 && mappings[i].Path == pool {
+			return &mappings[i]
+		}
+	}
+	return nil
+}
+
+// 根据虚拟机所在的文件夹或资源池获取同步归属的项目
+func (manager *SVmwarePoolMappingManager) fetchOwnerId(managerId string, extModel cloudprovider.IVirtualResource) (mcclient.IIdentityProvider, error) {
+	mappings, err := manager.getMappings(managerId)
+	if err != nil {
+		return nil, errors.Wrap(err, "getMappings")
+	}
+	if len(mappings) == 0 {
+		return nil, nil
+	}
+	folder, pool := "", ""
+	if vm, ok := extModel.(ICloudVMPath); ok {
+		folder = getVmwareVMFolder(vm.GetPath())
+	}
+	if extProjectId := extModel.GetProjectId(); len(extProjectId) > 0 {
+		extProject, err := ExternalProjectManager.GetProject(extProjectId, managerId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetProject(%s)", extProjectId)
+		}
+		pool = extProject.Name
+	}
+	mapping := matchVmwarePoolMapping(mappings, folder, pool)
+	if mapping == nil {
+		return nil, nil
+	}
+	return mapping.GetOwnerId(), nil
+}
+
+// 获取项目在vCenter上对应的资源池, 新建虚拟机时放置在该资源池
+func (manager *SVmwarePoolMappingManager) GetResourcePool(managerId, projectId string) (string, error) {
+	q := manager.Query().Equals("manager_id", managerId).Equals("tenant_id", projectId).
+		Equals("mapping_type", api.VMWARE_POOL_MAPPING_TYPE_RESOURCE_POOL).Asc("created_at")
+	mapping := &SVmwarePoolMapping{}
+	mapping.SetModelManager(manager, mapping)
+	err := q.First(mapping)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "q.First")
+	}
+	return mapping.Path, nil
+}
+
+// 映射仅归属于vCenter云订阅, 只按云订阅及云账号过滤
+func getVmwarePoolMappingManagedInput(query api.VmwarePoolMappingListInput) api.ManagedResourceListInput {
+	return api.ManagedResourceListInput{
+		CloudproviderResourceInput: query.CloudproviderResourceInput,
+		CloudaccountId:             query.CloudaccountId,
+		OrderByManager:             query.OrderByManager,
+	}
+}
+
+// VMware资源池映射列表
+func (manager *SVmwarePoolMappingManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.VmwarePoolMappingListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SManagedResourceBaseManager.ListItemFilter(ctx, q, userCred, getVmwarePoolMappingManagedInput(query))
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.ListItemFilter")
+	}
+	if len(query.MappingType) > 0 {
+		q = q.In("mapping_type", query.MappingType)
+	}
+	if len(query.Path) > 0 {
+		q = q.In("path", query.Path)
+	}
+	return q, nil
+}
+
+func (manager *SVmwarePoolMappingManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.VmwarePoolMappingListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SManagedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, getVmwarePoolMappingManagedInput(query))
+	if err != nil {
+		return nil, errors.Wrap(err, "SManagedResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SVmwarePoolMappingManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return db.ApplyQueryDistinctExtraField(q, field,
+		&manager.SStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SManagedResourceBaseManager,
+	)
+}
+
+func (manager *SVmwarePoolMappingManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	return db.ApplyListItemExportKeys(ctx, q, userCred, keys,
+		&manager.SStandaloneResourceBaseManager,
+		&manager.SProjectizedResourceBaseManager,
+		&manager.SManagedResourceBaseManager,
+	)
+}
+
+func (manager *SVmwarePoolMappingManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.VmwarePoolMappingDetails {
+	rows := make([]api.VmwarePoolMappingDetails, len(objs))
+	stdRows := manager.SStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	managerRows := manager.SManagedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.VmwarePoolMappingDetails{
+			StandaloneResourceDetails: stdRows[i],
+			ProjectizedResourceInfo:   projRows[i],
+			ManagedResourceInfo:       managerRows[i],
+		}
+	}
+	return rows
+}
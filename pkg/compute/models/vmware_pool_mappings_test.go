@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestGetVmwareVMFolder(t *testing.T) {
+	cases := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"Datacenters", "dc1", "vm", "dev", "web", "vm1"}, "dev/web"},
+		{[]string{"Datacenters", "dc1", "vm", "vm1"}, ""},
+		{[]string{"Datacenters", "dc1", "host", "vm1"}, ""},
+		{nil, ""},
+	}
+	for _, c := range cases {
+		got := getVmwareVMFolder(c.path)
+		if got != c.want {
+			t.Errorf("path %v: want %q, got %q", c.path, c.want, got)
+		}
+	}
+}
+
+func TestMatchVmwarePoolMapping(t *testing.T) {
+	mappings := []SVmwarePoolMapping{
+		{MappingType: api.VMWARE_POOL_MAPPING_TYPE_FOLDER, Path: "dev"},
+		{MappingType: api.VMWARE_POOL_MAPPING_TYPE_FOLDER, Path: "dev/web"},
+		{MappingType: api.VMWARE_POOL_MAPPING_TYPE_RESOURCE_POOL, Path: "prod"},
+	}
+	cases := []struct {
+		folder string
+		pool   string
+		want   string
+	}{
+		{"dev/web/frontend", "prod", "dev/web"},
+		{"dev/db", "", "dev"},
+		{"devops", "prod", "prod"},
+		{"", "test", ""},
+	}
+	for _, c := range cases {
+		got := ""
+		if mapping := matchVmwarePoolMapping(mappings, c.folder, c.pool); mapping != nil {
+			got = mapping.Path
+		}
+		if got != c.want {
+			t.Errorf("folder %q pool %q: want %q, got %q", c.folder, c.pool, c.want, got)
+		}
+	}
+}
@@ -161,31 +161,12 @@ func (manager *SVpcPeeringConnectionManager) ValidateCreateData(
 
 	// check vpc ip range overlap
 	if !factory.IsSupportVpcPeeringVpcCidrOverlap() {
-		vpcIpv4Ranges := []netutils.IPV4AddrRange{}
-		peervpcIpv4Ranges := []netutils.IPV4AddrRange{}
-		vpcCidrBlocks := strings.Split(vpc.CidrBlock, ",")
-		peervpcCidrBlocks := strings.Split(peerVpc.CidrBlock, ",")
-		for i := range vpcCidrBlocks {
-			vpcIpv4Range, err := netutils.NewIPV4Prefix(vpcCidrBlocks[i])
-			if err != nil {
-				return input, httperrors.NewGeneralError(errors.Wrapf(err, "convert vpc cidr %s to ipv4range error", vpcCidrBlocks[i]))
-			}
-			vpcIpv4Ranges = append(vpcIpv4Ranges, vpcIpv4Range.ToIPRange())
-		}
-
-		for i := range peervpcCidrBlocks {
-			peervpcIpv4Range, err := netutils.NewIPV4Prefix(peervpcCidrBlocks[i])
-			if err != nil {
-				return input, httperrors.NewGeneralError(errors.Wrapf(err, "convert vpc cidr %s to ipv4range error", peervpcCidrBlocks[i]))
-			}
-			peervpcIpv4Ranges = append(peervpcIpv4Ranges, peervpcIpv4Range.ToIPRange())
+		overlap, err := isVpcCidrOverlap(vpc, peerVpc)
+		if err != nil {
+			return input, httperrors.NewGeneralError(err)
 		}
-		for i := range vpcIpv4Ranges {
-			for j := range peervpcIpv4Ranges {
-				if vpcIpv4Ranges[i].IsOverlap(peervpcIpv4Ranges[j]) {
-					return input, httperrors.NewNotSupportedError("ipv4 range overlap")
-				}
-			}
+		if overlap {
+			return input, httperrors.NewNotSupportedError("ipv4 range overlap")
 		}
 	}
 
@@ -218,6 +199,36 @@ func (manager *SVpcPeeringConnectionManager) ValidateCreateData(
 	return input, nil
 }
 
+func isVpcCidrOverlap(vpc, peerVpc *SVpc) (bool, error) {
+	vpcIpv4Ranges := []netutils.IPV4AddrRange{}
+	peervpcIpv4Ranges := []netutils.IPV4AddrRange{}
+	vpcCidrBlocks := strings.Split(vpc.CidrBlock, ",")
+	peervpcCidrBlocks := strings.Split(peerVpc.CidrBlock, ",")
+	for i := range vpcCidrBlocks {
+		vpcIpv4Range, err := netutils.NewIPV4Prefix(vpcCidrBlocks[i])
+		if err != nil {
+			return false, errors.Wrapf(err, "convert vpc cidr %s to ipv4range error", vpcCidrBlocks[i])
+		}
+		vpcIpv4Ranges = append(vpcIpv4Ranges, vpcIpv4Range.ToIPRange())
+	}
+
+	for i := range peervpcCidrBlocks {
+		peervpcIpv4Range, err := netutils.NewIPV4Prefix(peervpcCidrBlocks[i])
+		if err != nil {
+			return false, errors.Wrapf(err, "convert vpc cidr %s to ipv4range error", peervpcCidrBlocks[i])
+		}
+		peervpcIpv4Ranges = append(peervpcIpv4Ranges, peervpcIpv4Range.ToIPRange())
+	}
+	for i := range vpcIpv4Ranges {
+		for j := range peervpcIpv4Ranges {
+			if vpcIpv4Ranges[i].IsOverlap(peervpcIpv4Ranges[j]) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 func (self *SVpcPeeringConnection) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	params := jsonutils.NewDict()
 	task, err := taskman.TaskManager.NewTask(ctx, "VpcPeeringConnectionCreateTask", self, userCred, params, "", "", nil)
@@ -0,0 +1,378 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type sVpcTopologyPlanner struct {
+	vpcs      []SVpc
+	bandwidth int
+
+	// vpcId-peerVpcId -> 对等连接
+	peerings map[string]*SVpcPeeringConnection
+	// vpcId -> vpc互联, 包括计划加入的
+	interVpcs map[string]*SInterVpcNetwork
+	// vpc互联中计划加入的vpc
+	pendingJoins map[string]*SInterVpcNetwork
+}
+
+func fetchTopologyVpcs(userCred mcclient.TokenCredential, vpcIds []string) ([]SVpc, error) {
+	vpcs := []SVpc{}
+	ids := map[string]bool{}
+	for i := range vpcIds {
+		_vpc, err := validators.ValidateModel(userCred, VpcManager, &vpcIds[i])
+		if err != nil {
+			return nil, err
+		}
+		vpc := _vpc.(*SVpc)
+		if ids[vpc.Id] {
+			continue
+		}
+		ids[vpc.Id] = true
+		vpcs = append(vpcs, *vpc)
+	}
+	return vpcs, nil
+}
+
+func newVpcTopologyPlanner(vpcs []SVpc, bandwidth int) (*sVpcTopologyPlanner, error) {
+	planner := &sVpcTopologyPlanner{
+		vpcs:         vpcs,
+		bandwidth:    bandwidth,
+		peerings:     map[string]*SVpcPeeringConnection{},
+		interVpcs:    map[string]*SInterVpcNetwork{},
+		pendingJoins: map[string]*SInterVpcNetwork{},
+	}
+	vpcIds := []string{}
+	for i := range vpcs {
+		vpcIds = append(vpcIds, vpcs[i].Id)
+	}
+
+	peerings := []SVpcPeeringConnection{}
+	q := VpcPeeringConnectionManager.Query().In("vpc_id", vpcIds).In("peer_vpc_id", vpcIds)
+	err := db.FetchModelObjects(VpcPeeringConnectionManager, q, &peerings)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchModelObjects vpc peering connections")
+	}
+	for i := range peerings {
+		planner.peerings[peerings[i].VpcId+"-"+peerings[i].PeerVpcId] = &peerings[i]
+	}
+
+	joints := []SInterVpcNetworkVpc{}
+	q = InterVpcNetworkVpcManager.Query().In("vpc_id", vpcIds)
+	err = db.FetchModelObjects(InterVpcNetworkVpcManager, q, &joints)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchModelObjects inter vpc network vpcs")
+	}
+	networks := map[string]*SInterVpcNetwork{}
+	for i := range joints {
+		network, ok := networks[joints[i].InterVpcNetworkId]
+		if !ok {
+			_network, err := InterVpcNetworkManager.FetchById(joints[i].InterVpcNetworkId)
+			if err != nil {
+				return nil, errors.Wrapf(err, "fetch inter vpc network %s", joints[i].InterVpcNetworkId)
+			}
+			network = _network.(*SInterVpcNetwork)
+			networks[network.Id] = network
+		}
+		planner.interVpcs[joints[i].VpcId] = network
+	}
+	return planner, nil
+}
+
+func (planner *sVpcTopologyPlanner) getPeering(vpc, peerVpc *SVpc) *SVpcPeeringConnection {
+	if peering, ok := planner.peerings[vpc.Id+"-"+peerVpc.Id]; ok {
+		return peering
+	}
+	return planner.peerings[peerVpc.Id+"-"+vpc.Id]
+}
+
+// 获取与vpc同一云账号下可用的vpc互联, 用于不支持跨区域对等连接的平台
+func (planner *sVpcTopologyPlanner) getAccountInterVpcNetwork(account *SCloudaccount) (*SInterVpcNetwork, error) {
+	providers := CloudproviderManager.Query("id").Equals("cloudaccount_id", account.Id).SubQuery()
+	q := InterVpcNetworkManager.Query().In("manager_id", providers).In("status", []string{api.INTER_VPC_NETWORK_STATUS_AVAILABLE, api.INTER_VPC_NETWORK_STATUS_ACTIVE})
+	networks := []SInterVpcNetwork{}
+	err := db.FetchModelObjects(InterVpcNetworkManager, q, &networks)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchModelObjects inter vpc networks")
+	}
+	if len(networks) == 0 {
+		return nil, nil
+	}
+	return &networks[0], nil
+}
+
+func (planner *sVpcTopologyPlanner) planInterVpcNetwork(vpc, peerVpc *SVpc, account *SCloudaccount, link *api.VpcTopologyLink) error {
+	network, peerNetwork := planner.interVpcs[vpc.Id], planner.interVpcs[peerVpc.Id]
+	if network != nil && peerNetwork != nil {
+		link.Type = api.VPC_TOPOLOGY_LINK_UNREACHABLE
+		link.Reason = fmt.Sprintf("vpcs already joined different inter vpc networks %s and %s", network.Name, peerNetwork.Name)
+		return nil
+	}
+	if network == nil {
+		network = peerNetwork
+	}
+	if network == nil {
+		var err error
+		network, err = planner.getAccountInterVpcNetwork(account)
+		if err != nil {
+			return err
+		}
+		if network == nil {
+			link.Type = api.VPC_TOPOLOGY_LINK_UNREACHABLE
+			link.Reason = fmt.Sprintf("cloudprovider %s not supported CrossRegion vpcpeering, create an inter vpc network first", account.Provider)
+			return nil
+		}
+	}
+	for _, v := range []*SVpc{vpc, peerVpc} {
+		if _, ok := planner.interVpcs[v.Id]; !ok {
+			planner.interVpcs[v.Id] = network
+			planner.pendingJoins[v.Id] = network
+		}
+	}
+	link.Type = api.VPC_TOPOLOGY_LINK_INTER_VPC_NETWORK
+	link.ResourceId = network.Id
+	link.Status = network.Status
+	link.Action = api.VPC_TOPOLOGY_ACTION_CREATE
+	return nil
+}
+
+// 计算两个vpc之间的互联方式, plan为false时只返回已存在的互联
+func (planner *sVpcTopologyPlanner) planLink(vpc, peerVpc *SVpc, plan bool) (*api.VpcTopologyLink, error) {
+	link := &api.VpcTopologyLink{
+		VpcId:     vpc.Id,
+		PeerVpcId: peerVpc.Id,
+		Action:    api.VPC_TOPOLOGY_ACTION_SKIP,
+	}
+	if peering := planner.getPeering(vpc, peerVpc); peering != nil {
+		link.Type = api.VPC_TOPOLOGY_LINK_PEERING
+		link.ResourceId = peering.Id
+		link.Status = peering.Status
+		link.Action = api.VPC_TOPOLOGY_ACTION_EXISTING
+		return link, nil
+	}
+	network, peerNetwork := planner.interVpcs[vpc.Id], planner.interVpcs[peerVpc.Id]
+	if network != nil && peerNetwork != nil && network.Id == peerNetwork.Id {
+		_, pending := planner.pendingJoins[vpc.Id]
+		_, peerPending := planner.pendingJoins[peerVpc.Id]
+		link.Type = api.VPC_TOPOLOGY_LINK_INTER_VPC_NETWORK
+		link.ResourceId = network.Id
+		link.Status = network.Status
+		link.Action = api.VPC_TOPOLOGY_ACTION_EXISTING
+		if pending || peerPending {
+			link.Action = api.VPC_TOPOLOGY_ACTION_CREATE
+		}
+		return link, nil
+	}
+	if !plan {
+		return nil, nil
+	}
+
+	link.Type = api.VPC_TOPOLOGY_LINK_UNREACHABLE
+	account, peerAccount := vpc.GetCloudaccount(), peerVpc.GetCloudaccount()
+	if account == nil || peerAccount == nil {
+		link.Reason = "Only public cloud support vpcpeering"
+		return link, nil
+	}
+	if account.Provider != peerAccount.Provider {
+		link.Reason = fmt.Sprintf("vpc on different cloudprovider %s and %s peering is not supported", account.Provider, peerAccount.Provider)
+		return link, nil
+	}
+	factory, err := cloudprovider.GetProviderFactory(account.Provider)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cloudprovider.GetProviderFactory(%s)", account.Provider)
+	}
+	if account.AccessUrl != peerAccount.AccessUrl && !factory.IsSupportCrossCloudEnvVpcPeering() {
+		link.Reason = fmt.Sprintf("cloudprovider %s not supported CrossCloud vpcpeering", account.Provider)
+		return link, nil
+	}
+	crossRegion := vpc.CloudregionId != peerVpc.CloudregionId
+	if crossRegion && !factory.IsSupportCrossRegionVpcPeering() {
+		if account.Id != peerAccount.Id {
+			link.Reason = fmt.Sprintf("cloudprovider %s not supported CrossRegion vpcpeering", account.Provider)
+			return link, nil
+		}
+		return link, planner.planInterVpcNetwork(vpc, peerVpc, account, link)
+	}
+	if !factory.IsSupportVpcPeeringVpcCidrOverlap() {
+		overlap, err := isVpcCidrOverlap(vpc, peerVpc)
+		if err != nil {
+			return nil, err
+		}
+		if overlap {
+			link.Reason = "ipv4 range overlap"
+			return link, nil
+		}
+	}
+	if crossRegion {
+		err = factory.ValidateCrossRegionVpcPeeringBandWidth(planner.bandwidth)
+		if err != nil {
+			link.Reason = err.Error()
+			return link, nil
+		}
+	}
+	link.Type = api.VPC_TOPOLOGY_LINK_PEERING
+	link.Action = api.VPC_TOPOLOGY_ACTION_CREATE
+	return link, nil
+}
+
+func (planner *sVpcTopologyPlanner) getTopology(plan bool) (*api.VpcTopology, error) {
+	ret := &api.VpcTopology{
+		Nodes: []api.VpcTopologyNode{},
+		Links: []api.VpcTopologyLink{},
+	}
+	for i := range planner.vpcs {
+		vpc := &planner.vpcs[i]
+		node := api.VpcTopologyNode{
+			Id:            vpc.Id,
+			Name:          vpc.Name,
+			Provider:      api.CLOUD_PROVIDER_ONECLOUD,
+			ManagerId:     vpc.ManagerId,
+			CloudregionId: vpc.CloudregionId,
+			CidrBlock:     vpc.CidrBlock,
+		}
+		if account := vpc.GetCloudaccount(); account != nil {
+			node.Provider = account.Provider
+		}
+		if region, err := vpc.GetRegion(); err == nil {
+			node.Cloudregion = region.Name
+		}
+		ret.Nodes = append(ret.Nodes, node)
+	}
+	for i := range planner.vpcs {
+		for j := i + 1; j < len(planner.vpcs); j++ {
+			link, err := planner.planLink(&planner.vpcs[i], &planner.vpcs[j], plan)
+			if err != nil {
+				return nil, err
+			}
+			if link != nil {
+				ret.Links = append(ret.Links, *link)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// 获取vpc之间已有的对等连接及vpc互联拓扑
+func (manager *SVpcPeeringConnectionManager) GetPropertyTopology(ctx context.Context, userCred mcclient.TokenCredential, query api.VpcPeeringTopologyInput) (*api.VpcTopology, error) {
+	if len(query.VpcIds) == 0 {
+		return nil, httperrors.NewMissingParameterError("vpc_ids")
+	}
+	vpcs, err := fetchTopologyVpcs(userCred, query.VpcIds)
+	if err != nil {
+		return nil, err
+	}
+	planner, err := newVpcTopologyPlanner(vpcs, 0)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	ret, err := planner.getTopology(false)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return ret, nil
+}
+
+// 根据各平台能力, 为指定vpc两两创建对等连接或加入vpc互联, 返回互联拓扑
+func (manager *SVpcPeeringConnectionManager) PerformMesh(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.VpcPeeringMeshInput) (*api.VpcTopology, error) {
+	if len(input.VpcIds) < 2 {
+		return nil, httperrors.NewInputParameterError("at least 2 vpcs are required")
+	}
+	vpcs, err := fetchTopologyVpcs(userCred, input.VpcIds)
+	if err != nil {
+		return nil, err
+	}
+	planner, err := newVpcTopologyPlanner(vpcs, input.Bandwidth)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	ret, err := planner.getTopology(true)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if input.DryRun {
+		return ret, nil
+	}
+
+	vpcMap := map[string]*SVpc{}
+	for i := range vpcs {
+		vpcMap[vpcs[i].Id] = &vpcs[i]
+	}
+	for vpcId, network := range planner.pendingJoins {
+		err := network.StartInterVpcNetworkAddVpcTask(ctx, userCred, vpcMap[vpcId])
+		if err != nil {
+			for i := range ret.Links {
+				link := &ret.Links[i]
+				if link.ResourceId == network.Id && (link.VpcId == vpcId || link.PeerVpcId == vpcId) {
+					link.Action = api.VPC_TOPOLOGY_ACTION_FAILED
+					link.Reason = err.Error()
+				}
+			}
+		}
+	}
+	for i := range ret.Links {
+		link := &ret.Links[i]
+		if link.Type != api.VPC_TOPOLOGY_LINK_PEERING || link.Action != api.VPC_TOPOLOGY_ACTION_CREATE {
+			continue
+		}
+		peering, err := manager.createMeshPeering(ctx, userCred, query, vpcMap[link.VpcId], vpcMap[link.PeerVpcId], input.Bandwidth)
+		if err != nil {
+			link.Action = api.VPC_TOPOLOGY_ACTION_FAILED
+			link.Reason = err.Error()
+			continue
+		}
+		link.ResourceId = peering.Id
+		link.Status = peering.Status
+	}
+	return ret, nil
+}
+
+func (manager *SVpcPeeringConnectionManager) createMeshPeering(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, vpc, peerVpc *SVpc, bandwidth int) (*SVpcPeeringConnection, error) {
+	input := api.VpcPeeringConnectionCreateInput{}
+	input.GenerateName = fmt.Sprintf("%s-%s", vpc.Name, peerVpc.Name)
+	input.VpcId = vpc.Id
+	input.PeerVpcId = peerVpc.Id
+	if vpc.CloudregionId != peerVpc.CloudregionId {
+		input.Bandwidth = bandwidth
+	}
+	data := jsonutils.Marshal(input)
+	model, err := db.DoCreate(manager, ctx, userCred, query, data, vpc.GetOwnerId())
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.DoCreate")
+	}
+	func() {
+		lockman.LockObject(ctx, model)
+		defer lockman.ReleaseObject(ctx, model)
+
+		model.PostCreate(ctx, userCred, vpc.GetOwnerId(), query, data)
+	}()
+	db.OpsLog.LogEvent(model, db.ACT_CREATE, model.GetShortDesc(ctx), userCred)
+	logclient.AddActionLogWithContext(ctx, model, logclient.ACT_CREATE, "", userCred, true)
+	return model.(*SVpcPeeringConnection), nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func newTestVpc(id string) SVpc {
+	vpc := SVpc{}
+	vpc.Id = id
+	vpc.Name = id
+	return vpc
+}
+
+func newTestInterVpcNetwork(id string) *SInterVpcNetwork {
+	network := &SInterVpcNetwork{}
+	network.Id = id
+	network.Name = id
+	network.Status = api.INTER_VPC_NETWORK_STATUS_AVAILABLE
+	return network
+}
+
+func newTestVpcTopologyPlanner(vpcs ...SVpc) *sVpcTopologyPlanner {
+	return &sVpcTopologyPlanner{
+		vpcs:         vpcs,
+		peerings:     map[string]*SVpcPeeringConnection{},
+		interVpcs:    map[string]*SInterVpcNetwork{},
+		pendingJoins: map[string]*SInterVpcNetwork{},
+	}
+}
+
+func TestVpcTopologyPlannerExistingLinks(t *testing.T) {
+	vpc1, vpc2, vpc3 := newTestVpc("vpc1"), newTestVpc("vpc2"), newTestVpc("vpc3")
+	planner := newTestVpcTopologyPlanner(vpc1, vpc2, vpc3)
+	peering := &SVpcPeeringConnection{PeerVpcId: "vpc2"}
+	peering.VpcId = "vpc1"
+	peering.Id = "peering"
+	peering.Status = api.VPC_PEERING_CONNECTION_STATUS_ACTIVE
+	planner.peerings["vpc1-vpc2"] = peering
+	network := newTestInterVpcNetwork("network")
+	planner.interVpcs["vpc2"] = network
+	planner.interVpcs["vpc3"] = network
+
+	for _, pair := range [][2]*SVpc{{&vpc1, &vpc2}, {&vpc2, &vpc1}} {
+		link, err := planner.planLink(pair[0], pair[1], false)
+		if err != nil || link == nil {
+			t.Fatalf("%s-%s: want existing peering, got %v %v", pair[0].Id, pair[1].Id, link, err)
+		}
+		if link.Type != api.VPC_TOPOLOGY_LINK_PEERING || link.ResourceId != "peering" || link.Action != api.VPC_TOPOLOGY_ACTION_EXISTING {
+			t.Errorf("%s-%s: unexpected link %+v", pair[0].Id, pair[1].Id, link)
+		}
+	}
+
+	link, err := planner.planLink(&vpc2, &vpc3, false)
+	if err != nil || link == nil || link.Type != api.VPC_TOPOLOGY_LINK_INTER_VPC_NETWORK || link.Action != api.VPC_TOPOLOGY_ACTION_EXISTING {
+		t.Errorf("vpc2-vpc3: want existing inter vpc network, got %+v %v", link, err)
+	}
+
+	// 拓扑查询不规划新的互联
+	link, err = planner.planLink(&vpc1, &vpc3, false)
+	if err != nil || link != nil {
+		t.Errorf("vpc1-vpc3: want no link without plan, got %+v %v", link, err)
+	}
+}
+
+func TestVpcTopologyPlannerPlanInterVpcNetwork(t *testing.T) {
+	vpc1, vpc2, vpc3 := newTestVpc("vpc1"), newTestVpc("vpc2"), newTestVpc("vpc3")
+	planner := newTestVpcTopologyPlanner(vpc1, vpc2, vpc3)
+	network := newTestInterVpcNetwork("network")
+	planner.interVpcs["vpc1"] = network
+
+	link := &api.VpcTopologyLink{VpcId: "vpc1", PeerVpcId: "vpc2"}
+	err := planner.planInterVpcNetwork(&vpc1, &vpc2, nil, link)
+	if err != nil {
+		t.Fatalf("planInterVpcNetwork: %v", err)
+	}
+	if link.Type != api.VPC_TOPOLOGY_LINK_INTER_VPC_NETWORK || link.ResourceId != "network" || link.Action != api.VPC_TOPOLOGY_ACTION_CREATE {
+		t.Errorf("unexpected link %+v", link)
+	}
+	if planner.pendingJoins["vpc2"] != network {
+		t.Errorf("vpc2 should be planned to join network")
+	}
+	if _, ok := planner.pendingJoins["vpc1"]; ok {
+		t.Errorf("vpc1 already joined network and should not be pending")
+	}
+
+	// 计划加入后, 同一网络内的其他vpc对复用该网络
+	planner.interVpcs["vpc3"] = network
+	link2, err := planner.planLink(&vpc2, &vpc3, false)
+	if err != nil || link2 == nil || link2.Action != api.VPC_TOPOLOGY_ACTION_CREATE {
+		t.Errorf("vpc2-vpc3: want pending inter vpc network link, got %+v %v", link2, err)
+	}
+
+	other := newTestInterVpcNetwork("other")
+	planner.interVpcs["vpc3"] = other
+	link3 := &api.VpcTopologyLink{VpcId: "vpc1", PeerVpcId: "vpc3"}
+	err = planner.planInterVpcNetwork(&vpc1, &vpc3, nil, link3)
+	if err != nil {
+		t.Fatalf("planInterVpcNetwork: %v", err)
+	}
+	if link3.Type != api.VPC_TOPOLOGY_LINK_UNREACHABLE || len(link3.Reason) == 0 {
+		t.Errorf("vpcs in different inter vpc networks should be unreachable, got %+v", link3)
+	}
+}
@@ -803,11 +803,27 @@ func (manager *SVpcManager) ValidateCreateData(
 		return input, err
 	}
 
+	globalvpcId := input.GlobalvpcId
+	if len(globalvpcId) > 0 {
+		input, err = validateVpcGlobalVpcAddressPlan(userCred, input)
+		if err != nil {
+			return input, err
+		}
+	}
+
 	input, err = region.GetDriver().ValidateCreateVpcData(ctx, userCred, input)
 	if err != nil {
 		return input, err
 	}
 
+	// 驱动自动选择了全局vpc时需按其地址规划校验网段
+	if len(globalvpcId) == 0 && len(input.GlobalvpcId) > 0 {
+		input, err = validateVpcGlobalVpcAddressPlan(userCred, input)
+		if err != nil {
+			return input, err
+		}
+	}
+
 	if region.GetDriver().IsVpcCreateNeedInputCidr() && len(input.CidrBlock) == 0 {
 		return input, httperrors.NewMissingParameterError("cidr")
 	}
@@ -0,0 +1,510 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/stringutils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-model-singular=waf_policy_template
+// +onecloud:swagger-gen-model-plural=waf_policy_templates
+type SWafPolicyTemplateManager struct {
+	db.SSharableVirtualResourceBaseManager
+}
+
+var WafPolicyTemplateManager *SWafPolicyTemplateManager
+
+func init() {
+	WafPolicyTemplateManager = &SWafPolicyTemplateManager{
+		SSharableVirtualResourceBaseManager: db.NewSharableVirtualResourceBaseManager(
+			SWafPolicyTemplate{},
+			"waf_policy_templates_tbl",
+			"waf_policy_template",
+			"waf_policy_templates",
+		),
+	}
+	WafPolicyTemplateManager.SetVirtualObject(WafPolicyTemplateManager)
+
+	WafPolicyTemplateInstanceManager = &SWafPolicyTemplateInstanceManager{
+		SResourceBaseManager: db.NewResourceBaseManager(
+			SWafPolicyTemplateInstance{},
+			"waf_policy_template_instances_tbl",
+			"waf_policy_template_instance",
+			"waf_policy_template_instances",
+		),
+	}
+	WafPolicyTemplateInstanceManager.SetVirtualObject(WafPolicyTemplateInstanceManager)
+}
+
+// WAF策略模板, 以与平台无关的规则描述, 应用时在各平台WAF实例上创建对应规则
+type SWafPolicyTemplate struct {
+	db.SSharableVirtualResourceBase
+
+	// 与平台无关的规则列表
+	Rules *api.WafPolicyTemplateRules `nullable:"true" list:"user" update:"user" create:"required"`
+}
+
+type SWafPolicyTemplateInstanceManager struct {
+	db.SResourceBaseManager
+}
+
+var WafPolicyTemplateInstanceManager *SWafPolicyTemplateInstanceManager
+
+// 模板在WAF实例上的应用记录
+type SWafPolicyTemplateInstance struct {
+	db.SResourceBase
+
+	Id                  string `width:"128" charset:"ascii" primary:"true" list:"user"`
+	WafPolicyTemplateId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	WafInstanceId       string `width:"36" charset:"ascii" nullable:"false" list:"user"`
+	Status              string `width:"36" charset:"ascii" nullable:"false" list:"user"`
+	// 应用失败原因
+	Reason string `charset:"utf8" nullable:"true" list:"user"`
+	// 最近一次成功应用的时间
+	AppliedAt time.Time `nullable:"true" list:"user"`
+}
+
+func (self *SWafPolicyTemplateInstance) BeforeInsert() {
+	if len(self.Id) == 0 {
+		self.Id = stringutils.UUID4()
+	}
+}
+
+func (self *SWafPolicyTemplateInstance) GetId() string {
+	return self.Id
+}
+
+func validateWafPolicyTemplateRules(rules api.WafPolicyTemplateRules) error {
+	if len(rules) == 0 {
+		return httperrors.NewMissingParameterError("rules")
+	}
+	names, priorities := map[string]bool{}, map[int]bool{}
+	for i, rule := range rules {
+		if len(rule.Name) == 0 {
+			return httperrors.NewInputParameterError("rule %d: missing name", i)
+		}
+		if names[rule.Name] {
+			return httperrors.NewInputParameterError("rule %d: duplicate name %s", i, rule.Name)
+		}
+		names[rule.Name] = true
+		if priorities[rule.Priority] {
+			return httperrors.NewInputParameterError("rule %s: duplicate priority %d", rule.Name, rule.Priority)
+		}
+		priorities[rule.Priority] = true
+		if rule.Action == nil || len(rule.Action.Action) == 0 {
+			return httperrors.NewInputParameterError("rule %s: missing action", rule.Name)
+		}
+		if len(rule.Statements) == 0 {
+			return httperrors.NewInputParameterError("rule %s: missing statements", rule.Name)
+		}
+		if len(rule.Statements) > 1 && rule.StatementCondition == cloudprovider.WafStatementConditionNone {
+			return httperrors.NewInputParameterError("rule %s: statement_condition is required for multiple statements", rule.Name)
+		}
+	}
+	return nil
+}
+
+// 模板规则在WAF实例上的规则名称
+func getWafPolicyTemplateRuleName(template, rule string) string {
+	return fmt.Sprintf("%s-%s", template, rule)
+}
+
+func (self *SWafPolicyTemplate) getRules() api.WafPolicyTemplateRules {
+	if self.Rules == nil {
+		return api.WafPolicyTemplateRules{}
+	}
+	return *self.Rules
+}
+
+func (manager *SWafPolicyTemplateManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.WafPolicyTemplateCreateInput,
+) (api.WafPolicyTemplateCreateInput, error) {
+	err := validateWafPolicyTemplateRules(input.Rules)
+	if err != nil {
+		return input, err
+	}
+	input.SharableVirtualResourceCreateInput, err = manager.SSharableVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.SharableVirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	input.Status = api.WAF_POLICY_TEMPLATE_STATUS_AVAILABLE
+	return input, nil
+}
+
+func (self *SWafPolicyTemplate) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.WafPolicyTemplateUpdateInput,
+) (api.WafPolicyTemplateUpdateInput, error) {
+	if input.Rules != nil {
+		err := validateWafPolicyTemplateRules(input.Rules)
+		if err != nil {
+			return input, err
+		}
+	}
+	var err error
+	input.SharableVirtualResourceBaseUpdateInput, err = self.SSharableVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.SharableVirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+// 规则变更后已应用的实例标记为 outdated
+func (self *SWafPolicyTemplate) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SSharableVirtualResourceBase.PostUpdate(ctx, userCred, query, data)
+
+	if !data.Contains("rules") {
+		return
+	}
+	instances, err := self.GetTemplateInstances()
+	if err != nil {
+		return
+	}
+	for i := range instances {
+		if instances[i].Status != api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLIED {
+			continue
+		}
+		db.Update(&instances[i], func() error {
+			instances[i].Status = api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_OUTDATED
+			return nil
+		})
+	}
+}
+
+func (self *SWafPolicyTemplate) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := self.GetTemplateInstanceQuery().In("status", []string{
+		api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLYING,
+		api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLIED,
+		api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_OUTDATED,
+	}).CountWithError()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("waf policy template %s has been applied to %d waf instances", self.Name, cnt)
+	}
+	return self.SSharableVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (self *SWafPolicyTemplate) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	instances, err := self.GetTemplateInstances()
+	if err != nil {
+		return errors.Wrapf(err, "GetTemplateInstances")
+	}
+	for i := range instances {
+		err = instances[i].Delete(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "Delete template instance %s", instances[i].Id)
+		}
+	}
+	return self.SSharableVirtualResourceBase.Delete(ctx, userCred)
+}
+
+func (self *SWafPolicyTemplate) GetTemplateInstanceQuery() *sqlchemy.SQuery {
+	return WafPolicyTemplateInstanceManager.Query().Equals("waf_policy_template_id", self.Id)
+}
+
+func (self *SWafPolicyTemplate) GetTemplateInstances() ([]SWafPolicyTemplateInstance, error) {
+	instances := []SWafPolicyTemplateInstance{}
+	err := db.FetchModelObjects(WafPolicyTemplateInstanceManager, self.GetTemplateInstanceQuery(), &instances)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.FetchModelObjects")
+	}
+	return instances, nil
+}
+
+func (self *SWafPolicyTemplate) getTemplateInstance(ctx context.Context, wafId string) (*SWafPolicyTemplateInstance, error) {
+	instance := &SWafPolicyTemplateInstance{}
+	instance.SetModelManager(WafPolicyTemplateInstanceManager, instance)
+	err := self.GetTemplateInstanceQuery().Equals("waf_instance_id", wafId).First(instance)
+	if err == nil {
+		return instance, nil
+	}
+	if errors.Cause(err) != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "First")
+	}
+	instance.WafPolicyTemplateId = self.Id
+	instance.WafInstanceId = wafId
+	instance.Status = api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLYING
+	err = WafPolicyTemplateInstanceManager.TableSpec().Insert(ctx, instance)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Insert")
+	}
+	return instance, nil
+}
+
+func (self *SWafPolicyTemplateInstance) setStatus(status, reason string) error {
+	_, err := db.Update(self, func() error {
+		self.Status = status
+		self.Reason = reason
+		if status == api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLIED {
+			self.AppliedAt = time.Now()
+		}
+		return nil
+	})
+	return err
+}
+
+// 应用模板到WAF实例, 各实例的应用状态可通过 instances 查看
+func (self *SWafPolicyTemplate) PerformApply(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.WafPolicyTemplateApplyInput) (jsonutils.JSONObject, error) {
+	if len(input.WafInstanceIds) == 0 {
+		return nil, httperrors.NewMissingParameterError("waf_instance_ids")
+	}
+	wafIds := []string{}
+	for i := range input.WafInstanceIds {
+		_waf, err := validators.ValidateModel(userCred, WafInstanceManager, &input.WafInstanceIds[i])
+		if err != nil {
+			return nil, err
+		}
+		waf := _waf.(*SWafInstance)
+		if waf.Status != api.WAF_STATUS_AVAILABLE {
+			return nil, httperrors.NewInvalidStatusError("waf %s status is not available", waf.Name)
+		}
+		region, err := waf.GetRegion()
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrapf(err, "GetRegion"))
+		}
+		err = region.GetDriver().ValidateApplyWafPolicyTemplate(ctx, userCred, waf, self.getRules())
+		if err != nil {
+			return nil, err
+		}
+		wafIds = append(wafIds, waf.Id)
+	}
+
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	q := self.GetTemplateInstanceQuery().In("waf_instance_id", wafIds).Equals("status", api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLYING)
+	cnt, err := q.CountWithError()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return nil, httperrors.NewInvalidStatusError("waf policy template %s is applying", self.Name)
+	}
+	for _, wafId := range wafIds {
+		instance, err := self.getTemplateInstance(ctx, wafId)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		err = instance.setStatus(api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLYING, "")
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+	}
+	params := jsonutils.NewDict()
+	params.Set("waf_instance_ids", jsonutils.NewStringArray(wafIds))
+	return nil, self.StartApplyTask(ctx, userCred, params, "")
+}
+
+func (self *SWafPolicyTemplate) StartApplyTask(ctx context.Context, userCred mcclient.TokenCredential, params *jsonutils.JSONDict, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "WafPolicyTemplateApplyTask", self, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask")
+	}
+	return task.ScheduleRun(nil)
+}
+
+// 在WAF实例上创建或更新模板中的规则, 已存在的同名规则会被更新
+func (self *SWafPolicyTemplate) applyToWafInstance(ctx context.Context, userCred mcclient.TokenCredential, waf *SWafInstance) error {
+	iWaf, err := waf.GetICloudWafInstance(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "GetICloudWafInstance")
+	}
+	rules, err := waf.GetWafRules()
+	if err != nil {
+		return errors.Wrapf(err, "GetWafRules")
+	}
+	exists := map[string]*SWafRule{}
+	for i := range rules {
+		exists[rules[i].Name] = &rules[i]
+	}
+	for _, rule := range self.getRules() {
+		opts := &cloudprovider.SWafRule{
+			Name:               getWafPolicyTemplateRuleName(self.Name, rule.Name),
+			Desc:               rule.Description,
+			Action:             rule.Action,
+			StatementCondition: rule.StatementCondition,
+			Priority:           rule.Priority,
+			Statements:         rule.Statements,
+		}
+		if local, ok := exists[opts.Name]; ok {
+			iRule, err := local.GetICloudWafRule(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "GetICloudWafRule(%s)", local.Name)
+			}
+			err = iRule.Update(opts)
+			if err != nil {
+				return errors.Wrapf(err, "Update rule %s", opts.Name)
+			}
+			err = local.SyncWithCloudRule(ctx, userCred, iRule)
+			if err != nil {
+				return errors.Wrapf(err, "SyncWithCloudRule(%s)", local.Name)
+			}
+			continue
+		}
+		iRule, err := iWaf.AddRule(opts)
+		if err != nil {
+			return errors.Wrapf(err, "AddRule %s", opts.Name)
+		}
+		err = waf.newFromCloudRule(ctx, userCred, iRule)
+		if err != nil {
+			return errors.Wrapf(err, "newFromCloudRule(%s)", opts.Name)
+		}
+	}
+	return nil
+}
+
+// 由 WafPolicyTemplateApplyTask 调用, 记录模板在实例上的应用结果
+func (self *SWafPolicyTemplate) ApplyToWafInstance(ctx context.Context, userCred mcclient.TokenCredential, wafId string) error {
+	instance, err := self.getTemplateInstance(ctx, wafId)
+	if err != nil {
+		return errors.Wrapf(err, "getTemplateInstance")
+	}
+	err = func() error {
+		_waf, err := WafInstanceManager.FetchById(wafId)
+		if err != nil {
+			return errors.Wrapf(err, "WafInstanceManager.FetchById(%s)", wafId)
+		}
+		return self.applyToWafInstance(ctx, userCred, _waf.(*SWafInstance))
+	}()
+	if err != nil {
+		instance.setStatus(api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLY_FAILED, err.Error())
+		return err
+	}
+	return instance.setStatus(api.WAF_POLICY_TEMPLATE_INSTANCE_STATUS_APPLIED, "")
+}
+
+// 模板在各WAF实例上的应用状态
+func (self *SWafPolicyTemplate) GetDetailsInstances(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) ([]api.WafPolicyTemplateInstanceStatus, error) {
+	instances, err := self.GetTemplateInstances()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	ret := []api.WafPolicyTemplateInstanceStatus{}
+	for i := range instances {
+		status := api.WafPolicyTemplateInstanceStatus{
+			WafInstanceId: instances[i].WafInstanceId,
+			Status:        instances[i].Status,
+			Reason:        instances[i].Reason,
+			AppliedAt:     instances[i].AppliedAt,
+		}
+		waf, err := WafInstanceManager.FetchById(instances[i].WafInstanceId)
+		if err == nil {
+			status.WafInstance = waf.GetName()
+			status.Provider = waf.(*SWafInstance).GetProviderName()
+		}
+		ret = append(ret, status)
+	}
+	return ret, nil
+}
+
+// WAF策略模板列表
+func (manager *SWafPolicyTemplateManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.WafPolicyTemplateListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, input.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.ListItemFilter")
+	}
+	return q, nil
+}
+
+func (manager *SWafPolicyTemplateManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.WafPolicyTemplateListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.SharableVirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SSharableVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SWafPolicyTemplateManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SSharableVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SWafPolicyTemplateManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.WafPolicyTemplateDetails {
+	rows := make([]api.WafPolicyTemplateDetails, len(objs))
+	virtRows := manager.SSharableVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	templateIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.WafPolicyTemplateDetails{
+			SharableVirtualResourceDetails: virtRows[i],
+		}
+		templateIds[i] = objs[i].(*SWafPolicyTemplate).Id
+	}
+
+	q := WafPolicyTemplateInstanceManager.Query().In("waf_policy_template_id", templateIds)
+	q = q.AppendField(q.Field("waf_policy_template_id"), sqlchemy.COUNT("waf_instance_count")).GroupBy(q.Field("waf_policy_template_id"))
+	counts := []struct {
+		WafPolicyTemplateId string
+		WafInstanceCount    int
+	}{}
+	err := q.All(&counts)
+	if err != nil {
+		return rows
+	}
+	countMap := map[string]int{}
+	for _, cnt := range counts {
+		countMap[cnt.WafPolicyTemplateId] = cnt.WafInstanceCount
+	}
+	for i := range rows {
+		rows[i].WafInstanceCount = countMap[templateIds[i]]
+	}
+	return rows
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestValidateWafPolicyTemplateRules(t *testing.T) {
+	block := &cloudprovider.DefaultAction{Action: cloudprovider.WafActionBlock}
+	sqli := []cloudprovider.SWafStatement{{Type: cloudprovider.WafStatementTypeSqliMatch}}
+	two := []cloudprovider.SWafStatement{{Type: cloudprovider.WafStatementTypeSqliMatch}, {Type: cloudprovider.WafStatementTypeXssMatch}}
+	cases := []struct {
+		name  string
+		rules api.WafPolicyTemplateRules
+		ok    bool
+	}{
+		{"empty", nil, false},
+		{"valid", api.WafPolicyTemplateRules{{Name: "a", Priority: 1, Action: block, Statements: sqli}}, true},
+		{"missing name", api.WafPolicyTemplateRules{{Priority: 1, Action: block, Statements: sqli}}, false},
+		{"duplicate name", api.WafPolicyTemplateRules{{Name: "a", Priority: 1, Action: block, Statements: sqli}, {Name: "a", Priority: 2, Action: block, Statements: sqli}}, false},
+		{"duplicate priority", api.WafPolicyTemplateRules{{Name: "a", Priority: 1, Action: block, Statements: sqli}, {Name: "b", Priority: 1, Action: block, Statements: sqli}}, false},
+		{"missing action", api.WafPolicyTemplateRules{{Name: "a", Priority: 1, Statements: sqli}}, false},
+		{"missing statements", api.WafPolicyTemplateRules{{Name: "a", Priority: 1, Action: block}}, false},
+		{"missing condition", api.WafPolicyTemplateRules{{Name: "a", Priority: 1, Action: block, Statements: two}}, false},
+		{"with condition", api.WafPolicyTemplateRules{{Name: "a", Priority: 1, Action: block, Statements: two, StatementCondition: cloudprovider.WafStatementConditionOr}}, true},
+	}
+	for _, c := range cases {
+		err := validateWafPolicyTemplateRules(c.rules)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v got %v", c.name, c.ok, err)
+		}
+	}
+}
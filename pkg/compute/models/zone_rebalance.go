@@ -0,0 +1,289 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type sRebalanceGuest struct {
+	id        string
+	name      string
+	vcpuCount int
+	vmemSize  int
+	live      bool
+}
+
+type sRebalanceHost struct {
+	id        string
+	name      string
+	managerId string
+
+	vcpuCapacity float64
+	vmemCapacity float64
+	vcpuCount    int
+	vmemSize     int
+
+	// 可迁移的虚拟机
+	guests []sRebalanceGuest
+}
+
+func (h *sRebalanceHost) pressure(vcpuCount, vmemSize int) (float64, float64) {
+	return float64(vmemSize) / h.vmemCapacity, float64(vcpuCount) / h.vcpuCapacity
+}
+
+// 宿主机负载取内存和CPU分配率中的较大值
+func (h *sRebalanceHost) loadWith(vcpuCount, vmemSize int) float64 {
+	mem, cpu := h.pressure(h.vcpuCount+vcpuCount, h.vmemSize+vmemSize)
+	return math.Max(mem, cpu)
+}
+
+func (h *sRebalanceHost) load() float64 {
+	return h.loadWith(0, 0)
+}
+
+type sRebalanceMove struct {
+	guest  sRebalanceGuest
+	source *sRebalanceHost
+	target *sRebalanceHost
+}
+
+// 贪心生成迁移计划: 每次从负载最高的宿主机选一台虚拟机迁移到同云订阅下负载较低的宿主机,
+// 要求迁移后两台宿主机中的较高负载低于迁移前源宿主机的负载, 且目标宿主机不超分配
+func planZoneRebalance(hosts []*sRebalanceHost, threshold float64, maxMigrations int) []sRebalanceMove {
+	moves := []sRebalanceMove{}
+	for len(moves) < maxMigrations && len(hosts) > 1 {
+		sort.SliceStable(hosts, func(i, j int) bool {
+			return hosts[i].load() < hosts[j].load()
+		})
+		source := hosts[len(hosts)-1]
+		if source.load()-hosts[0].load() <= threshold {
+			break
+		}
+		var move *sRebalanceMove
+		for _, target := range hosts[:len(hosts)-1] {
+			if target.managerId != source.managerId {
+				continue
+			}
+			best, bestPeak := -1, source.load()
+			for i, guest := range source.guests {
+				mem, cpu := target.pressure(target.vcpuCount+guest.vcpuCount, target.vmemSize+guest.vmemSize)
+				if mem > 1 || cpu > 1 {
+					continue
+				}
+				peak := math.Max(source.loadWith(-guest.vcpuCount, -guest.vmemSize), target.loadWith(guest.vcpuCount, guest.vmemSize))
+				if peak < bestPeak {
+					best, bestPeak = i, peak
+				}
+			}
+			if best >= 0 {
+				move = &sRebalanceMove{guest: source.guests[best], source: source, target: target}
+				source.guests = append(source.guests[:best], source.guests[best+1:]...)
+				break
+			}
+		}
+		if move == nil {
+			break
+		}
+		move.source.vcpuCount -= move.guest.vcpuCount
+		move.source.vmemSize -= move.guest.vmemSize
+		move.target.vcpuCount += move.guest.vcpuCount
+		move.target.vmemSize += move.guest.vmemSize
+		moves = append(moves, *move)
+	}
+	return moves
+}
+
+func rebalanceSkew(hosts []*sRebalanceHost) float64 {
+	if len(hosts) == 0 {
+		return 0
+	}
+	min, max := hosts[0].load(), hosts[0].load()
+	for _, h := range hosts[1:] {
+		min = math.Min(min, h.load())
+		max = math.Max(max, h.load())
+	}
+	return max - min
+}
+
+func (zone *SZone) isPrivateCloudZone() bool {
+	region, err := zone.GetRegion()
+	if err != nil {
+		return false
+	}
+	return region.Provider == api.CLOUD_PROVIDER_ONECLOUD || utils.IsInStringArray(region.Provider, api.PRIVATE_CLOUD_PROVIDERS)
+}
+
+func (guest *SGuest) isRebalanceMigratable() bool {
+	if guest.Status != api.VM_RUNNING && guest.Status != api.VM_READY {
+		return false
+	}
+	if len(guest.BackupHostId) > 0 {
+		return false
+	}
+	driver := guest.GetDriver()
+	if !driver.IsSupportMigrate() || (guest.Status == api.VM_RUNNING && !driver.IsSupportLiveMigrate()) {
+		return false
+	}
+	devs, _ := guest.GetIsolatedDevices()
+	return len(devs) == 0
+}
+
+func (zone *SZone) getRebalanceHosts() ([]*sRebalanceHost, error) {
+	q := HostManager.Query().Equals("zone_id", zone.Id).IsTrue("enabled").Equals("host_status", api.HOST_ONLINE)
+	q = q.NotEquals("host_type", api.HOST_TYPE_BAREMETAL)
+	q = q.NotIn("status", []string{api.BAREMETAL_START_MAINTAIN, api.BAREMETAL_MAINTAINING, api.BAREMETAL_MAINTAIN_FAIL})
+	hosts := []SHost{}
+	err := db.FetchModelObjects(HostManager, q, &hosts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchModelObjects")
+	}
+	ret := []*sRebalanceHost{}
+	for i := range hosts {
+		host := &sRebalanceHost{
+			id:           hosts[i].Id,
+			name:         hosts[i].Name,
+			managerId:    hosts[i].ManagerId,
+			vcpuCapacity: float64(hosts[i].GetVirtualCPUCount()),
+			vmemCapacity: float64(hosts[i].GetVirtualMemorySize()),
+		}
+		if host.vcpuCapacity <= 0 || host.vmemCapacity <= 0 {
+			continue
+		}
+		guests, err := hosts[i].GetGuests()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetGuests of host %s", hosts[i].Name)
+		}
+		for j := range guests {
+			host.vcpuCount += guests[j].VcpuCount
+			host.vmemSize += guests[j].VmemSize
+			if !guests[j].isRebalanceMigratable() {
+				continue
+			}
+			host.guests = append(host.guests, sRebalanceGuest{
+				id:        guests[j].Id,
+				name:      guests[j].Name,
+				vcpuCount: guests[j].VcpuCount,
+				vmemSize:  guests[j].VmemSize,
+				live:      guests[j].Status == api.VM_RUNNING,
+			})
+		}
+		ret = append(ret, host)
+	}
+	return ret, nil
+}
+
+func (zone *SZone) getRebalancePlan(input api.ZoneRebalancePlanInput) (*api.ZoneRebalancePlanOutput, error) {
+	if !zone.isPrivateCloudZone() {
+		return nil, httperrors.NewUnsupportOperationError("zone %s is not a private cloud zone", zone.Name)
+	}
+	if input.Threshold < 0 || input.Threshold >= 1 {
+		return nil, httperrors.NewInputParameterError("threshold must be in range [0, 1)")
+	}
+	if input.Threshold == 0 {
+		input.Threshold = api.ZONE_REBALANCE_DEFAULT_THRESHOLD
+	}
+	if input.MaxMigrations < 0 {
+		return nil, httperrors.NewInputParameterError("max_migrations must not be negative")
+	}
+	if input.MaxMigrations == 0 {
+		input.MaxMigrations = api.ZONE_REBALANCE_DEFAULT_MAX_MIGRATIONS
+	}
+	hosts, err := zone.getRebalanceHosts()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	ret := &api.ZoneRebalancePlanOutput{
+		Hosts:      []api.ZoneRebalanceHost{},
+		Migrations: []api.ZoneRebalanceMigration{},
+		SkewBefore: rebalanceSkew(hosts),
+	}
+	current := map[string]api.ZoneRebalanceHost{}
+	for _, h := range hosts {
+		mem, cpu := h.pressure(h.vcpuCount, h.vmemSize)
+		current[h.id] = api.ZoneRebalanceHost{Id: h.id, Name: h.name, MemPressure: mem, CpuPressure: cpu}
+	}
+	moves := planZoneRebalance(hosts, input.Threshold, input.MaxMigrations)
+	for _, move := range moves {
+		ret.Migrations = append(ret.Migrations, api.ZoneRebalanceMigration{
+			GuestId:      move.guest.id,
+			Guest:        move.guest.name,
+			SourceHostId: move.source.id,
+			SourceHost:   move.source.name,
+			TargetHostId: move.target.id,
+			TargetHost:   move.target.name,
+			VcpuCount:    move.guest.vcpuCount,
+			VmemSizeMb:   move.guest.vmemSize,
+			LiveMigrate:  move.guest.live,
+			Status:       api.ZONE_REBALANCE_MIGRATION_PENDING,
+		})
+	}
+	for _, h := range hosts {
+		host := current[h.id]
+		host.PlannedMemPressure, host.PlannedCpuPressure = h.pressure(h.vcpuCount, h.vmemSize)
+		ret.Hosts = append(ret.Hosts, host)
+	}
+	ret.SkewAfter = rebalanceSkew(hosts)
+	return ret, nil
+}
+
+// 分析可用区内宿主机的内存/CPU分配率偏差, 给出虚拟机迁移计划
+func (zone *SZone) GetDetailsRebalancePlan(ctx context.Context, userCred mcclient.TokenCredential, query api.ZoneRebalancePlanInput) (*api.ZoneRebalancePlanOutput, error) {
+	return zone.getRebalancePlan(query)
+}
+
+// 按迁移计划批量迁移虚拟机, 平衡可用区内宿主机负载
+func (zone *SZone) PerformRebalance(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ZoneRebalanceInput) (*api.ZoneRebalancePlanOutput, error) {
+	if input.Concurrency < 0 {
+		return nil, httperrors.NewInputParameterError("concurrency must not be negative")
+	}
+	if input.Concurrency == 0 {
+		input.Concurrency = api.ZONE_REBALANCE_DEFAULT_CONCURRENCY
+	}
+	if taskman.TaskManager.IsInTask(zone) {
+		return nil, httperrors.NewConflictError("zone %s is rebalancing", zone.Name)
+	}
+	plan, err := zone.getRebalancePlan(input.ZoneRebalancePlanInput)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Migrations) == 0 {
+		return plan, nil
+	}
+	params := jsonutils.NewDict()
+	params.Set("migrations", jsonutils.Marshal(plan.Migrations))
+	params.Set("concurrency", jsonutils.NewInt(int64(input.Concurrency)))
+	return plan, zone.StartRebalanceTask(ctx, userCred, params, "")
+}
+
+func (zone *SZone) StartRebalanceTask(ctx context.Context, userCred mcclient.TokenCredential, params *jsonutils.JSONDict, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "ZoneRebalanceTask", zone, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask")
+	}
+	return task.ScheduleRun(nil)
+}
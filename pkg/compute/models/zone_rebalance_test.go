@@ -0,0 +1,108 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestPlanZoneRebalance(t *testing.T) {
+	newHost := func(id, managerId string, guests ...sRebalanceGuest) *sRebalanceHost {
+		h := &sRebalanceHost{id: id, managerId: managerId, vcpuCapacity: 16, vmemCapacity: 16384, guests: guests}
+		for _, g := range guests {
+			h.vcpuCount += g.vcpuCount
+			h.vmemSize += g.vmemSize
+		}
+		return h
+	}
+	guest := func(id string, vcpu, vmem int) sRebalanceGuest {
+		return sRebalanceGuest{id: id, vcpuCount: vcpu, vmemSize: vmem}
+	}
+	cases := []struct {
+		name          string
+		hosts         []*sRebalanceHost
+		threshold     float64
+		maxMigrations int
+		want          []string
+	}{
+		{
+			name: "balanced",
+			hosts: []*sRebalanceHost{
+				newHost("h1", "", guest("g1", 4, 4096)),
+				newHost("h2", "", guest("g2", 4, 4096)),
+			},
+			threshold:     0.1,
+			maxMigrations: 10,
+			want:          []string{},
+		},
+		{
+			name: "move one guest to idle host",
+			hosts: []*sRebalanceHost{
+				newHost("h1", "", guest("g1", 4, 4096), guest("g2", 4, 4096)),
+				newHost("h2", ""),
+			},
+			threshold:     0.1,
+			maxMigrations: 10,
+			want:          []string{"g1:h1->h2"},
+		},
+		{
+			name: "respect max migrations",
+			hosts: []*sRebalanceHost{
+				newHost("h1", "", guest("g1", 2, 2048), guest("g2", 2, 2048), guest("g3", 2, 2048), guest("g4", 2, 2048)),
+				newHost("h2", ""),
+			},
+			threshold:     0.1,
+			maxMigrations: 1,
+			want:          []string{"g1:h1->h2"},
+		},
+		{
+			name: "different cloudprovider",
+			hosts: []*sRebalanceHost{
+				newHost("h1", "m1", guest("g1", 4, 4096), guest("g2", 4, 4096)),
+				newHost("h2", "m2"),
+			},
+			threshold:     0.1,
+			maxMigrations: 10,
+			want:          []string{},
+		},
+		{
+			name: "guest too large to improve",
+			hosts: []*sRebalanceHost{
+				newHost("h1", "", guest("g1", 12, 12288)),
+				newHost("h2", ""),
+			},
+			threshold:     0.1,
+			maxMigrations: 10,
+			want:          []string{},
+		},
+	}
+	for _, c := range cases {
+		moves := planZoneRebalance(c.hosts, c.threshold, c.maxMigrations)
+		got := []string{}
+		for _, m := range moves {
+			got = append(got, m.guest.id+":"+m.source.id+"->"+m.target.id)
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: want %v got %v", c.name, c.want, got)
+				break
+			}
+		}
+	}
+}
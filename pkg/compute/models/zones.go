@@ -227,13 +227,13 @@ func (manager *SZoneManager) SyncZones(ctx context.Context, userCred mcclient.To
 			syncResult.Update()
 		}
 	}
+	newZones, errs := manager.newFromCloudZones(ctx, userCred, added, region)
 	for i := 0; i < len(added); i += 1 {
-		new, err := manager.newFromCloudZone(ctx, userCred, added[i], region)
-		if err != nil {
-			syncResult.AddError(err)
+		if errs[i] != nil {
+			syncResult.AddError(errs[i])
 		} else {
-			syncMetadata(ctx, userCred, new, added[i])
-			localZones = append(localZones, *new)
+			syncMetadata(ctx, userCred, newZones[i], added[i])
+			localZones = append(localZones, *newZones[i])
 			remoteZones = append(remoteZones, added[i])
 			syncResult.Add()
 		}
@@ -277,40 +277,47 @@ func (self *SZone) syncWithCloudZone(ctx context.Context, userCred mcclient.Toke
 	return nil
 }
 
-func (manager *SZoneManager) newFromCloudZone(ctx context.Context, userCred mcclient.TokenCredential, extZone cloudprovider.ICloudZone, region *SCloudregion) (*SZone, error) {
-	zone := SZone{}
-	zone.SetModelManager(manager, &zone)
+func (manager *SZoneManager) newFromCloudZones(ctx context.Context, userCred mcclient.TokenCredential, extZones []cloudprovider.ICloudZone, region *SCloudregion) ([]*SZone, []error) {
+	zones := make([]*SZone, len(extZones))
+	objs := make([]db.IModel, len(extZones))
+	for i := range extZones {
+		zone := &SZone{}
+		zone.SetModelManager(manager, zone)
 
-	zone.Status = extZone.GetStatus()
-	zone.ExternalId = extZone.GetGlobalId()
+		zone.Status = extZones[i].GetStatus()
+		zone.ExternalId = extZones[i].GetGlobalId()
 
-	zone.IsEmulated = extZone.IsEmulated()
+		zone.IsEmulated = extZones[i].IsEmulated()
 
-	zone.CloudregionId = region.Id
+		zone.CloudregionId = region.Id
 
-	var err = func() error {
-		lockman.LockRawObject(ctx, manager.Keyword(), "name")
-		defer lockman.ReleaseRawObject(ctx, manager.Keyword(), "name")
+		zones[i] = zone
+		objs[i] = zone
+	}
 
-		newName, err := db.GenerateName(ctx, manager, userCred, extZone.GetName())
+	errs := make([]error, len(extZones))
+	insertSyncedModels(ctx, manager, objs, errs, func(i int, reserved map[string]bool) error {
+		newName, err := db.GenerateBatchName(ctx, manager, userCred, extZones[i].GetName(), reserved)
 		if err != nil {
 			return err
 		}
-		zone.Name = newName
+		zones[i].Name = newName
+		return nil
+	})
 
-		return manager.TableSpec().Insert(ctx, &zone)
-	}()
-	if err != nil {
-		return nil, errors.Wrapf(err, "Insert")
-	}
+	for i := range zones {
+		if errs[i] != nil {
+			continue
+		}
+		err := manager.SyncI18ns(ctx, userCred, zones[i], extZones[i].GetI18n())
+		if err != nil {
+			errs[i] = errors.Wrap(err, "SyncI18ns")
+			continue
+		}
 
-	err = manager.SyncI18ns(ctx, userCred, &zone, extZone.GetI18n())
-	if err != nil {
-		return nil, errors.Wrap(err, "SyncI18ns")
+		db.OpsLog.LogEvent(zones[i], db.ACT_CREATE, zones[i].GetShortDesc(ctx), userCred)
 	}
-
-	db.OpsLog.LogEvent(&zone, db.ACT_CREATE, zone.GetShortDesc(ctx), userCred)
-	return &zone, nil
+	return zones, errs
 }
 
 func (manager *SZoneManager) FetchZoneById(zoneId string) *SZone {
@@ -20,6 +20,7 @@ import (
 
 	common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
 	"yunion.io/x/onecloud/pkg/cloudcommon/pending_delete"
+	"yunion.io/x/onecloud/pkg/cloudcommon/secretstore"
 )
 
 type ComputeOptions struct {
@@ -140,6 +141,15 @@ type ComputeOptions struct {
 	CloudAutoSyncIntervalSeconds int `help:"frequency to check auto sync tasks" default:"30"`
 	DefaultSyncIntervalSeconds   int `help:"minimal synchronization interval, default 15 minutes" default:"900"`
 	MaxCloudAccountErrorCount    int `help:"maximal consecutive error count allow for a cloud account" default:"5"`
+	CloudSyncBatchSize           int `help:"maximal rows written by one insert statement when syncing new cloud resources, 1 means insert one by one" default:"100"`
+	CloudSyncVendorWorkerCount   int `help:"sync workers dedicated to each provider type, 0 means all provider types share the global sync workers" default:"2"`
+	CloudSyncVendorQueueSize     int `help:"maximal queued sync tasks of each provider type" default:"512"`
+
+	CloudApiRetryCount               int `help:"retry times of transient errors when connecting cloud provider or fetching its region before sync" default:"2"`
+	CloudApiRetryBackoffMilliseconds int `help:"initial backoff before retrying cloud provider API, doubled on each retry" default:"500"`
+	CloudApiCircuitFailureThreshold  int `help:"consecutive failed connections to a cloud provider to open its in-process circuit, 0 means never" default:"5"`
+	CloudApiCircuitCooldownSeconds   int `help:"seconds an opened circuit skips background syncs of the cloud provider" default:"300"`
+	CloudProviderClientCacheSeconds  int `help:"seconds to reuse an authenticated cloud provider client before authenticating again, 0 means no cache" default:"1800"`
 
 	NameSyncResources []string `help:"resources that need synchronization of name"`
 
@@ -164,6 +174,40 @@ type ComputeOptions struct {
 	SyncExtDiskSnapshotIntervalMinutes int  `help:"sync snapshot for external disk" default:"20"`
 	AutoReconcileBackupServers         bool `help:"auto reconcile backup servers" default:"false"`
 
+	ManagedHostHealthCheckIntervalSeconds int `help:"interval to check managed private cloud hosts status through provider api" default:"60"`
+	DnsFailoverProbeIntervalSeconds       int `help:"interval to probe endpoints of dns failover recordsets" default:"30"`
+	LbCertificateRenewBeforeDays          int `help:"renew loadbalancer certificates with renew hook in these days before expiration" default:"30"`
+
+	EnableSecgroupDriftCheck          bool `help:"periodically compare local secgroup rules with rules on cloud" default:"false"`
+	SecgroupDriftCheckIntervalMinutes int  `help:"interval to compare local secgroup rules with rules on cloud" default:"30"`
+	SecgroupDriftCheckBatchSize       int  `help:"max secgroup caches to check in each drift check round, least recently checked first" default:"50"`
+
+	BudgetCheckIntervalMinutes int `help:"interval to check budgets against current month spend" default:"60"`
+
+	QuotaReservationExpireCheckSeconds int `help:"interval to release expired quota reservations" default:"600"`
+
+	CloudaccountPermissionAuditIntervalHours int `help:"interval to audit permissions of cloud account credentials" default:"24"`
+
+	OperationApprovalExpireCheckSeconds int `help:"interval to expire pending operation approvals" default:"600"`
+
+	CommitmentRecommendMinUncovered int `help:"minimum number of uncovered on-demand instances to recommend purchasing reservations" default:"2"`
+
+	IdleResourceDetectIntervalHours int `help:"interval to detect idle eips, disks, loadbalancers and stopped guests" default:"24"`
+	IdleGuestStoppedDays            int `help:"guests stopped longer than these days are considered idle, 0 to disable" default:"30"`
+
+	StorageCapacitySampleIntervalHours int `help:"interval to sample storage capacity for forecasting" default:"6"`
+	StorageCapacitySampleRetentionDays int `help:"days to keep storage capacity samples" default:"90"`
+	StorageCapacityForecastAlertDays   int `help:"alert when storage capacity is forecasted to be exhausted within these days, 0 to disable" default:"30"`
+
+	StoragecacheGcIntervalHours int `help:"interval to garbage collect cached images of storagecaches with gc policy enabled" default:"6"`
+
+	GuestSoftwareInventoryIntervalHours int `help:"interval to collect os and installed updates of running servers" default:"24"`
+	GuestOsPatchOutdatedDays            int `help:"report server os as outdated when no updates installed within these days, 0 to disable" default:"90"`
+
+	GuestCommandMaxTimeoutSeconds int `help:"max timeout of commands executed in servers through qemu guest agent" default:"3600"`
+
+	EnableImageCatalog bool `help:"only allow non-admin users to create servers with images published in image catalogs" default:"false"`
+
 	SCapabilityOptions
 	SASControllerOptions
 	common_options.CommonOptions
@@ -200,6 +244,7 @@ type ComputeOptions struct {
 	MonitorEndpointType               string `help:"specify monitor endpoint type" default:"public"`
 
 	esxi.EsxiOptions
+	secretstore.SSecretStoreOptions
 }
 
 type SCapabilityOptions struct {
@@ -215,6 +260,8 @@ type SASControllerOptions struct {
 	ConcurrentUpper     int `help:"This represents the upper limit of concurrent sacling sctivities" default:"500"`
 	CheckScaleInterval  int `help:"The interval between the two checks about scaling, unit: s" default:"60"`
 	CheckHealthInterval int `help:"The interval bewteen the two check about instance's health unit: m" default:"1"`
+
+	TargetFailoverCooldown int `help:"Minutes to skip a scaling target after it failed to create instances or all its instances are unhealthy" default:"30"`
 }
 
 var (
@@ -676,6 +676,20 @@ func (self *SAliyunRegionDriver) IsSupportedElasticcacheSecgroup() bool {
 	return false
 }
 
+func (self *SAliyunRegionDriver) GetElasticcacheManageCapability(engine string) api.ElasticcacheManageCapability {
+	return api.ElasticcacheManageCapability{
+		Account:       true,
+		ResetPassword: true,
+		Acl:           true,
+		Backup:        true,
+		BackupPolicy:  true,
+		Parameters:    true,
+		Flush:         true,
+		AuthMode:      true,
+		Restore:       true,
+	}
+}
+
 func (self *SAliyunRegionDriver) GetMaxElasticcacheSecurityGroupCount() int {
 	return 0
 }
@@ -422,3 +422,19 @@ func (self *SAwsRegionDriver) ValidateCreateWafInstanceData(ctx context.Context,
 func (self *SAwsRegionDriver) ValidateCreateWafRuleData(ctx context.Context, userCred mcclient.TokenCredential, waf *models.SWafInstance, input api.WafRuleCreateInput) (api.WafRuleCreateInput, error) {
 	return input, nil
 }
+
+// WAFv2 自定义规则组为只读, 其余条件类型均可通过模板下发
+func (self *SAwsRegionDriver) ValidateApplyWafPolicyTemplate(ctx context.Context, userCred mcclient.TokenCredential, waf *models.SWafInstance, rules api.WafPolicyTemplateRules) error {
+	return validateWafPolicyTemplateStatements(self.GetProvider(), rules, []cloudprovider.TWafStatementType{
+		cloudprovider.WafStatementTypeByteMatch,
+		cloudprovider.WafStatementTypeGeoMatch,
+		cloudprovider.WafStatementTypeIPSet,
+		cloudprovider.WafStatementTypeLabelMatch,
+		cloudprovider.WafStatementTypeManagedRuleGroup,
+		cloudprovider.WafStatementTypeRate,
+		cloudprovider.WafStatementTypeRegexSet,
+		cloudprovider.WafStatementTypeSize,
+		cloudprovider.WafStatementTypeSqliMatch,
+		cloudprovider.WafStatementTypeXssMatch,
+	})
+}
@@ -337,6 +337,19 @@ func (self *SBaseRegionDriver) RequestChangeDBInstanceConfig(ctx context.Context
 	return fmt.Errorf("Not Implement RequestChangeDBInstanceConfig")
 }
 
+func (self *SBaseRegionDriver) ValidateChangeDBInstanceConfigData(ctx context.Context, userCred mcclient.TokenCredential, instance *models.SDBInstance, input *api.SDBInstanceChangeConfigInput) error {
+	return nil
+}
+
+// 云平台实现ICloudDBInstanceParameterUpdater后再在对应驱动中开启
+func (self *SBaseRegionDriver) IsSupportDBInstanceParameterUpdate() bool {
+	return false
+}
+
+func (self *SBaseRegionDriver) RequestUpdateDBInstanceParameters(ctx context.Context, userCred mcclient.TokenCredential, instance *models.SDBInstance, input api.DBInstanceUpdateParametersInput, task taskman.ITask) error {
+	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestUpdateDBInstanceParameters")
+}
+
 func (self *SBaseRegionDriver) ValidateCreateDBInstanceAccountData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, instance *models.SDBInstance, input api.DBInstanceAccountCreateInput) (api.DBInstanceAccountCreateInput, error) {
 	return input, fmt.Errorf("Not Implement ValidateCreateDBInstanceAccountData")
 }
@@ -406,6 +419,10 @@ func (self *SBaseRegionDriver) RequestSyncDBInstanceBackupStatus(ctx context.Con
 	return fmt.Errorf("Not Implement RequestSyncDBInstanceBackupStatus")
 }
 
+func (self *SBaseRegionDriver) GetElasticcacheManageCapability(engine string) api.ElasticcacheManageCapability {
+	return api.ElasticcacheManageCapability{}
+}
+
 func (self *SBaseRegionDriver) RequestCreateElasticcache(ctx context.Context, userCred mcclient.TokenCredential, ec *models.SElasticcache, task taskman.ITask, data *jsonutils.JSONDict) error {
 	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestCreateElasticcache")
 }
@@ -483,6 +500,10 @@ func (self *SBaseRegionDriver) ValidateCreateWafRuleData(ctx context.Context, us
 	return input, errors.Wrapf(cloudprovider.ErrNotImplemented, "ValidateCreateWafRuleData")
 }
 
+func (self *SBaseRegionDriver) ValidateApplyWafPolicyTemplate(ctx context.Context, userCred mcclient.TokenCredential, waf *models.SWafInstance, rules api.WafPolicyTemplateRules) error {
+	return httperrors.NewNotSupportedError("%s not support waf policy template", waf.GetProviderName())
+}
+
 func (self *SBaseRegionDriver) RequestCreateNetwork(ctx context.Context, userCred mcclient.TokenCredential, net *models.SNetwork) error {
 	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestCreateNetwork")
 }
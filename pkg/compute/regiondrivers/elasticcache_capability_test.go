@@ -0,0 +1,80 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package regiondrivers
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+func TestElasticcacheManageCapability(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Driver models.IRegionDriver
+		Engine string
+		Want   api.ElasticcacheManageCapability
+	}{
+		{
+			Name:   "huawei redis",
+			Driver: &SHuaWeiRegionDriver{},
+			Engine: api.ELASTIC_CACHE_ENGINE_REDIS,
+			Want:   api.ElasticcacheManageCapability{ResetPassword: true, BackupPolicy: true, Parameters: true, Flush: true, Restore: true},
+		},
+		{
+			Name:   "qcloud redis",
+			Driver: &SQcloudRegionDriver{},
+			Engine: api.ELASTIC_CACHE_ENGINE_REDIS,
+			Want:   api.ElasticcacheManageCapability{Account: true, ResetPassword: true, Backup: true, Parameters: true, Flush: true, AuthMode: true, Restore: true},
+		},
+		{
+			Name:   "qcloud memcached",
+			Driver: &SQcloudRegionDriver{},
+			Engine: api.ELASTIC_CACHE_ENGINE_MEMCACHED,
+			Want:   api.ElasticcacheManageCapability{},
+		},
+		{
+			Name:   "aliyun redis",
+			Driver: &SAliyunRegionDriver{},
+			Engine: api.ELASTIC_CACHE_ENGINE_REDIS,
+			Want:   api.ElasticcacheManageCapability{Account: true, ResetPassword: true, Acl: true, Backup: true, BackupPolicy: true, Parameters: true, Flush: true, AuthMode: true, Restore: true},
+		},
+		{
+			Name:   "hcs redis",
+			Driver: &SHCSRegionDriver{},
+			Engine: api.ELASTIC_CACHE_ENGINE_REDIS,
+			Want:   api.ElasticcacheManageCapability{},
+		},
+		{
+			Name:   "azure redis",
+			Driver: &SAzureRegionDriver{},
+			Engine: api.ELASTIC_CACHE_ENGINE_REDIS,
+			Want:   api.ElasticcacheManageCapability{},
+		},
+		{
+			Name:   "aws redis",
+			Driver: &SAwsRegionDriver{},
+			Engine: api.ELASTIC_CACHE_ENGINE_REDIS,
+			Want:   api.ElasticcacheManageCapability{},
+		},
+	}
+	for _, c := range cases {
+		got := c.Driver.GetElasticcacheManageCapability(c.Engine)
+		if got != c.Want {
+			t.Errorf("%s: want %+v got %+v", c.Name, c.Want, got)
+		}
+	}
+}
@@ -151,7 +151,14 @@ func (self *SGoogleRegionDriver) IsSupportedDBInstance() bool {
 	return true
 }
 
+// Cloud SQL 只能将备份整体恢复到相同引擎及版本的实例
 func (self *SGoogleRegionDriver) ValidateDBInstanceRecovery(ctx context.Context, userCred mcclient.TokenCredential, instance *models.SDBInstance, backup *models.SDBInstanceBackup, input api.SDBInstanceRecoveryConfigInput) error {
+	if len(input.Databases) > 0 {
+		return httperrors.NewInputParameterError("Google dbinstance not support databases recovery")
+	}
+	if backup.Engine != instance.Engine || backup.EngineVersion != instance.EngineVersion {
+		return httperrors.NewInputParameterError("Google dbinstance %s %s can not recovery from %s %s backup", instance.Engine, instance.EngineVersion, backup.Engine, backup.EngineVersion)
+	}
 	return nil
 }
 
@@ -160,6 +167,11 @@ func (self *SGoogleRegionDriver) ValidateCreateDBInstanceData(ctx context.Contex
 		return input, httperrors.NewInputParameterError("Google dbinstance not support prepaid billing type")
 	}
 
+	// Cloud SQL 创建实例时无法指定备份, 需创建后再从备份恢复
+	if len(input.DBInstancebackupId) > 0 {
+		return input, httperrors.NewNotSupportedError("Google dbinstance not support create from backup, please recovery from backup after created")
+	}
+
 	if input.DiskSizeGB < 10 || input.DiskSizeGB > 30720 {
 		return input, httperrors.NewInputParameterError("disk size gb must in range 10 ~ 30720 Gb")
 	}
@@ -195,11 +207,32 @@ func (self *SGoogleRegionDriver) InitDBInstanceUser(ctx context.Context, instanc
 	return account.SetPassword(desc.Password)
 }
 
+func (self *SGoogleRegionDriver) ValidateChangeDBInstanceConfigData(ctx context.Context, userCred mcclient.TokenCredential, instance *models.SDBInstance, input *api.SDBInstanceChangeConfigInput) error {
+	if input.DiskSizeGB == 0 {
+		return nil
+	}
+	if input.DiskSizeGB < instance.DiskSizeGB {
+		return httperrors.NewUnsupportOperationError("Google DBInstance Disk cannot be thrink")
+	}
+	if input.DiskSizeGB > 30720 {
+		return httperrors.NewInputParameterError("disk size gb must in range 10 ~ 30720 Gb")
+	}
+	return nil
+}
+
+// Cloud SQL 无库级别授权接口
+func (self *SGoogleRegionDriver) ValidateDBInstanceAccountPrivilege(ctx context.Context, userCred mcclient.TokenCredential, instance *models.SDBInstance, account string, privilege string) error {
+	return httperrors.NewNotSupportedError("Google dbinstance not support grant or revoke privilege")
+}
+
 func (self *SGoogleRegionDriver) ValidateCreateDBInstanceDatabaseData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, instance *models.SDBInstance, input api.DBInstanceDatabaseCreateInput) (api.DBInstanceDatabaseCreateInput, error) {
 	return input, nil
 }
 
 func (self *SGoogleRegionDriver) ValidateCreateDBInstanceBackupData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, instance *models.SDBInstance, input api.DBInstanceBackupCreateInput) (api.DBInstanceBackupCreateInput, error) {
+	if len(input.Databases) > 0 {
+		return input, httperrors.NewInputParameterError("Google dbinstance not support backup specified databases")
+	}
 	return input, nil
 }
 
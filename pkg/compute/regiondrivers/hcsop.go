@@ -36,6 +36,11 @@ func (self *SHCSOPRegionDriver) IsSupportedElasticcache() bool {
 	return true
 }
 
+// 管理接口均为空实现, 仅支持同步
+func (self *SHCSOPRegionDriver) GetElasticcacheManageCapability(engine string) api.ElasticcacheManageCapability {
+	return api.ElasticcacheManageCapability{}
+}
+
 func (self *SHCSOPRegionDriver) IsSecurityGroupBelongVpc() bool {
 	return true
 }
@@ -444,9 +444,18 @@ func (self *SHuaWeiRegionDriver) ValidateCreateDBInstanceBackupData(ctx context.
 }
 
 func (self *SHuaWeiRegionDriver) ValidateChangeDBInstanceConfigData(ctx context.Context, userCred mcclient.TokenCredential, instance *models.SDBInstance, input *api.SDBInstanceChangeConfigInput) error {
-	if input.DiskSizeGB != 0 && input.DiskSizeGB < instance.DiskSizeGB {
+	if input.DiskSizeGB == 0 {
+		return nil
+	}
+	if input.DiskSizeGB < instance.DiskSizeGB {
 		return httperrors.NewUnsupportOperationError("Huawei DBInstance Disk cannot be thrink")
 	}
+	if input.DiskSizeGB > 4000 {
+		return httperrors.NewInputParameterError("%s require disk size must in 40 ~ 4000 GB", self.GetProvider())
+	}
+	if input.DiskSizeGB%10 > 0 {
+		return httperrors.NewInputParameterError("The disk_size_gb must be an integer multiple of 10")
+	}
 	return nil
 }
 
@@ -639,6 +648,17 @@ func (self *SHuaWeiRegionDriver) IsSupportedElasticcacheSecgroup() bool {
 	return false
 }
 
+// DCS 不支持通过接口创建账号、白名单、手动备份及开关免密访问
+func (self *SHuaWeiRegionDriver) GetElasticcacheManageCapability(engine string) api.ElasticcacheManageCapability {
+	return api.ElasticcacheManageCapability{
+		ResetPassword: true,
+		BackupPolicy:  true,
+		Parameters:    true,
+		Flush:         true,
+		Restore:       true,
+	}
+}
+
 func (self *SHuaWeiRegionDriver) GetMaxElasticcacheSecurityGroupCount() int {
 	return 0
 }
@@ -2505,6 +2505,37 @@ func (self *SManagedVirtualizationRegionDriver) RequestChangeDBInstanceConfig(ct
 	return nil
 }
 
+func (self *SManagedVirtualizationRegionDriver) RequestUpdateDBInstanceParameters(ctx context.Context, userCred mcclient.TokenCredential, rds *models.SDBInstance, input api.DBInstanceUpdateParametersInput, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		iRds, err := rds.GetIDBInstance(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "rds.GetIDBInstance")
+		}
+		updater, ok := iRds.(models.ICloudDBInstanceParameterUpdater)
+		if !ok {
+			return nil, errors.Wrapf(cloudprovider.ErrNotSupported, "%s dbinstance update parameters", rds.GetProviderName())
+		}
+		err = updater.UpdateParameters(input.Parameters)
+		if err != nil {
+			return nil, errors.Wrapf(err, "UpdateParameters")
+		}
+
+		err = cloudprovider.WaitStatus(iRds, api.DBINSTANCE_RUNNING, time.Second*10, time.Minute*20)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cloudprovider.WaitStatus")
+		}
+
+		parameters, err := iRds.GetIDBInstanceParameters()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetIDBInstanceParameters")
+		}
+		result := models.DBInstanceParameterManager.SyncDBInstanceParameters(ctx, userCred, rds, parameters)
+		log.Infof("SyncDBInstanceParameters for dbinstance %s(%s) result: %s", rds.Name, rds.Id, result.Result())
+		return nil, nil
+	})
+	return nil
+}
+
 func (self *SManagedVirtualizationRegionDriver) RequestCreateDBInstanceBackup(ctx context.Context, userCred mcclient.TokenCredential, instance *models.SDBInstance, backup *models.SDBInstanceBackup, task taskman.ITask) error {
 	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
 		iRds, err := instance.GetIDBInstance(ctx)
@@ -520,6 +520,22 @@ func (self *SQcloudRegionDriver) IsSupportedElasticcacheSecgroup() bool {
 	return true
 }
 
+// 访问控制使用安全组, 不支持白名单; 暂不支持修改备份策略; memcached 实例仅支持同步
+func (self *SQcloudRegionDriver) GetElasticcacheManageCapability(engine string) api.ElasticcacheManageCapability {
+	if engine != api.ELASTIC_CACHE_ENGINE_REDIS {
+		return api.ElasticcacheManageCapability{}
+	}
+	return api.ElasticcacheManageCapability{
+		Account:       true,
+		ResetPassword: true,
+		Backup:        true,
+		Parameters:    true,
+		Flush:         true,
+		AuthMode:      true,
+		Restore:       true,
+	}
+}
+
 func (self *SQcloudRegionDriver) GetMaxElasticcacheSecurityGroupCount() int {
 	return 10
 }
@@ -0,0 +1,41 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package regiondrivers
+
+import (
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+// 检查模板规则的条件表达式类型是否都被平台支持
+func validateWafPolicyTemplateStatements(provider string, rules api.WafPolicyTemplateRules, supported []cloudprovider.TWafStatementType) error {
+	for _, rule := range rules {
+		for _, statement := range rule.Statements {
+			found := false
+			for _, t := range supported {
+				if statement.Type == t {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return httperrors.NewNotSupportedError("%s waf not support statement type %s in rule %s", provider, statement.Type, rule.Name)
+			}
+		}
+	}
+	return nil
+}
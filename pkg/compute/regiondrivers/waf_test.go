@@ -0,0 +1,53 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package regiondrivers
+
+import (
+	"context"
+	"testing"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+func TestValidateApplyWafPolicyTemplate(t *testing.T) {
+	rules := func(types ...cloudprovider.TWafStatementType) api.WafPolicyTemplateRules {
+		rule := api.WafPolicyTemplateRule{Name: "test"}
+		for _, t := range types {
+			rule.Statements = append(rule.Statements, cloudprovider.SWafStatement{Type: t})
+		}
+		return api.WafPolicyTemplateRules{rule}
+	}
+	cases := []struct {
+		name   string
+		driver models.IRegionDriver
+		rules  api.WafPolicyTemplateRules
+		ok     bool
+	}{
+		{"aws sqli", &SAwsRegionDriver{}, rules(cloudprovider.WafStatementTypeSqliMatch), true},
+		{"aws rule group", &SAwsRegionDriver{}, rules(cloudprovider.WafStatementTypeRuleGroup), false},
+		{"aliyun ipset", &SAliyunRegionDriver{}, rules(cloudprovider.WafStatementTypeIPSet), false},
+		{"huawei geo", &SHuaWeiRegionDriver{}, rules(cloudprovider.WafStatementTypeGeoMatch), false},
+		{"qcloud", &SQcloudRegionDriver{}, rules(cloudprovider.WafStatementTypeIPSet), false},
+	}
+	for _, c := range cases {
+		err := c.driver.ValidateApplyWafPolicyTemplate(context.Background(), nil, &models.SWafInstance{}, c.rules)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v got %v", c.name, c.ok, err)
+		}
+	}
+}
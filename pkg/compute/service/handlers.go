@@ -18,16 +18,20 @@ import (
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/appsrv/dispatcher"
 	app_common "yunion.io/x/onecloud/pkg/cloudcommon/app"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cloudevents"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/proxy"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/bucketobjects"
 	"yunion.io/x/onecloud/pkg/compute/capabilities"
+	"yunion.io/x/onecloud/pkg/compute/inventory"
 	"yunion.io/x/onecloud/pkg/compute/misc"
 	"yunion.io/x/onecloud/pkg/compute/models"
 	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/compute/specs"
 	"yunion.io/x/onecloud/pkg/compute/sshkeys"
+	"yunion.io/x/onecloud/pkg/compute/terraform"
 	"yunion.io/x/onecloud/pkg/compute/usages"
 )
 
@@ -51,6 +55,10 @@ func InitHandlers(app *appsrv.Application) {
 	sshkeys.AddSshKeysHandler("", app)
 	taskman.AddTaskHandler("", app)
 	misc.AddMiscHandler("", app)
+	bucketobjects.AddBucketObjectHandler("", app)
+	terraform.AddTerraformHandler("", app)
+	inventory.AddInventoryHandler("", app)
+	cloudevents.AddCloudEventsHandler("", app)
 
 	app_common.ExportOptionsHandler(app, &options.Options)
 
@@ -86,17 +94,23 @@ func InitHandlers(app *appsrv.Application) {
 		models.InfrasPendingUsageManager,
 
 		models.CloudproviderCapabilityManager,
+		models.CloudproviderInventoryArchiveManager,
 
 		models.ScalingTimerManager,
 		models.ScalingAlarmManager,
 		models.ScalingGroupGuestManager,
+		models.SnapshotPolicyGuestManager,
 		models.ScalingGroupNetworkManager,
+		models.ScalingGroupTargetManager,
 
 		models.DnsRecordSetTrafficPolicyManager,
 		models.CloudimageManager,
 
 		models.WafRuleStatementManager,
+		models.WafPolicyTemplateInstanceManager,
 		models.BillingResourceCheckManager,
+		models.StorageCapacitySampleManager,
+		models.GuestAvailabilityRecordManager,
 	} {
 		db.RegisterModelManager(manager)
 	}
@@ -124,6 +138,7 @@ func InitHandlers(app *appsrv.Application) {
 		models.DiskManager,
 		models.NetworkManager,
 		models.NetworkAddressManager,
+		models.NetworkIpConflictManager,
 		models.ReservedipManager,
 		models.KeypairManager,
 		models.IsolatedDeviceManager,
@@ -245,6 +260,30 @@ func InitHandlers(app *appsrv.Application) {
 		models.ModelartsPoolSkuManager,
 
 		models.MiscResourceManager,
+
+		models.ElasticipPoolManager,
+		models.SecurityPolicyTemplateManager,
+		models.DBInstanceParameterTemplateManager,
+		models.WafPolicyTemplateManager,
+		models.LoadbalancerBlueprintManager,
+		models.ReplicationPolicyManager,
+		models.ReplicationRecordManager,
+		models.ImageCatalogManager,
+		models.GuestSoftwareInventoryManager,
+		models.GuestCommandInvocationManager,
+		models.ResourceLockManager,
+		models.VmwarePoolMappingManager,
+		models.CloudBillItemManager,
+		models.ChargebackPricingManager,
+		models.BudgetManager,
+		models.CloudCommitmentManager,
+		models.IdleResourceManager,
+		models.QuotaReservationManager,
+		models.OperationApprovalPolicyManager,
+		models.OperationApprovalManager,
+		models.TagPolicyManager,
+		models.ProviderAlertRuleManager,
+		models.SchedtagRuleManager,
 	} {
 		db.RegisterModelManager(manager)
 		handler := db.NewModelHandler(manager)
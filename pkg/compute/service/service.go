@@ -36,6 +36,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/elect"
 	"yunion.io/x/onecloud/pkg/cloudcommon/etcd"
 	common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
+	"yunion.io/x/onecloud/pkg/cloudcommon/secretstore"
 	_ "yunion.io/x/onecloud/pkg/compute/guestdrivers"
 	_ "yunion.io/x/onecloud/pkg/compute/hostdrivers"
 	"yunion.io/x/onecloud/pkg/compute/models"
@@ -78,6 +79,11 @@ func StartService() {
 		log.Fatalf("unable to init esxi configs: %v", err)
 	}
 
+	err = secretstore.InitSecretStore(opts.SSecretStoreOptions)
+	if err != nil {
+		log.Fatalf("unable to init secret store: %v", err)
+	}
+
 	// always try to init etcd options
 	if err := initEtcdLockOpts(opts); err != nil {
 		log.Errorf("try to init etcd options error: %v", err)
@@ -157,13 +163,28 @@ func StartService() {
 		cron.AddJobAtIntervalsWithStartRun("CalculateInfrasQuotaUsages", time.Duration(opts.CalculateQuotaUsageIntervalSeconds)*time.Second, models.InfrasQuotaManager.CalculateQuotaUsages, true)
 		cron.AddJobAtIntervalsWithStartRun("AutoSyncCloudaccountStatusTask", time.Duration(opts.CloudAutoSyncIntervalSeconds)*time.Second, models.CloudaccountManager.AutoSyncCloudaccountStatusTask, true)
 		cron.AddJobAtIntervalsWithStartRun("SyncCapacityUsedForEsxiStorage", time.Duration(opts.SyncStorageCapacityUsedIntervalMinutes)*time.Minute, models.StorageManager.SyncCapacityUsedForEsxiStorage, true)
+		cron.AddJobAtIntervals("AuditCloudaccountPermissions", time.Duration(opts.CloudaccountPermissionAuditIntervalHours)*time.Hour, models.CloudaccountManager.AuditCloudaccountPermissions)
+		cron.AddJobAtIntervals("CheckBudgets", time.Duration(opts.BudgetCheckIntervalMinutes)*time.Minute, models.BudgetManager.CheckBudgets)
+		cron.AddJobAtIntervals("DetectIdleResources", time.Duration(opts.IdleResourceDetectIntervalHours)*time.Hour, models.IdleResourceManager.DetectIdleResources)
+		cron.AddJobAtIntervals("ExpireQuotaReservations", time.Duration(opts.QuotaReservationExpireCheckSeconds)*time.Second, models.QuotaReservationManager.ExpireQuotaReservations)
+		cron.AddJobAtIntervals("ExpireOperationApprovals", time.Duration(opts.OperationApprovalExpireCheckSeconds)*time.Second, models.OperationApprovalManager.ExpireOperationApprovals)
+		cron.AddJobAtIntervals("SampleStorageCapacity", time.Duration(opts.StorageCapacitySampleIntervalHours)*time.Hour, models.StorageManager.SampleCapacity)
+		cron.AddJobAtIntervals("StoragecacheGc", time.Duration(opts.StoragecacheGcIntervalHours)*time.Hour, models.StoragecacheManager.AutoGcCachedImages)
+		cron.AddJobAtIntervals("CollectGuestSoftwareInventories", time.Duration(opts.GuestSoftwareInventoryIntervalHours)*time.Hour, models.GuestSoftwareInventoryManager.AutoCollect)
 
 		cron.AddJobAtIntervalsWithStartRun("AutoSyncExtDiskSnapshot", time.Duration(opts.SyncExtDiskSnapshotIntervalMinutes)*time.Minute, models.DiskManager.AutoSyncExtDiskSnapshot, true)
+		cron.AddJobAtIntervals("ManagedHostHealthCheck", time.Duration(opts.ManagedHostHealthCheckIntervalSeconds)*time.Second, models.HostManager.ManagedHostHealthCheck)
+		cron.AddJobAtIntervals("DnsFailoverProbe", time.Duration(opts.DnsFailoverProbeIntervalSeconds)*time.Second, models.DnsRecordSetManager.FailoverProbe)
+		if opts.EnableSecgroupDriftCheck {
+			cron.AddJobAtIntervals("SecgroupDriftCheck", time.Duration(opts.SecgroupDriftCheckIntervalMinutes)*time.Minute, models.SecurityGroupCacheManager.DriftCheck)
+		}
 
 		cron.AddJobEveryFewHour("AutoPurgeSplitable", 4, 30, 0, db.AutoPurgeSplitable, false)
 
 		cron.AddJobEveryFewHour("AutoDiskSnapshot", 1, 5, 0, models.DiskManager.AutoDiskSnapshot, false)
+		cron.AddJobEveryFewHour("AutoInstanceSnapshot", 1, 5, 0, models.InstanceSnapshotManager.AutoInstanceSnapshot, false)
 		cron.AddJobEveryFewHour("SnapshotsCleanup", 1, 35, 0, models.SnapshotManager.CleanupSnapshots, false)
+		cron.AddJobEveryFewHour("AutoRenewLoadbalancerCertificates", 6, 15, 0, models.LoadbalancerCertificateManager.AutoRenewCertificates, false)
 
 		cron.AddJobEveryFewHour("AutoCleanImageCache", 1, 5, 0, models.CachedimageManager.AutoCleanImageCaches, false)
 
@@ -52,7 +52,7 @@ func (self *CloudAccountDeleteTask) OnInit(ctx context.Context, obj db.IStandalo
 	self.SetStage("OnAllCloudProviderDeleteComplete", nil)
 
 	for i := range providers {
-		err := providers[i].StartCloudproviderDeleteTask(ctx, self.UserCred, self.GetTaskId())
+		err := providers[i].StartCloudproviderDeleteTask(ctx, self.UserCred, false, self.GetTaskId())
 		if err != nil {
 			// very unlikely
 			account.SetStatus(self.UserCred, api.CLOUD_PROVIDER_DELETE_FAILED, err.Error())
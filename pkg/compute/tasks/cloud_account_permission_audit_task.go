@@ -0,0 +1,60 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type CloudaccountPermissionAuditTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(CloudaccountPermissionAuditTask{})
+}
+
+func (self *CloudaccountPermissionAuditTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	account := obj.(*models.SCloudaccount)
+
+	self.SetStage("OnAuditComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		audit, err := account.AuditPermissions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return jsonutils.Marshal(audit), nil
+	})
+}
+
+func (self *CloudaccountPermissionAuditTask) OnAuditComplete(ctx context.Context, account *models.SCloudaccount, data jsonutils.JSONObject) {
+	status, _ := data.GetString("status")
+	// 仅在发现缺失权限或审计失败时记录失败日志, 便于告警
+	logclient.AddActionLogWithStartable(self, account, logclient.ACT_AUDIT_PERMISSIONS, data, self.UserCred, status == api.CLOUD_ACCOUNT_PERMISSION_AUDIT_OK)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *CloudaccountPermissionAuditTask) OnAuditCompleteFailed(ctx context.Context, account *models.SCloudaccount, data jsonutils.JSONObject) {
+	logclient.AddActionLogWithStartable(self, account, logclient.ACT_AUDIT_PERMISSIONS, data, self.UserCred, false)
+	self.SetStageFailed(ctx, data)
+}
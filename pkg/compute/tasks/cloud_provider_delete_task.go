@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
@@ -39,6 +40,17 @@ func (self *CloudProviderDeleteTask) OnInit(ctx context.Context, obj db.IStandal
 
 	provider.SetStatus(self.UserCred, api.CLOUD_PROVIDER_DELETING, "StartDiskCloudproviderTask")
 
+	if jsonutils.QueryBoolean(body, "archive", false) {
+		cnt, err := models.CloudproviderInventoryArchiveManager.ArchiveProvider(ctx, provider)
+		if err != nil {
+			provider.SetStatus(self.UserCred, api.CLOUD_PROVIDER_DELETE_FAILED, "ArchiveProvider")
+			self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+			logclient.AddActionLogWithStartable(self, provider, logclient.ACT_DELETE, err, self.UserCred, false)
+			return
+		}
+		log.Infof("archived %d resources of cloudprovider %s", cnt, provider.Name)
+	}
+
 	err := provider.RealDelete(ctx, self.UserCred)
 	if err != nil {
 		provider.SetStatus(self.UserCred, api.CLOUD_PROVIDER_DELETE_FAILED, "StartDiskCloudproviderTask")
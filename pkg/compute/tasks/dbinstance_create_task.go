@@ -85,6 +85,43 @@ func (self *DBInstanceCreateTask) OnCreateDBInstanceCompleteFailed(ctx context.C
 }
 
 func (self *DBInstanceCreateTask) OnSyncDBInstanceStatusComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	rds := obj.(*models.SDBInstance)
+	if len(rds.ParameterTemplateId) > 0 {
+		self.applyParameterTemplate(ctx, rds)
+		return
+	}
+	self.OnApplyParameterTemplateComplete(ctx, rds, nil)
+}
+
+func (self *DBInstanceCreateTask) applyParameterTemplate(ctx context.Context, rds *models.SDBInstance) {
+	self.SetStage("OnApplyParameterTemplateComplete", nil)
+	region, err := rds.GetRegion()
+	if err != nil {
+		self.OnApplyParameterTemplateCompleteFailed(ctx, rds, jsonutils.NewString(err.Error()))
+		return
+	}
+	if !region.GetDriver().IsSupportDBInstanceParameterUpdate() {
+		self.OnApplyParameterTemplateCompleteFailed(ctx, rds, jsonutils.NewString(fmt.Sprintf("%s rds not support apply parameter template", region.Provider)))
+		return
+	}
+	template, err := rds.GetParameterTemplate()
+	if err != nil {
+		self.OnApplyParameterTemplateCompleteFailed(ctx, rds, jsonutils.NewString(err.Error()))
+		return
+	}
+	params, err := rds.GetTemplateParameters(template)
+	if err != nil {
+		self.OnApplyParameterTemplateCompleteFailed(ctx, rds, jsonutils.NewString(err.Error()))
+		return
+	}
+	err = rds.StartUpdateParametersTask(ctx, self.UserCred, api.DBInstanceUpdateParametersInput{Parameters: params}, self.GetTaskId())
+	if err != nil {
+		self.OnApplyParameterTemplateCompleteFailed(ctx, rds, jsonutils.NewString(err.Error()))
+		return
+	}
+}
+
+func (self *DBInstanceCreateTask) OnApplyParameterTemplateComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	rds := obj.(*models.SDBInstance)
 	//notifyclient.NotifyWebhook(ctx, self.UserCred, rds, notifyclient.ActionCreate)
 	notifyclient.EventNotify(ctx, self.UserCred, notifyclient.SEventNotifyParam{
@@ -94,6 +131,13 @@ func (self *DBInstanceCreateTask) OnSyncDBInstanceStatusComplete(ctx context.Con
 	self.SetStageComplete(ctx, nil)
 }
 
+// 实例已创建成功, 参数模板应用失败仅记录, 实例状态由修改参数任务设置
+func (self *DBInstanceCreateTask) OnApplyParameterTemplateCompleteFailed(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	rds := obj.(*models.SDBInstance)
+	logclient.AddActionLogWithStartable(self, rds, logclient.ACT_UPDATE_PARAMETERS, data, self.UserCred, false)
+	self.SetStageFailed(ctx, data)
+}
+
 func (self *DBInstanceCreateTask) OnSyncDBInstanceStatusCompleteFailed(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	self.SetStageFailed(ctx, data)
 }
@@ -0,0 +1,78 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type DBInstanceUpdateParametersTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(DBInstanceUpdateParametersTask{})
+}
+
+func (self *DBInstanceUpdateParametersTask) taskFailed(ctx context.Context, rds *models.SDBInstance, err error) {
+	rds.SetStatus(self.UserCred, api.DBINSTANCE_UPDATE_PARAMETERS_FAILED, err.Error())
+	db.OpsLog.LogEvent(rds, db.ACT_UPDATE, err, self.GetUserCred())
+	logclient.AddActionLogWithStartable(self, rds, logclient.ACT_UPDATE_PARAMETERS, err, self.UserCred, false)
+	self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+}
+
+func (self *DBInstanceUpdateParametersTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	rds := obj.(*models.SDBInstance)
+	input := api.DBInstanceUpdateParametersInput{}
+	err := self.GetParams().Unmarshal(&input)
+	if err != nil {
+		self.taskFailed(ctx, rds, errors.Wrapf(err, "GetParams().Unmarshal"))
+		return
+	}
+
+	region, err := rds.GetRegion()
+	if err != nil {
+		self.taskFailed(ctx, rds, errors.Wrapf(err, "GetRegion"))
+		return
+	}
+
+	self.SetStage("OnDBInstanceUpdateParametersComplete", nil)
+	err = region.GetDriver().RequestUpdateDBInstanceParameters(ctx, self.UserCred, rds, input, self)
+	if err != nil {
+		self.taskFailed(ctx, rds, errors.Wrapf(err, "RequestUpdateDBInstanceParameters"))
+		return
+	}
+}
+
+func (self *DBInstanceUpdateParametersTask) OnDBInstanceUpdateParametersComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	rds := obj.(*models.SDBInstance)
+	rds.SetStatus(self.UserCred, api.DBINSTANCE_RUNNING, "")
+	logclient.AddActionLogWithStartable(self, rds, logclient.ACT_UPDATE_PARAMETERS, self.GetParams(), self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *DBInstanceUpdateParametersTask) OnDBInstanceUpdateParametersCompleteFailed(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	rds := obj.(*models.SDBInstance)
+	self.taskFailed(ctx, rds, errors.Error(data.String()))
+}
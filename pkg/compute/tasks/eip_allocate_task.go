@@ -124,7 +124,7 @@ func (self *EipAllocateTask) OnInit(ctx context.Context, obj db.IStandaloneModel
 		var err error
 
 		_cloudprovider := eip.GetCloudprovider()
-		args.ProjectId, err = _cloudprovider.SyncProject(ctx, self.GetUserCred(), eip.ProjectId)
+		args.ProjectId, err = _cloudprovider.GetCreateProjectId(ctx, self.GetUserCred(), eip.ProjectId, eip.ExternalProjectId)
 		if err != nil {
 			log.Errorf("failed to sync project %s for create %s eip %s error: %v", eip.ProjectId, _cloudprovider.Provider, eip.Name, err)
 		}
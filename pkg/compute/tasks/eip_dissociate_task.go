@@ -20,6 +20,7 @@ import (
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
@@ -203,6 +204,13 @@ func (self *EipDissociateTask) OnInit(ctx context.Context, obj db.IStandaloneMod
 	autoDelete := jsonutils.QueryBoolean(self.GetParams(), "auto_delete", false)
 
 	if eip.AutoDellocate.IsTrue() || autoDelete {
+		if pool := eip.GetRetainPool(); pool != nil {
+			err := eip.RetainInPool(ctx, self.UserCred, pool)
+			if err == nil {
+				return
+			}
+			log.Warningf("retain eip %s in pool %s failed, deallocate it: %v", eip.Name, pool.Name, err)
+		}
 		eip.StartEipDeallocateTask(ctx, self.UserCred, "")
 	}
 }
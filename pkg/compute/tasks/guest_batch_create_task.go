@@ -206,6 +206,10 @@ func (self *GuestBatchCreateTask) allocateGuestOnHost(ctx context.Context, guest
 			return err
 		}
 		input.Eip = eip.Id
+		if len(eip.ExternalId) > 0 {
+			// reused from eip pool, association only
+			input.EipBw = 0
+		}
 	}
 
 	// allocate disks
@@ -291,6 +295,13 @@ func (self *GuestBatchCreateTask) SaveScheduleResult(ctx context.Context, obj IS
 	if len(guest.HostId) == 0 {
 		guest.OnScheduleToHost(ctx, self.UserCred, hostId)
 	}
+	// 按价格调度(prefer_lowest_price)时使用调度器选中的最低价套餐
+	if len(candidate.InstanceType) > 0 && len(guest.InstanceType) == 0 {
+		db.Update(guest, func() error {
+			guest.InstanceType = candidate.InstanceType
+			return nil
+		})
+	}
 
 	err = self.allocateGuestOnHost(ctx, guest, candidate)
 	if err != nil {
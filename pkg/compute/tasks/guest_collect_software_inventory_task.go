@@ -0,0 +1,57 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type GuestCollectSoftwareInventoryTask struct {
+	SGuestBaseTask
+}
+
+func init() {
+	taskman.RegisterTask(GuestCollectSoftwareInventoryTask{})
+}
+
+func (self *GuestCollectSoftwareInventoryTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guest := obj.(*models.SGuest)
+	self.SetStage("OnCollectComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		err := guest.CollectSoftwareInventory(ctx, self.UserCred)
+		if err != nil {
+			return nil, errors.Wrap(err, "CollectSoftwareInventory")
+		}
+		return nil, nil
+	})
+}
+
+func (self *GuestCollectSoftwareInventoryTask) OnCollectComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_COLLECT_SOFTWARE_INVENTORY, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *GuestCollectSoftwareInventoryTask) OnCollectCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_COLLECT_SOFTWARE_INVENTORY, data, self.UserCred, false)
+	self.SetStageFailed(ctx, data)
+}
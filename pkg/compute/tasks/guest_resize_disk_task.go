@@ -38,9 +38,30 @@ func init() {
 func (task *GuestResizeDiskTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	guest := obj.(*models.SGuest)
 
-	guest.SetStatus(task.GetUserCred(), api.VM_RESIZE_DISK, "")
 	db.OpsLog.LogEvent(guest, db.ACT_RESIZING, task.Params, task.UserCred)
 
+	if jsonutils.QueryBoolean(task.Params, "offline", false) {
+		task.SetStage("OnGuestStopComplete", nil)
+		err := guest.StartGuestStopTask(ctx, task.UserCred, false, false, task.GetId())
+		if err != nil {
+			task.OnTaskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		}
+		return
+	}
+	task.resizeDisk(ctx, guest)
+}
+
+func (task *GuestResizeDiskTask) OnGuestStopComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	task.resizeDisk(ctx, guest)
+}
+
+func (task *GuestResizeDiskTask) OnGuestStopCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	task.OnTaskFailed(ctx, guest, data)
+}
+
+func (task *GuestResizeDiskTask) resizeDisk(ctx context.Context, guest *models.SGuest) {
+	guest.SetStatus(task.GetUserCred(), api.VM_RESIZE_DISK, "")
+
 	diskId, _ := task.Params.GetString("disk_id")
 	sizeMb, _ := task.Params.Int("size")
 
@@ -72,6 +93,34 @@ func (task *GuestResizeDiskTask) OnTaskFailed(ctx context.Context, guest *models
 
 func (task *GuestResizeDiskTask) OnDiskResizeComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	guest := obj.(*models.SGuest)
+	if jsonutils.QueryBoolean(task.Params, "offline", false) {
+		task.SetStage("OnGuestStartComplete", nil)
+		err := guest.StartGueststartTask(ctx, task.UserCred, nil, task.GetId())
+		if err != nil {
+			task.OnTaskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		}
+		return
+	}
+	if jsonutils.QueryBoolean(task.Params, "grow_fs", false) {
+		err := guest.GetDriver().RequestGuestGrowFs(ctx, task.UserCred, guest)
+		if err != nil {
+			// 磁盘已经扩容成功, 文件系统扩容失败不影响任务结果
+			log.Warningf("guest %s grow fs error: %v", guest.Name, err)
+			logclient.AddActionLogWithStartable(task, guest, logclient.ACT_RESIZE, err, task.UserCred, false)
+		}
+	}
+	task.onResizeComplete(ctx, guest)
+}
+
+func (task *GuestResizeDiskTask) OnGuestStartComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	task.onResizeComplete(ctx, guest)
+}
+
+func (task *GuestResizeDiskTask) OnGuestStartCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	task.OnTaskFailed(ctx, guest, data)
+}
+
+func (task *GuestResizeDiskTask) onResizeComplete(ctx context.Context, guest *models.SGuest) {
 	db.OpsLog.LogEvent(guest, db.ACT_RESIZE, task.Params, task.UserCred)
 	logclient.AddActionLogWithStartable(task, guest, logclient.ACT_RESIZE, task.Params, task.UserCred, true)
 	task.SetStage("TaskComplete", nil)
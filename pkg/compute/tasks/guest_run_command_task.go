@@ -0,0 +1,86 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type GuestRunCommandTask struct {
+	SGuestBaseTask
+}
+
+func init() {
+	taskman.RegisterTask(GuestRunCommandTask{})
+}
+
+func (self *GuestRunCommandTask) getInvocation() (*models.SGuestCommandInvocation, error) {
+	invocationId, _ := self.GetParams().GetString("invocation_id")
+	invocation, err := models.GuestCommandInvocationManager.FetchById(invocationId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch invocation %s", invocationId)
+	}
+	return invocation.(*models.SGuestCommandInvocation), nil
+}
+
+func (self *GuestRunCommandTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guest := obj.(*models.SGuest)
+	invocation, err := self.getInvocation()
+	if err != nil {
+		self.OnRunCommandCompleteFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnRunCommandComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		err := invocation.Run(ctx, self.UserCred, guest)
+		if err != nil {
+			return nil, errors.Wrap(err, "Run")
+		}
+		return nil, nil
+	})
+}
+
+func (self *GuestRunCommandTask) OnRunCommandComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	invocation, err := self.getInvocation()
+	if err != nil {
+		self.OnRunCommandCompleteFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	notes := jsonutils.NewDict()
+	notes.Add(jsonutils.NewString(invocation.Id), "invocation_id")
+	notes.Add(jsonutils.NewString(invocation.Channel), "channel")
+	notes.Add(jsonutils.NewString(invocation.Script), "script")
+	notes.Add(jsonutils.NewString(invocation.Status), "status")
+	notes.Add(jsonutils.NewInt(int64(invocation.ExitCode)), "exit_code")
+	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_RUN_COMMAND, notes, self.UserCred, invocation.Status == api.GUEST_COMMAND_STATUS_SUCCESS)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *GuestRunCommandTask) OnRunCommandCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	notes := jsonutils.NewDict()
+	notes.Add(self.GetParams(), "params")
+	notes.Add(data, "error")
+	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_RUN_COMMAND, notes, self.UserCred, false)
+	self.SetStageFailed(ctx, data)
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type HostBmcPowerTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(HostBmcPowerTask{})
+}
+
+func (self *HostBmcPowerTask) getActions() (string, string, string) {
+	action, _ := self.GetParams().GetString("action")
+	force := jsonutils.QueryBoolean(self.GetParams(), "force", false)
+	switch action {
+	case api.HOST_BMC_POWER_ON:
+		return "On", db.ACT_START_FAIL, logclient.ACT_VM_START
+	case api.HOST_BMC_POWER_OFF:
+		if force {
+			return "ForceOff", db.ACT_STOP_FAIL, logclient.ACT_VM_STOP
+		}
+		return "GracefulShutdown", db.ACT_STOP_FAIL, logclient.ACT_VM_STOP
+	default:
+		return "ForceRestart", db.ACT_RESTART_FAIL, logclient.ACT_VM_RESET
+	}
+}
+
+func (self *HostBmcPowerTask) taskFailed(ctx context.Context, host *models.SHost, reason jsonutils.JSONObject) {
+	_, opsAct, logAct := self.getActions()
+	host.SetStatus(self.UserCred, api.HOST_BMC_POWER_FAIL, reason.String())
+	db.OpsLog.LogEvent(host, opsAct, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, host, logAct, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *HostBmcPowerTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	host := obj.(*models.SHost)
+	resetType, _, _ := self.getActions()
+	self.SetStage("OnPowerComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		drv, err := host.GetRedfishDriver(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "GetRedfishDriver")
+		}
+		err = drv.Reset(ctx, resetType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Reset %s", resetType)
+		}
+		_, info, err := drv.GetSystemInfo(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "GetSystemInfo")
+		}
+		ret := jsonutils.NewDict()
+		ret.Set("power_state", jsonutils.NewString(info.PowerState))
+		return ret, nil
+	})
+}
+
+func (self *HostBmcPowerTask) OnPowerComplete(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	_, _, logAct := self.getActions()
+	powerState, _ := data.GetString("power_state")
+	status := api.BAREMETAL_RUNNING
+	if strings.EqualFold(powerState, types.POWER_STATUS_OFF) {
+		status = api.BAREMETAL_READY
+	}
+	host.SetStatus(self.UserCred, status, "bmc power "+powerState)
+	logclient.AddActionLogWithStartable(self, host, logAct, data, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *HostBmcPowerTask) OnPowerCompleteFailed(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, host, data)
+}
@@ -0,0 +1,152 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type HostEnterMaintenanceTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(HostEnterMaintenanceTask{})
+}
+
+func (self *HostEnterMaintenanceTask) taskFailed(ctx context.Context, host *models.SHost, reason jsonutils.JSONObject) {
+	host.SetStatus(self.UserCred, api.BAREMETAL_MAINTAIN_FAIL, reason.String())
+	db.OpsLog.LogEvent(host, db.ACT_HOST_MAINTENANCE, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, host, logclient.ACT_HOST_MAINTAINING, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *HostEnterMaintenanceTask) getGuests() ([]api.HostMaintenanceGuest, error) {
+	guests := []api.HostMaintenanceGuest{}
+	err := self.GetParams().Unmarshal(&guests, "guests")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unmarshal guests")
+	}
+	return guests, nil
+}
+
+func (self *HostEnterMaintenanceTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	host := obj.(*models.SHost)
+	// 禁止调度到该宿主机
+	_, err := host.PerformDisable(ctx, self.UserCred, nil, apis.PerformDisableInput{})
+	if err != nil {
+		self.taskFailed(ctx, host, jsonutils.NewString(errors.Wrapf(err, "PerformDisable").Error()))
+		return
+	}
+	self.migrateNext(ctx, host)
+}
+
+// 逐台迁移虚拟机, 每台虚拟机的迁移进度记录在任务参数guests中
+func (self *HostEnterMaintenanceTask) migrateNext(ctx context.Context, host *models.SHost) {
+	guests, err := self.getGuests()
+	if err != nil {
+		self.taskFailed(ctx, host, jsonutils.NewString(err.Error()))
+		return
+	}
+	for i := range guests {
+		if guests[i].Status != api.HOST_MAINTENANCE_GUEST_PENDING {
+			continue
+		}
+		guest := models.GuestManager.FetchGuestById(guests[i].Id)
+		if guest == nil || guest.HostId != host.Id {
+			// 虚拟机已被删除或已不在该宿主机上
+			guests[i].Status = api.HOST_MAINTENANCE_GUEST_MIGRATED
+			continue
+		}
+		guests[i].Status = api.HOST_MAINTENANCE_GUEST_MIGRATING
+		params := jsonutils.NewDict()
+		params.Set("guests", jsonutils.Marshal(guests))
+		params.Set("current", jsonutils.NewInt(int64(i)))
+		self.SetStage("OnGuestMigrated", params)
+		if guests[i].LiveMigrate {
+			err = guest.StartGuestLiveMigrateTask(ctx, self.UserCred, guest.Status, guests[i].TargetHostId, nil, nil, nil, nil, nil, nil, self.GetTaskId())
+		} else {
+			err = guest.StartMigrateTask(ctx, self.UserCred, false, false, guest.Status, guests[i].TargetHostId, self.GetTaskId())
+		}
+		if err != nil {
+			self.OnGuestMigratedFailed(ctx, host, jsonutils.NewString(err.Error()))
+		}
+		return
+	}
+	self.onGuestsMigrated(ctx, host, guests)
+}
+
+func (self *HostEnterMaintenanceTask) setCurrentGuestStatus(ctx context.Context, host *models.SHost, status string, reason string) {
+	guests, err := self.getGuests()
+	if err != nil {
+		self.taskFailed(ctx, host, jsonutils.NewString(err.Error()))
+		return
+	}
+	current, _ := self.GetParams().Int("current")
+	if int(current) < len(guests) {
+		guests[current].Status = status
+		guests[current].Reason = reason
+		notes := fmt.Sprintf("migrate guest %s to host %s: %s %s", guests[current].Name, guests[current].TargetHostId, status, reason)
+		db.OpsLog.LogEvent(host, db.ACT_HOST_MAINTENANCE, notes, self.UserCred)
+	}
+	params := jsonutils.NewDict()
+	params.Set("guests", jsonutils.Marshal(guests))
+	self.SetStage("OnGuestMigrated", params)
+	self.migrateNext(ctx, host)
+}
+
+func (self *HostEnterMaintenanceTask) OnGuestMigrated(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	self.setCurrentGuestStatus(ctx, host, api.HOST_MAINTENANCE_GUEST_MIGRATED, "")
+}
+
+func (self *HostEnterMaintenanceTask) OnGuestMigratedFailed(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	self.setCurrentGuestStatus(ctx, host, api.HOST_MAINTENANCE_GUEST_FAILED, data.String())
+}
+
+func (self *HostEnterMaintenanceTask) onGuestsMigrated(ctx context.Context, host *models.SHost, guests []api.HostMaintenanceGuest) {
+	failed := []string{}
+	for i := range guests {
+		if guests[i].Status == api.HOST_MAINTENANCE_GUEST_FAILED {
+			failed = append(failed, guests[i].Name)
+		}
+	}
+	if len(failed) > 0 {
+		self.taskFailed(ctx, host, jsonutils.NewString(fmt.Sprintf("migrate guests %v failed", failed)))
+		return
+	}
+
+	self.OnHostMaintenance(ctx, host, nil)
+}
+
+func (self *HostEnterMaintenanceTask) OnHostMaintenance(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	db.Update(host, func() error {
+		host.IsMaintenance = true
+		return nil
+	})
+	host.SetStatus(self.UserCred, api.BAREMETAL_MAINTAINING, "enter maintenance complete")
+	logclient.AddActionLogWithStartable(self, host, logclient.ACT_HOST_MAINTAINING, self.GetParams(), self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type IdleResourceCleanupTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(IdleResourceCleanupTask{})
+}
+
+func (self *IdleResourceCleanupTask) taskFailed(ctx context.Context, res *models.SIdleResource, err jsonutils.JSONObject) {
+	res.SetStatus(self.UserCred, api.IDLE_RESOURCE_STATUS_CLEANUP_FAILED, err.String())
+	logclient.AddActionLogWithStartable(self, res, logclient.ACT_IDLE_CLEANUP, err, self.UserCred, false)
+	self.SetStageFailed(ctx, err)
+}
+
+func (self *IdleResourceCleanupTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	res := obj.(*models.SIdleResource)
+
+	self.SetStage("OnResourceDeleteComplete", nil)
+	err := res.StartResourceDeleteTask(ctx, self.UserCred, self.GetTaskId())
+	if err != nil {
+		if errors.Cause(err) == errors.ErrNotFound {
+			// 资源已被删除
+			self.OnResourceDeleteComplete(ctx, res, nil)
+			return
+		}
+		self.taskFailed(ctx, res, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *IdleResourceCleanupTask) OnResourceDeleteComplete(ctx context.Context, res *models.SIdleResource, data jsonutils.JSONObject) {
+	res.SetStatus(self.UserCred, api.IDLE_RESOURCE_STATUS_CLEANED, "")
+	logclient.AddActionLogWithStartable(self, res, logclient.ACT_IDLE_CLEANUP, res.GetShortDesc(ctx), self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *IdleResourceCleanupTask) OnResourceDeleteCompleteFailed(ctx context.Context, res *models.SIdleResource, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, res, data)
+}
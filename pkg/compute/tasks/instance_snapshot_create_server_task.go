@@ -0,0 +1,215 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// InstanceSnapshotCreateServerTask 跨可用区/平台从主机快照恢复:
+// 在源可用区创建中转主机, 逐块磁盘导出为镜像(按目标平台要求的格式), 再用镜像在目标位置创建主机
+type InstanceSnapshotCreateServerTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(InstanceSnapshotCreateServerTask{})
+}
+
+func (self *InstanceSnapshotCreateServerTask) getInput() api.InstanceSnapshotCreateServerInput {
+	input := api.InstanceSnapshotCreateServerInput{}
+	self.Params.Unmarshal(&input, "input")
+	return input
+}
+
+func (self *InstanceSnapshotCreateServerTask) getName() string {
+	input := self.getInput()
+	if len(input.Name) > 0 {
+		return input.Name
+	}
+	return input.GenerateName
+}
+
+func (self *InstanceSnapshotCreateServerTask) getStagingGuest() *models.SGuest {
+	guestId, _ := self.Params.GetString("staging_guest_id")
+	if len(guestId) == 0 {
+		return nil
+	}
+	return models.GuestManager.FetchGuestById(guestId)
+}
+
+func (self *InstanceSnapshotCreateServerTask) startDeleteStagingGuest(ctx context.Context, guest *models.SGuest, parentTaskId string) error {
+	guest.SetDisableDelete(self.UserCred, false)
+	opts := api.ServerDeleteInput{OverridePendingDelete: true}
+	return guest.StartDeleteGuestTask(ctx, self.UserCred, parentTaskId, opts)
+}
+
+func (self *InstanceSnapshotCreateServerTask) taskFailed(ctx context.Context, isp *models.SInstanceSnapshot, err error) {
+	if guest := self.getStagingGuest(); guest != nil {
+		if e := self.startDeleteStagingGuest(ctx, guest, ""); e != nil {
+			log.Warningf("delete staging server %s: %v", guest.Name, e)
+		}
+	}
+	isp.SetStatus(self.UserCred, api.INSTANCE_SNAPSHOT_READY, err.Error())
+	db.OpsLog.LogEvent(isp, db.ACT_RESTORE, err, self.UserCred)
+	logclient.AddActionLogWithStartable(self, isp, logclient.ACT_RESTORE, err, self.UserCred, false)
+	self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+}
+
+func (self *InstanceSnapshotCreateServerTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	isp := obj.(*models.SInstanceSnapshot)
+	guest, err := models.GuestManager.CreateGuestFromInput(ctx, self.UserCred, isp.GetOwnerId(), isp.GetStagingServerInput(self.getName()))
+	if err != nil {
+		self.taskFailed(ctx, isp, errors.Wrap(err, "create staging server"))
+		return
+	}
+	params := jsonutils.NewDict()
+	params.Set("staging_guest_id", jsonutils.NewString(guest.Id))
+	self.SetStage("OnStagingGuestReady", params)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		err := cloudprovider.Wait(time.Second*10, time.Hour, func() (bool, error) {
+			guest := models.GuestManager.FetchGuestById(guest.Id)
+			if guest == nil {
+				return false, errors.Wrap(errors.ErrNotFound, "staging server")
+			}
+			if guest.Status == api.VM_READY {
+				return true, nil
+			}
+			if strings.Contains(guest.Status, "fail") {
+				return false, errors.Errorf("staging server %s status %s", guest.Name, guest.Status)
+			}
+			return false, nil
+		})
+		return nil, err
+	})
+}
+
+func (self *InstanceSnapshotCreateServerTask) OnStagingGuestReady(ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
+	self.saveDisk(ctx, isp, 0)
+}
+
+func (self *InstanceSnapshotCreateServerTask) OnStagingGuestReadyFailed(ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, isp, errors.Errorf("wait staging server: %s", data.String()))
+}
+
+func (self *InstanceSnapshotCreateServerTask) saveDisk(ctx context.Context, isp *models.SInstanceSnapshot, index int) {
+	guest := self.getStagingGuest()
+	if guest == nil {
+		self.taskFailed(ctx, isp, errors.Wrap(errors.ErrNotFound, "staging server"))
+		return
+	}
+	disks, err := guest.GetDisks()
+	if err != nil {
+		self.taskFailed(ctx, isp, errors.Wrap(err, "GetDisks"))
+		return
+	}
+	if index >= len(disks) {
+		self.createServer(ctx, isp, guest)
+		return
+	}
+	disk := &disks[index]
+	opts := api.ServerSaveImageInput{
+		GenerateName: fmt.Sprintf("%s-disk%d", self.getName(), index),
+		Notes:        fmt.Sprintf("exported from instance snapshot %s", isp.Name),
+	}
+	if index == 0 {
+		opts.OsType = isp.OsType
+		opts.OsArch = isp.OsArch
+	}
+	imageId, err := disk.PrepareSaveImage(ctx, self.UserCred, opts)
+	if err != nil {
+		self.taskFailed(ctx, isp, errors.Wrapf(err, "PrepareSaveImage of disk %d", index))
+		return
+	}
+	provider, _ := self.Params.GetString("target_provider")
+	imageIds := []string{}
+	self.Params.Unmarshal(&imageIds, "image_ids")
+	params := jsonutils.NewDict()
+	params.Set("save_index", jsonutils.NewInt(int64(index)))
+	params.Set("image_ids", jsonutils.Marshal(append(imageIds, imageId)))
+	self.SetStage("OnDiskSaved", params)
+	saveInput := api.DiskSaveInput{
+		Name:    opts.GenerateName,
+		Format:  models.GetRequiredImageFormat(provider, disk.DiskFormat),
+		ImageId: imageId,
+	}
+	err = disk.StartDiskSaveTask(ctx, self.UserCred, saveInput, self.GetTaskId())
+	if err != nil {
+		self.taskFailed(ctx, isp, errors.Wrapf(err, "StartDiskSaveTask of disk %d", index))
+		return
+	}
+}
+
+func (self *InstanceSnapshotCreateServerTask) OnDiskSaved(ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
+	index, _ := self.Params.Int("save_index")
+	self.saveDisk(ctx, isp, int(index)+1)
+}
+
+func (self *InstanceSnapshotCreateServerTask) OnDiskSavedFailed(ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, isp, errors.Errorf("save disk image: %s", data.String()))
+}
+
+func (self *InstanceSnapshotCreateServerTask) createServer(ctx context.Context, isp *models.SInstanceSnapshot, staging *models.SGuest) {
+	input := self.getInput()
+	nets := []*api.NetworkConfig{}
+	self.Params.Unmarshal(&nets, "nets")
+	imageIds := []string{}
+	self.Params.Unmarshal(&imageIds, "image_ids")
+	createInput, err := isp.GetCreateServerInput(input, nets, imageIds)
+	if err != nil {
+		self.taskFailed(ctx, isp, errors.Wrap(err, "GetCreateServerInput"))
+		return
+	}
+	guest, err := models.GuestManager.CreateGuestFromInput(ctx, self.UserCred, isp.GetOwnerId(), createInput)
+	if err != nil {
+		self.taskFailed(ctx, isp, errors.Wrap(err, "create server"))
+		return
+	}
+	params := jsonutils.NewDict()
+	params.Set("guest_id", jsonutils.NewString(guest.Id))
+	self.SetStage("OnStagingGuestDeleted", params)
+	err = self.startDeleteStagingGuest(ctx, staging, self.GetTaskId())
+	if err != nil {
+		log.Warningf("delete staging server %s: %v", staging.Name, err)
+		self.OnStagingGuestDeleted(ctx, isp, nil)
+	}
+}
+
+func (self *InstanceSnapshotCreateServerTask) OnStagingGuestDeleted(ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
+	isp.AddRefCount(ctx)
+	isp.SetStatus(self.UserCred, api.INSTANCE_SNAPSHOT_READY, "")
+	db.OpsLog.LogEvent(isp, db.ACT_RESTORE, self.Params, self.UserCred)
+	logclient.AddActionLogWithStartable(self, isp, logclient.ACT_RESTORE, self.Params, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *InstanceSnapshotCreateServerTask) OnStagingGuestDeletedFailed(ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
+	log.Warningf("delete staging server: %s", data.String())
+	self.OnStagingGuestDeleted(ctx, isp, data)
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type LoadbalancerCertificateRenewTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(LoadbalancerCertificateRenewTask{})
+}
+
+func (self *LoadbalancerCertificateRenewTask) taskFail(ctx context.Context, lbcert *models.SLoadbalancerCertificate, err error) {
+	lbcert.SetStatus(self.GetUserCred(), api.LB_CERT_STATUS_RENEW_FAILED, err.Error())
+	db.OpsLog.LogEvent(lbcert, db.ACT_UPDATE, err, self.UserCred)
+	logclient.AddActionLogWithStartable(self, lbcert, logclient.ACT_RENEW, err, self.UserCred, false)
+	notifyclient.NotifySystemErrorWithCtx(ctx, lbcert.Id, lbcert.Name, api.LB_CERT_STATUS_RENEW_FAILED, err.Error())
+	self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+}
+
+func (self *LoadbalancerCertificateRenewTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	lbcert := obj.(*models.SLoadbalancerCertificate)
+	input := api.LoadbalancerCertificateRenewInput{}
+	self.GetParams().Unmarshal(&input)
+	self.SetStage("OnRenewComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		return nil, lbcert.Renew(ctx, self.GetUserCred(), input.Certificate, input.PrivateKey)
+	})
+}
+
+func (self *LoadbalancerCertificateRenewTask) OnRenewComplete(ctx context.Context, lbcert *models.SLoadbalancerCertificate, data jsonutils.JSONObject) {
+	lbcert.SetStatus(self.GetUserCred(), api.LB_STATUS_ENABLED, "")
+	logclient.AddActionLogWithStartable(self, lbcert, logclient.ACT_RENEW, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *LoadbalancerCertificateRenewTask) OnRenewCompleteFailed(ctx context.Context, lbcert *models.SLoadbalancerCertificate, reason jsonutils.JSONObject) {
+	self.taskFail(ctx, lbcert, errors.Errorf(reason.String()))
+}
@@ -83,6 +83,7 @@ func (self *LoadbalancerCreateTask) OnLoadbalancerStartComplete(ctx context.Cont
 		Obj:    lb,
 		Action: notifyclient.ActionCreate,
 	})
+	lb.ApplyBlueprint(ctx, self.GetUserCred())
 	self.SetStageComplete(ctx, nil)
 }
 
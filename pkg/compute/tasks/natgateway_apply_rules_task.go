@@ -0,0 +1,128 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type NatGatewayApplyRulesTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(NatGatewayApplyRulesTask{})
+}
+
+// 依次处理的规则队列, 先删除后创建, 避免新规则与待删除规则冲突
+var natApplyRulesQueues = []string{"dnat_delete", "snat_delete", "dnat_create", "snat_create"}
+
+func (self *NatGatewayApplyRulesTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	self.applyNext(ctx, obj.(*models.SNatGateway))
+}
+
+func (self *NatGatewayApplyRulesTask) recordError(err error) {
+	errs, _ := self.GetParams().GetArray("errors")
+	errs = append(errs, jsonutils.NewString(err.Error()))
+	self.SaveParams(jsonutils.Marshal(map[string]interface{}{"errors": errs}).(*jsonutils.JSONDict))
+}
+
+func (self *NatGatewayApplyRulesTask) hasErrors() bool {
+	errs, _ := self.GetParams().GetArray("errors")
+	return len(errs) > 0
+}
+
+// 每个队列都处理完后才进入下一个队列, 删除失败时不再创建新规则
+func (self *NatGatewayApplyRulesTask) applyNext(ctx context.Context, nat *models.SNatGateway) {
+	for _, queue := range natApplyRulesQueues {
+		if strings.HasSuffix(queue, "_create") && self.hasErrors() {
+			break
+		}
+		for {
+			ids, _ := self.GetParams().GetArray(queue)
+			if len(ids) == 0 {
+				break
+			}
+			id, _ := ids[0].GetString()
+			self.SaveParams(jsonutils.Marshal(map[string]interface{}{queue: ids[1:]}).(*jsonutils.JSONDict))
+			err := self.applyRule(ctx, queue, id)
+			if err != nil {
+				self.recordError(errors.Wrapf(err, "%s %s", queue, id))
+				continue
+			}
+			return
+		}
+	}
+	self.taskComplete(ctx, nat)
+}
+
+func (self *NatGatewayApplyRulesTask) applyRule(ctx context.Context, queue, id string) error {
+	var manager db.IModelManager = models.NatDEntryManager
+	if strings.HasPrefix(queue, "snat") {
+		manager = models.NatSEntryManager
+	}
+	obj, err := manager.FetchById(id)
+	if err != nil {
+		return errors.Wrapf(err, "FetchById")
+	}
+	self.SetStage("OnRuleApplied", nil)
+	switch queue {
+	case "dnat_delete":
+		return obj.(*models.SNatDEntry).StartDeleteDNatTask(ctx, self.UserCred, self.GetTaskId())
+	case "snat_delete":
+		return obj.(*models.SNatSEntry).StartDeleteSNatTask(ctx, self.UserCred, self.GetTaskId())
+	case "dnat_create":
+		return obj.(*models.SNatDEntry).StartCreateDNatTask(ctx, self.UserCred, self.GetTaskId())
+	default:
+		return obj.(*models.SNatSEntry).StartCreateSNatTask(ctx, self.UserCred, self.GetTaskId())
+	}
+}
+
+func (self *NatGatewayApplyRulesTask) OnRuleApplied(ctx context.Context, nat *models.SNatGateway, data jsonutils.JSONObject) {
+	self.applyNext(ctx, nat)
+}
+
+func (self *NatGatewayApplyRulesTask) OnRuleAppliedFailed(ctx context.Context, nat *models.SNatGateway, data jsonutils.JSONObject) {
+	self.recordError(errors.Error(data.String()))
+	self.applyNext(ctx, nat)
+}
+
+func (self *NatGatewayApplyRulesTask) taskComplete(ctx context.Context, nat *models.SNatGateway) {
+	errs, _ := self.GetParams().GetArray("errors")
+	if len(errs) > 0 {
+		dnatIds, snatIds := []string{}, []string{}
+		self.GetParams().Unmarshal(&dnatIds, "dnat_created")
+		self.GetParams().Unmarshal(&snatIds, "snat_created")
+		nat.CleanupUnappliedRules(ctx, self.UserCred, dnatIds, snatIds)
+		reason := jsonutils.NewArray(errs...)
+		nat.SetStatus(self.UserCred, api.NAT_STAUTS_AVAILABLE, "apply rules failed")
+		logclient.AddActionLogWithStartable(self, nat, logclient.ACT_NAT_APPLY_RULES, reason, self.UserCred, false)
+		self.SetStageFailed(ctx, reason)
+		return
+	}
+	nat.SetStatus(self.UserCred, api.NAT_STAUTS_AVAILABLE, "apply rules")
+	logclient.AddActionLogWithStartable(self, nat, logclient.ACT_NAT_APPLY_RULES, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
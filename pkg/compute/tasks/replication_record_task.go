@@ -0,0 +1,77 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type ReplicationRecordTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(ReplicationRecordTask{})
+}
+
+func (self *ReplicationRecordTask) taskFailed(ctx context.Context, record *models.SReplicationRecord, reason jsonutils.JSONObject) {
+	record.SetStatus(self.UserCred, api.REPLICATION_RECORD_STATUS_FAILED, reason.String())
+	db.OpsLog.LogEvent(record, db.ACT_REPLICATE_FAIL, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, record, logclient.ACT_REPLICATE, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *ReplicationRecordTask) taskComplete(ctx context.Context, record *models.SReplicationRecord) {
+	record.SetStatus(self.UserCred, api.REPLICATION_RECORD_STATUS_READY, "")
+	db.OpsLog.LogEvent(record, db.ACT_REPLICATE, record.GetShortDesc(ctx), self.UserCred)
+	logclient.AddActionLogWithStartable(self, record, logclient.ACT_REPLICATE, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *ReplicationRecordTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	record := obj.(*models.SReplicationRecord)
+
+	switch record.ResourceType {
+	case api.REPLICATION_RESOURCE_TYPE_IMAGE:
+		self.SetStage("OnImageCached", nil)
+		err := record.StartImageCache(ctx, self.UserCred, self.GetTaskId())
+		if err != nil {
+			self.taskFailed(ctx, record, jsonutils.NewString(errors.Wrapf(err, "StartImageCache").Error()))
+		}
+	default:
+		self.taskFailed(ctx, record, jsonutils.NewString("unsupported resource type "+record.ResourceType))
+	}
+}
+
+func (self *ReplicationRecordTask) OnImageCached(ctx context.Context, record *models.SReplicationRecord, data jsonutils.JSONObject) {
+	externalId, _ := data.GetString("image_id")
+	if len(externalId) > 0 {
+		record.SetTargetExternalId(externalId)
+	}
+	self.taskComplete(ctx, record)
+}
+
+func (self *ReplicationRecordTask) OnImageCachedFailed(ctx context.Context, record *models.SReplicationRecord, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, record, data)
+}
@@ -18,11 +18,14 @@ import (
 	"context"
 
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/util/logclient"
 )
 
@@ -62,8 +65,6 @@ func (self *StorageCacheImageTask) OnRelinquishLeastUsedCachedImageComplete(ctx
 
 	db.OpsLog.LogEvent(storageCache, db.ACT_CACHING_IMAGE, imageId, self.UserCred)
 
-	self.SetStage("OnImageCacheComplete", nil)
-
 	host, err := storageCache.GetHost()
 	if err != nil {
 		errData := taskman.Error2TaskData(err)
@@ -80,14 +81,47 @@ func (self *StorageCacheImageTask) OnRelinquishLeastUsedCachedImageComplete(ctx
 		}
 	}
 
+	format, err := host.GetCacheImageConvertFormat(imageId)
+	if err != nil {
+		self.OnImageCacheCompleteFailed(ctx, storageCache, taskman.Error2TaskData(err))
+		return
+	}
+	if len(format) == 0 {
+		self.OnImageConverted(ctx, storageCache, nil)
+		return
+	}
+
+	self.SetStage("OnImageConverted", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		s := auth.GetAdminSession(ctx, options.Options.Region)
+		_, err := models.EnsureImageFormat(ctx, s, imageId, format, func(progress float32) {
+			log.Infof("Convert image %s to %s for storagecache %s status: %.2f%%", imageId, format, storageCache.Name, progress)
+		})
+		return nil, err
+	})
+}
+
+func (self *StorageCacheImageTask) OnImageConverted(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	storageCache := obj.(*models.SStoragecache)
+
+	self.SetStage("OnImageCacheComplete", nil)
+
+	host, err := storageCache.GetHost()
+	if err != nil {
+		self.OnImageCacheCompleteFailed(ctx, storageCache, taskman.Error2TaskData(err))
+		return
+	}
 	err = host.GetHostDriver().CheckAndSetCacheImage(ctx, host, storageCache, self)
 	if err != nil {
-		errData := taskman.Error2TaskData(err)
-		self.OnImageCacheCompleteFailed(ctx, storageCache, errData)
+		self.OnImageCacheCompleteFailed(ctx, storageCache, taskman.Error2TaskData(err))
 		return
 	}
 }
 
+func (self *StorageCacheImageTask) OnImageConvertedFailed(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	self.OnImageCacheCompleteFailed(ctx, obj, data)
+}
+
 func (self *StorageCacheImageTask) OnImageCacheComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	storageCache := obj.(*models.SStoragecache)
 	self.OnCacheSucc(ctx, storageCache, data.(*jsonutils.JSONDict))
@@ -128,5 +162,12 @@ func (self *StorageCacheImageTask) OnCacheSucc(ctx context.Context, cache *model
 	}
 	models.CachedimageManager.ImageAddRefCount(imageId)
 	db.OpsLog.LogEvent(cache, db.ACT_CACHED_IMAGE, imageId, self.UserCred)
+	if region, err := cache.GetRegion(); err == nil {
+		imageName := imageId
+		if ci := scimg.GetCachedimage(); ci != nil {
+			imageName = ci.Name
+		}
+		models.ReplicationPolicyManager.StartReplication(ctx, self.UserCred, api.REPLICATION_RESOURCE_TYPE_IMAGE, imageId, imageName, region.Id, cache.ManagerId)
+	}
 	self.SetStageComplete(ctx, data)
 }
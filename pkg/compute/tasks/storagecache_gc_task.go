@@ -0,0 +1,99 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// StoragecacheGcTask 依次回收待回收的缓存镜像, 单个镜像回收失败不影响其他镜像
+type StoragecacheGcTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(StoragecacheGcTask{})
+}
+
+func (self *StoragecacheGcTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	cache := obj.(*models.SStoragecache)
+	db.Update(cache, func() error {
+		cache.GcLastRunAt = time.Now()
+		return nil
+	})
+	self.uncacheNext(ctx, cache, 0)
+}
+
+func (self *StoragecacheGcTask) uncacheNext(ctx context.Context, cache *models.SStoragecache, idx int) {
+	imageIds, _ := jsonutils.GetStringArray(self.Params, "image_ids")
+	for ; idx < len(imageIds); idx++ {
+		_, err := cache.MarkGcImageDeleting(ctx, self.UserCred, imageIds[idx])
+		if err != nil {
+			log.Warningf("skip gc cached image %s of storagecache %s: %v", imageIds[idx], cache.Name, err)
+			self.addSkipped(imageIds[idx])
+			continue
+		}
+		self.SetStage("OnImageUncached", jsonutils.Marshal(map[string]int{"index": idx}).(*jsonutils.JSONDict))
+		err = cache.StartImageUncacheTask(ctx, self.UserCred, imageIds[idx], false, self.GetTaskId())
+		if err != nil {
+			log.Errorf("StartImageUncacheTask %s error: %v", imageIds[idx], err)
+			self.addSkipped(imageIds[idx])
+			continue
+		}
+		return
+	}
+	self.taskComplete(ctx, cache)
+}
+
+func (self *StoragecacheGcTask) addSkipped(imageId string) {
+	skipped, _ := jsonutils.GetStringArray(self.Params, "skipped")
+	skipped = append(skipped, imageId)
+	self.Params.Set("skipped", jsonutils.NewStringArray(skipped))
+}
+
+func (self *StoragecacheGcTask) OnImageUncached(ctx context.Context, cache *models.SStoragecache, data jsonutils.JSONObject) {
+	idx, _ := self.Params.Int("index")
+	self.uncacheNext(ctx, cache, int(idx)+1)
+}
+
+func (self *StoragecacheGcTask) OnImageUncachedFailed(ctx context.Context, cache *models.SStoragecache, data jsonutils.JSONObject) {
+	imageIds, _ := jsonutils.GetStringArray(self.Params, "image_ids")
+	idx, _ := self.Params.Int("index")
+	if int(idx) < len(imageIds) {
+		self.addSkipped(imageIds[idx])
+	}
+	self.uncacheNext(ctx, cache, int(idx)+1)
+}
+
+func (self *StoragecacheGcTask) taskComplete(ctx context.Context, cache *models.SStoragecache) {
+	notes := jsonutils.NewDict()
+	if report, err := self.Params.Get("report"); err == nil {
+		notes.Add(report, "report")
+	}
+	if skipped, err := self.Params.Get("skipped"); err == nil {
+		notes.Add(skipped, "skipped")
+	}
+	logclient.AddActionLogWithStartable(self, cache, logclient.ACT_GARBAGE_COLLECT, notes, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type WafPolicyTemplateApplyTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(WafPolicyTemplateApplyTask{})
+}
+
+func (self *WafPolicyTemplateApplyTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	template := obj.(*models.SWafPolicyTemplate)
+
+	wafIds := jsonutils.GetQueryStringArray(self.GetParams(), "waf_instance_ids")
+	failed := jsonutils.NewDict()
+	for _, wafId := range wafIds {
+		err := template.ApplyToWafInstance(ctx, self.GetUserCred(), wafId)
+		if err != nil {
+			failed.Set(wafId, jsonutils.NewString(errors.Wrapf(err, "ApplyToWafInstance").Error()))
+		}
+	}
+	if failed.Length() > 0 {
+		logclient.AddActionLogWithStartable(self, template, logclient.ACT_APPLY_POLICY_TEMPLATE, failed, self.UserCred, false)
+		self.SetStageFailed(ctx, failed)
+		return
+	}
+	logclient.AddActionLogWithStartable(self, template, logclient.ACT_APPLY_POLICY_TEMPLATE, self.GetParams(), self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
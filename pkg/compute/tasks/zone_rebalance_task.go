@@ -0,0 +1,167 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type ZoneRebalanceTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(ZoneRebalanceTask{})
+}
+
+func (self *ZoneRebalanceTask) taskFailed(ctx context.Context, zone *models.SZone, reason jsonutils.JSONObject) {
+	db.OpsLog.LogEvent(zone, db.ACT_ZONE_REBALANCE, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, zone, logclient.ACT_ZONE_REBALANCE, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *ZoneRebalanceTask) getMigrations() ([]api.ZoneRebalanceMigration, error) {
+	migrations := []api.ZoneRebalanceMigration{}
+	err := self.GetParams().Unmarshal(&migrations, "migrations")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unmarshal migrations")
+	}
+	return migrations, nil
+}
+
+func (self *ZoneRebalanceTask) setMigrations(migrations []api.ZoneRebalanceMigration) {
+	params := jsonutils.NewDict()
+	params.Set("migrations", jsonutils.Marshal(migrations))
+	self.SetStage("OnBatchMigrated", params)
+}
+
+func (self *ZoneRebalanceTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	zone := obj.(*models.SZone)
+	self.migrateNextBatch(ctx, zone)
+}
+
+// 每批最多同时迁移concurrency台虚拟机, 本批迁移子任务全部结束后再开始下一批
+func (self *ZoneRebalanceTask) migrateNextBatch(ctx context.Context, zone *models.SZone) {
+	migrations, err := self.getMigrations()
+	if err != nil {
+		self.taskFailed(ctx, zone, jsonutils.NewString(err.Error()))
+		return
+	}
+	concurrency, _ := self.GetParams().Int("concurrency")
+	if concurrency <= 0 {
+		concurrency = api.ZONE_REBALANCE_DEFAULT_CONCURRENCY
+	}
+	for {
+		batch := []int{}
+		for i := range migrations {
+			if len(batch) >= int(concurrency) {
+				break
+			}
+			if migrations[i].Status != api.ZONE_REBALANCE_MIGRATION_PENDING {
+				continue
+			}
+			guest := models.GuestManager.FetchGuestById(migrations[i].GuestId)
+			if guest == nil || guest.HostId != migrations[i].SourceHostId {
+				// 虚拟机已被删除或已不在源宿主机上
+				migrations[i].Status = api.ZONE_REBALANCE_MIGRATION_FAILED
+				migrations[i].Reason = "guest not found on source host"
+				continue
+			}
+			migrations[i].Status = api.ZONE_REBALANCE_MIGRATION_MIGRATING
+			batch = append(batch, i)
+		}
+		if len(batch) == 0 {
+			self.onMigrationsDone(ctx, zone, migrations)
+			return
+		}
+		self.setMigrations(migrations)
+		started := 0
+		for _, i := range batch {
+			guest := models.GuestManager.FetchGuestById(migrations[i].GuestId)
+			if migrations[i].LiveMigrate {
+				err = guest.StartGuestLiveMigrateTask(ctx, self.UserCred, guest.Status, migrations[i].TargetHostId, nil, nil, nil, nil, nil, nil, self.GetTaskId())
+			} else {
+				err = guest.StartMigrateTask(ctx, self.UserCred, false, false, guest.Status, migrations[i].TargetHostId, self.GetTaskId())
+			}
+			if err != nil {
+				migrations[i].Status = api.ZONE_REBALANCE_MIGRATION_FAILED
+				migrations[i].Reason = err.Error()
+				continue
+			}
+			started++
+		}
+		self.setMigrations(migrations)
+		if started > 0 {
+			return
+		}
+	}
+}
+
+func (self *ZoneRebalanceTask) onBatchDone(ctx context.Context, zone *models.SZone, reason string) {
+	migrations, err := self.getMigrations()
+	if err != nil {
+		self.taskFailed(ctx, zone, jsonutils.NewString(err.Error()))
+		return
+	}
+	for i := range migrations {
+		if migrations[i].Status != api.ZONE_REBALANCE_MIGRATION_MIGRATING {
+			continue
+		}
+		guest := models.GuestManager.FetchGuestById(migrations[i].GuestId)
+		if guest != nil && guest.HostId == migrations[i].TargetHostId {
+			migrations[i].Status = api.ZONE_REBALANCE_MIGRATION_MIGRATED
+		} else {
+			migrations[i].Status = api.ZONE_REBALANCE_MIGRATION_FAILED
+			migrations[i].Reason = reason
+		}
+		notes := fmt.Sprintf("migrate guest %s from host %s to host %s: %s %s", migrations[i].Guest, migrations[i].SourceHost, migrations[i].TargetHost, migrations[i].Status, migrations[i].Reason)
+		db.OpsLog.LogEvent(zone, db.ACT_ZONE_REBALANCE, notes, self.UserCred)
+	}
+	self.setMigrations(migrations)
+	self.migrateNextBatch(ctx, zone)
+}
+
+func (self *ZoneRebalanceTask) OnBatchMigrated(ctx context.Context, zone *models.SZone, data jsonutils.JSONObject) {
+	self.onBatchDone(ctx, zone, "")
+}
+
+func (self *ZoneRebalanceTask) OnBatchMigratedFailed(ctx context.Context, zone *models.SZone, data jsonutils.JSONObject) {
+	self.onBatchDone(ctx, zone, data.String())
+}
+
+func (self *ZoneRebalanceTask) onMigrationsDone(ctx context.Context, zone *models.SZone, migrations []api.ZoneRebalanceMigration) {
+	failed := []string{}
+	for i := range migrations {
+		if migrations[i].Status == api.ZONE_REBALANCE_MIGRATION_FAILED {
+			failed = append(failed, migrations[i].Guest)
+		}
+	}
+	if len(failed) > 0 {
+		self.taskFailed(ctx, zone, jsonutils.NewString(fmt.Sprintf("migrate guests %v failed", failed)))
+		return
+	}
+	logclient.AddActionLogWithStartable(self, zone, logclient.ACT_ZONE_REBALANCE, jsonutils.Marshal(migrations), self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
@@ -0,0 +1,347 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/appctx"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/rbacscope"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+)
+
+func AddTerraformHandler(prefix string, app *appsrv.Application) {
+	prefix = fmt.Sprintf("%s/terraform/<project_id>", prefix)
+	app.AddHandler2("GET", fmt.Sprintf("%s/export", prefix), auth.Authenticate(exportHandler), nil, "terraform_export", nil)
+	app.AddHandler2("POST", fmt.Sprintf("%s/import", prefix), auth.Authenticate(importHandler), nil, "terraform_import", nil)
+}
+
+// 校验当前用户对项目内资源的权限, 导出需要list权限, 导入需要update权限
+func fetchProject(ctx context.Context, userCred mcclient.TokenCredential, action string) (*db.STenant, error) {
+	projectId := appctx.AppContextParams(ctx)["<project_id>"]
+	tenant, err := db.TenantCacheManager.FetchTenantByIdOrName(ctx, projectId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewResourceNotFoundError2("project", projectId)
+		}
+		return nil, httperrors.NewGeneralError(err)
+	}
+	scope := rbacscope.ScopeSystem
+	if tenant.Id == userCred.GetProjectId() {
+		scope = rbacscope.ScopeProject
+	} else if tenant.DomainId == userCred.GetProjectDomainId() {
+		scope = rbacscope.ScopeDomain
+	}
+	if !userCred.IsAllow(scope, consts.GetServiceType(), models.GuestManager.KeywordPlural(), action).Result.IsAllow() {
+		return nil, httperrors.NewForbiddenError("not allow to %s resources of project %s", action, tenant.Name)
+	}
+	return tenant, nil
+}
+
+func exportHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	userCred := auth.FetchUserCredential(ctx, policy.FilterPolicyCredential)
+	tenant, err := fetchProject(ctx, userCred, policy.PolicyActionList)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	query, err := jsonutils.ParseQueryString(r.URL.RawQuery)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	input := api.TerraformExportInput{}
+	err = query.Unmarshal(&input)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, httperrors.NewInputParameterError("unmarshal input: %v", err))
+		return
+	}
+	output, err := export(ctx, tenant, input)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	appsrv.SendJSON(w, jsonutils.Marshal(map[string]interface{}{"terraform": output}))
+}
+
+func importHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	userCred := auth.FetchUserCredential(ctx, policy.FilterPolicyCredential)
+	tenant, err := fetchProject(ctx, userCred, policy.PolicyActionUpdate)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	body, err := appsrv.FetchJSON(r)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, httperrors.NewInputParameterError("invalid body: %v", err))
+		return
+	}
+	input := api.TerraformImportInput{}
+	if body.Contains("terraform") {
+		body, _ = body.Get("terraform")
+	}
+	err = body.Unmarshal(&input)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, httperrors.NewInputParameterError("unmarshal input: %v", err))
+		return
+	}
+	output, err := importState(ctx, userCred, tenant, input)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	appsrv.SendJSON(w, jsonutils.Marshal(map[string]interface{}{"terraform": output}))
+}
+
+type sResourceCollector struct {
+	providers map[string]string
+	used      map[string]bool
+	resources []sTerraformResource
+	skipped   []string
+}
+
+func (c *sResourceCollector) getProvider(managerId string) string {
+	if provider, ok := c.providers[managerId]; ok {
+		return provider
+	}
+	provider := ""
+	obj, err := models.CloudproviderManager.FetchById(managerId)
+	if err == nil {
+		provider = obj.(*models.SCloudprovider).Provider
+	}
+	c.providers[managerId] = provider
+	return provider
+}
+
+func (c *sResourceCollector) add(kind, managerId, id, name, externalId string) {
+	provider, ok := terraformProviders[c.getProvider(managerId)]
+	if !ok {
+		c.skipped = append(c.skipped, fmt.Sprintf("%s %s", kind, name))
+		return
+	}
+	tfType, ok := provider.resources[kind]
+	if !ok {
+		c.skipped = append(c.skipped, fmt.Sprintf("%s %s", kind, name))
+		return
+	}
+	c.resources = append(c.resources, sTerraformResource{
+		kind:       kind,
+		provider:   provider,
+		tfType:     tfType,
+		tfName:     terraformName(tfType, name, c.used),
+		id:         id,
+		name:       name,
+		externalId: externalId,
+	})
+}
+
+// 导出项目内已同步的云上资源, vpc为项目内网络及负载均衡所在的vpc
+func export(ctx context.Context, tenant *db.STenant, input api.TerraformExportInput) (*api.TerraformExportOutput, error) {
+	if len(input.Format) == 0 {
+		input.Format = api.TERRAFORM_FORMAT_HCL
+	}
+	if !utils.IsInStringArray(input.Format, []string{api.TERRAFORM_FORMAT_HCL, api.TERRAFORM_FORMAT_STATE}) {
+		return nil, httperrors.NewInputParameterError("invalid format %s", input.Format)
+	}
+	if len(input.Resources) == 0 {
+		input.Resources = api.TERRAFORM_RESOURCES
+	}
+	for _, res := range input.Resources {
+		if !utils.IsInStringArray(res, api.TERRAFORM_RESOURCES) {
+			return nil, httperrors.NewInputParameterError("invalid resource %s", res)
+		}
+	}
+
+	guests := []models.SGuest{}
+	q := models.GuestManager.Query().Equals("tenant_id", tenant.Id).IsNotEmpty("external_id")
+	err := db.FetchModelObjects(models.GuestManager, q, &guests)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "fetch servers"))
+	}
+	networks := []models.SNetwork{}
+	q = models.NetworkManager.Query().Equals("tenant_id", tenant.Id).IsNotEmpty("external_id")
+	err = db.FetchModelObjects(models.NetworkManager, q, &networks)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "fetch networks"))
+	}
+	lbs := []models.SLoadbalancer{}
+	q = models.LoadbalancerManager.Query().Equals("tenant_id", tenant.Id).IsNotEmpty("external_id")
+	err = db.FetchModelObjects(models.LoadbalancerManager, q, &lbs)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "fetch loadbalancers"))
+	}
+	vpcIds := []string{}
+	for i := range networks {
+		vpc, err := networks[i].GetVpc()
+		if err != nil {
+			log.Warningf("get vpc of network %s error: %v", networks[i].Name, err)
+			continue
+		}
+		if !utils.IsInStringArray(vpc.Id, vpcIds) {
+			vpcIds = append(vpcIds, vpc.Id)
+		}
+	}
+	for i := range lbs {
+		if len(lbs[i].VpcId) > 0 && !utils.IsInStringArray(lbs[i].VpcId, vpcIds) {
+			vpcIds = append(vpcIds, lbs[i].VpcId)
+		}
+	}
+	vpcs := []models.SVpc{}
+	q = models.VpcManager.Query().In("id", vpcIds).IsNotEmpty("external_id")
+	err = db.FetchModelObjects(models.VpcManager, q, &vpcs)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "fetch vpcs"))
+	}
+
+	c := &sResourceCollector{
+		providers: map[string]string{},
+		used:      map[string]bool{},
+		resources: []sTerraformResource{},
+		skipped:   []string{},
+	}
+	if utils.IsInStringArray(api.TERRAFORM_RESOURCE_VPC, input.Resources) {
+		for i := range vpcs {
+			c.add(api.TERRAFORM_RESOURCE_VPC, vpcs[i].ManagerId, vpcs[i].Id, vpcs[i].Name, vpcs[i].ExternalId)
+		}
+	}
+	if utils.IsInStringArray(api.TERRAFORM_RESOURCE_NETWORK, input.Resources) {
+		for i := range networks {
+			c.add(api.TERRAFORM_RESOURCE_NETWORK, networks[i].GetCloudproviderId(), networks[i].Id, networks[i].Name, networks[i].ExternalId)
+		}
+	}
+	if utils.IsInStringArray(api.TERRAFORM_RESOURCE_SERVER, input.Resources) {
+		for i := range guests {
+			c.add(api.TERRAFORM_RESOURCE_SERVER, guests[i].GetCloudproviderId(), guests[i].Id, guests[i].Name, guests[i].ExternalId)
+		}
+	}
+	if utils.IsInStringArray(api.TERRAFORM_RESOURCE_LOADBALANCER, input.Resources) {
+		for i := range lbs {
+			c.add(api.TERRAFORM_RESOURCE_LOADBALANCER, lbs[i].ManagerId, lbs[i].Id, lbs[i].Name, lbs[i].ExternalId)
+		}
+	}
+
+	ret := &api.TerraformExportOutput{
+		Format:  input.Format,
+		Count:   len(c.resources),
+		Skipped: c.skipped,
+	}
+	if input.Format == api.TERRAFORM_FORMAT_STATE {
+		ret.Content, err = renderState(c.resources)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+	} else {
+		ret.Content = renderHCL(c.resources)
+	}
+	return ret, nil
+}
+
+func findResource(kind string, tenant *db.STenant, externalId string) (db.IStandaloneModel, error) {
+	var manager db.IModelManager
+	var q *sqlchemy.SQuery
+	switch kind {
+	case api.TERRAFORM_RESOURCE_SERVER:
+		manager = models.GuestManager
+		q = models.GuestManager.Query().Equals("tenant_id", tenant.Id)
+	case api.TERRAFORM_RESOURCE_NETWORK:
+		manager = models.NetworkManager
+		q = models.NetworkManager.Query().Equals("tenant_id", tenant.Id)
+	case api.TERRAFORM_RESOURCE_LOADBALANCER:
+		manager = models.LoadbalancerManager
+		q = models.LoadbalancerManager.Query().Equals("tenant_id", tenant.Id)
+	case api.TERRAFORM_RESOURCE_VPC:
+		manager = models.VpcManager
+		q = models.VpcManager.Query()
+		q = q.Filter(sqlchemy.OR(
+			sqlchemy.Equals(q.Field("domain_id"), tenant.DomainId),
+			sqlchemy.IsTrue(q.Field("is_public")),
+		))
+	default:
+		return nil, errors.Errorf("unsupported resource %s", kind)
+	}
+	// azure等平台的外部ID为小写
+	q = q.In("external_id", []string{externalId, strings.ToLower(externalId)})
+	obj, err := db.NewModelObject(manager)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewModelObject")
+	}
+	err = q.First(obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(db.IStandaloneModel), nil
+}
+
+// 按外部ID将state中的资源与已同步资源对应, 并在资源元数据中记录terraform地址
+func importState(ctx context.Context, userCred mcclient.TokenCredential, tenant *db.STenant, input api.TerraformImportInput) (*api.TerraformImportOutput, error) {
+	if input.State == nil {
+		return nil, httperrors.NewMissingParameterError("state")
+	}
+	addrs, err := parseState([]byte(input.State.String()))
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("invalid terraform state: %v", err)
+	}
+	ret := &api.TerraformImportOutput{
+		Matched:   []api.TerraformImportedResource{},
+		Unmatched: []string{},
+	}
+	for _, addr := range addrs {
+		kind, ok := getResourceKind(addr.tfType)
+		if !ok || len(addr.id) == 0 {
+			ret.Unmatched = append(ret.Unmatched, addr.address)
+			continue
+		}
+		obj, err := findResource(kind, tenant, addr.id)
+		if err != nil {
+			if errors.Cause(err) != sql.ErrNoRows {
+				return nil, httperrors.NewGeneralError(errors.Wrapf(err, "find %s %s", kind, addr.id))
+			}
+			ret.Unmatched = append(ret.Unmatched, addr.address)
+			continue
+		}
+		if !input.DryRun {
+			err = obj.SetMetadata(ctx, api.TERRAFORM_ADDRESS_METADATA_KEY, addr.address, userCred)
+			if err != nil {
+				return nil, httperrors.NewGeneralError(errors.Wrapf(err, "set metadata of %s %s", kind, obj.GetName()))
+			}
+		}
+		ret.Matched = append(ret.Matched, api.TerraformImportedResource{
+			Address:    addr.address,
+			Resource:   kind,
+			Id:         obj.GetId(),
+			Name:       obj.GetName(),
+			ExternalId: addr.id,
+		})
+	}
+	return ret, nil
+}
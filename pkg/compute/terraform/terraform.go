@@ -0,0 +1,273 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/stringutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+type sTerraformProvider struct {
+	// provider本地名称
+	name string
+	// registry地址
+	source string
+	// 资源类型到terraform资源类型的映射
+	resources map[string]string
+}
+
+var terraformProviders = map[string]sTerraformProvider{
+	api.CLOUD_PROVIDER_ALIYUN: {
+		name:   "alicloud",
+		source: "aliyun/alicloud",
+		resources: map[string]string{
+			api.TERRAFORM_RESOURCE_SERVER:       "alicloud_instance",
+			api.TERRAFORM_RESOURCE_VPC:          "alicloud_vpc",
+			api.TERRAFORM_RESOURCE_NETWORK:      "alicloud_vswitch",
+			api.TERRAFORM_RESOURCE_LOADBALANCER: "alicloud_slb_load_balancer",
+		},
+	},
+	api.CLOUD_PROVIDER_AWS: {
+		name:   "aws",
+		source: "hashicorp/aws",
+		resources: map[string]string{
+			api.TERRAFORM_RESOURCE_SERVER:       "aws_instance",
+			api.TERRAFORM_RESOURCE_VPC:          "aws_vpc",
+			api.TERRAFORM_RESOURCE_NETWORK:      "aws_subnet",
+			api.TERRAFORM_RESOURCE_LOADBALANCER: "aws_lb",
+		},
+	},
+	api.CLOUD_PROVIDER_QCLOUD: {
+		name:   "tencentcloud",
+		source: "tencentcloudstack/tencentcloud",
+		resources: map[string]string{
+			api.TERRAFORM_RESOURCE_SERVER:       "tencentcloud_instance",
+			api.TERRAFORM_RESOURCE_VPC:          "tencentcloud_vpc",
+			api.TERRAFORM_RESOURCE_NETWORK:      "tencentcloud_subnet",
+			api.TERRAFORM_RESOURCE_LOADBALANCER: "tencentcloud_clb_instance",
+		},
+	},
+	api.CLOUD_PROVIDER_HUAWEI: {
+		name:   "huaweicloud",
+		source: "huaweicloud/huaweicloud",
+		resources: map[string]string{
+			api.TERRAFORM_RESOURCE_SERVER:       "huaweicloud_compute_instance",
+			api.TERRAFORM_RESOURCE_VPC:          "huaweicloud_vpc",
+			api.TERRAFORM_RESOURCE_NETWORK:      "huaweicloud_vpc_subnet",
+			api.TERRAFORM_RESOURCE_LOADBALANCER: "huaweicloud_elb_loadbalancer",
+		},
+	},
+	api.CLOUD_PROVIDER_AZURE: {
+		name:   "azurerm",
+		source: "hashicorp/azurerm",
+		resources: map[string]string{
+			api.TERRAFORM_RESOURCE_SERVER:       "azurerm_virtual_machine",
+			api.TERRAFORM_RESOURCE_VPC:          "azurerm_virtual_network",
+			api.TERRAFORM_RESOURCE_NETWORK:      "azurerm_subnet",
+			api.TERRAFORM_RESOURCE_LOADBALANCER: "azurerm_lb",
+		},
+	},
+	api.CLOUD_PROVIDER_GOOGLE: {
+		name:   "google",
+		source: "hashicorp/google",
+		resources: map[string]string{
+			api.TERRAFORM_RESOURCE_SERVER:  "google_compute_instance",
+			api.TERRAFORM_RESOURCE_VPC:     "google_compute_network",
+			api.TERRAFORM_RESOURCE_NETWORK: "google_compute_subnetwork",
+		},
+	},
+}
+
+// 由terraform资源类型反查平台资源类型
+func getResourceKind(tfType string) (string, bool) {
+	for _, provider := range terraformProviders {
+		for kind, t := range provider.resources {
+			if t == tfType {
+				return kind, true
+			}
+		}
+	}
+	return "", false
+}
+
+type sTerraformResource struct {
+	kind       string
+	provider   sTerraformProvider
+	tfType     string
+	tfName     string
+	id         string
+	name       string
+	externalId string
+}
+
+func (res sTerraformResource) address() string {
+	return fmt.Sprintf("%s.%s", res.tfType, res.tfName)
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// 将资源名称转换为合法且在同一terraform资源类型内唯一的名称
+func terraformName(tfType, name string, used map[string]bool) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if len(name) == 0 {
+		name = "resource"
+	} else if name[0] != '_' && !(name[0] >= 'a' && name[0] <= 'z') && !(name[0] >= 'A' && name[0] <= 'Z') {
+		name = "_" + name
+	}
+	ret := name
+	for i := 2; used[tfType+"."+ret]; i++ {
+		ret = fmt.Sprintf("%s_%d", name, i)
+	}
+	used[tfType+"."+ret] = true
+	return ret
+}
+
+func getProviders(resources []sTerraformResource) []sTerraformProvider {
+	providers := map[string]sTerraformProvider{}
+	for _, res := range resources {
+		providers[res.provider.name] = res.provider
+	}
+	ret := []sTerraformProvider{}
+	for _, provider := range providers {
+		ret = append(ret, provider)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].name < ret[j].name })
+	return ret
+}
+
+// 生成terraform import块, 可配合terraform plan -generate-config-out生成资源配置
+func renderHCL(resources []sTerraformResource) string {
+	var sb strings.Builder
+	sb.WriteString("terraform {\n  required_providers {\n")
+	for _, provider := range getProviders(resources) {
+		sb.WriteString(fmt.Sprintf("    %s = {\n      source = %s\n    }\n", provider.name, strconv.Quote(provider.source)))
+	}
+	sb.WriteString("  }\n}\n")
+	for _, res := range resources {
+		sb.WriteString(fmt.Sprintf("\n# %s %s (%s)\n", res.kind, res.name, res.id))
+		sb.WriteString(fmt.Sprintf("import {\n  to = %s\n  id = %s\n}\n", res.address(), strconv.Quote(res.externalId)))
+	}
+	return sb.String()
+}
+
+type sTerraformState struct {
+	Version          int                       `json:"version"`
+	TerraformVersion string                    `json:"terraform_version"`
+	Serial           int                       `json:"serial"`
+	Lineage          string                    `json:"lineage"`
+	Outputs          map[string]interface{}    `json:"outputs"`
+	Resources        []sTerraformStateResource `json:"resources"`
+}
+
+type sTerraformStateResource struct {
+	Module    string                    `json:"module,omitempty"`
+	Mode      string                    `json:"mode"`
+	Type      string                    `json:"type"`
+	Name      string                    `json:"name"`
+	Provider  string                    `json:"provider"`
+	Instances []sTerraformStateInstance `json:"instances"`
+}
+
+type sTerraformStateInstance struct {
+	IndexKey      interface{}            `json:"index_key,omitempty"`
+	SchemaVersion int                    `json:"schema_version"`
+	Attributes    map[string]interface{} `json:"attributes"`
+}
+
+// 生成仅包含id和name属性的state v4文件, 使用前需执行terraform apply -refresh-only补全属性
+func renderState(resources []sTerraformResource) (string, error) {
+	state := sTerraformState{
+		Version:          4,
+		TerraformVersion: "1.5.0",
+		Serial:           1,
+		Lineage:          stringutils.UUID4(),
+		Outputs:          map[string]interface{}{},
+		Resources:        []sTerraformStateResource{},
+	}
+	for _, res := range resources {
+		state.Resources = append(state.Resources, sTerraformStateResource{
+			Mode:     "managed",
+			Type:     res.tfType,
+			Name:     res.tfName,
+			Provider: fmt.Sprintf("provider[\"registry.terraform.io/%s\"]", res.provider.source),
+			Instances: []sTerraformStateInstance{
+				{
+					Attributes: map[string]interface{}{
+						"id":   res.externalId,
+						"name": res.name,
+					},
+				},
+			},
+		})
+	}
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "json.MarshalIndent")
+	}
+	return string(content), nil
+}
+
+type sTerraformStateAddress struct {
+	address string
+	tfType  string
+	id      string
+}
+
+// 解析state v4文件中托管资源的地址及云上ID
+func parseState(content []byte) ([]sTerraformStateAddress, error) {
+	state := sTerraformState{}
+	err := json.Unmarshal(content, &state)
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	if state.Version != 4 {
+		return nil, errors.Errorf("unsupported terraform state version %d", state.Version)
+	}
+	ret := []sTerraformStateAddress{}
+	for _, res := range state.Resources {
+		if res.Mode != "managed" {
+			continue
+		}
+		prefix := fmt.Sprintf("%s.%s", res.Type, res.Name)
+		if len(res.Module) > 0 {
+			prefix = fmt.Sprintf("%s.%s", res.Module, prefix)
+		}
+		for _, instance := range res.Instances {
+			address := prefix
+			switch key := instance.IndexKey.(type) {
+			case float64:
+				address = fmt.Sprintf("%s[%d]", prefix, int64(key))
+			case string:
+				address = fmt.Sprintf("%s[%s]", prefix, strconv.Quote(key))
+			}
+			id, _ := instance.Attributes["id"].(string)
+			ret = append(ret, sTerraformStateAddress{
+				address: address,
+				tfType:  res.Type,
+				id:      id,
+			})
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"strings"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestTerraformName(t *testing.T) {
+	used := map[string]bool{}
+	cases := []struct {
+		tfType string
+		name   string
+		want   string
+	}{
+		{"alicloud_instance", "web-1", "web-1"},
+		{"alicloud_instance", "web-1", "web-1_2"},
+		{"alicloud_vpc", "web-1", "web-1"},
+		{"alicloud_instance", "1st.vm", "_1st_vm"},
+		{"alicloud_instance", "", "resource"},
+		{"alicloud_instance", "主机", "__"},
+	}
+	for i, c := range cases {
+		if got := terraformName(c.tfType, c.name, used); got != c.want {
+			t.Errorf("case %d: want %s got %s", i, c.want, got)
+		}
+	}
+}
+
+func TestRenderHCL(t *testing.T) {
+	provider := terraformProviders[api.CLOUD_PROVIDER_ALIYUN]
+	content := renderHCL([]sTerraformResource{
+		{
+			kind:       api.TERRAFORM_RESOURCE_SERVER,
+			provider:   provider,
+			tfType:     "alicloud_instance",
+			tfName:     "web",
+			id:         "b4c6f3c2",
+			name:       "web",
+			externalId: "i-bp1abc",
+		},
+	})
+	for _, want := range []string{`source = "aliyun/alicloud"`, "to = alicloud_instance.web", `id = "i-bp1abc"`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("hcl should contain %s: %s", want, content)
+		}
+	}
+}
+
+func TestParseState(t *testing.T) {
+	state := `{
+  "version": 4,
+  "resources": [
+    {"mode": "data", "type": "alicloud_zones", "name": "default", "instances": [{"attributes": {"id": "zones"}}]},
+    {"mode": "managed", "type": "alicloud_vpc", "name": "main", "instances": [{"attributes": {"id": "vpc-1"}}]},
+    {"module": "module.app", "mode": "managed", "type": "aws_instance", "name": "web", "instances": [
+      {"index_key": 0, "attributes": {"id": "i-0"}},
+      {"index_key": "b", "attributes": {"id": "i-b"}}
+    ]}
+  ]
+}`
+	addrs, err := parseState([]byte(state))
+	if err != nil {
+		t.Fatalf("parseState error: %v", err)
+	}
+	want := []sTerraformStateAddress{
+		{address: "alicloud_vpc.main", tfType: "alicloud_vpc", id: "vpc-1"},
+		{address: "module.app.aws_instance.web[0]", tfType: "aws_instance", id: "i-0"},
+		{address: `module.app.aws_instance.web["b"]`, tfType: "aws_instance", id: "i-b"},
+	}
+	if len(addrs) != len(want) {
+		t.Fatalf("want %d addresses got %d", len(want), len(addrs))
+	}
+	for i := range want {
+		if addrs[i] != want[i] {
+			t.Errorf("case %d: want %+v got %+v", i, want[i], addrs[i])
+		}
+	}
+	_, err = parseState([]byte(`{"version": 3}`))
+	if err == nil {
+		t.Errorf("state version 3 should be rejected")
+	}
+}
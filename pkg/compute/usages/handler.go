@@ -173,6 +173,8 @@ func AddUsageHandler(prefix string, app *appsrv.Application) {
 	} {
 		addHandler(prefix, key, f, app)
 	}
+	// 供 Prometheus 抓取的用量指标
+	app.AddHandler2("GET", fmt.Sprintf("%s/metrics", prefix), auth.Authenticate(usageMetricsHandler), nil, "get_usage_metrics", nil)
 }
 
 func response(w http.ResponseWriter, obj interface{}) {
@@ -0,0 +1,200 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usages
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+)
+
+const (
+	usageMetricPrefix = "onecloud_compute_"
+
+	contentTypePrometheusText = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics    = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+var usageMetricLabelNames = []string{"provider", "cloudprovider", "cloudregion", "project", "project_domain"}
+
+type sUsageMetricSample struct {
+	labels []string
+	value  int64
+}
+
+type sUsageMetricFamily struct {
+	name    string
+	help    string
+	samples []sUsageMetricSample
+}
+
+func (f *sUsageMetricFamily) add(labels []string, value int64) {
+	f.samples = append(f.samples, sUsageMetricSample{labels: labels, value: value})
+}
+
+func escapeMetricLabelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return v
+}
+
+// 按 Prometheus 文本格式或 OpenMetrics 格式输出指标
+func renderUsageMetrics(families []*sUsageMetricFamily, openMetrics bool) string {
+	buf := strings.Builder{}
+	for _, family := range families {
+		name := usageMetricPrefix + family.name
+		buf.WriteString(fmt.Sprintf("# HELP %s %s\n", name, family.help))
+		buf.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+		lines := make([]string, 0, len(family.samples))
+		for _, sample := range family.samples {
+			labels := make([]string, 0, len(sample.labels))
+			for i, v := range sample.labels {
+				labels = append(labels, fmt.Sprintf(`%s="%s"`, usageMetricLabelNames[i], escapeMetricLabelValue(v)))
+			}
+			lines = append(lines, fmt.Sprintf("%s{%s} %d\n", name, strings.Join(labels, ","), sample.value))
+		}
+		sort.Strings(lines)
+		for _, line := range lines {
+			buf.WriteString(line)
+		}
+	}
+	if openMetrics {
+		buf.WriteString("# EOF\n")
+	}
+	return buf.String()
+}
+
+type sUsageMetricLabeler struct {
+	ctx       context.Context
+	providers map[string][2]string
+	regions   map[string]string
+	projects  map[string][2]string
+}
+
+func newUsageMetricLabeler(ctx context.Context) *sUsageMetricLabeler {
+	return &sUsageMetricLabeler{
+		ctx:       ctx,
+		providers: map[string][2]string{},
+		regions:   map[string]string{},
+		projects:  map[string][2]string{},
+	}
+}
+
+func (l *sUsageMetricLabeler) labels(row models.SUsageMetricRow) []string {
+	provider, ok := l.providers[row.ManagerId]
+	if !ok {
+		provider = [2]string{api.CLOUD_PROVIDER_ONECLOUD, ""}
+		if len(row.ManagerId) > 0 {
+			if manager := models.CloudproviderManager.FetchCloudproviderById(row.ManagerId); manager != nil {
+				provider = [2]string{manager.Provider, manager.Name}
+			}
+		}
+		l.providers[row.ManagerId] = provider
+	}
+	region, ok := l.regions[row.CloudregionId]
+	if !ok {
+		region = row.CloudregionId
+		if len(row.CloudregionId) > 0 {
+			if r := models.CloudregionManager.FetchRegionById(row.CloudregionId); r != nil {
+				region = r.Name
+			}
+		}
+		l.regions[row.CloudregionId] = region
+	}
+	project, ok := l.projects[row.ProjectId]
+	if !ok {
+		project = [2]string{row.ProjectId, row.DomainId}
+		if tenant, err := db.TenantCacheManager.FetchTenantById(l.ctx, row.ProjectId); err == nil {
+			project = [2]string{tenant.Name, tenant.Domain}
+		}
+		l.projects[row.ProjectId] = project
+	}
+	return []string{provider[0], provider[1], region, project[0], project[1]}
+}
+
+func usageMetricsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	userCred := auth.FetchUserCredential(ctx, policy.FilterPolicyCredential)
+	ownerId, scope, err, _ := db.FetchUsageOwnerScope(ctx, userCred, getQuery(r))
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+
+	guests := &sUsageMetricFamily{name: "guests", help: "Number of guests."}
+	guestCpu := &sUsageMetricFamily{name: "guest_cpu_cores", help: "Total vCPU cores of guests."}
+	guestMem := &sUsageMetricFamily{name: "guest_memory_bytes", help: "Total memory of guests in bytes."}
+	disks := &sUsageMetricFamily{name: "disks", help: "Number of disks."}
+	diskSize := &sUsageMetricFamily{name: "disk_size_bytes", help: "Total size of disks in bytes."}
+	eips := &sUsageMetricFamily{name: "eips", help: "Number of elastic public ips."}
+	eipBw := &sUsageMetricFamily{name: "eip_bandwidth_mbps", help: "Total bandwidth of elastic public ips in Mbps."}
+
+	labeler := newUsageMetricLabeler(ctx)
+	guestRows, err := models.GuestManager.GetUsageMetrics(scope, ownerId)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	for _, row := range guestRows {
+		labels := labeler.labels(row)
+		guests.add(labels, int64(row.Count))
+		guestCpu.add(labels, int64(row.Cpu))
+		guestMem.add(labels, row.Memory*1024*1024)
+	}
+	diskRows, err := models.DiskManager.GetUsageMetrics(scope, ownerId)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	for _, row := range diskRows {
+		labels := labeler.labels(row)
+		disks.add(labels, int64(row.Count))
+		diskSize.add(labels, row.Storage*1024*1024)
+	}
+	eipRows, err := models.ElasticipManager.GetUsageMetrics(scope, ownerId)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	for _, row := range eipRows {
+		labels := labeler.labels(row)
+		eips.add(labels, int64(row.Count))
+		eipBw.add(labels, row.Bandwidth)
+	}
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	body := renderUsageMetrics([]*sUsageMetricFamily{guests, guestCpu, guestMem, disks, diskSize, eips, eipBw}, openMetrics)
+	if openMetrics {
+		w.Header().Set("Content-Type", contentTypeOpenMetrics)
+	} else {
+		w.Header().Set("Content-Type", contentTypePrometheusText)
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte(body))
+	if err != nil {
+		log.Errorf("write usage metrics error: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usages
+
+import (
+	"testing"
+)
+
+func TestRenderUsageMetrics(t *testing.T) {
+	family := &sUsageMetricFamily{name: "guests", help: "Number of guests."}
+	family.add([]string{"Aliyun", "ali-prod", "cn-beijing", "dev", "Default"}, 3)
+	family.add([]string{"OneCloud", "", "", `a"b`, "Default"}, 1)
+
+	want := `# HELP onecloud_compute_guests Number of guests.
+# TYPE onecloud_compute_guests gauge
+onecloud_compute_guests{provider="Aliyun",cloudprovider="ali-prod",cloudregion="cn-beijing",project="dev",project_domain="Default"} 3
+onecloud_compute_guests{provider="OneCloud",cloudprovider="",cloudregion="",project="a\"b",project_domain="Default"} 1
+`
+	got := renderUsageMetrics([]*sUsageMetricFamily{family}, false)
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+	got = renderUsageMetrics([]*sUsageMetricFamily{family}, true)
+	if got != want+"# EOF\n" {
+		t.Errorf("openmetrics output should end with # EOF, got:\n%s", got)
+	}
+}
@@ -242,30 +242,41 @@ func (asc *SASController) Scale(ctx context.Context, userCred mcclient.TokenCred
 	num := sg.DesireInstanceNumber - total
 	switch {
 	case num > 0:
-		// check guest template
-		gt := sg.GetGuestTemplate()
-		if gt == nil {
-			setFail(scalingActivity, fmt.Sprintf("fetch GuestTemplate of ScalingGroup '%s' error", sg.Id))
-			return
-		}
-		nets, err := sg.NetworkIds()
+		targets, err := sg.Targets()
 		if err != nil {
-			setFail(scalingActivity, fmt.Sprintf("fetch Networks of ScalingGroup '%s' error", sg.Id))
+			setFail(scalingActivity, fmt.Sprintf("fetch Targets of ScalingGroup '%s' error", sg.Id))
 			return
 		}
-		valid, msg := gt.Validate(context.TODO(), auth.AdminCredential(), gt.GetOwnerId(),
-			models.SGuestTemplateValidate{
-				Hypervisor:    sg.Hypervisor,
-				CloudregionId: sg.CloudregionId,
-				VpcId:         sg.VpcId,
-				NetworkIds:    nets,
-			},
-		)
-		if !valid {
-			err = scalingActivity.SetReject("", msg)
-			return
+		var succeedInstances []SInstance
+		if len(targets) > 0 {
+			succeedInstances, err = asc.CreateTargetInstances(ctx, userCred, ownerId, sg, targets, num)
+		} else {
+			// check guest template
+			gt := sg.GetGuestTemplate()
+			if gt == nil {
+				setFail(scalingActivity, fmt.Sprintf("fetch GuestTemplate of ScalingGroup '%s' error", sg.Id))
+				return
+			}
+			var nets []string
+			nets, err = sg.NetworkIds()
+			if err != nil {
+				setFail(scalingActivity, fmt.Sprintf("fetch Networks of ScalingGroup '%s' error", sg.Id))
+				return
+			}
+			valid, msg := gt.Validate(context.TODO(), auth.AdminCredential(), gt.GetOwnerId(),
+				models.SGuestTemplateValidate{
+					Hypervisor:    sg.Hypervisor,
+					CloudregionId: sg.CloudregionId,
+					VpcId:         sg.VpcId,
+					NetworkIds:    nets,
+				},
+			)
+			if !valid {
+				err = scalingActivity.SetReject("", msg)
+				return
+			}
+			succeedInstances, err = asc.CreateInstances(ctx, userCred, ownerId, sg, gt, nets[0], num, nil)
 		}
-		succeedInstances, err := asc.CreateInstances(ctx, userCred, ownerId, sg, gt, nets[0], num)
 		switch len(succeedInstances) {
 		case 0:
 			setFail(scalingActivity, fmt.Sprintf("All instances create failed: %s", err.Error()))
@@ -395,7 +406,33 @@ Loop:
 }
 
 func (asc *SASController) findSuitableInstance(sg *models.SScalingGroup, num int) ([]models.SGuest, error) {
-	ggSubQ := models.ScalingGroupGuestManager.Query("guest_id").Equals("scaling_group_id", sg.Id).SubQuery()
+	targets, err := sg.Targets()
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch targets")
+	}
+	if len(targets) == 0 {
+		ggSubQ := models.ScalingGroupGuestManager.Query("guest_id").Equals("scaling_group_id", sg.Id).SubQuery()
+		return asc.fetchShrinkInstances(sg, ggSubQ, num)
+	}
+	counts, err := sg.TargetInstanceCounts()
+	if err != nil {
+		return nil, errors.Wrap(err, "TargetInstanceCounts")
+	}
+	// 按权重从实例占比过高的目标中缩容
+	plan := models.PlanScaleIn(targets, counts, num)
+	guests := make([]models.SGuest, 0, num)
+	for targetId, n := range plan {
+		ggSubQ := models.ScalingGroupGuestManager.Query("guest_id").Equals("scaling_group_id", sg.Id).Equals("target_id", targetId).SubQuery()
+		ret, err := asc.fetchShrinkInstances(sg, ggSubQ, n)
+		if err != nil {
+			return nil, err
+		}
+		guests = append(guests, ret...)
+	}
+	return guests, nil
+}
+
+func (asc *SASController) fetchShrinkInstances(sg *models.SScalingGroup, ggSubQ *sqlchemy.SSubQuery, num int) ([]models.SGuest, error) {
 	guestQ := models.GuestManager.Query().In("id", ggSubQ)
 	switch sg.ShrinkPrinciple {
 	case compute.SHRINK_EARLIEST_CREATION_FIRST:
@@ -462,6 +499,7 @@ func (asc *SASController) CreateInstances(
 	gt *models.SGuestTemplate,
 	defaultNet string,
 	num int,
+	target *models.SScalingGroupTarget,
 ) ([]SInstance, error) {
 	// build the create request data
 	content := gt.Content.(*jsonutils.JSONDict)
@@ -503,8 +541,12 @@ func (asc *SASController) CreateInstances(
 	}
 
 	// second stage: joining scaling group
+	targetId, hypervisor := "", sg.Hypervisor
+	if target != nil {
+		targetId, hypervisor = target.Id, target.Hypervisor
+	}
 	for _, instance := range succeedList {
-		err := models.ScalingGroupGuestManager.Attach(ctx, sg.Id, instance.ID, false)
+		err := models.ScalingGroupGuestManager.Attach(ctx, sg.Id, instance.ID, targetId, false)
 		if err != nil {
 			log.Errorf("Attach ScalingGroup '%s' with Guest '%s' failed", sg.Id, instance.ID)
 		}
@@ -521,7 +563,7 @@ func (asc *SASController) CreateInstances(
 	}
 	// check all server's status
 	var waitLimit, waitinterval time.Duration
-	if sg.Hypervisor == compute.HYPERVISOR_KVM {
+	if hypervisor == compute.HYPERVISOR_KVM {
 		waitLimit = 5 * time.Minute
 		waitinterval = 3 * time.Second
 	} else {
@@ -567,6 +609,76 @@ func (asc *SASController) CreateInstances(
 	return instances, fmt.Errorf(failRecord.String())
 }
 
+// CreateTargetInstances 按权重在多个分布目标中创建实例, 某个目标创建失败(例如配额不足)时进入冷却,
+// 未能创建的实例按优先级转移到其他目标
+func (asc *SASController) CreateTargetInstances(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	sg *models.SScalingGroup,
+	targets []models.SScalingGroupTarget,
+	num int,
+) ([]SInstance, error) {
+	now := time.Now()
+	available := make([]models.SScalingGroupTarget, 0, len(targets))
+	for i := range targets {
+		if targets[i].IsAvailable(now) {
+			available = append(available, targets[i])
+		}
+	}
+	counts, err := sg.TargetInstanceCounts()
+	if err != nil {
+		return nil, errors.Wrap(err, "TargetInstanceCounts")
+	}
+	cooldown := time.Duration(asc.options.TargetFailoverCooldown) * time.Minute
+
+	failedList := make([]string, 0)
+	succeedList := make([]SInstance, 0, num)
+	failed := map[string]bool{}
+	create := func(target *models.SScalingGroupTarget, count int) int {
+		gt, err := target.ValidateTemplate(ctx, auth.AdminCredential())
+		var instances []SInstance
+		if err == nil {
+			instances, err = asc.CreateInstances(ctx, userCred, ownerId, sg, gt, target.NetworkId, count, target)
+			succeedList = append(succeedList, instances...)
+		}
+		if len(instances) < count {
+			reason := fmt.Sprintf("target %s(%s) create %d of %d instances: %v", target.Hypervisor, target.CloudregionId, len(instances), count, err)
+			failedList = append(failedList, reason)
+			failed[target.Id] = true
+			if e := target.MarkUnavailable(reason, cooldown); e != nil {
+				log.Errorf("MarkUnavailable for scaling target %s: %v", target.Id, e)
+			}
+		}
+		return len(instances)
+	}
+
+	remain := 0
+	plan := models.PlanScaleOut(available, counts, num)
+	for i := range available {
+		if count := plan[available[i].Id]; count > 0 {
+			remain += count - create(&available[i], count)
+		}
+	}
+	planned := 0
+	for _, count := range plan {
+		planned += count
+	}
+	remain += num - planned
+	// 故障转移: 将未能创建的实例按优先级转移到其他目标
+	for _, target := range models.FailoverTargets(available, failed) {
+		if remain <= 0 {
+			break
+		}
+		target := target
+		remain -= create(&target, remain)
+	}
+	if remain > 0 {
+		failedList = append(failedList, fmt.Sprintf("%d instances could not be placed on any target", remain))
+	}
+	return succeedList, fmt.Errorf(strings.Join(failedList, "; "))
+}
+
 type SCreateRet struct {
 	Id     string
 	Status string
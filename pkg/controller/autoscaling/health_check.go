@@ -16,6 +16,7 @@ package autoscaling
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"yunion.io/x/jsonutils"
@@ -41,16 +42,17 @@ type sUnnormalGuest struct {
 	Status             string    `json:"status"`
 	ScalngGroupId      string    `json:"scaling_group_id"`
 	CreateCompleteTime time.Time `json:"create_complete_time"`
+	TargetId           string    `json:"target_id"`
 }
 
 func (asc *SASController) HealthCheckSql() *sqlchemy.SQuery {
 	now := time.Now()
 	sgSubQ := models.ScalingGroupManager.Query("id").IsTrue("enabled").LT("next_check_time", now).SubQuery()
-	sggQ := models.ScalingGroupGuestManager.Query("guest_id", "scaling_group_id", "updated_at").Equals("guest_status", apis.SG_GUEST_STATUS_READY)
+	sggQ := models.ScalingGroupGuestManager.Query("guest_id", "scaling_group_id", "updated_at", "target_id").Equals("guest_status", apis.SG_GUEST_STATUS_READY)
 	sggSubQ := sggQ.Join(sgSubQ, sqlchemy.Equals(sgSubQ.Field("id"), sggQ.Field("scaling_group_id"))).SubQuery()
 	q := models.GuestManager.Query("id", "status").In("status", UnhealthStatus)
 	q = q.Join(sggSubQ, sqlchemy.Equals(q.Field("id"), sggSubQ.Field("guest_id")))
-	q = q.AppendField(sggSubQ.Field("scaling_group_id"), sggSubQ.Field("updated_at", "create_complete_time"))
+	q = q.AppendField(sggSubQ.Field("scaling_group_id"), sggSubQ.Field("updated_at", "create_complete_time"), sggSubQ.Field("target_id"))
 	return q
 }
 
@@ -64,7 +66,7 @@ func (asc *SASController) CheckInstanceHealth(ctx context.Context, userCred mccl
 	}
 	for rows.Next() {
 		var ug sUnnormalGuest
-		rows.Scan(&ug.Id, &ug.Status, &ug.ScalngGroupId, &ug.CreateCompleteTime)
+		rows.Scan(&ug.Id, &ug.Status, &ug.ScalngGroupId, &ug.CreateCompleteTime, &ug.TargetId)
 		scalingGroupIdSet.Insert(ug.ScalngGroupId)
 		unnormalGuests = append(unnormalGuests, ug)
 	}
@@ -104,11 +106,15 @@ func (asc *SASController) CheckInstanceHealth(ctx context.Context, userCred mccl
 	removeParams.Set("delete_server", jsonutils.JSONTrue)
 	removeParams.Set("auto", jsonutils.JSONTrue)
 	session := auth.GetSession(ctx, userCred, "")
+	unhealthyTargets := make(map[string]int)
 	for i := range unnormalGuests {
 		ug := unnormalGuests[i]
 		if ug.CreateCompleteTime.Add(time.Duration(scalingGroupMap[ug.ScalngGroupId].HealthCheckGov) * time.Second).After(now) {
 			continue
 		}
+		if len(ug.TargetId) > 0 {
+			unhealthyTargets[ug.TargetId] += 1
+		}
 		if ug.Status == apis.VM_READY {
 			readyGuestList = append(readyGuestList, ug.Id)
 			readyGuestMap[ug.Id] = ug.ScalngGroupId
@@ -121,6 +127,8 @@ func (asc *SASController) CheckInstanceHealth(ctx context.Context, userCred mccl
 		}
 	}
 
+	asc.checkTargetHealth(scalingGroupMap, unhealthyTargets)
+
 	// check NextCheckTime for ScalngGroup
 
 	if len(readyGuestList) > 0 {
@@ -149,3 +157,32 @@ func (asc *SASController) CheckInstanceHealth(ctx context.Context, userCred mccl
 		}()
 	}
 }
+
+// checkTargetHealth 分布目标内的实例全部不健康时, 目标进入冷却, 替换实例会转移到其他目标
+func (asc *SASController) checkTargetHealth(scalingGroupMap map[string]*models.SScalingGroup, unhealthyTargets map[string]int) {
+	if len(unhealthyTargets) == 0 {
+		return
+	}
+	cooldown := time.Duration(asc.options.TargetFailoverCooldown) * time.Minute
+	for _, sg := range scalingGroupMap {
+		targets, err := sg.Targets()
+		if err != nil || len(targets) == 0 {
+			continue
+		}
+		counts, err := sg.TargetInstanceCounts()
+		if err != nil {
+			log.Errorf("TargetInstanceCounts for ScalingGroup '%s': %s", sg.GetId(), err)
+			continue
+		}
+		for i := range targets {
+			unhealthy := unhealthyTargets[targets[i].Id]
+			if unhealthy == 0 || unhealthy < counts[targets[i].Id] {
+				continue
+			}
+			reason := fmt.Sprintf("all %d instances are unhealthy", unhealthy)
+			if err := targets[i].MarkUnavailable(reason, cooldown); err != nil {
+				log.Errorf("MarkUnavailable for scaling target %s: %s", targets[i].Id, err)
+			}
+		}
+	}
+}
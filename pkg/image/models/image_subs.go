@@ -21,10 +21,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
 
 	api "yunion.io/x/onecloud/pkg/apis/image"
 	noapi "yunion.io/x/onecloud/pkg/apis/notify"
@@ -73,6 +75,11 @@ type SImageSubformat struct {
 	TorrentLocation string `nullable:"true"`
 	TorrentChecksum string `width:"32" charset:"ascii" nullable:"true"`
 	TorrentStatus   string `nullable:"false"`
+
+	// 格式转换进度
+	Progress float32 `nullable:"false" default:"0"`
+	// 是否为按需转换的格式, 按需转换的格式不受 target_image_formats 配置清理
+	ConvertOnDemand bool `nullable:"false" default:"false"`
 }
 
 func (manager *SImageSubformatManager) FetchSubImage(id string, format string) *SImageSubformat {
@@ -104,6 +111,28 @@ func (manager *SImageSubformatManager) GetAllSubImages(id string) []SImageSubfor
 	return subImgs
 }
 
+// isWanted 判断该格式是否需要保留
+func (self *SImageSubformat) isWanted(image *SImage) bool {
+	return self.ConvertOnDemand || self.Format == image.DiskFormat || utils.IsInStringArray(self.Format, options.Options.TargetImageFormats)
+}
+
+func (manager *SImageSubformatManager) newOnDemandSubformat(ctx context.Context, image *SImage, format string) (*SImageSubformat, error) {
+	subformat := &SImageSubformat{}
+	subformat.SetModelManager(manager, subformat)
+
+	subformat.ImageId = image.Id
+	subformat.Format = format
+	subformat.Status = api.IMAGE_STATUS_QUEUED
+	subformat.TorrentStatus = api.IMAGE_STATUS_QUEUED
+	subformat.ConvertOnDemand = true
+
+	err := manager.TableSpec().Insert(ctx, subformat)
+	if err != nil {
+		return nil, errors.Wrapf(err, "insert subformat %s", format)
+	}
+	return subformat, nil
+}
+
 func (self *SImageSubformat) isLocal() bool {
 	return strings.HasPrefix(self.Location, LocalFilePrefix)
 }
@@ -145,13 +174,17 @@ func (self *SImageSubformat) Save(image *SImage) error {
 	}
 	_, err = db.Update(self, func() error {
 		self.Status = api.IMAGE_STATUS_SAVING
+		self.Progress = 0
 		return nil
 	})
 	if err != nil {
 		log.Errorf("updateStatus fail %s", err)
 		return err
 	}
+	done := make(chan struct{})
+	go self.watchConvertProgress(image, done)
 	info, err := storage.ConvertImage(context.Background(), image, self.Format)
+	close(done)
 	if err != nil {
 		return errors.Wrap(err, "unable to ConvertImage")
 	}
@@ -172,6 +205,7 @@ func (self *SImageSubformat) Save(image *SImage) error {
 		self.FastHash = fastHash
 		self.Size = info.SizeBytes
 		self.Status = api.IMAGE_STATUS_ACTIVE
+		self.Progress = 100
 		return nil
 	})
 	if err != nil {
@@ -181,6 +215,35 @@ func (self *SImageSubformat) Save(image *SImage) error {
 	return nil
 }
 
+// watchConvertProgress 根据目标文件大小估算转换进度, qemu-img 转换后的文件大小与源文件不一定一致, 转换完成前进度最多为99%
+func (self *SImageSubformat) watchConvertProgress(image *SImage, done chan struct{}) {
+	if image.Size <= 0 {
+		return
+	}
+	location := image.GetPath(self.Format)
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			fi, err := os.Stat(location)
+			if err != nil {
+				continue
+			}
+			progress := float32(fi.Size()) * 100 / float32(image.Size)
+			if progress > 99 {
+				progress = 99
+			}
+			db.Update(self, func() error {
+				self.Progress = progress
+				return nil
+			})
+		}
+	}
+}
+
 func (self *SImageSubformat) SaveTorrent() error {
 	if self.TorrentStatus == api.IMAGE_STATUS_ACTIVE {
 		return nil
@@ -286,6 +349,9 @@ type SImageSubformatDetails struct {
 	Checksum string
 	FastHash string
 	Status   string
+	Progress float32
+
+	ConvertOnDemand bool
 
 	TorrentSize     int64
 	TorrentChecksum string
@@ -302,6 +368,8 @@ func (self *SImageSubformat) GetDetails() SImageSubformatDetails {
 	details.Checksum = self.Checksum
 	details.FastHash = self.FastHash
 	details.Status = self.Status
+	details.Progress = self.Progress
+	details.ConvertOnDemand = self.ConvertOnDemand
 	details.TorrentSize = self.TorrentSize
 	details.TorrentChecksum = self.TorrentChecksum
 	details.TorrentStatus = self.TorrentStatus
@@ -45,6 +45,7 @@ import (
 	noapi "yunion.io/x/onecloud/pkg/apis/notify"
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
@@ -1127,7 +1128,7 @@ func (self *SImage) doConvertAllSubformats() error {
 		if subimgs[i].Status == api.IMAGE_STATUS_ACTIVE {
 			continue
 		}
-		if !utils.IsInStringArray(subimgs[i].Format, options.Options.TargetImageFormats) {
+		if !subimgs[i].isWanted(self) {
 			// cleanup
 			continue
 		}
@@ -1632,6 +1633,60 @@ func (img *SImage) PerformProbe(ctx context.Context, userCred mcclient.TokenCred
 	return nil, nil
 }
 
+// 将镜像转换为指定格式, 用于上传到要求特定镜像格式的云平台
+func (img *SImage) PerformConvert(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.PerformConvertInput) (jsonutils.JSONObject, error) {
+	if img.Status != api.IMAGE_STATUS_ACTIVE {
+		return nil, httperrors.NewInvalidStatusError("cannot convert in status %s", img.Status)
+	}
+	if img.IsIso() {
+		return nil, httperrors.NewUnsupportOperationError("cannot convert iso image")
+	}
+	if img.isEncrypted() {
+		return nil, httperrors.NewUnsupportOperationError("cannot convert encrypted image")
+	}
+	if len(input.Format) == 0 {
+		return nil, httperrors.NewMissingParameterError("format")
+	}
+	if !qemuimgfmt.IsSupportedImageFormat(input.Format) {
+		return nil, httperrors.NewInputParameterError("unsupported image format %s", input.Format)
+	}
+
+	lockman.LockObject(ctx, img)
+	defer lockman.ReleaseObject(ctx, img)
+
+	subimg := ImageSubformatManager.FetchSubImage(img.Id, input.Format)
+	if subimg == nil {
+		var err error
+		subimg, err = ImageSubformatManager.newOnDemandSubformat(ctx, img, input.Format)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+	}
+	if subimg.Status == api.IMAGE_STATUS_ACTIVE || subimg.Status == api.IMAGE_STATUS_SAVING {
+		return jsonutils.Marshal(subimg.GetDetails()), nil
+	}
+	err := subimg.SetStatus(api.IMAGE_STATUS_SAVING)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	err = img.StartImageConvertTask(ctx, userCred, input.Format, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "StartImageConvertTask")
+	}
+	return jsonutils.Marshal(subimg.GetDetails()), nil
+}
+
+func (img *SImage) StartImageConvertTask(ctx context.Context, userCred mcclient.TokenCredential, format string, parentTaskId string) error {
+	params := jsonutils.NewDict()
+	params.Set("format", jsonutils.NewString(format))
+	task, err := taskman.TaskManager.NewTask(ctx, "ImageConvertTask", img, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return err
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
 func (img *SImage) PerformChangeOwner(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformChangeProjectOwnerInput) (jsonutils.JSONObject, error) {
 	ret, err := img.SVirtualResourceBase.PerformChangeOwner(ctx, userCred, query, input)
 	if err != nil {
@@ -1887,7 +1942,7 @@ func (img *SImage) doConvert(ctx context.Context, userCred mcclient.TokenCredent
 		needConvert = true
 	} else {
 		for i := 0; i < len(subimgs); i += 1 {
-			if !utils.IsInStringArray(subimgs[i].Format, options.Options.TargetImageFormats) && subimgs[i].Format != img.DiskFormat {
+			if !subimgs[i].isWanted(img) {
 				// no need to have this subformat
 				err := subimgs[i].cleanup(ctx, userCred)
 				if err != nil {
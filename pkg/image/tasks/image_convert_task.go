@@ -0,0 +1,76 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/image/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type ImageConvertTask struct {
+	taskman.STask
+}
+
+func init() {
+	convertWorker := appsrv.NewWorkerManager("ImageConvertTaskWorkerManager", 2, 1024, true)
+	taskman.RegisterTaskAndWorker(ImageConvertTask{}, convertWorker)
+}
+
+func (self *ImageConvertTask) taskFailed(ctx context.Context, image *models.SImage, reason jsonutils.JSONObject) {
+	db.OpsLog.LogEvent(image, db.ACT_CONVERT_FAIL, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, image, logclient.ACT_IMAGE_CONVERT, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *ImageConvertTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	image := obj.(*models.SImage)
+
+	format, _ := self.GetParams().GetString("format")
+	subimg := models.ImageSubformatManager.FetchSubImage(image.Id, format)
+	if subimg == nil {
+		self.taskFailed(ctx, image, jsonutils.NewString(fmt.Sprintf("subformat %s not found", format)))
+		return
+	}
+
+	db.OpsLog.LogEvent(image, db.ACT_CONVERT_START, format, self.UserCred)
+	self.SetStage("OnConvertComplete", nil)
+
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		err := subimg.Save(image)
+		if err != nil {
+			return nil, err
+		}
+		return jsonutils.Marshal(subimg.GetDetails()), nil
+	})
+}
+
+func (self *ImageConvertTask) OnConvertComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	image := obj.(*models.SImage)
+	db.OpsLog.LogEvent(image, db.ACT_CONVERT_COMPLETE, data, self.UserCred)
+	logclient.AddActionLogWithStartable(self, image, logclient.ACT_IMAGE_CONVERT, data, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *ImageConvertTask) OnConvertCompleteFailed(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, obj.(*models.SImage), data)
+}
@@ -20,14 +20,22 @@ import (
 )
 
 var (
-	Cloudevents modulebase.ResourceManager
+	Cloudevents                 modulebase.ResourceManager
+	CloudeventRetentionPolicies modulebase.ResourceManager
 )
 
 func init() {
 	Cloudevents = modules.NewCloudeventManager("cloudevent", "cloudevents",
 		[]string{"Action", "Service", "Success",
+			"Actor", "Verb", "Resource",
 			"Resource_Type", "Cloudprovider_Id", "Manager", "Provider", "Domain", "Domain_Id"},
 		[]string{})
 
 	modules.Register(&Cloudevents)
+
+	CloudeventRetentionPolicies = modules.NewCloudeventManager("cloudevent_retention_policy", "cloudevent_retention_policies",
+		[]string{"Id", "Name", "Keep_Days", "Domain_Id", "Project_Domain"},
+		[]string{})
+
+	modules.Register(&CloudeventRetentionPolicies)
 }
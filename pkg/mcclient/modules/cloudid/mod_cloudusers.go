@@ -15,15 +15,10 @@
 package cloudid
 
 import (
-	"fmt"
-	"net/url"
-	"strings"
-
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/utils"
 
-	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
@@ -53,11 +48,8 @@ func (this *SClouduserManager) GetLoginInfo(s *mcclient.ClientSession, id string
 	}
 
 	user := struct {
-		Id          string
-		Name        string
-		Secret      string
-		Provider    string
-		IamLoginUrl string
+		Id     string
+		Secret string
 	}{}
 
 	err = data.Unmarshal(&user)
@@ -74,32 +66,25 @@ func (this *SClouduserManager) GetLoginInfo(s *mcclient.ClientSession, id string
 		return nil, errors.Wrap(err, "Descrypt")
 	}
 
-	account := ""
-
-	switch user.Provider {
-	case api.CLOUD_PROVIDER_ALIYUN:
-		suffix := strings.TrimPrefix(user.IamLoginUrl, "https://signin.aliyun.com/")
-		suffix = strings.TrimSuffix(suffix, "/login.htm")
-		if len(suffix) > 0 {
-			user.Name = fmt.Sprintf("%s@%s", user.Name, suffix)
-			account = suffix
-		}
-	case api.CLOUD_PROVIDER_QCLOUD, api.CLOUD_PROVIDER_HUAWEI:
-		u, _ := url.Parse(user.IamLoginUrl)
-		if u != nil {
-			account = u.Query().Get("account")
-		}
-	case api.CLOUD_PROVIDER_AWS:
-		account = strings.TrimPrefix(user.IamLoginUrl, "https://")
-		if info := strings.Split(account, "."); len(info) > 0 {
-			account = info[0]
-		}
+	// 控制台登录地址和用户名由服务端按云平台生成
+	info := struct {
+		Account  string
+		Username string
+		LoginUrl string
+	}{}
+	result, err := this.GetSpecific(s, id, "login-url", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetSpecific login-url")
+	}
+	err = result.Unmarshal(&info)
+	if err != nil {
+		return nil, errors.Wrap(err, "result.Unmarshal")
 	}
 
 	return jsonutils.Marshal(map[string]string{
-		"account":  account,
-		"username": user.Name,
+		"account":  info.Account,
+		"username": info.Username,
 		"password": password,
-		"url":      user.IamLoginUrl,
+		"url":      info.LoginUrl,
 	}), nil
 }
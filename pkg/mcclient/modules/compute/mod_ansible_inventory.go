@@ -0,0 +1,51 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+type SAnsibleInventoryManager struct {
+	modulebase.ResourceManager
+}
+
+func (this *SAnsibleInventoryManager) Get(s *mcclient.ClientSession, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	url := "/ansible-inventory"
+	if params != nil {
+		if queryStr := params.QueryString(); queryStr != "" {
+			url = fmt.Sprintf("%s?%s", url, queryStr)
+		}
+	}
+	return modulebase.Get(this.ResourceManager, s, url, "")
+}
+
+var (
+	AnsibleInventory SAnsibleInventoryManager
+)
+
+func init() {
+	AnsibleInventory = SAnsibleInventoryManager{modules.NewComputeManager("ansible_inventory", "ansible_inventories",
+		[]string{},
+		[]string{})}
+
+	modules.RegisterCompute(&AnsibleInventory)
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
@@ -65,6 +66,52 @@ func (manager *SBucketManager) Upload(s *mcclient.ClientSession, bucketId string
 	return nil
 }
 
+func (manager *SBucketManager) UploadPart(s *mcclient.ClientSession, bucketId string, key string, uploadId string, partIndex int, body io.Reader, partSize int64, offset int64, totalSize int64) (string, error) {
+	method := httputils.POST
+	path := fmt.Sprintf("/%s/%s/upload-part", manager.URLPath(), bucketId)
+	headers := http.Header{}
+	headers.Set(api.BUCKET_UPLOAD_OBJECT_KEY_HEADER, key)
+	headers.Set(api.BUCKET_UPLOAD_PART_UPLOAD_ID_HEADER, uploadId)
+	headers.Set(api.BUCKET_UPLOAD_PART_INDEX_HEADER, strconv.Itoa(partIndex))
+	headers.Set(api.BUCKET_UPLOAD_PART_OFFSET_HEADER, strconv.FormatInt(offset, 10))
+	headers.Set(api.BUCKET_UPLOAD_PART_TOTAL_SIZE_HEADER, strconv.FormatInt(totalSize, 10))
+	headers.Set("Content-Length", strconv.FormatInt(partSize, 10))
+
+	resp, err := modulebase.RawRequest(manager.ResourceManager, s, method, path, headers, body)
+	if err != nil {
+		return "", errors.Wrap(err, "rawRequest")
+	}
+
+	_, result, err := s.ParseJSONResponse("", resp, err)
+	if err != nil {
+		return "", err
+	}
+	output := api.BucketUploadPartOutput{}
+	err = result.Unmarshal(&output, manager.Keyword)
+	if err != nil {
+		return "", errors.Wrap(err, "Unmarshal")
+	}
+	return output.Etag, nil
+}
+
+func (manager *SBucketManager) Download(s *mcclient.ClientSession, bucketId string, key string, rangeStr string) (io.ReadCloser, int64, error) {
+	path := fmt.Sprintf("/%s/%s/download?key=%s", manager.URLPath(), bucketId, url.QueryEscape(key))
+	headers := http.Header{}
+	if len(rangeStr) > 0 {
+		headers.Set("Range", rangeStr)
+	}
+	resp, err := modulebase.RawRequest(manager.ResourceManager, s, httputils.GET, path, headers, nil)
+	if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		sizeBytes, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+		if err != nil {
+			sizeBytes = -1
+		}
+		return resp.Body, sizeBytes, nil
+	}
+	_, _, err = s.ParseJSONResponse("", resp, err)
+	return nil, -1, err
+}
+
 var (
 	Buckets SBucketManager
 )
@@ -20,7 +20,8 @@ import (
 )
 
 var (
-	NetworkAddresses modulebase.ResourceManager
+	NetworkAddresses   modulebase.ResourceManager
+	NetworkIpConflicts modulebase.ResourceManager
 )
 
 func init() {
@@ -36,4 +37,20 @@ func init() {
 		[]string{})
 
 	modules.RegisterCompute(&NetworkAddresses)
+
+	NetworkIpConflicts = modules.NewComputeManager("network_ip_conflict", "network_ip_conflicts",
+		[]string{
+			"ID",
+			"guest_id",
+			"guest",
+			"network_id",
+			"mac",
+			"local_ip",
+			"remote_ip",
+			"reason",
+			"created_at",
+		},
+		[]string{})
+
+	modules.RegisterCompute(&NetworkIpConflicts)
 }
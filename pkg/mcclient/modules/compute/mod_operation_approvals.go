@@ -0,0 +1,38 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+var (
+	OperationApprovalPolicies modulebase.ResourceManager
+	OperationApprovals        modulebase.ResourceManager
+)
+
+func init() {
+	OperationApprovalPolicies = modules.NewComputeManager("operation_approval_policy", "operation_approval_policies",
+		[]string{"ID", "Name", "Enabled", "Status", "Actions", "Expire_hours", "Project_domain"},
+		[]string{})
+
+	OperationApprovals = modules.NewComputeManager("operation_approval", "operation_approvals",
+		[]string{"ID", "Name", "Status", "Action", "Resource_type", "Resource_ids", "Requester", "Approver", "Reason", "Expired_at", "Project_domain"},
+		[]string{})
+
+	modules.RegisterCompute(&OperationApprovalPolicies)
+	modules.RegisterCompute(&OperationApprovals)
+}
@@ -0,0 +1,38 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+var (
+	ReplicationPolicies modulebase.ResourceManager
+	ReplicationRecords  modulebase.ResourceManager
+)
+
+func init() {
+	ReplicationPolicies = modules.NewComputeManager("replication_policy", "replication_policies",
+		[]string{"ID", "Name", "Status", "Enabled", "Resource_type", "Cloudregion", "Targets", "Record_status"},
+		[]string{})
+
+	ReplicationRecords = modules.NewComputeManager("replication_record", "replication_records",
+		[]string{"ID", "Name", "Status", "Replication_policy", "Resource_type", "Resource_id", "Target_cloudregion", "Target_manager", "Target_external_id"},
+		[]string{})
+
+	modules.RegisterCompute(&ReplicationPolicies)
+	modules.RegisterCompute(&ReplicationRecords)
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+type STerraformManager struct {
+	modulebase.ResourceManager
+}
+
+func (this *STerraformManager) Export(s *mcclient.ClientSession, projectId string, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	url := fmt.Sprintf("/terraform/%s/export", projectId)
+	if params != nil {
+		if queryStr := params.QueryString(); queryStr != "" {
+			url = fmt.Sprintf("%s?%s", url, queryStr)
+		}
+	}
+	return modulebase.Get(this.ResourceManager, s, url, "terraform")
+}
+
+func (this *STerraformManager) Import(s *mcclient.ClientSession, projectId string, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	url := fmt.Sprintf("/terraform/%s/import", projectId)
+	return modulebase.Post(this.ResourceManager, s, url, params, "terraform")
+}
+
+var (
+	Terraform STerraformManager
+)
+
+func init() {
+	Terraform = STerraformManager{modules.NewComputeManager("terraform", "terraform",
+		[]string{},
+		[]string{})}
+
+	modules.RegisterCompute(&Terraform)
+}
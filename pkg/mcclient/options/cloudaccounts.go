@@ -36,6 +36,14 @@ func (opts *CloudaccountListOptions) Params() (jsonutils.JSONObject, error) {
 	return ListStructToParams(opts)
 }
 
+type CloudaccountHealthSummaryOptions struct {
+	CloudaccountListOptions
+}
+
+func (opts *CloudaccountHealthSummaryOptions) Property() string {
+	return "health-summary"
+}
+
 type SUserPasswordCredential struct {
 	Username string `help:"Username" positional:"true"`
 	Password string `help:"Password" positional:"true"`
@@ -1237,3 +1245,11 @@ func (opts *SRemoteFileAccountCreateOptions) Params() (jsonutils.JSONObject, err
 	params.(*jsonutils.JSONDict).Add(jsonutils.NewString(api.CLOUD_PROVIDER_REMOTEFILE), "provider")
 	return params, nil
 }
+
+type CloudaccountMigrateSecretsOptions struct {
+	ToLocal bool `help:"migrate secrets back to AES encrypted database columns"`
+}
+
+func (opts *CloudaccountMigrateSecretsOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
@@ -64,11 +64,27 @@ func (opts *ClouduserIdOption) Params() (jsonutils.JSONObject, error) {
 
 type ClouduserSyncOptions struct {
 	ClouduserIdOption
-	PolicyOnly bool `help:"Ony sync clouduser policies for cloud"`
+	PolicyOnly bool   `help:"Ony sync clouduser policies for cloud"`
+	Direction  string `help:"sync direction, push local settings to cloud or pull from cloud" choices:"push|pull"`
 }
 
 func (opts *ClouduserSyncOptions) Params() (jsonutils.JSONObject, error) {
-	return jsonutils.Marshal(map[string]bool{"policy_only": opts.PolicyOnly}), nil
+	params := jsonutils.NewDict()
+	params.Set("policy_only", jsonutils.NewBool(opts.PolicyOnly))
+	if len(opts.Direction) > 0 {
+		params.Set("direction", jsonutils.NewString(opts.Direction))
+	}
+	return params, nil
+}
+
+type ClouduserSyncOwnerOptions struct {
+	USER_ID        string   `help:"local user id" json:"user_id"`
+	CloudgroupIds  []string `help:"cloudgroup ids"`
+	CloudpolicyIds []string `help:"cloudpolicy ids"`
+}
+
+func (opts *ClouduserSyncOwnerOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
 }
 
 type ClouduserPolicyOptions struct {
@@ -47,3 +47,12 @@ type SAMLProviderCreateOptions struct {
 func (opts *SAMLProviderCreateOptions) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(opts), nil
 }
+
+type SAMLProviderSetupOptions struct {
+	CLOUDACCOUNT_ID string   `help:"cloudaccount id or name" json:"cloudaccount_id"`
+	CloudgroupIds   []string `help:"cloudgroup ids to create federated login roles"`
+}
+
+func (opts *SAMLProviderSetupOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
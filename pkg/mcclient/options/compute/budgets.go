@@ -0,0 +1,110 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type BudgetListOptions struct {
+	options.BaseListOptions
+
+	Scope    []string `help:"filter by scope" choices:"project|domain|cloudaccount"`
+	ScopeId  []string `help:"filter by id of project, domain or cloudaccount"`
+	Blocking *bool    `help:"filter budgets blocking new resources"`
+}
+
+func (opts *BudgetListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+func parseBudgetThresholds(thresholds []string) (api.SBudgetThresholds, error) {
+	ret := api.SBudgetThresholds{}
+	for _, threshold := range thresholds {
+		pos := strings.Index(threshold, ":")
+		if pos <= 0 {
+			return nil, fmt.Errorf("invalid threshold %q, should be <percent>:<action>[,<action>]", threshold)
+		}
+		percent, err := strconv.ParseFloat(threshold[:pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold percent %q", threshold[:pos])
+		}
+		ret = append(ret, api.SBudgetThreshold{
+			Percent: percent,
+			Actions: strings.Split(threshold[pos+1:], ","),
+		})
+	}
+	return ret, nil
+}
+
+type BudgetCreateOptions struct {
+	options.BaseCreateOptions
+
+	SCOPE        string   `help:"budget scope" choices:"project|domain|cloudaccount"`
+	ScopeId      string   `help:"id or name of project, domain or cloudaccount"`
+	AMOUNT       float64  `help:"monthly budget amount"`
+	Currency     string   `help:"currency of bills counted in the budget"`
+	Threshold    []string `help:"threshold and actions, e.g. 80:notify 100:notify,block_create,stop_guests"`
+	StopGuestTag string   `help:"tag of non-production guests to stop when exceeded, e.g. env=dev"`
+}
+
+func (opts *BudgetCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	params.Remove("threshold")
+	if len(opts.Threshold) > 0 {
+		thresholds, err := parseBudgetThresholds(opts.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("thresholds", jsonutils.Marshal(thresholds))
+	}
+	return params, nil
+}
+
+type BudgetUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name         string
+	Desc         string
+	Amount       *float64 `help:"monthly budget amount"`
+	Threshold    []string `help:"threshold and actions, e.g. 80:notify 100:notify,block_create,stop_guests"`
+	StopGuestTag *string  `help:"tag of non-production guests to stop when exceeded, e.g. env=dev"`
+}
+
+func (opts *BudgetUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	params.Remove("threshold")
+	if len(opts.Threshold) > 0 {
+		thresholds, err := parseBudgetThresholds(opts.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("thresholds", jsonutils.Marshal(thresholds))
+	}
+	return params, nil
+}
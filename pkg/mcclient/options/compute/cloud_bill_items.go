@@ -0,0 +1,98 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type CloudBillItemListOptions struct {
+	options.BaseListOptions
+
+	Region       string   `help:"filter by region"`
+	BillingMonth []string `help:"filter by billing month, e.g. 2023-01"`
+	StartMonth   string   `help:"start billing month (inclusive)"`
+	EndMonth     string   `help:"end billing month (inclusive)"`
+	ResourceType []string `help:"filter by resource type"`
+	ResourceId   []string `help:"filter by cloud resource id"`
+	ChargeType   []string `help:"filter by charge type"`
+}
+
+func (opts *CloudBillItemListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type CloudBillCostOptions struct {
+	CloudBillItemListOptions
+
+	GroupBy string `help:"group cost by" choices:"project|provider|region|cloudaccount|resource_type|billing_month" default:"project"`
+}
+
+func (opts *CloudBillCostOptions) Property() string {
+	return "cost"
+}
+
+type ChargebackStatementOptions struct {
+	CloudBillItemListOptions
+
+	Format string `help:"statement format" choices:"json|csv|xlsx" default:"json"`
+	Output string `help:"file to save exported csv or xlsx statement"`
+}
+
+func (opts *ChargebackStatementOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.ListStructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	params.Remove("output")
+	return params, nil
+}
+
+type ChargebackPricingListOptions struct {
+	options.BaseListOptions
+
+	Provider     []string `help:"filter by provider"`
+	ResourceType []string `help:"filter by resource type"`
+}
+
+func (opts *ChargebackPricingListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type ChargebackPricingCreateOptions struct {
+	options.BaseCreateOptions
+
+	Provider     string  `help:"provider the multiplier applies to, empty for all providers"`
+	ResourceType string  `help:"resource type the multiplier applies to, empty for all resource types"`
+	MULTIPLIER   float64 `help:"pricing multiplier, chargeback amount = cloud amount * multiplier"`
+}
+
+func (opts *ChargebackPricingCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type ChargebackPricingUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name       string
+	Desc       string
+	Multiplier *float64 `help:"pricing multiplier"`
+}
+
+func (opts *ChargebackPricingUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
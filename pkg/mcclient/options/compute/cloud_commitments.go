@@ -0,0 +1,75 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type CloudCommitmentListOptions struct {
+	options.BaseListOptions
+
+	Region           string   `help:"filter by region"`
+	CommitmentType   []string `help:"filter by commitment type" choices:"reserved_instance|savings_plan|committed_use"`
+	InstanceType     []string `help:"filter by instance type"`
+	ExpireWithinDays int      `help:"list commitments expiring within the given days"`
+}
+
+func (opts *CloudCommitmentListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type CloudCommitmentCoverageOptions struct {
+	CloudCommitmentListOptions
+}
+
+func (opts *CloudCommitmentCoverageOptions) Property() string {
+	return "coverage"
+}
+
+type CloudCommitmentCreateOptions struct {
+	NAME             string   `help:"name of commitment"`
+	Cloudprovider    string   `help:"cloudprovider the commitment purchased in" required:"true"`
+	Cloudregion      string   `help:"region of the commitment" required:"true"`
+	CommitmentType   string   `help:"commitment type" choices:"reserved_instance|savings_plan|committed_use" required:"true"`
+	InstanceType     string   `help:"instance type of reserved instances"`
+	InstanceCount    int      `help:"count of reserved instances"`
+	OsType           string   `help:"os type of reserved instances"`
+	PaymentOption    string   `help:"payment option, e.g. All Upfront"`
+	HourlyCommitment float64  `help:"hourly commitment of savings plan"`
+	Currency         string   `help:"currency of hourly commitment"`
+	StartAt          string   `help:"start time, e.g. 2023-01-01T00:00:00Z"`
+	EndAt            string   `help:"end time, e.g. 2024-01-01T00:00:00Z"`
+	Utilization      *float64 `help:"utilization percent reported by cloud console"`
+}
+
+func (opts *CloudCommitmentCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type CloudCommitmentUpdateOptions struct {
+	options.BaseIdOptions
+	InstanceCount    *int     `help:"count of reserved instances"`
+	HourlyCommitment *float64 `help:"hourly commitment of savings plan"`
+	StartAt          string   `help:"start time, e.g. 2023-01-01T00:00:00Z"`
+	EndAt            string   `help:"end time, e.g. 2024-01-01T00:00:00Z"`
+	Utilization      *float64 `help:"utilization percent reported by cloud console"`
+}
+
+func (opts *CloudCommitmentUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
@@ -15,7 +15,10 @@
 package compute
 
 import (
+	"os"
+
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
 
 	"yunion.io/x/onecloud/pkg/mcclient/options"
 )
@@ -93,6 +96,15 @@ func (opts *CloudproviderChangeProjectOptions) Params() (jsonutils.JSONObject, e
 	return jsonutils.Marshal(map[string]string{"project": opts.TENANT}), nil
 }
 
+type CloudproviderDeleteOptions struct {
+	options.BaseIdOptions
+	Archive bool `help:"Archive tags, descriptions and projects of purged resources for later restore"`
+}
+
+func (opts *CloudproviderDeleteOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
 type CloudproviderSyncOptions struct {
 	options.BaseIdOptions
 	Force       bool     `help:"Force sync no matter what"`
@@ -156,3 +168,35 @@ func (opts *ClouproviderSetSyncingOptions) Params() (jsonutils.JSONObject, error
 		"cloudregion_ids": opts.CloudregionIds,
 	}), nil
 }
+
+type CloudproviderIssueCredentialOptions struct {
+	options.BaseIdOptions
+	DurationSeconds int64  `help:"credential duration in seconds" default:"3600"`
+	RoleArn         string `help:"role arn to assume, required for Aliyun, Aws requires role arn or policy"`
+	Policy          string `help:"policy document to restrict credential permissions"`
+}
+
+func (opts *CloudproviderIssueCredentialOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type CloudproviderImportBillsOptions struct {
+	options.BaseIdOptions
+	BillingMonth string `help:"billing month of the items, e.g. 2023-01" required:"true" json:"billing_month"`
+	ItemsFile    string `help:"json file of normalized bill line items exported from cloud billing" required:"true" json:"-"`
+}
+
+func (opts *CloudproviderImportBillsOptions) Params() (jsonutils.JSONObject, error) {
+	content, err := os.ReadFile(opts.ItemsFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", opts.ItemsFile)
+	}
+	items, err := jsonutils.Parse(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s", opts.ItemsFile)
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(opts.BillingMonth), "billing_month")
+	params.Add(items, "items")
+	return params, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type DBInstanceParameterTemplateListOptions struct {
+	options.BaseListOptions
+
+	Engine []string `help:"filter by engine"`
+}
+
+func (opts *DBInstanceParameterTemplateListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type DBInstanceParameterTemplateCreateOptions struct {
+	options.BaseCreateOptions
+
+	ENGINE        string   `help:"dbinstance engine" choices:"MySQL|SQLServer|PostgreSQL|MariaDB|Oracle|PPAS"`
+	EngineVersion string   `help:"dbinstance engine version, empty for all versions"`
+	PARAMETER     []string `help:"native engine parameter, format: key=value, e.g. max_connections=2000"`
+}
+
+func (opts *DBInstanceParameterTemplateCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts).(*jsonutils.JSONDict)
+	params.Remove("parameter")
+	parameters, err := parseDBParameters(opts.PARAMETER)
+	if err != nil {
+		return nil, err
+	}
+	params.Add(parameters, "parameters")
+	return params, nil
+}
+
+type DBInstanceParameterTemplateUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name        string
+	Description string
+	Parameter   []string `help:"replace all parameters, format: key=value"`
+}
+
+func (opts *DBInstanceParameterTemplateUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	if len(opts.Name) > 0 {
+		params.Add(jsonutils.NewString(opts.Name), "name")
+	}
+	if len(opts.Description) > 0 {
+		params.Add(jsonutils.NewString(opts.Description), "description")
+	}
+	if len(opts.Parameter) > 0 {
+		parameters, err := parseDBParameters(opts.Parameter)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(parameters, "parameters")
+	}
+	return params, nil
+}
@@ -44,6 +44,7 @@ type DBInstanceCreateOptions struct {
 	Tags               []string `help:"Tags info,prefix with 'user:', eg: user:project=default" json:"-"`
 	DBInstancebackupId string   `help:"create dbinstance from backup" json:"dbinstancebackup_id"`
 	MultiAz            bool     `help:"deploy rds with multi az"`
+	ParameterTemplate  string   `help:"parameter template id or name applied after create, only for providers supporting parameter update" json:"parameter_template_id"`
 }
 
 func (opts *DBInstanceCreateOptions) Params() (jsonutils.JSONObject, error) {
@@ -156,6 +157,33 @@ func (opts *DBInstanceChangeConfigOptions) Params() (jsonutils.JSONObject, error
 	return params, nil
 }
 
+type DBInstanceUpdateParametersOptions struct {
+	DBInstanceIdOptions
+	PARAMETER []string `help:"Parameter to update, format: key=value"`
+}
+
+func parseDBParameters(parameterStrs []string) (*jsonutils.JSONDict, error) {
+	parameters := jsonutils.NewDict()
+	for _, p := range parameterStrs {
+		idx := strings.Index(p, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid parameter %s, format should be key=value", p)
+		}
+		parameters.Add(jsonutils.NewString(p[idx+1:]), p[:idx])
+	}
+	return parameters, nil
+}
+
+func (opts *DBInstanceUpdateParametersOptions) Params() (jsonutils.JSONObject, error) {
+	parameters, err := parseDBParameters(opts.PARAMETER)
+	if err != nil {
+		return nil, err
+	}
+	params := jsonutils.NewDict()
+	params.Add(parameters, "parameters")
+	return params, nil
+}
+
 type DBInstancePublicConnectionOptions struct {
 	DBInstanceIdOptions
 	IS_OPEN string `help:"Open Or Close public connection" choices:"true|false"`
@@ -37,7 +37,8 @@ type DiskCreateOptions struct {
 	SnapshotId string   `help:"snapshot id"`
 	BackupId   string   `help:"backupid"`
 
-	Project string `help:"owner project"`
+	Project         string `help:"owner project"`
+	ExternalProject string `help:"external enterprise project or resource group, only for Aliyun, Huawei and Azure"`
 }
 
 func (o DiskCreateOptions) Params() (*api.DiskCreateInput, error) {
@@ -67,6 +68,7 @@ func (o DiskCreateOptions) Params() (*api.DiskCreateInput, error) {
 	params.Description = o.Desc
 	params.Name = o.NAME
 	params.ProjectId = o.Project
+	params.ExternalProjectId = o.ExternalProject
 	if o.Storage != "" {
 		params.Storage = o.Storage
 	}
@@ -27,6 +27,11 @@ type ElasticipListOptions struct {
 	UsableEipForAssociateType string `help:"With associate id filter which eip can associate" choices:"server|natgateway|loadbalancer"`
 	UsableEipForAssociateId   string `help:"With associate type filter which eip can associate"`
 
+	PoolId string `help:"List eips retained in eip pool"`
+	InPool *bool  `help:"List eips retained (or not) in any eip pool"`
+
+	ExternalProjectId []string `help:"List eips in external project or resource group"`
+
 	options.BaseListOptions
 }
 
@@ -43,6 +48,8 @@ type EipCreateOptions struct {
 	Network    *string `help:"Network of the EIP"`
 	BgpType    *string `help:"BgpType of the EIP" positional:"false"`
 	ChargeType *string `help:"bandwidth charge type" choices:"traffic|bandwidth"`
+
+	ExternalProjectId *string `help:"external enterprise project or resource group, only for Aliyun, Huawei and Azure"`
 }
 
 func (opts *EipCreateOptions) Params() (jsonutils.JSONObject, error) {
@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type ElasticipPoolListOptions struct {
+	options.BaseListOptions
+
+	Region string `help:"List eip pools in cloudregion"`
+}
+
+func (opts *ElasticipPoolListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type ElasticipPoolCreateOptions struct {
+	options.BaseCreateOptions
+
+	CLOUDPROVIDER string `help:"public cloud provider id or name" json:"cloudprovider_id"`
+	REGION        string `help:"cloudregion id or name" json:"cloudregion_id"`
+
+	MaxCount            int    `help:"max eips retained in pool, 0 means unlimited"`
+	ProjectReserveCount int    `help:"max eips retained for each project, 0 means unlimited"`
+	ChargeType          string `help:"only retain eips with this charge type" choices:"traffic|bandwidth"`
+}
+
+func (opts *ElasticipPoolCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type ElasticipPoolUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name                string
+	Description         string
+	MaxCount            *int `help:"max eips retained in pool, 0 means unlimited"`
+	ProjectReserveCount *int `help:"max eips retained for each project, 0 means unlimited"`
+}
+
+func (opts *ElasticipPoolUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
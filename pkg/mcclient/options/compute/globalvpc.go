@@ -43,8 +43,48 @@ func (opts *GlobalVpcIdOption) Params() (jsonutils.JSONObject, error) {
 type GlobalVpcCreateOptions struct {
 	NAME    string `help:"Global vpc name"`
 	MANAGER string `help:"Cloudprovider Id"`
+
+	AddressPlan          string `help:"Address plan of member vpcs, separated by comma, e.g. 10.0.0.0/8,172.16.0.0/12"`
+	AddressPlanPrefixLen int    `help:"Default prefix length of allocated member vpc cidr"`
 }
 
 func (opts *GlobalVpcCreateOptions) Params() (jsonutils.JSONObject, error) {
 	return options.StructToParams(opts)
 }
+
+type GlobalVpcUpdateOptions struct {
+	options.BaseUpdateOptions
+
+	AddressPlan          string `help:"Address plan of member vpcs, separated by comma"`
+	AddressPlanPrefixLen int    `help:"Default prefix length of allocated member vpc cidr"`
+}
+
+func (opts *GlobalVpcUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type GlobalVpcVpcOptions struct {
+	GlobalVpcIdOption
+
+	VPC string `help:"Vpc id or name"`
+}
+
+func (opts *GlobalVpcVpcOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	params.Set("vpc_id", jsonutils.NewString(opts.VPC))
+	return params, nil
+}
+
+type GlobalVpcAddressPlanOptions struct {
+	GlobalVpcIdOption
+
+	PrefixLen int `help:"Prefix length of next allocatable cidr"`
+}
+
+func (opts *GlobalVpcAddressPlanOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	if opts.PrefixLen > 0 {
+		params.Set("prefix_len", jsonutils.NewInt(int64(opts.PrefixLen)))
+	}
+	return params, nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type GuestCommandInvocationListOptions struct {
+	options.BaseListOptions
+
+	ServerId    string   `help:"filter by server"`
+	Channel     []string `help:"filter by channel" choices:"qga"`
+	CommandType []string `help:"filter by command type" choices:"shell|powershell"`
+	Operator    string   `help:"filter by operator"`
+}
+
+func (opts *GuestCommandInvocationListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
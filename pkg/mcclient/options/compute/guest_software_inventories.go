@@ -0,0 +1,49 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type GuestSoftwareInventoryListOptions struct {
+	options.BaseListOptions
+
+	ServerId        string   `help:"filter by server"`
+	Source          []string `help:"filter by collect source" choices:"qga|provider"`
+	OsDistribution  []string `help:"filter by os distribution"`
+	OsVersion       []string `help:"filter by os version"`
+	KernelVersion   []string `help:"filter by kernel version"`
+	InstalledUpdate string   `help:"filter by installed update or package name"`
+}
+
+func (opts *GuestSoftwareInventoryListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type GuestOsOutdatedReportOptions struct {
+	ServerId       string   `help:"filter by server"`
+	OsDistribution []string `help:"filter by os distribution"`
+}
+
+func (opts *GuestOsOutdatedReportOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+func (opts *GuestOsOutdatedReportOptions) Property() string {
+	return "outdated-report"
+}
@@ -106,7 +106,24 @@ func (o *HostAutoMigrateOnHostDownOptions) Params() (jsonutils.JSONObject, error
 	return options.StructToParams(o)
 }
 
+type HostEnterMaintenanceOptions struct {
+	options.BaseIdOptions
+	PreferHost string `help:"Host id or name to migrate guests to"`
+}
+
+func (o *HostEnterMaintenanceOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(o)
+}
+
 type HostStatusStatisticsOptions struct {
 	HostListOptions
 	options.StatusStatisticsOptions
 }
+
+type HostEolReportOptions struct {
+	HostListOptions
+}
+
+func (o *HostEolReportOptions) Property() string {
+	return "eol-report"
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type IdleResourceListOptions struct {
+	options.BaseListOptions
+
+	ResourceType []string `help:"filter by resource type" choices:"eip|disk|loadbalancer|server"`
+	ResourceId   []string `help:"filter by resource id"`
+	Reason       []string `help:"filter by idle reason" choices:"unassociated|unattached|no_backend|long_stopped"`
+}
+
+func (opts *IdleResourceListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type IdleResourceWasteOptions struct {
+	IdleResourceListOptions
+}
+
+func (opts *IdleResourceWasteOptions) Property() string {
+	return "waste"
+}
+
+type IdleResourceReasonOptions struct {
+	options.BaseIdOptions
+
+	Reason string `help:"reason of the request or rejection"`
+}
+
+func (opts *IdleResourceReasonOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type IdleResourceBatchRequestCleanupOptions struct {
+	IdleResources []string `help:"id or name of idle resources, empty for all detected idle resources"`
+	ResourceType  []string `help:"resource type" choices:"eip|disk|loadbalancer|server"`
+	Reason        string   `help:"reason of the request"`
+}
+
+func (opts *IdleResourceBatchRequestCleanupOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
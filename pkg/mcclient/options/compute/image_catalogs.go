@@ -0,0 +1,105 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type ImageCatalogListOptions struct {
+	options.BaseListOptions
+
+	OsType   []string `help:"filter by os type"`
+	Provider string   `help:"filter by available platform"`
+}
+
+func (opts *ImageCatalogListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+func parseImageCatalogMappings(mappings []string) (jsonutils.JSONObject, error) {
+	ret := jsonutils.NewArray()
+	for _, mapping := range mappings {
+		parts := strings.Split(mapping, ":")
+		if len(parts) != 2 && len(parts) != 3 {
+			return nil, errors.Errorf("invalid mapping %q, format: provider[:region]:image", mapping)
+		}
+		item := jsonutils.NewDict()
+		item.Add(jsonutils.NewString(parts[0]), "provider")
+		if len(parts) == 3 {
+			item.Add(jsonutils.NewString(parts[1]), "cloudregion_id")
+		}
+		item.Add(jsonutils.NewString(parts[len(parts)-1]), "image_id")
+		ret.Add(item)
+	}
+	return ret, nil
+}
+
+type ImageCatalogCreateOptions struct {
+	options.BaseCreateOptions
+
+	Image          string   `help:"local image id or name" json:"image_id"`
+	OsType         string   `help:"os type, default from image"`
+	OsDistribution string   `help:"os distribution, default from image"`
+	OsVersion      string   `help:"os version, default from image"`
+	OsArch         string   `help:"os arch, default from image"`
+	Mapping        []string `help:"platform image mapping, format: provider[:region]:image" json:"-"`
+}
+
+func (opts *ImageCatalogCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := parseImageCatalogMappings(opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	params.Add(mappings, "mappings")
+	return params, nil
+}
+
+type ImageCatalogUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name           string   `help:"new name of image catalog"`
+	Desc           string   `help:"description" json:"description"`
+	Image          string   `help:"local image id or name" json:"image_id"`
+	OsType         string   `help:"os type"`
+	OsDistribution string   `help:"os distribution"`
+	OsVersion      string   `help:"os version"`
+	OsArch         string   `help:"os arch"`
+	Mapping        []string `help:"platform image mapping, replaces all mappings, format: provider[:region]:image" json:"-"`
+}
+
+func (opts *ImageCatalogUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Mapping) > 0 {
+		mappings, err := parseImageCatalogMappings(opts.Mapping)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(mappings, "mappings")
+	}
+	return params, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type LoadbalancerBlueprintListOptions struct {
+	options.BaseListOptions
+}
+
+func (opts *LoadbalancerBlueprintListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type LoadbalancerBlueprintSpecOptions struct {
+	SpecFile string   `help:"json file of blueprint spec, which contains listeners and backends" json:"-"`
+	Listener []string `help:"listener, format: <listener_type>:<listener_port>[:<backend_port>], e.g. http:80:8080" json:"-"`
+	Backend  []string `help:"backend server, format: <server_id>[:<weight>]" json:"-"`
+}
+
+func (opts *LoadbalancerBlueprintSpecOptions) isSet() bool {
+	return len(opts.SpecFile) > 0 || len(opts.Listener) > 0 || len(opts.Backend) > 0
+}
+
+func (opts *LoadbalancerBlueprintSpecOptions) spec() (jsonutils.JSONObject, error) {
+	if len(opts.SpecFile) > 0 {
+		content, err := os.ReadFile(opts.SpecFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read %s", opts.SpecFile)
+		}
+		return jsonutils.Parse(content)
+	}
+	listeners := jsonutils.NewArray()
+	for _, listener := range opts.Listener {
+		parts := strings.Split(listener, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, errors.Errorf("invalid listener %s", listener)
+		}
+		l := jsonutils.NewDict()
+		l.Add(jsonutils.NewString(parts[0]), "listener_type")
+		for i, key := range []string{"listener_port", "backend_port"}[:len(parts)-1] {
+			port, err := strconv.Atoi(parts[i+1])
+			if err != nil {
+				return nil, errors.Errorf("invalid listener %s", listener)
+			}
+			l.Add(jsonutils.NewInt(int64(port)), key)
+		}
+		listeners.Add(l)
+	}
+	backends := jsonutils.NewArray()
+	for _, backend := range opts.Backend {
+		parts := strings.Split(backend, ":")
+		b := jsonutils.NewDict()
+		b.Add(jsonutils.NewString(parts[0]), "server_id")
+		if len(parts) > 1 {
+			weight, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, errors.Errorf("invalid backend %s", backend)
+			}
+			b.Add(jsonutils.NewInt(int64(weight)), "weight")
+		}
+		backends.Add(b)
+	}
+	spec := jsonutils.NewDict()
+	spec.Add(listeners, "listeners")
+	spec.Add(backends, "backends")
+	return spec, nil
+}
+
+type LoadbalancerBlueprintCreateOptions struct {
+	options.BaseCreateOptions
+	LoadbalancerBlueprintSpecOptions
+}
+
+func (opts *LoadbalancerBlueprintCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts).(*jsonutils.JSONDict)
+	spec, err := opts.spec()
+	if err != nil {
+		return nil, err
+	}
+	params.Add(spec, "spec")
+	return params, nil
+}
+
+type LoadbalancerBlueprintUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name        string
+	Description string
+	LoadbalancerBlueprintSpecOptions
+}
+
+func (opts *LoadbalancerBlueprintUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	if len(opts.Name) > 0 {
+		params.Add(jsonutils.NewString(opts.Name), "name")
+	}
+	if len(opts.Description) > 0 {
+		params.Add(jsonutils.NewString(opts.Description), "description")
+	}
+	if opts.isSet() {
+		spec, err := opts.spec()
+		if err != nil {
+			return nil, err
+		}
+		params.Add(spec, "spec")
+	}
+	return params, nil
+}
+
+type LoadbalancerBlueprintInstantiateOptions struct {
+	options.BaseIdOptions
+	LoadbalancerCreateOptions
+}
+
+func (opts *LoadbalancerBlueprintInstantiateOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *LoadbalancerBlueprintInstantiateOptions) Params() (jsonutils.JSONObject, error) {
+	return opts.LoadbalancerCreateOptions.Params()
+}
+
+type LoadbalancerBlueprintSyncOptions struct {
+	options.BaseIdOptions
+
+	Loadbalancer []string `help:"loadbalancer ids or names to sync, default all loadbalancers created from the blueprint" json:"loadbalancer_ids"`
+}
+
+func (opts *LoadbalancerBlueprintSyncOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string][]string{"loadbalancer_ids": opts.Loadbalancer}), nil
+}
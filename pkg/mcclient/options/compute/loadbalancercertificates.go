@@ -56,6 +56,14 @@ type LoadbalancerCertificateCreateOptions struct {
 
 	Cert string `required:"true" json:"-" help:"path to certificate file"`
 	Pkey string `required:"true" json:"-" help:"path to private key file"`
+
+	LoadbalancerCertificateRenewHookOptions
+}
+
+type LoadbalancerCertificateRenewHookOptions struct {
+	RenewHook       *string `help:"hook to renew certificate before expiration, e.g. webhook, empty to disable"`
+	RenewHookUrl    *string `help:"url of renew hook"`
+	RenewBeforeDays *int    `help:"renew certificate in these days before expiration"`
 }
 
 func (opts *LoadbalancerCertificateCreateOptions) Params() (*jsonutils.JSONDict, error) {
@@ -95,6 +103,7 @@ type LoadbalancerCertificateListOptions struct {
 	SignatureAlgorithm string
 	Cloudregion        string
 	Usable             *bool `help:"List certificates are usable"`
+	ExpireWithinDays   *int  `help:"List certificates expiring in these days, including expired ones"`
 }
 
 func (opts *LoadbalancerCertificateListOptions) Params() (jsonutils.JSONObject, error) {
@@ -107,6 +116,8 @@ type LoadbalancerCertificateUpdateOptions struct {
 
 	Cert string `json:"-" help:"path to certificate file"`
 	Pkey string `json:"-" help:"path to private key file"`
+
+	LoadbalancerCertificateRenewHookOptions
 }
 
 func (opts *LoadbalancerCertificateUpdateOptions) Params() (*jsonutils.JSONDict, error) {
@@ -114,10 +125,22 @@ func (opts *LoadbalancerCertificateUpdateOptions) Params() (*jsonutils.JSONDict,
 	if err != nil {
 		return nil, err
 	}
+	paramsCertKey.Update(jsonutils.Marshal(opts.LoadbalancerCertificateRenewHookOptions))
 
 	return paramsCertKey, nil
 }
 
+type LoadbalancerCertificateRenewOptions struct {
+	ID string `json:"-"`
+
+	Cert string `json:"-" help:"path to new certificate file, renew by renew hook if not specified"`
+	Pkey string `json:"-" help:"path to new private key file"`
+}
+
+func (opts *LoadbalancerCertificateRenewOptions) Params() (*jsonutils.JSONDict, error) {
+	return loadbalancerCertificateLoadFiles(opts.Cert, opts.Pkey, true)
+}
+
 type LoadbalancerCertificatePublicOptions struct {
 	options.SharableResourcePublicBaseOptions
 
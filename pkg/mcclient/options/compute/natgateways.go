@@ -15,9 +15,14 @@
 package compute
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"yunion.io/x/jsonutils"
 
 	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/mcclient/options"
 )
 
@@ -101,3 +106,52 @@ type NatPostpaidExpireOptions struct {
 func (opts *NatPostpaidExpireOptions) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(opts.PostpaidExpireInput), nil
 }
+
+type NatGatewayApplyRulesOptions struct {
+	NatGatewayIdOptions
+	RuleType []string `help:"Rule types to apply" choices:"dnat|snat"`
+	Dnat     []string `help:"DNAT rule, format: eip:external_port:internal_ip:internal_port[:protocol]"`
+	Snat     []string `help:"SNAT rule, format: eip:network_id or eip:source_cidr"`
+	DryRun   bool     `help:"Only show the rules to be added and removed"`
+}
+
+func (opts *NatGatewayApplyRulesOptions) Params() (jsonutils.JSONObject, error) {
+	input := api.NatGatewayApplyRulesInput{
+		RuleTypes: opts.RuleType,
+		DryRun:    opts.DryRun,
+	}
+	for _, rule := range opts.Dnat {
+		parts := strings.Split(rule, ":")
+		if len(parts) != 4 && len(parts) != 5 {
+			return nil, fmt.Errorf("invalid dnat rule %s", rule)
+		}
+		dnat := api.NatDRuleInput{Eip: parts[0], InternalIp: parts[2], IpProtocol: "tcp"}
+		var err error
+		dnat.ExternalPort, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid external port in dnat rule %s", rule)
+		}
+		dnat.InternalPort, err = strconv.Atoi(parts[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid internal port in dnat rule %s", rule)
+		}
+		if len(parts) == 5 {
+			dnat.IpProtocol = parts[4]
+		}
+		input.DnatRules = append(input.DnatRules, dnat)
+	}
+	for _, rule := range opts.Snat {
+		idx := strings.Index(rule, ":")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid snat rule %s", rule)
+		}
+		snat := api.NatSRuleInput{Eip: rule[:idx]}
+		if strings.Contains(rule[idx+1:], "/") {
+			snat.SourceCidr = rule[idx+1:]
+		} else {
+			snat.NetworkId = rule[idx+1:]
+		}
+		input.SnatRules = append(input.SnatRules, snat)
+	}
+	return jsonutils.Marshal(input), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type OperationApprovalPolicyListOptions struct {
+	options.BaseListOptions
+
+	Action []string `help:"filter by action" choices:"purge_cloudprovider|delete_server|batch_delete_server|change_project_cross_domain"`
+}
+
+func (opts *OperationApprovalPolicyListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type OperationApprovalPolicyCreateOptions struct {
+	options.BaseCreateOptions
+
+	ACTIONS     []string `help:"actions require approval" choices:"purge_cloudprovider|delete_server|batch_delete_server|change_project_cross_domain"`
+	ExpireHours int      `help:"hours before a pending approval expires, default 24"`
+}
+
+func (opts *OperationApprovalPolicyCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type OperationApprovalPolicyUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name        string
+	Desc        string
+	Actions     []string `help:"actions require approval" choices:"purge_cloudprovider|delete_server|batch_delete_server|change_project_cross_domain"`
+	ExpireHours *int     `help:"hours before a pending approval expires"`
+}
+
+func (opts *OperationApprovalPolicyUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type OperationApprovalListOptions struct {
+	options.BaseListOptions
+
+	Action       []string `help:"filter by action" choices:"purge_cloudprovider|delete_server|batch_delete_server|change_project_cross_domain"`
+	ResourceType []string `help:"filter by resource type"`
+	Mine         *bool    `help:"list approvals requested by current user"`
+}
+
+func (opts *OperationApprovalListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type OperationApprovalReasonOptions struct {
+	options.BaseIdOptions
+
+	Reason string `help:"approval comment or reject reason"`
+}
+
+func (opts *OperationApprovalReasonOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string]string{"reason": opts.Reason}), nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type ProviderAlertRuleListOptions struct {
+	options.BaseListOptions
+
+	RuleType []string `help:"filter by rule type" choices:"provider_disconnected|sync_failed|account_arrears"`
+}
+
+func (opts *ProviderAlertRuleListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type ProviderAlertRuleCreateOptions struct {
+	options.BaseCreateOptions
+
+	RULE_TYPE string   `help:"alert rule type" choices:"provider_disconnected|sync_failed|account_arrears"`
+	Threshold int      `help:"consecutive sync failures to alert, only for sync_failed rule"`
+	Receiver  []string `help:"id of receivers, send system exception notification if empty"`
+	Channel   []string `help:"notify channels" choices:"email|mobile|dingtalk|feishu|workwx|webconsole|webhook"`
+}
+
+func (opts *ProviderAlertRuleCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	params.Remove("receiver")
+	params.Remove("channel")
+	params.Set("notification", jsonutils.Marshal(api.SProviderAlertNotification{
+		Receivers: opts.Receiver,
+		Channels:  opts.Channel,
+	}))
+	return params, nil
+}
+
+type ProviderAlertRuleUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name      string
+	Desc      string
+	Threshold *int     `help:"consecutive sync failures to alert, only for sync_failed rule"`
+	Receiver  []string `help:"id of receivers"`
+	Channel   []string `help:"notify channels" choices:"email|mobile|dingtalk|feishu|workwx|webconsole|webhook"`
+}
+
+func (opts *ProviderAlertRuleUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	params.Remove("receiver")
+	params.Remove("channel")
+	if len(opts.Receiver) > 0 || len(opts.Channel) > 0 {
+		params.Set("notification", jsonutils.Marshal(api.SProviderAlertNotification{
+			Receivers: opts.Receiver,
+			Channels:  opts.Channel,
+		}))
+	}
+	return params, nil
+}
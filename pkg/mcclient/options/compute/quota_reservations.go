@@ -0,0 +1,61 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type QuotaReservationListOptions struct {
+	options.BaseListOptions
+}
+
+func (opts *QuotaReservationListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type QuotaReservationCreateOptions struct {
+	options.BaseCreateOptions
+
+	Project   string `help:"id or name of project owning the reservation"`
+	Count     int    `help:"number of servers to reserve"`
+	Cpu       int    `help:"cpu cores to reserve"`
+	Memory    int    `help:"memory to reserve in MB"`
+	Storage   int    `help:"storage to reserve in MB"`
+	Duration  string `help:"duration of the reservation, e.g. 2h, 1d" default:"1d"`
+	ExpiredAt string `help:"expire time of the reservation, e.g. 2023-01-01T00:00:00Z"`
+}
+
+func (opts *QuotaReservationCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type QuotaForecastOptions struct {
+	Project   string `help:"id or name of project"`
+	Count     int    `help:"number of servers planned to create"`
+	VcpuCount int    `help:"cpu cores of each server"`
+	VmemSize  int    `help:"memory of each server in MB"`
+	DiskSize  int    `help:"storage of each server in MB"`
+}
+
+func (opts *QuotaForecastOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+func (opts *QuotaForecastOptions) Property() string {
+	return "forecast"
+}
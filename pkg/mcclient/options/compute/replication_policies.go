@@ -0,0 +1,113 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type ReplicationPolicyListOptions struct {
+	options.BaseListOptions
+
+	Cloudregion  string   `help:"filter by source region"`
+	ResourceType []string `help:"filter by resource type" choices:"snapshot|image"`
+}
+
+func (opts *ReplicationPolicyListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+func parseReplicationTargets(targets []string) (jsonutils.JSONObject, error) {
+	ret := jsonutils.NewArray()
+	for _, target := range targets {
+		parts := strings.SplitN(target, ":", 2)
+		region, manager := parts[0], ""
+		if len(parts) > 1 {
+			manager = parts[1]
+		}
+		if len(region) == 0 {
+			return nil, errors.Errorf("invalid target %q, format: region[:cloudprovider]", target)
+		}
+		item := jsonutils.NewDict()
+		item.Add(jsonutils.NewString(region), "cloudregion_id")
+		if len(manager) > 0 {
+			item.Add(jsonutils.NewString(manager), "manager_id")
+		}
+		ret.Add(item)
+	}
+	return ret, nil
+}
+
+type ReplicationPolicyCreateOptions struct {
+	options.EnabledStatusCreateOptions
+
+	CLOUDREGION  string   `help:"source region id or name" json:"cloudregion_id"`
+	RESOURCETYPE string   `help:"resource type to replicate" choices:"image" json:"resource_type"`
+	Target       []string `help:"replication target, format: region[:cloudprovider]" json:"-"`
+}
+
+func (opts *ReplicationPolicyCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := parseReplicationTargets(opts.Target)
+	if err != nil {
+		return nil, err
+	}
+	params.Add(targets, "targets")
+	return params, nil
+}
+
+type ReplicationPolicyUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name   string   `help:"new name of replication policy"`
+	Desc   string   `help:"description" json:"description"`
+	Target []string `help:"replication target, format: region[:cloudprovider]" json:"-"`
+}
+
+func (opts *ReplicationPolicyUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Target) > 0 {
+		targets, err := parseReplicationTargets(opts.Target)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(targets, "targets")
+	}
+	return params, nil
+}
+
+type ReplicationRecordListOptions struct {
+	options.BaseListOptions
+
+	ReplicationPolicyId string   `help:"filter by replication policy"`
+	ResourceType        []string `help:"filter by resource type" choices:"snapshot|image"`
+	ResourceId          []string `help:"filter by source snapshot or image id"`
+	TargetCloudregionId []string `help:"filter by target region id"`
+}
+
+func (opts *ReplicationRecordListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
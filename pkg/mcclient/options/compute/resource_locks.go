@@ -0,0 +1,45 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type ResourceLockListOptions struct {
+	options.BaseListOptions
+
+	ResourceType []string `help:"filter by resource type" choices:"server|disk|eip"`
+	ResourceId   []string `help:"filter by resource id"`
+	LockType     []string `help:"filter by lock type" choices:"delete|stop"`
+}
+
+func (opts *ResourceLockListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type ResourceLockCreateOptions struct {
+	RESOURCE_TYPE string `help:"locked resource type" choices:"server|disk|eip" json:"resource_type"`
+	RESOURCE      string `help:"locked resource id or name" json:"resource_id"`
+	LOCK_TYPE     string `help:"lock type, stop lock only applies to server" choices:"delete|stop" json:"lock_type"`
+	Name          string `help:"lock name, default generated from resource name"`
+	Desc          string `help:"description" json:"description"`
+}
+
+func (opts *ResourceLockCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
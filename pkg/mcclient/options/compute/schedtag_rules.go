@@ -0,0 +1,77 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type SchedtagRuleListOptions struct {
+	options.BaseListOptions
+
+	Schedtag      string   `help:"filter by schedtag"`
+	Provider      []string `help:"filter by matched provider"`
+	CloudregionId string   `help:"filter by matched cloudregion"`
+	ZoneId        string   `help:"filter by matched zone"`
+}
+
+func (opts *SchedtagRuleListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type SchedtagRuleCreateOptions struct {
+	options.BaseCreateOptions
+
+	SCHEDTAG      string `help:"ID or name of schedtag to attach, resource type must be hosts, storages or networks" json:"schedtag_id"`
+	Provider      string `help:"match resources synced from provider, e.g. Aliyun"`
+	CloudregionId string `help:"match resources in cloudregion"`
+	ZoneId        string `help:"match resources in zone"`
+	Tags          string `help:"match resources with cloud tags, e.g. 'env=prod;team='" json:"-"`
+}
+
+func (opts *SchedtagRuleCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Tags) > 0 {
+		params.Set("tags", jsonutils.Marshal(options.SplitTag(opts.Tags)))
+	}
+	return params, nil
+}
+
+type SchedtagRuleUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name          string
+	Desc          string  `json:"description"`
+	Provider      *string `help:"match resources synced from provider, empty to match all"`
+	CloudregionId *string `help:"match resources in cloudregion, empty to match all"`
+	ZoneId        *string `help:"match resources in zone, empty to match all"`
+	Tags          string  `help:"match resources with cloud tags, e.g. 'env=prod;team='" json:"-"`
+}
+
+func (opts *SchedtagRuleUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Tags) > 0 {
+		params.Set("tags", jsonutils.Marshal(options.SplitTag(opts.Tags)))
+	}
+	return params, nil
+}
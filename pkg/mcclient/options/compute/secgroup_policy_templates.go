@@ -0,0 +1,115 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/secrules"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type SecgroupPolicyTemplateListOptions struct {
+	options.BaseListOptions
+}
+
+func (opts *SecgroupPolicyTemplateListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+func parsePolicyRules(ruleStrs []string) (jsonutils.JSONObject, error) {
+	rules := jsonutils.NewArray()
+	for i, ruleStr := range ruleStrs {
+		rule, err := secrules.ParseSecurityRule(ruleStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ParseSecurityRule(%s)", ruleStr)
+		}
+		r := jsonutils.NewDict()
+		r.Add(jsonutils.NewInt(int64(len(ruleStrs)-i)), "priority")
+		r.Add(jsonutils.NewString(string(rule.Direction)), "direction")
+		r.Add(jsonutils.NewString(string(rule.Action)), "action")
+		r.Add(jsonutils.NewString(rule.Protocol), "protocol")
+		r.Add(jsonutils.NewString(rule.GetPortsString()), "ports")
+		if rule.IPNet != nil {
+			r.Add(jsonutils.NewString(rule.IPNet.String()), "cidr")
+		}
+		rules.Add(r)
+	}
+	return rules, nil
+}
+
+type SecgroupPolicyTemplateCreateOptions struct {
+	options.BaseCreateOptions
+
+	RULE []string `help:"security rule, former rules have higher priority, e.g. in:allow tcp 22"`
+}
+
+func (opts *SecgroupPolicyTemplateCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts).(*jsonutils.JSONDict)
+	params.Remove("rule")
+	rules, err := parsePolicyRules(opts.RULE)
+	if err != nil {
+		return nil, err
+	}
+	params.Add(rules, "rules")
+	return params, nil
+}
+
+type SecgroupPolicyTemplateUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name        string
+	Description string
+	Rule        []string `help:"replace all rules, former rules have higher priority, e.g. in:allow tcp 22"`
+}
+
+func (opts *SecgroupPolicyTemplateUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	if len(opts.Name) > 0 {
+		params.Add(jsonutils.NewString(opts.Name), "name")
+	}
+	if len(opts.Description) > 0 {
+		params.Add(jsonutils.NewString(opts.Description), "description")
+	}
+	if len(opts.Rule) > 0 {
+		rules, err := parsePolicyRules(opts.Rule)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(rules, "rules")
+	}
+	return params, nil
+}
+
+type SecgroupPolicyTemplateApplyOptions struct {
+	options.BaseIdOptions
+
+	SERVER []string `help:"server ids or names to apply the template" json:"server_ids"`
+}
+
+func (opts *SecgroupPolicyTemplateApplyOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string][]string{"server_ids": opts.SERVER}), nil
+}
+
+type SecgroupPolicyTemplateCompatibilityOptions struct {
+	options.BaseIdOptions
+
+	Provider []string `help:"providers to check, default all"`
+}
+
+func (opts *SecgroupPolicyTemplateCompatibilityOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string][]string{"providers": opts.Provider}), nil
+}
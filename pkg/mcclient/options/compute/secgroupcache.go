@@ -22,7 +22,8 @@ import (
 
 type SecGroupCacheListOptions struct {
 	options.BaseListOptions
-	Secgroup string `help:"Secgroup ID or Name"`
+	Secgroup    string   `help:"Secgroup ID or Name"`
+	DriftStatus []string `help:"Filter by rule drift status" choices:"in_sync|drifted"`
 }
 
 func (opts *SecGroupCacheListOptions) Params() (jsonutils.JSONObject, error) {
@@ -40,3 +41,12 @@ func (opts *SecGroupCacheIdOptions) GetId() string {
 func (opts *SecGroupCacheIdOptions) Params() (jsonutils.JSONObject, error) {
 	return nil, nil
 }
+
+type SecGroupCacheCheckDriftOptions struct {
+	SecGroupCacheIdOptions
+	Remediate bool `help:"Reapply local rules to cloud when drift detected"`
+}
+
+func (opts *SecGroupCacheCheckDriftOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string]bool{"remediate": opts.Remediate}), nil
+}
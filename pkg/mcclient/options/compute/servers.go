@@ -68,6 +68,8 @@ type ServerListOptions struct {
 
 	ScalingGroup string `help:"ScalingGroup's id or name'"`
 
+	ExternalProjectId []string `help:"filter by external project or resource group id"`
+
 	options.BaseListOptions
 	options.MultiArchListOptions
 
@@ -253,8 +255,10 @@ type ServerConfigs struct {
 	Backup                       bool   `help:"Create server with backup server"`
 	AutoSwitchToBackupOnHostDown bool   `help:"Auto switch to backup server on host down"`
 
-	Schedtag []string `help:"Schedule policy, key = aggregate name, value = require|exclude|prefer|avoid" metavar:"<KEY:VALUE>"`
-	Disk     []string `help:"
+	Schedtag            []string `help:"Schedule policy, key = aggregate name, value = require|exclude|prefer|avoid" metavar:"<KEY:VALUE>"`
+	PreferLowestPrice   bool     `help:"Prefer the candidate with the lowest price of equivalent server sku"`
+	SchedPriorityWeight []string `help:"Scheduler priority weight, e.g. 'guest_prefer_cheapest_region=5', 0 to disable" metavar:"<NAME=WEIGHT>" json:"-"`
+	Disk                []string `help:"
 	Disk descriptions
 	size: 500M, 10G
 	fs: swap, ext2, ext3, ext4, xfs, ntfs, fat, hfsplus
@@ -262,6 +266,7 @@ type ServerConfigs struct {
 	driver: virtio, ide, scsi, sata, pvscsi
 	cache_mod: writeback, none, writethrough
 	medium: rotate, ssd, hybrid
+	storage_tier: hdd, ssd, nvme
 	disk_type: sys, data
 	mountpoint: /, /opt
 	storage_type: local, rbd, nas, nfs
@@ -286,16 +291,17 @@ type ServerConfigs struct {
 
 func (o ServerConfigs) Data() (*computeapi.ServerConfigs, error) {
 	data := &computeapi.ServerConfigs{
-		PreferManager:    o.Manager,
-		PreferRegion:     o.Region,
-		PreferZone:       o.Zone,
-		PreferWire:       o.Wire,
-		PreferHost:       o.Host,
-		PreferBackupHost: o.BackupHost,
-		Hypervisor:       o.Hypervisor,
-		ResourceType:     o.ResourceType,
-		Backup:           o.Backup,
-		Count:            o.Count,
+		PreferManager:     o.Manager,
+		PreferRegion:      o.Region,
+		PreferZone:        o.Zone,
+		PreferWire:        o.Wire,
+		PreferHost:        o.Host,
+		PreferBackupHost:  o.BackupHost,
+		Hypervisor:        o.Hypervisor,
+		ResourceType:      o.ResourceType,
+		Backup:            o.Backup,
+		Count:             o.Count,
+		PreferLowestPrice: o.PreferLowestPrice,
 	}
 	for i, d := range o.Disk {
 		disk, err := cmdline.ParseDiskConfig(d, i)
@@ -359,6 +365,20 @@ func (o ServerConfigs) Data() (*computeapi.ServerConfigs, error) {
 		}
 		data.Schedtags = append(data.Schedtags, schedtag)
 	}
+	for _, w := range o.SchedPriorityWeight {
+		parts := strings.SplitN(w, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid sched priority weight %q", w)
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid sched priority weight %q: %v", w, err)
+		}
+		if data.SchedPriorityWeights == nil {
+			data.SchedPriorityWeights = map[string]int{}
+		}
+		data.SchedPriorityWeights[parts[0]] = weight
+	}
 	return data, nil
 }
 
@@ -431,6 +451,7 @@ type ServerCreateOptionalOptions struct {
 	System           bool     `help:"Create a system VM, sysadmin ONLY option" json:"is_system"`
 	TaskNotify       *bool    `help:"Setup task notify" json:"-"`
 	DryRun           *bool    `help:"Dry run to test scheduler" json:"-"`
+	ValidateOnly     *bool    `help:"Only validate create params without creating server" json:"-"`
 	UserDataFile     string   `help:"user_data file path" json:"-"`
 	InstanceSnapshot string   `help:"instance snapshot" json:"instance_snapshot"`
 	Secgroups        []string `help:"secgroups" json:"secgroups"`
@@ -454,7 +475,11 @@ type ServerCreateOptionalOptions struct {
 
 	GuestImageID string `help:"create from guest image, need to specify the guest image id"`
 
+	ImageCatalog string `help:"create from published image catalog, the root disk image is chosen by platform and region"`
+
 	EncryptKey string `help:"encryption key"`
+
+	ExternalProject string `help:"external enterprise project or resource group, only for Aliyun, Huawei and Azure"`
 }
 
 func (o *ServerCreateOptions) ToScheduleInput() (*schedapi.ScheduleInput, error) {
@@ -540,9 +565,11 @@ func (opts *ServerCreateOptionalOptions) OptionalParams() (*computeapi.ServerCre
 		EnableCloudInit:    opts.EnableCloudInit,
 		OsType:             opts.OsType,
 		GuestImageID:       opts.GuestImageID,
+		ImageCatalogId:     opts.ImageCatalog,
 		Secgroups:          opts.Secgroups,
 		EnableMemclean:     opts.EnableMemclean,
 	}
+	params.ExternalProjectId = opts.ExternalProject
 
 	if len(opts.EncryptKey) > 0 {
 		params.EncryptKeyId = &opts.EncryptKey
@@ -696,6 +723,29 @@ func (o *ServerDeleteOptions) QueryParams() (jsonutils.JSONObject, error) {
 	return options.StructToParams(o)
 }
 
+type ServerBatchDeleteOptions struct {
+	SERVERS               []string `help:"ID or name of servers to delete"`
+	OverridePendingDelete bool     `help:"Delete servers directly instead of pending delete"`
+	Purge                 bool     `help:"Only delete local resources"`
+	DeleteSnapshots       bool     `help:"Delete server snapshots"`
+	DeleteDisks           bool     `help:"Delete server disks"`
+	DeleteEip             bool     `help:"Delete eip"`
+}
+
+func (o *ServerBatchDeleteOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(o)
+}
+
+type ServerBatchChangeProjectOptions struct {
+	SERVERS []string `help:"ID or name of servers to change project"`
+	PROJECT string   `help:"ID or name of target project" json:"project_id"`
+	DryRun  bool     `help:"Only check dependent resources without changing project"`
+}
+
+func (o *ServerBatchChangeProjectOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(o)
+}
+
 type ServerCancelDeleteOptions struct {
 	ServerIdsOptions
 }
@@ -836,6 +886,29 @@ func (o *ServerQgaCommand) Params() (jsonutils.JSONObject, error) {
 	return options.StructToParams(o)
 }
 
+type ServerRunCommandOptions struct {
+	ServerIdOptions
+
+	Script      string `help:"script content to run"`
+	ScriptFile  string `help:"read script from file" json:"-"`
+	CommandType string `help:"command type, default powershell for windows, otherwise shell" choices:"shell|powershell"`
+	Timeout     int    `help:"timeout in seconds, default 60"`
+}
+
+func (o *ServerRunCommandOptions) Params() (jsonutils.JSONObject, error) {
+	if len(o.ScriptFile) > 0 {
+		script, err := ioutil.ReadFile(o.ScriptFile)
+		if err != nil {
+			return nil, err
+		}
+		o.Script = string(script)
+	}
+	if len(o.Script) == 0 {
+		return nil, fmt.Errorf("script or script_file is required")
+	}
+	return options.StructToParams(o)
+}
+
 type ServerSetPasswordOptions struct {
 	ServerIdOptions
 
@@ -1338,3 +1411,18 @@ type ServerVncOptions struct {
 func (o *ServerVncOptions) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(o), nil
 }
+
+type ServerSlaReportOptions struct {
+	ServerListOptions
+
+	Month   string `help:"month of the report, e.g. 2006-01, default current month"`
+	GroupBy string `help:"report uptime by guest or project" choices:"guest|project"`
+}
+
+func (o *ServerSlaReportOptions) Property() string {
+	return "sla-report"
+}
+
+func (o *ServerSlaReportOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(o)
+}
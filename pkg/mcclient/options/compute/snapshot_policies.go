@@ -0,0 +1,42 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type SnapshotPolicyUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name string `help:"new name of snapshot policy"`
+	Desc string `help:"description" json:"description"`
+}
+
+func (opts *SnapshotPolicyUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type SnapshotPolicyBindServersOptions struct {
+	options.BaseIdOptions
+
+	Server []string `help:"id or name of servers" json:"servers"`
+}
+
+func (opts *SnapshotPolicyBindServersOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
@@ -33,9 +33,10 @@ type StorageListOptions struct {
 	Schedtag string `help:"filter storage by schedtag"`
 	HostId   string `help:"filter storages which attached the specified host"`
 
-	HostSchedtagId string `help:"filter storage by host schedtag"`
-	ImageId        string `help:"filter storage by image"`
-	IsBaremetal    *bool  `help:"Baremetal storage list"`
+	HostSchedtagId string   `help:"filter storage by host schedtag"`
+	ImageId        string   `help:"filter storage by image"`
+	IsBaremetal    *bool    `help:"Baremetal storage list"`
+	Tier           []string `help:"filter storage by performance tier" choices:"hdd|ssd|nvme"`
 }
 
 func (opts *StorageListOptions) Params() (jsonutils.JSONObject, error) {
@@ -50,6 +51,7 @@ type StorageUpdateOptions struct {
 	options.BaseUpdateOptions
 	CommitBound           float64 `help:"Upper bound of storage overcommit rate" json:"cmtbound"`
 	MediumType            string  `help:"Medium type" choices:"ssd|rotate"`
+	Tier                  string  `help:"Performance tier" choices:"hdd|ssd|nvme"`
 	RbdRadosMonOpTimeout  int64   `help:"ceph rados_mon_op_timeout"`
 	RbdRadosOsdOpTimeout  int64   `help:"ceph rados_osd_op_timeout"`
 	RbdClientMountTimeout int64   `help:"ceph client_mount_timeout"`
@@ -67,6 +69,7 @@ type StorageCreateOptions struct {
 	ZONE                  string `help:"Zone id of storage"`
 	Capacity              int64  `help:"Capacity of the Storage"`
 	MediumType            string `help:"Medium type" choices:"ssd|rotate" default:"ssd"`
+	Tier                  string `help:"Performance tier, auto detected if not specified" choices:"hdd|ssd|nvme"`
 	StorageType           string `help:"Storage type" choices:"local|nas|vsan|rbd|nfs|gpfs|baremetal"`
 	RbdMonHost            string `help:"Ceph mon_host config"`
 	RbdRadosMonOpTimeout  int64  `help:"ceph rados_mon_op_timeout"`
@@ -120,3 +123,35 @@ type StorageForceDetachHost struct {
 func (opts *StorageForceDetachHost) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(map[string]string{"host": opts.HOST}), nil
 }
+
+type StorageCapacityForecastOptions struct {
+	options.BaseIdOptions
+	SampleDays int `help:"Days of history capacity samples used to forecast, default 30"`
+}
+
+func (opts *StorageCapacityForecastOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type StorageCapacityForecastListOptions struct {
+	StorageListOptions
+
+	SampleDays        int    `help:"Days of history capacity samples used to forecast, default 30"`
+	GroupBy           string `help:"forecast capacity by storage or zone" choices:"storage|zone"`
+	ExhaustWithinDays int    `help:"only show storages or zones forecasted to be exhausted within these days"`
+}
+
+func (opts *StorageCapacityForecastListOptions) Property() string {
+	return "capacity-forecast"
+}
+
+func (opts *StorageCapacityForecastListOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.ListStructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Zone) > 0 {
+		params.Add(jsonutils.NewString(opts.Zone), "zone_id")
+	}
+	return params, nil
+}
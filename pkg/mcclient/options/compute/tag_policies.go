@@ -0,0 +1,55 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type TagPolicyListOptions struct {
+	options.BaseListOptions
+
+	Key []string `help:"filter by required tag key"`
+}
+
+func (opts *TagPolicyListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type TagPolicyCreateOptions struct {
+	options.BaseCreateOptions
+
+	KEYS              []string `help:"required tag keys, e.g. owner cost-center"`
+	PushInheritedTags bool     `help:"inherit missing tags from project and push them to cloud resources"`
+}
+
+func (opts *TagPolicyCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type TagPolicyUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name              string
+	Desc              string
+	Keys              []string `help:"required tag keys"`
+	PushInheritedTags *bool    `help:"inherit missing tags from project and push them to cloud resources"`
+}
+
+func (opts *TagPolicyUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
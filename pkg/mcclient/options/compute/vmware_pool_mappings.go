@@ -0,0 +1,45 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type VmwarePoolMappingListOptions struct {
+	options.BaseListOptions
+
+	MappingType []string `help:"filter by mapping type" choices:"resource_pool|folder"`
+	Path        []string `help:"filter by resource pool name or folder path"`
+}
+
+func (opts *VmwarePoolMappingListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type VmwarePoolMappingCreateOptions struct {
+	CLOUDPROVIDER string `help:"vCenter cloudprovider id or name" json:"cloudprovider_id"`
+	MAPPING_TYPE  string `help:"mapping type" choices:"resource_pool|folder" json:"mapping_type"`
+	PATH          string `help:"resource pool name or vm folder path relative to datacenter vm folder, e.g. dev/web" json:"path"`
+	PROJECT       string `help:"target project id or name" json:"project_id"`
+	Name          string `help:"mapping name, default generated from path"`
+	Desc          string `help:"description" json:"description"`
+}
+
+func (opts *VmwarePoolMappingCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
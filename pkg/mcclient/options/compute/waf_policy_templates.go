@@ -0,0 +1,93 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type WafPolicyTemplateListOptions struct {
+	options.BaseListOptions
+}
+
+func (opts *WafPolicyTemplateListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+func parseWafPolicyTemplateRules(rules string) (jsonutils.JSONObject, error) {
+	obj, err := jsonutils.ParseString(rules)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid rules, should be json array")
+	}
+	if _, ok := obj.(*jsonutils.JSONArray); !ok {
+		return nil, errors.Errorf("invalid rules, should be json array")
+	}
+	return obj, nil
+}
+
+type WafPolicyTemplateCreateOptions struct {
+	options.BaseCreateOptions
+
+	RULES string `help:"rules in json format, e.g. [{\"name\":\"block-sqli\",\"priority\":1,\"action\":{\"action\":\"Block\"},\"statements\":[{\"type\":\"SqliMatch\",\"match_field\":\"Query\"}]}]"`
+}
+
+func (opts *WafPolicyTemplateCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts).(*jsonutils.JSONDict)
+	rules, err := parseWafPolicyTemplateRules(opts.RULES)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("rules", rules)
+	return params, nil
+}
+
+type WafPolicyTemplateUpdateOptions struct {
+	options.BaseIdOptions
+
+	Name        string
+	Description string
+	Rules       string `help:"replace all rules, in json format"`
+}
+
+func (opts *WafPolicyTemplateUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	if len(opts.Name) > 0 {
+		params.Add(jsonutils.NewString(opts.Name), "name")
+	}
+	if len(opts.Description) > 0 {
+		params.Add(jsonutils.NewString(opts.Description), "description")
+	}
+	if len(opts.Rules) > 0 {
+		rules, err := parseWafPolicyTemplateRules(opts.Rules)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(rules, "rules")
+	}
+	return params, nil
+}
+
+type WafPolicyTemplateApplyOptions struct {
+	options.BaseIdOptions
+
+	WAF []string `help:"waf instance id or name"`
+}
+
+func (opts *WafPolicyTemplateApplyOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string][]string{"waf_instance_ids": opts.WAF}), nil
+}
@@ -15,6 +15,9 @@
 package options
 
 import (
+	"strconv"
+	"strings"
+
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/errors"
 )
@@ -137,3 +140,53 @@ func (opts DnsRecordSetTrafficPolicyOptions) Params() (jsonutils.JSONObject, err
 	params.Add(policy)
 	return jsonutils.Marshal(map[string]interface{}{"traffic_policies": params}), nil
 }
+
+type DnsRecordSetFailoverOptions struct {
+	DnsRecordSetIdOptions
+
+	Endpoint         []string `help:"failover endpoint, format: <value>[,<priority>[,<provider>]], e.g. 1.2.3.4,0,Aliyun"`
+	ProbeProtocol    string   `help:"health probe protocol" choices:"tcp|http|https"`
+	ProbePort        int      `help:"health probe port"`
+	ProbePath        string   `help:"http(s) health probe path"`
+	FailureThreshold int      `help:"consecutive probe failures before an endpoint is considered unhealthy"`
+	Clear            bool     `help:"clear failover settings"`
+}
+
+func (opts *DnsRecordSetFailoverOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	if opts.Clear {
+		params.Add(jsonutils.JSONTrue, "clear")
+		return params, nil
+	}
+	endpoints := jsonutils.NewArray()
+	for _, ep := range opts.Endpoint {
+		parts := strings.Split(ep, ",")
+		endpoint := jsonutils.NewDict()
+		endpoint.Add(jsonutils.NewString(parts[0]), "value")
+		if len(parts) > 1 && len(parts[1]) > 0 {
+			priority, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid priority %s", parts[1])
+			}
+			endpoint.Add(jsonutils.NewInt(int64(priority)), "priority")
+		}
+		if len(parts) > 2 {
+			endpoint.Add(jsonutils.NewString(parts[2]), "provider")
+		}
+		endpoints.Add(endpoint)
+	}
+	params.Add(endpoints, "endpoints")
+	if len(opts.ProbeProtocol) > 0 {
+		params.Add(jsonutils.NewString(opts.ProbeProtocol), "probe_protocol")
+	}
+	if opts.ProbePort > 0 {
+		params.Add(jsonutils.NewInt(int64(opts.ProbePort)), "probe_port")
+	}
+	if len(opts.ProbePath) > 0 {
+		params.Add(jsonutils.NewString(opts.ProbePath), "probe_path")
+	}
+	if opts.FailureThreshold > 0 {
+		params.Add(jsonutils.NewInt(int64(opts.FailureThreshold)), "failure_threshold")
+	}
+	return params, nil
+}
@@ -57,6 +57,27 @@ func (opts *NetworkListOptions) Params() (jsonutils.JSONObject, error) {
 	return ListStructToParams(opts)
 }
 
+type NetworkIpUtilizationOptions struct {
+	NetworkListOptions
+
+	GroupBy string `help:"report ip utilization by network, wire or zone" choices:"network|wire|zone"`
+}
+
+func (opts *NetworkIpUtilizationOptions) Property() string {
+	return "ip-utilization"
+}
+
+func (opts *NetworkIpUtilizationOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := ListStructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Wire) > 0 {
+		params.Add(jsonutils.NewString(opts.Wire), "wire")
+	}
+	return params, nil
+}
+
 type NetworkUpdateOptions struct {
 	BaseUpdateOptions
 
@@ -73,3 +73,15 @@ func (opts *NetworkAddressIdOptions) GetId() string {
 func (opts *NetworkAddressIdOptions) Params() (jsonutils.JSONObject, error) {
 	return nil, nil
 }
+
+type NetworkIpConflictListOptions struct {
+	BaseListOptions
+
+	NetworkId string   `help:"filter by network"`
+	GuestId   []string `help:"filter by guest id"`
+	Reason    []string `help:"filter by conflict reason" choices:"ip_in_use|no_network"`
+}
+
+func (opts *NetworkIpConflictListOptions) Params() (jsonutils.JSONObject, error) {
+	return ListStructToParams(opts)
+}
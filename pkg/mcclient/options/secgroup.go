@@ -128,6 +128,15 @@ func (opts *SecurityGroupUncacheSecurityGroup) Params() (jsonutils.JSONObject, e
 	return params, nil
 }
 
+type SecgroupSetDriftRemediationOptions struct {
+	SecgroupIdOptions
+	Enable bool `help:"Reapply local rules automatically when rules on cloud drift"`
+}
+
+func (opts *SecgroupSetDriftRemediationOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string]bool{"auto_remediate_drift": opts.Enable}), nil
+}
+
 type SecgroupChangeOwnerOptions struct {
 	SecgroupIdOptions
 	apis.ProjectizedResourceInput
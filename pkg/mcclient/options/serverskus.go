@@ -114,8 +114,30 @@ type ServerSkusUpdateOptions struct {
 
 	Zone   *string `help:"Zone ID or name"`
 	Region *string `help:"Region ID or name"`
+
+	HourlyPrice *float64 `help:"Hourly price of postpaid instance"`
+	Currency    *string  `help:"Currency of hourly price, e.g. CNY, USD"`
 }
 
 func (opts *ServerSkusUpdateOptions) Params() (jsonutils.JSONObject, error) {
 	return StructToParams(opts)
 }
+
+type ServerSkusEquivalentOptions struct {
+	SkuId                string   `help:"ID of source sku"`
+	CpuCoreCount         int      `help:"Cpu core count of source spec" json:"cpu_core_count,omitzero"`
+	MemorySizeMB         int      `help:"Memory size in MB of source spec" json:"memory_size_mb,omitzero"`
+	InstanceTypeCategory string   `help:"instance type category of source spec" choices:"general_purpose|burstable|compute_optimized|memory_optimized|storage_optimized|hardware_accelerated|high_memory|high_storage"`
+	CpuArch              string   `help:"cpu arch of source spec" choices:"x86|arm"`
+	Provider             []string `help:"target providers, default all providers except the source sku's"`
+	CloudregionId        []string `help:"target cloudregion id or name"`
+	Limit                int      `help:"max count of equivalent skus" json:"limit,omitzero"`
+}
+
+func (opts *ServerSkusEquivalentOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(opts)
+}
+
+func (opts *ServerSkusEquivalentOptions) Property() string {
+	return "equivalents"
+}
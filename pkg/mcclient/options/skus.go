@@ -68,3 +68,30 @@ func (opts *CloudregionIdOptions) GetId() string {
 func (opts *CloudregionIdOptions) Params() (jsonutils.JSONObject, error) {
 	return nil, nil
 }
+
+type CloudregionSyncRegionSkusOptions struct {
+	ID        string   `help:"Cloudregion Id" json:"-"`
+	Resources []string `help:"Resource of skus, default all supported by region" choices:"serversku|elasticcachesku|dbinstance_sku"`
+}
+
+func (opts *CloudregionSyncRegionSkusOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *CloudregionSyncRegionSkusOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type CloudregionSkuFreshnessOptions struct {
+	SkuSyncOptions
+
+	StaleHours int `help:"Skus not synced within these hours are stale, default sync skus period" json:"stale_hours,omitzero"`
+}
+
+func (opts *CloudregionSkuFreshnessOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+func (opts *CloudregionSkuFreshnessOptions) Property() string {
+	return "sku-freshness"
+}
@@ -46,3 +46,25 @@ type VpcPeeringConnectionCreateOptions struct {
 func (opts *VpcPeeringConnectionCreateOptions) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(opts).(*jsonutils.JSONDict), nil
 }
+
+type VpcPeeringConnectionMeshOptions struct {
+	VPC_IDS   []string `help:"vpc ids or names to be connected with each other"`
+	Bandwidth int      `help:"cross region vpc peering bandwidth, only for qcloud"`
+	DryRun    bool     `help:"only compute the peering plan"`
+}
+
+func (opts *VpcPeeringConnectionMeshOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type VpcPeeringConnectionTopologyOptions struct {
+	VPC_IDS []string `help:"vpc ids or names"`
+}
+
+func (opts *VpcPeeringConnectionTopologyOptions) Property() string {
+	return "topology"
+}
+
+func (opts *VpcPeeringConnectionTopologyOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	skuman "yunion.io/x/onecloud/pkg/scheduler/data_manager/sku"
+)
+
+// LowestPricePriority 请求指定 prefer_lowest_price 时, 比较各候选者所在区域/可用区下
+// 同规格套餐的按量付费价格, 优先选择价格最低的候选者
+type LowestPricePriority struct {
+	priorities.BasePriority
+
+	prices map[string]float64
+	min    float64
+	max    float64
+}
+
+func (p *LowestPricePriority) Name() string {
+	return "guest_lowest_price"
+}
+
+func (p *LowestPricePriority) Clone() core.Priority {
+	return &LowestPricePriority{}
+}
+
+func cheapestSku(skus []*skuman.ServerSku, zoneId string) *skuman.ServerSku {
+	var ret *skuman.ServerSku
+	for _, sku := range skus {
+		if sku.HourlyPrice <= 0 || (len(sku.ZoneId) > 0 && sku.ZoneId != zoneId) {
+			continue
+		}
+		if ret == nil || sku.HourlyPrice < ret.HourlyPrice {
+			ret = sku
+		}
+	}
+	return ret
+}
+
+func (p *LowestPricePriority) getCandidateSku(u *core.Unit, c core.Candidater) *skuman.ServerSku {
+	d := u.SchedData()
+	region, zone := c.Getter().Region(), c.Getter().Zone()
+	if region == nil || zone == nil {
+		return nil
+	}
+	// 指定套餐时比较同名套餐在各区域的价格, 否则比较同规格套餐的价格
+	if len(d.InstanceType) > 0 {
+		return cheapestSku(skuman.GetByRegion(d.InstanceType, region.Id), zone.Id)
+	}
+	return skuman.GetCheapestEquivalent(region.Id, zone.Id, d.Ncpu, d.Memory)
+}
+
+func (p *LowestPricePriority) PreExecute(u *core.Unit, cs []core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	d := u.SchedData()
+	if d.ServerConfigs == nil || !d.PreferLowestPrice {
+		return false, nil, nil
+	}
+	skus := map[string]*skuman.ServerSku{}
+	currencies := map[string]bool{}
+	for _, c := range cs {
+		sku := p.getCandidateSku(u, c)
+		if sku == nil {
+			continue
+		}
+		skus[c.IndexKey()] = sku
+		currencies[sku.Currency] = true
+	}
+	// 不同币种的价格不可比较
+	if len(currencies) > 1 {
+		log.Warningf("candidate skus are priced in different currencies, skip comparing price")
+		return false, nil, nil
+	}
+	p.prices = map[string]float64{}
+	for id, sku := range skus {
+		p.prices[id] = sku.HourlyPrice
+		if len(p.prices) == 1 || sku.HourlyPrice < p.min {
+			p.min = sku.HourlyPrice
+		}
+		if len(p.prices) == 1 || sku.HourlyPrice > p.max {
+			p.max = sku.HourlyPrice
+		}
+	}
+	if len(p.prices) == 0 {
+		return false, nil, nil
+	}
+	for id, sku := range skus {
+		u.SetCandidatePrice(id, &core.SCandidatePrice{
+			InstanceType: sku.Name,
+			HourlyPrice:  sku.HourlyPrice,
+			Currency:     sku.Currency,
+			PriceDelta:   sku.HourlyPrice - p.max,
+		})
+	}
+	return true, nil, nil
+}
+
+func (p *LowestPricePriority) Map(u *core.Unit, c core.Candidater) (core.HostPriority, error) {
+	h := priorities.NewPriorityHelper(p, u, c)
+	if price, ok := p.prices[c.IndexKey()]; ok {
+		score := priorities.NormalizeSoftScore(price, p.min, p.max, true)
+		// 只有价格最低的候选者得满分
+		if price <= p.min {
+			score = priorities.SoftPreferenceMaxScore
+		} else if score >= priorities.SoftPreferenceMaxScore {
+			score = priorities.SoftPreferenceMaxScore - 1
+		}
+		h.SetPreferScore(score)
+	}
+	return h.GetResult()
+}
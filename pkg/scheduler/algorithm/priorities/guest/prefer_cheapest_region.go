@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"time"
+
+	"yunion.io/x/log"
+
+	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
+	schedapi "yunion.io/x/onecloud/pkg/apis/scheduler"
+	computemodels "yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// CheapestRegionScorer 根据上个账单月份各区域平均每个资源的费用, 优先选择费用最低的区域
+type CheapestRegionScorer struct {
+	costs map[string]float64
+}
+
+func (s *CheapestRegionScorer) Name() string {
+	return schedapi.PRIORITY_PREFER_CHEAPEST_REGION
+}
+
+func (s *CheapestRegionScorer) Clone() priorities.ISoftScorer {
+	return &CheapestRegionScorer{}
+}
+
+func (s *CheapestRegionScorer) Prepare(u *core.Unit, cs []core.Candidater) (bool, error) {
+	now := time.Now().UTC()
+	billingMonth := now.AddDate(0, 0, -now.Day()).Format(computeapi.CLOUD_BILL_MONTH_FORMAT)
+	costs, err := computemodels.CloudBillItemManager.GetRegionAverageCosts(billingMonth)
+	if err != nil {
+		// 账单数据缺失不影响调度
+		log.Errorf("GetRegionAverageCosts %s: %v", billingMonth, err)
+		return false, nil
+	}
+	s.costs = costs
+	return len(costs) > 0, nil
+}
+
+func (s *CheapestRegionScorer) Value(u *core.Unit, c core.Candidater) (float64, bool) {
+	region := c.Getter().Region()
+	if region == nil {
+		return 0, false
+	}
+	cost, ok := s.costs[region.Id]
+	return cost, ok
+}
+
+func (s *CheapestRegionScorer) LowerIsBetter() bool {
+	return true
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"context"
+	"strconv"
+
+	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
+	schedapi "yunion.io/x/onecloud/pkg/apis/scheduler"
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// LowestLatencyZoneScorer 根据可用区元数据中记录的网络延迟, 优先选择延迟最低的可用区
+type LowestLatencyZoneScorer struct {
+	latencies map[string]float64
+}
+
+func (s *LowestLatencyZoneScorer) Name() string {
+	return schedapi.PRIORITY_PREFER_LOWEST_LATENCY_ZONE
+}
+
+func (s *LowestLatencyZoneScorer) Clone() priorities.ISoftScorer {
+	return &LowestLatencyZoneScorer{}
+}
+
+func (s *LowestLatencyZoneScorer) Prepare(u *core.Unit, cs []core.Candidater) (bool, error) {
+	s.latencies = map[string]float64{}
+	visited := map[string]bool{}
+	for _, c := range cs {
+		zone := c.Getter().Zone()
+		if zone == nil || visited[zone.Id] {
+			continue
+		}
+		visited[zone.Id] = true
+		latency, err := strconv.ParseFloat(zone.GetMetadata(context.Background(), computeapi.ZONEMETA_LATENCY_MS, nil), 64)
+		if err != nil {
+			continue
+		}
+		s.latencies[zone.Id] = latency
+	}
+	return len(s.latencies) > 0, nil
+}
+
+func (s *LowestLatencyZoneScorer) Value(u *core.Unit, c core.Candidater) (float64, bool) {
+	zone := c.Getter().Zone()
+	if zone == nil {
+		return 0, false
+	}
+	latency, ok := s.latencies[zone.Id]
+	return latency, ok
+}
+
+func (s *LowestLatencyZoneScorer) LowerIsBetter() bool {
+	return true
+}
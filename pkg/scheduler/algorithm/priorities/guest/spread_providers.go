@@ -0,0 +1,63 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	schedapi "yunion.io/x/onecloud/pkg/apis/scheduler"
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// SpreadProvidersScorer 统计项目在各云订阅下已有的虚拟机数量, 优先选择虚拟机较少的云订阅,
+// 使项目的虚拟机分散在不同的云平台上, 本地宿主机视为同一个订阅
+type SpreadProvidersScorer struct {
+	guests map[string]int64
+}
+
+func (s *SpreadProvidersScorer) Name() string {
+	return schedapi.PRIORITY_SPREAD_PROVIDERS
+}
+
+func (s *SpreadProvidersScorer) Clone() priorities.ISoftScorer {
+	return &SpreadProvidersScorer{}
+}
+
+func getCandidateProviderId(c core.Candidater) string {
+	provider := c.Getter().Cloudprovider()
+	if provider == nil {
+		return ""
+	}
+	return provider.Id
+}
+
+func (s *SpreadProvidersScorer) Prepare(u *core.Unit, cs []core.Candidater) (bool, error) {
+	s.guests = map[string]int64{}
+	projectId := u.SchedData().Project
+	for _, c := range cs {
+		providerId := getCandidateProviderId(c)
+		s.guests[providerId] += c.Getter().ProjectGuests()[projectId]
+	}
+	// 只有一个云订阅时无需打散
+	return len(s.guests) > 1, nil
+}
+
+func (s *SpreadProvidersScorer) Value(u *core.Unit, c core.Candidater) (float64, bool) {
+	count, ok := s.guests[getCandidateProviderId(c)]
+	return float64(count), ok
+}
+
+func (s *SpreadProvidersScorer) LowerIsBetter() bool {
+	return true
+}
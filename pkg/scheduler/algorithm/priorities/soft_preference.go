@@ -0,0 +1,105 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorities
+
+import (
+	"math"
+
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// SoftPreferenceMaxScore 软约束打分插件的最高分, 打分范围为 [0, SoftPreferenceMaxScore]
+const SoftPreferenceMaxScore = 10
+
+// ISoftScorer 软约束打分插件接口, 插件只需提供候选者的原始度量值,
+// 由 SoftPreferencePriority 在所有候选者之间归一化为分数
+type ISoftScorer interface {
+	// 插件名称, 同时作为 sched_priority_weights 中的 key
+	Name() string
+	Clone() ISoftScorer
+	// 打分前批量加载数据, 返回 false 表示本次调度跳过该插件
+	Prepare(u *core.Unit, cs []core.Candidater) (bool, error)
+	// 返回候选者的原始度量值, 返回 false 表示无法度量, 该候选者得0分
+	Value(u *core.Unit, c core.Candidater) (float64, bool)
+	// 原始度量值越小越优先时返回 true
+	LowerIsBetter() bool
+}
+
+// SoftPreferencePriority 将 ISoftScorer 适配为调度打分插件
+type SoftPreferencePriority struct {
+	BasePriority
+
+	scorer ISoftScorer
+	values map[string]float64
+	min    float64
+	max    float64
+}
+
+func NewSoftPreferencePriority(scorer ISoftScorer) *SoftPreferencePriority {
+	return &SoftPreferencePriority{
+		scorer: scorer,
+	}
+}
+
+func (p *SoftPreferencePriority) Name() string {
+	return p.scorer.Name()
+}
+
+func (p *SoftPreferencePriority) Clone() core.Priority {
+	return NewSoftPreferencePriority(p.scorer.Clone())
+}
+
+func (p *SoftPreferencePriority) PreExecute(u *core.Unit, cs []core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	ok, err := p.scorer.Prepare(u, cs)
+	if err != nil || !ok {
+		return false, nil, err
+	}
+	p.values = map[string]float64{}
+	p.min, p.max = math.MaxFloat64, -math.MaxFloat64
+	for _, c := range cs {
+		val, ok := p.scorer.Value(u, c)
+		if !ok {
+			continue
+		}
+		p.values[c.IndexKey()] = val
+		p.min = math.Min(p.min, val)
+		p.max = math.Max(p.max, val)
+	}
+	// 没有可比较的候选者时打分无意义
+	if len(p.values) == 0 {
+		return false, nil, nil
+	}
+	return true, nil, nil
+}
+
+func (p *SoftPreferencePriority) Map(u *core.Unit, c core.Candidater) (core.HostPriority, error) {
+	h := NewPriorityHelper(p, u, c)
+	if val, ok := p.values[c.IndexKey()]; ok {
+		h.SetScore(NormalizeSoftScore(val, p.min, p.max, p.scorer.LowerIsBetter()))
+	}
+	return h.GetResult()
+}
+
+// NormalizeSoftScore 将原始度量值按 [min, max] 线性映射为 [0, SoftPreferenceMaxScore] 的分数
+func NormalizeSoftScore(val, min, max float64, lowerIsBetter bool) int {
+	if max <= min {
+		return 0
+	}
+	ratio := (val - min) / (max - min)
+	if lowerIsBetter {
+		ratio = 1 - ratio
+	}
+	return int(math.Round(ratio * SoftPreferenceMaxScore))
+}
@@ -19,6 +19,7 @@ import (
 
 	"yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates"
 	predicateguest "yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates/guest"
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
 	priorityguest "yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities/guest"
 	"yunion.io/x/onecloud/pkg/scheduler/factory"
 )
@@ -59,5 +60,10 @@ func defaultPriorities() sets.String {
 		factory.RegisterPriority("guest-lowload", &priorityguest.LowLoadPriority{}, 1),
 		factory.RegisterPriority("guest-creating", &priorityguest.CreatingPriority{}, 1),
 		factory.RegisterPriority("guest-capacity", &priorityguest.CapacityPriority{}, 1),
+		factory.RegisterPriority("guest-lowest-price", &priorityguest.LowestPricePriority{}, 1),
+		// 软约束, 默认权重为0, 需在请求中通过 sched_priority_weights 开启
+		factory.RegisterPriority("guest-prefer-cheapest-region", priorities.NewSoftPreferencePriority(&priorityguest.CheapestRegionScorer{}), 0),
+		factory.RegisterPriority("guest-prefer-lowest-latency-zone", priorities.NewSoftPreferencePriority(&priorityguest.LowestLatencyZoneScorer{}), 0),
+		factory.RegisterPriority("guest-spread-providers", priorities.NewSoftPreferencePriority(&priorityguest.SpreadProvidersScorer{}), 0),
 	)
 }
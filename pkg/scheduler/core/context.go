@@ -377,6 +377,15 @@ func (m *SchedLogManager) Read() []string {
 	return rets
 }
 
+// SCandidatePrice 按价格调度时候选者选中的套餐及价格
+type SCandidatePrice struct {
+	InstanceType string  `json:"instance_type"`
+	HourlyPrice  float64 `json:"hourly_price"`
+	Currency     string  `json:"currency"`
+	// 与候选者中最高价格的差值
+	PriceDelta float64 `json:"price_delta"`
+}
+
 // Unit wraps sched input info and other log and record manager
 type Unit struct {
 	SchedInfo             *api.SchedInfo
@@ -389,6 +398,11 @@ type Unit struct {
 	capacityLock sync.Mutex
 	scoreLock    sync.Mutex
 
+	// 打分插件名称 => 生效权重
+	priorityWeights map[string]int
+	// 候选者 => 按价格调度时选中的套餐
+	candidatePrices map[string]*SCandidatePrice
+
 	FailedCandidateMap     map[string]*FailedCandidates
 	failedCandidateMapLock sync.Mutex
 
@@ -428,6 +442,7 @@ func NewScheduleUnit(info *api.SchedInfo, schedManager interface{}) *Unit {
 		LogManager:             NewSchedLogManager(),
 		SchedulerManager:       schedManager,
 		AllocatedResources:     make(map[string]*AllocatedResource),
+		candidatePrices:        make(map[string]*SCandidatePrice),
 
 		SelectPriorityMap:        spmap,
 		SelectPriorityUpdaterMap: spumap,
@@ -634,11 +649,45 @@ func (u *Unit) setScore(id string, val score.SScore, prefer tristate.TriState) {
 		u.ScoreMap[id] = scoreObj
 	}
 
+	if weight, ok := u.priorityWeights[val.Name]; ok {
+		val.Score = val.Score * score.TScore(weight)
+	}
 	scoreObj.ScoreBucket.SetScore(val, prefer)
 
 	log.V(10).Infof("SetScore: %q -> %s", id, val.String())
 }
 
+// SetPriorityWeights 设置打分插件权重, 插件打分结果会乘以对应权重
+func (u *Unit) SetPriorityWeights(weights map[string]int) {
+	u.scoreLock.Lock()
+	defer u.scoreLock.Unlock()
+
+	u.priorityWeights = weights
+}
+
+func (u *Unit) SetCandidatePrice(id string, price *SCandidatePrice) {
+	u.scoreLock.Lock()
+	defer u.scoreLock.Unlock()
+
+	u.candidatePrices[id] = price
+}
+
+func (u *Unit) GetCandidatePrice(id string) *SCandidatePrice {
+	u.scoreLock.Lock()
+	defer u.scoreLock.Unlock()
+
+	return u.candidatePrices[id]
+}
+
+// GetPriorityWeightOverrides 返回请求中指定的打分插件权重
+func (u *Unit) GetPriorityWeightOverrides() map[string]int {
+	data := u.SchedData()
+	if data == nil || data.ScheduleInput == nil || data.ServerConfigs == nil {
+		return nil
+	}
+	return data.SchedPriorityWeights
+}
+
 func (u *Unit) SetScore(id string, val score.SScore) {
 	u.setScore(id, val, tristate.None)
 }
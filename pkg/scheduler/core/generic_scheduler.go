@@ -188,6 +188,7 @@ func newSchedResultByCtx(u *Unit, count int64, c Candidater) *SchedResultItem {
 		Candidater:        c,
 		AllocatedResource: u.GetAllocatedResource(id),
 		SchedData:         u.SchedData(),
+		Price:             u.GetCandidatePrice(id),
 	}
 
 	if showDetails {
@@ -577,6 +578,9 @@ func PrioritizeCandidates(
 	candidates []Candidater,
 	priorities []PriorityConfig,
 ) (HostPriorityList, error) {
+	priorities, weights := resolvePriorityWeights(priorities, unit.GetPriorityWeightOverrides())
+	unit.SetPriorityWeights(weights)
+
 	// If no priority configs are provided, then the EqualPriority function is applied
 	// This is required to generate the priority list in the required format
 	if len(priorities) == 0 {
@@ -662,6 +666,27 @@ func PrioritizeCandidates(
 	return result, nil
 }
 
+// resolvePriorityWeights 使用请求中指定的权重覆盖打分插件默认权重,
+// 权重不大于0的打分插件不参与打分
+func resolvePriorityWeights(priorities []PriorityConfig, overrides map[string]int) ([]PriorityConfig, map[string]int) {
+	newPriorities := []PriorityConfig{}
+	weights := map[string]int{}
+	for _, p := range priorities {
+		weight := p.Weight
+		if w, ok := overrides[p.Name]; ok {
+			weight = w
+		}
+		if weight <= 0 {
+			continue
+		}
+		if len(p.Name) > 0 {
+			weights[p.Name] = weight
+		}
+		newPriorities = append(newPriorities, p)
+	}
+	return newPriorities, weights
+}
+
 func preExecPriorities(priorities []PriorityConfig, unit *Unit, candidates []Candidater) ([]PriorityConfig, error) {
 	newPriorities := []PriorityConfig{}
 	for _, p := range priorities {
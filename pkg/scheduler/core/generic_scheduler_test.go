@@ -0,0 +1,58 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolvePriorityWeights(t *testing.T) {
+	priorities := []PriorityConfig{
+		{Name: "guest_avoid_same_host", Weight: 1},
+		{Name: "host_lowload", Weight: 1},
+		{Name: "guest_prefer_cheapest_region", Weight: 0},
+		{Name: "guest_spread_providers", Weight: 0},
+	}
+	cases := []struct {
+		name      string
+		overrides map[string]int
+		want      map[string]int
+	}{
+		{
+			name: "defaults",
+			want: map[string]int{"guest_avoid_same_host": 1, "host_lowload": 1},
+		},
+		{
+			name:      "enable soft preference",
+			overrides: map[string]int{"guest_prefer_cheapest_region": 5},
+			want:      map[string]int{"guest_avoid_same_host": 1, "host_lowload": 1, "guest_prefer_cheapest_region": 5},
+		},
+		{
+			name:      "disable and reweight",
+			overrides: map[string]int{"host_lowload": 0, "guest_avoid_same_host": 3, "unknown": 2},
+			want:      map[string]int{"guest_avoid_same_host": 3},
+		},
+	}
+	for _, c := range cases {
+		got, weights := resolvePriorityWeights(priorities, c.overrides)
+		if !reflect.DeepEqual(weights, c.want) {
+			t.Errorf("%s: weights got %v want %v", c.name, weights, c.want)
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: priorities got %d want %d", c.name, len(got), len(c.want))
+		}
+	}
+}
@@ -45,6 +45,8 @@ type SchedResultItem struct {
 
 	Candidater Candidater `json:"-"`
 
+	Price *SCandidatePrice `json:"price,omitempty"`
+
 	*AllocatedResource
 
 	SchedData *api.SchedInfo
@@ -76,12 +78,19 @@ func (its SchedResultItems) Less(i, j int) bool {
 }
 
 func (item *SchedResultItem) ToCandidateResource(storageUsed *StorageUsed) *schedapi.CandidateResource {
-	return &schedapi.CandidateResource{
+	ret := &schedapi.CandidateResource{
 		HostId: item.ID,
 		Name:   item.Name,
 		Disks:  item.getDisks(storageUsed),
 		Nets:   item.Nets,
 	}
+	if item.Price != nil {
+		ret.InstanceType = item.Price.InstanceType
+		ret.HourlyPrice = item.Price.HourlyPrice
+		ret.Currency = item.Price.Currency
+		ret.PriceDelta = item.Price.PriceDelta
+	}
+	return ret
 }
 
 func (item *SchedResultItem) getDisks(used *StorageUsed) []*schedapi.CandidateDisk {
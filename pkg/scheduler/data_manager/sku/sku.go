@@ -60,6 +60,11 @@ func GetByRegion(instanceType, regionId string) []*ServerSku {
 	return skuManager.GetByRegion(instanceType, regionId)
 }
 
+// GetCheapestEquivalent 返回区域(或可用区)内与指定规格相同且按量付费价格最低的套餐
+func GetCheapestEquivalent(regionId, zoneId string, cpu, memMB int) *ServerSku {
+	return skuManager.GetCheapestEquivalent(regionId, zoneId, cpu, memMB)
+}
+
 type skuMap struct {
 	*sync.Map
 }
@@ -69,6 +74,12 @@ type ServerSku struct {
 	Name     string `json:"name"`
 	RegionId string `json:"cloudregion_id"`
 	ZoneId   string `json:"zone_id"`
+
+	CpuCoreCount   int     `json:"cpu_core_count"`
+	MemorySizeMB   int     `json:"memory_size_mb"`
+	PostpaidStatus string  `json:"postpaid_status"`
+	HourlyPrice    float64 `json:"hourly_price"`
+	Currency       string  `json:"currency"`
 }
 
 type skuList []*ServerSku
@@ -131,7 +142,9 @@ func (cache *skuMap) Add(instanceType string, sku *ServerSku) {
 
 type SSkuManager struct {
 	// skus cache all server skus in database, key is InstanceType, value is []models.SServerSku
-	skuMap          *skuMap
+	skuMap *skuMap
+	// regionSkus cache all server skus by cloudregion_id
+	regionSkus      map[string]skuList
 	refreshInterval time.Duration
 }
 
@@ -140,7 +153,7 @@ func (m *SSkuManager) syncOnce() {
 	startTime := time.Now()
 
 	skus := make([]ServerSku, 0)
-	q := models.ServerSkuManager.Query("id", "name", "cloudregion_id", "zone_id").IsTrue("enabled")
+	q := models.ServerSkuManager.Query("id", "name", "cloudregion_id", "zone_id", "cpu_core_count", "memory_size_mb", "postpaid_status", "hourly_price", "currency").IsTrue("enabled")
 	q = q.Filter(
 		sqlchemy.OR(
 			sqlchemy.Equals(q.Field("prepaid_status"), computeapi.SkuStatusAvailable),
@@ -149,11 +162,15 @@ func (m *SSkuManager) syncOnce() {
 		log.Errorf("SkuManager query all available skus error: %v", err)
 		return
 	}
-	m.skuMap = newSkuMap()
+	skuMap := newSkuMap()
+	regionSkus := map[string]skuList{}
 	for _, sku := range skus {
 		tmp := sku
-		m.skuMap.Add(sku.Name, &tmp)
+		skuMap.Add(sku.Name, &tmp)
+		regionSkus[sku.RegionId] = append(regionSkus[sku.RegionId], &tmp)
 	}
+	m.skuMap = skuMap
+	m.regionSkus = regionSkus
 	log.Infof("SkuManager end sync, consume %s", time.Since(startTime))
 }
 
@@ -176,3 +193,22 @@ func (m *SSkuManager) GetByRegion(instanceType, regionId string) []*ServerSku {
 	}
 	return l.GetByRegion(regionId)
 }
+
+func (m *SSkuManager) GetCheapestEquivalent(regionId, zoneId string, cpu, memMB int) *ServerSku {
+	var ret *ServerSku
+	for _, sku := range m.regionSkus[regionId] {
+		if sku.CpuCoreCount != cpu || sku.MemorySizeMB != memMB || sku.HourlyPrice <= 0 {
+			continue
+		}
+		if sku.PostpaidStatus != computeapi.SkuStatusAvailable {
+			continue
+		}
+		if len(zoneId) > 0 && len(sku.ZoneId) > 0 && sku.ZoneId != zoneId {
+			continue
+		}
+		if ret == nil || sku.HourlyPrice < ret.HourlyPrice {
+			ret = sku
+		}
+	}
+	return ret
+}
@@ -35,6 +35,7 @@ type FitPredicateFactory func() core.FitPredicate
 type PriorityFunctionFactory func() (core.PriorityPreFunction, core.PriorityMapFunction, core.PriorityReduceFunction)
 
 type PriorityConfigFactory struct {
+	Name              string
 	MapReduceFunction PriorityFunctionFactory
 	Weight            int
 }
@@ -113,6 +114,7 @@ func RegisterPriority(name string, priority core.Priority, weight int) string {
 	defer schedulerFactoryMutex.Unlock()
 	validateAlgorithmNameOrDie(name)
 	priorityConfigMap[name] = PriorityConfigFactory{
+		Name: priority.Name(),
 		MapReduceFunction: func() (core.PriorityPreFunction, core.PriorityMapFunction, core.PriorityReduceFunction) {
 			p := priority.Clone()
 			return p.PreExecute, p.Map, p.Reduce
@@ -134,6 +136,7 @@ func getPriorityConfigs(names sets.String) ([]core.PriorityConfig, error) {
 		}
 		preFunc, mapFunc, reduceFunc := factory.MapReduceFunction()
 		configs = append(configs, core.PriorityConfig{
+			Name:   factory.Name,
 			Pre:    preFunc,
 			Map:    mapFunc,
 			Reduce: reduceFunc,
@@ -73,6 +73,7 @@ const (
 	ACT_RESET_DISK                   = "reset_disk"
 	ACT_SYNC_STATUS                  = "sync_status"
 	ACT_SYNC_CONF                    = "sync_conf"
+	ACT_SETUP_SAML                   = "setup_saml"
 	ACT_CREATE_BACKUP                = "create_backup"
 	ACT_SWITCH_TO_BACKUP             = "switch_to_backup"
 	ACT_RENEW                        = "renew"
@@ -83,6 +84,11 @@ const (
 	ACT_EIP_ASSOCIATE                = "eip_associate"
 	ACT_EIP_DISSOCIATE               = "eip_dissociate"
 	ACT_EIP_CONVERT                  = "eip_convert"
+	ACT_EIP_RELEASE_TO_POOL          = "eip_release_to_pool"
+	ACT_DNS_FAILOVER                 = "dns_failover"
+	ACT_APPLY_POLICY_TEMPLATE        = "apply_policy_template"
+	ACT_SECGROUP_DRIFT               = "secgroup_drift"
+	ACT_SECGROUP_REMEDIATE_DRIFT     = "secgroup_remediate_drift"
 	ACT_CHANGE_BANDWIDTH             = "change_bandwidth"
 	ACT_DISK_CREATE_SNAPSHOT         = "disk_create_snapshot"
 	ACT_DISK_CHANGE_STORAGE          = "disk_change_storage"
@@ -96,6 +102,8 @@ const (
 	ACT_CANCEL_SNAPSHOT_POLICY       = "cancel_snapshot_policy"
 	ACT_BIND_DISK                    = "bind_disk"
 	ACT_UNBIND_DISK                  = "unbind_disk"
+	ACT_BIND_SERVER                  = "bind_server"
+	ACT_UNBIND_SERVER                = "unbind_server"
 	ACT_ATTACH_HOST                  = "attach_host"
 	ACT_DETACH_HOST                  = "detach_host"
 	ACT_VM_IO_THROTTLE               = "vm_io_throttle"
@@ -138,6 +146,7 @@ const (
 	ACT_GUEST_CREATE_FROM_IMPORT    = "guest_create_from_import"
 	ACT_GUEST_PANICKED              = "guest_panicked"
 	ACT_HOST_MAINTAINING            = "host_maintaining"
+	ACT_ZONE_REBALANCE              = "zone_rebalance"
 
 	ACT_MKDIR          = "mkdir"
 	ACT_DELETE_OBJECT  = "delete_object"
@@ -150,10 +159,30 @@ const (
 	ACT_SET_POLICY     = "set_policy"
 	ACT_DELETE_POLICY  = "delete_policy"
 
+	ACT_REPLICATE = "replicate"
+
+	ACT_IMAGE_CONVERT = "image_convert"
+
+	ACT_IMPORT_BILLS = "import_bills"
+
+	ACT_BUDGET_STOP_GUESTS = "budget_stop_guests"
+
+	ACT_IDLE_REQUEST_CLEANUP = "idle_request_cleanup"
+	ACT_IDLE_REJECT_CLEANUP  = "idle_reject_cleanup"
+	ACT_IDLE_CLEANUP         = "idle_cleanup"
+
+	ACT_SCALING_GROUP_ADD_TARGET    = "scaling_group_add_target"
+	ACT_SCALING_GROUP_REMOVE_TARGET = "scaling_group_remove_target"
+
+	ACT_QUOTA_RESERVATION_RELEASE = "quota_reservation_release"
+
+	ACT_PROVIDER_ALERT = "provider_alert"
+
 	ACT_NAT_CREATE_SNAT = "nat_create_snat"
 	ACT_NAT_CREATE_DNAT = "nat_create_dnat"
 	ACT_NAT_DELETE_SNAT = "nat_delete_snat"
 	ACT_NAT_DELETE_DNAT = "nat_delete_dnat"
+	ACT_NAT_APPLY_RULES = "nat_apply_rules"
 
 	ACT_GRANT_PRIVILEGE  = "grant_privilege"
 	ACT_REVOKE_PRIVILEGE = "revoke_privilege"
@@ -198,6 +227,13 @@ const (
 
 	ACT_UPDATE_BILLING_OPTIONS = "update_billing_options"
 	ACT_UPDATE_CREDENTIAL      = "update_credential"
+	ACT_ISSUE_CREDENTIAL       = "issue_credential"
+	ACT_AUDIT_PERMISSIONS      = "audit_permissions"
+	ACT_UPDATE_PARAMETERS      = "update_parameters"
+
+	ACT_REQUEST_APPROVAL = "request_approval"
+	ACT_APPROVE          = "approve"
+	ACT_REJECT           = "reject"
 
 	ACT_PULL_SUBCONTACT   = "pull_subcontact"
 	ACT_SEND_NOTIFICATION = "send_notification"
@@ -233,6 +269,12 @@ const (
 
 	ACT_ENCRYPTION = "encrypt"
 
+	ACT_SET_GC_POLICY   = "set_gc_policy"
+	ACT_GARBAGE_COLLECT = "garbage_collect"
+
+	ACT_COLLECT_SOFTWARE_INVENTORY = "collect_software_inventory"
+	ACT_RUN_COMMAND                = "run_command"
+
 	ACT_CONSOLE           = "console"
 	ACT_WEBSSH            = "webssh"
 	ACT_SET_USER_PASSWORD = "set_user_password"
@@ -328,6 +328,14 @@ func init() {
 		EN("Unbind Disk").
 		CN("解绑磁盘"),
 	)
+	t.Set(ACT_BIND_SERVER, i18n.NewTableEntry().
+		EN("Bind Server").
+		CN("绑定主机"),
+	)
+	t.Set(ACT_UNBIND_SERVER, i18n.NewTableEntry().
+		EN("Unbind Server").
+		CN("解绑主机"),
+	)
 	t.Set(ACT_ATTACH_HOST, i18n.NewTableEntry().
 		EN("Attach Host").
 		CN("关联宿主机"),
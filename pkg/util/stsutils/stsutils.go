@@ -0,0 +1,165 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stsutils
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	alisdk "github.com/aliyun/alibaba-cloud-sdk-go/sdk"
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	ALIYUN_STS_ENDPOINT = "sts.aliyuncs.com"
+	ALIYUN_STS_VERSION  = "2015-04-01"
+)
+
+type SCredentialOptions struct {
+	// 有效期(秒)
+	DurationSeconds int64
+	// 进一步收缩权限的策略文档
+	Policy string
+	// 扮演的角色, 阿里云必须指定
+	RoleArn string
+	// 会话名称, 用于云上审计
+	SessionName string
+}
+
+type SCredential struct {
+	AccessKeyId     string    `json:"access_key_id,omitempty"`
+	AccessKeySecret string    `json:"access_key_secret,omitempty"`
+	SecurityToken   string    `json:"security_token,omitempty"`
+	Expiration      time.Time `json:"expiration"`
+}
+
+var sessionNameReg = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// 会话名称仅允许字母数字及+=,.@-, 长度2-32
+func NormalizeSessionName(name string) string {
+	name = sessionNameReg.ReplaceAllString(name, "-")
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	for len(name) < 2 {
+		name += "-"
+	}
+	return name
+}
+
+// 必须指定角色或策略以收缩权限, 不签发与主账号同权限的临时凭据
+func IssueAwsCredential(accessKey, secret, regionId string, opts SCredentialOptions) (*SCredential, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(regionId),
+		Credentials: credentials.NewStaticCredentials(accessKey, secret, ""),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "NewSession")
+	}
+	svc := sts.New(sess)
+	if len(opts.RoleArn) == 0 && len(opts.Policy) == 0 {
+		return nil, errors.Error("role_arn or policy is required for aws")
+	}
+	var cred *sts.Credentials
+	switch {
+	case len(opts.RoleArn) > 0:
+		input := &sts.AssumeRoleInput{
+			RoleArn:         aws.String(opts.RoleArn),
+			RoleSessionName: aws.String(opts.SessionName),
+			DurationSeconds: aws.Int64(opts.DurationSeconds),
+		}
+		if len(opts.Policy) > 0 {
+			input.Policy = aws.String(opts.Policy)
+		}
+		output, err := svc.AssumeRole(input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "AssumeRole")
+		}
+		cred = output.Credentials
+	default:
+		output, err := svc.GetFederationToken(&sts.GetFederationTokenInput{
+			Name:            aws.String(opts.SessionName),
+			DurationSeconds: aws.Int64(opts.DurationSeconds),
+			Policy:          aws.String(opts.Policy),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetFederationToken")
+		}
+		cred = output.Credentials
+	}
+	if cred == nil {
+		return nil, errors.Error("empty credentials")
+	}
+	return &SCredential{
+		AccessKeyId:     aws.StringValue(cred.AccessKeyId),
+		AccessKeySecret: aws.StringValue(cred.SecretAccessKey),
+		SecurityToken:   aws.StringValue(cred.SessionToken),
+		Expiration:      aws.TimeValue(cred.Expiration),
+	}, nil
+}
+
+func IssueAliyunCredential(accessKey, secret, regionId string, opts SCredentialOptions) (*SCredential, error) {
+	if len(opts.RoleArn) == 0 {
+		return nil, errors.Error("role_arn is required for aliyun")
+	}
+	client, err := alisdk.NewClientWithAccessKey(regionId, accessKey, secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "NewClientWithAccessKey")
+	}
+	req := requests.NewCommonRequest()
+	req.Method = "POST"
+	req.Scheme = "https"
+	req.Domain = ALIYUN_STS_ENDPOINT
+	req.Version = ALIYUN_STS_VERSION
+	req.ApiName = "AssumeRole"
+	req.QueryParams["RoleArn"] = opts.RoleArn
+	req.QueryParams["RoleSessionName"] = opts.SessionName
+	req.QueryParams["DurationSeconds"] = fmt.Sprintf("%d", opts.DurationSeconds)
+	if len(opts.Policy) > 0 {
+		req.QueryParams["Policy"] = opts.Policy
+	}
+	resp, err := client.ProcessCommonRequest(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "AssumeRole")
+	}
+	body, err := jsonutils.Parse(resp.GetHttpContentBytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "Parse")
+	}
+	ret := struct {
+		AccessKeyId     string
+		AccessKeySecret string
+		SecurityToken   string
+		Expiration      time.Time
+	}{}
+	err = body.Unmarshal(&ret, "Credentials")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unmarshal Credentials")
+	}
+	return &SCredential{
+		AccessKeyId:     ret.AccessKeyId,
+		AccessKeySecret: ret.AccessKeySecret,
+		SecurityToken:   ret.SecurityToken,
+		Expiration:      ret.Expiration,
+	}, nil
+}
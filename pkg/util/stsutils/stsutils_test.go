@@ -0,0 +1,36 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stsutils
+
+import "testing"
+
+func TestNormalizeSessionName(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"admin", "admin"},
+		{"张三", "--"},
+		{"a", "a-"},
+		{"user name/with spaces", "user-name-with-spaces"},
+		{"abcdefghijklmnopqrstuvwxyz0123456789", "abcdefghijklmnopqrstuvwxyz012345"},
+	}
+	for _, c := range cases {
+		got := NormalizeSessionName(c.in)
+		if got != c.want {
+			t.Errorf("NormalizeSessionName(%q) want %q got %q", c.in, c.want, got)
+		}
+	}
+}